@@ -2,18 +2,32 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/rawblock/coinjoin-engine/internal/api"
 	"github.com/rawblock/coinjoin-engine/internal/bitcoin"
 	"github.com/rawblock/coinjoin-engine/internal/db"
 	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/internal/logging"
 	"github.com/rawblock/coinjoin-engine/internal/mempool"
+	"github.com/rawblock/coinjoin-engine/internal/publish"
 	"github.com/rawblock/coinjoin-engine/internal/scanner"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
 )
 
 func main() {
+	// Structured logging first, so every later record uses the chosen format
+	if err := logging.Setup(os.Getenv("LOG_LEVEL"), os.Getenv("LOG_FORMAT")); err != nil {
+		log.Printf("Warning: %v, using the default", err)
+	}
+
 	log.Println("Starting RawBlock Coinjoin Heuristics Engine (Microservice: btc-coinjoin-cuda-analytics)...")
 	log.Println("Initializing Anonymity Set Matchers and Bloom Filters...")
 
@@ -23,6 +37,10 @@ func main() {
 	// development: cp .env.example .env && edit .env
 	// ────────────────────────────────────────────────────────────────────
 
+	// Root context: cancelled on SIGINT/SIGTERM, stopping the poller and any scan
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	dbUrl := requireEnv("DATABASE_URL")
 
 	dbConn, err := db.Connect(dbUrl)
@@ -33,6 +51,14 @@ func main() {
 		if err := dbConn.InitSchema(); err != nil {
 			log.Printf("Warning: DB schema init failed: %v", err)
 		}
+		// Persist the full analysis JSON alongside the flags (larger rows)
+		if raw := os.Getenv("STORE_FULL_ANALYSIS"); raw != "" {
+			if enabled, err := strconv.ParseBool(raw); err == nil {
+				dbConn.SetStoreFullAnalysis(enabled)
+			} else {
+				log.Printf("Warning: invalid STORE_FULL_ANALYSIS %q, storing flags only", raw)
+			}
+		}
 	}
 
 	btcHost := getEnvOrDefault("BTC_RPC_HOST", "localhost:8332")
@@ -44,13 +70,119 @@ func main() {
 		User: btcUser,
 		Pass: btcPass,
 	}
+	if raw := os.Getenv("BTC_RPC_TLS"); raw != "" {
+		if enabled, err := strconv.ParseBool(raw); err == nil {
+			cfg.TLS = enabled
+		} else {
+			log.Printf("Warning: invalid BTC_RPC_TLS %q, connecting over plain HTTP", raw)
+		}
+	}
 	btcClient, err := bitcoin.NewClient(cfg)
 	if err != nil {
-		log.Printf("Warning: Failed to connect to Bitcoin RPC: %v", err)
+		logging.Component("rpc").Error("failed to connect to Bitcoin RPC", "host", btcHost, "err", err)
 	} else {
 		defer btcClient.Shutdown()
 	}
 
+	// Reported float precision (decimal places in API JSON)
+	if raw := os.Getenv("FLOAT_PRECISION"); raw != "" {
+		if decimals, err := strconv.Atoi(raw); err == nil {
+			models.SetFloatPrecision(decimals)
+		} else {
+			log.Printf("Warning: invalid FLOAT_PRECISION %q, using default %d", raw, models.DefaultFloatPrecision)
+		}
+	}
+
+	// Watchlist/taint address matching: fold bech32 case (BIP173) unless disabled
+	if raw := os.Getenv("WATCHLIST_BECH32_CASE_FOLD"); raw != "" {
+		if enabled, err := strconv.ParseBool(raw); err == nil {
+			heuristics.SetBech32CaseFolding(enabled)
+		} else {
+			log.Printf("Warning: invalid WATCHLIST_BECH32_CASE_FOLD %q, keeping case folding enabled", raw)
+		}
+	}
+	if raw := os.Getenv("WATCHLIST_XPUB_GAP_LIMIT"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			heuristics.SetXpubGapLimit(n)
+		} else {
+			log.Printf("Warning: invalid WATCHLIST_XPUB_GAP_LIMIT %q, using %d", raw, heuristics.XpubGapLimit())
+		}
+	}
+
+	// CoinJoins with more estimated participants than this raise a large_coinjoin alert
+	if raw := os.Getenv("LARGE_COINJOIN_PARTICIPANTS"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n >= 2 {
+			heuristics.SetLargeCoinJoinThreshold(n)
+		} else {
+			log.Printf("Warning: invalid LARGE_COINJOIN_PARTICIPANTS %q, using %d", raw, heuristics.LargeCoinJoinThreshold())
+		}
+	}
+
+	// CIOH edge emission (minimum confidence, consolidation hyper-edge size)
+	cioh := heuristics.DefaultCIOHPolicy()
+	if raw := os.Getenv("CIOH_MIN_CONFIDENCE"); raw != "" {
+		if v, err := strconv.ParseFloat(raw, 64); err == nil && v >= 0 && v <= 1 {
+			cioh.MinConfidence = v
+		} else {
+			log.Printf("Warning: invalid CIOH_MIN_CONFIDENCE %q, using %.2f", raw, cioh.MinConfidence)
+		}
+	}
+	if raw := os.Getenv("CIOH_HYPEREDGE_INPUTS"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n >= 3 {
+			cioh.HyperEdgeInputs = n
+		} else {
+			log.Printf("Warning: invalid CIOH_HYPEREDGE_INPUTS %q, using %d", raw, cioh.HyperEdgeInputs)
+		}
+	}
+	heuristics.SetCIOHPolicy(cioh)
+
+	// Analysis endpoint transaction size caps (413 above these)
+	maxIn, maxOut := api.TxSizeLimits()
+	if raw := os.Getenv("API_MAX_TX_INPUTS"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			maxIn = n
+		} else {
+			log.Printf("Warning: invalid API_MAX_TX_INPUTS %q, using %d", raw, maxIn)
+		}
+	}
+	if raw := os.Getenv("API_MAX_TX_OUTPUTS"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			maxOut = n
+		} else {
+			log.Printf("Warning: invalid API_MAX_TX_OUTPUTS %q, using %d", raw, maxOut)
+		}
+	}
+	api.SetTxSizeLimits(maxIn, maxOut)
+
+	// Per-IP rate limits of protected endpoints (RATE_LIMIT_* env vars)
+	api.SetRateLimitConfig(api.RateLimitConfigFromEnv())
+
+	// Anonymity-set solver tolerances (SSMP_* env vars; defaults preserve historical behavior)
+	heuristics.SetSSMPConfig(heuristics.SSMPConfigFromEnv())
+
+	// CoinJoin gate of AnalyzeTx Step 2 (COINJOIN_DETECTION_POLICY: strict/balanced/sensitive)
+	heuristics.SetDetectionPolicy(heuristics.DetectionPolicyFromEnv())
+
+	// Change-detection vote weights (CHANGE_* env vars; defaults preserve historical behavior)
+	heuristics.SetChangeDetectionConfig(heuristics.ChangeDetectionConfigFromEnv())
+
+	// Per-step AnalyzeTx timing (stepTimings on results, slow analyses logged)
+	if raw := os.Getenv("PIPELINE_PROFILE"); raw != "" {
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			log.Printf("Warning: invalid PIPELINE_PROFILE %q, profiling disabled", raw)
+		}
+		var slow time.Duration
+		if rawSlow := os.Getenv("PIPELINE_PROFILE_SLOW_MS"); rawSlow != "" {
+			if ms, err := strconv.Atoi(rawSlow); err == nil && ms >= 0 {
+				slow = time.Duration(ms) * time.Millisecond
+			} else {
+				log.Printf("Warning: invalid PIPELINE_PROFILE_SLOW_MS %q, slow analyses will not be logged", rawSlow)
+			}
+		}
+		heuristics.SetPipelineProfiling(enabled, slow)
+	}
+
 	// Setup WebSocket Hub
 	wsHub := api.NewHub()
 	go wsHub.Run()
@@ -58,6 +190,42 @@ func main() {
 	// Sprint 1: Initialize global taint map for risk detection
 	heuristics.InitGlobalTaintMap()
 	watchlist := heuristics.GetGlobalAddressWatchlist()
+
+	// Sanctioned-address list (e.g. OFAC SDN) reloaded on every boot
+	if path := os.Getenv("SANCTIONED_ADDRESS_FILE"); path != "" {
+		if f, err := os.Open(path); err != nil {
+			log.Printf("Warning: failed to open SANCTIONED_ADDRESS_FILE %q: %v", path, err)
+		} else {
+			parsed, err := heuristics.ParseAddressList(f)
+			f.Close()
+			if err != nil {
+				log.Printf("Warning: failed to parse SANCTIONED_ADDRESS_FILE %q: %v", path, err)
+			} else {
+				res := heuristics.ImportSanctionedAddresses(watchlist, parsed.Addresses, "OFAC SDN")
+				log.Printf("Loaded %d sanctioned addresses from %s (%d new)", res.Parsed, path, res.Added)
+			}
+		}
+	}
+
+	// Known Lightning channel points (gossip data) for deterministic close detection
+	if path := os.Getenv("LN_CHANNEL_POINTS_FILE"); path != "" {
+		if n, err := heuristics.GetGlobalChannelPointIndex().LoadFile(path); err != nil {
+			log.Printf("Warning: failed to load LN_CHANNEL_POINTS_FILE %q: %v", path, err)
+		} else {
+			log.Printf("Loaded %d known Lightning channel points from %s", n, path)
+		}
+	}
+
+	// Mining pool coinbase tags and payout addresses (known-pools JSON);
+	// the built-in markers stay in use when unset or unreadable
+	if path := os.Getenv("POOL_TAGS_FILE"); path != "" {
+		if stats, err := heuristics.GetGlobalPoolTagDB().LoadFile(path); err != nil {
+			log.Printf("Warning: failed to load POOL_TAGS_FILE %q: %v, using built-in pool tags", path, err)
+		} else {
+			log.Printf("Loaded %d pool tags and %d payout addresses from %s", stats.Tags, stats.Addresses, path)
+		}
+	}
+
 	if dbConn != nil {
 		seeds, err := dbConn.LoadActiveInvestigationSeeds(context.Background())
 		if err != nil {
@@ -80,35 +248,208 @@ func main() {
 			heuristics.SeedFromExternalIntel(sources)
 			log.Printf("Warm-loaded %d investigation seeds into watchlist/taint map", len(seeds))
 		}
+
+		// Known-entity labels (exchange exits, analysis enrichment)
+		if labels, err := dbConn.LoadAddressLabels(context.Background()); err != nil {
+			log.Printf("Warning: failed to warm-load address labels: %v", err)
+		} else if n := heuristics.GetGlobalAddressLabels().Load(labels); n > 0 {
+			log.Printf("Warm-loaded %d address labels", n)
+		}
+
+		// Taint propagated by earlier scans (seeds alone would lose every downstream hop)
+		if scores, err := dbConn.LoadTaintScores(context.Background()); err != nil {
+			log.Printf("Warning: failed to warm-load taint scores: %v", err)
+		} else {
+			restored := heuristics.RestoreTaintScores(scores)
+			log.Printf("Warm-loaded %d persisted taint scores (%d tracked addresses)", restored, heuristics.GetGlobalTaintMapSize())
+		}
+		heuristics.EnableTaintChangeTracking()
+
+		// Retroactive anonset windows (1d/7d/30d/365d) over scanned CoinJoin outputs
+		anonSetInterval := heuristics.DefaultAnonSetWindowInterval
+		if raw := os.Getenv("ANONSET_WINDOW_INTERVAL"); raw != "" {
+			if d, err := time.ParseDuration(raw); err == nil && d >= 0 {
+				anonSetInterval = d
+			} else {
+				log.Printf("Warning: invalid ANONSET_WINDOW_INTERVAL %q, using %s", raw, anonSetInterval)
+			}
+		}
+		if anonSetInterval > 0 {
+			go heuristics.NewAnonSetWindowJob(dbConn, anonSetInterval).Run(ctx)
+		}
 	}
 
 	// Setup and start the Mempool Poller + Block Scanner
 	// GUARD: Only start if btcClient is non-nil to avoid runtime panic
 	var blockScanner *scanner.BlockScanner
+	var mempoolStats api.MempoolStatsProvider
+	var pollerDone chan struct{} // Closed when the poller has stopped
+	var alertMgr *heuristics.AlertManager
 	if btcClient != nil {
+		// Optional result bus (RESULT_BUS=nats|kafka); disabled by default
+		resultBus, err := publish.New(publish.ConfigFromEnv())
+		if err != nil {
+			log.Printf("Warning: result bus disabled: %v", err)
+		} else if resultBus != nil {
+			defer func() {
+				ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+				defer cancel()
+				_ = resultBus.Close(ctx)
+			}()
+			log.Printf("Streaming analysis results to %s", os.Getenv("RESULT_BUS"))
+		}
+
 		poller := mempool.NewPoller(btcClient, wsHub, dbConn)
-		ctx, cancel := context.WithCancel(context.Background())
-		defer cancel()
-		go poller.Run(ctx)
+		poller.Publisher = resultBus
+		if raw := os.Getenv("MEMPOOL_STATE_INTERVAL"); raw != "" {
+			if interval, err := time.ParseDuration(raw); err == nil && interval >= 0 {
+				poller.StateInterval = interval
+			} else {
+				log.Printf("Warning: invalid MEMPOOL_STATE_INTERVAL %q, using %s", raw, mempool.DefaultStateInterval)
+			}
+		}
+		if raw := os.Getenv("POLLER_INTERVAL_MS"); raw != "" {
+			if interval, err := mempool.ParsePollInterval(raw); err == nil {
+				poller.PollInterval = interval
+			} else {
+				log.Printf("Warning: invalid POLLER_INTERVAL_MS %q, using %d: %v", raw, mempool.DefaultPollInterval.Milliseconds(), err)
+			}
+		}
+		if raw := os.Getenv("POLLER_BATCH_SIZE"); raw != "" {
+			if n, err := mempool.ParsePollBatchSize(raw); err == nil {
+				poller.BatchSize = n
+			} else {
+				log.Printf("Warning: invalid POLLER_BATCH_SIZE %q, using %d: %v", raw, mempool.DefaultPollBatchSize, err)
+			}
+		}
+		mempoolStats, alertMgr = poller, poller.AlertMgr
+		if raw := os.Getenv("ALERT_DEDUP_WINDOW"); raw != "" {
+			if window, err := time.ParseDuration(raw); err == nil && window >= 0 {
+				alertMgr.SetDedupWindow(window)
+			} else {
+				log.Printf("Warning: invalid ALERT_DEDUP_WINDOW %q, using %s", raw, heuristics.DefaultAlertDedupWindow)
+			}
+		}
+		// A case's own fund movements: one reduced-severity alert per grace window
+		intraCase := heuristics.DefaultIntraCasePolicy()
+		if raw := os.Getenv("ALERT_INTRA_CASE_SEVERITY"); raw == "off" {
+			intraCase.Enabled = false
+		} else if raw != "" {
+			if heuristics.IsValidSeverity(raw) {
+				intraCase.Severity = raw
+			} else {
+				log.Printf("Warning: invalid ALERT_INTRA_CASE_SEVERITY %q, using %s", raw, intraCase.Severity)
+			}
+		}
+		if raw := os.Getenv("ALERT_INTRA_CASE_GRACE_WINDOW"); raw != "" {
+			if window, err := time.ParseDuration(raw); err == nil && window >= 0 {
+				intraCase.GraceWindow = window
+			} else {
+				log.Printf("Warning: invalid ALERT_INTRA_CASE_GRACE_WINDOW %q, using %s", raw, intraCase.GraceWindow)
+			}
+		}
+		alertMgr.SetIntraCasePolicy(intraCase)
+		pollerDone = make(chan struct{})
+		go func() {
+			defer close(pollerDone)
+			poller.Run(ctx)
+		}()
 
 		// Create the Historical Block Scanner with real-time WebSocket alert broadcasting
 		blockScanner = scanner.NewBlockScanner(btcClient, dbConn, api.BroadcastCoinJoinAlert(wsHub))
+		blockScanner.SetAlertManager(alertMgr)
+		blockScanner.SetPublisher(resultBus)
+		if raw := os.Getenv("SCAN_CONCURRENCY"); raw != "" {
+			if n, err := strconv.Atoi(raw); err == nil && n >= 1 {
+				blockScanner.SetConcurrency(n)
+			} else {
+				log.Printf("Warning: invalid SCAN_CONCURRENCY %q, using %d", raw, blockScanner.Concurrency())
+			}
+		}
+
+		// Change-edge merges: LLR threshold and hard (merge) vs soft (link only) mode
+		changePolicy := blockScanner.Clusters().ChangeMergePolicy()
+		if raw := os.Getenv("CHANGE_MERGE_MIN_LLR"); raw != "" {
+			if llr, err := strconv.ParseFloat(raw, 64); err == nil && llr >= 0 {
+				changePolicy.MinLLR = llr
+			} else {
+				log.Printf("Warning: invalid CHANGE_MERGE_MIN_LLR %q, using %.1f", raw, changePolicy.MinLLR)
+			}
+		}
+		switch raw := os.Getenv("CHANGE_MERGE_MODE"); raw {
+		case "", "hard":
+		case "soft":
+			changePolicy.Soft = true
+		default:
+			log.Printf("Warning: invalid CHANGE_MERGE_MODE %q, using hard", raw)
+		}
+		blockScanner.Clusters().SetChangeMergePolicy(changePolicy)
+		// Clustered addresses count as known entities for the fresh-address change vote
+		heuristics.SetKnownAddressSource(blockScanner.Clusters().Contains)
+		if dbConn != nil {
+			if err := blockScanner.LoadClusters(ctx); err != nil {
+				log.Printf("Warning: failed to warm-load address clusters: %v", err)
+			}
+		}
 	} else {
 		log.Println("WARNING: Bitcoin RPC unavailable — engine running in API-only mode (no poller/scanner)")
 	}
 
 	// Setup the Gin Router
-	r := api.SetupRouter(dbConn, btcClient, wsHub, blockScanner)
+	r := api.SetupRouter(ctx, dbConn, btcClient, wsHub, blockScanner, mempoolStats, alertMgr)
 
 	port := getEnvOrDefault("PORT", "5339")
+	srv := &http.Server{Addr: ":" + port, Handler: r}
 
 	// Start the server
 	log.Printf("Engine running on :%s (API Node: btc-coinjoin-cuda-analytics)\n", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	stop() // A second signal kills the process immediately
+
+	shutdownTimeout := defaultShutdownTimeout
+	if raw := os.Getenv("SHUTDOWN_TIMEOUT"); raw != "" {
+		if timeout, err := time.ParseDuration(raw); err == nil && timeout > 0 {
+			shutdownTimeout = timeout
+		} else {
+			log.Printf("Warning: invalid SHUTDOWN_TIMEOUT %q, using %s", raw, defaultShutdownTimeout)
+		}
 	}
+	log.Printf("Shutting down (draining for up to %s)...", shutdownTimeout)
+	drainCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	// Stop accepting requests and finish in-flight ones, then drop stream clients
+	if err := srv.Shutdown(drainCtx); err != nil {
+		log.Printf("Warning: HTTP server shutdown: %v", err)
+	}
+	wsHub.Close()
+
+	// The poller and an active scan observe ctx; wait for them (and the scan's final flush)
+	if pollerDone != nil {
+		select {
+		case <-pollerDone:
+		case <-drainCtx.Done():
+			log.Printf("Warning: mempool poller did not stop within %s", shutdownTimeout)
+		}
+	}
+	if blockScanner != nil {
+		if err := blockScanner.Wait(drainCtx); err != nil {
+			log.Printf("Warning: block scan did not stop within %s", shutdownTimeout)
+		}
+	}
+	// Deferred cleanup closes the result bus, the RPC client and the DB pool
+	log.Println("Shutdown complete")
 }
 
+// defaultShutdownTimeout bounds how long shutdown waits for in-flight work
+const defaultShutdownTimeout = 30 * time.Second
+
 // requireEnv reads a required environment variable and exits if it is not set.
 // This prevents the binary from starting with missing critical configuration.
 func requireEnv(key string) string {
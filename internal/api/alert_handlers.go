@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+)
+
+// ════════════════════════════════════════════════════════════════════
+// Alert History API
+// ════════════════════════════════════════════════════════════════════
+
+const (
+	defaultAlertLimit = 100
+	maxAlertLimit     = 1000 // AlertManager history size
+)
+
+// GET /api/v1/alerts?limit=100&minSeverity=high
+// Returns the poller's recent alerts, most recent first, so a dashboard
+// can reload alert history after reconnecting the WebSocket stream.
+func (h *APIHandler) handleGetAlerts(c *gin.Context) {
+	if h.alertMgr == nil {
+		writeError(c, errUnavailable("Alert manager not running"))
+		return
+	}
+
+	limit := defaultAlertLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxAlertLimit {
+			writeError(c, errBadRequest("limit must be between 1 and 1000"))
+			return
+		}
+		limit = n
+	}
+
+	minSeverity := c.DefaultQuery("minSeverity", "info")
+	if !heuristics.IsValidSeverity(minSeverity) {
+		writeError(c, errBadRequest("minSeverity must be one of info, low, medium, high, critical"))
+		return
+	}
+
+	alerts := h.alertMgr.GetRecentAlertsAtLeast(limit, minSeverity)
+	c.JSON(http.StatusOK, gin.H{
+		"data":        alerts,
+		"count":       len(alerts),
+		"limit":       limit,
+		"minSeverity": minSeverity,
+	})
+}
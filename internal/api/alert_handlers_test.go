@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+)
+
+func getAlerts(t *testing.T, h *APIHandler, query string) (*httptest.ResponseRecorder, []heuristics.Alert) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/alerts?"+query, nil)
+	h.handleGetAlerts(c)
+
+	var body struct {
+		Data []heuristics.Alert `json:"data"`
+	}
+	if w.Code == http.StatusOK {
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+			t.Fatalf("Invalid response body: %v", err)
+		}
+	}
+	return w, body.Data
+}
+
+func TestHandleGetAlerts_FiltersBySeverity(t *testing.T) {
+	alerts := heuristics.NewAlertManager(nil)
+	alerts.EmitAlert(heuristics.Alert{Severity: "high", AlertType: "watchlist_hit", TxID: "tx1"})
+	alerts.EmitAlert(heuristics.Alert{Severity: "medium", AlertType: "high_risk", TxID: "tx2"})
+	alerts.EmitAlert(heuristics.Alert{Severity: "critical", AlertType: "compound", TxID: "tx3"})
+	alerts.EmitAlert(heuristics.Alert{Severity: "low", AlertType: "high_risk", TxID: "tx4"})
+	h := &APIHandler{alertMgr: alerts}
+
+	w, got := getAlerts(t, h, "minSeverity=high")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200. Got %d: %s", w.Code, w.Body.String())
+	}
+	if len(got) != 2 || got[0].TxID != "tx3" || got[1].TxID != "tx1" {
+		t.Errorf("Expected the high+ alerts, most recent first. Got %+v", got)
+	}
+
+	if _, got := getAlerts(t, h, "limit=3"); len(got) != 3 || got[0].TxID != "tx4" {
+		t.Errorf("Expected the 3 most recent alerts. Got %+v", got)
+	}
+
+	for _, query := range []string{"minSeverity=urgent", "limit=0", "limit=abc"} {
+		if w, _ := getAlerts(t, h, query); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %q. Got %d", query, w.Code)
+		}
+	}
+
+	if w, _ := getAlerts(t, &APIHandler{}, ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without an alert manager. Got %d", w.Code)
+	}
+}
@@ -0,0 +1,36 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/gin-gonic/gin"
+)
+
+// GET /api/v1/analysis/:txid
+// Returns the stored analysis of a transaction. Only CoinJoins and
+// transactions submitted to /analyze are stored; the full
+// PrivacyAnalysisResult is included when STORE_FULL_ANALYSIS was enabled
+// at the time, otherwise just the flags and anonset.
+func (h *APIHandler) handleGetAnalysis(c *gin.Context) {
+	hash, err := chainhash.NewHashFromStr(c.Param("txid"))
+	if err != nil {
+		writeError(c, errInvalidTxid("Invalid txid"))
+		return
+	}
+	if h.dbStore == nil {
+		writeError(c, errUnavailable("Database not connected"))
+		return
+	}
+
+	analysis, err := h.dbStore.GetAnalysis(c.Request.Context(), hash.String())
+	if err != nil {
+		writeError(c, errInternal("Failed to fetch analysis", err))
+		return
+	}
+	if analysis == nil {
+		writeError(c, errNotFound("No stored analysis for transaction").With("txid", hash.String()))
+		return
+	}
+	c.JSON(http.StatusOK, analysis)
+}
@@ -0,0 +1,23 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetAnalysis_ValidatesTxidBeforeDatabase(t *testing.T) {
+	h := &APIHandler{}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/analysis/:txid", h.handleGetAnalysis)
+
+	if w := serve(r, http.MethodGet, "/analysis/not-a-txid", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid txid. Got %d", w.Code)
+	}
+	if w := serve(r, http.MethodGet, "/analysis/"+strings.Repeat("ab", 32), ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a database. Got %d", w.Code)
+	}
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ──────────────────────────────────────────────────────────────────
+// Analyze Single-Flight
+//
+// Dashboards open the same transaction from several tabs and clients at
+// once. Without coalescing each request repeats the per-input prevout RPC
+// lookups, the full pipeline and both DB writes. Concurrent requests for
+// one txid share a single computation and receive its response; a request
+// arriving after it finishes starts a fresh one (no result caching).
+// ──────────────────────────────────────────────────────────────────
+
+// analyzeReply is one /analyze response, shared by coalesced requests
+type analyzeReply struct {
+	status int
+	body   gin.H
+	err    *APIError // Set instead of status/body for error responses
+}
+
+// write sends the reply to one of the coalesced requests
+func (r analyzeReply) write(c *gin.Context) {
+	if r.err != nil {
+		writeError(c, r.err)
+		return
+	}
+	c.JSON(r.status, r.body)
+}
+
+// analyzeCall is an in-flight computation
+type analyzeCall struct {
+	wg    sync.WaitGroup
+	reply analyzeReply
+	dups  int // Requests that joined instead of computing
+}
+
+// analyzeFlight coalesces concurrent computations by key. The zero value is ready to use.
+type analyzeFlight struct {
+	mu    sync.Mutex
+	calls map[string]*analyzeCall
+}
+
+// do runs fn once for all concurrent callers with the same key and
+// returns its reply to each of them, reporting whether it was shared.
+// If fn panics, the joined callers receive a 500.
+func (f *analyzeFlight) do(key string, fn func() analyzeReply) (reply analyzeReply, shared bool) {
+	f.mu.Lock()
+	if f.calls == nil {
+		f.calls = make(map[string]*analyzeCall)
+	}
+	if call, ok := f.calls[key]; ok {
+		call.dups++
+		f.mu.Unlock()
+		call.wg.Wait()
+		return call.reply, true
+	}
+	call := &analyzeCall{reply: analyzeReply{err: errInternal("Analysis failed", errors.New("analysis panicked"))}}
+	call.wg.Add(1)
+	f.calls[key] = call
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		delete(f.calls, key)
+		shared = call.dups > 0
+		f.mu.Unlock()
+		call.wg.Done()
+	}()
+	call.reply = fn()
+	return call.reply, false
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestAnalyzeFlight_CoalescesConcurrentRequests(t *testing.T) {
+	var f analyzeFlight
+	var computations atomic.Int32
+	release := make(chan struct{})
+
+	const callers = 10
+	var wg sync.WaitGroup
+	replies := make([]analyzeReply, callers)
+	var sharedCount atomic.Int32
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			reply, shared := f.do("txid", func() analyzeReply {
+				computations.Add(1)
+				<-release // Hold the flight open until every caller has joined
+				return analyzeReply{status: http.StatusOK, body: gin.H{"txid": "txid"}}
+			})
+			replies[i] = reply
+			if shared {
+				sharedCount.Add(1)
+			}
+		}(i)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		f.mu.Lock()
+		joined := 0
+		if call := f.calls["txid"]; call != nil {
+			joined = call.dups
+		}
+		f.mu.Unlock()
+		if joined == callers-1 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if n := computations.Load(); n != 1 {
+		t.Fatalf("Expected one computation for %d concurrent requests. Got %d", callers, n)
+	}
+	if sharedCount.Load() != callers {
+		t.Errorf("Expected every caller to report a shared reply. Got %d", sharedCount.Load())
+	}
+	for i, r := range replies {
+		if r.status != http.StatusOK || r.body["txid"] != "txid" {
+			t.Errorf("Caller %d got %+v", i, r)
+		}
+	}
+
+	// A finished flight is not a cache
+	f.do("txid", func() analyzeReply { computations.Add(1); return analyzeReply{status: http.StatusOK} })
+	if computations.Load() != 2 {
+		t.Errorf("Expected a later request to recompute. Got %d computations", computations.Load())
+	}
+}
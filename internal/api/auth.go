@@ -42,31 +42,25 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		auth := c.GetHeader("Authorization")
 		if auth == "" {
-			c.JSON(http.StatusUnauthorized, gin.H{
-				"error": "Missing Authorization header",
-				"hint":  "Use: Authorization: Bearer <API_AUTH_TOKEN>",
-			})
-			c.Abort()
+			writeError(c, newAPIError(http.StatusUnauthorized, ErrCodeUnauthorized, "Missing Authorization header").
+				With("hint", "Use: Authorization: Bearer <API_AUTH_TOKEN>"))
 			return
 		}
 
 		// Parse "Bearer <token>"
 		parts := strings.SplitN(auth, " ", 2)
 		if len(parts) != 2 || parts[0] != "Bearer" {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Invalid Authorization header format"})
-			c.Abort()
+			writeError(c, newAPIError(http.StatusForbidden, ErrCodeForbidden, "Invalid Authorization header format"))
 			return
 		}
 
 		// Use constant-time comparison to prevent timing-based token enumeration.
 		if subtle.ConstantTimeCompare([]byte(parts[1]), []byte(token)) != 1 {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Invalid or expired token",
-			})
-			c.Abort()
+			writeError(c, newAPIError(http.StatusForbidden, ErrCodeForbidden, "Invalid or expired token"))
 			return
 		}
 
+		c.Set(ctxAuthenticated, true)
 		c.Next()
 	}
 }
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ════════════════════════════════════════════════════════════════════
+// Block Summary API
+// ════════════════════════════════════════════════════════════════════
+
+// maxSummaryRange caps the blocks returned by one range query (~2 weeks)
+const maxSummaryRange = 2016
+
+// parseSummaryRange validates the from/to heights of a summary range query
+func parseSummaryRange(fromRaw, toRaw string) (int, int, error) {
+	from, err := strconv.Atoi(fromRaw)
+	if err != nil || from < 0 {
+		return 0, 0, fmt.Errorf("from must be a non-negative block height")
+	}
+	to, err := strconv.Atoi(toRaw)
+	if err != nil || to < from {
+		return 0, 0, fmt.Errorf("to must be a block height >= from")
+	}
+	if to-from+1 > maxSummaryRange {
+		return 0, 0, fmt.Errorf("range exceeds maximum of %d blocks", maxSummaryRange)
+	}
+	return from, to, nil
+}
+
+// GET /api/v1/blocks/:height/summary
+// Returns the rollup the block scanner wrote for a scanned block.
+func (h *APIHandler) handleGetBlockSummary(c *gin.Context) {
+	if h.dbStore == nil {
+		writeError(c, errUnavailable("Database not connected"))
+		return
+	}
+
+	height, err := strconv.Atoi(c.Param("height"))
+	if err != nil || height < 0 {
+		writeError(c, errBadRequest("height must be a non-negative integer"))
+		return
+	}
+
+	summary, err := h.dbStore.GetBlockSummary(c.Request.Context(), height)
+	if err != nil {
+		writeError(c, errInternal("Failed to fetch block summary", err))
+		return
+	}
+	if summary == nil {
+		writeError(c, errNotFound("Block has not been scanned").With("height", height))
+		return
+	}
+	c.JSON(http.StatusOK, summary)
+}
+
+// GET /api/v1/blocks/summaries?from=850000&to=850143
+// Returns the rollups of scanned blocks in the range for charting.
+func (h *APIHandler) handleGetBlockSummaries(c *gin.Context) {
+	if h.dbStore == nil {
+		writeError(c, errUnavailable("Database not connected"))
+		return
+	}
+
+	from, to, err := parseSummaryRange(c.Query("from"), c.Query("to"))
+	if err != nil {
+		writeError(c, errBadRequest(err.Error()))
+		return
+	}
+
+	summaries, err := h.dbStore.GetBlockSummaries(c.Request.Context(), from, to)
+	if err != nil {
+		writeError(c, errInternal("Failed to fetch block summaries", err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": summaries,
+		"from": from,
+		"to":   to,
+	})
+}
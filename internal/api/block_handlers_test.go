@@ -0,0 +1,22 @@
+package api
+
+import "testing"
+
+func TestParseSummaryRange(t *testing.T) {
+	from, to, err := parseSummaryRange("850000", "850143")
+	if err != nil || from != 850_000 || to != 850_143 {
+		t.Fatalf("Expected a valid range. Got %d-%d (%v)", from, to, err)
+	}
+
+	for _, tc := range []struct{ from, to string }{
+		{"", "10"},
+		{"-1", "10"},
+		{"10", "9"},
+		{"10", "abc"},
+		{"0", "2016"}, // 2017 blocks
+	} {
+		if _, _, err := parseSummaryRange(tc.from, tc.to); err == nil {
+			t.Errorf("Expected %q-%q to be rejected", tc.from, tc.to)
+		}
+	}
+}
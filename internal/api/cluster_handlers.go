@@ -0,0 +1,146 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/internal/metrics"
+)
+
+// ════════════════════════════════════════════════════════════════════
+// Entity Cluster API
+// ════════════════════════════════════════════════════════════════════
+
+// clusterMemberLimit caps members returned per lookup (large exchange
+// clusters hold millions of addresses)
+const clusterMemberLimit = 1000
+
+// maxGroundTruthLabels caps the addresses of an uploaded accuracy dataset
+const maxGroundTruthLabels = 500_000
+
+// ClusterLookup is the /cluster/:address response
+type ClusterLookup struct {
+	Address     string                  `json:"address"`
+	Known       bool                    `json:"known"` // Seen by the scanner's cluster engine
+	ClusterSize int                     `json:"clusterSize"`
+	Stats       heuristics.ClusterStats `json:"stats"`
+	Members     []string                `json:"members"`
+	Truncated   bool                    `json:"truncated"`           // Members capped at clusterMemberLimit
+	SoftLinks   []heuristics.SoftLink   `json:"softLinks,omitempty"` // Change links not merged (soft change-merge mode)
+}
+
+// lookupCluster reads addr's entity cluster without registering unseen addresses
+func lookupCluster(ce *heuristics.ClusterEngine, addr string, limit int) ClusterLookup {
+	if !ce.Contains(addr) {
+		return ClusterLookup{
+			Address:     addr,
+			ClusterSize: 1,
+			Stats:       heuristics.ClusterStats{RootAddress: addr, AddressCount: 1},
+			Members:     []string{addr},
+			SoftLinks:   ce.SoftLinks(addr),
+		}
+	}
+
+	members := ce.GetCluster(addr)
+	sort.Strings(members)
+	lookup := ClusterLookup{
+		Address:     addr,
+		Known:       true,
+		ClusterSize: ce.GetClusterSize(addr),
+		Stats:       ce.GetStats(addr),
+		Members:     members,
+		SoftLinks:   ce.SoftLinks(addr),
+	}
+	if limit > 0 && len(members) > limit {
+		lookup.Members = members[:limit]
+		lookup.Truncated = true
+	}
+	return lookup
+}
+
+// GET /api/v1/cluster/:address
+// Returns the entity cluster the block scanner has resolved for an address.
+func (h *APIHandler) handleGetCluster(c *gin.Context) {
+	if h.blockScanner == nil {
+		writeError(c, errUnavailable("Block scanner not initialized"))
+		return
+	}
+
+	addr := heuristics.NormalizeAddress(c.Param("address"))
+	if addr == "" {
+		writeError(c, errBadRequest("Address is required"))
+		return
+	}
+
+	c.JSON(http.StatusOK, lookupCluster(h.blockScanner.Clusters(), addr, clusterMemberLimit))
+}
+
+// GET /api/v1/clusters/health?threshold=N
+// Reports the cluster size distribution and flags implausibly large
+// entities (over-merge diagnostics for analysts auditing false positives).
+func (h *APIHandler) handleGetClusterHealth(c *gin.Context) {
+	if h.blockScanner == nil {
+		writeError(c, errUnavailable("Block scanner not initialized"))
+		return
+	}
+
+	threshold := 0
+	if raw := c.Query("threshold"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			writeError(c, errBadRequest("threshold must be a positive integer"))
+			return
+		}
+		threshold = n
+	}
+
+	c.JSON(http.StatusOK, h.blockScanner.Clusters().Health(threshold))
+}
+
+// engineLabels returns the engine's cluster root for every labeled address
+// the engine has seen; unseen addresses are left out (singletons) and are
+// not registered
+func engineLabels(ce *heuristics.ClusterEngine, groundTruth map[string]string) map[string]string {
+	predicted := make(map[string]string, len(groundTruth))
+	for addr := range groundTruth {
+		if ce.Contains(addr) {
+			predicted[addr] = ce.Find(addr)
+		}
+	}
+	return predicted
+}
+
+// POST /api/v1/clusters/accuracy {"labels": {"<address>": "<entity>", ...}}
+// Scores the engine's current partition against a ground-truth labeling
+// (Adjusted Rand Index and Variation of Information).
+func (h *APIHandler) handleClusterAccuracy(c *gin.Context) {
+	if h.blockScanner == nil {
+		writeError(c, errUnavailable("Block scanner not initialized"))
+		return
+	}
+
+	var req struct {
+		Labels map[string]string `json:"labels"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.Labels) < 2 {
+		writeError(c, errBadRequest("Expected {labels: {address: entity}} with at least two addresses"))
+		return
+	}
+	if len(req.Labels) > maxGroundTruthLabels {
+		writeError(c, newAPIError(http.StatusRequestEntityTooLarge, ErrCodePayloadTooLarge, "Too many labeled addresses").With("maxLabels", maxGroundTruthLabels))
+		return
+	}
+
+	truth := make(map[string]string, len(req.Labels))
+	for addr, entity := range req.Labels {
+		if addr = heuristics.NormalizeAddress(addr); addr != "" && entity != "" {
+			truth[addr] = entity
+		}
+	}
+
+	cmp := metrics.ComparePartitions(engineLabels(h.blockScanner.Clusters(), truth), truth)
+	c.JSON(http.StatusOK, cmp)
+}
@@ -0,0 +1,76 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/internal/metrics"
+	"github.com/rawblock/coinjoin-engine/internal/scanner"
+)
+
+func TestLookupCluster_ReturnsMembers(t *testing.T) {
+	ce := heuristics.NewClusterEngine()
+	ce.Union("bc1qa", "bc1qb")
+	ce.Union("bc1qb", "bc1qc")
+	ce.Union("bc1qx", "bc1qy")
+
+	got := lookupCluster(ce, "bc1qc", 0)
+	if !got.Known || got.ClusterSize != 3 || got.Stats.AddressCount != 3 {
+		t.Fatalf("Expected a known 3-address cluster. Got %+v", got)
+	}
+	if len(got.Members) != 3 || got.Members[0] != "bc1qa" || got.Members[2] != "bc1qc" {
+		t.Errorf("Expected sorted members [bc1qa bc1qb bc1qc]. Got %v", got.Members)
+	}
+
+	capped := lookupCluster(ce, "bc1qa", 2)
+	if !capped.Truncated || len(capped.Members) != 2 || capped.ClusterSize != 3 {
+		t.Errorf("Expected members truncated to 2 of 3. Got %+v", capped)
+	}
+}
+
+func TestLookupCluster_UnknownAddressNotRegistered(t *testing.T) {
+	ce := heuristics.NewClusterEngine()
+	ce.Union("bc1qa", "bc1qb")
+
+	got := lookupCluster(ce, "bc1qunseen", 0)
+	if got.Known || got.ClusterSize != 1 || len(got.Members) != 1 {
+		t.Errorf("Expected a singleton lookup for an unseen address. Got %+v", got)
+	}
+	if ce.TotalAddresses() != 2 {
+		t.Errorf("Lookup must not register unseen addresses. Got %d tracked", ce.TotalAddresses())
+	}
+}
+
+func TestClusterAccuracy_ScoresEnginePartition(t *testing.T) {
+	h := &APIHandler{blockScanner: scanner.NewBlockScanner(nil, nil, nil)}
+	ce := h.blockScanner.Clusters()
+	ce.Union("bc1qa", "bc1qb")
+	ce.Union("bc1qc", "bc1qd")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/clusters/accuracy", h.handleClusterAccuracy)
+
+	w := serve(r, http.MethodPost, "/clusters/accuracy",
+		`{"labels":{"bc1qa":"exchange","bc1qb":"exchange","bc1qc":"mixer","bc1qd":"mixer","bc1qe":"user"}}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200. Got %d: %s", w.Code, w.Body.String())
+	}
+	var cmp metrics.PartitionComparison
+	if err := json.Unmarshal(w.Body.Bytes(), &cmp); err != nil {
+		t.Fatal(err)
+	}
+	if cmp.Addresses != 5 || cmp.ARI < 0.999 || cmp.VI > 1e-9 {
+		t.Errorf("Expected a perfect score over 5 addresses. Got %+v", cmp)
+	}
+	if ce.Contains("bc1qe") {
+		t.Errorf("Scoring must not register unseen ground-truth addresses")
+	}
+
+	if w := serve(r, http.MethodPost, "/clusters/accuracy", `{"labels":{"bc1qa":"x"}}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a single label. Got %d", w.Code)
+	}
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/logging"
+)
+
+// ──────────────────────────────────────────────────────────────────
+// API Error Model
+//
+// Every error response uses one envelope:
+//
+//   {"error": {"code": "not_found", "message": "Investigation not found",
+//              "details": {...}},
+//    "requestId": "…"}
+//
+// Codes are stable and meant for clients to switch on; messages are for
+// humans and may change. Internal failures (database, node RPC) are logged
+// with their cause; the raw cause is echoed in details.cause only to
+// callers that presented a valid API token, so node and driver errors do
+// not leak to anonymous clients.
+// ──────────────────────────────────────────────────────────────────
+
+// Error codes
+const (
+	ErrCodeBadRequest      = "bad_request"
+	ErrCodeInvalidTxid     = "invalid_txid"
+	ErrCodeUnauthorized    = "unauthorized"
+	ErrCodeForbidden       = "forbidden"
+	ErrCodeNotFound        = "not_found"
+	ErrCodeConflict        = "conflict"
+	ErrCodePayloadTooLarge = "payload_too_large"
+	ErrCodeRateLimited     = "rate_limited"
+	ErrCodeInternal        = "internal_error"
+	ErrCodeUpstream        = "upstream_error"   // Bitcoin node RPC failed
+	ErrCodeUpstreamTimeout = "upstream_timeout" // Bitcoin node RPC timed out
+	ErrCodeUnavailable     = "service_unavailable"
+)
+
+// ctxAuthenticated marks requests whose bearer token AuthMiddleware verified
+const ctxAuthenticated = "authenticated"
+
+// APIError is an error response
+type APIError struct {
+	Status  int            `json:"-"`
+	Code    string         `json:"code"`
+	Message string         `json:"message"`
+	Details map[string]any `json:"details,omitempty"`
+	cause   error          // Logged; echoed only to authenticated callers
+}
+
+func (e *APIError) Error() string {
+	if e.cause != nil {
+		return e.Code + ": " + e.Message + ": " + e.cause.Error()
+	}
+	return e.Code + ": " + e.Message
+}
+
+// Unwrap returns the internal cause
+func (e *APIError) Unwrap() error { return e.cause }
+
+// With adds a detail field
+func (e *APIError) With(key string, value any) *APIError {
+	if e.Details == nil {
+		e.Details = make(map[string]any)
+	}
+	e.Details[key] = value
+	return e
+}
+
+func newAPIError(status int, code, message string) *APIError {
+	return &APIError{Status: status, Code: code, Message: message}
+}
+
+func errBadRequest(message string) *APIError {
+	return newAPIError(http.StatusBadRequest, ErrCodeBadRequest, message)
+}
+
+// errInvalidInput reports a client input error; err is the caller's own
+// mistake (malformed JSON, bad parameter) and is safe to echo
+func errInvalidInput(message string, err error) *APIError {
+	return errBadRequest(message + ": " + err.Error())
+}
+
+func errInvalidTxid(message string) *APIError {
+	return newAPIError(http.StatusBadRequest, ErrCodeInvalidTxid, message)
+}
+
+func errNotFound(message string) *APIError {
+	return newAPIError(http.StatusNotFound, ErrCodeNotFound, message)
+}
+
+func errUnavailable(message string) *APIError {
+	return newAPIError(http.StatusServiceUnavailable, ErrCodeUnavailable, message)
+}
+
+func errInternal(message string, cause error) *APIError {
+	e := newAPIError(http.StatusInternalServerError, ErrCodeInternal, message)
+	e.cause = cause
+	return e
+}
+
+// errUpstream classifies a Bitcoin node RPC failure: unknown
+// transactions/blocks are 404, timeouts 504, anything else 502
+func errUpstream(message string, cause error) *APIError {
+	var rpcErr *btcjson.RPCError
+	var netErr net.Error
+	var e *APIError
+	switch {
+	case errors.As(cause, &rpcErr) && rpcErr.Code == btcjson.ErrRPCNoTxInfo:
+		e = errNotFound(message)
+	case errors.Is(cause, context.DeadlineExceeded) || (errors.As(cause, &netErr) && netErr.Timeout()):
+		e = newAPIError(http.StatusGatewayTimeout, ErrCodeUpstreamTimeout, message)
+	default:
+		e = newAPIError(http.StatusBadGateway, ErrCodeUpstream, message)
+	}
+	e.cause = cause
+	return e
+}
+
+// toAPIError maps err to an APIError; errors that are not already one are internal
+func toAPIError(err error) *APIError {
+	var e *APIError
+	if errors.As(err, &e) {
+		return e
+	}
+	return errInternal("Internal error", err)
+}
+
+// errorBody renders the envelope, echoing the cause to authenticated callers
+func errorBody(c *gin.Context, e *APIError) gin.H {
+	if e.cause != nil && c.GetBool(ctxAuthenticated) {
+		withCause := *e
+		withCause.Details = make(map[string]any, len(e.Details)+1)
+		for k, v := range e.Details {
+			withCause.Details[k] = v
+		}
+		withCause.Details["cause"] = e.cause.Error()
+		e = &withCause
+	}
+	body := gin.H{"error": e}
+	if id := c.GetString(ctxRequestID); id != "" {
+		body["requestId"] = id
+	}
+	return body
+}
+
+// writeError aborts the request with err's status and envelope
+func writeError(c *gin.Context, err error) {
+	e := toAPIError(err)
+	if e.cause != nil {
+		logging.FromContext(c.Request.Context(), "api").Error(e.Message,
+			"method", c.Request.Method, "path", c.FullPath(), "code", e.Code, "status", e.Status, "err", e.cause)
+	}
+	c.AbortWithStatusJSON(e.Status, errorBody(c, e))
+}
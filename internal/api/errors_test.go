@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/gin-gonic/gin"
+)
+
+type errorEnvelope struct {
+	Error struct {
+		Code    string         `json:"code"`
+		Message string         `json:"message"`
+		Details map[string]any `json:"details"`
+	} `json:"error"`
+}
+
+func decodeEnvelope(t *testing.T, w *httptest.ResponseRecorder) errorEnvelope {
+	t.Helper()
+	var env errorEnvelope
+	if err := json.Unmarshal(w.Body.Bytes(), &env); err != nil || env.Error.Code == "" {
+		t.Fatalf("Expected an error envelope. Got %d: %s", w.Code, w.Body)
+	}
+	return env
+}
+
+func TestErrUpstream_MapsNodeFailures(t *testing.T) {
+	cases := []struct {
+		cause  error
+		status int
+		code   string
+	}{
+		{&btcjson.RPCError{Code: btcjson.ErrRPCNoTxInfo, Message: "No such mempool or blockchain transaction"}, http.StatusNotFound, ErrCodeNotFound},
+		{fmt.Errorf("getrawtransaction: %w", context.DeadlineExceeded), http.StatusGatewayTimeout, ErrCodeUpstreamTimeout},
+		{errors.New("dial tcp 10.0.0.5:8332: connection refused"), http.StatusBadGateway, ErrCodeUpstream},
+	}
+	for _, tc := range cases {
+		e := errUpstream("Failed to fetch tx from node", tc.cause)
+		if e.Status != tc.status || e.Code != tc.code {
+			t.Errorf("%v: got %d %s, want %d %s", tc.cause, e.Status, e.Code, tc.status, tc.code)
+		}
+	}
+	if e := toAPIError(errors.New("boom")); e.Status != http.StatusInternalServerError || e.Code != ErrCodeInternal {
+		t.Errorf("Expected plain errors to map to internal_error. Got %d %s", e.Status, e.Code)
+	}
+}
+
+func TestWriteError_HidesCauseFromUnauthenticatedCallers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cause := errors.New("dial tcp 10.0.0.5:8332: connection refused")
+	handler := func(c *gin.Context) {
+		writeError(c, errUpstream("Failed to fetch tx from node", cause).With("txid", "abc"))
+	}
+
+	r := gin.New()
+	r.GET("/anon", handler)
+	r.GET("/authed", func(c *gin.Context) { c.Set(ctxAuthenticated, true) }, handler)
+
+	w := serve(r, http.MethodGet, "/anon", "")
+	env := decodeEnvelope(t, w)
+	if w.Code != http.StatusBadGateway || env.Error.Code != ErrCodeUpstream || env.Error.Details["txid"] != "abc" {
+		t.Errorf("Unexpected response %d: %s", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), "10.0.0.5") {
+		t.Errorf("Node error leaked to an unauthenticated caller: %s", w.Body)
+	}
+
+	w = serve(r, http.MethodGet, "/authed", "")
+	if env := decodeEnvelope(t, w); env.Error.Details["cause"] != cause.Error() {
+		t.Errorf("Expected the cause for an authenticated caller. Got %s", w.Body)
+	}
+}
+
+func TestHandlers_UseErrorEnvelope(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &APIHandler{}
+	r := gin.New()
+	r.GET("/analyze/:txid", h.handleAnalyzeTx)
+	r.GET("/analysis/:txid", h.handleGetAnalysis)
+	r.GET("/peel-chains", h.handleListPeelChains)
+
+	cases := []struct {
+		path   string
+		status int
+		code   string
+	}{
+		{"/analyze/whirlpool", http.StatusForbidden, ErrCodeForbidden},
+		{"/analyze/not-a-txid", http.StatusServiceUnavailable, ErrCodeUnavailable},
+		{"/analysis/not-a-txid", http.StatusBadRequest, ErrCodeInvalidTxid},
+		{"/peel-chains", http.StatusServiceUnavailable, ErrCodeUnavailable},
+	}
+	for _, tc := range cases {
+		w := serve(r, http.MethodGet, tc.path, "")
+		if env := decodeEnvelope(t, w); w.Code != tc.status || env.Error.Code != tc.code || env.Error.Message == "" {
+			t.Errorf("%s: got %d %+v, want %d %s", tc.path, w.Code, env.Error, tc.status, tc.code)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+)
+
+// ════════════════════════════════════════════════════════════════════
+// Investigation Evidence Export
+// ════════════════════════════════════════════════════════════════════
+
+// EvidenceManifest is manifest.json of the CSV export: the case summary
+// plus a SHA-256 of every file so the package can be verified later.
+type EvidenceManifest struct {
+	SnapshotID     int                     `json:"snapshotId"`
+	GeneratedAt    time.Time               `json:"generatedAt"`
+	CaseID         string                  `json:"caseId"`
+	Name           string                  `json:"name"`
+	Description    string                  `json:"description"`
+	Status         string                  `json:"status"`
+	TheftAddresses []string                `json:"theftAddresses"`
+	TraceConfig    heuristics.TraceConfig  `json:"traceConfig"`
+	Trace          *heuristics.CaseTrace   `json:"trace"`
+	Recovery       heuristics.CaseRecovery `json:"recovery"`
+	Files          map[string]string       `json:"files"` // File name → SHA-256 (hex)
+}
+
+// GET /api/v1/investigation/:id/export?format=json|csv
+// Downloads the complete case file: a single JSON document, or a zip of
+// CSV files (nodes, edges, timeline, tagged addresses, exchange exits)
+// with a manifest.json. Both carry the engine snapshot ID.
+func (h *APIHandler) handleExportInvestigation(c *gin.Context) {
+	caseID := c.Param("id")
+
+	inv := h.invManager.GetInvestigation(caseID)
+	if inv == nil {
+		writeError(c, errNotFound("Investigation not found"))
+		return
+	}
+
+	caseFile := inv.CaseFile()
+	switch format := c.DefaultQuery("format", "json"); format {
+	case "json":
+		body, err := json.MarshalIndent(caseFile, "", "  ")
+		if err != nil {
+			writeError(c, errInternal("Failed to encode case file", err))
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-evidence.json"`, caseID))
+		c.Data(http.StatusOK, "application/json", body)
+	case "csv":
+		var buf bytes.Buffer
+		if err := writeEvidenceZip(&buf, caseFile); err != nil {
+			writeError(c, errInternal("Failed to build evidence archive", err))
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-evidence.zip"`, caseID))
+		c.Data(http.StatusOK, "application/zip", buf.Bytes())
+	default:
+		writeError(c, errBadRequest("Unsupported format "+strconv.Quote(format)+" (want json or csv)"))
+	}
+}
+
+// writeEvidenceZip writes the case file as CSV sections plus manifest.json
+func writeEvidenceZip(w io.Writer, cf heuristics.CaseFile) error {
+	files := []struct {
+		name string
+		rows [][]string
+	}{
+		{"nodes.csv", nodeRows(cf.Nodes)},
+		{"edges.csv", edgeRows(cf.Edges)},
+		{"timeline.csv", timelineRows(cf.Timeline)},
+		{"tagged_addresses.csv", taggedRows(cf.TaggedAddresses)},
+		{"exchange_exits.csv", exitRows(cf.ExchangeExits)},
+	}
+
+	manifest := EvidenceManifest{
+		SnapshotID:     cf.SnapshotID,
+		GeneratedAt:    cf.GeneratedAt,
+		CaseID:         cf.CaseID,
+		Name:           cf.Name,
+		Description:    cf.Description,
+		Status:         cf.Status,
+		TheftAddresses: cf.TheftAddresses,
+		TraceConfig:    cf.TraceConfig,
+		Trace:          cf.Trace,
+		Recovery:       cf.Recovery,
+		Files:          make(map[string]string, len(files)),
+	}
+
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		var buf bytes.Buffer
+		cw := csv.NewWriter(&buf)
+		if err := cw.WriteAll(f.rows); err != nil {
+			return fmt.Errorf("encode %s: %w", f.name, err)
+		}
+		sum := sha256.Sum256(buf.Bytes())
+		manifest.Files[f.name] = hex.EncodeToString(sum[:])
+		if err := writeZipFile(zw, f.name, cf.GeneratedAt, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+
+	body, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+	if err := writeZipFile(zw, "manifest.json", cf.GeneratedAt, body); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func writeZipFile(zw *zip.Writer, name string, modified time.Time, body []byte) error {
+	fw, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: modified})
+	if err != nil {
+		return fmt.Errorf("create %s: %w", name, err)
+	}
+	if _, err := fw.Write(body); err != nil {
+		return fmt.Errorf("write %s: %w", name, err)
+	}
+	return nil
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func nodeRows(nodes []heuristics.FlowNode) [][]string {
+	rows := [][]string{{"address", "hop", "value_received_sats", "value_sent_sats", "role", "label", "risk_score", "flagged"}}
+	for _, n := range nodes {
+		rows = append(rows, []string{
+			n.Address, strconv.Itoa(n.HopNumber), strconv.FormatInt(n.ValueReceived, 10), strconv.FormatInt(n.ValueSent, 10),
+			n.Role, n.Label, strconv.FormatFloat(n.RiskScore, 'f', 4, 64), strconv.FormatBool(n.IsFlagged),
+		})
+	}
+	return rows
+}
+
+func edgeRows(edges []heuristics.FlowEdge) [][]string {
+	rows := [][]string{{"from_address", "to_address", "txid", "value_sats", "hop", "is_coinjoin", "confidence", "penetration_method", "timestamp"}}
+	for _, e := range edges {
+		rows = append(rows, []string{
+			e.FromAddress, e.ToAddress, e.Txid, strconv.FormatInt(e.Value, 10), strconv.Itoa(e.HopNumber),
+			strconv.FormatBool(e.IsCoinJoin), strconv.FormatFloat(e.Confidence, 'f', 4, 64), e.PenetrationMethod, formatTime(e.Timestamp),
+		})
+	}
+	return rows
+}
+
+func timelineRows(events []heuristics.TimelineEvent) [][]string {
+	rows := [][]string{{"timestamp", "event_type", "description", "txid", "from_address", "to_address", "value_sats", "hop"}}
+	for _, e := range events {
+		rows = append(rows, []string{
+			formatTime(e.Timestamp), e.EventType, e.Description, e.Txid, e.FromAddress, e.ToAddress,
+			strconv.FormatInt(e.Value, 10), strconv.Itoa(e.HopNumber),
+		})
+	}
+	return rows
+}
+
+func taggedRows(tags []heuristics.TaggedAddress) [][]string {
+	rows := [][]string{{"address", "label", "role", "notes", "hop", "value_sats", "tagged_at", "tagged_by"}}
+	for _, t := range tags {
+		rows = append(rows, []string{
+			t.Address, t.Label, t.Role, t.Notes, strconv.Itoa(t.HopNumber), strconv.FormatInt(t.Value, 10),
+			formatTime(t.TaggedAt), t.TaggedBy,
+		})
+	}
+	return rows
+}
+
+func exitRows(exits []heuristics.FlowNode) [][]string {
+	rows := [][]string{{"address", "exchange", "hop", "value_received_sats", "risk_score"}}
+	for _, n := range exits {
+		rows = append(rows, []string{
+			n.Address, n.Label, strconv.Itoa(n.HopNumber), strconv.FormatInt(n.ValueReceived, 10), strconv.FormatFloat(n.RiskScore, 'f', 4, 64),
+		})
+	}
+	return rows
+}
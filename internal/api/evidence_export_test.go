@@ -0,0 +1,121 @@
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// exportChain is a two-hop chain ending at a known exchange address
+type exportChain map[string][]models.Transaction
+
+func (c exportChain) FindSpendingTxs(ctx context.Context, addr string, fromHeight int) ([]models.Transaction, error) {
+	return c[addr], nil
+}
+
+func tracedInvestigationHandler(t *testing.T) (*APIHandler, *gin.Engine) {
+	t.Helper()
+	h := &APIHandler{invManager: heuristics.NewInvestigationManager()}
+	inv := h.invManager.CreateInvestigation("CASE-EXPORT", "Exchange hack", "Hot wallet drained", []string{"theft"}, 1_000_000)
+	inv.TagAddress("hop1", "Suspect, \"mule\" wallet", "suspect", "first hop", "analyst")
+	inv.RunTrace(context.Background(), exportChain{
+		"theft": {{Txid: "tx1", BlockHeight: 100, Inputs: []models.TxIn{{Address: "theft", Value: 1_000_000}},
+			Outputs: []models.TxOut{{Address: "hop1", Value: 990_000}}}},
+		"hop1": {{Txid: "tx2", BlockHeight: 101, Inputs: []models.TxIn{{Address: "hop1", Value: 990_000}},
+			Outputs: []models.TxOut{{Address: "bc1qm34lsc65zpw79lxes69zkqmexport", Value: 980_000}}}},
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/investigation/:id/export", h.handleExportInvestigation)
+	return h, r
+}
+
+func TestExportInvestigation_JSON(t *testing.T) {
+	_, r := tracedInvestigationHandler(t)
+
+	w := serve(r, http.MethodGet, "/investigation/CASE-EXPORT/export?format=json", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200. Got %d: %s", w.Code, w.Body.String())
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.Contains(cd, `filename="CASE-EXPORT-evidence.json"`) {
+		t.Errorf("Expected a download filename. Got %q", cd)
+	}
+	var cf heuristics.CaseFile
+	if err := json.Unmarshal(w.Body.Bytes(), &cf); err != nil {
+		t.Fatal(err)
+	}
+	if cf.SnapshotID != heuristics.CurrentSnapshotID || cf.CaseID != "CASE-EXPORT" || cf.Trace == nil {
+		t.Errorf("Expected the snapshot ID and trace summary. Got %+v", cf)
+	}
+	if len(cf.Nodes) != 3 || len(cf.Edges) != 2 || len(cf.TaggedAddresses) != 1 || len(cf.ExchangeExits) != 1 || len(cf.Timeline) == 0 {
+		t.Errorf("Expected the full flow graph, tags, exits and timeline. Got %+v", cf)
+	}
+	if cf.Recovery.TotalRecoverable != 980_000 || cf.Recovery.RecoveryRate != 0.98 {
+		t.Errorf("Expected recovery totals. Got %+v", cf.Recovery)
+	}
+
+	if w := serve(r, http.MethodGet, "/investigation/CASE-EXPORT/export?format=pdf", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unsupported format. Got %d", w.Code)
+	}
+	if w := serve(r, http.MethodGet, "/investigation/CASE-NONE/export", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown case. Got %d", w.Code)
+	}
+}
+
+func TestExportInvestigation_CSVZip(t *testing.T) {
+	_, r := tracedInvestigationHandler(t)
+
+	w := serve(r, http.MethodGet, "/investigation/CASE-EXPORT/export?format=csv", "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("Expected a zip download. Got %d %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	zr, err := zip.NewReader(bytes.NewReader(w.Body.Bytes()), int64(w.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string][]byte)
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+
+	var manifest EvidenceManifest
+	if err := json.Unmarshal(files["manifest.json"], &manifest); err != nil {
+		t.Fatalf("Expected manifest.json: %v", err)
+	}
+	if manifest.SnapshotID != heuristics.CurrentSnapshotID || len(manifest.Files) != 5 {
+		t.Errorf("Unexpected manifest %+v", manifest)
+	}
+	for name, digest := range manifest.Files {
+		sum := sha256.Sum256(files[name])
+		if hex.EncodeToString(sum[:]) != digest {
+			t.Errorf("Checksum mismatch for %s", name)
+		}
+	}
+
+	rowCounts := map[string]int{"nodes.csv": 4, "edges.csv": 3, "tagged_addresses.csv": 2, "exchange_exits.csv": 2}
+	for name, want := range rowCounts {
+		rows, err := csv.NewReader(bytes.NewReader(files[name])).ReadAll()
+		if err != nil || len(rows) != want {
+			t.Errorf("Expected %d rows (with header) in %s. Got %d, %v", want, name, len(rows), err)
+		}
+	}
+	tags, _ := csv.NewReader(bytes.NewReader(files["tagged_addresses.csv"])).ReadAll()
+	if len(tags) == 2 && tags[1][1] != `Suspect, "mule" wallet` {
+		t.Errorf("Expected the label to survive CSV quoting. Got %q", tags[1][1])
+	}
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/db"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// handlePropagateEvidence composes a multi-hop evidence chain into one
+// transitive edge. The chain is either posted directly (each edge's
+// dstNodeId must be the next edge's srcNodeId) or assembled from stored
+// evidence_edge rows between a source and a sink address.
+// POST /api/v1/evidence/propagate
+//
+//	{"edges": [...], "hopDecay": 0.76}
+//	{"source": "bc1q...", "sink": "bc1q..."}
+func (h *APIHandler) handlePropagateEvidence(c *gin.Context) {
+	var req struct {
+		Edges    []models.EvidenceEdge `json:"edges"`
+		Source   string                `json:"source"`
+		Sink     string                `json:"sink"`
+		HopDecay float64               `json:"hopDecay"` // 0 = DefaultHopDecay
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, errBadRequest("Invalid request body. Expected: {edges} or {source, sink}"))
+		return
+	}
+
+	chain := req.Edges
+	switch {
+	case len(chain) > 0:
+		if len(chain) > heuristics.MaxPropagationHops {
+			writeError(c, errBadRequest("Chain too long").With("maxHops", heuristics.MaxPropagationHops))
+			return
+		}
+		for i := 1; i < len(chain); i++ {
+			if chain[i].SrcNodeID != chain[i-1].DstNodeID {
+				writeError(c, errBadRequest("Edges do not form a chain").With("hop", i+1))
+				return
+			}
+		}
+	case req.Source != "" && req.Sink != "":
+		if h.dbStore == nil {
+			writeError(c, errUnavailable("Chain assembly needs the database; post the edges instead"))
+			return
+		}
+		var err error
+		chain, err = heuristics.AssembleEvidenceChain(c.Request.Context(), h.dbStore, req.Source, req.Sink)
+		if err != nil {
+			writeError(c, errInternal("Failed to assemble evidence chain", err))
+			return
+		}
+		if len(chain) == 0 {
+			writeError(c, errNotFound("No stored evidence chain links source to sink").With("maxHops", heuristics.MaxPropagationHops))
+			return
+		}
+	default:
+		writeError(c, errBadRequest("Provide edges, or a source and sink address"))
+		return
+	}
+
+	c.JSON(http.StatusOK, propagationResponse(chain, req.HopDecay))
+}
+
+// propagationResponse is the propagated edge of a chain with its strength.
+// A single edge is returned as-is ("direct"); a chain that decays below
+// MinTransitiveLLR has no propagated edge ("trace").
+func propagationResponse(chain []models.EvidenceEdge, hopDecay float64) gin.H {
+	resp := gin.H{"chain": chain, "hops": len(chain)}
+	if len(chain) == 1 {
+		resp["propagated"] = nil
+		resp["strength"] = heuristics.ComputeChainStrength(1, heuristics.ClampLLR(chain[0].LLRScore))
+		return resp
+	}
+	prop := heuristics.PropagateEvidence(chain, hopDecay)
+	resp["propagated"] = prop
+	if prop == nil {
+		resp["strength"] = heuristics.ComputeChainStrength(len(chain), 0)
+		return resp
+	}
+	resp["transitiveEdge"] = heuristics.BuildTransitiveEdge(prop)
+	resp["strength"] = heuristics.ComputeChainStrength(prop.Hops, prop.DecayedLLR)
+	return resp
+}
+
+// maxGraphHops caps the radius of an evidence subgraph query
+const maxGraphHops = 3
+
+// handleGetEvidenceGraph returns the stored evidence subgraph around an
+// address for chain assembly and graph views.
+// GET /api/v1/graph/:address?hops=1
+func (h *APIHandler) handleGetEvidenceGraph(c *gin.Context) {
+	if h.dbStore == nil {
+		writeError(c, errUnavailable("Evidence graph needs the database"))
+		return
+	}
+	address := c.Param("address")
+	hops, err := strconv.Atoi(c.DefaultQuery("hops", "1"))
+	if err != nil || hops < 1 || hops > maxGraphHops {
+		writeError(c, errBadRequest("Invalid hops").With("maxHops", maxGraphHops))
+		return
+	}
+
+	edges, err := h.dbStore.GetEdgesForAddress(c.Request.Context(), address, hops)
+	if err != nil {
+		writeError(c, errInternal("Failed to load evidence edges", err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"address":   address,
+		"hops":      hops,
+		"nodes":     graphNodes(edges),
+		"edges":     edges,
+		"truncated": len(edges) >= db.MaxSubgraphEdges,
+	})
+}
+
+// graphNodes lists the distinct endpoints and hyper-edge members of edges, sorted
+func graphNodes(edges []models.EvidenceEdge) []string {
+	seen := make(map[string]bool)
+	nodes := make([]string, 0)
+	for _, e := range edges {
+		for _, a := range append([]string{e.SrcNodeID, e.DstNodeID}, e.Members...) {
+			if a != "" && !seen[a] {
+				seen[a] = true
+				nodes = append(nodes, a)
+			}
+		}
+	}
+	sort.Strings(nodes)
+	return nodes
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func TestPropagateEvidence_ThreeHopChain(t *testing.T) {
+	h := &APIHandler{}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/evidence/propagate", h.handlePropagateEvidence)
+
+	w := serve(r, http.MethodPost, "/evidence/propagate", `{"edges":[
+		{"edgeId":"tx1","srcNodeId":"A","dstNodeId":"B","edgeType":2,"llrScore":3.0},
+		{"edgeId":"tx2","srcNodeId":"B","dstNodeId":"C","edgeType":2,"llrScore":2.0},
+		{"edgeId":"tx3","srcNodeId":"C","dstNodeId":"D","edgeType":1,"llrScore":1.5}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200. Got %d: %s", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Propagated     heuristics.PropagatedEdge `json:"propagated"`
+		TransitiveEdge struct {
+			SrcNodeID string `json:"srcNodeId"`
+			DstNodeID string `json:"dstNodeId"`
+			EdgeType  int    `json:"edgeType"`
+		} `json:"transitiveEdge"`
+		Strength string `json:"strength"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	// (3.0 + 2.0 + 1.5) × 0.76² = 3.75
+	if resp.Propagated.Hops != 3 || resp.Propagated.DecayedLLR < 3.7 || resp.Propagated.DecayedLLR > 3.8 {
+		t.Errorf("Expected a 3-hop edge decayed to ~3.75. Got %+v", resp.Propagated)
+	}
+	if resp.TransitiveEdge.SrcNodeID != "A" || resp.TransitiveEdge.DstNodeID != "D" || resp.TransitiveEdge.EdgeType != heuristics.EdgeTypeTransitive {
+		t.Errorf("Expected a transitive A→D edge. Got %+v", resp.TransitiveEdge)
+	}
+	if resp.Strength != "strong" {
+		t.Errorf("Expected strength 'strong'. Got %q", resp.Strength)
+	}
+}
+
+func TestPropagateEvidence_RejectsBrokenChain(t *testing.T) {
+	h := &APIHandler{}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/evidence/propagate", h.handlePropagateEvidence)
+
+	w := serve(r, http.MethodPost, "/evidence/propagate", `{"edges":[
+		{"srcNodeId":"A","dstNodeId":"B","llrScore":3.0},
+		{"srcNodeId":"X","dstNodeId":"C","llrScore":2.0}]}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for non-contiguous edges. Got %d", w.Code)
+	}
+	if w := serve(r, http.MethodPost, "/evidence/propagate", `{"source":"A","sink":"D"}`); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 for chain assembly without a database. Got %d", w.Code)
+	}
+}
+
+func TestGraphNodes_IncludesHyperEdgeMembers(t *testing.T) {
+	nodes := graphNodes([]models.EvidenceEdge{
+		{SrcNodeID: "B", DstNodeID: "A"},
+		{SrcNodeID: "A", DstNodeID: "consolidation:tx1", Members: []string{"C", "B"}},
+	})
+	if len(nodes) != 4 || nodes[0] != "A" || nodes[2] != "C" || nodes[3] != "consolidation:tx1" {
+		t.Errorf("Expected [A B C consolidation:tx1]. Got %v", nodes)
+	}
+}
+
+func TestEvidenceGraph_NeedsDatabase(t *testing.T) {
+	h := &APIHandler{}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/graph/:address", h.handleGetEvidenceGraph)
+
+	if w := serve(r, http.MethodGet, "/graph/bc1qa?hops=2", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a database. Got %d", w.Code)
+	}
+}
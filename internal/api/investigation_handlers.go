@@ -1,12 +1,17 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
+	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/bitcoin"
 	"github.com/rawblock/coinjoin-engine/internal/heuristics"
 )
 
@@ -25,12 +30,12 @@ func (h *APIHandler) handleCreateInvestigation(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		writeError(c, errInvalidInput("Invalid request", err))
 		return
 	}
 
 	if len(req.TheftAddresses) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one theft address is required"})
+		writeError(c, errBadRequest("At least one theft address is required"))
 		return
 	}
 
@@ -51,20 +56,11 @@ func (h *APIHandler) handleCreateInvestigation(c *gin.Context) {
 
 	// Persist investigation to DB for restart durability.
 	dbPersisted := false
-	if h.dbStore != nil {
-		if err := h.dbStore.SaveInvestigation(c.Request.Context(), caseID, req.Name, req.Description, req.TotalStolen); err != nil {
+	if h.invManager.Persistent() {
+		if err := h.invManager.Save(c.Request.Context(), inv); err != nil {
 			log.Printf("[Investigation] failed to persist case %s: %v", caseID, err)
 		} else {
 			dbPersisted = true
-			for _, theftAddr := range req.TheftAddresses {
-				if theftAddr == "" {
-					continue
-				}
-				if err := h.dbStore.SaveInvestigationAddress(c.Request.Context(), caseID, theftAddr,
-					"Theft: "+req.Name, "theft", "seeded theft origin", "system"); err != nil {
-					log.Printf("[Investigation] failed to persist theft address %s for case %s: %v", theftAddr, caseID, err)
-				}
-			}
 		}
 	}
 
@@ -83,13 +79,115 @@ func (h *APIHandler) handleGetInvestigation(c *gin.Context) {
 
 	inv := h.invManager.GetInvestigation(caseID)
 	if inv == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Investigation not found"})
+		writeError(c, errNotFound("Investigation not found"))
 		return
 	}
 
 	c.JSON(http.StatusOK, inv)
 }
 
+// InvestigationSummary is one case in the /investigation listing (the
+// flow graph is fetched per case)
+type InvestigationSummary struct {
+	ID              string    `json:"id"`
+	Name            string    `json:"name"`
+	Status          string    `json:"status"`
+	TheftAddresses  int       `json:"theftAddresses"`
+	TaggedAddresses int       `json:"taggedAddresses"`
+	TotalStolen     int64     `json:"totalStolen"`
+	TotalRecovered  int64     `json:"totalRecovered"`
+	Traced          bool      `json:"traced"`
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+// GET /api/v1/investigation?status=active
+// Lists all cases, oldest first, optionally filtered by status.
+func (h *APIHandler) handleListInvestigations(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && !heuristics.IsValidInvestigationStatus(status) {
+		writeError(c, errBadRequest("Invalid status "+strconv.Quote(status)+" (want active, paused, completed or archived)"))
+		return
+	}
+
+	var cases []*heuristics.Investigation
+	for _, inv := range h.invManager.ListInvestigations() {
+		cases = append(cases, inv.Snapshot())
+	}
+	sort.Slice(cases, func(i, j int) bool {
+		if !cases[i].CreatedAt.Equal(cases[j].CreatedAt) {
+			return cases[i].CreatedAt.Before(cases[j].CreatedAt)
+		}
+		return cases[i].ID < cases[j].ID
+	})
+
+	list := make([]InvestigationSummary, 0, len(cases))
+	for _, inv := range cases {
+		if status != "" && inv.Status != status {
+			continue
+		}
+		list = append(list, InvestigationSummary{
+			ID:              inv.ID,
+			Name:            inv.Name,
+			Status:          inv.Status,
+			TheftAddresses:  len(inv.TheftAddresses),
+			TaggedAddresses: len(inv.TaggedAddresses),
+			TotalStolen:     inv.TotalStolen,
+			TotalRecovered:  inv.TotalRecovered,
+			Traced:          inv.FlowGraph != nil,
+			CreatedAt:       inv.CreatedAt,
+			UpdatedAt:       inv.UpdatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"investigations": list,
+		"total":          len(list),
+	})
+}
+
+// PATCH /api/v1/investigation/:id/status
+// Moves a case through its lifecycle: {"status": "completed"}.
+func (h *APIHandler) handleSetInvestigationStatus(c *gin.Context) {
+	caseID := c.Param("id")
+
+	inv := h.invManager.GetInvestigation(caseID)
+	if inv == nil {
+		writeError(c, errNotFound("Investigation not found"))
+		return
+	}
+
+	var req struct {
+		Status string `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, errInvalidInput("Invalid request", err))
+		return
+	}
+	if !heuristics.IsValidInvestigationStatus(req.Status) {
+		writeError(c, errBadRequest("Invalid status "+strconv.Quote(req.Status)+" (want active, paused, completed or archived)"))
+		return
+	}
+
+	previous := inv.SetStatus(req.Status)
+
+	dbPersisted := false
+	if h.invManager.Persistent() {
+		if err := h.invManager.Save(c.Request.Context(), inv); err != nil {
+			log.Printf("[Investigation] failed to persist status of case %s: %v", caseID, err)
+		} else {
+			dbPersisted = true
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"caseId":         caseID,
+		"previousStatus": previous,
+		"status":         req.Status,
+		"dbPersisted":    dbPersisted,
+	})
+}
+
 // POST /api/v1/investigation/:id/trace
 // Runs the fund flow trace for an investigation.
 func (h *APIHandler) handleRunTrace(c *gin.Context) {
@@ -97,7 +195,13 @@ func (h *APIHandler) handleRunTrace(c *gin.Context) {
 
 	inv := h.invManager.GetInvestigation(caseID)
 	if inv == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Investigation not found"})
+		writeError(c, errNotFound("Investigation not found"))
+		return
+	}
+
+	if h.btcClient == nil {
+		writeError(c, errUnavailable("Bitcoin RPC not configured").
+			With("hint", "Fund tracing requires a node with -blockfilterindex=1 and -txindex=1"))
 		return
 	}
 
@@ -107,32 +211,53 @@ func (h *APIHandler) handleRunTrace(c *gin.Context) {
 		MinValue        int64   `json:"minValue"`
 		PenetrateMixers *bool   `json:"penetrateMixers"`
 		MinConfidence   float64 `json:"minConfidence"`
+		TimeoutSeconds  int     `json:"timeoutSeconds"`
 	}
 	if err := c.ShouldBindJSON(&req); err == nil {
-		if req.MaxHops > 0 {
-			inv.TraceConfig.MaxHops = req.MaxHops
-		}
-		if req.MinValue > 0 {
-			inv.TraceConfig.MinValue = req.MinValue
-		}
-		if req.PenetrateMixers != nil {
-			inv.TraceConfig.PenetrateMixers = *req.PenetrateMixers
-		}
-		if req.MinConfidence > 0 {
-			inv.TraceConfig.MinConfidence = req.MinConfidence
-		}
+		inv.UpdateTraceConfig(func(cfg *heuristics.TraceConfig) {
+			if req.MaxHops > 0 {
+				cfg.MaxHops = req.MaxHops
+			}
+			if req.MinValue > 0 {
+				cfg.MinValue = req.MinValue
+			}
+			if req.PenetrateMixers != nil {
+				cfg.PenetrateMixers = *req.PenetrateMixers
+			}
+			if req.MinConfidence > 0 {
+				cfg.MinConfidence = req.MinConfidence
+			}
+		})
 	}
 
-	// Execute the trace
-	inv.RunTrace()
+	// Bound the trace by the client connection and the optional timeout
+	ctx := c.Request.Context()
+	if req.TimeoutSeconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(req.TimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+
+	// Execute the trace (walks the chain via the node's compact block filters)
+	inv.RunTrace(ctx, h.btcClient)
 
 	summary := map[string]interface{}{
 		"status": "trace_complete",
 		"caseId": caseID,
 	}
 
-	if inv.FlowGraph != nil {
-		summary["summary"] = inv.FlowGraph.Summary()
+	if graph := inv.Snapshot().FlowGraph; graph != nil {
+		summary["summary"] = graph.Summary()
+		summary["truncated"] = graph.Truncated
+		if graph.Truncated {
+			summary["status"] = "trace_truncated"
+		}
+	}
+
+	// Persist the refreshed recovery total
+	inv.ComputeRecovery()
+	if err := h.invManager.Save(c.Request.Context(), inv); err != nil {
+		log.Printf("[Investigation] failed to persist trace of case %s: %v", caseID, err)
 	}
 
 	c.JSON(http.StatusOK, summary)
@@ -145,11 +270,12 @@ func (h *APIHandler) handleGetFlowGraph(c *gin.Context) {
 
 	inv := h.invManager.GetInvestigation(caseID)
 	if inv == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Investigation not found"})
+		writeError(c, errNotFound("Investigation not found"))
 		return
 	}
 
-	if inv.FlowGraph == nil {
+	graph := inv.Snapshot().FlowGraph
+	if graph == nil {
 		c.JSON(http.StatusOK, gin.H{
 			"message": "No trace has been run yet. POST to /trace first.",
 			"nodes":   []heuristics.FlowNode{},
@@ -158,7 +284,36 @@ func (h *APIHandler) handleGetFlowGraph(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, inv.FlowGraph)
+	c.JSON(http.StatusOK, graph)
+}
+
+// GET /api/v1/investigation/:id/entity-graph
+// Returns the flow graph collapsed to entity clusters (CIOH), with
+// aggregated inter-entity flows and exchange/suspect annotations.
+func (h *APIHandler) handleGetEntityGraph(c *gin.Context) {
+	caseID := c.Param("id")
+
+	inv := h.invManager.GetInvestigation(caseID)
+	if inv == nil {
+		writeError(c, errNotFound("Investigation not found"))
+		return
+	}
+
+	if inv.Snapshot().FlowGraph == nil {
+		c.JSON(http.StatusOK, gin.H{
+			"message":  "No trace has been run yet. POST to /trace first.",
+			"entities": []heuristics.EntityNode{},
+			"flows":    []heuristics.EntityFlow{},
+		})
+		return
+	}
+
+	// Collapse with the scanner's persisted clusters, not just the trace's
+	var known *heuristics.ClusterEngine
+	if h.blockScanner != nil {
+		known = h.blockScanner.Clusters()
+	}
+	c.JSON(http.StatusOK, inv.EntityGraph(known))
 }
 
 // POST /api/v1/investigation/:id/tag
@@ -168,7 +323,7 @@ func (h *APIHandler) handleTagAddress(c *gin.Context) {
 
 	inv := h.invManager.GetInvestigation(caseID)
 	if inv == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Investigation not found"})
+		writeError(c, errNotFound("Investigation not found"))
 		return
 	}
 
@@ -181,7 +336,7 @@ func (h *APIHandler) handleTagAddress(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request: " + err.Error()})
+		writeError(c, errInvalidInput("Invalid request", err))
 		return
 	}
 
@@ -205,9 +360,9 @@ func (h *APIHandler) handleTagAddress(c *gin.Context) {
 	})
 
 	dbPersisted := false
-	if h.dbStore != nil {
-		if err := h.dbStore.SaveInvestigationAddress(c.Request.Context(), caseID, req.Address,
-			req.Label, req.Role, req.Notes, req.TaggedBy); err != nil {
+	if h.invManager.Persistent() {
+		tag := heuristics.TaggedAddress{Address: req.Address, Label: req.Label, Role: req.Role, Notes: req.Notes, TaggedBy: req.TaggedBy}
+		if err := h.invManager.SaveTag(c.Request.Context(), caseID, tag); err != nil {
 			log.Printf("[Investigation] failed to persist tagged address %s for case %s: %v", req.Address, caseID, err)
 		} else {
 			dbPersisted = true
@@ -230,7 +385,7 @@ func (h *APIHandler) handleGetTimeline(c *gin.Context) {
 
 	inv := h.invManager.GetInvestigation(caseID)
 	if inv == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Investigation not found"})
+		writeError(c, errNotFound("Investigation not found"))
 		return
 	}
 
@@ -254,7 +409,7 @@ func (h *APIHandler) handleGetExchangeExits(c *gin.Context) {
 
 	inv := h.invManager.GetInvestigation(caseID)
 	if inv == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Investigation not found"})
+		writeError(c, errNotFound("Investigation not found"))
 		return
 	}
 
@@ -264,14 +419,15 @@ func (h *APIHandler) handleGetExchangeExits(c *gin.Context) {
 	}
 
 	recovery := inv.ComputeRecovery()
+	totalStolen := inv.Snapshot().TotalStolen
 
 	c.JSON(http.StatusOK, gin.H{
 		"caseId":           caseID,
 		"exchangeExits":    exits,
 		"totalExits":       len(exits),
 		"totalRecoverable": recovery,
-		"totalStolen":      inv.TotalStolen,
-		"recoveryRate":     safeDiv(float64(recovery), float64(inv.TotalStolen)),
+		"totalStolen":      totalStolen,
+		"recoveryRate":     safeDiv(float64(recovery), float64(totalStolen)),
 	})
 }
 
@@ -282,3 +438,65 @@ func safeDiv(a, b float64) float64 {
 	}
 	return a / b
 }
+
+// GET /api/v1/investigation/:id/utxos
+// Returns the live unspent outputs and balances of the case's addresses
+// (theft, tagged and traced endpoints) from a UTXO set scan — no wallet
+// import needed.
+func (h *APIHandler) handleGetCaseUTXOs(c *gin.Context) {
+	caseID := c.Param("id")
+
+	inv := h.invManager.GetInvestigation(caseID)
+	if inv == nil {
+		writeError(c, errNotFound("Investigation not found"))
+		return
+	}
+
+	if h.btcClient == nil {
+		writeError(c, errUnavailable("Bitcoin RPC not configured"))
+		return
+	}
+
+	scan, err := h.btcClient.ScanAddresses(caseUTXOAddresses(inv))
+	if errors.Is(err, bitcoin.ErrScanInProgress) || errors.Is(err, bitcoin.ErrUTXOSetBusy) {
+		writeError(c, errUnavailable(err.Error()))
+		return
+	}
+	if err != nil {
+		writeError(c, errUpstream("UTXO scan failed", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"caseId": caseID,
+		"scan":   scan,
+	})
+}
+
+// caseUTXOAddresses lists the addresses whose funds a case watches: theft
+// and tagged addresses, plus the unspent endpoints of the last trace
+func caseUTXOAddresses(inv *heuristics.Investigation) []string {
+	inv = inv.Snapshot()
+	seen := make(map[string]bool)
+	var addrs []string
+	add := func(addr string) {
+		if addr != "" && !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	for _, addr := range inv.TheftAddresses {
+		add(addr)
+	}
+	for _, tag := range inv.TaggedAddresses {
+		add(tag.Address)
+	}
+	if inv.FlowGraph != nil {
+		for _, node := range inv.FlowGraph.Nodes {
+			if node.Role == "unspent" || node.UnspentBalance > 0 {
+				add(node.Address)
+			}
+		}
+	}
+	return addrs
+}
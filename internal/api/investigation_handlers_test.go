@@ -0,0 +1,141 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// statusStore records the last persisted case row
+type statusStore struct {
+	saved map[string]models.InvestigationRecord
+}
+
+func (s *statusStore) SaveInvestigation(_ context.Context, rec models.InvestigationRecord) error {
+	s.saved[rec.CaseID] = rec
+	return nil
+}
+
+func (s *statusStore) SaveInvestigationAddress(context.Context, string, string, string, string, string, string) error {
+	return nil
+}
+
+func (s *statusStore) LoadInvestigations(context.Context) ([]models.InvestigationRecord, error) {
+	return nil, nil
+}
+
+func investigationRouter(t *testing.T) (*APIHandler, *statusStore, *gin.Engine) {
+	t.Helper()
+	store := &statusStore{saved: make(map[string]models.InvestigationRecord)}
+	h := &APIHandler{invManager: heuristics.NewInvestigationManager()}
+	if _, err := h.invManager.AttachStore(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+	first := h.invManager.CreateInvestigation("CASE-A", "Bridge exploit", "", []string{"bc1qtheft1"}, 1_000)
+	second := h.invManager.CreateInvestigation("CASE-B", "Phishing", "", []string{"bc1qtheft2", "bc1qtheft3"}, 2_000)
+	second.CreatedAt = first.CreatedAt.Add(time.Second)
+	second.SetStatus("paused")
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/investigation", h.handleListInvestigations)
+	r.PATCH("/investigation/:id/status", h.handleSetInvestigationStatus)
+	return h, store, r
+}
+
+func TestListInvestigations_StatusFilter(t *testing.T) {
+	_, _, r := investigationRouter(t)
+
+	var all struct {
+		Investigations []InvestigationSummary `json:"investigations"`
+		Total          int                    `json:"total"`
+	}
+	w := serve(r, http.MethodGet, "/investigation", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200. Got %d: %s", w.Code, w.Body.String())
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &all); err != nil {
+		t.Fatal(err)
+	}
+	if all.Total != 2 || all.Investigations[0].ID != "CASE-A" || all.Investigations[1].TheftAddresses != 2 {
+		t.Errorf("Expected both cases, oldest first. Got %+v", all)
+	}
+
+	var paused struct {
+		Investigations []InvestigationSummary `json:"investigations"`
+	}
+	w = serve(r, http.MethodGet, "/investigation?status=paused", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &paused); err != nil {
+		t.Fatal(err)
+	}
+	if len(paused.Investigations) != 1 || paused.Investigations[0].ID != "CASE-B" {
+		t.Errorf("Expected only the paused case. Got %+v", paused.Investigations)
+	}
+
+	if w := serve(r, http.MethodGet, "/investigation?status=closed", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown status filter. Got %d", w.Code)
+	}
+}
+
+func TestSetInvestigationStatus_PersistsChange(t *testing.T) {
+	h, store, r := investigationRouter(t)
+
+	w := serve(r, http.MethodPatch, "/investigation/CASE-A/status", `{"status":"completed"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200. Got %d: %s", w.Code, w.Body.String())
+	}
+	if got := h.invManager.GetInvestigation("CASE-A").Status; got != "completed" {
+		t.Errorf("Expected CASE-A completed. Got %s", got)
+	}
+	if rec, ok := store.saved["CASE-A"]; !ok || rec.Status != "completed" {
+		t.Errorf("Expected the status change persisted. Got %+v", rec)
+	}
+
+	if w := serve(r, http.MethodPatch, "/investigation/CASE-A/status", `{"status":"closed"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid status. Got %d", w.Code)
+	}
+	if w := serve(r, http.MethodPatch, "/investigation/CASE-X/status", `{"status":"paused"}`); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown case. Got %d", w.Code)
+	}
+}
+
+func TestCaseUTXOAddresses_TheftTaggedAndEndpoints(t *testing.T) {
+	inv := heuristics.NewInvestigationManager().CreateInvestigation("CASE-U", "Drain", "", []string{"bc1qtheft", "bc1qtheft"}, 0)
+	inv.TagAddress("bc1qsuspect", "Suspect", "suspect", "", "")
+	inv.FlowGraph = &heuristics.FlowGraph{Nodes: []heuristics.FlowNode{
+		{Address: "bc1qtheft", Role: "theft"},
+		{Address: "bc1qhop", Role: "intermediate"},
+		{Address: "bc1qleaf", Role: "unspent"},
+		{Address: "bc1qdeep", Role: "intermediate", UnspentBalance: 5_000},
+	}}
+
+	got := caseUTXOAddresses(inv)
+	want := []string{"bc1qtheft", "bc1qsuspect", "bc1qleaf", "bc1qdeep"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v. Got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %v. Got %v", want, got)
+			break
+		}
+	}
+}
+
+func TestGetCaseUTXOs_RequiresNode(t *testing.T) {
+	h, _, r := investigationRouter(t)
+	r.GET("/investigation/:id/utxos", h.handleGetCaseUTXOs)
+
+	if w := serve(r, http.MethodGet, "/investigation/CASE-X/utxos", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unknown case. Got %d", w.Code)
+	}
+	if w := serve(r, http.MethodGet, "/investigation/CASE-A/utxos", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a node. Got %d", w.Code)
+	}
+}
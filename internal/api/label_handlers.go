@@ -0,0 +1,161 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// ════════════════════════════════════════════════════════════════════
+// Address Labels API
+// ════════════════════════════════════════════════════════════════════
+
+const maxLabelImportBytes = 16 << 20
+
+// LabelRejection reports a label of an import that failed validation
+type LabelRejection struct {
+	Index   int    `json:"index"`
+	Address string `json:"address"`
+	Error   string `json:"error"`
+}
+
+// LabelImportResponse is the POST /labels response
+type LabelImportResponse struct {
+	Imported    int              `json:"imported"`
+	Rejected    []LabelRejection `json:"rejected"`
+	DBPersisted bool             `json:"dbPersisted"`
+	LabelCount  int              `json:"labelCount"`
+}
+
+// parseLabelImport decodes a single label object or an array of labels
+func parseLabelImport(body []byte) ([]models.AddressLabel, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 {
+		return nil, fmt.Errorf("empty request body")
+	}
+	if body[0] == '[' {
+		var labels []models.AddressLabel
+		if err := json.Unmarshal(body, &labels); err != nil {
+			return nil, err
+		}
+		return labels, nil
+	}
+	var label models.AddressLabel
+	if err := json.Unmarshal(body, &label); err != nil {
+		return nil, err
+	}
+	return []models.AddressLabel{label}, nil
+}
+
+// POST /api/v1/labels
+// Imports one label or an array of labels
+// ({address, label, category, source, confidence}). Valid labels replace
+// any existing label of their address; invalid ones are reported back.
+func (h *APIHandler) handleImportLabels(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxLabelImportBytes))
+	if err != nil {
+		writeError(c, errInvalidInput("Failed to read request", err))
+		return
+	}
+	labels, err := parseLabelImport(body)
+	if err != nil {
+		writeError(c, errInvalidInput("Invalid request", err))
+		return
+	}
+
+	index := heuristics.GetGlobalAddressLabels()
+	res := LabelImportResponse{Rejected: make([]LabelRejection, 0)}
+	accepted := make([]models.AddressLabel, 0, len(labels))
+	for i, l := range labels {
+		stored, err := index.Set(l)
+		if err != nil {
+			res.Rejected = append(res.Rejected, LabelRejection{Index: i, Address: l.Address, Error: err.Error()})
+			continue
+		}
+		accepted = append(accepted, stored)
+	}
+	res.Imported = len(accepted)
+	res.LabelCount = index.Size()
+	if res.Imported == 0 {
+		c.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if h.dbStore != nil {
+		if err := h.dbStore.SaveAddressLabels(c.Request.Context(), accepted); err != nil {
+			log.Printf("[Labels] failed to persist %d address labels: %v", len(accepted), err)
+		} else {
+			res.DBPersisted = true
+		}
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// GET /api/v1/labels?category=exchange&page=1&limit=100
+// Lists labeled addresses, optionally of one category.
+func (h *APIHandler) handleListLabels(c *gin.Context) {
+	category := strings.ToLower(c.Query("category"))
+	if category != "" && !heuristics.IsLabelCategory(category) {
+		writeError(c, errBadRequest(fmt.Sprintf("invalid category %q (want one of %s)",
+			category, strings.Join(heuristics.LabelCategories(), ", "))))
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	labels := heuristics.GetGlobalAddressLabels().List(category)
+	total := len(labels)
+	start := min((page-1)*limit, total)
+	end := min(start+limit, total)
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       labels[start:end],
+		"totalCount": total,
+		"page":       page,
+		"limit":      limit,
+	})
+}
+
+// GET /api/v1/labels/:address
+func (h *APIHandler) handleGetLabel(c *gin.Context) {
+	label, ok := heuristics.GetGlobalAddressLabels().Get(c.Param("address"))
+	if !ok {
+		writeError(c, errNotFound("Address is not labeled"))
+		return
+	}
+	c.JSON(http.StatusOK, label)
+}
+
+// DELETE /api/v1/labels/:address
+func (h *APIHandler) handleDeleteLabel(c *gin.Context) {
+	address := heuristics.NormalizeAddress(c.Param("address"))
+	if !heuristics.GetGlobalAddressLabels().Remove(address) {
+		writeError(c, errNotFound("Address is not labeled"))
+		return
+	}
+
+	dbPersisted := false
+	if h.dbStore != nil {
+		if err := h.dbStore.DeleteAddressLabel(c.Request.Context(), address); err != nil {
+			log.Printf("[Labels] failed to delete address label %s: %v", address, err)
+		} else {
+			dbPersisted = true
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "deleted", "address": address, "dbPersisted": dbPersisted})
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+)
+
+func TestLabelEndpoints_ImportGetDelete(t *testing.T) {
+	const addr = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+	h := &APIHandler{}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/labels", h.handleImportLabels)
+	r.GET("/labels", h.handleListLabels)
+	r.GET("/labels/:address", h.handleGetLabel)
+	r.DELETE("/labels/:address", h.handleDeleteLabel)
+	defer heuristics.GetGlobalAddressLabels().Remove(addr)
+
+	w := serve(r, http.MethodPost, "/labels", `[
+		{"address": "`+addr+`", "label": "Kraken", "category": "exchange", "source": "vendor"},
+		{"address": "nope", "label": "Bad", "category": "exchange"}
+	]`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200. Got %d: %s", w.Code, w.Body)
+	}
+	var res LabelImportResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res.Imported != 1 || len(res.Rejected) != 1 || res.Rejected[0].Index != 1 || res.DBPersisted {
+		t.Errorf("Expected 1 imported and the second rejected. Got %+v", res)
+	}
+
+	if w := serve(r, http.MethodGet, "/labels/"+addr, ""); w.Code != http.StatusOK {
+		t.Errorf("Expected the label. Got %d", w.Code)
+	}
+	if w := serve(r, http.MethodGet, "/labels?category=bank", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown category. Got %d", w.Code)
+	}
+	w = serve(r, http.MethodGet, "/labels?category=exchange", "")
+	var list struct {
+		TotalCount int `json:"totalCount"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || list.TotalCount != 1 {
+		t.Errorf("Expected 1 exchange label. Got %s", w.Body)
+	}
+
+	if w := serve(r, http.MethodDelete, "/labels/"+addr, ""); w.Code != http.StatusOK {
+		t.Errorf("Expected the label deleted. Got %d", w.Code)
+	}
+	if w := serve(r, http.MethodDelete, "/labels/"+addr, ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a deleted label. Got %d", w.Code)
+	}
+}
+
+func TestImportLabels_RejectsWhenNothingValid(t *testing.T) {
+	h := &APIHandler{}
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/labels", h.handleImportLabels)
+
+	if w := serve(r, http.MethodPost, "/labels", `{"address": "nope", "label": "Bad", "category": "exchange"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400. Got %d", w.Code)
+	}
+	if w := serve(r, http.MethodPost, "/labels", `not json`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for malformed JSON. Got %d", w.Code)
+	}
+}
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ════════════════════════════════════════════════════════════════════
+// Peel Chain API
+// ════════════════════════════════════════════════════════════════════
+
+// GET /api/v1/peel-chains?minLength=2&limit=100
+// Lists the multi-hop peel chains linked by the block scanner, longest first
+func (h *APIHandler) handleListPeelChains(c *gin.Context) {
+	if h.blockScanner == nil {
+		writeError(c, errUnavailable("Block scanner not initialized"))
+		return
+	}
+	minLength, _ := strconv.Atoi(c.DefaultQuery("minLength", "2"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if minLength < 1 {
+		minLength = 2
+	}
+	if limit < 1 || limit > 1000 {
+		limit = 100
+	}
+
+	chains := h.blockScanner.PeelChains().Chains(minLength)
+	total := len(chains)
+	c.JSON(http.StatusOK, gin.H{
+		"data":       chains[:min(limit, total)],
+		"totalCount": total,
+		"limit":      limit,
+	})
+}
+
+// GET /api/v1/peel-chains/:txid
+// Returns the peel chain a transaction belongs to
+func (h *APIHandler) handleGetPeelChain(c *gin.Context) {
+	if h.blockScanner == nil {
+		writeError(c, errUnavailable("Block scanner not initialized"))
+		return
+	}
+	chain, ok := h.blockScanner.PeelChains().Chain(c.Param("txid"))
+	if !ok {
+		writeError(c, errNotFound("Transaction is not a linked peel step"))
+		return
+	}
+	c.JSON(http.StatusOK, chain)
+}
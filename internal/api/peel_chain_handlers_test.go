@@ -0,0 +1,41 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/internal/scanner"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func TestPeelChainEndpoints(t *testing.T) {
+	h := &APIHandler{blockScanner: scanner.NewBlockScanner(nil, nil, nil)}
+	tracker := h.blockScanner.PeelChains()
+	step := &models.PeelChainResult{IsChain: true, ChainLength: 1, Confidence: 0.6}
+	tracker.Observe(models.Transaction{Txid: "hop-1", Outputs: []models.TxOut{{Value: 1}, {Value: 2}}}, step)
+	tracker.Observe(models.Transaction{Txid: "hop-2", Inputs: []models.TxIn{{Txid: "hop-1", Vout: 1}}}, step)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/peel-chains", h.handleListPeelChains)
+	r.GET("/peel-chains/:txid", h.handleGetPeelChain)
+
+	w := serve(r, http.MethodGet, "/peel-chains/hop-2", "")
+	var chain heuristics.PeelChain
+	if err := json.Unmarshal(w.Body.Bytes(), &chain); err != nil || w.Code != http.StatusOK || chain.Length != 2 {
+		t.Errorf("Expected the 2-hop chain. Got %d: %s", w.Code, w.Body)
+	}
+	if w := serve(r, http.MethodGet, "/peel-chains/unknown", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unlinked tx. Got %d", w.Code)
+	}
+	w = serve(r, http.MethodGet, "/peel-chains", "")
+	var list struct {
+		TotalCount int `json:"totalCount"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil || list.TotalCount != 1 {
+		t.Errorf("Expected 1 multi-hop chain. Got %s", w.Body)
+	}
+}
@@ -0,0 +1,34 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+)
+
+// ════════════════════════════════════════════════════════════════════
+// Mining Pool Tags API
+// ════════════════════════════════════════════════════════════════════
+
+// GET /api/v1/pools
+// Summarizes the coinbase tag database used for pool attribution
+func (h *APIHandler) handleGetPoolTags(c *gin.Context) {
+	c.JSON(http.StatusOK, heuristics.GetGlobalPoolTagDB().Stats())
+}
+
+// POST /api/v1/pools/reload
+// Re-reads POOL_TAGS_FILE; on failure the previous tags stay in use
+func (h *APIHandler) handleReloadPoolTags(c *gin.Context) {
+	db := heuristics.GetGlobalPoolTagDB()
+	if db.Path() == "" {
+		writeError(c, newAPIError(http.StatusConflict, ErrCodeConflict, "No pool tags file loaded (set POOL_TAGS_FILE)"))
+		return
+	}
+	stats, err := db.Reload()
+	if err != nil {
+		writeError(c, errInternal("Failed to reload pool tags", err))
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
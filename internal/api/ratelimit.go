@@ -1,7 +1,10 @@
 package api
 
 import (
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"sync"
 	"time"
 
@@ -14,8 +17,10 @@ import (
 // Uses stdlib only — no external dependency.
 //
 // Each IP gets its own bucket with a configurable capacity and refill rate.
-// When the bucket is empty the request receives HTTP 429 with a Retry-After
-// header indicating when to try again.
+// Every response carries X-RateLimit-Limit (requests per minute),
+// X-RateLimit-Remaining and X-RateLimit-Reset (seconds until the bucket is
+// full again). When the bucket is empty the request receives HTTP 429 with
+// a Retry-After header (seconds) indicating when to try again.
 //
 // A background goroutine cleans up buckets that have been idle for more than
 // cleanupIdleDuration to prevent unbounded memory growth from transient IPs.
@@ -31,25 +36,35 @@ type ipBucket struct {
 
 // RateLimiter holds per-IP state.
 type RateLimiter struct {
-	rate     float64 // tokens added per second
-	burst    float64 // max bucket capacity
-	mu       sync.Mutex
-	buckets  map[string]*ipBucket
+	perMinute int
+	rate      float64 // tokens added per second
+	burst     float64 // max bucket capacity
+	mu        sync.Mutex
+	buckets   map[string]*ipBucket
+}
+
+// rateDecision is the outcome of one request against a bucket
+type rateDecision struct {
+	allowed    bool
+	remaining  int           // Whole tokens left
+	retryAfter time.Duration // Until the next token (denied requests)
+	reset      time.Duration // Until the bucket is full again
 }
 
 // NewRateLimiter creates a rate limiter allowing `ratePerMin` requests per
 // minute per IP, with a burst capacity of `burst` requests.
 func NewRateLimiter(ratePerMin, burst int) *RateLimiter {
 	rl := &RateLimiter{
-		rate:    float64(ratePerMin) / 60.0,
-		burst:   float64(burst),
-		buckets: make(map[string]*ipBucket),
+		perMinute: ratePerMin,
+		rate:      float64(ratePerMin) / 60.0,
+		burst:     float64(burst),
+		buckets:   make(map[string]*ipBucket),
 	}
 	go rl.cleanupLoop()
 	return rl
 }
 
-func (rl *RateLimiter) allow(ip string) (bool, time.Duration) {
+func (rl *RateLimiter) allow(ip string) rateDecision {
 	rl.mu.Lock()
 	bucket, ok := rl.buckets[ip]
 	if !ok {
@@ -70,33 +85,43 @@ func (rl *RateLimiter) allow(ip string) (bool, time.Duration) {
 	}
 	bucket.lastSeen = now
 
-	if bucket.tokens >= 1.0 {
+	d := rateDecision{allowed: bucket.tokens >= 1.0}
+	if d.allowed {
 		bucket.tokens--
-		return true, 0
+	} else {
+		// Calculate how long until a token is available.
+		d.retryAfter = time.Duration((1.0-bucket.tokens)/rl.rate*1000) * time.Millisecond
 	}
-
-	// Calculate how long until a token is available.
-	retryAfter := time.Duration((1.0-bucket.tokens)/rl.rate*1000) * time.Millisecond
-	return false, retryAfter
+	d.remaining = int(bucket.tokens)
+	d.reset = time.Duration((rl.burst-bucket.tokens)/rl.rate*1000) * time.Millisecond
+	return d
 }
 
 // Middleware returns a Gin handler that enforces the rate limit.
 func (rl *RateLimiter) Middleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		ip := c.ClientIP()
-		allowed, retryAfter := rl.allow(ip)
-		if !allowed {
-			c.Header("Retry-After", retryAfter.String())
-			c.JSON(http.StatusTooManyRequests, gin.H{
-				"error":       "Rate limit exceeded",
-				"retryAfter":  retryAfter.String(),
-				"limit":       "30 requests/minute per IP",
-			})
-			c.Abort()
-			return
-		}
-		c.Next()
+	return rl.handle
+}
+
+// handle charges the request to the client's bucket
+func (rl *RateLimiter) handle(c *gin.Context) {
+	d := rl.allow(c.ClientIP())
+	c.Header("X-RateLimit-Limit", strconv.Itoa(rl.perMinute))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(d.remaining))
+	c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(d.reset)))
+	if !d.allowed {
+		retryAfter := max(1, ceilSeconds(d.retryAfter))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		writeError(c, newAPIError(http.StatusTooManyRequests, ErrCodeRateLimited, "Rate limit exceeded").
+			With("retryAfter", retryAfter).
+			With("limit", fmt.Sprintf("%d requests/minute per IP (burst %d)", rl.perMinute, int(rl.burst))))
+		return
 	}
+	c.Next()
+}
+
+// ceilSeconds rounds d up to whole seconds
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
 }
 
 // cleanupLoop removes stale IP buckets every cleanupIdleDuration.
@@ -0,0 +1,195 @@
+package api
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ──────────────────────────────────────────────────────────────────────
+// Per-Endpoint Rate Limits
+//
+// Protected endpoints differ by orders of magnitude in cost: reading a case
+// is a map lookup, while a historical scan or a scantxoutset walks the
+// chain. Each request is charged to exactly one per-IP budget, chosen by
+// its route:
+//
+//   - Routes:  a per-path override (own budget per route)
+//   - Heavy:   heavyRoutes, sharing one budget so alternating between
+//              them does not multiply the allowance
+//   - Default: everything else
+//
+// Environment overrides (read by RateLimitConfigFromEnv), "perMinute/burst":
+//   RATE_LIMIT_DEFAULT=30/5
+//   RATE_LIMIT_HEAVY=4/1
+//   RATE_LIMIT_ROUTES=/api/v1/analyze/:txid=20/5,/api/v1/labels=60/10
+// ──────────────────────────────────────────────────────────────────────
+
+// heavyRoutes are the protected routes charged to the heavy budget
+var heavyRoutes = []string{
+	"/api/v1/scan",
+	"/api/v1/shadow/run",
+	"/api/v1/investigation/:id/trace",
+	"/api/v1/investigation/:id/utxos",
+}
+
+// RateLimit is a per-IP request budget
+type RateLimit struct {
+	PerMinute int `json:"perMinute"`
+	Burst     int `json:"burst"`
+}
+
+func (l RateLimit) String() string {
+	return fmt.Sprintf("%d/%d", l.PerMinute, l.Burst)
+}
+
+// ParseRateLimit parses "perMinute/burst", e.g. "30/5"
+func ParseRateLimit(s string) (RateLimit, error) {
+	rate, burst, ok := strings.Cut(strings.TrimSpace(s), "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("rate limit %q: want perMinute/burst", s)
+	}
+	perMinute, err := strconv.Atoi(strings.TrimSpace(rate))
+	if err != nil || perMinute <= 0 {
+		return RateLimit{}, fmt.Errorf("rate limit %q: perMinute must be a positive integer", s)
+	}
+	b, err := strconv.Atoi(strings.TrimSpace(burst))
+	if err != nil || b <= 0 {
+		return RateLimit{}, fmt.Errorf("rate limit %q: burst must be a positive integer", s)
+	}
+	return RateLimit{PerMinute: perMinute, Burst: b}, nil
+}
+
+// RateLimitConfig holds the protected endpoints' budgets
+type RateLimitConfig struct {
+	Default RateLimit            `json:"default"`
+	Heavy   RateLimit            `json:"heavy"`
+	Routes  map[string]RateLimit `json:"routes"` // Route path (as registered) → own budget
+}
+
+// DefaultRateLimitConfig returns the built-in budgets: the historical
+// 30/min (burst 5) everywhere, 4/min (burst 1) for heavy routes
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		Default: RateLimit{PerMinute: 30, Burst: 5},
+		Heavy:   RateLimit{PerMinute: 4, Burst: 1},
+	}
+}
+
+var rateLimitConfig atomic.Pointer[RateLimitConfig]
+
+func init() {
+	cfg := DefaultRateLimitConfig()
+	rateLimitConfig.Store(&cfg)
+}
+
+// SetRateLimitConfig replaces the budgets used by routers built afterwards.
+// Non-positive tier values fall back to the defaults; invalid route
+// overrides are dropped.
+func SetRateLimitConfig(cfg RateLimitConfig) {
+	def := DefaultRateLimitConfig()
+	if cfg.Default.PerMinute <= 0 || cfg.Default.Burst <= 0 {
+		cfg.Default = def.Default
+	}
+	if cfg.Heavy.PerMinute <= 0 || cfg.Heavy.Burst <= 0 {
+		cfg.Heavy = def.Heavy
+	}
+	routes := make(map[string]RateLimit, len(cfg.Routes))
+	for path, l := range cfg.Routes {
+		if l.PerMinute > 0 && l.Burst > 0 {
+			routes[path] = l
+		}
+	}
+	cfg.Routes = routes
+	rateLimitConfig.Store(&cfg)
+}
+
+// CurrentRateLimitConfig returns the configured budgets
+func CurrentRateLimitConfig() RateLimitConfig {
+	return *rateLimitConfig.Load()
+}
+
+// RateLimitConfigFromEnv returns the defaults with RATE_LIMIT_* overrides
+// applied; invalid values are logged and ignored
+func RateLimitConfigFromEnv() RateLimitConfig {
+	cfg := DefaultRateLimitConfig()
+	for _, tier := range []struct {
+		env   string
+		limit *RateLimit
+	}{
+		{"RATE_LIMIT_DEFAULT", &cfg.Default},
+		{"RATE_LIMIT_HEAVY", &cfg.Heavy},
+	} {
+		raw := os.Getenv(tier.env)
+		if raw == "" {
+			continue
+		}
+		if l, err := ParseRateLimit(raw); err == nil {
+			*tier.limit = l
+		} else {
+			log.Printf("Warning: invalid %s %q, using %s: %v", tier.env, raw, tier.limit, err)
+		}
+	}
+
+	if raw := os.Getenv("RATE_LIMIT_ROUTES"); raw != "" {
+		cfg.Routes = make(map[string]RateLimit)
+		for _, entry := range strings.Split(raw, ",") {
+			entry = strings.TrimSpace(entry)
+			if entry == "" {
+				continue
+			}
+			path, limit, ok := strings.Cut(entry, "=")
+			l, err := ParseRateLimit(limit)
+			if !ok || strings.TrimSpace(path) == "" || err != nil {
+				log.Printf("Warning: invalid RATE_LIMIT_ROUTES entry %q (want /path=perMinute/burst), ignoring", entry)
+				continue
+			}
+			cfg.Routes[strings.TrimSpace(path)] = l
+		}
+	}
+	return cfg
+}
+
+// RouteRateLimiter charges each request to the budget of its route
+type RouteRateLimiter struct {
+	def    *RateLimiter
+	heavy  *RateLimiter
+	routes map[string]*RateLimiter // Route path → own or shared heavy limiter
+}
+
+// NewRouteRateLimiter builds the per-route limiters of cfg
+func NewRouteRateLimiter(cfg RateLimitConfig) *RouteRateLimiter {
+	rl := &RouteRateLimiter{
+		def:    NewRateLimiter(cfg.Default.PerMinute, cfg.Default.Burst),
+		heavy:  NewRateLimiter(cfg.Heavy.PerMinute, cfg.Heavy.Burst),
+		routes: make(map[string]*RateLimiter),
+	}
+	for _, path := range heavyRoutes {
+		rl.routes[path] = rl.heavy
+	}
+	for path, l := range cfg.Routes {
+		rl.routes[path] = NewRateLimiter(l.PerMinute, l.Burst)
+	}
+	return rl
+}
+
+// limiterFor returns the limiter of a registered route path
+func (rl *RouteRateLimiter) limiterFor(path string) *RateLimiter {
+	if l, ok := rl.routes[path]; ok {
+		return l
+	}
+	return rl.def
+}
+
+// Middleware returns a Gin handler enforcing the per-route limits. It must
+// run inside a route group so the matched route path is known.
+func (rl *RouteRateLimiter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rl.limiterFor(c.FullPath()).handle(c)
+	}
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	if l, err := ParseRateLimit(" 20 / 4 "); err != nil || l != (RateLimit{PerMinute: 20, Burst: 4}) {
+		t.Errorf("Expected 20/4. Got %+v (%v)", l, err)
+	}
+	for _, raw := range []string{"", "30", "0/5", "30/0", "x/5", "30/-1"} {
+		if _, err := ParseRateLimit(raw); err == nil {
+			t.Errorf("Expected %q to be rejected", raw)
+		}
+	}
+}
+
+func TestRateLimitConfigFromEnv(t *testing.T) {
+	t.Setenv("RATE_LIMIT_DEFAULT", "60/10")
+	t.Setenv("RATE_LIMIT_HEAVY", "bogus")
+	t.Setenv("RATE_LIMIT_ROUTES", "/api/v1/analyze/:txid=20/5, /api/v1/labels=oops ,")
+
+	cfg := RateLimitConfigFromEnv()
+	if cfg.Default != (RateLimit{PerMinute: 60, Burst: 10}) {
+		t.Errorf("Expected the default override. Got %+v", cfg.Default)
+	}
+	if cfg.Heavy != DefaultRateLimitConfig().Heavy {
+		t.Errorf("Expected an invalid heavy value to keep the default. Got %+v", cfg.Heavy)
+	}
+	if len(cfg.Routes) != 1 || cfg.Routes["/api/v1/analyze/:txid"] != (RateLimit{PerMinute: 20, Burst: 5}) {
+		t.Errorf("Expected one valid route override. Got %+v", cfg.Routes)
+	}
+}
+
+func TestRouteRateLimiter_HeavyRoutesThrottledHarder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := DefaultRateLimitConfig()
+	cfg.Routes = map[string]RateLimit{"/api/v1/labels": {PerMinute: 60, Burst: 2}}
+
+	r := gin.New()
+	g := r.Group("/api/v1")
+	g.Use(NewRouteRateLimiter(cfg).Middleware())
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	g.POST("/scan", ok)
+	g.POST("/shadow/run", ok)
+	g.GET("/labels", ok)
+	g.GET("/investigation/:id", ok)
+
+	// Heavy routes share a burst of 1
+	w := serve(r, http.MethodPost, "/api/v1/scan", "")
+	if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "4" || w.Header().Get("X-RateLimit-Remaining") != "0" {
+		t.Fatalf("Expected the first scan allowed with heavy headers. Got %d %v", w.Code, w.Header())
+	}
+	w = serve(r, http.MethodPost, "/api/v1/shadow/run", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected the heavy budget shared across heavy routes. Got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "15" {
+		t.Errorf("Expected Retry-After of 15 seconds at 4/min. Got %q", got)
+	}
+	if env := decodeEnvelope(t, w); env.Error.Code != ErrCodeRateLimited {
+		t.Errorf("Expected rate_limited. Got %+v", env.Error)
+	}
+
+	// Light routes keep their own budgets
+	for i := 0; i < 5; i++ {
+		if w := serve(r, http.MethodGet, "/api/v1/investigation/case-1", ""); w.Code != http.StatusOK {
+			t.Fatalf("Request %d to a light route was throttled", i+1)
+		}
+	}
+	w = serve(r, http.MethodGet, "/api/v1/investigation/case-1", "")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("X-RateLimit-Limit") != "30" {
+		t.Errorf("Expected the default 30/min budget exhausted after its burst. Got %d %v", w.Code, w.Header())
+	}
+	if w := serve(r, http.MethodGet, "/api/v1/labels", ""); w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Limit") != "60" {
+		t.Errorf("Expected the per-route override. Got %d %v", w.Code, w.Header())
+	}
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/logging"
+)
+
+// ──────────────────────────────────────────────────────────────────
+// Request Correlation IDs
+//
+// Every request gets an ID: the caller's X-Request-ID when it is
+// well-formed (so a proxy or client can thread its own), otherwise a fresh
+// random one. The ID is echoed in the X-Request-ID response header and in
+// error envelopes, and carried in the request context so that
+// logging.FromContext tags the request's log records with request_id,
+// including the access log line written when the request completes.
+// ──────────────────────────────────────────────────────────────────
+
+// RequestIDHeader carries the correlation ID in requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 64
+
+// ctxRequestID holds the request's correlation ID in the Gin context
+const ctxRequestID = "requestID"
+
+// validRequestID accepts caller IDs of 1-64 characters from [A-Za-z0-9._:-],
+// keeping arbitrary header content out of the logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-' || r == '_' || r == '.' || r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID returns 16 random bytes, hex-encoded
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16) // Extremely unlikely
+	}
+	return hex.EncodeToString(b)
+}
+
+// RequestIDMiddleware assigns the correlation ID and logs each request on
+// completion (warn for 5xx)
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.Set(ctxRequestID, id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+
+		start := time.Now()
+		c.Next()
+
+		level := slog.LevelInfo
+		if c.Writer.Status() >= http.StatusInternalServerError {
+			level = slog.LevelWarn
+		}
+		path := c.FullPath()
+		if path == "" {
+			path = c.Request.URL.Path // Unmatched route
+		}
+		logging.FromContext(c.Request.Context(), "api").Log(c.Request.Context(), level, "request",
+			"method", c.Request.Method,
+			"path", path,
+			"uri", c.Request.URL.RequestURI(),
+			"status", c.Writer.Status(),
+			"duration_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP())
+	}
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/logging"
+)
+
+func TestValidRequestID(t *testing.T) {
+	for _, id := range []string{"abc123", "req-7f3a_x.y:z", strings.Repeat("a", maxRequestIDLength)} {
+		if !validRequestID(id) {
+			t.Errorf("Expected %q to be accepted", id)
+		}
+	}
+	for _, id := range []string{"", "has space", "inject\nline", strings.Repeat("a", maxRequestIDLength+1)} {
+		if validRequestID(id) {
+			t.Errorf("Expected %q to be rejected", id)
+		}
+	}
+}
+
+func TestRequestIDMiddleware_PropagatesToResponseAndLogs(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer slog.SetDefault(prev)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDMiddleware())
+	r.GET("/analyze/:txid", func(c *gin.Context) {
+		writeError(c, errUpstream("Failed to fetch tx from node", errors.New("connection refused")))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/analyze/abc", nil)
+	req.Header.Set(RequestIDHeader, "trace-42")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if got := w.Header().Get(RequestIDHeader); got != "trace-42" {
+		t.Errorf("Expected the caller's request ID echoed. Got %q", got)
+	}
+	var body struct {
+		RequestID string `json:"requestId"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.RequestID != "trace-42" {
+		t.Errorf("Expected requestId in the error envelope. Got %s", w.Body)
+	}
+
+	// Both the error record and the access log line carry the ID
+	var tagged int
+	for _, line := range bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n")) {
+		var rec map[string]any
+		if err := json.Unmarshal(line, &rec); err != nil {
+			t.Fatalf("Unparseable log line %q", line)
+		}
+		if rec["request_id"] == "trace-42" {
+			tagged++
+		}
+	}
+	if tagged != 2 {
+		t.Errorf("Expected 2 log records tagged with the request ID. Got %d in %s", tagged, buf.String())
+	}
+}
+
+func TestRequestIDMiddleware_GeneratesID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(RequestIDMiddleware())
+	var seen string
+	r.GET("/ping", func(c *gin.Context) {
+		seen = logging.RequestID(c.Request.Context())
+		c.Status(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set(RequestIDHeader, "bad id with spaces")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	got := w.Header().Get(RequestIDHeader)
+	if len(got) != 32 || got != seen {
+		t.Errorf("Expected a generated 32-char ID in header and context. Got header %q, context %q", got, seen)
+	}
+}
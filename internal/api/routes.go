@@ -5,18 +5,20 @@ import (
 	"crypto/rand"
 	"encoding/binary"
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
-	"github.com/btcsuite/btcd/btcutil"
 	"github.com/gin-gonic/gin"
 	"github.com/rawblock/coinjoin-engine/internal/bitcoin"
 	"github.com/rawblock/coinjoin-engine/internal/db"
 	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/internal/logging"
 	"github.com/rawblock/coinjoin-engine/internal/scanner"
 	"github.com/rawblock/coinjoin-engine/pkg/models"
 )
@@ -25,16 +27,6 @@ import (
 // runaway resource exhaustion from unconstrained requests.
 const maxScanBlocks int64 = 50_000
 
-// btcToSats converts a float64 BTC value to satoshis using btcutil.NewAmount
-// which performs correct IEEE-754 rounding instead of naive float multiplication.
-func btcToSats(btc float64) int64 {
-	amt, err := btcutil.NewAmount(btc)
-	if err != nil {
-		return 0
-	}
-	return int64(amt)
-}
-
 // cryptoRandFloat64 returns a cryptographically random float64 in [0, 1).
 func cryptoRandFloat64() float64 {
 	b := make([]byte, 8)
@@ -53,10 +45,19 @@ type APIHandler struct {
 	wsHub        *Hub
 	blockScanner *scanner.BlockScanner
 	invManager   *heuristics.InvestigationManager
+	mempoolStats MempoolStatsProvider     // nil when the poller is not running
+	alertMgr     *heuristics.AlertManager // nil when the poller is not running
+	stats        statsCache
+	analyzing    analyzeFlight   // Coalesces concurrent /analyze requests per txid
+	rootCtx      context.Context // Cancelled on shutdown; bounds background work such as scans
 }
 
-func SetupRouter(dbStore *db.PostgresStore, btcClient *bitcoin.Client, wsHub *Hub, blockScanner *scanner.BlockScanner) *gin.Engine {
-	r := gin.Default()
+// SetupRouter builds the API. ctx is the process root context: background
+// work started by requests (historical scans) is cancelled with it.
+func SetupRouter(ctx context.Context, dbStore *db.PostgresStore, btcClient *bitcoin.Client, wsHub *Hub, blockScanner *scanner.BlockScanner,
+	mempoolStats MempoolStatsProvider, alertMgr *heuristics.AlertManager) *gin.Engine {
+	r := gin.New()
+	r.Use(gin.Recovery(), RequestIDMiddleware())
 
 	// Enable CORS — configurable via ALLOWED_ORIGINS env var
 	// Production: ALLOWED_ORIGINS=https://rawblock.net,https://www.rawblock.net
@@ -76,8 +77,9 @@ func SetupRouter(dbStore *db.PostgresStore, btcClient *bitcoin.Client, wsHub *Hu
 			}
 		}
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With, X-Request-ID")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", RequestIDHeader)
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -86,12 +88,25 @@ func SetupRouter(dbStore *db.PostgresStore, btcClient *bitcoin.Client, wsHub *Hu
 		c.Next()
 	})
 
+	// Investigations survive restarts when a database is configured
+	invManager := heuristics.NewInvestigationManager()
+	if dbStore != nil {
+		if n, err := invManager.AttachStore(ctx, dbStore); err != nil {
+			log.Printf("Warning: failed to load persisted investigations: %v", err)
+		} else {
+			log.Printf("Loaded %d persisted investigations", n)
+		}
+	}
+
 	handler := &APIHandler{
 		dbStore:      dbStore,
 		btcClient:    btcClient,
 		wsHub:        wsHub,
 		blockScanner: blockScanner,
-		invManager:   heuristics.NewInvestigationManager(),
+		invManager:   invManager,
+		mempoolStats: mempoolStats,
+		alertMgr:     alertMgr,
+		rootCtx:      ctx,
 	}
 
 	// ── Public endpoints (no auth) ─────────────────────────────
@@ -101,31 +116,65 @@ func SetupRouter(dbStore *db.PostgresStore, btcClient *bitcoin.Client, wsHub *Hu
 		pub.GET("/stream", wsHub.Subscribe)
 		pub.GET("/mixers", handler.handleGetMixers)
 		pub.GET("/scan/progress", handler.handleScanProgress)
+		pub.GET("/blocks/summaries", handler.handleGetBlockSummaries)
+		pub.GET("/blocks/:height/summary", handler.handleGetBlockSummary)
 	}
 
 	// ── Protected endpoints (require bearer token if API_AUTH_TOKEN set) ──
 	auth := r.Group("/api/v1")
 	auth.Use(AuthMiddleware())
-	// Rate-limit protected endpoints to 30 req/min per IP (burst=5).
+	// Rate-limit protected endpoints per IP: 30 req/min (burst=5) by default,
+	// tighter for heavy routes (scans, traces, UTXO-set walks). See ratelimit_config.go.
 	// The /analyze/:txid endpoint performs O(n) RPC calls — especially important here.
-	auth.Use(NewRateLimiter(30, 5).Middleware())
+	auth.Use(NewRouteRateLimiter(CurrentRateLimitConfig()).Middleware())
 	{
 		auth.GET("/analyze/:txid", handler.handleAnalyzeTx)
+		auth.GET("/analysis/:txid", handler.handleGetAnalysis)
 		auth.POST("/cluster/evaluate", handler.handleEvaluateCluster)
+		auth.POST("/evidence/propagate", handler.handlePropagateEvidence)
+		auth.GET("/graph/:address", handler.handleGetEvidenceGraph)
+		auth.GET("/cluster/:address", handler.handleGetCluster)
+		auth.GET("/clusters/health", handler.handleGetClusterHealth)
+		auth.POST("/clusters/accuracy", handler.handleClusterAccuracy)
+		auth.GET("/peel-chains", handler.handleListPeelChains)
+		auth.GET("/peel-chains/:txid", handler.handleGetPeelChain)
+		auth.GET("/taint/:address", handler.handleGetAddressTaint)
+		auth.GET("/taint/tx/:txid", handler.handleGetTxTaint)
+		auth.POST("/watchlist/import", handler.handleImportWatchlist)
+		auth.POST("/labels", handler.handleImportLabels)
+		auth.GET("/labels", handler.handleListLabels)
+		auth.GET("/labels/:address", handler.handleGetLabel)
+		auth.DELETE("/labels/:address", handler.handleDeleteLabel)
+		auth.GET("/pools", handler.handleGetPoolTags)
+		auth.POST("/pools/reload", handler.handleReloadPoolTags)
+		auth.GET("/stats", handler.handleGetStats)
+		auth.GET("/stats/timeseries", handler.handleGetTimeSeries)
+		auth.GET("/alerts", handler.handleGetAlerts)
+		auth.GET("/webhooks", handler.handleListWebhooks)
+		auth.POST("/webhooks", handler.handleRegisterWebhook)
+		auth.DELETE("/webhooks/:name", handler.handleDeleteWebhook)
 
 		// Historical Block Scanner
 		auth.POST("/scan", handler.handleStartScan)
 
+		// Shadow mode: diff this build's heuristics against stored production results
+		auth.POST("/shadow/run", handler.handleShadowRun)
+
 		// ── Incident Response & Fund Tracking (Phase 18) ──────────
 		inv := auth.Group("/investigation")
 		{
 			inv.POST("", handler.handleCreateInvestigation)
+			inv.GET("", handler.handleListInvestigations)
 			inv.GET("/:id", handler.handleGetInvestigation)
+			inv.PATCH("/:id/status", handler.handleSetInvestigationStatus)
 			inv.POST("/:id/trace", handler.handleRunTrace)
 			inv.GET("/:id/graph", handler.handleGetFlowGraph)
+			inv.GET("/:id/entity-graph", handler.handleGetEntityGraph)
 			inv.POST("/:id/tag", handler.handleTagAddress)
 			inv.GET("/:id/timeline", handler.handleGetTimeline)
 			inv.GET("/:id/exits", handler.handleGetExchangeExits)
+			inv.GET("/:id/utxos", handler.handleGetCaseUTXOs)
+			inv.GET("/:id/export", handler.handleExportInvestigation)
 		}
 	}
 
@@ -138,21 +187,17 @@ func SetupRouter(dbStore *db.PostgresStore, btcClient *bitcoin.Client, wsHub *Hu
 func (h *APIHandler) handleAnalyzeTx(c *gin.Context) {
 	txid := c.Param("txid")
 
-	var tx models.Transaction
-
 	if txid == "whirlpool" || txid == "mix" {
 		// Synthetic modes are gated in production to prevent data poisoning
 		if !IsSyntheticEnabled() {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Synthetic transaction modes are disabled in production",
-				"hint":  "Set ENABLE_SYNTHETIC=true to enable test data generation",
-			})
+			writeError(c, newAPIError(http.StatusForbidden, ErrCodeForbidden, "Synthetic transaction modes are disabled in production").
+				With("hint", "Set ENABLE_SYNTHETIC=true to enable test data generation"))
 			return
 		}
 
 		// Generate a perfect 5x5 Whirlpool Mix.
 		// Uses crypto/rand so synthetic outputs are not predictable.
-		tx = models.Transaction{
+		tx := models.Transaction{
 			Txid:    txid,
 			Inputs:  make([]models.TxIn, 5),
 			Outputs: make([]models.TxOut, 5),
@@ -161,96 +206,119 @@ func (h *APIHandler) handleAnalyzeTx(c *gin.Context) {
 		for i := 0; i < 5; i++ {
 			// Random value in [0.06, 0.46) BTC converted with integer precision.
 			btcFrac := cryptoRandFloat64()*0.4 + 0.06
-			tx.Inputs[i] = models.TxIn{Value: btcToSats(btcFrac), Address: "bc1q_in"}
+			tx.Inputs[i] = models.TxIn{Value: models.BTCToSats(btcFrac), Address: "bc1q_in"}
 			tx.Outputs[i] = models.TxOut{Value: 5000000, Address: "bc1q_out"}
 		}
 
-	} else {
-		// Fetch Real Transaction from Bitcoin RPC
-		if h.btcClient == nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Bitcoin RPC not configured"})
-			return
-		}
+		h.analyzeAndPersist(c.Request.Context(), tx).write(c)
+		return
+	}
 
-		hash, err := chainhash.NewHashFromStr(txid)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid txid format"})
-			return
-		}
+	// Fetch Real Transaction from Bitcoin RPC
+	if h.btcClient == nil {
+		writeError(c, errUnavailable("Bitcoin RPC not configured"))
+		return
+	}
 
-		rawTx, err := h.btcClient.GetRawTransaction(hash)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tx from node", "details": err.Error()})
-			return
-		}
+	hash, err := chainhash.NewHashFromStr(txid)
+	if err != nil {
+		writeError(c, newAPIError(http.StatusBadRequest, ErrCodeInvalidTxid, "Invalid txid format"))
+		return
+	}
 
-		tx = models.Transaction{
-			Txid:      rawTx.Txid,
-			Inputs:    make([]models.TxIn, len(rawTx.Vin)),
-			Outputs:   make([]models.TxOut, len(rawTx.Vout)),
-			Weight:    int(rawTx.Weight),
-			Vsize:     int(rawTx.Vsize),
-			Version:   int32(rawTx.Version),
-			LockTime:  rawTx.LockTime,
-			BlockTime: rawTx.Blocktime,
-		}
+	// Concurrent requests for one txid share the fetch, analysis and DB writes
+	reply, _ := h.analyzing.do(hash.String(), func() analyzeReply { return h.fetchAndAnalyze(c.Request.Context(), hash) })
+	reply.write(c)
+}
 
-		// Calculate Fee: Sum(Inputs) - Sum(Outputs)
-		// Accumulated in float64 then converted once to minimise rounding.
-		var totalIn, totalOut float64
-
-		// Note: GetRawTransactionVerbose does not return input values directly (vin just has txid/vout).
-		// For true forensics we'd need to look up previous outputs.
-		// For testing the CUDA engine math, we'll try to fetch input values if needed,
-		// but since we are doing deep forensics, we MUST fetch prevouts.
-		for i, vin := range rawTx.Vin {
-			if vin.Txid == "" {
-				continue // Coinbase
-			}
+// fetchAndAnalyze fetches a transaction and its prevouts from the node,
+// then analyzes and persists it. ctx carries the leading request's ID for
+// logging; the work is not cancelled with it, as joined requests share it.
+func (h *APIHandler) fetchAndAnalyze(ctx context.Context, hash *chainhash.Hash) analyzeReply {
+	rawTx, err := h.btcClient.GetRawTransaction(hash)
+	if err != nil {
+		return analyzeReply{err: errUpstream("Failed to fetch tx from node", err)}
+	}
+	if apiErr := oversizedTxError(len(rawTx.Vin), len(rawTx.Vout)); apiErr != nil {
+		return analyzeReply{err: apiErr} // Before the per-input prevout lookups
+	}
 
-			// Fetch previous transaction to get the input value
-			prevHash, _ := chainhash.NewHashFromStr(vin.Txid)
-			prevTx, err := h.btcClient.GetRawTransaction(prevHash)
-			var inValue float64
-			var inAddr string
-			if err == nil && int(vin.Vout) < len(prevTx.Vout) {
-				inValue = prevTx.Vout[vin.Vout].Value
-				if len(prevTx.Vout[vin.Vout].ScriptPubKey.Addresses) > 0 {
-					inAddr = prevTx.Vout[vin.Vout].ScriptPubKey.Addresses[0]
-				}
-			}
+	tx := models.Transaction{
+		Txid:      rawTx.Txid,
+		Inputs:    make([]models.TxIn, len(rawTx.Vin)),
+		Outputs:   make([]models.TxOut, len(rawTx.Vout)),
+		Weight:    int(rawTx.Weight),
+		Vsize:     int(rawTx.Vsize),
+		Version:   int32(rawTx.Version),
+		LockTime:  rawTx.LockTime,
+		BlockTime: rawTx.Blocktime,
+	}
 
-			totalIn += inValue
-			scriptSigHex := ""
-			if vin.ScriptSig != nil {
-				scriptSigHex = vin.ScriptSig.Hex
-			}
-			tx.Inputs[i] = models.TxIn{
-				Txid:      vin.Txid,
-				Vout:      vin.Vout,
-				Value:     btcToSats(inValue), // integer-safe BTC→sat conversion
-				Address:   inAddr,
-				ScriptSig: scriptSigHex,
-				Sequence:  vin.Sequence,
-			}
+	// Calculate Fee: Sum(Inputs) - Sum(Outputs)
+	// Accumulated in float64 then converted once to minimise rounding.
+	var totalIn, totalOut int64
+
+	// Note: GetRawTransactionVerbose does not return input values directly (vin just has txid/vout).
+	// For true forensics we'd need to look up previous outputs.
+	// For testing the CUDA engine math, we'll try to fetch input values if needed,
+	// but since we are doing deep forensics, we MUST fetch prevouts.
+	// Chain tip anchors the prevouts' confirmation heights
+	tipHeight := 0
+	if count, err := h.btcClient.RPC.GetBlockCount(); err == nil {
+		tipHeight = int(count)
+	}
+
+	for i, vin := range rawTx.Vin {
+		if vin.Txid == "" {
+			continue // Coinbase
 		}
 
-		for i, vout := range rawTx.Vout {
-			totalOut += vout.Value
-			var outAddr string
-			if len(vout.ScriptPubKey.Addresses) > 0 {
-				outAddr = vout.ScriptPubKey.Addresses[0]
-			}
-			tx.Outputs[i] = models.TxOut{
-				Value:        btcToSats(vout.Value), // integer-safe BTC→sat conversion
-				Address:      outAddr,
-				ScriptPubKey: vout.ScriptPubKey.Hex,
-			}
+		// Fetch previous transaction to get the input value
+		prevHash, _ := chainhash.NewHashFromStr(vin.Txid)
+		prevTx, err := h.btcClient.GetRawTransaction(prevHash)
+		var inValue float64
+		var inAddr string
+		if err == nil && int(vin.Vout) < len(prevTx.Vout) {
+			inValue = prevTx.Vout[vin.Vout].Value
+			inAddr = bitcoin.ScriptAddress(prevTx.Vout[vin.Vout].ScriptPubKey)
 		}
 
-		tx.Fee = int64((totalIn - totalOut) * 100000000)
+		totalIn += models.BTCToSats(inValue)
+		scriptSigHex := ""
+		if vin.ScriptSig != nil {
+			scriptSigHex = vin.ScriptSig.Hex
+		}
+		tx.Inputs[i] = models.TxIn{
+			Txid:      vin.Txid,
+			Vout:      vin.Vout,
+			Value:     models.BTCToSats(inValue), // integer-safe BTC→sat conversion
+			Address:   inAddr,
+			ScriptSig: scriptSigHex,
+			Sequence:  vin.Sequence,
+		}
+		if err == nil {
+			tx.Inputs[i].PrevBlockHeight, tx.Inputs[i].PrevBlockTime = bitcoin.PrevoutConfirmation(prevTx, tipHeight)
+		}
 	}
 
+	for i, vout := range rawTx.Vout {
+		totalOut += models.BTCToSats(vout.Value)
+		outAddr := bitcoin.ScriptAddress(vout.ScriptPubKey)
+		tx.Outputs[i] = models.TxOut{
+			Value:        models.BTCToSats(vout.Value), // integer-safe BTC→sat conversion
+			Address:      outAddr,
+			ScriptPubKey: vout.ScriptPubKey.Hex,
+		}
+	}
+
+	tx.Fee = totalIn - totalOut
+
+	return h.analyzeAndPersist(ctx, tx)
+}
+
+// analyzeAndPersist runs the pipeline on tx and stores the result and
+// risk assessment when a database is connected
+func (h *APIHandler) analyzeAndPersist(ctx context.Context, tx models.Transaction) analyzeReply {
 	// 2. Run the Heuristics Engine Analysis
 	result := heuristics.AnalyzeTx(tx)
 	watchlistHits := heuristics.GetGlobalAddressWatchlist().CheckTransaction(tx)
@@ -266,8 +334,12 @@ func (h *APIHandler) handleAnalyzeTx(c *gin.Context) {
 				blockHeight = int(count)
 			}
 		}
-		if err := h.dbStore.SaveAnalysisResult(context.Background(), blockHeight, result); err != nil {
-			log.Printf("Failed to save analysis result to DB: %v", err)
+		detectedAt := tx.BlockTime
+		if detectedAt == 0 {
+			detectedAt = time.Now().Unix() // Unconfirmed or synthetic
+		}
+		if err := h.dbStore.SaveAnalysisResult(context.Background(), blockHeight, detectedAt, result); err != nil {
+			logging.FromContext(ctx, "api").Error("failed to save analysis result", "txid", tx.Txid, "err", err)
 		}
 
 		totalValue := int64(0)
@@ -281,39 +353,45 @@ func (h *APIHandler) handleAnalyzeTx(c *gin.Context) {
 		if err := h.dbStore.SaveRiskAssessment(context.Background(), blockHeight, tx.Txid,
 			assessment.RiskScore, riskLevel, result.PrivacyScore, result.HeuristicFlags,
 			taintLevel, len(tx.Inputs), len(tx.Outputs), totalValue); err != nil {
-			log.Printf("Failed to save risk assessment to DB: %v", err)
+			logging.FromContext(ctx, "api").Error("failed to save risk assessment", "txid", tx.Txid, "err", err)
 		}
 	}
 
 	// 4. Return JSON payload
-	c.JSON(http.StatusOK, gin.H{
+	return analyzeReply{status: http.StatusOK, body: gin.H{
 		"tx":               tx,
 		"analysis":         result,
 		"threatAssessment": assessment,
 		"watchlistHits":    watchlistHits,
-	})
+	}}
 }
 
 // handleEvaluateCluster accepts a set of evidence edges and runs factor-graph
-// inference to determine if clustering is warranted.
+// inference to determine if clustering is warranted. With an anchor address
+// it also returns per-address beliefs from loopy belief propagation.
 func (h *APIHandler) handleEvaluateCluster(c *gin.Context) {
 	var req struct {
-		Edges []models.EvidenceEdge `json:"edges"`
+		Edges  []models.EvidenceEdge `json:"edges"`
+		Anchor string                `json:"anchor"` // Optional: run loopy BP from this address
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		writeError(c, errBadRequest("Invalid request body"))
 		return
 	}
 
 	shouldCluster, posteriorLLR := heuristics.ComputeClusterPosterior(req.Edges)
 	inference := heuristics.EvaluateFactorGraph(req.Edges)
 
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"shouldCluster": shouldCluster,
 		"posteriorLLR":  posteriorLLR,
 		"inference":     inference,
-	})
+	}
+	if req.Anchor != "" {
+		resp["beliefs"] = heuristics.InferEntityBeliefs(req.Edges, req.Anchor)
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 // handleHealth returns engine status and capabilities for service discovery
@@ -337,9 +415,16 @@ func (h *APIHandler) handleHealth(c *gin.Context) {
 }
 
 // handleGetMixers returns the historically indexed WabiSabi and Whirlpool CoinJoin transactions.
+// GET /api/v1/mixers?page=1&limit=50&mixerType=Whirlpool&minAnonset=5&fromHeight=850000&toHeight=860000
 func (h *APIHandler) handleGetMixers(c *gin.Context) {
+	filter, err := parseMixerFilter(c)
+	if err != nil {
+		writeError(c, errBadRequest(err.Error()))
+		return
+	}
+
 	if h.dbStore == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Database not connected"})
+		writeError(c, errUnavailable("Database not connected"))
 		return
 	}
 
@@ -347,9 +432,9 @@ func (h *APIHandler) handleGetMixers(c *gin.Context) {
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
 
-	mixers, totalCount, err := h.dbStore.GetMixers(c.Request.Context(), page, limit)
+	mixers, totalCount, err := h.dbStore.GetMixers(c.Request.Context(), page, limit, filter)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch historical mixers", "details": err.Error()})
+		writeError(c, errInternal("Failed to fetch historical mixers", err))
 		return
 	}
 
@@ -361,35 +446,72 @@ func (h *APIHandler) handleGetMixers(c *gin.Context) {
 	})
 }
 
+// parseMixerFilter reads the optional /mixers filters
+func parseMixerFilter(c *gin.Context) (db.MixerFilter, error) {
+	var f db.MixerFilter
+	if t := c.Query("mixerType"); t != "" {
+		for _, valid := range db.MixerTypes() {
+			if strings.EqualFold(t, valid) {
+				f.MixerType = valid
+			}
+		}
+		if f.MixerType == "" {
+			return f, fmt.Errorf("invalid mixerType %q (want %s)", t, strings.Join(db.MixerTypes(), " or "))
+		}
+	}
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{
+		{"minAnonset", &f.MinAnonset},
+		{"fromHeight", &f.FromHeight},
+		{"toHeight", &f.ToHeight},
+	} {
+		raw := c.Query(p.name)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.Atoi(raw)
+		if err != nil || v < 0 {
+			return f, fmt.Errorf("invalid %s %q (want a non-negative integer)", p.name, raw)
+		}
+		*p.dst = v
+	}
+	if f.ToHeight > 0 && f.FromHeight > f.ToHeight {
+		return f, fmt.Errorf("fromHeight %d is above toHeight %d", f.FromHeight, f.ToHeight)
+	}
+	return f, nil
+}
+
 // handleStartScan launches a historical block scan in the background.
 // POST /api/v1/scan { "startHeight": 850000, "endHeight": 850100 }
 func (h *APIHandler) handleStartScan(c *gin.Context) {
 	if h.blockScanner == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Block scanner not initialized"})
+		writeError(c, errUnavailable("Block scanner not initialized"))
 		return
 	}
 
 	var req struct {
 		StartHeight int64 `json:"startHeight"`
 		EndHeight   int64 `json:"endHeight"`
+		Persist     *bool `json:"persist"`    // false = dry run (default true)
+		EmitAlerts  *bool `json:"emitAlerts"` // default true
 	}
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body. Expected: {startHeight, endHeight}"})
+		writeError(c, errBadRequest("Invalid request body. Expected: {startHeight, endHeight, persist?, emitAlerts?}"))
 		return
 	}
 
 	// Validate range
 	if req.StartHeight <= 0 || req.EndHeight <= 0 || req.StartHeight > req.EndHeight {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid block range"})
+		writeError(c, errBadRequest("Invalid block range"))
 		return
 	}
 	// Cap the range to prevent unbounded background resource consumption.
 	if req.EndHeight-req.StartHeight > maxScanBlocks {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":  "Block range too large",
-			"maxBlocks": maxScanBlocks,
-			"hint":   "Split into multiple smaller requests",
-		})
+		writeError(c, errBadRequest("Block range too large").
+			With("maxBlocks", maxScanBlocks).
+			With("hint", "Split into multiple smaller requests"))
 		return
 	}
 
@@ -402,22 +524,35 @@ func (h *APIHandler) handleStartScan(c *gin.Context) {
 		}
 	}
 
-	// Launch scan in background
-	ctx := context.Background()
-	h.blockScanner.ScanRange(ctx, req.StartHeight, req.EndHeight)
+	// Launch scan in background (outlives the request, stops on shutdown)
+	ctx := h.rootCtx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx = logging.WithRequestID(ctx, logging.RequestID(c.Request.Context()))
+	opts := scanner.DefaultScanOptions()
+	if req.Persist != nil {
+		opts.Persist = *req.Persist
+	}
+	if req.EmitAlerts != nil {
+		opts.EmitAlerts = *req.EmitAlerts
+	}
+	h.blockScanner.ScanRange(ctx, req.StartHeight, req.EndHeight, opts)
 
 	c.JSON(http.StatusOK, gin.H{
 		"status":      "scan_started",
 		"startHeight": req.StartHeight,
 		"endHeight":   req.EndHeight,
 		"totalBlocks": req.EndHeight - req.StartHeight + 1,
+		"persist":     opts.Persist,
+		"emitAlerts":  opts.EmitAlerts,
 	})
 }
 
 // handleScanProgress returns the current progress of the block scanner.
 func (h *APIHandler) handleScanProgress(c *gin.Context) {
 	if h.blockScanner == nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Block scanner not initialized"})
+		writeError(c, errUnavailable("Block scanner not initialized"))
 		return
 	}
 	progress := h.blockScanner.GetProgress()
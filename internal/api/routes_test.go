@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func queryContext(query string) *gin.Context {
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	return c
+}
+
+func TestParseMixerFilter(t *testing.T) {
+	f, err := parseMixerFilter(queryContext("mixerType=whirlpool&minAnonset=5&fromHeight=850000&toHeight=860000"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if f.MixerType != "Whirlpool" || f.MinAnonset != 5 || f.FromHeight != 850000 || f.ToHeight != 860000 {
+		t.Errorf("Unexpected filter %+v", f)
+	}
+
+	for _, query := range []string{
+		"mixerType=Tornado",
+		"minAnonset=-1",
+		"fromHeight=abc",
+		"fromHeight=860000&toHeight=850000",
+	} {
+		if _, err := parseMixerFilter(queryContext(query)); err == nil {
+			t.Errorf("Expected %q to be rejected", query)
+		}
+	}
+}
+
+func TestGetMixers_RejectsInvalidFilterBeforeDB(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/mixers", (&APIHandler{}).handleGetMixers)
+
+	if w := serve(r, http.MethodGet, "/mixers?mixerType=Tornado", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown mixer type. Got %d", w.Code)
+	}
+	if w := serve(r, http.MethodGet, "/mixers?mixerType=WabiSabi", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a database. Got %d", w.Code)
+	}
+}
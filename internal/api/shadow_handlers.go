@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/internal/shadow"
+)
+
+// maxShadowBlocks caps a synchronous shadow comparison (every stored tx
+// is re-fetched with its prevouts)
+const maxShadowBlocks = 100
+
+// handleShadowRun re-analyzes the stored production results of a block
+// range with this build's heuristics and returns the per-tx diff report.
+// POST /api/v1/shadow/run {"startHeight": N, "endHeight": M}
+func (h *APIHandler) handleShadowRun(c *gin.Context) {
+	if h.dbStore == nil || h.btcClient == nil {
+		writeError(c, errUnavailable("Shadow mode needs both the database and the Bitcoin node"))
+		return
+	}
+
+	var req struct {
+		StartHeight int `json:"startHeight"`
+		EndHeight   int `json:"endHeight"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, errBadRequest("Invalid request body. Expected: {startHeight, endHeight}"))
+		return
+	}
+	if req.StartHeight <= 0 || req.EndHeight <= 0 || req.StartHeight > req.EndHeight {
+		writeError(c, errBadRequest("Invalid block range"))
+		return
+	}
+	if req.EndHeight-req.StartHeight >= maxShadowBlocks {
+		writeError(c, errBadRequest("Block range too large").With("maxBlocks", maxShadowBlocks))
+		return
+	}
+
+	runner := shadow.NewShadowRunner(h.dbStore.GetPool(), heuristics.CurrentSnapshotID)
+	runner.SetBitcoinClient(h.btcClient)
+	report, err := runner.RunRange(c.Request.Context(), req.StartHeight, req.EndHeight)
+	if err != nil {
+		writeError(c, errInternal("Shadow run failed", err))
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
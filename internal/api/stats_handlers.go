@@ -0,0 +1,305 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/db"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/internal/scanner"
+)
+
+// ════════════════════════════════════════════════════════════════════
+// Dashboard Statistics API
+// ════════════════════════════════════════════════════════════════════
+
+// statsCacheTTL bounds how often /stats hits the database and node
+const statsCacheTTL = 5 * time.Second
+
+// statsAlertWindow is the lookback for recent alert counts
+const statsAlertWindow = 24 * time.Hour
+
+// MempoolStats is the mempool poller's latest observation
+type MempoolStats struct {
+	Size          int       `json:"size"`          // Transactions in the node mempool
+	FeeFloorSatVB float64   `json:"feeFloorSatVB"` // max(mempoolminfee, minrelaytxfee)
+	TotalAnalyzed int64     `json:"totalAnalyzed"` // Mempool txs analyzed since startup
+	LastPoll      time.Time `json:"lastPoll"`
+}
+
+// MempoolStatsProvider exposes live mempool observations (implemented by the poller)
+type MempoolStatsProvider interface {
+	MempoolStats() MempoolStats
+}
+
+// DashboardStats is the composite /stats response
+type DashboardStats struct {
+	Analysis       *db.DashboardStats    `json:"analysis"` // nil when the DB is unavailable
+	Mempool        *MempoolStats         `json:"mempool"`  // nil when the poller is not running
+	Scan           *scanner.ScanProgress `json:"scan"`     // nil when the scanner is not initialized
+	Investigations InvestigationStats    `json:"investigations"`
+	Alerts         AlertStats            `json:"alerts"`
+	Engine         EngineSnapshot        `json:"engine"`
+	GeneratedAt    time.Time             `json:"generatedAt"`
+}
+
+// InvestigationStats counts in-memory cases by status
+type InvestigationStats struct {
+	Total    int            `json:"total"`
+	Active   int            `json:"active"`
+	ByStatus map[string]int `json:"byStatus"`
+}
+
+// AlertStats counts alerts emitted in the lookback window by severity
+type AlertStats struct {
+	WindowHours int            `json:"windowHours"`
+	BySeverity  map[string]int `json:"bySeverity"`
+}
+
+// EngineSnapshot identifies the running heuristics version and solver configuration
+type EngineSnapshot struct {
+	SnapshotID  int                   `json:"snapshotId"`
+	SSMP        heuristics.SSMPConfig `json:"ssmp"`
+	DBConnected bool                  `json:"dbConnected"`
+}
+
+// Narrow views of the components /stats reads, so the aggregation can be
+// exercised without a database or node.
+type analysisStatsSource interface {
+	GetDashboardStats(ctx context.Context) (db.DashboardStats, error)
+}
+
+type scanProgressSource interface {
+	GetProgress() scanner.ScanProgress
+}
+
+type alertCountSource interface {
+	CountBySeverity(since time.Time) map[string]int
+}
+
+type investigationSource interface {
+	ListInvestigations() []*heuristics.Investigation
+}
+
+// statsSources holds the optional components; nil entries yield empty sections
+type statsSources struct {
+	store          analysisStatsSource
+	scanner        scanProgressSource
+	mempool        MempoolStatsProvider
+	alerts         alertCountSource
+	investigations investigationSource
+}
+
+// statsCache memoizes the last /stats response for statsCacheTTL
+type statsCache struct {
+	mu      sync.Mutex
+	stats   DashboardStats
+	expires time.Time
+}
+
+// collectDashboardStats aggregates all sections from the given sources
+func collectDashboardStats(ctx context.Context, src statsSources) DashboardStats {
+	now := time.Now()
+	stats := DashboardStats{
+		Investigations: InvestigationStats{ByStatus: make(map[string]int)},
+		Alerts: AlertStats{
+			WindowHours: int(statsAlertWindow.Hours()),
+			BySeverity:  make(map[string]int),
+		},
+		Engine: EngineSnapshot{
+			SnapshotID: heuristics.CurrentSnapshotID,
+			SSMP:       heuristics.CurrentSSMPConfig(),
+		},
+		GeneratedAt: now,
+	}
+
+	if src.store != nil {
+		stats.Engine.DBConnected = true
+		analysis, err := src.store.GetDashboardStats(ctx)
+		if err != nil {
+			log.Printf("[API] Stats aggregation failed: %v", err)
+		} else {
+			stats.Analysis = &analysis
+		}
+	}
+
+	if src.mempool != nil {
+		mempool := src.mempool.MempoolStats()
+		stats.Mempool = &mempool
+	}
+
+	if src.scanner != nil {
+		progress := src.scanner.GetProgress()
+		stats.Scan = &progress
+	}
+
+	if src.investigations != nil {
+		for _, inv := range src.investigations.ListInvestigations() {
+			status := inv.Snapshot().Status
+			stats.Investigations.Total++
+			stats.Investigations.ByStatus[status]++
+			if status == "active" {
+				stats.Investigations.Active++
+			}
+		}
+	}
+
+	if src.alerts != nil {
+		for severity, count := range src.alerts.CountBySeverity(now.Add(-statsAlertWindow)) {
+			stats.Alerts.BySeverity[severity] = count
+		}
+	}
+
+	return stats
+}
+
+// statsSources builds the source set from the handler's optional components
+func (h *APIHandler) statsSources() statsSources {
+	var src statsSources
+	if h.dbStore != nil {
+		src.store = h.dbStore
+	}
+	if h.blockScanner != nil {
+		src.scanner = h.blockScanner
+	}
+	if h.mempoolStats != nil {
+		src.mempool = h.mempoolStats
+	}
+	if h.alertMgr != nil {
+		src.alerts = h.alertMgr
+	}
+	if h.invManager != nil {
+		src.investigations = h.invManager
+	}
+	return src
+}
+
+// GET /api/v1/stats
+// Returns the operator overview: persisted analysis totals, mempool state,
+// scan progress, investigations, recent alerts, and the engine snapshot.
+func (h *APIHandler) handleGetStats(c *gin.Context) {
+	h.stats.mu.Lock()
+	defer h.stats.mu.Unlock()
+
+	if time.Now().Before(h.stats.expires) {
+		c.JSON(http.StatusOK, h.stats.stats)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), 5*time.Second)
+	defer cancel()
+
+	h.stats.stats = collectDashboardStats(ctx, h.statsSources())
+	h.stats.expires = time.Now().Add(statsCacheTTL)
+	c.JSON(http.StatusOK, h.stats.stats)
+}
+
+// Time-series range defaults and limits
+const (
+	defaultTimeSeriesDays  = 30
+	defaultTimeSeriesWeeks = 26
+	maxTimeSeriesRange     = 5 * 365 * 24 * time.Hour
+)
+
+// timeSeriesQuery is a validated /stats/timeseries request; To is exclusive
+type timeSeriesQuery struct {
+	Interval string
+	Metric   string
+	From     time.Time
+	To       time.Time
+}
+
+// parseTimeParam reads a YYYY-MM-DD date (UTC midnight) or unix seconds;
+// isDate reports the date form
+func parseTimeParam(raw string) (t time.Time, isDate bool, err error) {
+	if d, err := time.Parse(time.DateOnly, raw); err == nil {
+		return d, true, nil
+	}
+	secs, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || secs < 0 {
+		return time.Time{}, false, fmt.Errorf("want YYYY-MM-DD or unix seconds")
+	}
+	return time.Unix(secs, 0).UTC(), false, nil
+}
+
+// parseTimeSeriesQuery reads interval (day|week), metric (count|value)
+// and the optional from/to bounds; a to date includes that whole day
+func parseTimeSeriesQuery(c *gin.Context, now time.Time) (timeSeriesQuery, error) {
+	q := timeSeriesQuery{
+		Interval: c.DefaultQuery("interval", db.IntervalDay),
+		Metric:   c.DefaultQuery("metric", db.MetricCount),
+		To:       now.UTC(),
+	}
+	if q.Interval != db.IntervalDay && q.Interval != db.IntervalWeek {
+		return q, fmt.Errorf("invalid interval %q (want day or week)", q.Interval)
+	}
+	if q.Metric != db.MetricCount && q.Metric != db.MetricValue {
+		return q, fmt.Errorf("invalid metric %q (want count or value)", q.Metric)
+	}
+
+	if raw := c.Query("to"); raw != "" {
+		to, isDate, err := parseTimeParam(raw)
+		if err != nil {
+			return q, fmt.Errorf("invalid to %q: %v", raw, err)
+		}
+		if isDate {
+			to = to.AddDate(0, 0, 1)
+		}
+		q.To = to
+	}
+	if raw := c.Query("from"); raw != "" {
+		from, _, err := parseTimeParam(raw)
+		if err != nil {
+			return q, fmt.Errorf("invalid from %q: %v", raw, err)
+		}
+		q.From = from
+	} else if q.Interval == db.IntervalWeek {
+		q.From = q.To.AddDate(0, 0, -7*defaultTimeSeriesWeeks)
+	} else {
+		q.From = q.To.AddDate(0, 0, -defaultTimeSeriesDays)
+	}
+
+	if !q.From.Before(q.To) {
+		return q, fmt.Errorf("from must be before to")
+	}
+	if q.To.Sub(q.From) > maxTimeSeriesRange {
+		return q, fmt.Errorf("range exceeds %d days", int(maxTimeSeriesRange.Hours()/24))
+	}
+	return q, nil
+}
+
+// GET /api/v1/stats/timeseries?interval=day|week&metric=count|value&from=2024-01-01&to=2024-06-30
+// Returns CoinJoin activity per interval split by mixer type, dated by
+// block time (first-seen time for mempool detections).
+func (h *APIHandler) handleGetTimeSeries(c *gin.Context) {
+	q, err := parseTimeSeriesQuery(c, time.Now())
+	if err != nil {
+		writeError(c, errBadRequest(err.Error()))
+		return
+	}
+
+	if h.dbStore == nil {
+		writeError(c, errUnavailable("Database not connected"))
+		return
+	}
+
+	buckets, err := h.dbStore.GetCoinJoinTimeSeries(c.Request.Context(), q.Interval, q.Metric, q.From, q.To)
+	if err != nil {
+		writeError(c, errInternal("Failed to aggregate time series", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"interval": q.Interval,
+		"metric":   q.Metric,
+		"from":     q.From,
+		"to":       q.To,
+		"buckets":  buckets,
+	})
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/db"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/internal/scanner"
+)
+
+type fakeStatsStore struct {
+	stats db.DashboardStats
+	err   error
+}
+
+func (f fakeStatsStore) GetDashboardStats(ctx context.Context) (db.DashboardStats, error) {
+	return f.stats, f.err
+}
+
+type fakeScanner struct{ progress scanner.ScanProgress }
+
+func (f fakeScanner) GetProgress() scanner.ScanProgress { return f.progress }
+
+type fakeMempool struct{ stats MempoolStats }
+
+func (f fakeMempool) MempoolStats() MempoolStats { return f.stats }
+
+func TestCollectDashboardStats_AllSections(t *testing.T) {
+	invs := heuristics.NewInvestigationManager()
+	invs.CreateInvestigation("CASE-1", "Exchange hack", "", []string{"bc1qtheft"}, 1_000_000)
+	invs.CreateInvestigation("CASE-2", "Phishing", "", []string{"bc1qphish"}, 50_000).SetStatus("completed")
+
+	alerts := heuristics.NewAlertManager(nil)
+	alerts.EmitAlert(heuristics.Alert{Severity: "high", AlertType: "watchlist_hit", TxID: "tx1"})
+	alerts.EmitAlert(heuristics.Alert{Severity: "high", AlertType: "watchlist_hit", TxID: "tx2"})
+	alerts.EmitAlert(heuristics.Alert{Severity: "critical", AlertType: "compound"})
+	alerts.EmitAlert(heuristics.Alert{Severity: "medium", AlertType: "high_risk", Timestamp: time.Now().Add(-48 * time.Hour)})
+
+	src := statsSources{
+		store: fakeStatsStore{stats: db.DashboardStats{
+			TotalAnalyzed:   1200,
+			TotalCoinJoins:  40,
+			CoinJoinsByType: map[string]int64{"Whirlpool": 30, "WabiSabi": 10},
+			RiskLevels:      map[string]int64{"info": 1100, "high": 100},
+			DailyCoinJoins:  []db.DailyCoinJoins{{Day: "2024-07-01", Count: 40, ValueSats: 2_000_000_000}},
+		}},
+		scanner:        fakeScanner{progress: scanner.ScanProgress{IsRunning: true, CurrentHeight: 850_000}},
+		mempool:        fakeMempool{stats: MempoolStats{Size: 42_000, FeeFloorSatVB: 1.5}},
+		alerts:         alerts,
+		investigations: invs,
+	}
+
+	stats := collectDashboardStats(context.Background(), src)
+
+	if stats.Analysis == nil || stats.Analysis.CoinJoinsByType["Whirlpool"] != 30 {
+		t.Errorf("Expected analysis section from the store. Got %+v", stats.Analysis)
+	}
+	if stats.Analysis != nil && (stats.Analysis.RiskLevels["high"] != 100 || len(stats.Analysis.DailyCoinJoins) != 1) {
+		t.Errorf("Expected the risk histogram and daily trend from the store. Got %+v", stats.Analysis)
+	}
+	if stats.Mempool == nil || stats.Mempool.Size != 42_000 || stats.Mempool.FeeFloorSatVB != 1.5 {
+		t.Errorf("Expected mempool section from the poller. Got %+v", stats.Mempool)
+	}
+	if stats.Scan == nil || stats.Scan.CurrentHeight != 850_000 {
+		t.Errorf("Expected scan section from the scanner. Got %+v", stats.Scan)
+	}
+	if stats.Investigations.Total != 2 || stats.Investigations.Active != 1 || stats.Investigations.ByStatus["completed"] != 1 {
+		t.Errorf("Unexpected investigation stats: %+v", stats.Investigations)
+	}
+	if stats.Alerts.BySeverity["high"] != 2 || stats.Alerts.BySeverity["critical"] != 1 || stats.Alerts.BySeverity["medium"] != 0 {
+		t.Errorf("Expected only in-window alerts counted. Got %+v", stats.Alerts.BySeverity)
+	}
+	if stats.Engine.SnapshotID != heuristics.CurrentSnapshotID || !stats.Engine.DBConnected {
+		t.Errorf("Unexpected engine snapshot: %+v", stats.Engine)
+	}
+
+	raw, err := json.Marshal(stats)
+	if err != nil {
+		t.Fatalf("Failed to marshal stats: %v", err)
+	}
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &sections); err != nil {
+		t.Fatalf("Failed to decode stats: %v", err)
+	}
+	for _, key := range []string{"analysis", "mempool", "scan", "investigations", "alerts", "engine"} {
+		if string(sections[key]) == "" || string(sections[key]) == "null" {
+			t.Errorf("Expected %q section in the response", key)
+		}
+	}
+}
+
+func TestCollectDashboardStats_MissingComponents(t *testing.T) {
+	stats := collectDashboardStats(context.Background(), statsSources{
+		store: fakeStatsStore{err: errors.New("connection refused")},
+	})
+
+	if stats.Analysis != nil || stats.Mempool != nil || stats.Scan != nil {
+		t.Errorf("Expected unavailable sections to be nil. Got %+v", stats)
+	}
+	if stats.Engine.SnapshotID != heuristics.CurrentSnapshotID {
+		t.Errorf("Expected engine snapshot even without components. Got %+v", stats.Engine)
+	}
+}
+
+func TestParseTimeSeriesQuery(t *testing.T) {
+	now := time.Date(2024, 7, 15, 12, 0, 0, 0, time.UTC)
+
+	q, err := parseTimeSeriesQuery(queryContext(""), now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if q.Interval != db.IntervalDay || q.Metric != db.MetricCount || !q.To.Equal(now) || !q.From.Equal(now.AddDate(0, 0, -30)) {
+		t.Errorf("Expected the last 30 days of daily counts by default. Got %+v", q)
+	}
+
+	q, err = parseTimeSeriesQuery(queryContext("interval=week&metric=value&from=2024-01-01&to=2024-06-30"), now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !q.From.Equal(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)) || !q.To.Equal(time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected the to date to include its whole day. Got %v → %v", q.From, q.To)
+	}
+
+	q, err = parseTimeSeriesQuery(queryContext("from=1704067200&to=1704153600"), now)
+	if err != nil || q.To.Sub(q.From) != 24*time.Hour {
+		t.Errorf("Expected unix-second bounds to be used as-is. Got %+v (%v)", q, err)
+	}
+
+	for _, query := range []string{
+		"interval=month",
+		"metric=fees",
+		"from=yesterday",
+		"from=2024-06-30&to=2024-01-01",
+		"from=2010-01-01&to=2024-01-01",
+	} {
+		if _, err := parseTimeSeriesQuery(queryContext(query), now); err == nil {
+			t.Errorf("Expected %q to be rejected", query)
+		}
+	}
+}
+
+func TestGetTimeSeries_ValidatesBeforeDB(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/stats/timeseries", (&APIHandler{}).handleGetTimeSeries)
+
+	if w := serve(r, http.MethodGet, "/stats/timeseries?interval=hour", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an unknown interval. Got %d", w.Code)
+	}
+	if w := serve(r, http.MethodGet, "/stats/timeseries?interval=week", ""); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a database. Got %d", w.Code)
+	}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+)
+
+// ════════════════════════════════════════════════════════════════════
+// Taint / Risk API
+// ════════════════════════════════════════════════════════════════════
+
+// TaintLookup is the /taint/:address response
+type TaintLookup struct {
+	Address string                 `json:"address"`
+	Tainted bool                   `json:"tainted"` // Above the 1% exposure floor
+	Risk    heuristics.TaintResult `json:"risk"`
+}
+
+// TxTaintLookup is the /taint/tx/:txid response
+type TxTaintLookup struct {
+	Txid       string                 `json:"txid"`
+	Exposure   float64                `json:"exposure"`   // Value-weighted input taint (CheckInputsForTaint)
+	IsHighRisk bool                   `json:"isHighRisk"` // Would set FlagHighRisk
+	Risk       heuristics.TaintResult `json:"risk"`
+}
+
+// lookupTaint assesses an address against the global taint map
+func lookupTaint(addr string) TaintLookup {
+	risk := heuristics.GlobalTaintRisk(addr)
+	return TaintLookup{
+		Address: addr,
+		Tainted: risk.TaintedRatio > 0.01,
+		Risk:    risk,
+	}
+}
+
+// GET /api/v1/taint/:address
+// Returns the taint and risk breakdown of an address: score, level,
+// contributing sources, hops from the nearest seed and tainted ratio.
+func (h *APIHandler) handleGetAddressTaint(c *gin.Context) {
+	addr := heuristics.NormalizeAddress(c.Param("address"))
+	if addr == "" {
+		writeError(c, errBadRequest("Address is required"))
+		return
+	}
+
+	c.JSON(http.StatusOK, lookupTaint(addr))
+}
+
+// GET /api/v1/taint/tx/:txid
+// Returns the taint exposure of a transaction's inputs.
+func (h *APIHandler) handleGetTxTaint(c *gin.Context) {
+	if h.btcClient == nil {
+		writeError(c, errUnavailable("Bitcoin RPC not configured"))
+		return
+	}
+
+	hash, err := chainhash.NewHashFromStr(c.Param("txid"))
+	if err != nil {
+		writeError(c, errInvalidTxid("Invalid txid"))
+		return
+	}
+	raw, err := h.btcClient.GetRawTransaction(hash)
+	if err != nil {
+		writeError(c, errUpstream("Transaction not found", err))
+		return
+	}
+	tx, err := h.btcClient.TransactionFromRaw(raw, 0, raw.Blocktime, 0)
+	if err != nil {
+		writeError(c, errUpstream("Failed to resolve transaction inputs", err))
+		return
+	}
+
+	exposure, isHighRisk := heuristics.CheckInputsForTaint(tx)
+	c.JSON(http.StatusOK, TxTaintLookup{
+		Txid:       tx.Txid,
+		Exposure:   exposure,
+		IsHighRisk: isHighRisk,
+		Risk:       heuristics.GlobalTransactionTaintRisk(tx),
+	})
+}
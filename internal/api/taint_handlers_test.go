@@ -0,0 +1,58 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func taintRouter(h *APIHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/taint/:address", h.handleGetAddressTaint)
+	r.GET("/taint/tx/:txid", h.handleGetTxTaint)
+	return r
+}
+
+func TestTaintEndpoint_AddressBreakdown(t *testing.T) {
+	heuristics.SeedFromExternalIntel([]heuristics.TaintSource{{Address: "taint-api-theft", Category: "theft", TaintLevel: 1.0}})
+	heuristics.PropagateGlobalTaint(models.Transaction{
+		Inputs:  []models.TxIn{{Address: "taint-api-theft", Value: 100_000}},
+		Outputs: []models.TxOut{{Address: "taint-api-hop1", Value: 60_000}, {Address: "taint-api-other", Value: 39_000}},
+	}, false)
+	r := taintRouter(&APIHandler{})
+
+	var seed TaintLookup
+	w := serve(r, http.MethodGet, "/taint/taint-api-theft", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &seed); err != nil || w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 JSON. Got %d: %s", w.Code, w.Body.String())
+	}
+	if !seed.Tainted || seed.Risk.RiskLevel != "critical" || len(seed.Risk.TaintSources) != 1 || seed.Risk.TaintSources[0].Category != "theft" {
+		t.Errorf("Expected a critical seed listing itself as a theft source. Got %+v", seed)
+	}
+
+	var hop TaintLookup
+	w = serve(r, http.MethodGet, "/taint/taint-api-hop1", "")
+	_ = json.Unmarshal(w.Body.Bytes(), &hop)
+	if !hop.Tainted || hop.Risk.HopsFromSource != 1 || hop.Risk.TaintedRatio <= 0.5 {
+		t.Errorf("Expected a tainted address one hop out. Got %+v", hop)
+	}
+
+	var clean TaintLookup
+	w = serve(r, http.MethodGet, "/taint/taint-api-unseen", "")
+	_ = json.Unmarshal(w.Body.Bytes(), &clean)
+	if clean.Tainted || clean.Risk.RiskLevel != "clean" {
+		t.Errorf("Expected an unseen address to be clean. Got %+v", clean)
+	}
+}
+
+func TestTaintEndpoint_TxRequiresRPC(t *testing.T) {
+	w := serve(taintRouter(&APIHandler{}), http.MethodGet, "/taint/tx/00", "")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 without a Bitcoin RPC client. Got %d", w.Code)
+	}
+}
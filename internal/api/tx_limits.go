@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ──────────────────────────────────────────────────────────────────
+// Transaction Size Limits
+//
+// Analysis endpoints must not let a caller pick the work: every input
+// costs a prevout RPC lookup, and the solvers allocate per input and
+// output. Transactions above the caps are refused with 413 before any
+// of that work starts. Txs at or below the caps still reach the
+// solvers' structural fallbacks (MitM/CP-SAT budgets), never exact
+// enumeration.
+//
+// Configured via API_MAX_TX_INPUTS / API_MAX_TX_OUTPUTS. The defaults
+// cover every standard transaction (400k weight ≈ 2,400 P2WPKH inputs).
+// ──────────────────────────────────────────────────────────────────
+
+const (
+	defaultMaxTxInputs  = 5000
+	defaultMaxTxOutputs = 5000
+)
+
+var (
+	maxTxInputs  atomic.Int64
+	maxTxOutputs atomic.Int64
+)
+
+func init() {
+	maxTxInputs.Store(defaultMaxTxInputs)
+	maxTxOutputs.Store(defaultMaxTxOutputs)
+}
+
+// SetTxSizeLimits sets the largest input/output counts the analysis
+// endpoints accept. Non-positive values keep the current limit.
+func SetTxSizeLimits(inputs, outputs int) {
+	if inputs > 0 {
+		maxTxInputs.Store(int64(inputs))
+	}
+	if outputs > 0 {
+		maxTxOutputs.Store(int64(outputs))
+	}
+}
+
+// TxSizeLimits returns the current input and output caps
+func TxSizeLimits() (inputs, outputs int) {
+	return int(maxTxInputs.Load()), int(maxTxOutputs.Load())
+}
+
+// rejectOversizedTx writes 413 and returns true when a transaction exceeds
+// the configured input/output caps
+func rejectOversizedTx(c *gin.Context, numInputs, numOutputs int) bool {
+	if err := oversizedTxError(numInputs, numOutputs); err != nil {
+		writeError(c, err)
+		return true
+	}
+	return false
+}
+
+// oversizedTxError returns the 413 error when a transaction exceeds the
+// configured input/output caps, nil otherwise
+func oversizedTxError(numInputs, numOutputs int) *APIError {
+	maxIn, maxOut := TxSizeLimits()
+	if numInputs <= maxIn && numOutputs <= maxOut {
+		return nil
+	}
+	return newAPIError(http.StatusRequestEntityTooLarge, ErrCodePayloadTooLarge, "Transaction exceeds the analysis size limit").
+		With("numInputs", numInputs).
+		With("numOutputs", numOutputs).
+		With("maxInputs", maxIn).
+		With("maxOutputs", maxOut)
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func TestRejectOversizedTx_Returns413(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	SetTxSizeLimits(100, 50)
+	defer SetTxSizeLimits(defaultMaxTxInputs, defaultMaxTxOutputs)
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	if !rejectOversizedTx(c, 101, 2) {
+		t.Fatalf("Expected 101 inputs to exceed the 100-input cap")
+	}
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413. Got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	if !rejectOversizedTx(c, 2, 51) || w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 51 outputs to be rejected with 413. Got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	c, _ = gin.CreateTestContext(w)
+	if rejectOversizedTx(c, 100, 50) {
+		t.Errorf("A transaction at the caps must be accepted")
+	}
+}
+
+func TestAnalyzeTx_AtCapReachesSolverFallbacks(t *testing.T) {
+	maxIn, maxOut := TxSizeLimits()
+	tx := models.Transaction{Txid: "at_cap"}
+	for i := 0; i < maxIn; i++ {
+		tx.Inputs = append(tx.Inputs, models.TxIn{Address: fmt.Sprintf("bc1qin%d", i), Value: int64(100_000 + i)})
+	}
+	for i := 0; i < maxOut; i++ {
+		tx.Outputs = append(tx.Outputs, models.TxOut{Address: fmt.Sprintf("bc1qout%d", i), Value: 90_000})
+	}
+
+	start := time.Now()
+	heuristics.AnalyzeTx(tx)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the budgeted fallbacks for a %dx%d tx, took %v", maxIn, maxOut, elapsed)
+	}
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func watchlistRouter(h *APIHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/watchlist/import", h.handleImportWatchlist)
+	return r
+}
+
+func TestWatchlistImport_SanctionedHit(t *testing.T) {
+	r := watchlistRouter(&APIHandler{})
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, _ := form.CreateFormFile("file", "sdn_xbt.txt")
+	part.Write([]byte("# OFAC XBT\n1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2\nbc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq\n"))
+	form.Close()
+	req := httptest.NewRequest(http.MethodPost, "/watchlist/import?label=OFAC+SDN+2026-10", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200. Got %d: %s", w.Code, w.Body.String())
+	}
+	var res WatchlistImportResponse
+	_ = json.Unmarshal(w.Body.Bytes(), &res)
+	if res.Parsed != 2 || res.Added+res.Duplicates != 2 || res.Label != "OFAC SDN 2026-10" {
+		t.Errorf("Expected both addresses imported. Got %+v", res)
+	}
+
+	hits := heuristics.GetGlobalAddressWatchlist().CheckTransaction(models.Transaction{
+		Inputs:  []models.TxIn{{Address: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", Value: 10_000}},
+		Outputs: []models.TxOut{{Address: "bc1qelsewhere", Value: 9_000}},
+	})
+	if len(hits) != 1 || hits[0].Category != "sanctioned" || hits[0].Direction != "input" {
+		t.Errorf("Expected a sanctioned input hit. Got %+v", hits)
+	}
+
+	// Raw body, same list: deduplicated
+	w = serve(r, http.MethodPost, "/watchlist/import", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2\n")
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected 200 for a raw body. Got %d", w.Code)
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &res)
+	if res.Added != 0 || res.Duplicates != 1 {
+		t.Errorf("Expected the re-upload deduplicated. Got %+v", res)
+	}
+
+	if w := serve(r, http.MethodPost, "/watchlist/import", "0x098B716B8Aaf21512996dC57EB0615e2383E2f96\n"); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a list without Bitcoin addresses. Got %d", w.Code)
+	}
+}
@@ -0,0 +1,177 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+)
+
+// ════════════════════════════════════════════════════════════════════
+// Webhook Management API
+// ════════════════════════════════════════════════════════════════════
+
+const maxWebhookNameLen = 64
+
+// webhookRequest is the POST /webhooks body
+type webhookRequest struct {
+	Name        string            `json:"name"`
+	URL         string            `json:"url"`
+	MinSeverity string            `json:"minSeverity"` // Defaults to "high"
+	Headers     map[string]string `json:"headers"`
+	Format      string            `json:"format"`     // raw/slack/discord/pagerduty (default raw)
+	RoutingKey  string            `json:"routingKey"` // Required for pagerduty
+	Ping        bool              `json:"ping"`       // Send a test alert after registering
+}
+
+// webhookView is a registered webhook as returned by the API. Header
+// values often carry credentials, so only their names are exposed. Slack
+// and Discord incoming webhooks embed the secret in the URL path, so the
+// URL is cut to scheme and host plus a fingerprint of the full URL.
+type webhookView struct {
+	Name           string   `json:"name"`
+	URL            string   `json:"url"`            // scheme://host/… (path and query redacted)
+	URLFingerprint string   `json:"urlFingerprint"` // First 8 hex chars of SHA-256(URL)
+	Enabled        bool     `json:"enabled"`
+	MinSeverity    string   `json:"minSeverity"`
+	Format         string   `json:"format"`
+	HeaderNames    []string `json:"headerNames"`
+
+	ConsecutiveFailures int `json:"consecutiveFailures"`
+}
+
+// validateWebhookRequest normalizes and checks a registration request
+func validateWebhookRequest(req *webhookRequest) error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > maxWebhookNameLen {
+		return fmt.Errorf("name is required (max %d characters)", maxWebhookNameLen)
+	}
+
+	u, err := url.Parse(strings.TrimSpace(req.URL))
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("url must be an absolute http(s) URL")
+	}
+	req.URL = u.String()
+
+	if req.MinSeverity == "" {
+		req.MinSeverity = "high"
+	}
+	if !heuristics.IsValidSeverity(req.MinSeverity) {
+		return fmt.Errorf("minSeverity must be one of info, low, medium, high, critical")
+	}
+
+	if req.Format == "" {
+		req.Format = heuristics.WebhookFormatRaw
+	}
+	if !heuristics.IsValidWebhookFormat(req.Format) {
+		return fmt.Errorf("format must be one of raw, slack, discord, pagerduty")
+	}
+	if req.Format == heuristics.WebhookFormatPagerDuty && strings.TrimSpace(req.RoutingKey) == "" {
+		return fmt.Errorf("routingKey is required for the pagerduty format")
+	}
+	return nil
+}
+
+func toWebhookView(wh heuristics.WebhookEndpoint) webhookView {
+	view := webhookView{
+		Name:        wh.Name,
+		Enabled:     wh.Enabled,
+		MinSeverity: wh.MinSeverity,
+		Format:      wh.Format,
+		HeaderNames: make([]string, 0, len(wh.Headers)),
+
+		ConsecutiveFailures: wh.ConsecutiveFailures,
+	}
+	view.URL, view.URLFingerprint = redactWebhookURL(wh.URL)
+	for name := range wh.Headers {
+		view.HeaderNames = append(view.HeaderNames, name)
+	}
+	return view
+}
+
+// redactWebhookURL keeps the scheme and host of a webhook URL and returns
+// a short fingerprint of the full URL
+func redactWebhookURL(raw string) (string, string) {
+	sum := sha256.Sum256([]byte(raw))
+	fingerprint := hex.EncodeToString(sum[:4])
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return "", fingerprint
+	}
+	redacted := u.Scheme + "://" + u.Host
+	if (u.Path != "" && u.Path != "/") || u.RawQuery != "" {
+		redacted += "/…"
+	}
+	return redacted, fingerprint
+}
+
+// POST /api/v1/webhooks { "name": "slack-oncall", "url": "https://hooks.slack.com/...", "minSeverity": "high", "format": "slack" }
+// Registers (or replaces) a webhook on the poller's alert manager.
+func (h *APIHandler) handleRegisterWebhook(c *gin.Context) {
+	if h.alertMgr == nil {
+		writeError(c, errUnavailable("Alert manager not running"))
+		return
+	}
+
+	var req webhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, errBadRequest("Invalid request body. Expected: {name, url, minSeverity, headers, format}"))
+		return
+	}
+	if err := validateWebhookRequest(&req); err != nil {
+		writeError(c, errBadRequest(err.Error()))
+		return
+	}
+
+	wh := heuristics.WebhookEndpoint{
+		Name:        req.Name,
+		URL:         req.URL,
+		Enabled:     true,
+		Headers:     req.Headers,
+		MinSeverity: req.MinSeverity,
+		Format:      req.Format,
+		RoutingKey:  strings.TrimSpace(req.RoutingKey),
+	}
+	h.alertMgr.RegisterWebhookEndpoint(wh)
+	if req.Ping {
+		h.alertMgr.PingWebhook(req.Name)
+	}
+
+	c.JSON(http.StatusCreated, toWebhookView(wh))
+}
+
+// GET /api/v1/webhooks
+func (h *APIHandler) handleListWebhooks(c *gin.Context) {
+	if h.alertMgr == nil {
+		writeError(c, errUnavailable("Alert manager not running"))
+		return
+	}
+
+	webhooks := h.alertMgr.ListWebhooks()
+	views := make([]webhookView, 0, len(webhooks))
+	for _, wh := range webhooks {
+		views = append(views, toWebhookView(wh))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": views})
+}
+
+// DELETE /api/v1/webhooks/:name
+func (h *APIHandler) handleDeleteWebhook(c *gin.Context) {
+	if h.alertMgr == nil {
+		writeError(c, errUnavailable("Alert manager not running"))
+		return
+	}
+
+	name := c.Param("name")
+	if !h.alertMgr.RemoveWebhook(name) {
+		writeError(c, errNotFound("Webhook not found").With("name", name))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "removed", "name": name})
+}
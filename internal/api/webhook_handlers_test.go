@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+)
+
+func webhookRouter(h *APIHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/webhooks", h.handleListWebhooks)
+	r.POST("/webhooks", h.handleRegisterWebhook)
+	r.DELETE("/webhooks/:name", h.handleDeleteWebhook)
+	return r
+}
+
+func serve(r *gin.Engine, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w
+}
+
+func TestWebhookEndpoints_RegisterReceiveDelete(t *testing.T) {
+	received := make(chan heuristics.Alert, 4)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert heuristics.Alert
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &alert)
+		received <- alert
+	}))
+	defer receiver.Close()
+
+	alerts := heuristics.NewAlertManager(nil)
+	r := webhookRouter(&APIHandler{alertMgr: alerts})
+
+	w := serve(r, http.MethodPost, "/webhooks",
+		`{"name":"slack-oncall","url":"`+receiver.URL+`","minSeverity":"high","headers":{"Authorization":"Bearer secret"}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201. Got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = serve(r, http.MethodGet, "/webhooks", "")
+	if !strings.Contains(w.Body.String(), `"slack-oncall"`) || strings.Contains(w.Body.String(), "secret") {
+		t.Errorf("Expected the webhook listed without header values. Got %s", w.Body.String())
+	}
+
+	alerts.EmitAlert(heuristics.Alert{Severity: "medium", AlertType: "high_risk", TxID: "below"})
+	alerts.EmitAlert(heuristics.Alert{Severity: "critical", AlertType: "compound", TxID: "above"})
+	select {
+	case got := <-received:
+		if got.TxID != "above" {
+			t.Errorf("Expected only the critical alert delivered. Got %+v", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the critical alert to reach the webhook")
+	}
+
+	if w := serve(r, http.MethodDelete, "/webhooks/slack-oncall", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected 200 on delete. Got %d", w.Code)
+	}
+	if w := serve(r, http.MethodDelete, "/webhooks/slack-oncall", ""); w.Code != http.StatusNotFound {
+		t.Errorf("Expected 404 for a removed webhook. Got %d", w.Code)
+	}
+	if len(alerts.ListWebhooks()) != 0 {
+		t.Errorf("Expected no webhooks left. Got %+v", alerts.ListWebhooks())
+	}
+}
+
+func TestWebhookEndpoints_RedactURL(t *testing.T) {
+	const secretURL = "https://hooks.slack.com/services/T000/B000/XXXXSECRET?token=abc"
+	r := webhookRouter(&APIHandler{alertMgr: heuristics.NewAlertManager(nil)})
+
+	w := serve(r, http.MethodPost, "/webhooks", `{"name":"slack","url":"`+secretURL+`","format":"slack"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected 201. Got %d: %s", w.Code, w.Body.String())
+	}
+	created := w.Body.String()
+	listed := serve(r, http.MethodGet, "/webhooks", "").Body.String()
+
+	for _, body := range []string{created, listed} {
+		if strings.Contains(body, "XXXXSECRET") || strings.Contains(body, "token") {
+			t.Errorf("Expected the URL path and query redacted. Got %s", body)
+		}
+		if !strings.Contains(body, `"url":"https://hooks.slack.com/…"`) {
+			t.Errorf("Expected the scheme and host kept. Got %s", body)
+		}
+	}
+
+	_, fp := redactWebhookURL(secretURL)
+	if _, other := redactWebhookURL("https://hooks.slack.com/services/T000/B000/OTHER"); fp == other || !strings.Contains(listed, fp) {
+		t.Errorf("Expected a per-URL fingerprint in the listing. Got %q vs %q in %s", fp, other, listed)
+	}
+}
+
+func TestWebhookEndpoints_Validation(t *testing.T) {
+	r := webhookRouter(&APIHandler{alertMgr: heuristics.NewAlertManager(nil)})
+
+	for _, body := range []string{
+		`{"name":"","url":"https://hooks.example.com/x"}`,
+		`{"name":"a","url":"ftp://hooks.example.com/x"}`,
+		`{"name":"a","url":"/relative/path"}`,
+		`{"name":"a","url":"https://hooks.example.com/x","minSeverity":"urgent"}`,
+		`{"name":"a","url":"https://hooks.example.com/x","format":"teams"}`,
+		`{"name":"a","url":"https://events.pagerduty.com/v2/enqueue","format":"pagerduty"}`,
+		`not json`,
+	} {
+		if w := serve(r, http.MethodPost, "/webhooks", body); w.Code != http.StatusBadRequest {
+			t.Errorf("Expected 400 for %s. Got %d", body, w.Code)
+		}
+	}
+
+	req := webhookRequest{Name: " pager ", URL: "https://events.example.com/v2"}
+	if err := validateWebhookRequest(&req); err != nil || req.Name != "pager" || req.MinSeverity != "high" || req.Format != "raw" {
+		t.Errorf("Expected trimmed name and default high severity / raw format. Got %+v (%v)", req, err)
+	}
+}
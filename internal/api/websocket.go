@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"log"
 	"net/http"
 	"os"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
 )
 
 // wsAllowedOrigins is parsed once at startup from ALLOWED_ORIGINS.
@@ -47,22 +49,84 @@ var upgrader = websocket.Upgrader{
 
 // Hub maintains the set of active websocket clients and broadcasts messages.
 type Hub struct {
-	clients   map[*websocket.Conn]bool
+	clients   map[*websocket.Conn]streamFilter
 	broadcast chan []byte
 	mutex     sync.Mutex
 }
 
+// streamFilter is a client's subscription: messages below minSeverity or
+// outside types are not forwarded
+type streamFilter struct {
+	minSeverity string          // "" = every severity
+	types       map[string]bool // nil = every message type
+}
+
+// streamMessage is the routing metadata of a broadcast payload. Payloads
+// without a "type" are the per-tx mempool stream ("tx"); payloads without
+// an alert severity (the tx stream, coinjoin_alert) count as info.
+type streamMessage struct {
+	Type  string `json:"type"`
+	Alert struct {
+		Severity string `json:"severity"`
+	} `json:"alert"`
+}
+
+func parseStreamMessage(data []byte) (msgType, severity string) {
+	var msg streamMessage
+	_ = json.Unmarshal(data, &msg)
+	msgType, severity = msg.Type, msg.Alert.Severity
+	if msgType == "" {
+		msgType = "tx"
+	}
+	if severity == "" {
+		severity = "info"
+	}
+	return msgType, severity
+}
+
+// matches reports whether a message of the given type and severity passes the filter
+func (f streamFilter) matches(msgType, severity string) bool {
+	if f.types != nil && !f.types[msgType] {
+		return false
+	}
+	return f.minSeverity == "" || heuristics.SeverityAtLeast(severity, f.minSeverity)
+}
+
+// parseStreamFilter reads ?minSeverity=high&types=coinjoin_alert,security_alert
+func parseStreamFilter(c *gin.Context) (streamFilter, bool) {
+	var f streamFilter
+	if minSeverity := c.Query("minSeverity"); minSeverity != "" {
+		if !heuristics.IsValidSeverity(minSeverity) {
+			return f, false
+		}
+		f.minSeverity = minSeverity
+	}
+	if raw := c.Query("types"); raw != "" {
+		f.types = make(map[string]bool)
+		for _, t := range strings.Split(raw, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				f.types[t] = true
+			}
+		}
+	}
+	return f, true
+}
+
 func NewHub() *Hub {
 	return &Hub{
 		broadcast: make(chan []byte, 256),
-		clients:   make(map[*websocket.Conn]bool),
+		clients:   make(map[*websocket.Conn]streamFilter),
 	}
 }
 
 func (h *Hub) Run() {
 	for message := range h.broadcast {
+		msgType, severity := parseStreamMessage(message)
 		h.mutex.Lock()
-		for client := range h.clients {
+		for client, filter := range h.clients {
+			if !filter.matches(msgType, severity) {
+				continue
+			}
 			// Set write deadline to prevent blocked clients from hanging the hub
 			_ = client.SetWriteDeadline(time.Now().Add(5 * time.Second))
 			err := client.WriteMessage(websocket.TextMessage, message)
@@ -77,7 +141,14 @@ func (h *Hub) Run() {
 }
 
 // Subscribe handles incoming websocket connections
+// (?minSeverity= and ?types= narrow what the client receives)
 func (h *Hub) Subscribe(c *gin.Context) {
+	filter, ok := parseStreamFilter(c)
+	if !ok {
+		writeError(c, errBadRequest("minSeverity must be one of info, low, medium, high, critical"))
+		return
+	}
+
 	conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
 	if err != nil {
 		log.Printf("Failed to upgrade websocket: %v", err)
@@ -85,7 +156,7 @@ func (h *Hub) Subscribe(c *gin.Context) {
 	}
 
 	h.mutex.Lock()
-	h.clients[conn] = true
+	h.clients[conn] = filter
 	h.mutex.Unlock()
 
 	log.Printf("New WebSocket client connected. Total clients: %d", len(h.clients))
@@ -111,6 +182,19 @@ func (h *Hub) Subscribe(c *gin.Context) {
 	}()
 }
 
+// Close disconnects every client with a going-away close frame (shutdown)
+func (h *Hub) Close() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	closing := websocket.FormatCloseMessage(websocket.CloseGoingAway, "server shutting down")
+	for client := range h.clients {
+		_ = client.WriteControl(websocket.CloseMessage, closing, time.Now().Add(time.Second))
+		client.Close()
+		delete(h.clients, client)
+	}
+}
+
 // Broadcast sends JSON data to all connected clients
 func (h *Hub) Broadcast(data []byte) {
 	h.broadcast <- data
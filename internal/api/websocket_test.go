@@ -0,0 +1,126 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// waitForClients waits until the hub has registered n clients
+func waitForClients(t *testing.T, hub *Hub, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		hub.mutex.Lock()
+		registered := len(hub.clients)
+		hub.mutex.Unlock()
+		if registered == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected %d registered clients. Got %d", n, registered)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStreamFilter_Matches(t *testing.T) {
+	high := streamFilter{minSeverity: "high"}
+	cases := []struct {
+		payload string
+		want    bool
+	}{
+		{`{"type":"security_alert","alert":{"severity":"critical"}}`, true},
+		{`{"type":"security_alert","alert":{"severity":"high"}}`, true},
+		{`{"type":"security_alert","alert":{"severity":"medium"}}`, false},
+		{`{"type":"coinjoin_alert","alert":{"txid":"abc"}}`, false}, // No severity = info
+		{`{"txid":"abc","privacyScore":40}`, false},                 // Mempool tx stream
+	}
+	for _, tc := range cases {
+		msgType, severity := parseStreamMessage([]byte(tc.payload))
+		if got := high.matches(msgType, severity); got != tc.want {
+			t.Errorf("%s: expected %v, got %v", tc.payload, tc.want, got)
+		}
+	}
+
+	types := streamFilter{types: map[string]bool{"coinjoin_alert": true, "tx": true}}
+	for payload, want := range map[string]bool{
+		`{"type":"coinjoin_alert","alert":{}}`:                      true,
+		`{"txid":"abc"}`:                                            true,
+		`{"type":"security_alert","alert":{"severity":"critical"}}`: false,
+	} {
+		if got := types.matches(parseStreamMessage([]byte(payload))); got != want {
+			t.Errorf("%s: expected %v, got %v", payload, want, got)
+		}
+	}
+}
+
+func TestHubSubscribe_MinSeverityNeverReceivesInfo(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/stream", hub.Subscribe)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	if w := serve(r, http.MethodGet, "/stream?minSeverity=urgent", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an invalid minSeverity. Got %d", w.Code)
+	}
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/stream?minSeverity=high"
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	// Wait for the hub to register the client before broadcasting
+	waitForClients(t, hub, 1)
+
+	hub.Broadcast([]byte(`{"txid":"info-tx"}`))
+	hub.Broadcast([]byte(`{"type":"security_alert","alert":{"severity":"info","txid":"info-alert"}}`))
+	hub.Broadcast([]byte(`{"type":"security_alert","alert":{"severity":"high","txid":"high-alert"}}`))
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, msg, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !strings.Contains(string(msg), "high-alert") {
+		t.Errorf("Expected the first forwarded message to be the high alert. Got %s", msg)
+	}
+}
+
+func TestHubClose_DisconnectsClients(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/stream", hub.Subscribe)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/stream", nil)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	waitForClients(t, hub, 1)
+
+	hub.Close()
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, _, err = conn.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.CloseGoingAway) {
+		t.Errorf("Expected a going-away close frame. Got %v", err)
+	}
+}
@@ -2,12 +2,15 @@ package bitcoin
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"math"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/btcsuite/btcd/btcjson"
@@ -15,8 +18,12 @@ import (
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/rawblock/coinjoin-engine/internal/logging"
 )
 
+// rpcLogger tags the node client's records (component=rpc)
+func rpcLogger() *slog.Logger { return logging.Component("rpc") }
+
 type Client struct {
 	RPC       *rpcclient.Client
 	WalletRPC *rpcclient.Client
@@ -27,6 +34,7 @@ type Config struct {
 	Host string
 	User string
 	Pass string
+	TLS  bool // Connect over HTTPS
 }
 
 func NewClient(cfg Config) (*Client, error) {
@@ -35,10 +43,10 @@ func NewClient(cfg Config) (*Client, error) {
 		User:         cfg.User,
 		Pass:         cfg.Pass,
 		HTTPPostMode: true, // Bitcoin Core only supports HTTP POST mode
-		DisableTLS:   true, // Assuming local node without TLS for this setup
+		DisableTLS:   !cfg.TLS,
 	}
 
-	log.Printf("Connecting to Bitcoin RPC at %s...", cfg.Host)
+	rpcLogger().Info("connecting to Bitcoin RPC", "host", cfg.Host)
 	client, err := rpcclient.New(connCfg, nil)
 	if err != nil {
 		return nil, err
@@ -51,15 +59,15 @@ func NewClient(cfg Config) (*Client, error) {
 		return nil, err
 	}
 
-	log.Printf("Connected to Bitcoin Node. Current Block Height: %d", blockCount)
+	rpcLogger().Info("connected to Bitcoin node", "height", blockCount)
 
 	c := &Client{RPC: client, Config: cfg}
 
 	// Ensure a wallet is loaded for watch-only operations
 	if err := c.InitializeWallet(); err != nil {
-		log.Printf("Warning: Failed to initialize wallet: %v. Watch-only features might fail.", err)
+		rpcLogger().Warn("failed to initialize wallet; watch-only features might fail", "err", err)
 	} else {
-		log.Println("Wallet initialized successfully.")
+		rpcLogger().Info("wallet initialized")
 	}
 
 	return c, nil
@@ -263,7 +271,7 @@ func (c *Client) InitializeWallet() error {
 		User:         c.Config.User,
 		Pass:         c.Config.Pass,
 		HTTPPostMode: true,
-		DisableTLS:   true,
+		DisableTLS:   !c.Config.TLS,
 	}
 
 	walletClient, err := rpcclient.New(walletConnCfg, nil)
@@ -373,7 +381,37 @@ func (c *Client) ListUnspent(addresses []string) ([]btcjson.ListUnspentResult, e
 	return c.RPC.ListUnspentMinMaxAddresses(0, 9999999, decodedAddrs)
 }
 
+// UTXO-set RPCs (scantxoutset, gettxoutsetinfo) walk the whole chainstate,
+// and Bitcoin Core rejects a second scantxoutset while one is running
+// ("-8: Scan already in progress"). utxoSetSlot serializes them across
+// the process: overlapping callers queue for up to utxoSetQueueTimeout.
+var utxoSetSlot = make(chan struct{}, 1)
+
+var utxoSetQueueTimeout = 5 * time.Minute
+
+// ErrUTXOSetBusy is returned when a UTXO-set call waited
+// utxoSetQueueTimeout without the running one finishing
+var ErrUTXOSetBusy = errors.New("timed out waiting for the running UTXO set scan")
+
+// acquireUTXOSet waits for the UTXO-set slot and returns its release func
+func acquireUTXOSet(method string) (func(), error) {
+	timer := time.NewTimer(utxoSetQueueTimeout)
+	defer timer.Stop()
+	select {
+	case utxoSetSlot <- struct{}{}:
+		return func() { <-utxoSetSlot }, nil
+	case <-timer.C:
+		return nil, fmt.Errorf("%s: %w", method, ErrUTXOSetBusy)
+	}
+}
+
 func (c *Client) ScanTxOutset(action string, descriptors []string) (*ScanTxOutResult, error) {
+	release, err := acquireUTXOSet("scantxoutset")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
 	// Build JSON-RPC params
 	param1, _ := json.Marshal(action)
 	params := []json.RawMessage{param1}
@@ -390,56 +428,17 @@ func (c *Client) ScanTxOutset(action string, descriptors []string) (*ScanTxOutRe
 	// Use a direct HTTP POST with a 5-minute timeout.
 	// The default rpcclient timeout is 60s which is too short for scantxoutset;
 	// it causes a timeout + automatic retry that triggers "-8: Scan already in progress".
-	type jsonRPCRequest struct {
-		JSONRPC string            `json:"jsonrpc"`
-		ID      int               `json:"id"`
-		Method  string            `json:"method"`
-		Params  []json.RawMessage `json:"params"`
-	}
-	reqBody, _ := json.Marshal(jsonRPCRequest{
-		JSONRPC: "1.0",
-		ID:      1,
-		Method:  "scantxoutset",
-		Params:  params,
-	})
-
-	url := fmt.Sprintf("http://%s", c.Config.Host)
-	httpReq, err := http.NewRequest("POST", url, bytes.NewReader(reqBody))
-	if err != nil {
-		return nil, fmt.Errorf("scantxoutset: create request: %w", err)
-	}
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.SetBasicAuth(c.Config.User, c.Config.Pass)
-
-	httpClient := &http.Client{Timeout: 5 * time.Minute}
-	httpResp, err := httpClient.Do(httpReq)
+	result, err := c.rawRequest(context.Background(), "scantxoutset", params, 5*time.Minute)
 	if err != nil {
-		return nil, fmt.Errorf("scantxoutset: http request: %w", err)
-	}
-	defer httpResp.Body.Close()
-
-	body, err := io.ReadAll(httpResp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("scantxoutset: read body: %w", err)
-	}
-
-	type jsonRPCResponse struct {
-		Result json.RawMessage `json:"result"`
-		Error  *struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-		} `json:"error"`
-	}
-	var rpcResp jsonRPCResponse
-	if err := json.Unmarshal(body, &rpcResp); err != nil {
-		return nil, fmt.Errorf("scantxoutset: unmarshal rpc response: %w", err)
-	}
-	if rpcResp.Error != nil {
-		return nil, fmt.Errorf("%d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+		var rpcErr *btcjson.RPCError
+		if errors.As(err, &rpcErr) && rpcErr.Code == btcjson.ErrRPCInvalidParameter && strings.Contains(rpcErr.Message, "in progress") {
+			return nil, ErrScanInProgress // Started by another client of the node
+		}
+		return nil, err
 	}
 
 	var res ScanTxOutResult
-	if err := json.Unmarshal(rpcResp.Result, &res); err != nil {
+	if err := json.Unmarshal(result, &res); err != nil {
 		return nil, fmt.Errorf("scantxoutset: unmarshal result: %w", err)
 	}
 
@@ -453,6 +452,27 @@ func (c *Client) GetPeerInfo() ([]btcjson.GetPeerInfoResult, error) {
 // GetTxOutSetInfoLong calls gettxoutsetinfo with a 3-minute timeout.
 // The default rpcclient timeout (60s) is too short for this expensive RPC.
 func (c *Client) GetTxOutSetInfoLong() (json.RawMessage, error) {
+	release, err := acquireUTXOSet("gettxoutsetinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return c.rawRequest(context.Background(), "gettxoutsetinfo", []json.RawMessage{}, 3*time.Minute)
+}
+
+// rpcURL returns the node's JSON-RPC endpoint
+func (c *Client) rpcURL() string {
+	if c.Config.TLS {
+		return "https://" + c.Config.Host
+	}
+	return "http://" + c.Config.Host
+}
+
+// rawRequest performs a JSON-RPC call over a direct HTTP POST so that
+// long-running calls are not cut off by the rpcclient timeout. Node errors
+// are returned as *btcjson.RPCError.
+func (c *Client) rawRequest(ctx context.Context, method string, params []json.RawMessage, timeout time.Duration) (json.RawMessage, error) {
 	type jsonRPCRequest struct {
 		JSONRPC string            `json:"jsonrpc"`
 		ID      int               `json:"id"`
@@ -462,43 +482,39 @@ func (c *Client) GetTxOutSetInfoLong() (json.RawMessage, error) {
 	reqBody, _ := json.Marshal(jsonRPCRequest{
 		JSONRPC: "1.0",
 		ID:      1,
-		Method:  "gettxoutsetinfo",
-		Params:  []json.RawMessage{},
+		Method:  method,
+		Params:  params,
 	})
 
-	url := fmt.Sprintf("http://%s", c.Config.Host)
-	httpReq, err := http.NewRequest("POST", url, bytes.NewReader(reqBody))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.rpcURL(), bytes.NewReader(reqBody))
 	if err != nil {
-		return nil, fmt.Errorf("gettxoutsetinfo: create request: %w", err)
+		return nil, fmt.Errorf("%s: create request: %w", method, err)
 	}
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.SetBasicAuth(c.Config.User, c.Config.Pass)
 
-	httpClient := &http.Client{Timeout: 3 * time.Minute}
+	httpClient := &http.Client{Timeout: timeout}
 	httpResp, err := httpClient.Do(httpReq)
 	if err != nil {
-		return nil, fmt.Errorf("gettxoutsetinfo: http request: %w", err)
+		return nil, fmt.Errorf("%s: http request: %w", method, err)
 	}
 	defer httpResp.Body.Close()
 
 	body, err := io.ReadAll(httpResp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("gettxoutsetinfo: read body: %w", err)
+		return nil, fmt.Errorf("%s: read body: %w", method, err)
 	}
 
 	type jsonRPCResponse struct {
-		Result json.RawMessage `json:"result"`
-		Error  *struct {
-			Code    int    `json:"code"`
-			Message string `json:"message"`
-		} `json:"error"`
+		Result json.RawMessage   `json:"result"`
+		Error  *btcjson.RPCError `json:"error"`
 	}
 	var rpcResp jsonRPCResponse
 	if err := json.Unmarshal(body, &rpcResp); err != nil {
-		return nil, fmt.Errorf("gettxoutsetinfo: unmarshal rpc response: %w", err)
+		return nil, fmt.Errorf("%s: unmarshal rpc response: %w", method, err)
 	}
 	if rpcResp.Error != nil {
-		return nil, fmt.Errorf("%d: %s", rpcResp.Error.Code, rpcResp.Error.Message)
+		return nil, rpcResp.Error
 	}
 
 	return rpcResp.Result, nil
@@ -519,27 +535,58 @@ func (c *Client) estimateSmartFeeByMode(confTarget int64, mode *btcjson.Estimate
 }
 
 func (c *Client) getMempoolFeeFloorBTCPerKVb() (float64, error) {
-	rawResp, err := c.RPC.RawRequest("getmempoolinfo", nil)
+	mempoolMinFee, minRelayTxFee, err := c.getMempoolFeesBTCPerKVb()
 	if err != nil {
 		return 0, err
 	}
 
+	floor := mempoolMinFee
+	if minRelayTxFee > floor {
+		floor = minRelayTxFee
+	}
+	if !isFinitePositive(floor) {
+		return 0, nil
+	}
+	return floor, nil
+}
+
+// getMempoolFeesBTCPerKVb returns getmempoolinfo's mempoolminfee and minrelaytxfee
+func (c *Client) getMempoolFeesBTCPerKVb() (float64, float64, error) {
+	rawResp, err := c.RPC.RawRequest("getmempoolinfo", nil)
+	if err != nil {
+		return 0, 0, err
+	}
+
 	var mempool struct {
 		MempoolMinFee float64 `json:"mempoolminfee"`
 		MinRelayTxFee float64 `json:"minrelaytxfee"`
 	}
 	if err := json.Unmarshal(rawResp, &mempool); err != nil {
-		return 0, err
+		return 0, 0, err
 	}
+	return mempool.MempoolMinFee, mempool.MinRelayTxFee, nil
+}
 
-	floor := mempool.MempoolMinFee
-	if mempool.MinRelayTxFee > floor {
-		floor = mempool.MinRelayTxFee
+// GetMinRelayFeeSatVB returns the node's minrelaytxfee in sat/vB
+func (c *Client) GetMinRelayFeeSatVB() (float64, error) {
+	_, minRelayTxFee, err := c.getMempoolFeesBTCPerKVb()
+	if err != nil {
+		return 0, err
 	}
-	if !isFinitePositive(floor) {
+	if !isFinitePositive(minRelayTxFee) {
 		return 0, nil
 	}
-	return floor, nil
+	return BTCPerKVbToSatPerVB(minRelayTxFee), nil
+}
+
+// GetMempoolFeeFloorSatVB returns the current mempool admission floor in sat/vB
+// (max of mempoolminfee and minrelaytxfee)
+func (c *Client) GetMempoolFeeFloorSatVB() (float64, error) {
+	floor, err := c.getMempoolFeeFloorBTCPerKVb()
+	if err != nil {
+		return 0, err
+	}
+	return BTCPerKVbToSatPerVB(floor), nil
 }
 
 func isFinitePositive(v float64) bool {
@@ -0,0 +1,266 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/rpcclient"
+)
+
+func TestAcquireUTXOSet_SerializesCallers(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+
+	var wg sync.WaitGroup
+	for range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			release, err := acquireUTXOSet("scantxoutset")
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+				return
+			}
+			mu.Lock()
+			running++
+			maxRunning = max(maxRunning, running)
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			release()
+		}()
+	}
+	wg.Wait()
+
+	if maxRunning != 1 {
+		t.Errorf("Expected one UTXO set call at a time. Got %d", maxRunning)
+	}
+}
+
+func TestAcquireUTXOSet_TimesOut(t *testing.T) {
+	defer func(d time.Duration) { utxoSetQueueTimeout = d }(utxoSetQueueTimeout)
+	utxoSetQueueTimeout = 20 * time.Millisecond
+
+	release, err := acquireUTXOSet("scantxoutset")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := acquireUTXOSet("gettxoutsetinfo"); !errors.Is(err, ErrUTXOSetBusy) {
+		t.Errorf("Expected ErrUTXOSetBusy while a scan is running. Got %v", err)
+	}
+	release()
+
+	again, err := acquireUTXOSet("gettxoutsetinfo")
+	if err != nil {
+		t.Fatalf("Expected the slot to be free after release. Got %v", err)
+	}
+	again()
+}
+
+// rpcStub serves a fixed JSON-RPC response body
+func rpcStub(t *testing.T, body string) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(srv.Close)
+	return &Client{Config: Config{Host: strings.TrimPrefix(srv.URL, "http://")}}
+}
+
+func TestRawRequest_ReturnsRPCError(t *testing.T) {
+	c := rpcStub(t, `{"result":null,"error":{"code":-5,"message":"No such mempool or blockchain transaction"},"id":1}`)
+
+	_, err := c.rawRequest(context.Background(), "getrawtransaction", nil, 0)
+	var rpcErr *btcjson.RPCError
+	if !errors.As(err, &rpcErr) || rpcErr.Code != btcjson.ErrRPCNoTxInfo {
+		t.Fatalf("Expected RPC error -5. Got %v", err)
+	}
+}
+
+func TestRPCURL_Scheme(t *testing.T) {
+	c := &Client{Config: Config{Host: "node:8332"}}
+	if got := c.rpcURL(); got != "http://node:8332" {
+		t.Errorf("Expected http URL. Got %q", got)
+	}
+	c.Config.TLS = true
+	if got := c.rpcURL(); got != "https://node:8332" {
+		t.Errorf("Expected https URL. Got %q", got)
+	}
+}
+
+func TestScanBlocks_Incomplete(t *testing.T) {
+	c := rpcStub(t, `{"result":{"from_height":0,"to_height":500,"relevant_blocks":["00aa"],"completed":false},"error":null,"id":1}`)
+
+	res, err := c.ScanBlocks(context.Background(), []string{"addr(bc1qtest)"}, 0)
+	if !errors.Is(err, ErrScanBlocksIncomplete) {
+		t.Fatalf("Expected ErrScanBlocksIncomplete. Got %v", err)
+	}
+	if res == nil || len(res.RelevantBlocks) != 1 {
+		t.Errorf("Expected the partial result alongside the error. Got %+v", res)
+	}
+
+	if _, err := c.FindSpendingTxs(context.Background(), "bc1qtest", 0); !errors.Is(err, ErrScanBlocksIncomplete) {
+		t.Errorf("Expected FindSpendingTxs to report the truncated scan. Got %v", err)
+	}
+}
+
+func TestScanBlocks_Completed(t *testing.T) {
+	c := rpcStub(t, `{"result":{"from_height":0,"to_height":500,"relevant_blocks":[],"completed":true},"error":null,"id":1}`)
+
+	res, err := c.ScanBlocks(context.Background(), []string{"addr(bc1qtest)"}, 0)
+	if err != nil || !res.Completed {
+		t.Fatalf("Expected a completed scan. Got %+v, %v", res, err)
+	}
+}
+
+// rpcMethodStub serves JSON-RPC results by method, over both the raw
+// request path and the rpcclient connection
+func rpcMethodStub(t *testing.T, results func(method string, params []json.RawMessage) any) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+			ID     json.RawMessage   `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{"result": results(req.Method, req.Params), "error": nil, "id": req.ID})
+	}))
+	t.Cleanup(srv.Close)
+
+	cfg := Config{Host: strings.TrimPrefix(srv.URL, "http://"), User: "user", Pass: "pass"}
+	rpc, err := rpcclient.New(&rpcclient.ConnConfig{Host: cfg.Host, User: cfg.User, Pass: cfg.Pass, HTTPPostMode: true, DisableTLS: true}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(rpc.Shutdown)
+	return &Client{RPC: rpc, Config: cfg}
+}
+
+func TestFindSpendingTxs_PrevoutHeightFromBlockTip(t *testing.T) {
+	const owner = "bc1qowner"
+	fundTxid, spendTxid := strings.Repeat("aa", 32), strings.Repeat("bb", 32)
+	fundHash, spendHash := strings.Repeat("0a", 32), strings.Repeat("0b", 32)
+	payTo := func(addr string, btc float64) btcjson.Vout {
+		return btcjson.Vout{Value: btc, ScriptPubKey: btcjson.ScriptPubKeyResult{Address: addr}}
+	}
+	fund := btcjson.TxRawResult{Txid: fundTxid, Vout: []btcjson.Vout{payTo(owner, 1.0)}}
+	spend := btcjson.TxRawResult{
+		Txid: spendTxid,
+		Vin:  []btcjson.Vin{{Txid: fundTxid, Vout: 0}},
+		Vout: []btcjson.Vout{payTo("bc1qpayee", 0.6), payTo("bc1qchange", 0.3999)},
+	}
+	// Tip 850,009: the funding block is 110 deep, the spending block 10
+	blocks := map[string]btcjson.GetBlockVerboseTxResult{
+		fundHash:  {Hash: fundHash, Height: 849_900, Confirmations: 110, Tx: []btcjson.TxRawResult{fund}},
+		spendHash: {Hash: spendHash, Height: 850_000, Confirmations: 10, Time: 1_718_000_000, Tx: []btcjson.TxRawResult{spend}},
+	}
+
+	c := rpcMethodStub(t, func(method string, params []json.RawMessage) any {
+		switch method {
+		case "scanblocks":
+			return ScanBlocksResult{ToHeight: 850_009, RelevantBlocks: []string{fundHash, spendHash}, Completed: true}
+		case "getblock":
+			var hash string
+			json.Unmarshal(params[0], &hash)
+			return blocks[hash] // Entries without per-tx confirmations, as with verbosity 2
+		case "getrawtransaction":
+			withConfs := fund
+			withConfs.Confirmations, withConfs.Blocktime = 110, 1_717_940_000
+			return withConfs
+		}
+		return nil
+	})
+
+	spends, err := c.FindSpendingTxs(context.Background(), owner, 0)
+	if err != nil || len(spends) != 1 {
+		t.Fatalf("Expected one spend. Got %d, %v", len(spends), err)
+	}
+	in := spends[0].Inputs[0]
+	if in.PrevBlockHeight != 849_900 || in.PrevBlockTime != 1_717_940_000 {
+		t.Errorf("Expected the prevout confirmed at 849900. Got height %d, time %d", in.PrevBlockHeight, in.PrevBlockTime)
+	}
+	if in.Value != 100_000_000 || in.Address != owner {
+		t.Errorf("Expected the 1 BTC owner prevout. Got %+v", in)
+	}
+}
+
+func TestScanBlocks_AbortsOnCancel(t *testing.T) {
+	var mu sync.Mutex
+	var actions []string
+	started := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		var action string
+		json.Unmarshal(req.Params[0], &action)
+		mu.Lock()
+		actions = append(actions, action)
+		mu.Unlock()
+		if action == "start" {
+			started <- struct{}{}
+			<-r.Context().Done() // A long filter scan
+			return
+		}
+		io.WriteString(w, `{"result":true,"error":null,"id":1}`)
+	}))
+	t.Cleanup(srv.Close)
+	c := &Client{Config: Config{Host: strings.TrimPrefix(srv.URL, "http://")}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { <-started; cancel() }()
+	if _, err := c.ScanBlocks(ctx, []string{"addr(bc1qtest)"}, 0); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the cancelled scan to fail. Got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(actions) != 2 || actions[1] != "abort" {
+		t.Errorf("Expected scanblocks abort after the cancelled start. Got %v", actions)
+	}
+}
+
+func TestAcquireScanBlocks_SerializesCallers(t *testing.T) {
+	defer func(d time.Duration) { scanBlocksQueueTimeout = d }(scanBlocksQueueTimeout)
+	scanBlocksQueueTimeout = 20 * time.Millisecond
+
+	release, err := acquireScanBlocks(context.Background())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if _, err := acquireScanBlocks(context.Background()); !errors.Is(err, ErrScanBlocksBusy) {
+		t.Errorf("Expected ErrScanBlocksBusy while a scan is running. Got %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := acquireScanBlocks(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a cancelled caller to stop queueing. Got %v", err)
+	}
+	release()
+
+	again, err := acquireScanBlocks(context.Background())
+	if err != nil {
+		t.Fatalf("Expected the slot to be free after release. Got %v", err)
+	}
+	again()
+}
@@ -0,0 +1,96 @@
+package bitcoin
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// DecodeRawTransaction parses a serialized transaction (hex, with or
+// without the BIP144 segwit marker) into the engine's transaction model,
+// encoding output addresses for mainnet.
+//
+// Everything the serialization carries is populated: version, locktime,
+// per-input outpoint, scriptSig, sequence and witness stack, per-output
+// value, scriptPubKey and address, plus txid, wtxid, weight and vsize. Input
+// values and addresses live in the spent outputs, not the raw tx, so they
+// are left zero (as is Fee) for the caller to resolve.
+func DecodeRawTransaction(rawHex string) (models.Transaction, error) {
+	return DecodeRawTransactionForNet(rawHex, &chaincfg.MainNetParams)
+}
+
+// DecodeRawTransactionForNet is DecodeRawTransaction with explicit address
+// encoding parameters (testnet/signet/regtest)
+func DecodeRawTransactionForNet(rawHex string, net *chaincfg.Params) (models.Transaction, error) {
+	raw, err := hex.DecodeString(strings.TrimSpace(rawHex))
+	if err != nil {
+		return models.Transaction{}, fmt.Errorf("invalid transaction hex: %w", err)
+	}
+
+	var msgTx wire.MsgTx
+	if err := msgTx.Deserialize(bytes.NewReader(raw)); err != nil {
+		return models.Transaction{}, fmt.Errorf("failed to deserialize transaction: %w", err)
+	}
+
+	// BIP141: weight = base size × 3 + total size; vsize = ⌈weight / 4⌉
+	weight := msgTx.SerializeSizeStripped()*3 + msgTx.SerializeSize()
+	tx := models.Transaction{
+		Txid:       msgTx.TxHash().String(),
+		Wtxid:      msgTx.WitnessHash().String(),
+		Inputs:     make([]models.TxIn, len(msgTx.TxIn)),
+		Outputs:    make([]models.TxOut, len(msgTx.TxOut)),
+		Weight:     weight,
+		Vsize:      (weight + 3) / 4,
+		Version:    msgTx.Version,
+		LockTime:   msgTx.LockTime,
+		HasWitness: msgTx.HasWitness(),
+	}
+
+	for i, txIn := range msgTx.TxIn {
+		in := models.TxIn{
+			Txid:      txIn.PreviousOutPoint.Hash.String(),
+			Vout:      txIn.PreviousOutPoint.Index,
+			ScriptSig: hex.EncodeToString(txIn.SignatureScript),
+			Sequence:  txIn.Sequence,
+		}
+		if len(txIn.Witness) > 0 {
+			in.Witness = make([]string, len(txIn.Witness))
+			for j, item := range txIn.Witness {
+				in.Witness[j] = hex.EncodeToString(item)
+			}
+		}
+		tx.Inputs[i] = in
+	}
+
+	for i, txOut := range msgTx.TxOut {
+		out := models.TxOut{
+			Value:        txOut.Value,
+			ScriptPubKey: hex.EncodeToString(txOut.PkScript),
+		}
+		if _, addrs, _, err := txscript.ExtractPkScriptAddrs(txOut.PkScript, net); err == nil && len(addrs) == 1 {
+			out.Address = addrs[0].EncodeAddress()
+		}
+		tx.Outputs[i] = out
+	}
+
+	return tx, nil
+}
+
+// RawWtxid returns the wtxid of a verbose RPC transaction: the node's
+// "hash" field when present, otherwise computed from the raw hex
+func RawWtxid(raw *btcjson.TxRawResult) string {
+	if raw.Hash != "" {
+		return raw.Hash
+	}
+	if tx, err := DecodeRawTransaction(raw.Hex); err == nil {
+		return tx.Wtxid
+	}
+	return ""
+}
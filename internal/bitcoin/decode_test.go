@@ -0,0 +1,76 @@
+package bitcoin
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// segwitTxHex is a v2 tx spending one P2WPKH input (RBF sequence,
+// 72-byte signature + 33-byte pubkey witness) to a P2WPKH and a P2PKH
+// output, locktime 850000. Base size 116, total size 226.
+const segwitTxHex = "020000000001012a3b6f7e2f2f5ddbb0053defb5a9a3a0e4a8d86e1b2fbecf70965ce84292185a0100000000fdffffff02f049020000000000160014222222222222222222222222222222222222222280bb0000000000001976a914333333333333333333333333333333333333333388ac02483030303030303030303030303030303030303030303030303030303030303030303030303030303030303030303030303030303030303030303030303030303030303030303030012102111111111111111111111111111111111111111111111111111111111111111150f80c00"
+
+func TestDecodeRawTransaction_Segwit(t *testing.T) {
+	tx, err := DecodeRawTransaction(segwitTxHex)
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+
+	if tx.Txid != "bbcaa58d14483ec219f7f31b9a17dcc8f762084e6a3e4f7a7b5a396f15e17e20" {
+		t.Errorf("Unexpected txid %s", tx.Txid)
+	}
+	if tx.Wtxid != "219b01c070003e7f27858b3c9281650379b030b3df08c099fd60a569e162ff9d" {
+		t.Errorf("Unexpected wtxid %s", tx.Wtxid)
+	}
+	if tx.Version != 2 || tx.LockTime != 850000 || !tx.HasWitness {
+		t.Errorf("Unexpected header fields: version=%d locktime=%d witness=%v", tx.Version, tx.LockTime, tx.HasWitness)
+	}
+	if tx.Weight != 116*3+226 || tx.Vsize != 144 {
+		t.Errorf("Expected weight 574 / vsize 144. Got %d / %d", tx.Weight, tx.Vsize)
+	}
+
+	if len(tx.Inputs) != 1 {
+		t.Fatalf("Expected 1 input. Got %d", len(tx.Inputs))
+	}
+	in := tx.Inputs[0]
+	if in.Txid != "5a189242e85c9670cfbe2f1b6ed8a8e4a0a3a9b5ef3d05b0db5d2f2f7e6f3b2a" || in.Vout != 1 {
+		t.Errorf("Unexpected outpoint %s:%d", in.Txid, in.Vout)
+	}
+	if in.Sequence != 0xfffffffd || in.ScriptSig != "" {
+		t.Errorf("Expected RBF sequence and empty scriptSig. Got %x / %q", in.Sequence, in.ScriptSig)
+	}
+	if len(in.Witness) != 2 || in.Witness[0] != strings.Repeat("30", 71)+"01" || in.Witness[1] != "02"+strings.Repeat("11", 32) {
+		t.Errorf("Unexpected witness stack %v", in.Witness)
+	}
+
+	wpkh, _ := btcutil.NewAddressWitnessPubKeyHash(bytes.Repeat([]byte{0x22}, 20), &chaincfg.MainNetParams)
+	pkh, _ := btcutil.NewAddressPubKeyHash(bytes.Repeat([]byte{0x33}, 20), &chaincfg.MainNetParams)
+	if len(tx.Outputs) != 2 {
+		t.Fatalf("Expected 2 outputs. Got %d", len(tx.Outputs))
+	}
+	if out := tx.Outputs[0]; out.Value != 150_000 || out.Address != wpkh.EncodeAddress() || out.ScriptPubKey != "0014"+strings.Repeat("22", 20) {
+		t.Errorf("Unexpected P2WPKH output %+v", out)
+	}
+	if out := tx.Outputs[1]; out.Value != 48_000 || out.Address != pkh.EncodeAddress() || out.ScriptPubKey != "76a914"+strings.Repeat("33", 20)+"88ac" {
+		t.Errorf("Unexpected P2PKH output %+v", out)
+	}
+}
+
+func TestDecodeRawTransaction_Invalid(t *testing.T) {
+	if _, err := DecodeRawTransaction("zz"); err == nil {
+		t.Error("Expected non-hex input to be rejected")
+	}
+	if _, err := DecodeRawTransaction(segwitTxHex[:40]); err == nil {
+		t.Error("Expected a truncated transaction to be rejected")
+	}
+
+	// Regtest addresses when decoding for another network
+	tx, err := DecodeRawTransactionForNet(segwitTxHex, &chaincfg.RegressionNetParams)
+	if err != nil || !strings.HasPrefix(tx.Outputs[0].Address, "bcrt1") {
+		t.Errorf("Expected a regtest bech32 address. Got %q (%v)", tx.Outputs[0].Address, err)
+	}
+}
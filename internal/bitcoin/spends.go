@@ -0,0 +1,292 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// --- Address Spend Discovery ---
+//
+// Bitcoin Core has no address index, so "which transactions spent from
+// address X?" cannot be answered with a single RPC. We use BIP158 compact
+// block filters instead: `scanblocks` returns every block whose filter
+// matches the address script (filters cover both created outputs and
+// spent prevouts). Walking those blocks in height order we first see the
+// outputs paid to X, then the transactions that spend them.
+//
+// Requirements on the node: -blockfilterindex=1 (for scanblocks) and
+// -txindex=1 (for resolving input values via getrawtransaction).
+
+// scanBlocksTimeout bounds a single scanblocks call; a full-chain filter
+// scan takes minutes on mainnet.
+const scanBlocksTimeout = 10 * time.Minute
+
+// scanBlocksAbortTimeout bounds the "abort" sent when a caller gives up
+const scanBlocksAbortTimeout = 30 * time.Second
+
+// Bitcoin Core runs one scanblocks at a time and rejects a second
+// ("-8: Scan already in progress"). scanBlocksSlot serializes them across
+// the process: overlapping callers queue until their ctx is done or
+// scanBlocksQueueTimeout passes.
+var scanBlocksSlot = make(chan struct{}, 1)
+
+var scanBlocksQueueTimeout = scanBlocksTimeout
+
+// ErrScanBlocksBusy is returned when a scanblocks call waited
+// scanBlocksQueueTimeout without the running one finishing
+var ErrScanBlocksBusy = errors.New("timed out waiting for the running filter scan")
+
+// acquireScanBlocks waits for the scanblocks slot and returns its release func
+func acquireScanBlocks(ctx context.Context) (func(), error) {
+	timer := time.NewTimer(scanBlocksQueueTimeout)
+	defer timer.Stop()
+	select {
+	case scanBlocksSlot <- struct{}{}:
+		return func() { <-scanBlocksSlot }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-timer.C:
+		return nil, fmt.Errorf("scanblocks: %w", ErrScanBlocksBusy)
+	}
+}
+
+// ErrScanBlocksIncomplete is returned when scanblocks stopped before the
+// chain tip (e.g. aborted by another client of the node); the blocks it
+// did match are still returned
+var ErrScanBlocksIncomplete = errors.New("filter scan did not complete")
+
+// ScanBlocksResult mirrors the scanblocks "start" response
+type ScanBlocksResult struct {
+	FromHeight     int64    `json:"from_height"`
+	ToHeight       int64    `json:"to_height"`
+	RelevantBlocks []string `json:"relevant_blocks"`
+	Completed      bool     `json:"completed"`
+}
+
+// ScanBlocks returns the hashes of blocks whose compact filters match any
+// of the given descriptors, starting at startHeight. Calls are serialized
+// process-wide; cancelling ctx drops the HTTP request and aborts the scan
+// on the node so the next caller can start one. A scan the node did not
+// complete returns its partial result together with ErrScanBlocksIncomplete.
+func (c *Client) ScanBlocks(ctx context.Context, descriptors []string, startHeight int64) (*ScanBlocksResult, error) {
+	release, err := acquireScanBlocks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	action, _ := json.Marshal("start")
+	descs, _ := json.Marshal(descriptors)
+	start, _ := json.Marshal(startHeight)
+
+	raw, err := c.rawRequest(ctx, "scanblocks", []json.RawMessage{action, descs, start}, scanBlocksTimeout)
+	if err != nil {
+		if ctx.Err() != nil {
+			c.abortScanBlocks()
+		}
+		return nil, err
+	}
+
+	var res ScanBlocksResult
+	if err := json.Unmarshal(raw, &res); err != nil {
+		return nil, fmt.Errorf("scanblocks: unmarshal result: %w", err)
+	}
+	if !res.Completed {
+		return &res, fmt.Errorf("scanblocks: %w (heights %d-%d)", ErrScanBlocksIncomplete, res.FromHeight, res.ToHeight)
+	}
+	return &res, nil
+}
+
+// abortScanBlocks stops the node's running scanblocks. Dropping the HTTP
+// request does not: the node keeps scanning and rejects the next start.
+func (c *Client) abortScanBlocks() {
+	action, _ := json.Marshal("abort")
+	if _, err := c.rawRequest(context.Background(), "scanblocks", []json.RawMessage{action}, scanBlocksAbortTimeout); err != nil {
+		rpcLogger().Warn("scanblocks abort failed", "err", err)
+	}
+}
+
+// FindSpendingTxs returns the confirmed transactions that spend outputs
+// paid to addr, in block order. Only blocks at or above fromHeight are
+// scanned. Input values and addresses are resolved from the previous
+// transactions so the results can be fed directly to the heuristics.
+// Returns ctx.Err() if cancelled part-way through the relevant blocks.
+func (c *Client) FindSpendingTxs(ctx context.Context, addr string, fromHeight int) ([]models.Transaction, error) {
+	if fromHeight < 0 {
+		fromHeight = 0
+	}
+
+	scan, err := c.ScanBlocks(ctx, []string{"addr(" + addr + ")"}, int64(fromHeight))
+	if err != nil {
+		return nil, fmt.Errorf("scanblocks %s: %w", addr, err)
+	}
+
+	// Outpoints ("txid:vout") currently held by addr
+	owned := make(map[string]bool)
+	var spends []models.Transaction
+
+	for _, blockHashStr := range scan.RelevantBlocks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		blockHash, err := chainhash.NewHashFromStr(blockHashStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block hash %s: %w", blockHashStr, err)
+		}
+		block, err := c.RPC.GetBlockVerboseTx(blockHash)
+		if err != nil {
+			return nil, fmt.Errorf("getblock %s: %w", blockHashStr, err)
+		}
+		// The block's entries carry no confirmations of their own
+		tipHeight := 0
+		if block.Confirmations > 0 {
+			tipHeight = TipFromConfirmations(int(block.Height), uint64(block.Confirmations))
+		}
+
+		for i := range block.Tx {
+			raw := &block.Tx[i]
+
+			spendsOwned := false
+			for _, vin := range raw.Vin {
+				key := outpointKey(vin.Txid, vin.Vout)
+				if owned[key] {
+					spendsOwned = true
+					delete(owned, key)
+				}
+			}
+
+			for _, vout := range raw.Vout {
+				if ScriptAddress(vout.ScriptPubKey) == addr {
+					owned[outpointKey(raw.Txid, vout.N)] = true
+				}
+			}
+
+			if spendsOwned {
+				tx, err := c.TransactionFromRaw(raw, int(block.Height), block.Time, tipHeight)
+				if err != nil {
+					return nil, err
+				}
+				spends = append(spends, tx)
+			}
+		}
+	}
+
+	return spends, nil
+}
+
+// TransactionFromRaw converts a verbose RPC transaction into the engine's
+// transaction model, fetching each previous output to resolve input
+// values and addresses. Coinbase inputs are left with zero value.
+// tipHeight is the chain tip the prevouts' confirmation counts are
+// measured against (0 = unknown, leaving PrevBlockHeight 0); getblock
+// entries have no confirmations, so callers pass the block's tip.
+func (c *Client) TransactionFromRaw(raw *btcjson.TxRawResult, height int, blockTime int64, tipHeight int) (models.Transaction, error) {
+	tx := models.Transaction{
+		Txid:        raw.Txid,
+		Wtxid:       RawWtxid(raw),
+		Inputs:      make([]models.TxIn, len(raw.Vin)),
+		Outputs:     make([]models.TxOut, len(raw.Vout)),
+		Weight:      int(raw.Weight),
+		Vsize:       int(raw.Vsize),
+		Version:     int32(raw.Version),
+		LockTime:    raw.LockTime,
+		BlockHeight: height,
+		BlockTime:   blockTime,
+	}
+
+	var totalIn, totalOut int64
+	for i, vin := range raw.Vin {
+		in := models.TxIn{
+			Txid:     vin.Txid,
+			Vout:     vin.Vout,
+			Sequence: vin.Sequence,
+		}
+		if vin.ScriptSig != nil {
+			in.ScriptSig = vin.ScriptSig.Hex
+		}
+		if len(vin.Witness) > 0 {
+			in.Witness = vin.Witness
+			tx.HasWitness = true
+		}
+		if !vin.IsCoinBase() {
+			prevHash, err := chainhash.NewHashFromStr(vin.Txid)
+			if err != nil {
+				return tx, fmt.Errorf("invalid prevout txid %s: %w", vin.Txid, err)
+			}
+			prevTx, err := c.GetRawTransaction(prevHash)
+			if err != nil {
+				return tx, fmt.Errorf("getrawtransaction %s: %w", vin.Txid, err)
+			}
+			if int(vin.Vout) < len(prevTx.Vout) {
+				prevOut := prevTx.Vout[vin.Vout]
+				in.Value = models.BTCToSats(prevOut.Value)
+				in.Address = ScriptAddress(prevOut.ScriptPubKey)
+			}
+			in.PrevBlockHeight, in.PrevBlockTime = PrevoutConfirmation(prevTx, tipHeight)
+		}
+		totalIn += in.Value
+		tx.Inputs[i] = in
+	}
+
+	for i, vout := range raw.Vout {
+		out := models.TxOut{
+			Value:        models.BTCToSats(vout.Value),
+			Address:      ScriptAddress(vout.ScriptPubKey),
+			ScriptPubKey: vout.ScriptPubKey.Hex,
+		}
+		totalOut += out.Value
+		tx.Outputs[i] = out
+	}
+
+	if totalIn > totalOut {
+		tx.Fee = totalIn - totalOut
+	}
+	return tx, nil
+}
+
+// ScriptAddress returns the address for a scriptPubKey, handling both the
+// modern "address" field and the deprecated "addresses" array.
+func ScriptAddress(spk btcjson.ScriptPubKeyResult) string {
+	if spk.Address != "" {
+		return spk.Address
+	}
+	if len(spk.Addresses) > 0 {
+		return spk.Addresses[0]
+	}
+	return ""
+}
+
+// TipFromConfirmations recovers the chain tip height from a confirmed
+// transaction's height and confirmation count (0 when unconfirmed)
+func TipFromConfirmations(height int, confirmations uint64) int {
+	if height <= 0 || confirmations == 0 {
+		return 0
+	}
+	return height + int(confirmations) - 1
+}
+
+// PrevoutConfirmation returns the confirmation height and block time of a
+// previous transaction. The height is derived from its confirmation count
+// against tipHeight; both are 0 while the previous tx is unconfirmed.
+func PrevoutConfirmation(prevTx *btcjson.TxRawResult, tipHeight int) (int, int64) {
+	if prevTx == nil || prevTx.Confirmations == 0 {
+		return 0, 0
+	}
+	height := 0
+	if tipHeight > 0 {
+		height = tipHeight - int(prevTx.Confirmations) + 1
+	}
+	return height, prevTx.Blocktime
+}
+
+func outpointKey(txid string, vout uint32) string {
+	return fmt.Sprintf("%s:%d", txid, vout)
+}
@@ -0,0 +1,137 @@
+package bitcoin
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// --- Address UTXO Watching ---
+//
+// Investigators need the current unspent outputs of a case's addresses.
+// ListUnspent only sees addresses imported into a wallet; scantxoutset
+// scans the whole UTXO set for addr(...) descriptors instead, so no
+// wallet or rescan is needed. A scan takes tens of seconds on mainnet;
+// ScanTxOutset queues concurrent callers (see utxoSetSlot).
+
+// ErrScanInProgress is returned when the node is already running a
+// scantxoutset started outside this process
+var ErrScanInProgress = errors.New("scantxoutset: scan already in progress")
+
+// AddressUTXOs is the live unspent balance of one address
+type AddressUTXOs struct {
+	Address  string      `json:"address"`
+	Balance  int64       `json:"balance"` // Sats
+	Unspents []ScanTxOut `json:"unspents"`
+}
+
+// AddressScan is the aggregated scantxoutset result for an address set
+type AddressScan struct {
+	Height    int64          `json:"height"`
+	BestBlock string         `json:"bestBlock"`
+	Total     int64          `json:"total"`     // Sats across all addresses
+	Addresses []AddressUTXOs `json:"addresses"` // One per distinct address, request order
+}
+
+// Balances returns address → unspent sats
+func (s *AddressScan) Balances() map[string]int64 {
+	out := make(map[string]int64, len(s.Addresses))
+	for _, a := range s.Addresses {
+		out[a.Address] = a.Balance
+	}
+	return out
+}
+
+// addressScripts maps each distinct address to its scriptPubKey hex
+func addressScripts(addrs []string) ([]string, map[string]string, error) {
+	order := make([]string, 0, len(addrs))
+	scripts := make(map[string]string, len(addrs))
+	for _, addr := range addrs {
+		if addr == "" {
+			continue
+		}
+		if _, dup := scripts[addr]; dup {
+			continue
+		}
+		decoded, err := btcutil.DecodeAddress(addr, &chaincfg.MainNetParams)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid address %s: %w", addr, err)
+		}
+		script, err := txscript.PayToAddrScript(decoded)
+		if err != nil {
+			return nil, nil, fmt.Errorf("no script for address %s: %w", addr, err)
+		}
+		order = append(order, addr)
+		scripts[addr] = hex.EncodeToString(script)
+	}
+	return order, scripts, nil
+}
+
+// aggregateAddressScan groups the unspents of a scan by address
+func aggregateAddressScan(order []string, scripts map[string]string, res *ScanTxOutResult) *AddressScan {
+	scan := &AddressScan{Height: res.Height, BestBlock: res.BestBlock, Addresses: make([]AddressUTXOs, len(order))}
+	byScript := make(map[string]int, len(order))
+	for i, addr := range order {
+		scan.Addresses[i] = AddressUTXOs{Address: addr, Unspents: make([]ScanTxOut, 0)}
+		byScript[scripts[addr]] = i
+	}
+	for _, u := range res.Unspents {
+		i, ok := byScript[strings.ToLower(u.ScriptPubKey)]
+		if !ok {
+			continue
+		}
+		sats := models.BTCToSats(u.Amount)
+		scan.Addresses[i].Balance += sats
+		scan.Addresses[i].Unspents = append(scan.Addresses[i].Unspents, u)
+		scan.Total += sats
+	}
+	return scan
+}
+
+// ScanAddresses returns the current UTXOs and balances of addrs by
+// scanning the UTXO set for their addr(...) descriptors. Concurrent calls
+// wait for the running scan rather than failing with -8.
+func (c *Client) ScanAddresses(addrs []string) (*AddressScan, error) {
+	order, scripts, err := addressScripts(addrs)
+	if err != nil {
+		return nil, err
+	}
+	if len(order) == 0 {
+		return &AddressScan{Addresses: make([]AddressUTXOs, 0)}, nil
+	}
+
+	descs := make([]string, len(order))
+	for i, addr := range order {
+		descs[i] = "addr(" + addr + ")"
+	}
+
+	res, err := c.ScanTxOutset("start", descs)
+	if err != nil {
+		return nil, err
+	}
+	if !res.Success {
+		return nil, fmt.Errorf("scantxoutset did not complete")
+	}
+	return aggregateAddressScan(order, scripts, res), nil
+}
+
+// UnspentBalances returns the live unspent sats of each address
+// (heuristics.UnspentBalanceSource). The scan itself cannot be cancelled;
+// ctx is only checked before it starts.
+func (c *Client) UnspentBalances(ctx context.Context, addrs []string) (map[string]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	scan, err := c.ScanAddresses(addrs)
+	if err != nil {
+		return nil, err
+	}
+	return scan.Balances(), nil
+}
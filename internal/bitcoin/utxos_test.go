@@ -0,0 +1,49 @@
+package bitcoin
+
+import "testing"
+
+func TestAggregateAddressScan_GroupsByAddress(t *testing.T) {
+	p2wpkh := "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+	p2pkh := "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"
+
+	order, scripts, err := addressScripts([]string{p2wpkh, p2pkh, p2wpkh, ""})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(order) != 2 {
+		t.Fatalf("Expected 2 distinct addresses. Got %v", order)
+	}
+	if scripts[p2wpkh] != "0014e8df018c7e326cc253faac7e46cdc51e68542c42" {
+		t.Errorf("Unexpected P2WPKH script %s", scripts[p2wpkh])
+	}
+
+	res := &ScanTxOutResult{
+		Success:   true,
+		Height:    850000,
+		BestBlock: "00000000000000000002",
+		Unspents: []ScanTxOut{
+			{TxID: "aa", Vout: 0, ScriptPubKey: scripts[p2wpkh], Amount: 0.29},
+			{TxID: "bb", Vout: 1, ScriptPubKey: scripts[p2wpkh], Amount: 0.01},
+			{TxID: "cc", Vout: 0, ScriptPubKey: scripts[p2pkh], Amount: 0.00000546},
+			{TxID: "dd", Vout: 0, ScriptPubKey: "51", Amount: 1},
+		},
+	}
+	scan := aggregateAddressScan(order, scripts, res)
+
+	if scan.Height != 850000 || scan.Total != 30_000_546 {
+		t.Errorf("Expected height 850000 and total 30000546. Got %d, %d", scan.Height, scan.Total)
+	}
+	balances := scan.Balances()
+	if balances[p2wpkh] != 30_000_000 || len(scan.Addresses[0].Unspents) != 2 {
+		t.Errorf("Expected 2 UTXOs worth 30000000 sats at %s. Got %+v", p2wpkh, scan.Addresses[0])
+	}
+	if balances[p2pkh] != 546 {
+		t.Errorf("Expected 546 sats at %s. Got %d", p2pkh, balances[p2pkh])
+	}
+}
+
+func TestAddressScripts_RejectsInvalidAddress(t *testing.T) {
+	if _, _, err := addressScripts([]string{"not-an-address"}); err == nil {
+		t.Error("Expected an error for an invalid address")
+	}
+}
@@ -14,3 +14,6 @@ func CalculateAnonSetHardware(tx models.Transaction) int {
 	log.Println("[WARNING] Hardware acceleration requested, but engine was compiled without CUDA support. Falling back to CPU Heuristics.")
 	return 0 // Fallback handled by the CPU SSMP module
 }
+
+// Available reports whether the engine was compiled with the CUDA kernel
+func Available() bool { return false }
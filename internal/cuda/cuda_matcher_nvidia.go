@@ -46,3 +46,6 @@ func CalculateAnonSetHardware(tx models.Transaction) int {
 
 	return int(anonSet)
 }
+
+// Available reports whether the engine was compiled with the CUDA kernel
+func Available() bool { return true }
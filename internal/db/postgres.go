@@ -3,8 +3,12 @@ package db
 import (
 	"context"
 	_ "embed"
+	"encoding/json"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/rawblock/coinjoin-engine/pkg/models"
@@ -19,6 +23,16 @@ var schemaSQL string
 
 type PostgresStore struct {
 	pool *pgxpool.Pool
+
+	// storeFullAnalysis keeps the whole PrivacyAnalysisResult as JSONB in
+	// tx_heuristics.analysis (off by default: it multiplies the row size)
+	storeFullAnalysis bool
+}
+
+// SetStoreFullAnalysis enables persisting full analysis results. Call
+// before the store is shared with the scanner and poller.
+func (s *PostgresStore) SetStoreFullAnalysis(enabled bool) {
+	s.storeFullAnalysis = enabled
 }
 
 // Connect initializes the connection pool to PostgreSQL using pgx
@@ -54,8 +68,10 @@ func (s *PostgresStore) InitSchema() error {
 	return nil
 }
 
-// SaveAnalysisResult persists the computed heuristics and the evidence graph
-func (s *PostgresStore) SaveAnalysisResult(ctx context.Context, blockHeight int, result models.PrivacyAnalysisResult) error {
+// SaveAnalysisResult persists the computed heuristics and the evidence graph.
+// blockTime (unix seconds) dates the detection for time-series queries:
+// the block timestamp, or the first-seen time of a mempool transaction.
+func (s *PostgresStore) SaveAnalysisResult(ctx context.Context, blockHeight int, blockTime int64, result models.PrivacyAnalysisResult) error {
 	// 1. Begin Transaction
 	tx, err := s.pool.Begin(ctx)
 	if err != nil {
@@ -65,12 +81,19 @@ func (s *PostgresStore) SaveAnalysisResult(ctx context.Context, blockHeight int,
 
 	// 2. Insert main heuristic row
 	insertHeuristicSQL := `
-		INSERT INTO tx_heuristics (block_height, txid, heuristic_flags, anonset_local)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO tx_heuristics (block_height, txid, heuristic_flags, anonset_local, block_time, analysis)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		ON CONFLICT (block_height, txid) DO UPDATE 
-		SET heuristic_flags = EXCLUDED.heuristic_flags, anonset_local = EXCLUDED.anonset_local;
+		SET heuristic_flags = EXCLUDED.heuristic_flags, anonset_local = EXCLUDED.anonset_local, block_time = EXCLUDED.block_time,
+		    analysis = COALESCE(EXCLUDED.analysis, tx_heuristics.analysis);
 	`
-	_, err = tx.Exec(ctx, insertHeuristicSQL, blockHeight, result.Txid, result.HeuristicFlags, result.AnonSet)
+	var analysis []byte // NULL unless full results are stored
+	if s.storeFullAnalysis {
+		if analysis, err = json.Marshal(result); err != nil {
+			return fmt.Errorf("failed to encode analysis result: %v", err)
+		}
+	}
+	_, err = tx.Exec(ctx, insertHeuristicSQL, blockHeight, result.Txid, result.HeuristicFlags, result.AnonSet, blockTime, analysis)
 	if err != nil {
 		return fmt.Errorf("failed to insert tx_heuristics: %v", err)
 	}
@@ -79,8 +102,8 @@ func (s *PostgresStore) SaveAnalysisResult(ctx context.Context, blockHeight int,
 	if len(result.Edges) > 0 {
 		insertEdgeSQL := `
 			INSERT INTO evidence_edge 
-			(created_height, src_node_id, dst_node_id, edge_type, llr_score, dependency_group, snapshot_id, audit_hash)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+			(created_height, src_node_id, dst_node_id, edge_type, llr_score, dependency_group, snapshot_id, audit_hash, members)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
 		`
 		for _, edge := range result.Edges {
 			auditHash := edge.AuditHash
@@ -97,6 +120,7 @@ func (s *PostgresStore) SaveAnalysisResult(ctx context.Context, blockHeight int,
 				edge.DependencyGroup,
 				edge.SnapshotID,
 				auditHash,
+				edge.Members,
 			)
 			if err != nil {
 				return fmt.Errorf("failed to insert evidence edge: %v", err)
@@ -108,25 +132,468 @@ func (s *PostgresStore) SaveAnalysisResult(ctx context.Context, blockHeight int,
 	return tx.Commit(ctx)
 }
 
-// SaveAnonSetWindow persists the time-evolving anonymity set windows
-func (s *PostgresStore) SaveAnonSetWindow(ctx context.Context, txid string, outputIndex int, anonsetLocal int) error {
+// SaveEvidenceEdges persists standalone evidence edges produced by
+// cross-transaction passes (e.g. same-block self-spend timing links)
+func (s *PostgresStore) SaveEvidenceEdges(ctx context.Context, blockHeight int, edges []models.EvidenceEdge) error {
+	if len(edges) == 0 {
+		return nil
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	insertEdgeSQL := `
+		INSERT INTO evidence_edge 
+		(created_height, src_node_id, dst_node_id, edge_type, llr_score, dependency_group, snapshot_id, audit_hash, members)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9);
+	`
+	for _, edge := range edges {
+		_, err = tx.Exec(ctx, insertEdgeSQL,
+			blockHeight,
+			edge.SrcNodeID,
+			edge.DstNodeID,
+			edge.EdgeType,
+			edge.LLRScore,
+			edge.DependencyGroup,
+			edge.SnapshotID,
+			edge.AuditHash,
+			edge.Members,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert evidence edge: %v", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// evidenceEdgeColumns is the evidence_edge projection scanEvidenceEdges reads
+const evidenceEdgeColumns = `edge_id, created_height, src_node_id, dst_node_id, edge_type, llr_score,
+		dependency_group, snapshot_id, audit_hash, COALESCE(members, '{}')`
+
+// MaxSubgraphEdges caps the edges GetEdgesForAddress returns
+const MaxSubgraphEdges = 2000
+
+// EvidenceEdgesFrom returns up to limit stored edges whose source, or one
+// of whose hyper-edge members, is in addrs (strongest first)
+func (s *PostgresStore) EvidenceEdgesFrom(ctx context.Context, addrs []string, limit int) ([]models.EvidenceEdge, error) {
+	sql := `SELECT ` + evidenceEdgeColumns + `
+		FROM evidence_edge
+		WHERE src_node_id = ANY($1) OR members && $1
+		ORDER BY llr_score DESC
+		LIMIT $2;`
+	return s.queryEvidenceEdges(ctx, sql, addrs, limit)
+}
+
+// GetEdgesForAddress returns the evidence subgraph around addr: every
+// stored edge within maxHops of it, following edges in either direction
+// and through hyper-edge members. At most MaxSubgraphEdges are returned,
+// strongest first at each hop.
+func (s *PostgresStore) GetEdgesForAddress(ctx context.Context, addr string, maxHops int) ([]models.EvidenceEdge, error) {
+	sql := `SELECT ` + evidenceEdgeColumns + `
+		FROM evidence_edge
+		WHERE (src_node_id = ANY($1) OR dst_node_id = ANY($1) OR members && $1)
+		  AND NOT (edge_id = ANY($2))
+		ORDER BY llr_score DESC
+		LIMIT $3;`
+
+	edges := make([]models.EvidenceEdge, 0)
+	seenEdges := make([]int64, 0)
+	seenAddrs := map[string]bool{addr: true}
+	frontier := []string{addr}
+	for hop := 0; hop < maxHops && len(frontier) > 0 && len(edges) < MaxSubgraphEdges; hop++ {
+		found, err := s.queryEvidenceEdges(ctx, sql, frontier, seenEdges, MaxSubgraphEdges-len(edges))
+		if err != nil {
+			return nil, err
+		}
+		var next []string
+		for _, e := range found {
+			id, _ := strconv.ParseInt(e.EdgeID, 10, 64)
+			seenEdges = append(seenEdges, id)
+			edges = append(edges, e)
+			for _, a := range append([]string{e.SrcNodeID, e.DstNodeID}, e.Members...) {
+				if !seenAddrs[a] {
+					seenAddrs[a] = true
+					next = append(next, a)
+				}
+			}
+		}
+		frontier = next
+	}
+	return edges, nil
+}
+
+// queryEvidenceEdges runs a SELECT of evidenceEdgeColumns
+func (s *PostgresStore) queryEvidenceEdges(ctx context.Context, sql string, args ...any) ([]models.EvidenceEdge, error) {
+	rows, err := s.pool.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edges := make([]models.EvidenceEdge, 0)
+	for rows.Next() {
+		var e models.EvidenceEdge
+		var edgeID, snapshotID int64
+		if err := rows.Scan(&edgeID, &e.CreatedHeight, &e.SrcNodeID, &e.DstNodeID, &e.EdgeType, &e.LLRScore,
+			&e.DependencyGroup, &snapshotID, &e.AuditHash, &e.Members); err != nil {
+			return nil, err
+		}
+		e.EdgeID = strconv.FormatInt(edgeID, 10)
+		e.SnapshotID = int(snapshotID)
+		edges = append(edges, e)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return edges, nil
+}
+
+// SaveClusterChanges persists a drained cluster change set in one
+// transaction: rows under each absorbed root are re-pointed to its new
+// root first, then the changed memberships are upserted.
+func (s *PostgresStore) SaveClusterChanges(ctx context.Context, members []models.ClusterMembership, reroots map[string]string, snapshotID int) error {
+	if len(members) == 0 && len(reroots) == 0 {
+		return nil
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	rerootSQL := `
+		UPDATE address_clusters
+		SET root_address = $2, snapshot_id = $3, updated_at = NOW()
+		WHERE root_address = $1;
+	`
+	for oldRoot, newRoot := range reroots {
+		if _, err := tx.Exec(ctx, rerootSQL, oldRoot, newRoot, snapshotID); err != nil {
+			return fmt.Errorf("failed to re-point cluster %s: %v", oldRoot, err)
+		}
+	}
+
+	upsertSQL := `
+		INSERT INTO address_clusters (address, root_address, snapshot_id, updated_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (address) DO UPDATE SET
+			root_address = EXCLUDED.root_address,
+			snapshot_id = EXCLUDED.snapshot_id,
+			updated_at = NOW();
+	`
+	for _, m := range members {
+		if _, err := tx.Exec(ctx, upsertSQL, m.Address, m.RootAddress, snapshotID); err != nil {
+			return fmt.Errorf("failed to upsert cluster membership: %v", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// LoadClusterMemberships reads every persisted cluster membership for
+// warm-starting a ClusterEngine on process boot.
+func (s *PostgresStore) LoadClusterMemberships(ctx context.Context) ([]models.ClusterMembership, error) {
+	rows, err := s.pool.Query(ctx, `SELECT address, root_address FROM address_clusters;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	members := make([]models.ClusterMembership, 0)
+	for rows.Next() {
+		var m models.ClusterMembership
+		if err := rows.Scan(&m.Address, &m.RootAddress); err != nil {
+			return nil, err
+		}
+		members = append(members, m)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return members, nil
+}
+
+// SaveTaintScores upserts drained taint changes in one transaction with
+// upsert-max semantics: the stored taint only rises and the hop distance
+// only shrinks, so a stale flush never downgrades a stronger score.
+func (s *PostgresStore) SaveTaintScores(ctx context.Context, scores []models.TaintScore) error {
+	if len(scores) == 0 {
+		return nil
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql := `
+		INSERT INTO taint_scores (address, taint_level, category, hops_from_source, updated_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (address) DO UPDATE SET
+			taint_level = GREATEST(taint_scores.taint_level, EXCLUDED.taint_level),
+			category = COALESCE(NULLIF(EXCLUDED.category, ''), taint_scores.category),
+			hops_from_source = LEAST(taint_scores.hops_from_source, EXCLUDED.hops_from_source),
+			updated_at = NOW();
+	`
+	for _, score := range scores {
+		if _, err := tx.Exec(ctx, sql, score.Address, score.TaintLevel, score.Category, score.HopsFromSource); err != nil {
+			return fmt.Errorf("failed to upsert taint score: %v", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// LoadTaintScores reads every persisted taint score for warm-starting the
+// global taint map on process boot.
+func (s *PostgresStore) LoadTaintScores(ctx context.Context) ([]models.TaintScore, error) {
+	rows, err := s.pool.Query(ctx, `SELECT address, taint_level, category, hops_from_source FROM taint_scores;`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	scores := make([]models.TaintScore, 0)
+	for rows.Next() {
+		var score models.TaintScore
+		if err := rows.Scan(&score.Address, &score.TaintLevel, &score.Category, &score.HopsFromSource); err != nil {
+			return nil, err
+		}
+		scores = append(scores, score)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return scores, nil
+}
+
+// SaveAddressLabels upserts address labels in one transaction
+func (s *PostgresStore) SaveAddressLabels(ctx context.Context, labels []models.AddressLabel) error {
+	if len(labels) == 0 {
+		return nil
+	}
+
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }()
+
+	sql := `
+		INSERT INTO address_labels (address, label, category, source, confidence, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (address) DO UPDATE SET
+			label = EXCLUDED.label,
+			category = EXCLUDED.category,
+			source = EXCLUDED.source,
+			confidence = EXCLUDED.confidence,
+			updated_at = EXCLUDED.updated_at;
+	`
+	for _, l := range labels {
+		if _, err := tx.Exec(ctx, sql, l.Address, l.Label, l.Category, l.Source, l.Confidence, l.UpdatedAt); err != nil {
+			return fmt.Errorf("failed to upsert address label: %v", err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeleteAddressLabel removes the label of an address
+func (s *PostgresStore) DeleteAddressLabel(ctx context.Context, address string) error {
+	if _, err := s.pool.Exec(ctx, `DELETE FROM address_labels WHERE address = $1;`, address); err != nil {
+		return fmt.Errorf("failed to delete address label: %v", err)
+	}
+	return nil
+}
+
+// LoadAddressLabels reads every persisted address label for warm-starting
+// the label index on process boot.
+func (s *PostgresStore) LoadAddressLabels(ctx context.Context) ([]models.AddressLabel, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT address, label, category, source, confidence, COALESCE(updated_at, NOW())
+		FROM address_labels;
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	labels := make([]models.AddressLabel, 0)
+	for rows.Next() {
+		var l models.AddressLabel
+		if err := rows.Scan(&l.Address, &l.Label, &l.Category, &l.Source, &l.Confidence, &l.UpdatedAt); err != nil {
+			return nil, err
+		}
+		labels = append(labels, l)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return labels, nil
+}
+
+// SaveBlockSummary upserts the rollup of a scanned block
+func (s *PostgresStore) SaveBlockSummary(ctx context.Context, summary models.BlockSummary, snapshotID int) error {
+	upsertSQL := `
+		INSERT INTO block_summaries
+		(block_height, block_hash, block_time, tx_count, coinjoin_count, coinjoins_by_type,
+		 total_value_sats, avg_privacy_score, wallet_families, dominant_wallets, snapshot_id, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+		ON CONFLICT (block_height) DO UPDATE SET
+			block_hash = EXCLUDED.block_hash,
+			block_time = EXCLUDED.block_time,
+			tx_count = EXCLUDED.tx_count,
+			coinjoin_count = EXCLUDED.coinjoin_count,
+			coinjoins_by_type = EXCLUDED.coinjoins_by_type,
+			total_value_sats = EXCLUDED.total_value_sats,
+			avg_privacy_score = EXCLUDED.avg_privacy_score,
+			wallet_families = EXCLUDED.wallet_families,
+			dominant_wallets = EXCLUDED.dominant_wallets,
+			snapshot_id = EXCLUDED.snapshot_id,
+			updated_at = NOW();
+	`
+	_, err := s.pool.Exec(ctx, upsertSQL,
+		summary.BlockHeight,
+		summary.BlockHash,
+		summary.BlockTime,
+		summary.TxCount,
+		summary.CoinJoinCount,
+		summary.CoinJoinsByType,
+		summary.TotalValueSats,
+		summary.AvgPrivacyScore,
+		summary.WalletFamilies,
+		summary.DominantWallets,
+		snapshotID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert block summary %d: %v", summary.BlockHeight, err)
+	}
+	return nil
+}
+
+const blockSummaryColumns = `
+	block_height, block_hash, block_time, tx_count, coinjoin_count, coinjoins_by_type,
+	total_value_sats, avg_privacy_score, wallet_families, dominant_wallets`
+
+// GetBlockSummary returns the rollup for one block, or nil if the block
+// has not been scanned
+func (s *PostgresStore) GetBlockSummary(ctx context.Context, height int) (*models.BlockSummary, error) {
+	summaries, err := s.GetBlockSummaries(ctx, height, height)
+	if err != nil || len(summaries) == 0 {
+		return nil, err
+	}
+	return &summaries[0], nil
+}
+
+// GetBlockSummaries returns the rollups for scanned blocks in [from, to],
+// ascending by height (unscanned heights are absent)
+func (s *PostgresStore) GetBlockSummaries(ctx context.Context, from, to int) ([]models.BlockSummary, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT`+blockSummaryColumns+`
+		FROM block_summaries
+		WHERE block_height BETWEEN $1 AND $2
+		ORDER BY block_height ASC;
+	`, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query block summaries: %v", err)
+	}
+	defer rows.Close()
+
+	summaries := make([]models.BlockSummary, 0)
+	for rows.Next() {
+		var b models.BlockSummary
+		if err := rows.Scan(&b.BlockHeight, &b.BlockHash, &b.BlockTime, &b.TxCount, &b.CoinJoinCount,
+			&b.CoinJoinsByType, &b.TotalValueSats, &b.AvgPrivacyScore, &b.WalletFamilies, &b.DominantWallets); err != nil {
+			return nil, err
+		}
+		summaries = append(summaries, b)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return summaries, nil
+}
+
+// SaveAnonSetWindow persists a CoinJoin output's transaction-local
+// anonymity set with its denomination and block time; the windowed
+// columns are filled in later by the anonset window job
+func (s *PostgresStore) SaveAnonSetWindow(ctx context.Context, out models.AnonSetOutput) error {
 	sql := `
-		INSERT INTO anonset_windows (txid, output_index, anonset_local)
-		VALUES ($1, $2, $3)
+		INSERT INTO anonset_windows (txid, output_index, anonset_local, value_sats, block_time)
+		VALUES ($1, $2, $3, $4, $5)
 		ON CONFLICT (txid, output_index) DO UPDATE
-		SET anonset_local = EXCLUDED.anonset_local, last_updated = NOW();
+		SET anonset_local = EXCLUDED.anonset_local, value_sats = EXCLUDED.value_sats,
+			block_time = EXCLUDED.block_time, last_updated = NOW();
 	`
-	_, err := s.pool.Exec(ctx, sql, txid, outputIndex, anonsetLocal)
+	_, err := s.pool.Exec(ctx, sql, out.Txid, out.OutputIndex, out.AnonSetLocal, out.ValueSats, out.BlockTime)
 	return err
 }
 
+// AnonSetHorizon returns the latest block time among tracked CoinJoin
+// outputs (0 when none): windows ending after it are not yet observable
+func (s *PostgresStore) AnonSetHorizon(ctx context.Context) (int64, error) {
+	var horizon int64
+	err := s.pool.QueryRow(ctx, `SELECT COALESCE(MAX(block_time), 0) FROM anonset_windows`).Scan(&horizon)
+	return horizon, err
+}
+
+// PendingAnonSetWindows returns up to limit outputs whose window column is
+// still NULL and whose window closed at or before horizon (unix seconds)
+func (s *PostgresStore) PendingAnonSetWindows(ctx context.Context, window string, windowSecs, horizon int64, limit int) ([]models.AnonSetOutput, error) {
+	if !validAnonSetWindows[window] {
+		return nil, fmt.Errorf("invalid window: %s", window)
+	}
+	sql := fmt.Sprintf(`
+		SELECT txid, output_index, anonset_local, value_sats, block_time
+		FROM anonset_windows
+		WHERE %s IS NULL AND value_sats > 0 AND block_time > 0 AND block_time + $1 <= $2
+		ORDER BY block_time ASC
+		LIMIT $3`, window)
+	rows, err := s.pool.Query(ctx, sql, windowSecs, horizon, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	outputs := make([]models.AnonSetOutput, 0)
+	for rows.Next() {
+		var out models.AnonSetOutput
+		if err := rows.Scan(&out.Txid, &out.OutputIndex, &out.AnonSetLocal, &out.ValueSats, &out.BlockTime); err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, out)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return outputs, nil
+}
+
+// CountDenominationOutputs counts tracked CoinJoin outputs of exactly
+// valueSats confirmed in [from, to], excluding those of excludeTxid
+func (s *PostgresStore) CountDenominationOutputs(ctx context.Context, valueSats int64, excludeTxid string, from, to int64) (int, error) {
+	var n int
+	err := s.pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM anonset_windows
+		WHERE value_sats = $1 AND txid <> $2 AND block_time BETWEEN $3 AND $4`,
+		valueSats, excludeTxid, from, to).Scan(&n)
+	return n, err
+}
+
+// validAnonSetWindows are the anonset_windows columns callers may name
+var validAnonSetWindows = map[string]bool{
+	"anonset_1d": true, "anonset_7d": true, "anonset_30d": true, "anonset_365d": true,
+}
+
 // UpdateAnonSetWindows updates a specific time window column for an output
 func (s *PostgresStore) UpdateAnonSetWindows(ctx context.Context, txid string, outputIndex int, window string, value int) error {
 	// Validate the window parameter to prevent SQL injection
-	validWindows := map[string]bool{
-		"anonset_1d": true, "anonset_7d": true, "anonset_30d": true, "anonset_365d": true,
-	}
-	if !validWindows[window] {
+	if !validAnonSetWindows[window] {
 		return fmt.Errorf("invalid window: %s", window)
 	}
 
@@ -145,7 +612,60 @@ type MixerInfo struct {
 	MixerType      string `json:"mixerType"`
 }
 
-func (s *PostgresStore) GetMixers(ctx context.Context, page int, limit int) ([]MixerInfo, int, error) {
+// MixerFilter narrows GetMixers; zero fields do not filter
+type MixerFilter struct {
+	MixerType  string // Whirlpool or WabiSabi (see MixerTypes)
+	MinAnonset int
+	FromHeight int
+	ToHeight   int
+}
+
+// mixerTypeConditions classifies rows with the same flag precedence as
+// the MixerType GetMixers reports
+var mixerTypeConditions = map[string]string{
+	"Whirlpool": "(heuristic_flags & 8) > 0",
+	"WabiSabi":  "(heuristic_flags & 8) = 0 AND (heuristic_flags & 8388608) > 0",
+}
+
+// MixerTypes returns the mixerType values GetMixers can filter on
+func MixerTypes() []string {
+	return []string{"Whirlpool", "WabiSabi"}
+}
+
+// mixerWhere builds the WHERE clause shared by the GetMixers count and
+// data queries; values are bound as $1..$n
+func mixerWhere(f MixerFilter) (string, []any, error) {
+	conds := []string{"((heuristic_flags & 8) > 0 OR (heuristic_flags & 8388608) > 0)"}
+	var args []any
+	if f.MixerType != "" {
+		cond, ok := mixerTypeConditions[f.MixerType]
+		if !ok {
+			return "", nil, fmt.Errorf("invalid mixer type: %s", f.MixerType)
+		}
+		conds = append(conds, cond)
+	}
+	if f.MinAnonset > 0 {
+		args = append(args, f.MinAnonset)
+		conds = append(conds, fmt.Sprintf("anonset_local >= $%d", len(args)))
+	}
+	if f.FromHeight > 0 {
+		args = append(args, f.FromHeight)
+		conds = append(conds, fmt.Sprintf("block_height >= $%d", len(args)))
+	}
+	if f.ToHeight > 0 {
+		args = append(args, f.ToHeight)
+		conds = append(conds, fmt.Sprintf("block_height <= $%d", len(args)))
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args, nil
+}
+
+// txHeuristicsLatest reads one tx_heuristics row per txid, the highest
+// block height. A mempool detection is stored at the tip it was first seen
+// at, so the row the scanner later writes at the confirming block
+// supersedes it instead of counting the CoinJoin twice.
+const txHeuristicsLatest = `(SELECT DISTINCT ON (txid) * FROM tx_heuristics ORDER BY txid, block_height DESC)`
+
+func (s *PostgresStore) GetMixers(ctx context.Context, page int, limit int, filter MixerFilter) ([]MixerInfo, int, error) {
 	if limit <= 0 || limit > 500 {
 		limit = 50
 	}
@@ -154,22 +674,27 @@ func (s *PostgresStore) GetMixers(ctx context.Context, page int, limit int) ([]M
 	}
 	offset := (page - 1) * limit
 
-	// Get total count first
+	where, args, err := mixerWhere(filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Get total count first (same filters as the page)
 	var totalCount int
-	countSQL := `SELECT COUNT(*) FROM tx_heuristics WHERE (heuristic_flags & 8) > 0 OR (heuristic_flags & 8388608) > 0`
-	err := s.pool.QueryRow(ctx, countSQL).Scan(&totalCount)
+	countSQL := `SELECT COUNT(*) FROM ` + txHeuristicsLatest + ` h ` + where
+	err = s.pool.QueryRow(ctx, countSQL, args...).Scan(&totalCount)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	dataSQL := `
+	dataSQL := fmt.Sprintf(`
 		SELECT block_height, txid, heuristic_flags, anonset_local
-		FROM tx_heuristics 
-		WHERE (heuristic_flags & 8) > 0 OR (heuristic_flags & 8388608) > 0
+		FROM %s h
+		%s
 		ORDER BY block_height DESC
-		LIMIT $1 OFFSET $2
-	`
-	rows, err := s.pool.Query(ctx, dataSQL, limit, offset)
+		LIMIT $%d OFFSET $%d
+	`, txHeuristicsLatest, where, len(args)+1, len(args)+2)
+	rows, err := s.pool.Query(ctx, dataSQL, append(args, limit, offset)...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -202,6 +727,226 @@ func (s *PostgresStore) GetMixers(ctx context.Context, page int, limit int) ([]M
 	return mixers, totalCount, nil
 }
 
+// DashboardStats aggregates persisted analysis totals for the /stats overview
+type DashboardStats struct {
+	TotalAnalyzed   int64            `json:"totalAnalyzed"`   // Rows in risk_assessments
+	TotalCoinJoins  int64            `json:"totalCoinJoins"`  // Distinct txids in tx_heuristics
+	CoinJoinsByType map[string]int64 `json:"coinJoinsByType"` // Whirlpool / WabiSabi / JoinMarket / CoinJoin
+	HighRiskTxs     int64            `json:"highRiskTxs"`     // risk_level high or critical
+	LatestBlock     int              `json:"latestBlock"`     // Highest analyzed block height
+	RiskLevels      map[string]int64 `json:"riskLevels"`      // risk_assessments rows by risk_level
+	DailyCoinJoins  []DailyCoinJoins `json:"dailyCoinJoins"`  // Last dashboardTrendDays days with detections, oldest first
+}
+
+// DailyCoinJoins is one day of the CoinJoin trend (UTC, by block time)
+type DailyCoinJoins struct {
+	Day       string `json:"day"` // YYYY-MM-DD
+	Count     int64  `json:"count"`
+	ValueSats int64  `json:"valueSats"` // Total output value of the day's CoinJoins
+}
+
+// dashboardTrendDays is the lookback of DashboardStats.DailyCoinJoins
+const dashboardTrendDays = 30
+
+// GetDashboardStats computes aggregate counts across the analysis tables.
+// CoinJoin types are classified with the same flag precedence as GetMixers.
+func (s *PostgresStore) GetDashboardStats(ctx context.Context) (DashboardStats, error) {
+	stats := DashboardStats{CoinJoinsByType: make(map[string]int64)}
+
+	riskSQL := `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE risk_level IN ('high', 'critical')),
+		       COALESCE(MAX(block_height), 0)
+		FROM risk_assessments
+	`
+	if err := s.pool.QueryRow(ctx, riskSQL).Scan(&stats.TotalAnalyzed, &stats.HighRiskTxs, &stats.LatestBlock); err != nil {
+		return stats, fmt.Errorf("failed to aggregate risk assessments: %v", err)
+	}
+
+	// 8 = Whirlpool, 8388608 = WabiSabi, 2199040032768 = JoinMarket | JoinMarket bond
+	mixerSQL := `
+		SELECT
+			COUNT(*) FILTER (WHERE (heuristic_flags & 8) > 0),
+			COUNT(*) FILTER (WHERE (heuristic_flags & 8) = 0 AND (heuristic_flags & 8388608) > 0),
+			COUNT(*) FILTER (WHERE (heuristic_flags & 8) = 0 AND (heuristic_flags & 8388608) = 0 AND (heuristic_flags & 2199040032768) > 0),
+			COUNT(*)
+		FROM ` + txHeuristicsLatest + ` h
+	`
+	var whirlpool, wabisabi, joinmarket int64
+	if err := s.pool.QueryRow(ctx, mixerSQL).Scan(&whirlpool, &wabisabi, &joinmarket, &stats.TotalCoinJoins); err != nil {
+		return stats, fmt.Errorf("failed to aggregate coinjoins: %v", err)
+	}
+	stats.CoinJoinsByType["Whirlpool"] = whirlpool
+	stats.CoinJoinsByType["WabiSabi"] = wabisabi
+	stats.CoinJoinsByType["JoinMarket"] = joinmarket
+	stats.CoinJoinsByType["CoinJoin"] = stats.TotalCoinJoins - whirlpool - wabisabi - joinmarket
+
+	stats.RiskLevels = make(map[string]int64)
+	levelRows, err := s.pool.Query(ctx, `SELECT risk_level, COUNT(*) FROM risk_assessments GROUP BY risk_level`)
+	if err != nil {
+		return stats, fmt.Errorf("failed to aggregate risk levels: %v", err)
+	}
+	defer levelRows.Close()
+	for levelRows.Next() {
+		var level string
+		var n int64
+		if err := levelRows.Scan(&level, &n); err != nil {
+			return stats, fmt.Errorf("failed to aggregate risk levels: %v", err)
+		}
+		stats.RiskLevels[level] = n
+	}
+	if err := levelRows.Err(); err != nil {
+		return stats, fmt.Errorf("failed to aggregate risk levels: %v", err)
+	}
+
+	// Rows stored before detection times were recorded (block_time 0) are left out
+	trendSQL := `
+		SELECT to_char(to_timestamp(h.block_time) AT TIME ZONE 'UTC', 'YYYY-MM-DD') AS day,
+		       COUNT(*),
+		       COALESCE(SUM(r.total_value_sats), 0)
+		FROM ` + txHeuristicsLatest + ` h
+		LEFT JOIN risk_assessments r ON r.txid = h.txid
+		WHERE h.block_time >= EXTRACT(EPOCH FROM NOW())::BIGINT - $1 * 86400
+		GROUP BY day
+		ORDER BY day ASC
+	`
+	trendRows, err := s.pool.Query(ctx, trendSQL, dashboardTrendDays)
+	if err != nil {
+		return stats, fmt.Errorf("failed to aggregate coinjoin trend: %v", err)
+	}
+	defer trendRows.Close()
+	stats.DailyCoinJoins = make([]DailyCoinJoins, 0, dashboardTrendDays)
+	for trendRows.Next() {
+		var d DailyCoinJoins
+		if err := trendRows.Scan(&d.Day, &d.Count, &d.ValueSats); err != nil {
+			return stats, fmt.Errorf("failed to aggregate coinjoin trend: %v", err)
+		}
+		stats.DailyCoinJoins = append(stats.DailyCoinJoins, d)
+	}
+	if err := trendRows.Err(); err != nil {
+		return stats, fmt.Errorf("failed to aggregate coinjoin trend: %v", err)
+	}
+
+	return stats, nil
+}
+
+// StoredAnalysis is a persisted tx_heuristics row
+type StoredAnalysis struct {
+	Txid           string          `json:"txid"`
+	BlockHeight    int             `json:"blockHeight"`
+	BlockTime      int64           `json:"blockTime"`
+	HeuristicFlags int64           `json:"heuristicFlags"`
+	AnonsetLocal   int             `json:"anonsetLocal"`
+	Analysis       json.RawMessage `json:"analysis,omitempty"` // Full PrivacyAnalysisResult; absent if stored without STORE_FULL_ANALYSIS
+}
+
+// GetAnalysis returns the most recent stored analysis of txid, preferring
+// rows that carry the full result; nil if the transaction was never stored
+func (s *PostgresStore) GetAnalysis(ctx context.Context, txid string) (*StoredAnalysis, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT txid, block_height, block_time, heuristic_flags, COALESCE(anonset_local, 0), analysis
+		FROM tx_heuristics
+		WHERE txid = $1
+		ORDER BY (analysis IS NOT NULL) DESC, block_height DESC
+		LIMIT 1;
+	`, txid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query analysis: %v", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return nil, rows.Err()
+	}
+	var a StoredAnalysis
+	var analysis []byte
+	if err := rows.Scan(&a.Txid, &a.BlockHeight, &a.BlockTime, &a.HeuristicFlags, &a.AnonsetLocal, &analysis); err != nil {
+		return nil, err
+	}
+	if analysis != nil {
+		a.Analysis = analysis
+	}
+	return &a, nil
+}
+
+// Time-series intervals and metrics accepted by GetCoinJoinTimeSeries
+const (
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+	MetricCount  = "count"
+	MetricValue  = "value"
+)
+
+// TimeSeriesBucket is one interval of CoinJoin activity, split by mixer
+// type (Whirlpool / WabiSabi / JoinMarket / CoinJoin)
+type TimeSeriesBucket struct {
+	Start  string           `json:"start"` // YYYY-MM-DD (UTC); weeks start on Monday
+	ByType map[string]int64 `json:"byType"`
+	Total  int64            `json:"total"`
+}
+
+// mixerTypeCase labels a tx_heuristics row with the same flag precedence
+// as GetDashboardStats
+const mixerTypeCase = `CASE
+			WHEN (h.heuristic_flags & 8) > 0 THEN 'Whirlpool'
+			WHEN (h.heuristic_flags & 8388608) > 0 THEN 'WabiSabi'
+			WHEN (h.heuristic_flags & 2199040032768) > 0 THEN 'JoinMarket'
+			ELSE 'CoinJoin'
+		END`
+
+// GetCoinJoinTimeSeries buckets stored CoinJoin detections with a
+// detection time in [from, to) by interval (IntervalDay / IntervalWeek)
+// and mixer type. MetricCount counts transactions; MetricValue sums their
+// total output value (sats) from risk_assessments.
+func (s *PostgresStore) GetCoinJoinTimeSeries(ctx context.Context, interval, metric string, from, to time.Time) ([]TimeSeriesBucket, error) {
+	if interval != IntervalDay && interval != IntervalWeek {
+		return nil, fmt.Errorf("invalid interval: %s", interval)
+	}
+	measure := "COUNT(*)"
+	switch metric {
+	case MetricCount:
+	case MetricValue:
+		measure = "COALESCE(SUM(r.total_value_sats), 0)"
+	default:
+		return nil, fmt.Errorf("invalid metric: %s", metric)
+	}
+
+	sql := fmt.Sprintf(`
+		SELECT to_char(date_trunc('%s', to_timestamp(h.block_time) AT TIME ZONE 'UTC'), 'YYYY-MM-DD') AS bucket,
+		       %s AS mixer_type,
+		       %s
+		FROM %s h
+		LEFT JOIN risk_assessments r ON r.txid = h.txid
+		WHERE h.block_time >= $1 AND h.block_time < $2
+		GROUP BY bucket, mixer_type
+		ORDER BY bucket ASC
+	`, interval, mixerTypeCase, measure, txHeuristicsLatest)
+	rows, err := s.pool.Query(ctx, sql, from.Unix(), to.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	buckets := make([]TimeSeriesBucket, 0)
+	for rows.Next() {
+		var start, mixerType string
+		var v int64
+		if err := rows.Scan(&start, &mixerType, &v); err != nil {
+			return nil, err
+		}
+		if len(buckets) == 0 || buckets[len(buckets)-1].Start != start {
+			buckets = append(buckets, TimeSeriesBucket{Start: start, ByType: make(map[string]int64)})
+		}
+		b := &buckets[len(buckets)-1]
+		b.ByType[mixerType] = v
+		b.Total += v
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return buckets, nil
+}
+
 // GetPool exposes the connection pool for the shadow runner and other subsystems
 func (s *PostgresStore) GetPool() *pgxpool.Pool {
 	return s.pool
@@ -216,18 +961,24 @@ type InvestigationSeed struct {
 }
 
 // SaveInvestigation upserts investigation metadata for durable case storage.
-func (s *PostgresStore) SaveInvestigation(ctx context.Context, caseID, name, description string, totalStolen int64) error {
+func (s *PostgresStore) SaveInvestigation(ctx context.Context, rec models.InvestigationRecord) error {
 	sql := `
-		INSERT INTO investigations (case_id, name, description, total_stolen, status)
-		VALUES ($1, $2, $3, $4, 'active')
+		INSERT INTO investigations (case_id, name, description, total_stolen, total_recovered, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 		ON CONFLICT (case_id) DO UPDATE SET
 			name = EXCLUDED.name,
 			description = EXCLUDED.description,
 			total_stolen = EXCLUDED.total_stolen,
-			status = 'active',
-			updated_at = NOW();
+			total_recovered = EXCLUDED.total_recovered,
+			status = EXCLUDED.status,
+			updated_at = EXCLUDED.updated_at;
 	`
-	_, err := s.pool.Exec(ctx, sql, caseID, name, description, totalStolen)
+	status := rec.Status
+	if status == "" {
+		status = "active"
+	}
+	_, err := s.pool.Exec(ctx, sql, rec.CaseID, rec.Name, rec.Description, rec.TotalStolen, rec.TotalRecovered,
+		status, rec.CreatedAt, rec.UpdatedAt)
 	return err
 }
 
@@ -295,6 +1046,50 @@ func (s *PostgresStore) LoadActiveInvestigationSeeds(ctx context.Context) ([]Inv
 	return seeds, nil
 }
 
+// LoadInvestigations loads every investigation with its tagged addresses,
+// oldest first, for rebuilding the case manager on boot.
+func (s *PostgresStore) LoadInvestigations(ctx context.Context) ([]models.InvestigationRecord, error) {
+	sql := `
+		SELECT i.case_id, i.name, COALESCE(i.description, ''), COALESCE(i.status, 'active'),
+			COALESCE(i.total_stolen, 0), COALESCE(i.total_recovered, 0),
+			COALESCE(i.created_at, NOW()), COALESCE(i.updated_at, NOW()),
+			a.address, COALESCE(a.label, ''), a.role, COALESCE(a.notes, ''), COALESCE(a.tagged_by, ''),
+			COALESCE(a.tagged_at, NOW())
+		FROM investigations i
+		LEFT JOIN investigation_addresses a ON a.investigation_id = i.id
+		ORDER BY i.id, a.id;
+	`
+	rows, err := s.pool.Query(ctx, sql)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load investigations: %v", err)
+	}
+	defer rows.Close()
+
+	records := make([]models.InvestigationRecord, 0)
+	for rows.Next() {
+		var rec models.InvestigationRecord
+		var address, role *string
+		var addr models.InvestigationAddressRecord
+		if err := rows.Scan(&rec.CaseID, &rec.Name, &rec.Description, &rec.Status,
+			&rec.TotalStolen, &rec.TotalRecovered, &rec.CreatedAt, &rec.UpdatedAt,
+			&address, &addr.Label, &role, &addr.Notes, &addr.TaggedBy, &addr.TaggedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan investigation: %v", err)
+		}
+		if n := len(records); n == 0 || records[n-1].CaseID != rec.CaseID {
+			records = append(records, rec)
+		}
+		if address != nil && role != nil {
+			addr.Address, addr.Role = *address, *role
+			last := &records[len(records)-1]
+			last.Addresses = append(last.Addresses, addr)
+		}
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return records, nil
+}
+
 // SaveRiskAssessment persists the risk assessment for ANY analyzed transaction.
 // Unlike SaveAnalysisResult (which only stores CoinJoin-flagged txs), this
 // stores a risk row for every tx processed by the pipeline, enabling
@@ -7,18 +7,22 @@ CREATE TABLE IF NOT EXISTS evidence_edge (
     created_height    INT NOT NULL,
     src_node_id       VARCHAR(255) NOT NULL,    -- Changed from BIGINT since Transaction models use Address strings
     dst_node_id       VARCHAR(255) NOT NULL,
-    edge_type         SMALLINT NOT NULL,        -- 1=CIOH, 2=Change, 3=NegativeGating, 4=PayJoinSuspect
+    edge_type         SMALLINT NOT NULL,        -- 1=CIOH, 2=Change, 3=NegativeGating, 4=PayJoinSuspect, 11=TimingCorrelation
     llr_score         REAL NOT NULL,            -- Log-Likelihood Ratio (Calibrated Probability)
     dependency_group  INT NOT NULL,             -- Handles correlated feature discounting
     snapshot_id       BIGINT NOT NULL,          -- Tied to the specific heuristics version release
-    audit_hash        VARCHAR(64) NOT NULL      -- Hex-encoded SHA256
+    audit_hash        VARCHAR(64) NOT NULL,     -- Hex-encoded SHA256
+    members           TEXT[] NULL               -- CIOH hyper-edge: inputs bound to src_node_id (dst is consolidation:<txid>)
 );
+ALTER TABLE evidence_edge ADD COLUMN IF NOT EXISTS members TEXT[] NULL;
 
 -- BRIN Index for massive temporal scanning
 CREATE INDEX IF NOT EXISTS idx_evidence_edge_height ON evidence_edge USING BRIN (created_height);
 -- Partial B-Tree indexes for fast policy lookups
 CREATE INDEX IF NOT EXISTS idx_evidence_edge_src_type ON evidence_edge (src_node_id, edge_type);
 CREATE INDEX IF NOT EXISTS idx_evidence_edge_dst_type ON evidence_edge (dst_node_id, edge_type);
+-- Hyper-edge member lookups for evidence chain assembly
+CREATE INDEX IF NOT EXISTS idx_evidence_edge_members ON evidence_edge USING GIN (members);
 
 -- Computed transaction heuristics for high-QPS filtering
 CREATE TABLE IF NOT EXISTS tx_heuristics (
@@ -28,6 +32,12 @@ CREATE TABLE IF NOT EXISTS tx_heuristics (
     anonset_local     SMALLINT NULL,            -- The derived AnonSet size (e.g. 5 for Whirlpool)
     PRIMARY KEY (block_height, txid)
 );
+-- Detection time for time-series charts: block time, or first-seen time for mempool detections
+ALTER TABLE tx_heuristics ADD COLUMN IF NOT EXISTS block_time BIGINT NOT NULL DEFAULT 0;   -- unix seconds
+CREATE INDEX IF NOT EXISTS idx_tx_heuristics_block_time ON tx_heuristics USING BRIN (block_time);
+-- Full PrivacyAnalysisResult, written only when STORE_FULL_ANALYSIS is enabled
+ALTER TABLE tx_heuristics ADD COLUMN IF NOT EXISTS analysis JSONB NULL;
+CREATE INDEX IF NOT EXISTS idx_tx_heuristics_txid ON tx_heuristics (txid);
 
 -- ============================================================
 -- Time-Evolving Anonymity Windows
@@ -46,6 +56,10 @@ CREATE TABLE IF NOT EXISTS anonset_windows (
 );
 
 CREATE INDEX IF NOT EXISTS idx_anonset_windows_txid ON anonset_windows (txid);
+-- Denomination + confirmation time: window recomputation counts later equal-value outputs
+ALTER TABLE anonset_windows ADD COLUMN IF NOT EXISTS value_sats BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE anonset_windows ADD COLUMN IF NOT EXISTS block_time BIGINT NOT NULL DEFAULT 0;   -- unix seconds
+CREATE INDEX IF NOT EXISTS idx_anonset_windows_denom ON anonset_windows (value_sats, block_time);
 
 -- ============================================================
 -- Shadow-Mode Deployment Framework
@@ -62,6 +76,13 @@ CREATE TABLE IF NOT EXISTS shadow_results (
 );
 
 CREATE INDEX IF NOT EXISTS idx_shadow_results_txid ON shadow_results (txid);
+-- Block-range re-analysis: per-tx privacy score and AnonSet on both sides
+ALTER TABLE shadow_results ADD COLUMN IF NOT EXISTS block_height INT NULL;
+ALTER TABLE shadow_results ADD COLUMN IF NOT EXISTS production_score SMALLINT NULL;
+ALTER TABLE shadow_results ADD COLUMN IF NOT EXISTS shadow_score SMALLINT NULL;
+ALTER TABLE shadow_results ADD COLUMN IF NOT EXISTS production_anonset SMALLINT NULL;
+ALTER TABLE shadow_results ADD COLUMN IF NOT EXISTS shadow_anonset SMALLINT NULL;
+CREATE INDEX IF NOT EXISTS idx_shadow_results_snapshot_height ON shadow_results (snapshot_id, block_height);
 
 -- ============================================================
 -- Incident Response & Fund Tracking (Phase 18)
@@ -115,6 +136,68 @@ CREATE INDEX IF NOT EXISTS idx_fund_flows_investigation ON fund_flows (investiga
 CREATE INDEX IF NOT EXISTS idx_fund_flows_from ON fund_flows (from_address);
 CREATE INDEX IF NOT EXISTS idx_fund_flows_to ON fund_flows (to_address);
 
+-- ============================================================
+-- Address Clusters (entity resolution survives restarts)
+-- ============================================================
+-- One row per address in a multi-address cluster. When union-by-rank
+-- absorbs a root, its members are re-pointed by root_address.
+CREATE TABLE IF NOT EXISTS address_clusters (
+    address           VARCHAR(255) PRIMARY KEY,
+    root_address      VARCHAR(255) NOT NULL,
+    snapshot_id       BIGINT NOT NULL,          -- Heuristics version that produced the membership
+    updated_at        TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_address_clusters_root ON address_clusters (root_address);
+
+-- ============================================================
+-- Address Labels (known-entity attribution)
+-- ============================================================
+-- One label per address (exchange/service/mining_pool/...), imported via
+-- /api/v1/labels and warm-loaded into the in-memory label index.
+CREATE TABLE IF NOT EXISTS address_labels (
+    address           VARCHAR(255) PRIMARY KEY,
+    label             VARCHAR(255) NOT NULL,
+    category          VARCHAR(32) NOT NULL,
+    source            VARCHAR(255) NOT NULL DEFAULT 'manual',
+    confidence        REAL NOT NULL DEFAULT 1.0,     -- 0.0 to 1.0
+    updated_at        TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_address_labels_category ON address_labels (category);
+
+-- ============================================================
+-- Taint Scores (propagated taint survives restarts)
+-- ============================================================
+-- One row per tainted address, seeds and propagated alike. Upserts keep
+-- the highest taint and the shortest hop distance seen.
+CREATE TABLE IF NOT EXISTS taint_scores (
+    address           VARCHAR(255) PRIMARY KEY,
+    taint_level       REAL NOT NULL,                 -- 0.0 (clean) to 1.0 (fully tainted)
+    category          VARCHAR(30) NOT NULL DEFAULT '', -- Seed category ('' = propagated)
+    hops_from_source  INT NOT NULL DEFAULT 0,        -- 0 = seed
+    updated_at        TIMESTAMP DEFAULT NOW()
+);
+
+-- ============================================================
+-- Block Summaries (per-block rollups for trend charts)
+-- ============================================================
+-- Written once a block scan finishes; rescanning a block replaces its row.
+CREATE TABLE IF NOT EXISTS block_summaries (
+    block_height       INT PRIMARY KEY,
+    block_hash         VARCHAR(64) NOT NULL,
+    block_time         BIGINT NOT NULL DEFAULT 0,     -- Block timestamp (unix seconds)
+    tx_count           INT NOT NULL DEFAULT 0,        -- Analyzed txs (coinbase excluded)
+    coinjoin_count     INT NOT NULL DEFAULT 0,
+    coinjoins_by_type  JSONB NOT NULL DEFAULT '{}',   -- {"Whirlpool": 2, "WabiSabi": 1, ...}
+    total_value_sats   BIGINT NOT NULL DEFAULT 0,
+    avg_privacy_score  REAL NOT NULL DEFAULT 0,
+    wallet_families    JSONB NOT NULL DEFAULT '{}',   -- Attributed wallet family → tx count
+    dominant_wallets   TEXT[] NOT NULL DEFAULT '{}',
+    snapshot_id        BIGINT NOT NULL,               -- Heuristics version that produced the rollup
+    updated_at         TIMESTAMP DEFAULT NOW()
+);
+
 -- ============================================================
 -- Risk Assessments (Sprint 1 — persist ALL analyzed txs)
 -- ============================================================
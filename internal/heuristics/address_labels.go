@@ -0,0 +1,223 @@
+package heuristics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Address Label Store
+//
+// Attributes addresses to known entities (exchanges, services, pools)
+// beyond the hard-coded exchange prefixes and per-case investigation tags.
+// Labels are persisted in address_labels and warm-loaded into this
+// process-wide index at boot; the index is consulted by:
+//
+//   - ExchangeLabel / DetectExchangeExit: exchange-labeled addresses are
+//     cash-out points, so traces mark them via FlowGraph.MarkExchangeExit
+//   - AnalyzeTx: labeled inputs and outputs are reported on the result
+//   - ScoreTransaction: outputs paying a labeled exchange are marked
+//
+// Unlike the watchlist, a label never raises an alert on its own: a busy
+// exchange hot wallet appears in thousands of transactions a day.
+
+// Label categories
+const (
+	LabelCategoryExchange   = "exchange"
+	LabelCategoryService    = "service"
+	LabelCategoryMiningPool = "mining_pool"
+	LabelCategoryMixer      = "mixer"
+	LabelCategoryMerchant   = "merchant"
+	LabelCategoryGambling   = "gambling"
+	LabelCategoryDarknet    = "darknet"
+	LabelCategoryOther      = "other"
+)
+
+var labelCategories = map[string]bool{
+	LabelCategoryExchange:   true,
+	LabelCategoryService:    true,
+	LabelCategoryMiningPool: true,
+	LabelCategoryMixer:      true,
+	LabelCategoryMerchant:   true,
+	LabelCategoryGambling:   true,
+	LabelCategoryDarknet:    true,
+	LabelCategoryOther:      true,
+}
+
+// LabelCategories returns the accepted label categories, sorted
+func LabelCategories() []string {
+	list := make([]string, 0, len(labelCategories))
+	for c := range labelCategories {
+		list = append(list, c)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// IsLabelCategory reports whether category is an accepted label category
+func IsLabelCategory(category string) bool {
+	return labelCategories[category]
+}
+
+const defaultLabelSource = "manual"
+
+// NormalizeAddressLabel validates l and fills its defaults: the address is
+// normalized, the category lowercased, an empty source becomes "manual"
+// and a zero confidence becomes 1
+func NormalizeAddressLabel(l models.AddressLabel) (models.AddressLabel, error) {
+	addr, ok := parseListedAddress(strings.TrimSpace(l.Address))
+	if !ok {
+		return l, fmt.Errorf("invalid address %q", l.Address)
+	}
+	l.Address = addr
+	l.Label = strings.TrimSpace(l.Label)
+	if l.Label == "" {
+		return l, fmt.Errorf("label is required")
+	}
+	l.Category = strings.ToLower(strings.TrimSpace(l.Category))
+	if !labelCategories[l.Category] {
+		return l, fmt.Errorf("unknown category %q", l.Category)
+	}
+	l.Source = strings.TrimSpace(l.Source)
+	if l.Source == "" {
+		l.Source = defaultLabelSource
+	}
+	if l.Confidence == 0 {
+		l.Confidence = 1
+	}
+	if l.Confidence < 0 || l.Confidence > 1 {
+		return l, fmt.Errorf("confidence must be within (0, 1]")
+	}
+	if l.UpdatedAt.IsZero() {
+		l.UpdatedAt = time.Now().UTC()
+	}
+	return l, nil
+}
+
+// AddressLabelIndex is a concurrent-safe address → label map
+type AddressLabelIndex struct {
+	mu     sync.RWMutex
+	labels map[string]models.AddressLabel
+}
+
+var (
+	globalAddressLabels     *AddressLabelIndex
+	globalAddressLabelsOnce sync.Once
+)
+
+// NewAddressLabelIndex creates an empty index
+func NewAddressLabelIndex() *AddressLabelIndex {
+	return &AddressLabelIndex{labels: make(map[string]models.AddressLabel)}
+}
+
+// GetGlobalAddressLabels returns the process-wide index shared by the
+// analysis pipeline, the tracer and the labels API
+func GetGlobalAddressLabels() *AddressLabelIndex {
+	globalAddressLabelsOnce.Do(func() {
+		globalAddressLabels = NewAddressLabelIndex()
+	})
+	return globalAddressLabels
+}
+
+// Set validates l and stores it, replacing any label of the address
+func (idx *AddressLabelIndex) Set(l models.AddressLabel) (models.AddressLabel, error) {
+	l, err := NormalizeAddressLabel(l)
+	if err != nil {
+		return l, err
+	}
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.labels[l.Address] = l
+	return l, nil
+}
+
+// Load stores previously validated labels (the DB warm-load) and returns
+// how many were accepted
+func (idx *AddressLabelIndex) Load(labels []models.AddressLabel) int {
+	loaded := 0
+	for _, l := range labels {
+		if _, err := idx.Set(l); err == nil {
+			loaded++
+		}
+	}
+	return loaded
+}
+
+// Get returns the label of addr
+func (idx *AddressLabelIndex) Get(addr string) (models.AddressLabel, bool) {
+	addr = NormalizeAddress(addr)
+	if addr == "" {
+		return models.AddressLabel{}, false
+	}
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	l, ok := idx.labels[addr]
+	return l, ok
+}
+
+// Remove deletes the label of addr, reporting whether one existed
+func (idx *AddressLabelIndex) Remove(addr string) bool {
+	addr = NormalizeAddress(addr)
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if _, ok := idx.labels[addr]; !ok {
+		return false
+	}
+	delete(idx.labels, addr)
+	return true
+}
+
+// Size returns the number of labeled addresses
+func (idx *AddressLabelIndex) Size() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.labels)
+}
+
+// List returns the labels of a category ("" for all), ordered by address
+func (idx *AddressLabelIndex) List(category string) []models.AddressLabel {
+	idx.mu.RLock()
+	list := make([]models.AddressLabel, 0, len(idx.labels))
+	for _, l := range idx.labels {
+		if category == "" || l.Category == category {
+			list = append(list, l)
+		}
+	}
+	idx.mu.RUnlock()
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Address < list[j].Address })
+	return list
+}
+
+// LabelTransaction returns the global labels of tx's inputs and outputs
+func LabelTransaction(tx models.Transaction) []models.LabelHit {
+	idx := GetGlobalAddressLabels()
+	if idx.Size() == 0 {
+		return nil
+	}
+
+	var hits []models.LabelHit
+	match := func(direction string, i int, addr string) {
+		if l, ok := idx.Get(addr); ok {
+			hits = append(hits, models.LabelHit{
+				Direction:  direction,
+				Index:      i,
+				Address:    addr,
+				Label:      l.Label,
+				Category:   l.Category,
+				Confidence: l.Confidence,
+			})
+		}
+	}
+	for i, in := range tx.Inputs {
+		match("input", i, in.Address)
+	}
+	for i, out := range tx.Outputs {
+		match("output", i, out.Address)
+	}
+	return hits
+}
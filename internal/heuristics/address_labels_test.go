@@ -0,0 +1,60 @@
+package heuristics
+
+import (
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+const labeledDeposit = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+
+func TestNormalizeAddressLabel(t *testing.T) {
+	l, err := NormalizeAddressLabel(models.AddressLabel{Address: " BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ ", Label: "Kraken", Category: "Exchange"})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if l.Address != labeledDeposit || l.Category != LabelCategoryExchange || l.Source != "manual" || l.Confidence != 1 {
+		t.Errorf("Expected normalized defaults. Got %+v", l)
+	}
+
+	for _, bad := range []models.AddressLabel{
+		{Address: "not-an-address", Label: "Kraken", Category: "exchange"},
+		{Address: labeledDeposit, Category: "exchange"},
+		{Address: labeledDeposit, Label: "Kraken", Category: "bank"},
+		{Address: labeledDeposit, Label: "Kraken", Category: "exchange", Confidence: 1.5},
+	} {
+		if _, err := NormalizeAddressLabel(bad); err == nil {
+			t.Errorf("Expected %+v to be rejected", bad)
+		}
+	}
+}
+
+func TestAddressLabels_FeedExchangeDetection(t *testing.T) {
+	labels := GetGlobalAddressLabels()
+	if _, err := labels.Set(models.AddressLabel{Address: labeledDeposit, Label: "Kraken", Category: "exchange", Confidence: 0.9}); err != nil {
+		t.Fatal(err)
+	}
+	defer labels.Remove(labeledDeposit)
+
+	if dep := ClassifyExchangeDeposit(labeledDeposit, nil); !dep.IsDeposit || dep.ExchangeName != "Kraken" || dep.Method != "label" {
+		t.Errorf("Expected a labeled trace exit. Got %+v", dep)
+	}
+
+	tx := models.Transaction{
+		Txid:    "deposit",
+		Inputs:  []models.TxIn{{Address: "bc1qsenderinputaaaaaaaaaaaaaaaaaaaaaaaaaa", Value: 600_000}},
+		Outputs: []models.TxOut{{Address: labeledDeposit, Value: 500_000}, {Address: "bc1qchange", Value: 99_000}},
+	}
+	if exit := DetectExchangeExit(tx); !exit.IsExchangeDeposit || exit.DetectionMethod != "address_label" || exit.Confidence != 0.9 {
+		t.Errorf("Expected an address_label exchange exit. Got %+v", exit)
+	}
+
+	res := AnalyzeTx(tx)
+	if len(res.Labels) != 1 || res.Labels[0].Direction != "output" || res.Labels[0].Index != 0 {
+		t.Fatalf("Expected the labeled output on the analysis. Got %+v", res.Labels)
+	}
+	assessment := ScoreTransaction(tx, res, nil)
+	if len(assessment.ExchangeDeposits) != 1 || assessment.ExchangeDeposits[0] != "Kraken" {
+		t.Errorf("Expected the Kraken deposit marked. Got %+v", assessment)
+	}
+}
@@ -1,8 +1,11 @@
 package heuristics
 
 import (
+	"fmt"
+	"log"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rawblock/coinjoin-engine/pkg/models"
@@ -12,7 +15,9 @@ import (
 //
 // Concurrent-safe address monitoring for incident response. Every
 // mempool transaction is checked against the watchlist. When a
-// watched address appears as input or output, an alert fires.
+// watched address appears as input or output, an alert fires. Hits carry
+// their direction: an input-side hit means the watched funds are moving
+// and escalates to a "watchlisted funds moving" alert.
 //
 // Performance: O(1) lookup using map-based set.
 // Concurrency: sync.RWMutex allows concurrent reads during the
@@ -25,6 +30,46 @@ import (
 //   exchange   — Known exchange deposit/withdrawal addresses
 //   sanctioned — OFAC/SDN listed addresses
 //   service    — Known service addresses (mixing, gambling, etc)
+//
+// Address normalization: bech32/bech32m addresses are case-insensitive
+// (BIP173) but canonically lowercase on-chain, while base58 is
+// case-sensitive. Keys are stored and looked up via NormalizeAddress so an
+// analyst-entered "BC1Q..." still matches the lowercase form in blocks.
+//
+// Wallet-level watching: Add also accepts an xpub or output descriptor
+// (see xpub_descriptor.go). Its derived addresses are watched like any
+// other, and hits carry the originating descriptor.
+
+// bech32HRPs are the human-readable parts of segwit addresses (mainnet, testnet/signet, regtest)
+var bech32HRPs = []string{"bc1", "tb1", "bcrt1"}
+
+var bech32CaseFolding atomic.Bool
+
+func init() {
+	bech32CaseFolding.Store(true)
+}
+
+// SetBech32CaseFolding toggles lowercasing of bech32 addresses in
+// NormalizeAddress (enabled by default; WATCHLIST_BECH32_CASE_FOLD)
+func SetBech32CaseFolding(enabled bool) {
+	bech32CaseFolding.Store(enabled)
+}
+
+// NormalizeAddress returns the canonical matching key for an address:
+// whitespace trimmed, bech32 lowercased, base58 case preserved.
+func NormalizeAddress(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if !bech32CaseFolding.Load() {
+		return addr
+	}
+	lower := strings.ToLower(addr)
+	for _, hrp := range bech32HRPs {
+		if strings.HasPrefix(lower, hrp) {
+			return lower
+		}
+	}
+	return addr
+}
 
 // WatchedAddress holds metadata for a monitored address
 type WatchedAddress struct {
@@ -34,6 +79,13 @@ type WatchedAddress struct {
 	CaseID     string    `json:"caseId"`   // Investigation case reference
 	AddedAt    time.Time `json:"addedAt"`
 	AlertLevel string    `json:"alertLevel"` // info/low/medium/high/critical
+
+	// Set for addresses derived from a watched xpub/descriptor
+	Descriptor     string `json:"descriptor,omitempty"`
+	DerivationPath string `json:"derivationPath,omitempty"` // Relative to the key, e.g. "0/7"
+
+	branch int
+	index  uint32
 }
 
 // WatchlistHit represents a match during transaction scanning
@@ -45,12 +97,23 @@ type WatchlistHit struct {
 	Direction  string `json:"direction"` // "input" or "output"
 	Value      int64  `json:"value"`     // Sats involved
 	AlertLevel string `json:"alertLevel"`
+
+	Descriptor     string `json:"descriptor,omitempty"` // Watched wallet the address was derived from
+	DerivationPath string `json:"derivationPath,omitempty"`
 }
 
 // AddressWatchlist is a concurrent-safe address monitoring engine
 type AddressWatchlist struct {
-	mu        sync.RWMutex
-	addresses map[string]WatchedAddress
+	mu          sync.RWMutex
+	addresses   map[string]WatchedAddress
+	descriptors map[string]*descriptorWatch
+}
+
+// descriptorWatch is a watched xpub/descriptor and its derivation window
+type descriptorWatch struct {
+	desc     *watchDescriptor
+	template WatchedAddress // Category/label/case metadata for derived entries
+	next     []uint32       // Next underived index per branch
 }
 
 var (
@@ -61,7 +124,8 @@ var (
 // NewAddressWatchlist creates a new empty watchlist
 func NewAddressWatchlist() *AddressWatchlist {
 	return &AddressWatchlist{
-		addresses: make(map[string]WatchedAddress),
+		addresses:   make(map[string]WatchedAddress),
+		descriptors: make(map[string]*descriptorWatch),
 	}
 }
 
@@ -74,9 +138,17 @@ func GetGlobalAddressWatchlist() *AddressWatchlist {
 	return globalWatchlist
 }
 
-// Add registers an address for monitoring
+// Add registers an address for monitoring. An xpub or output descriptor
+// is expanded via AddDescriptor; parse failures are logged.
 func (w *AddressWatchlist) Add(addr, category, label, caseID, alertLevel string) {
-	addr = strings.TrimSpace(addr)
+	if IsWatchDescriptor(addr) {
+		if _, err := w.AddDescriptor(addr, category, label, caseID, alertLevel); err != nil {
+			log.Printf("[WatchList] Rejected descriptor for case %s: %v", caseID, err)
+		}
+		return
+	}
+
+	addr = NormalizeAddress(addr)
 	if addr == "" {
 		return
 	}
@@ -94,9 +166,114 @@ func (w *AddressWatchlist) Add(addr, category, label, caseID, alertLevel string)
 	}
 }
 
-// Remove stops monitoring an address
+// AddDescriptor watches every address of an xpub or output descriptor:
+// indices [0, XpubGapLimit()) on each branch, extended as hits arrive.
+// Returns the number of addresses derived.
+func (w *AddressWatchlist) AddDescriptor(descriptor, category, label, caseID, alertLevel string) (int, error) {
+	desc, err := parseWatchDescriptor(descriptor)
+	if err != nil {
+		return 0, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.removeDescriptorLocked(desc.source)
+	dw := &descriptorWatch{
+		desc: desc,
+		template: WatchedAddress{
+			Category:   category,
+			Label:      label,
+			CaseID:     caseID,
+			AddedAt:    time.Now(),
+			AlertLevel: alertLevel,
+			Descriptor: desc.source,
+		},
+		next: make([]uint32, len(desc.branches)),
+	}
+	w.descriptors[desc.source] = dw
+
+	end := uint32(1)
+	if desc.ranged {
+		end = XpubGapLimit()
+	}
+	derived := 0
+	for b := range desc.branches {
+		n, err := w.deriveLocked(dw, b, end)
+		derived += n
+		if err != nil {
+			return derived, err
+		}
+	}
+	return derived, nil
+}
+
+// deriveLocked watches a branch's addresses up to (excluding) index end
+func (w *AddressWatchlist) deriveLocked(dw *descriptorWatch, branch int, end uint32) (int, error) {
+	derived := 0
+	for i := dw.next[branch]; i < end; i++ {
+		addr, err := dw.desc.addressAt(branch, i)
+		if err != nil {
+			return derived, fmt.Errorf("derive %s: %w", dw.desc.pathAt(branch, i), err)
+		}
+		entry := dw.template
+		entry.Address = addr
+		entry.DerivationPath = dw.desc.pathAt(branch, i)
+		entry.branch = branch
+		entry.index = i
+		w.addresses[NormalizeAddress(addr)] = entry
+		dw.next[branch] = i + 1
+		derived++
+	}
+	return derived, nil
+}
+
+// extendDescriptor slides a ranged descriptor's window past a seen index
+func (w *AddressWatchlist) extendDescriptor(descriptor string, branch int, seen uint32) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	dw, ok := w.descriptors[descriptor]
+	if !ok || !dw.desc.ranged {
+		return
+	}
+	if _, err := w.deriveLocked(dw, branch, seen+1+XpubGapLimit()); err != nil {
+		log.Printf("[WatchList] Gap extension failed for %s: %v", descriptor, err)
+	}
+}
+
+// RemoveDescriptor stops monitoring an xpub/descriptor and all its derived addresses
+func (w *AddressWatchlist) RemoveDescriptor(descriptor string) {
+	desc, err := parseWatchDescriptor(descriptor)
+	if err != nil {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.removeDescriptorLocked(desc.source)
+}
+
+func (w *AddressWatchlist) removeDescriptorLocked(source string) {
+	if _, ok := w.descriptors[source]; !ok {
+		return
+	}
+	delete(w.descriptors, source)
+	for key, entry := range w.addresses {
+		if entry.Descriptor == source {
+			delete(w.addresses, key)
+		}
+	}
+}
+
+// Remove stops monitoring an address (or an xpub/descriptor)
 func (w *AddressWatchlist) Remove(addr string) {
-	addr = strings.TrimSpace(addr)
+	if IsWatchDescriptor(addr) {
+		w.RemoveDescriptor(addr)
+		return
+	}
+
+	addr = NormalizeAddress(addr)
 	if addr == "" {
 		return
 	}
@@ -108,7 +285,7 @@ func (w *AddressWatchlist) Remove(addr string) {
 
 // Contains checks if an address is watchlisted (O(1))
 func (w *AddressWatchlist) Contains(addr string) bool {
-	addr = strings.TrimSpace(addr)
+	addr = NormalizeAddress(addr)
 	if addr == "" {
 		return false
 	}
@@ -121,7 +298,7 @@ func (w *AddressWatchlist) Contains(addr string) bool {
 
 // Get returns the watchlist entry for an address
 func (w *AddressWatchlist) Get(addr string) (WatchedAddress, bool) {
-	addr = strings.TrimSpace(addr)
+	addr = NormalizeAddress(addr)
 	if addr == "" {
 		return WatchedAddress{}, false
 	}
@@ -134,53 +311,64 @@ func (w *AddressWatchlist) Get(addr string) (WatchedAddress, bool) {
 
 // CheckTransaction scans a transaction for watchlisted addresses.
 // Returns all hits (may be multiple if both inputs and outputs match).
+// Hits on descriptor-derived addresses extend that descriptor's window.
 func (w *AddressWatchlist) CheckTransaction(tx models.Transaction) []WatchlistHit {
+	hits, seen := w.checkTransaction(tx)
+	for _, entry := range seen {
+		w.extendDescriptor(entry.Descriptor, entry.branch, entry.index)
+	}
+	return hits
+}
+
+// checkTransaction matches a transaction under the read lock, also
+// returning the descriptor-derived entries that were hit
+func (w *AddressWatchlist) checkTransaction(tx models.Transaction) ([]WatchlistHit, []WatchedAddress) {
 	w.mu.RLock()
 	defer w.mu.RUnlock()
 
 	var hits []WatchlistHit
+	var seen []WatchedAddress
+	match := func(addr, direction string, value int64) {
+		if addr == "" {
+			return
+		}
+		entry, exists := w.addresses[NormalizeAddress(addr)]
+		if !exists {
+			return
+		}
+		hits = append(hits, WatchlistHit{
+			Address:        addr,
+			Category:       entry.Category,
+			Label:          entry.Label,
+			CaseID:         entry.CaseID,
+			Direction:      direction,
+			Value:          value,
+			AlertLevel:     entry.AlertLevel,
+			Descriptor:     entry.Descriptor,
+			DerivationPath: entry.DerivationPath,
+		})
+		if entry.Descriptor != "" {
+			seen = append(seen, entry)
+		}
+	}
 
 	// Check all inputs
 	for _, in := range tx.Inputs {
-		if in.Address == "" {
-			continue
-		}
-		if entry, exists := w.addresses[in.Address]; exists {
-			hits = append(hits, WatchlistHit{
-				Address:    in.Address,
-				Category:   entry.Category,
-				Label:      entry.Label,
-				CaseID:     entry.CaseID,
-				Direction:  "input",
-				Value:      in.Value,
-				AlertLevel: entry.AlertLevel,
-			})
-		}
+		match(in.Address, "input", in.Value)
 	}
 
 	// Check all outputs
 	for _, out := range tx.Outputs {
-		if out.Address == "" {
-			continue
-		}
-		if entry, exists := w.addresses[out.Address]; exists {
-			hits = append(hits, WatchlistHit{
-				Address:    out.Address,
-				Category:   entry.Category,
-				Label:      entry.Label,
-				CaseID:     entry.CaseID,
-				Direction:  "output",
-				Value:      out.Value,
-				AlertLevel: entry.AlertLevel,
-			})
-		}
+		match(out.Address, "output", out.Value)
 	}
 
-	return hits
+	return hits, seen
 }
 
 // LoadFromInvestigation populates the watchlist from an investigation's addresses
 func (w *AddressWatchlist) LoadFromInvestigation(inv *Investigation) {
+	inv = inv.Snapshot()
+
 	// Add theft addresses
 	for _, addr := range inv.TheftAddresses {
 		w.Add(addr, "theft", "Theft: "+inv.Name, inv.ID, "critical")
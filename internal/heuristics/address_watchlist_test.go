@@ -0,0 +1,109 @@
+package heuristics
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	cases := map[string]string{
+		"BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ":   "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+		" tb1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KXPJZSX ": "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx",
+		"bcrt1QXYZ":                          "bcrt1qxyz",
+		"1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2": "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", // base58 keeps case
+		"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy": "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy",
+	}
+	for in, want := range cases {
+		if got := NormalizeAddress(in); got != want {
+			t.Errorf("NormalizeAddress(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAddressWatchlist_MixedCaseBech32MatchesOnChain(t *testing.T) {
+	w := NewAddressWatchlist()
+	w.Add("BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ", "theft", "Hack", "CASE-1", "critical")
+	w.Add("bc1pMixedCaseTaprootEntry", "suspect", "Suspect", "CASE-1", "high")
+
+	tx := models.Transaction{
+		Inputs:  []models.TxIn{{Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", Value: 50_000}},
+		Outputs: []models.TxOut{{Address: "bc1pmixedcasetaprootentry", Value: 49_000}},
+	}
+
+	hits := w.CheckTransaction(tx)
+	if len(hits) != 2 {
+		t.Fatalf("Expected both mixed-case entries to match. Got %+v", hits)
+	}
+	if hits[0].Address != "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq" {
+		t.Errorf("Expected hits to report the on-chain address. Got %q", hits[0].Address)
+	}
+	if !w.Contains("bc1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ") {
+		t.Error("Expected Contains to fold bech32 case")
+	}
+	if w.Contains("1bvbmseystwetqtfn5au4m4gfg7xjanvn2") {
+		t.Error("Expected base58 lookups to stay case-sensitive")
+	}
+}
+
+func TestAddressWatchlist_CaseFoldingDisabled(t *testing.T) {
+	SetBech32CaseFolding(false)
+	defer SetBech32CaseFolding(true)
+
+	w := NewAddressWatchlist()
+	w.Add("BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ", "theft", "Hack", "CASE-1", "critical")
+	if w.Contains("bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq") {
+		t.Error("Expected exact matching when case folding is disabled")
+	}
+}
+
+func TestAddressWatchlist_InputSideHitEscalatesAsFundsMoving(t *testing.T) {
+	w := NewAddressWatchlist()
+	w.Add("bc1qvictimwallet", "theft", "Victim hot wallet", "CASE-7", "high")
+	w.Add("bc1qsuspectdeposit", "suspect", "Suspect deposit", "CASE-7", "medium")
+
+	// Drain: the watched address is spent from
+	drain := models.Transaction{
+		Txid:    "drain",
+		Inputs:  []models.TxIn{{Address: "bc1qvictimwallet", Value: 20_000_000}},
+		Outputs: []models.TxOut{{Address: "bc1qattacker", Value: 19_990_000}},
+	}
+	hits := w.CheckTransaction(drain)
+	if len(hits) != 1 || hits[0].Direction != "input" || hits[0].Value != 20_000_000 {
+		t.Fatalf("Expected one input-side hit. Got %+v", hits)
+	}
+	spend := ScoreTransaction(drain, models.PrivacyAnalysisResult{}, hits)
+	if !spend.IsWatchlistSpend || !slices.Contains(spend.Signals, "watchlisted_funds_moving") {
+		t.Errorf("Expected a funds-moving signal. Got %+v", spend)
+	}
+
+	// Deposit: funds arrive at a watched address
+	deposit := models.Transaction{
+		Txid:    "deposit",
+		Inputs:  []models.TxIn{{Address: "bc1qsomeone", Value: 5_000_000}},
+		Outputs: []models.TxOut{{Address: "bc1qsuspectdeposit", Value: 4_990_000}},
+	}
+	hits = w.CheckTransaction(deposit)
+	if len(hits) != 1 || hits[0].Direction != "output" {
+		t.Fatalf("Expected one output-side hit. Got %+v", hits)
+	}
+	arrival := ScoreTransaction(deposit, models.PrivacyAnalysisResult{}, hits)
+	if arrival.IsWatchlistSpend {
+		t.Errorf("Funds arriving must not be flagged as moving. Got %+v", arrival)
+	}
+	if spend.RiskScore <= arrival.RiskScore {
+		t.Errorf("Expected the drain to outrank the deposit. Got %d vs %d", spend.RiskScore, arrival.RiskScore)
+	}
+
+	alerts := NewAlertManager(nil)
+	alerts.EmitFromAssessment(spend, nil)
+	alerts.EmitFromAssessment(arrival, nil)
+	recent := alerts.GetRecentAlerts(0)
+	if len(recent) != 2 || recent[1].AlertType != "watchlist_funds_moving" || recent[0].AlertType != "watchlist_hit" {
+		t.Fatalf("Expected a funds-moving alert then a plain watchlist hit. Got %+v", recent)
+	}
+	if !severityMeetsThreshold(recent[1].Severity, "high") {
+		t.Errorf("Expected the funds-moving alert at high+ severity. Got %s", recent[1].Severity)
+	}
+}
@@ -0,0 +1,143 @@
+package heuristics
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Intra-Case Movement Consolidation
+//
+// An investigation typically seeds many addresses of one suspect. Every
+// time the suspect shuffles funds among those addresses, each watched
+// address produces a watchlist hit and the assessment escalates to a
+// high/critical "watchlisted funds moving" alert — a storm that is really
+// just the suspect using their own wallet.
+//
+// A transaction is intra-case movement when every addressed input and
+// output is watched under the same case ID and the same role (category).
+// Such a transaction raises a single "intra_case_movement" alert at a
+// configurable reduced severity instead; anything that sends value to,
+// or receives value from, an address outside the case is never
+// consolidated. Within the grace window, further intra-case movements of
+// the same case are folded into the first alert rather than re-emitted.
+
+// IntraCasePolicy configures intra-case movement consolidation
+type IntraCasePolicy struct {
+	Enabled     bool
+	Severity    string        // Severity of the consolidated alert
+	GraceWindow time.Duration // Further movements of a case within this window are not re-alerted (0 = one alert per tx)
+}
+
+// DefaultIntraCasePolicy consolidates at "low" with a 10-minute grace window
+func DefaultIntraCasePolicy() IntraCasePolicy {
+	return IntraCasePolicy{Enabled: true, Severity: "low", GraceWindow: 10 * time.Minute}
+}
+
+// IntraCaseMovement describes a transaction moving funds among one case's addresses
+type IntraCaseMovement struct {
+	CaseID    string
+	Role      string
+	Addresses []string // Distinct watched addresses touched
+}
+
+// DetectIntraCaseMovement reports whether the hits of a transaction are all
+// one case's addresses of one role, covering every input and output address.
+// At least two distinct addresses must be involved.
+func DetectIntraCaseMovement(tx models.Transaction, hits []WatchlistHit) (IntraCaseMovement, bool) {
+	if len(hits) < 2 || hits[0].CaseID == "" {
+		return IntraCaseMovement{}, false
+	}
+	move := IntraCaseMovement{CaseID: hits[0].CaseID, Role: hits[0].Category}
+
+	watched := make(map[string]bool)
+	for _, hit := range hits {
+		if hit.CaseID != move.CaseID || hit.Category != move.Role {
+			return IntraCaseMovement{}, false
+		}
+		addr := NormalizeAddress(hit.Address)
+		if !watched[addr] {
+			watched[addr] = true
+			move.Addresses = append(move.Addresses, hit.Address)
+		}
+	}
+	if len(move.Addresses) < 2 {
+		return IntraCaseMovement{}, false
+	}
+
+	// Value entering from, or leaving to, an unwatched address is not intra-case
+	for _, in := range tx.Inputs {
+		if in.Address != "" && !watched[NormalizeAddress(in.Address)] {
+			return IntraCaseMovement{}, false
+		}
+	}
+	for _, out := range tx.Outputs {
+		if out.Address != "" && !watched[NormalizeAddress(out.Address)] {
+			return IntraCaseMovement{}, false
+		}
+	}
+	return move, true
+}
+
+// EmitFromTransaction emits the alert for a scored transaction: one
+// consolidated intra-case movement alert when the policy applies,
+// otherwise the regular assessment alert (EmitFromAssessment)
+func (am *AlertManager) EmitFromTransaction(tx models.Transaction, assessment ThreatAssessment, hits []WatchlistHit) {
+	am.mu.RLock()
+	enabled := am.intraCase.Enabled
+	am.mu.RUnlock()
+
+	if enabled && !assessment.IsCoinJoin {
+		if move, ok := DetectIntraCaseMovement(tx, hits); ok {
+			am.emitIntraCaseMovement(assessment, hits, move)
+			return
+		}
+	}
+	am.EmitFromAssessment(assessment, hits)
+}
+
+// SetIntraCasePolicy replaces the intra-case consolidation policy. An
+// invalid severity keeps the default.
+func (am *AlertManager) SetIntraCasePolicy(p IntraCasePolicy) {
+	if !IsValidSeverity(p.Severity) {
+		p.Severity = DefaultIntraCasePolicy().Severity
+	}
+	if p.GraceWindow < 0 {
+		p.GraceWindow = 0
+	}
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.intraCase = p
+}
+
+// emitIntraCaseMovement emits the consolidated alert for move, unless the
+// case already alerted within the grace window
+func (am *AlertManager) emitIntraCaseMovement(assessment ThreatAssessment, hits []WatchlistHit, move IntraCaseMovement) {
+	now := time.Now()
+	am.mu.Lock()
+	policy := am.intraCase
+	if last, ok := am.lastIntraCase[move.CaseID]; ok && policy.GraceWindow > 0 && now.Sub(last) < policy.GraceWindow {
+		am.mu.Unlock()
+		log.Printf("[Alert] Intra-case movement for case %s within grace window (tx: %s)", move.CaseID, assessment.TxID)
+		return
+	}
+	am.lastIntraCase[move.CaseID] = now
+	am.mu.Unlock()
+
+	alert := Alert{
+		Severity:  policy.Severity,
+		AlertType: "intra_case_movement",
+		Title:     fmt.Sprintf("Intra-case movement: case %s", move.CaseID),
+		Description: fmt.Sprintf("Transaction moves funds among %d %s addresses of case %s only; no value enters or leaves the case.",
+			len(move.Addresses), move.Role, move.CaseID),
+		TxID:       assessment.TxID,
+		Assessment: &assessment,
+		Hits:       hits,
+	}
+	if assessment.ValueBTC > 0 {
+		alert.Value = models.BTCToSats(assessment.ValueBTC)
+	}
+	am.EmitAlert(alert)
+}
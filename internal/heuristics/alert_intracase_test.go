@@ -0,0 +1,105 @@
+package heuristics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func suspectCaseWatchlist() *AddressWatchlist {
+	w := NewAddressWatchlist()
+	for _, addr := range []string{"bc1qsuspecta", "bc1qsuspectb", "bc1qsuspectc"} {
+		w.Add(addr, "suspect", "Suspect wallet", "CASE-9", "medium")
+	}
+	w.Add("bc1qothercase", "suspect", "Other suspect", "CASE-10", "medium")
+	return w
+}
+
+// shuffle spends two of the case's addresses into a third
+func shuffle(txid string) models.Transaction {
+	return models.Transaction{
+		Txid: txid,
+		Inputs: []models.TxIn{
+			{Address: "bc1qsuspecta", Value: 30_000_000},
+			{Address: "bc1qsuspectb", Value: 20_000_000},
+		},
+		Outputs: []models.TxOut{{Address: "bc1qsuspectc", Value: 49_990_000}},
+	}
+}
+
+func TestEmitFromTransaction_ConsolidatesIntraCaseMovement(t *testing.T) {
+	w := suspectCaseWatchlist()
+	tx := shuffle("shuffle-1")
+	hits := w.CheckTransaction(tx)
+	if len(hits) != 3 {
+		t.Fatalf("Expected three watchlist hits. Got %+v", hits)
+	}
+
+	move, ok := DetectIntraCaseMovement(tx, hits)
+	if !ok || move.CaseID != "CASE-9" || move.Role != "suspect" || len(move.Addresses) != 3 {
+		t.Fatalf("Expected intra-case movement across 3 addresses of CASE-9. Got %+v (%v)", move, ok)
+	}
+
+	am := NewAlertManager(nil)
+	assessment := ScoreTransaction(tx, models.PrivacyAnalysisResult{}, hits)
+	am.EmitFromTransaction(tx, assessment, hits)
+
+	recent := am.GetRecentAlerts(0)
+	if len(recent) != 1 {
+		t.Fatalf("Expected one consolidated alert. Got %+v", recent)
+	}
+	if recent[0].AlertType != "intra_case_movement" || recent[0].Severity != "low" || len(recent[0].Hits) != 3 {
+		t.Errorf("Expected a low intra_case_movement alert carrying all hits. Got %+v", recent[0])
+	}
+}
+
+func TestEmitFromTransaction_GraceWindowAndSeverity(t *testing.T) {
+	w := suspectCaseWatchlist()
+	am := NewAlertManager(nil)
+	am.SetIntraCasePolicy(IntraCasePolicy{Enabled: true, Severity: "info", GraceWindow: time.Hour})
+
+	for _, txid := range []string{"shuffle-1", "shuffle-2", "shuffle-3"} {
+		tx := shuffle(txid)
+		hits := w.CheckTransaction(tx)
+		am.EmitFromTransaction(tx, ScoreTransaction(tx, models.PrivacyAnalysisResult{}, hits), hits)
+	}
+
+	recent := am.GetRecentAlerts(0)
+	if len(recent) != 1 || recent[0].TxID != "shuffle-1" || recent[0].Severity != "info" {
+		t.Errorf("Expected one info alert for the first shuffle within the grace window. Got %+v", recent)
+	}
+}
+
+func TestEmitFromTransaction_ValueLeavingCaseNotConsolidated(t *testing.T) {
+	w := suspectCaseWatchlist()
+	am := NewAlertManager(nil)
+
+	// Pays an outside address with change back into the case
+	payment := shuffle("payment")
+	payment.Outputs = append(payment.Outputs, models.TxOut{Address: "bc1qmerchant", Value: 1_000_000})
+	hits := w.CheckTransaction(payment)
+	if _, ok := DetectIntraCaseMovement(payment, hits); ok {
+		t.Errorf("Value leaving the case must not be intra-case movement")
+	}
+	am.EmitFromTransaction(payment, ScoreTransaction(payment, models.PrivacyAnalysisResult{}, hits), hits)
+
+	// Two different cases in one tx
+	crossCase := shuffle("cross-case")
+	crossCase.Outputs[0].Address = "bc1qothercase"
+	hits = w.CheckTransaction(crossCase)
+	if _, ok := DetectIntraCaseMovement(crossCase, hits); ok {
+		t.Errorf("Hits from two cases must not be intra-case movement")
+	}
+	am.EmitFromTransaction(crossCase, ScoreTransaction(crossCase, models.PrivacyAnalysisResult{}, hits), hits)
+
+	recent := am.GetRecentAlerts(0)
+	if len(recent) != 2 {
+		t.Fatalf("Expected one alert per transaction. Got %+v", recent)
+	}
+	for _, alert := range recent {
+		if alert.AlertType != "watchlist_funds_moving" {
+			t.Errorf("Expected regular funds-moving alerts. Got %s for %s", alert.AlertType, alert.TxID)
+		}
+	}
+}
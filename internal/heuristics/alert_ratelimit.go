@@ -0,0 +1,137 @@
+package heuristics
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Severity-Aware Webhook Rate Limiting
+//
+// Each severity tier has its own token bucket in front of the webhook
+// fan-out, so a burst of medium alerts cannot exhaust the budget of high
+// ones (and critical alerts are never throttled). Throttled alerts are
+// still recorded in history and broadcast to dashboards; only their
+// webhook delivery is withheld. At the end of each summary interval the
+// withheld alerts of a tier are coalesced into one "alerts_suppressed"
+// alert ("N medium alerts suppressed in last minute").
+
+// AlertRateLimit is the webhook budget of one severity tier
+type AlertRateLimit struct {
+	Burst     int // Alerts delivered back-to-back before throttling
+	PerMinute int // Sustained refill rate (0 = unlimited)
+}
+
+// DefaultAlertRateLimits returns the per-tier budgets: critical unlimited,
+// lower tiers progressively tighter
+func DefaultAlertRateLimits() map[string]AlertRateLimit {
+	return map[string]AlertRateLimit{
+		"critical": {},
+		"high":     {Burst: 20, PerMinute: 60},
+		"medium":   {Burst: 10, PerMinute: 20},
+		"low":      {Burst: 5, PerMinute: 10},
+		"info":     {Burst: 5, PerMinute: 10},
+	}
+}
+
+// alertSummaryInterval is how often throttled alerts are summarized
+const alertSummaryInterval = time.Minute
+
+// tokenBucket is a refilling token bucket (not synchronized; guarded by am.mu)
+type tokenBucket struct {
+	tokens   float64
+	capacity float64
+	perSec   float64
+	last     time.Time
+}
+
+func newTokenBucket(limit AlertRateLimit, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		tokens:   float64(limit.Burst),
+		capacity: float64(limit.Burst),
+		perSec:   float64(limit.PerMinute) / 60,
+		last:     now,
+	}
+}
+
+// allow takes a token if one is available
+func (b *tokenBucket) allow(now time.Time) bool {
+	b.tokens += now.Sub(b.last).Seconds() * b.perSec
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true
+	}
+	return false
+}
+
+// SetAlertRateLimits replaces the per-tier webhook budgets. Tiers missing
+// from limits (or with PerMinute 0) are unlimited.
+func (am *AlertManager) SetAlertRateLimits(limits map[string]AlertRateLimit) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	now := time.Now()
+	am.buckets = make(map[string]*tokenBucket)
+	for severity, limit := range limits {
+		if limit.PerMinute > 0 {
+			am.buckets[severity] = newTokenBucket(limit, now)
+		}
+	}
+}
+
+// allowWebhookLocked reports whether an alert of this severity may fan out
+// to webhooks, counting it for the next summary if not. Caller holds am.mu.
+func (am *AlertManager) allowWebhookLocked(severity string, now time.Time) bool {
+	bucket, ok := am.buckets[severity]
+	if !ok || bucket.allow(now) {
+		return true
+	}
+
+	if len(am.throttled) == 0 {
+		time.AfterFunc(am.summaryInterval, am.flushThrottled)
+	}
+	am.throttled[severity]++
+	return false
+}
+
+// flushThrottled emits one summary alert per tier with throttled alerts
+// and resets the counters
+func (am *AlertManager) flushThrottled() {
+	am.mu.Lock()
+	counts := am.throttled
+	am.throttled = make(map[string]int)
+	webhooks := make([]WebhookEndpoint, len(am.webhooks))
+	copy(webhooks, am.webhooks)
+	window := am.summaryInterval
+	am.mu.Unlock()
+
+	for severity, n := range counts {
+		summary := Alert{
+			Timestamp:   time.Now(),
+			Severity:    severity,
+			AlertType:   "alerts_suppressed",
+			Title:       fmt.Sprintf("%d %s alerts suppressed in last %s", n, severity, describeWindow(window)),
+			Description: fmt.Sprintf("Webhook rate limit reached for %s alerts; see the alert history for the individual alerts.", severity),
+		}
+		summary.ID = fmt.Sprintf("%s-alerts_suppressed-%d", severity, summary.Timestamp.Unix())
+
+		for _, wh := range webhooks {
+			if wh.Enabled && severityMeetsThreshold(severity, wh.MinSeverity) {
+				go am.sendWebhook(wh, summary)
+			}
+		}
+		log.Printf("[Alert] %s", summary.Title)
+	}
+}
+
+// describeWindow renders the summary interval for alert titles
+func describeWindow(d time.Duration) string {
+	if d == time.Minute {
+		return "minute"
+	}
+	return d.String()
+}
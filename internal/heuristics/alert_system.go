@@ -2,11 +2,16 @@ package heuristics
 
 import (
 	"bytes"
-	"encoding/json"
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand"
 	"net/http"
 	"sync"
 	"time"
+
+	"github.com/rawblock/coinjoin-engine/internal/logging"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
 )
 
 // Alert & Webhook System
@@ -16,17 +21,30 @@ import (
 //   2. Pushed to registered webhook endpoints (Slack, Discord, SIEM)
 //   3. Stored in memory for recent alert history
 //
-// Webhook payloads follow a common JSON format compatible with
-// Slack incoming webhooks, Discord webhooks, and PagerDuty Events API.
+// Each webhook picks a payload format: the raw Alert JSON (SIEMs), or
+// the shapes Slack incoming webhooks, Discord webhooks and the PagerDuty
+// Events API v2 expect (see webhook_format.go).
+//
+// Per-severity rate limiting prevents webhook flood during high-activity
+// periods; throttled alerts are coalesced into periodic summaries (see
+// alert_ratelimit.go).
 //
-// Rate limiting prevents webhook flood during high-activity periods.
+// Delivery is retried with exponential backoff and jitter on network
+// errors and 5xx responses (4xx means the receiver rejected the payload
+// and is not retried). An endpoint whose deliveries keep failing is
+// disabled after WebhookRetryPolicy.DisableAfter consecutive failures;
+// re-registering it re-enables it.
+//
+// Alerts are deduplicated by ID (severity-alertType-txid): an identical
+// alert within the dedup window — e.g. a tx seen in the mempool and again
+// when mined, or a compound signal re-firing — is suppressed.
 
 // Alert represents a structured security alert
 type Alert struct {
 	ID          string            `json:"id"`
 	Timestamp   time.Time         `json:"timestamp"`
 	Severity    string            `json:"severity"`  // info/low/medium/high/critical
-	AlertType   string            `json:"alertType"` // watchlist_hit/coinjoin_detected/high_risk/compound
+	AlertType   string            `json:"alertType"` // watchlist_hit/watchlist_funds_moving/coinjoin_detected/large_coinjoin/high_risk/compound
 	Title       string            `json:"title"`
 	Description string            `json:"description"`
 	TxID        string            `json:"txid,omitempty"`
@@ -42,6 +60,45 @@ type WebhookEndpoint struct {
 	Enabled     bool              `json:"enabled"`
 	Headers     map[string]string `json:"headers,omitempty"`
 	MinSeverity string            `json:"minSeverity"` // Only send alerts >= this severity
+	Format      string            `json:"format"`      // raw/slack/discord/pagerduty (see webhook_format.go)
+	RoutingKey  string            `json:"-"`           // PagerDuty integration key (pagerduty format only)
+
+	ConsecutiveFailures int `json:"consecutiveFailures"` // Failed deliveries (after retries) since the last success
+}
+
+// WebhookRetryPolicy bounds webhook delivery retries
+type WebhookRetryPolicy struct {
+	MaxAttempts  int           // Attempts per alert, including the first
+	BaseDelay    time.Duration // Backoff before the 2nd attempt, doubled per attempt
+	MaxDelay     time.Duration // Backoff ceiling
+	DisableAfter int           // Consecutive failed deliveries before the endpoint is disabled
+}
+
+// DefaultWebhookRetryPolicy returns 3 attempts at 1s/2s backoff, disabling
+// an endpoint after 10 consecutive failed deliveries
+func DefaultWebhookRetryPolicy() WebhookRetryPolicy {
+	return WebhookRetryPolicy{
+		MaxAttempts:  3,
+		BaseDelay:    1 * time.Second,
+		MaxDelay:     30 * time.Second,
+		DisableAfter: 10,
+	}
+}
+
+// backoff returns the delay before the given retry (1 = first retry):
+// BaseDelay·2^(retry-1) capped at MaxDelay, plus up to 50% jitter
+func (p WebhookRetryPolicy) backoff(retry int) time.Duration {
+	if p.BaseDelay <= 0 {
+		return 0
+	}
+	delay := p.BaseDelay << (retry - 1)
+	if delay > p.MaxDelay || delay <= 0 {
+		delay = p.MaxDelay
+	}
+	if half := int64(delay / 2); half > 0 {
+		delay += time.Duration(rand.Int63n(half))
+	}
+	return delay
 }
 
 // AlertManager handles alert emission and webhook delivery
@@ -51,47 +108,194 @@ type AlertManager struct {
 	recentAlerts  []Alert
 	maxHistory    int
 	httpClient    *http.Client
+	retry         WebhookRetryPolicy
 	alertCallback func(Alert) // WebSocket broadcast callback
+
+	dedupWindow time.Duration        // Identical alert IDs within this window are suppressed (0 = off)
+	lastEmitted map[string]time.Time // Alert ID → last emission
+	lastPrune   time.Time
+	suppressed  int64 // Duplicates suppressed since start
+
+	buckets         map[string]*tokenBucket // Severity → webhook budget (absent = unlimited)
+	throttled       map[string]int          // Severity → alerts withheld since the last summary
+	summaryInterval time.Duration
+
+	intraCase     IntraCasePolicy      // Consolidation of a case's own fund movements (see alert_intracase.go)
+	lastIntraCase map[string]time.Time // Case ID → last intra-case movement alert
 }
 
+// DefaultAlertDedupWindow covers a mempool tx being mined and re-observed
+const DefaultAlertDedupWindow = time.Hour
+
 // NewAlertManager creates a new alert system
 func NewAlertManager(broadcastFn func(Alert)) *AlertManager {
-	return &AlertManager{
+	am := &AlertManager{
 		webhooks:      make([]WebhookEndpoint, 0),
 		recentAlerts:  make([]Alert, 0),
 		maxHistory:    1000,
 		httpClient:    &http.Client{Timeout: 5 * time.Second},
+		retry:         DefaultWebhookRetryPolicy(),
 		alertCallback: broadcastFn,
+		dedupWindow:   DefaultAlertDedupWindow,
+		lastEmitted:   make(map[string]time.Time),
+
+		throttled:       make(map[string]int),
+		summaryInterval: alertSummaryInterval,
+
+		intraCase:     DefaultIntraCasePolicy(),
+		lastIntraCase: make(map[string]time.Time),
 	}
+	am.SetAlertRateLimits(DefaultAlertRateLimits())
+	return am
 }
 
-// RegisterWebhook adds a webhook endpoint
-func (am *AlertManager) RegisterWebhook(name, url, minSeverity string, headers map[string]string) {
+// SetDedupWindow sets how long an alert ID suppresses identical alerts
+// (0 disables deduplication)
+func (am *AlertManager) SetDedupWindow(window time.Duration) {
+	if window < 0 {
+		window = 0
+	}
 	am.mu.Lock()
 	defer am.mu.Unlock()
+	am.dedupWindow = window
+}
+
+// SuppressedCount returns how many duplicate alerts have been suppressed
+func (am *AlertManager) SuppressedCount() int64 {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	return am.suppressed
+}
+
+// isDuplicateLocked records an emission of id at now, reporting whether an
+// identical alert was emitted within the dedup window. Caller holds am.mu.
+func (am *AlertManager) isDuplicateLocked(id string, now time.Time) bool {
+	if am.dedupWindow <= 0 {
+		return false
+	}
 
-	am.webhooks = append(am.webhooks, WebhookEndpoint{
+	// Sweep expired IDs at most once per window
+	if now.Sub(am.lastPrune) >= am.dedupWindow {
+		for key, at := range am.lastEmitted {
+			if now.Sub(at) >= am.dedupWindow {
+				delete(am.lastEmitted, key)
+			}
+		}
+		am.lastPrune = now
+	}
+
+	if at, ok := am.lastEmitted[id]; ok && now.Sub(at) < am.dedupWindow {
+		am.suppressed++
+		return true
+	}
+	am.lastEmitted[id] = now
+	return false
+}
+
+// SetWebhookRetryPolicy replaces the delivery retry policy
+func (am *AlertManager) SetWebhookRetryPolicy(p WebhookRetryPolicy) {
+	def := DefaultWebhookRetryPolicy()
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = def.MaxAttempts
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = def.MaxDelay
+	}
+	if p.DisableAfter <= 0 {
+		p.DisableAfter = def.DisableAfter
+	}
+
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.retry = p
+}
+
+// RegisterWebhook adds a raw-JSON webhook endpoint, replacing any with the same name
+func (am *AlertManager) RegisterWebhook(name, url, minSeverity string, headers map[string]string) {
+	am.RegisterWebhookEndpoint(WebhookEndpoint{
 		Name:        name,
 		URL:         url,
-		Enabled:     true,
 		Headers:     headers,
 		MinSeverity: minSeverity,
+		Format:      WebhookFormatRaw,
 	})
+}
+
+// RegisterWebhookEndpoint adds (or replaces by name) a fully specified
+// endpoint. The endpoint is (re-)enabled and an empty Format means raw.
+func (am *AlertManager) RegisterWebhookEndpoint(wh WebhookEndpoint) {
+	wh.Enabled = true
+	wh.ConsecutiveFailures = 0
+	if wh.Format == "" {
+		wh.Format = WebhookFormatRaw
+	}
 
-	log.Printf("[AlertManager] Registered webhook: %s → %s (min: %s)", name, url, minSeverity)
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	replaced := false
+	for i := range am.webhooks {
+		if am.webhooks[i].Name == wh.Name {
+			am.webhooks[i] = wh
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		am.webhooks = append(am.webhooks, wh)
+	}
+
+	alertLogger().Info("registered webhook", "webhook", wh.Name, "url", wh.URL, "minSeverity", wh.MinSeverity, "format", wh.Format)
 }
 
-// RemoveWebhook removes a webhook by name
-func (am *AlertManager) RemoveWebhook(name string) {
+// RemoveWebhook removes a webhook by name, reporting whether it existed
+func (am *AlertManager) RemoveWebhook(name string) bool {
 	am.mu.Lock()
 	defer am.mu.Unlock()
 
 	for i, wh := range am.webhooks {
 		if wh.Name == name {
 			am.webhooks = append(am.webhooks[:i], am.webhooks[i+1:]...)
-			return
+			return true
 		}
 	}
+	return false
+}
+
+// ListWebhooks returns a copy of the registered webhook endpoints
+func (am *AlertManager) ListWebhooks() []WebhookEndpoint {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+	return append([]WebhookEndpoint{}, am.webhooks...)
+}
+
+// PingWebhook sends a test alert to one registered webhook, bypassing its
+// severity filter. Returns false if no webhook has that name.
+func (am *AlertManager) PingWebhook(name string) bool {
+	am.mu.RLock()
+	var target *WebhookEndpoint
+	for i := range am.webhooks {
+		if am.webhooks[i].Name == name {
+			wh := am.webhooks[i]
+			target = &wh
+			break
+		}
+	}
+	am.mu.RUnlock()
+	if target == nil {
+		return false
+	}
+
+	ping := Alert{
+		Timestamp:   time.Now(),
+		Severity:    "info",
+		AlertType:   "webhook_test",
+		Title:       "Webhook registered",
+		Description: "Test delivery from the CoinJoin engine alert system",
+	}
+	ping.ID = generateAlertID(ping)
+	go am.sendWebhook(*target, ping)
+	return true
 }
 
 // EmitAlert processes and distributes an alert
@@ -103,14 +307,23 @@ func (am *AlertManager) EmitAlert(alert Alert) {
 		alert.ID = generateAlertID(alert)
 	}
 
-	// Store in history
+	// Store in history (unless an identical alert was just emitted)
 	am.mu.Lock()
+	if am.isDuplicateLocked(alert.ID, time.Now()) {
+		suppressed := am.suppressed
+		am.mu.Unlock()
+		alertLogger().Debug("suppressed duplicate alert", "alert", alert.ID, "suppressed", suppressed)
+		return
+	}
 	am.recentAlerts = append(am.recentAlerts, alert)
 	if len(am.recentAlerts) > am.maxHistory {
 		am.recentAlerts = am.recentAlerts[len(am.recentAlerts)-am.maxHistory:]
 	}
-	webhooks := make([]WebhookEndpoint, len(am.webhooks))
-	copy(webhooks, am.webhooks)
+	var webhooks []WebhookEndpoint
+	if am.allowWebhookLocked(alert.Severity, time.Now()) {
+		webhooks = make([]WebhookEndpoint, len(am.webhooks))
+		copy(webhooks, am.webhooks)
+	}
 	am.mu.Unlock()
 
 	// Broadcast via WebSocket callback
@@ -118,7 +331,7 @@ func (am *AlertManager) EmitAlert(alert Alert) {
 		am.alertCallback(alert)
 	}
 
-	// Send to webhooks (async, non-blocking)
+	// Send to webhooks (async, non-blocking; none when the tier is throttled)
 	for _, wh := range webhooks {
 		if !wh.Enabled {
 			continue
@@ -129,7 +342,8 @@ func (am *AlertManager) EmitAlert(alert Alert) {
 		go am.sendWebhook(wh, alert)
 	}
 
-	log.Printf("[Alert] [%s] %s: %s (tx: %s)", alert.Severity, alert.AlertType, alert.Title, alert.TxID)
+	alertLogger().Log(context.Background(), alertLogLevel(alert.Severity), alert.Title,
+		"alert", alert.ID, "severity", alert.Severity, "type", alert.AlertType, "txid", alert.TxID)
 }
 
 // EmitFromAssessment creates and emits an alert from a threat assessment
@@ -145,6 +359,10 @@ func (am *AlertManager) EmitFromAssessment(assessment ThreatAssessment, hits []W
 		alertType = "watchlist_hit"
 		title = "⚠️ Watchlist hit detected"
 	}
+	if assessment.IsWatchlistSpend {
+		alertType = "watchlist_funds_moving"
+		title = "🚨 Watchlisted funds moving"
+	}
 	if assessment.IsCoinJoin && assessment.IsWatchlistHit {
 		alertType = "compound"
 		title = "🚨 Watchlisted funds entering CoinJoin mixer"
@@ -161,7 +379,7 @@ func (am *AlertManager) EmitFromAssessment(assessment ThreatAssessment, hits []W
 	}
 
 	if assessment.ValueBTC > 0 {
-		alert.Value = int64(assessment.ValueBTC * 100000000)
+		alert.Value = models.BTCToSats(assessment.ValueBTC)
 	}
 
 	am.EmitAlert(alert)
@@ -185,6 +403,39 @@ func (am *AlertManager) GetRecentAlerts(limit int) []Alert {
 	return result
 }
 
+// GetRecentAlertsAtLeast returns up to limit alerts at or above minSeverity,
+// most recent first (limit ≤ 0 returns every match)
+func (am *AlertManager) GetRecentAlertsAtLeast(limit int, minSeverity string) []Alert {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+
+	result := make([]Alert, 0)
+	for i := len(am.recentAlerts) - 1; i >= 0; i-- {
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+		if severityMeetsThreshold(am.recentAlerts[i].Severity, minSeverity) {
+			result = append(result, am.recentAlerts[i])
+		}
+	}
+	return result
+}
+
+// CountBySeverity tallies alerts emitted since the given time by severity
+func (am *AlertManager) CountBySeverity(since time.Time) map[string]int {
+	am.mu.RLock()
+	defer am.mu.RUnlock()
+
+	counts := make(map[string]int)
+	for _, alert := range am.recentAlerts {
+		if alert.Timestamp.Before(since) {
+			continue
+		}
+		counts[alert.Severity]++
+	}
+	return counts
+}
+
 // GetAlertsBySeverity returns alerts matching a minimum severity
 func (am *AlertManager) GetAlertsBySeverity(minSeverity string) []Alert {
 	am.mu.RLock()
@@ -199,18 +450,40 @@ func (am *AlertManager) GetAlertsBySeverity(minSeverity string) []Alert {
 	return filtered
 }
 
-// sendWebhook delivers an alert to a webhook endpoint
+// sendWebhook delivers an alert to a webhook endpoint, retrying transient
+// failures per the retry policy and recording the outcome on the endpoint
 func (am *AlertManager) sendWebhook(wh WebhookEndpoint, alert Alert) {
-	payload, err := json.Marshal(alert)
+	payload, err := formatWebhookPayload(wh, alert)
 	if err != nil {
-		log.Printf("[Webhook] Failed to marshal alert: %v", err)
+		alertLogger().Error("failed to format webhook alert", "webhook", wh.Name, "alert", alert.ID, "err", err)
 		return
 	}
 
+	am.mu.RLock()
+	policy := am.retry
+	am.mu.RUnlock()
+
+	for attempt := 1; ; attempt++ {
+		retryable, err := am.postWebhook(wh, payload)
+		if err == nil {
+			am.recordDelivery(wh, true)
+			return
+		}
+		if !retryable || attempt >= policy.MaxAttempts {
+			alertLogger().Error("webhook delivery failed", "webhook", wh.Name, "alert", alert.ID, "attempts", attempt, "err", err)
+			am.recordDelivery(wh, false)
+			return
+		}
+		time.Sleep(policy.backoff(attempt))
+	}
+}
+
+// postWebhook makes one delivery attempt. Network errors and 5xx responses
+// are retryable; 4xx responses are not.
+func (am *AlertManager) postWebhook(wh WebhookEndpoint, payload []byte) (bool, error) {
 	req, err := http.NewRequest("POST", wh.URL, bytes.NewBuffer(payload))
 	if err != nil {
-		log.Printf("[Webhook] Failed to create request for %s: %v", wh.Name, err)
-		return
+		return false, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -220,22 +493,64 @@ func (am *AlertManager) sendWebhook(wh WebhookEndpoint, alert Alert) {
 
 	resp, err := am.httpClient.Do(req)
 	if err != nil {
-		log.Printf("[Webhook] Failed to send to %s: %v", wh.Name, err)
-		return
+		return true, err
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode >= 500 {
+		return true, fmt.Errorf("status %d", resp.StatusCode)
+	}
 	if resp.StatusCode >= 400 {
-		log.Printf("[Webhook] %s returned status %d", wh.Name, resp.StatusCode)
+		return false, fmt.Errorf("status %d", resp.StatusCode)
 	}
+	return false, nil
+}
+
+// recordDelivery updates an endpoint's consecutive-failure counter and
+// disables it once the counter reaches the policy's DisableAfter. The
+// endpoint is matched by name and URL so a replacement registered while the
+// delivery was in flight is left alone.
+func (am *AlertManager) recordDelivery(wh WebhookEndpoint, ok bool) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	for i := range am.webhooks {
+		ep := &am.webhooks[i]
+		if ep.Name != wh.Name || ep.URL != wh.URL {
+			continue
+		}
+		if ok {
+			ep.ConsecutiveFailures = 0
+			return
+		}
+		ep.ConsecutiveFailures++
+		if ep.Enabled && ep.ConsecutiveFailures >= am.retry.DisableAfter {
+			ep.Enabled = false
+			alertLogger().Warn("disabling webhook after consecutive failed deliveries", "webhook", ep.Name, "failures", ep.ConsecutiveFailures)
+		}
+		return
+	}
+}
+
+// severityLevels orders the alert severities
+var severityLevels = map[string]int{
+	"info": 0, "low": 1, "medium": 2, "high": 3, "critical": 4,
 }
 
 // severityMeetsThreshold checks if a severity level meets the minimum
 func severityMeetsThreshold(severity, minimum string) bool {
-	levels := map[string]int{
-		"info": 0, "low": 1, "medium": 2, "high": 3, "critical": 4,
-	}
-	return levels[severity] >= levels[minimum]
+	return severityLevels[severity] >= severityLevels[minimum]
+}
+
+// IsValidSeverity reports whether s is one of info/low/medium/high/critical
+func IsValidSeverity(s string) bool {
+	_, ok := severityLevels[s]
+	return ok
+}
+
+// SeverityAtLeast reports whether severity is at or above minimum
+func SeverityAtLeast(severity, minimum string) bool {
+	return severityMeetsThreshold(severity, minimum)
 }
 
 // generateAlertID creates a unique alert ID
@@ -266,3 +581,14 @@ func buildDescription(a ThreatAssessment) string {
 	}
 	return desc
 }
+
+// alertLogger tags the alert manager's records (component=alerts)
+func alertLogger() *slog.Logger { return logging.Component("alerts") }
+
+// alertLogLevel logs high and critical alerts as warnings
+func alertLogLevel(severity string) slog.Level {
+	if severity == "critical" || severity == "high" {
+		return slog.LevelWarn
+	}
+	return slog.LevelInfo
+}
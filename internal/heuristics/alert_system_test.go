@@ -0,0 +1,195 @@
+package heuristics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func fastRetryManager(disableAfter int) *AlertManager {
+	am := NewAlertManager(nil)
+	am.SetWebhookRetryPolicy(WebhookRetryPolicy{
+		MaxAttempts:  3,
+		BaseDelay:    time.Millisecond,
+		MaxDelay:     5 * time.Millisecond,
+		DisableAfter: disableAfter,
+	})
+	return am
+}
+
+func TestSendWebhook_RetriesUntilReceiverRecovers(t *testing.T) {
+	var calls, delivered atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable) // Flapping
+			return
+		}
+		delivered.Add(1)
+	}))
+	defer receiver.Close()
+
+	am := fastRetryManager(5)
+	am.RegisterWebhook("siem", receiver.URL, "info", nil)
+	am.sendWebhook(am.ListWebhooks()[0], sampleAlert())
+
+	if calls.Load() != 3 || delivered.Load() != 1 {
+		t.Errorf("Expected delivery on the 3rd attempt. Got %d calls, %d delivered", calls.Load(), delivered.Load())
+	}
+	if wh := am.ListWebhooks()[0]; wh.ConsecutiveFailures != 0 || !wh.Enabled {
+		t.Errorf("Expected a recovered endpoint to stay enabled with no failures. Got %+v", wh)
+	}
+}
+
+func TestSendWebhook_ClientErrorNotRetried(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer receiver.Close()
+
+	am := fastRetryManager(5)
+	am.RegisterWebhook("siem", receiver.URL, "info", nil)
+	am.sendWebhook(am.ListWebhooks()[0], sampleAlert())
+
+	if calls.Load() != 1 {
+		t.Errorf("Expected a 4xx to be attempted once. Got %d", calls.Load())
+	}
+	if wh := am.ListWebhooks()[0]; wh.ConsecutiveFailures != 1 {
+		t.Errorf("Expected the rejection counted as a failure. Got %d", wh.ConsecutiveFailures)
+	}
+}
+
+func TestSendWebhook_BrokenEndpointDisabled(t *testing.T) {
+	var calls atomic.Int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	am := fastRetryManager(2)
+	am.RegisterWebhook("broken", receiver.URL, "info", nil)
+	for i := 0; i < 2; i++ {
+		am.sendWebhook(am.ListWebhooks()[0], sampleAlert())
+	}
+
+	wh := am.ListWebhooks()[0]
+	if wh.Enabled || wh.ConsecutiveFailures != 2 {
+		t.Fatalf("Expected the endpoint disabled after 2 failed deliveries. Got %+v", wh)
+	}
+	if calls.Load() != 6 {
+		t.Errorf("Expected 3 attempts per delivery. Got %d calls", calls.Load())
+	}
+
+	// Disabled endpoints are skipped by EmitAlert
+	am.EmitAlert(sampleAlert())
+	time.Sleep(50 * time.Millisecond)
+	if calls.Load() != 6 {
+		t.Errorf("Expected no delivery to a disabled endpoint. Got %d calls", calls.Load())
+	}
+
+	// Re-registering re-enables it
+	am.RegisterWebhook("broken", receiver.URL, "info", nil)
+	if wh := am.ListWebhooks()[0]; !wh.Enabled || wh.ConsecutiveFailures != 0 {
+		t.Errorf("Expected re-registration to reset the endpoint. Got %+v", wh)
+	}
+}
+
+func TestEmitAlert_DeduplicatesWithinWindow(t *testing.T) {
+	var broadcasts atomic.Int32
+	am := NewAlertManager(func(Alert) { broadcasts.Add(1) })
+
+	// Same tx scored from the mempool and again once mined
+	assessment := ThreatAssessment{TxID: "txdup", Severity: "high", RiskScore: 60, IsWatchlistHit: true}
+	am.EmitFromAssessment(assessment, nil)
+	am.EmitFromAssessment(assessment, nil)
+
+	if broadcasts.Load() != 1 || len(am.GetRecentAlerts(0)) != 1 {
+		t.Errorf("Expected one alert for a repeated tx. Got %d broadcasts", broadcasts.Load())
+	}
+	if am.SuppressedCount() != 1 {
+		t.Errorf("Expected one suppressed duplicate. Got %d", am.SuppressedCount())
+	}
+
+	// A different severity is a different alert
+	assessment.Severity = "critical"
+	am.EmitFromAssessment(assessment, nil)
+	if broadcasts.Load() != 2 {
+		t.Errorf("Expected an escalation to alert. Got %d broadcasts", broadcasts.Load())
+	}
+}
+
+func TestEmitAlert_DedupWindowExpires(t *testing.T) {
+	am := NewAlertManager(nil)
+	am.SetDedupWindow(20 * time.Millisecond)
+
+	alert := Alert{Severity: "high", AlertType: "watchlist_hit", TxID: "txwin"}
+	am.EmitAlert(alert)
+	time.Sleep(30 * time.Millisecond)
+	am.EmitAlert(alert)
+	if n := len(am.GetRecentAlerts(0)); n != 2 {
+		t.Errorf("Expected the alert to re-fire after the window. Got %d", n)
+	}
+
+	am.SetDedupWindow(0)
+	am.EmitAlert(alert)
+	am.EmitAlert(alert)
+	if n := len(am.GetRecentAlerts(0)); n != 4 {
+		t.Errorf("Expected a zero window to disable dedup. Got %d", n)
+	}
+}
+
+func TestEmitAlert_RateLimitsWebhookFanOut(t *testing.T) {
+	var calls atomic.Int32
+	titles := make(chan string, 600)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var alert Alert
+		_ = json.NewDecoder(r.Body).Decode(&alert)
+		calls.Add(1)
+		titles <- alert.Title
+	}))
+	defer receiver.Close()
+
+	am := NewAlertManager(nil)
+	am.RegisterWebhook("siem", receiver.URL, "info", nil)
+
+	for i := 0; i < 500; i++ {
+		am.EmitAlert(Alert{Severity: "medium", AlertType: "high_risk", TxID: fmt.Sprintf("burst%d", i)})
+	}
+	am.EmitAlert(Alert{Severity: "critical", AlertType: "compound", TxID: "urgent"})
+
+	time.Sleep(100 * time.Millisecond)
+	delivered := int(calls.Load())
+	if delivered > 12 {
+		t.Fatalf("Expected the medium burst throttled to ~10 webhook calls. Got %d", delivered)
+	}
+	if len(am.GetRecentAlerts(0)) != 501 {
+		t.Errorf("Expected throttled alerts still recorded in history")
+	}
+
+	sawCritical := false
+	for i := 0; i < delivered; i++ {
+		if title := <-titles; title == "" {
+			sawCritical = true // The critical alert has no title in this test
+		}
+	}
+	if !sawCritical {
+		t.Error("Expected the critical alert to bypass the medium throttle")
+	}
+
+	am.flushThrottled()
+	select {
+	case title := <-titles:
+		want := fmt.Sprintf("%d medium alerts suppressed in last minute", 500-(delivered-1))
+		if title != want {
+			t.Errorf("Expected summary %q. Got %q", want, title)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected a summary alert for the throttled tier")
+	}
+}
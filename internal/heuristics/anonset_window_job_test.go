@@ -0,0 +1,103 @@
+package heuristics
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// memAnonSetStore mimics the anonset_windows table
+type memAnonSetStore struct {
+	rows    []models.AnonSetOutput
+	windows map[string]map[string]int // column → "txid:index" → value
+}
+
+func (s *memAnonSetStore) AnonSetHorizon(context.Context) (int64, error) {
+	var horizon int64
+	for _, r := range s.rows {
+		horizon = max(horizon, r.BlockTime)
+	}
+	return horizon, nil
+}
+
+func (s *memAnonSetStore) PendingAnonSetWindows(_ context.Context, window string, windowSecs, horizon int64, limit int) ([]models.AnonSetOutput, error) {
+	var pending []models.AnonSetOutput
+	for _, r := range s.rows {
+		if _, done := s.windows[window][anonSetKey(r)]; !done && r.BlockTime+windowSecs <= horizon && len(pending) < limit {
+			pending = append(pending, r)
+		}
+	}
+	return pending, nil
+}
+
+func (s *memAnonSetStore) CountDenominationOutputs(_ context.Context, valueSats int64, excludeTxid string, from, to int64) (int, error) {
+	n := 0
+	for _, r := range s.rows {
+		if r.ValueSats == valueSats && r.Txid != excludeTxid && r.BlockTime >= from && r.BlockTime <= to {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *memAnonSetStore) UpdateAnonSetWindows(_ context.Context, txid string, outputIndex int, window string, value int) error {
+	if s.windows[window] == nil {
+		s.windows[window] = make(map[string]int)
+	}
+	s.windows[window][anonSetKey(models.AnonSetOutput{Txid: txid, OutputIndex: outputIndex})] = value
+	return nil
+}
+
+func anonSetKey(o models.AnonSetOutput) string {
+	return fmt.Sprintf("%s:%d", o.Txid, o.OutputIndex)
+}
+
+func TestCoinJoinAnonSetOutputs(t *testing.T) {
+	tx := models.Transaction{Txid: "cj", BlockTime: 1_000, Outputs: []models.TxOut{
+		{Value: 1_000_000}, {Value: 1_000_000}, {Value: 1_000_000}, {Value: 37_000},
+	}}
+	outs := CoinJoinAnonSetOutputs(tx)
+	if len(outs) != 3 || outs[0].AnonSetLocal != 3 || outs[2].OutputIndex != 2 || outs[0].BlockTime != 1_000 {
+		t.Errorf("Expected the three equal-value outputs with a local pool of 3. Got %+v", outs)
+	}
+}
+
+func TestAnonSetWindowJob_CountsLaterDenominationOutputs(t *testing.T) {
+	const day = int64(24 * 60 * 60)
+	t0 := int64(1_700_000_000)
+	mix := func(txid string, at int64, n int, value int64) []models.AnonSetOutput {
+		outs := make([]models.TxOut, n)
+		for i := range outs {
+			outs[i].Value = value
+		}
+		return CoinJoinAnonSetOutputs(models.Transaction{Txid: txid, BlockTime: at, Outputs: outs})
+	}
+
+	store := &memAnonSetStore{windows: make(map[string]map[string]int)}
+	store.rows = append(store.rows, mix("first", t0, 5, 1_000_000)...)
+	store.rows = append(store.rows, mix("same-day", t0+day/2, 5, 1_000_000)...)
+	store.rows = append(store.rows, mix("week-3", t0+20*day, 5, 1_000_000)...)
+	store.rows = append(store.rows, mix("other-pool", t0+2*day, 5, 5_000_000)...)
+	store.rows = append(store.rows, mix("horizon", t0+40*day, 2, 100_000)...)
+
+	n, err := NewAnonSetWindowJob(store, 0).RunOnce(context.Background())
+	if err != nil || n == 0 {
+		t.Fatalf("Expected windows updated. Got %d (%v)", n, err)
+	}
+
+	key := "first:0"
+	if got := store.windows["anonset_1d"][key]; got != 10 {
+		t.Errorf("Expected A(1d) = 5 local + 5 same-day. Got %d", got)
+	}
+	if got := store.windows["anonset_30d"][key]; got != 15 {
+		t.Errorf("Expected A(30d) to include the week-3 mix but not the other pool. Got %d", got)
+	}
+	if _, ok := store.windows["anonset_365d"][key]; ok {
+		t.Errorf("Expected the 365d window left open beyond the observed horizon")
+	}
+	if _, ok := store.windows["anonset_30d"]["week-3:0"]; ok {
+		t.Errorf("Expected week-3's 30d window open (horizon is 20 days later)")
+	}
+}
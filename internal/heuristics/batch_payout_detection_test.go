@@ -0,0 +1,75 @@
+package heuristics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// exchangeWithdrawal is a 1-in-n-out batch paying distinct amounts to a
+// rotating mix of customer address types
+func exchangeWithdrawal(n int) models.Transaction {
+	prefixes := []string{"bc1q", "bc1p", "3", "1"}
+	tx := models.Transaction{
+		Txid:   "exchange_batch",
+		Inputs: []models.TxIn{{Address: "bc1qexchangehotwallet", Value: 50_000_000_000}},
+	}
+	for i := 0; i < n; i++ {
+		tx.Outputs = append(tx.Outputs, models.TxOut{
+			Address: fmt.Sprintf("%scustomer%d", prefixes[i%len(prefixes)], i),
+			Value:   int64(100_000 + i*7_919),
+		})
+	}
+	return tx
+}
+
+func TestDetectBatchPayout_ExchangeWithdrawal(t *testing.T) {
+	tx := exchangeWithdrawal(200)
+
+	b := DetectBatchPayout(tx)
+	if !b.IsBatch || b.PayoutCount != 200 {
+		t.Fatalf("Expected a 200-payout batch. Got %+v", b)
+	}
+	if b.ValueEntropy < 0.999 {
+		t.Errorf("Expected maximal value entropy for distinct amounts. Got %.3f", b.ValueEntropy)
+	}
+	if b.AddressTypeEntropy < 0.8 {
+		t.Errorf("Expected high address-type dispersion. Got %.3f", b.AddressTypeEntropy)
+	}
+	if b.Confidence < 0.95 {
+		t.Errorf("Expected near-certain confidence. Got %.3f", b.Confidence)
+	}
+
+	res := AnalyzeTx(tx)
+	if res.HeuristicFlags&FlagIsBatchPayout == 0 {
+		t.Errorf("Expected FlagIsBatchPayout on the analysis result")
+	}
+	if res.Topology == nil || res.Topology.BatchPayout == nil || !res.Topology.BatchPayout.IsBatch {
+		t.Errorf("Expected the batch result on the topology. Got %+v", res.Topology)
+	}
+}
+
+func TestDetectBatchPayout_EqualValueFanOut(t *testing.T) {
+	tx := models.Transaction{Inputs: []models.TxIn{{Address: "bc1qairdrop", Value: 30_000_000}}}
+	for i := 0; i < 200; i++ {
+		tx.Outputs = append(tx.Outputs, models.TxOut{Address: fmt.Sprintf("bc1qrecipient%d", i), Value: 100_000})
+	}
+
+	b := DetectBatchPayout(tx)
+	if b.ValueEntropy != 0 || b.AddressTypeEntropy != 0 {
+		t.Errorf("Expected zero value/type entropy. Got %.3f / %.3f", b.ValueEntropy, b.AddressTypeEntropy)
+	}
+	if b.IsBatch {
+		t.Errorf("Expected an equal-value single-type fan-out below the batch threshold. Got %+v", b)
+	}
+
+	// Too many inputs for a hot-wallet payout
+	wide := exchangeWithdrawal(50)
+	for i := 0; i < 5; i++ {
+		wide.Inputs = append(wide.Inputs, models.TxIn{Address: fmt.Sprintf("bc1qin%d", i), Value: 1_000_000})
+	}
+	if got := DetectBatchPayout(wide); got.IsBatch {
+		t.Errorf("Expected a 6-input fan-out to be rejected. Got %+v", got)
+	}
+}
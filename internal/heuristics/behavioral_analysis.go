@@ -1,8 +1,11 @@
 package heuristics
 
 import (
+	"encoding/json"
 	"math"
 	"time"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
 )
 
 // Pattern-of-Life Behavioral Analysis
@@ -36,6 +39,16 @@ type BehavioralProfile struct {
 	IsBot            bool    `json:"isBot"`            // High regularity + high frequency
 }
 
+// MarshalJSON rounds reported floats to the configured precision
+func (p BehavioralProfile) MarshalJSON() ([]byte, error) {
+	type alias BehavioralProfile
+	a := alias(p)
+	a.WeekdayRatio = models.RoundFloat(a.WeekdayRatio)
+	a.Regularity = models.RoundFloat(a.Regularity)
+	a.TxFrequency = models.RoundFloat(a.TxFrequency)
+	return json.Marshal(a)
+}
+
 // AnalyzeBehavioralPattern computes temporal behavioral profile
 // from a set of transaction timestamps.
 func AnalyzeBehavioralPattern(txTimes []time.Time) BehavioralProfile {
@@ -73,7 +86,7 @@ func AnalyzeBehavioralPattern(txTimes []time.Time) BehavioralProfile {
 	profile.InferredTimezone = inferTimezoneFromPeak(profile.PeakHourUTC)
 
 	// 4. Weekday ratio
-	profile.WeekdayRatio = math.Round(float64(weekdayCount)*100/float64(len(txTimes))) / 100
+	profile.WeekdayRatio = float64(weekdayCount) / float64(len(txTimes))
 
 	// 5. Regularity (coefficient of variation of inter-tx intervals)
 	profile.Regularity = computeRegularity(txTimes)
@@ -82,7 +95,7 @@ func AnalyzeBehavioralPattern(txTimes []time.Time) BehavioralProfile {
 	if len(txTimes) >= 2 {
 		span := txTimes[len(txTimes)-1].Sub(txTimes[0])
 		if span.Hours() > 0 {
-			profile.TxFrequency = math.Round(float64(len(txTimes))*100/(span.Hours()/24)) / 100
+			profile.TxFrequency = float64(len(txTimes)) / (span.Hours() / 24)
 		}
 	}
 
@@ -174,7 +187,7 @@ func computeRegularity(times []time.Time) float64 {
 	// Convert to regularity: reg = 1 / (1 + CV)
 	regularity := 1.0 / (1.0 + cv)
 
-	return math.Round(regularity*100) / 100
+	return regularity
 }
 
 // classifyEntityFromBehavior infers entity type from behavioral patterns
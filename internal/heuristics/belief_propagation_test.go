@@ -0,0 +1,108 @@
+package heuristics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func TestInferEntityBeliefs_ChainIsExact(t *testing.T) {
+	edges := []models.EvidenceEdge{
+		{SrcNodeID: "A", DstNodeID: "B", EdgeType: 1, LLRScore: 1.0},
+		{SrcNodeID: "B", DstNodeID: "C", EdgeType: 1, LLRScore: 1.0},
+	}
+	res := InferEntityBeliefs(edges, "A")
+	if !res.Converged {
+		t.Fatalf("Expected BP to converge on a tree. Ran %d iterations", res.Iterations)
+	}
+	if math.Abs(res.Beliefs["B"]-1.0) > 1e-4 {
+		t.Errorf("Expected B to carry the direct edge LLR 1.0. Got %.4f", res.Beliefs["B"])
+	}
+	// P(A~C) = P(both links) + P(neither) with odds 10:1 each → log10(101/20)
+	if want := math.Log10(101.0 / 20.0); math.Abs(res.Beliefs["C"]-want) > 1e-4 {
+		t.Errorf("Expected the two-hop belief %.4f. Got %.4f", want, res.Beliefs["C"])
+	}
+}
+
+func TestInferEntityBeliefs_CorrelatedEdgesDiscounted(t *testing.T) {
+	correlated := []models.EvidenceEdge{
+		{SrcNodeID: "A", DstNodeID: "B", EdgeType: 2, LLRScore: 1.0, DependencyGroup: DepGroupValueConstraints},
+		{SrcNodeID: "A", DstNodeID: "B", EdgeType: 2, LLRScore: 1.0, DependencyGroup: DepGroupValueConstraints},
+		{SrcNodeID: "A", DstNodeID: "B", EdgeType: 2, LLRScore: 1.0, DependencyGroup: DepGroupValueConstraints},
+	}
+	independent := []models.EvidenceEdge{
+		{SrcNodeID: "A", DstNodeID: "B", EdgeType: 1, LLRScore: 1.0, DependencyGroup: DepGroupNone},
+		{SrcNodeID: "A", DstNodeID: "B", EdgeType: 1, LLRScore: 1.0, DependencyGroup: DepGroupNone},
+		{SrcNodeID: "A", DstNodeID: "B", EdgeType: 1, LLRScore: 1.0, DependencyGroup: DepGroupNone},
+	}
+
+	c := InferEntityBeliefs(correlated, "A")
+	i := InferEntityBeliefs(independent, "A")
+	if c.Beliefs["B"] >= i.Beliefs["B"] {
+		t.Errorf("Expected correlated change edges to contribute less than independent CIOH edges. Got %.2f vs %.2f",
+			c.Beliefs["B"], i.Beliefs["B"])
+	}
+	if c.DiscountedEdges != 2 || c.EffectiveFactors != 1 {
+		t.Errorf("Expected 2 discounted edges and 1 factor. Got %d / %d", c.DiscountedEdges, c.EffectiveFactors)
+	}
+	if i.DiscountedEdges != 0 || i.EffectiveFactors != 3 {
+		t.Errorf("Expected 0 discounted edges and 3 factors. Got %d / %d", i.DiscountedEdges, i.EffectiveFactors)
+	}
+	if math.Abs(i.Beliefs["B"]-3.0) > 1e-4 {
+		t.Errorf("Expected independent evidence to add up to 3.0. Got %.4f", i.Beliefs["B"])
+	}
+}
+
+func TestInferEntityBeliefs_LoopConverges(t *testing.T) {
+	edges := []models.EvidenceEdge{
+		{SrcNodeID: "A", DstNodeID: "B", EdgeType: 1, LLRScore: 1.0},
+		{SrcNodeID: "B", DstNodeID: "C", EdgeType: 1, LLRScore: 1.0},
+		{SrcNodeID: "C", DstNodeID: "A", EdgeType: 1, LLRScore: 1.0},
+		{SrcNodeID: "C", DstNodeID: "D", EdgeType: 3, LLRScore: -2.0, DependencyGroup: DepGroupCoordination},
+	}
+	res := InferEntityBeliefs(edges, "A")
+	if !res.Converged {
+		t.Fatalf("Expected damped BP to converge on a triangle. Ran %d iterations", res.Iterations)
+	}
+	b := res.Beliefs["B"]
+	if b <= 1.0 || b >= 2.0 || math.IsNaN(b) {
+		t.Errorf("Expected the second path through C to strengthen but not double B's belief. Got %.4f", b)
+	}
+	if res.Beliefs["D"] >= 0 {
+		t.Errorf("Expected negative gating to push D away from the anchor's entity. Got %.4f", res.Beliefs["D"])
+	}
+}
+
+func TestInferEntityBeliefs_HyperEdgeAndUnknownAnchor(t *testing.T) {
+	edges := []models.EvidenceEdge{
+		{SrcNodeID: "A", EdgeType: 1, LLRScore: 1.28, DependencyGroup: DepGroupScriptHomogeneity, Members: []string{"B", "C"}},
+	}
+	res := InferEntityBeliefs(edges, "A")
+	if math.Abs(res.Beliefs["B"]-1.28) > 1e-4 || math.Abs(res.Beliefs["C"]-1.28) > 1e-4 {
+		t.Errorf("Expected each hyper-edge member bound to the anchor. Got %v", res.Beliefs)
+	}
+	if res := InferEntityBeliefs(edges, "Z"); len(res.Beliefs) != 0 {
+		t.Errorf("Expected no beliefs for an unconnected anchor. Got %v", res.Beliefs)
+	}
+}
+
+func TestEvaluateFactorGraph_IndependentEdgesAdd(t *testing.T) {
+	change := []models.EvidenceEdge{
+		{EdgeType: 2, LLRScore: 0.8, DependencyGroup: DepGroupValueConstraints},
+		{EdgeType: 2, LLRScore: 0.8, DependencyGroup: DepGroupValueConstraints},
+		{EdgeType: 2, LLRScore: 0.8, DependencyGroup: DepGroupValueConstraints},
+	}
+	cioh := []models.EvidenceEdge{
+		{EdgeType: 1, LLRScore: 0.8, DependencyGroup: DepGroupNone},
+		{EdgeType: 1, LLRScore: 0.8, DependencyGroup: DepGroupNone},
+		{EdgeType: 1, LLRScore: 0.8, DependencyGroup: DepGroupNone},
+	}
+	c, i := EvaluateFactorGraph(change), EvaluateFactorGraph(cioh)
+	if c.PosteriorLLR >= i.PosteriorLLR || math.Abs(i.PosteriorLLR-2.4) > 1e-9 {
+		t.Errorf("Expected 0.8 for correlated vs 2.4 for independent. Got %.2f vs %.2f", c.PosteriorLLR, i.PosteriorLLR)
+	}
+	if i.EffectiveFactors != 3 || i.DiscountedEdges != 0 {
+		t.Errorf("Expected 3 independent factors. Got %d (%d discounted)", i.EffectiveFactors, i.DiscountedEdges)
+	}
+}
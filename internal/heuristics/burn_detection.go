@@ -0,0 +1,94 @@
+package heuristics
+
+import (
+	"strings"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Burn / Provably-Unspendable Output Detection
+//
+// Some outputs can never be spent: value sent there is destroyed. They
+// are terminal in fund flow — tracing must stop there, and the value is
+// "burned", not "unspent" (which would imply it can still move):
+//
+//   - OP_RETURN outputs: the script fails unconditionally (data carriers,
+//     protocol markers, deliberate burns)
+//   - Oversized scripts: > MAX_SCRIPT_SIZE (10,000 bytes) can never execute
+//   - Burn addresses: base58 strings chosen by hand with a valid checksum
+//     but no known key (1BitcoinEater..., Counterparty's proof-of-burn,
+//     the all-zero hash160), recognized exactly or by a long vanity run
+//     no key-derived address would contain
+//
+// References:
+//   - Bitcoin Core script/script.h: CScript::IsUnspendable(), MAX_SCRIPT_SIZE
+//   - Counterparty, "Proof-of-Burn" (2014)
+//   - Bartoletti & Pompianu, "An analysis of Bitcoin OP_RETURN metadata" (FC 2017)
+
+// maxScriptSize is consensus MAX_SCRIPT_SIZE in bytes
+const maxScriptSize = 10_000
+
+// burnVanityRun is the repeated-character run marking a vanity burn address.
+// A run of 10 in a key-derived address has probability ~58^-9.
+const burnVanityRun = 10
+
+// knownBurnAddresses are widely documented provably-unspendable addresses
+var knownBurnAddresses = map[string]string{
+	"1BitcoinEaterAddressDontSendf59kuE": "Bitcoin Eater",
+	"1CounterpartyXXXXXXXXXXXXXXXUWLpVr": "Counterparty proof-of-burn",
+	"1111111111111111111114oLvT2":        "Zero hash160",
+}
+
+// DetectBurnOutput reports whether an output is provably unspendable,
+// returning the reason ("op_return", "oversized_script" or "burn_address:<label>")
+func DetectBurnOutput(out models.TxOut) (string, bool) {
+	if isOPReturn(out.ScriptPubKey) {
+		return "op_return", true
+	}
+	if len(out.ScriptPubKey)/2 > maxScriptSize {
+		return "oversized_script", true
+	}
+	if label, ok := knownBurnAddresses[out.Address]; ok {
+		return "burn_address:" + label, true
+	}
+	if isVanityBurnAddress(out.Address) {
+		return "burn_address:vanity", true
+	}
+	return "", false
+}
+
+// DetectBurnOutputs returns the indices of a transaction's burn outputs and their total value
+func DetectBurnOutputs(tx models.Transaction) ([]int, int64) {
+	var indices []int
+	var value int64
+	for i, out := range tx.Outputs {
+		if _, burned := DetectBurnOutput(out); burned {
+			indices = append(indices, i)
+			value += out.Value
+		}
+	}
+	return indices, value
+}
+
+// isVanityBurnAddress spots hand-made base58 burn addresses by a long
+// run of one repeated character (bech32 burns are not human-chosen text)
+func isVanityBurnAddress(addr string) bool {
+	if addr == "" || strings.HasPrefix(strings.ToLower(addr), "bc1") {
+		return false
+	}
+	if addr[0] != '1' && addr[0] != '3' {
+		return false
+	}
+	run := 1
+	for i := 1; i < len(addr); i++ {
+		if addr[i] == addr[i-1] {
+			run++
+			if run >= burnVanityRun {
+				return true
+			}
+		} else {
+			run = 1
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package heuristics
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func TestDetectBurnOutput(t *testing.T) {
+	cases := []struct {
+		name   string
+		out    models.TxOut
+		reason string
+	}{
+		{"op_return", models.TxOut{ScriptPubKey: opReturnScript("hello"), Value: 0}, "op_return"},
+		{"uppercase op_return", models.TxOut{ScriptPubKey: "6A0401020304"}, "op_return"},
+		{"bitcoin eater", models.TxOut{Address: "1BitcoinEaterAddressDontSendf59kuE", Value: 5_000}, "burn_address:Bitcoin Eater"},
+		{"counterparty", models.TxOut{Address: "1CounterpartyXXXXXXXXXXXXXXXUWLpVr", Value: 100_000}, "burn_address:Counterparty proof-of-burn"},
+		{"vanity run", models.TxOut{Address: "1BurnXXXXXXXXXXXXXXXXXXXXXXXXTqpnB"}, "burn_address:vanity"},
+		{"oversized script", models.TxOut{ScriptPubKey: "51" + strings.Repeat("00", maxScriptSize)}, "oversized_script"},
+	}
+	for _, tc := range cases {
+		reason, burned := DetectBurnOutput(tc.out)
+		if !burned || reason != tc.reason {
+			t.Errorf("%s: expected burn %q. Got %q (burned=%v)", tc.name, tc.reason, reason, burned)
+		}
+	}
+
+	spendable := []models.TxOut{
+		{Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", ScriptPubKey: "0014e8df018c7e326cc253faac7e46cdc51e68542c42"},
+		{Address: "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", ScriptPubKey: "76a914" + strings.Repeat("ab", 20) + "88ac"},
+		{Address: "bc1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqq"}, // bech32 runs are not vanity burns
+	}
+	for _, out := range spendable {
+		if reason, burned := DetectBurnOutput(out); burned {
+			t.Errorf("Expected %s to be spendable. Got burn %q", out.Address, reason)
+		}
+	}
+}
+
+func TestAnalyzeTx_RecordsBurnedOutputs(t *testing.T) {
+	tx := models.Transaction{
+		Txid:   "burn1",
+		Inputs: []models.TxIn{{Address: "bc1qsender", Value: 200_000}},
+		Outputs: []models.TxOut{
+			{Address: "bc1qrecipient", Value: 90_000},
+			{Address: "1BitcoinEaterAddressDontSendf59kuE", Value: 100_000},
+			{ScriptPubKey: opReturnScript("gm")},
+		},
+	}
+	res := AnalyzeTx(tx)
+	if len(res.BurnedOutputs) != 2 || res.BurnedOutputs[0] != 1 || res.BurnedOutputs[1] != 2 {
+		t.Errorf("Expected outputs [1 2] burned. Got %v", res.BurnedOutputs)
+	}
+	if res.BurnedValue != 100_000 {
+		t.Errorf("Expected 100000 sats burned. Got %d", res.BurnedValue)
+	}
+}
+
+func TestTraceFundFlow_StopsAtBurn(t *testing.T) {
+	burnTx := simpleSpend("tx2", "theft", 1_000_000, 101,
+		models.TxOut{Address: "1BitcoinEaterAddressDontSendf59kuE", Value: 600_000},
+		models.TxOut{Address: "hopA", Value: 390_000},
+		models.TxOut{ScriptPubKey: opReturnScript("proof-of-burn"), Value: 5_000},
+	)
+	chain := fakeChain{"theft": {burnTx}}
+
+	graph := TraceFundFlow(context.Background(), chain, []string{"theft"}, DefaultTraceConfig())
+
+	if role := nodeRole(graph, "1BitcoinEaterAddressDontSendf59kuE"); role != "burned" {
+		t.Errorf("Expected the burn address to be labeled burned. Got %q", role)
+	}
+	if role := nodeRole(graph, "hopA"); role != "unspent" {
+		t.Errorf("Expected the spendable output to stay unspent. Got %q", role)
+	}
+	if role := nodeRole(graph, "burn:tx2:2"); role != "burned" {
+		t.Errorf("Expected the OP_RETURN output to be labeled burned. Got %q", role)
+	}
+	if graph.BurnedValue != 605_000 {
+		t.Errorf("Expected 605000 sats burned. Got %d", graph.BurnedValue)
+	}
+	for _, e := range graph.Edges {
+		if e.FromAddress == "1BitcoinEaterAddressDontSendf59kuE" {
+			t.Errorf("Trace must stop at a burn. Got onward edge %+v", e)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package heuristics
+
+import (
+	"sync/atomic"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Wallet-Clustering Change Link
+//
+// The sixth change sub-heuristic. Wallets derive a brand-new address for
+// change, while payments to exchanges, merchants and services usually go
+// to addresses the engine has already seen: reused deposit addresses that
+// sit in a cluster or carry an address label. When exactly one output is
+// unseen and every other output is known, the unseen output is the change.
+//
+// "Known" is answered by the address labels plus an optional source
+// registered with SetKnownAddressSource (the block scanner registers its
+// persistent ClusterEngine). Without either, the vote never fires.
+
+// KnownAddressSource reports whether an address already belongs to an
+// entity the engine has seen
+type KnownAddressSource func(addr string) bool
+
+var knownAddressSource atomic.Pointer[KnownAddressSource]
+
+// SetKnownAddressSource registers the cluster lookup consulted by the
+// wallet-clustering change vote; nil unregisters it
+func SetKnownAddressSource(src KnownAddressSource) {
+	if src == nil {
+		knownAddressSource.Store(nil)
+		return
+	}
+	knownAddressSource.Store(&src)
+}
+
+// isKnownAddress reports whether addr is labeled or seen by the registered source
+func isKnownAddress(addr string) bool {
+	if addr == "" {
+		return false
+	}
+	if _, ok := GetGlobalAddressLabels().Get(addr); ok {
+		return true
+	}
+	if src := knownAddressSource.Load(); src != nil {
+		return (*src)(addr)
+	}
+	return false
+}
+
+// freshChangeOutput returns the index of the only output not known to the
+// engine when every other output is known, or -1
+func freshChangeOutput(tx models.Transaction) int {
+	fresh := -1
+	for i, out := range tx.Outputs {
+		if out.Address == "" {
+			return -1 // OP_RETURN / non-standard: nothing to attribute
+		}
+		if isKnownAddress(out.Address) {
+			continue
+		}
+		if fresh >= 0 {
+			return -1 // More than one unseen output
+		}
+		fresh = i
+	}
+	if fresh < 0 {
+		return -1 // Every output known
+	}
+	for _, in := range tx.Inputs {
+		if in.Address == tx.Outputs[fresh].Address {
+			return -1 // Not fresh: pays back an input
+		}
+	}
+	return fresh
+}
@@ -0,0 +1,60 @@
+package heuristics
+
+import (
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func TestDetectChangeOutput_PrefersFreshAddressBesideKnownEntity(t *testing.T) {
+	// Optimal change alone picks the smaller output 0, the exchange deposit
+	tx := models.Transaction{
+		Inputs: []models.TxIn{
+			{Address: "bc1qsenderinputaaaaaaaaaaaaaaaaaaaaaaaaaa", Value: 300_000},
+			{Address: "bc1qsenderinputbbbbbbbbbbbbbbbbbbbbbbbbbb", Value: 250_000},
+		},
+		Outputs: []models.TxOut{
+			{Address: "bc1qexchangedepositcccccccccccccccccccccc", Value: 123_457},
+			{Address: "bc1qfreshchangeddddddddddddddddddddddddddd", Value: 400_001},
+		},
+		Fee: 26_542,
+	}
+	if res := DetectChangeOutput(tx); res.ChangeIndex != 0 {
+		t.Fatalf("Expected optimal change to pick output 0 without cluster data. Got %+v", res)
+	}
+
+	ce := NewClusterEngine()
+	ce.Union(tx.Outputs[0].Address, "bc1qexchangehotwalleteeeeeeeeeeeeeeeeeeeee")
+	SetKnownAddressSource(ce.Contains)
+	defer SetKnownAddressSource(nil)
+
+	res := DetectChangeOutput(tx)
+	if res.ChangeIndex != 1 || res.Method != "fresh_address_cluster" {
+		t.Errorf("Expected the unseen output 1 as change. Got %+v", res)
+	}
+
+	cfg := DefaultChangeDetectionConfig()
+	cfg.ClusterChange = 0
+	if res := DetectChangeOutputWith(tx, cfg); res.ChangeIndex != 0 {
+		t.Errorf("Expected the disabled vote to leave output 0. Got %+v", res)
+	}
+}
+
+func TestFreshChangeOutput_RequiresExactlyOneUnseen(t *testing.T) {
+	tx := models.Transaction{
+		Inputs: []models.TxIn{{Address: "bc1qsenderinputaaaaaaaaaaaaaaaaaaaaaaaaaa", Value: 300_000}},
+		Outputs: []models.TxOut{
+			{Address: "bc1qfreshoneaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", Value: 100_000},
+			{Address: "bc1qfreshtwobbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Value: 190_000},
+		},
+	}
+	if idx := freshChangeOutput(tx); idx != -1 {
+		t.Errorf("Expected no vote with two unseen outputs. Got %d", idx)
+	}
+
+	SetKnownAddressSource(func(addr string) bool { return true })
+	defer SetKnownAddressSource(nil)
+	if idx := freshChangeOutput(tx); idx != -1 {
+		t.Errorf("Expected no vote when every output is known. Got %d", idx)
+	}
+}
@@ -18,7 +18,7 @@ type ChangeDetectionResult struct {
 	ScriptTypeMatch bool    `json:"scriptTypeMatch"` // Change matches input script type
 }
 
-// DetectChangeOutput implements 5 independent sub-heuristics used by
+// DetectChangeOutput implements 6 independent sub-heuristics used by
 // Chainalysis, BlockSci, and OXT Research to identify which output
 // returns change to the sender:
 //
@@ -32,10 +32,19 @@ type ChangeDetectionResult struct {
 //     it's the sender (not change in the traditional sense, but still linkable)
 //  5. Shadow Change (Largest Remainder) — in 1-in-2-out transactions,
 //     if fee + smaller output ≈ an input value, that smaller output is the change
+//  6. Wallet-Clustering Change Link — wallets send change to a brand-new
+//     address, so the only output never seen in a cluster (or label) beside
+//     payments to known entities is the change (see change_cluster_link.go)
 //
 // Returns the index of the most likely change output and a confidence score.
 // Explicitly gates against CoinJoin transactions where change detection is meaningless.
+// Votes are weighted by the process-wide ChangeDetectionConfig.
 func DetectChangeOutput(tx models.Transaction) ChangeDetectionResult {
+	return DetectChangeOutputWith(tx, CurrentChangeDetectionConfig())
+}
+
+// DetectChangeOutputWith runs DetectChangeOutput with explicit vote weights
+func DetectChangeOutputWith(tx models.Transaction, cfg ChangeDetectionConfig) ChangeDetectionResult {
 	result := ChangeDetectionResult{ChangeIndex: -1}
 
 	// Gate: Change detection is only meaningful for non-CoinJoin standard transactions
@@ -71,7 +80,7 @@ func DetectChangeOutput(tx models.Transaction) ChangeDetectionResult {
 			}
 		}
 		if smallestIdx >= 0 {
-			votes = append(votes, vote{smallestIdx, 0.3, "optimal_change"})
+			votes = append(votes, vote{smallestIdx, cfg.OptimalChange, "optimal_change"})
 		}
 	}
 
@@ -97,7 +106,7 @@ func DetectChangeOutput(tx models.Transaction) ChangeDetectionResult {
 	}
 
 	if roundCount >= 1 && nonRoundCount == 1 {
-		votes = append(votes, vote{lastNonRoundIdx, 0.35, "round_number"})
+		votes = append(votes, vote{lastNonRoundIdx, cfg.RoundNumber, "round_number"})
 		result.IsRoundPayment = true
 	}
 
@@ -134,7 +143,7 @@ func DetectChangeOutput(tx models.Transaction) ChangeDetectionResult {
 
 			// If exactly one output matches the input type and others don't, it's likely change
 			if len(matchingOutputs) == 1 {
-				votes = append(votes, vote{matchingOutputs[0], 0.25, "script_type_match"})
+				votes = append(votes, vote{matchingOutputs[0], cfg.ScriptTypeMatch, "script_type_match"})
 				result.ScriptTypeMatch = true
 			}
 		}
@@ -151,7 +160,7 @@ func DetectChangeOutput(tx models.Transaction) ChangeDetectionResult {
 	}
 	for i, out := range tx.Outputs {
 		if out.Address != "" && inputAddrs[out.Address] {
-			votes = append(votes, vote{i, 0.5, "address_reuse_self"})
+			votes = append(votes, vote{i, cfg.AddressReuseSelf, "address_reuse_self"})
 		}
 	}
 
@@ -167,11 +176,19 @@ func DetectChangeOutput(tx models.Transaction) ChangeDetectionResult {
 			expectedPayment := inputVal - fee - tx.Outputs[otherIdx].Value
 			if expectedPayment == tx.Outputs[i].Value {
 				// Output i is the payment, otherIdx is the change
-				votes = append(votes, vote{otherIdx, 0.2, "shadow_change"})
+				votes = append(votes, vote{otherIdx, cfg.ShadowChange, "shadow_change"})
 			}
 		}
 	}
 
+	// ─── Heuristic 6: Wallet-Clustering Change Link ────────────────────
+	// Payments to exchanges and services go to addresses the engine has
+	// already clustered or labeled; fresh change addresses have never been
+	// seen. Only fires when exactly one output is unseen.
+	if idx := freshChangeOutput(tx); idx >= 0 {
+		votes = append(votes, vote{idx, cfg.ClusterChange, "fresh_address_cluster"})
+	}
+
 	// ─── Weighted Majority Vote ────────────────────────────────────────
 	if len(votes) == 0 {
 		return result
@@ -180,6 +197,9 @@ func DetectChangeOutput(tx models.Transaction) ChangeDetectionResult {
 	scoreByIndex := make(map[int]float64)
 	methodsByIndex := make(map[int][]string)
 	for _, v := range votes {
+		if v.weight <= 0 {
+			continue // Sub-heuristic disabled
+		}
 		scoreByIndex[v.index] += v.weight
 		methodsByIndex[v.index] = append(methodsByIndex[v.index], v.method)
 	}
@@ -193,7 +213,7 @@ func DetectChangeOutput(tx models.Transaction) ChangeDetectionResult {
 		}
 	}
 
-	if bestIdx >= 0 && bestScore >= 0.25 { // Minimum confidence threshold
+	if bestIdx >= 0 && bestScore >= cfg.MinScore { // Minimum confidence threshold
 		result.ChangeIndex = bestIdx
 		result.Confidence = math.Min(bestScore, 1.0)
 		result.Method = strings.Join(methodsByIndex[bestIdx], "+")
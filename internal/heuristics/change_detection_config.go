@@ -0,0 +1,123 @@
+package heuristics
+
+import (
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"sync/atomic"
+)
+
+// Change Detection Voting Weights
+//
+// DetectChangeOutput sums the weights of the sub-heuristics voting for
+// each output and picks the best-scoring output at or above MinScore.
+// How much each sub-heuristic can be trusted depends on the wallet mix of
+// the dataset (round payments are rarer on exchange-heavy data, script
+// types matter less once most wallets are Taproot), so the weights are
+// tunable. A zero weight disables that sub-heuristic.
+//
+// Environment overrides (read by ChangeDetectionConfigFromEnv):
+//   CHANGE_WEIGHT_OPTIMAL, CHANGE_WEIGHT_ROUND, CHANGE_WEIGHT_SCRIPT_TYPE,
+//   CHANGE_WEIGHT_ADDRESS_REUSE, CHANGE_WEIGHT_SHADOW, CHANGE_WEIGHT_CLUSTER,
+//   CHANGE_MIN_SCORE
+
+// ChangeDetectionConfig holds the sub-heuristic vote weights
+type ChangeDetectionConfig struct {
+	OptimalChange    float64 `json:"optimalChange"`    // Smallest output below every input
+	RoundNumber      float64 `json:"roundNumber"`      // Only non-round output beside round payments
+	ScriptTypeMatch  float64 `json:"scriptTypeMatch"`  // Only output matching the dominant input type
+	AddressReuseSelf float64 `json:"addressReuseSelf"` // Output pays an input address
+	ShadowChange     float64 `json:"shadowChange"`     // 1-in-2-out fee subtraction
+	ClusterChange    float64 `json:"clusterChange"`    // Only unseen output beside known-entity outputs
+	MinScore         float64 `json:"minScore"`         // Summed weight required to report a change output
+}
+
+// DefaultChangeDetectionConfig returns the historical weights
+func DefaultChangeDetectionConfig() ChangeDetectionConfig {
+	return ChangeDetectionConfig{
+		OptimalChange:    0.3,
+		RoundNumber:      0.35,
+		ScriptTypeMatch:  0.25,
+		AddressReuseSelf: 0.5,
+		ShadowChange:     0.2,
+		ClusterChange:    0.4,
+		MinScore:         0.25,
+	}
+}
+
+var changeDetectionConfig atomic.Pointer[ChangeDetectionConfig]
+
+func init() {
+	cfg := DefaultChangeDetectionConfig()
+	changeDetectionConfig.Store(&cfg)
+}
+
+// SetChangeDetectionConfig replaces the process-wide vote weights.
+// Negative or non-finite weights and a non-positive MinScore fall back to
+// their defaults.
+func SetChangeDetectionConfig(cfg ChangeDetectionConfig) {
+	cfg = cfg.withDefaults()
+	changeDetectionConfig.Store(&cfg)
+}
+
+// CurrentChangeDetectionConfig returns the process-wide vote weights
+func CurrentChangeDetectionConfig() ChangeDetectionConfig {
+	return *changeDetectionConfig.Load()
+}
+
+// ChangeDetectionConfigFromEnv builds the vote weights from the CHANGE_*
+// environment variables, keeping defaults for unset or invalid values.
+func ChangeDetectionConfigFromEnv() ChangeDetectionConfig {
+	cfg := DefaultChangeDetectionConfig()
+	for _, f := range []struct {
+		env   string
+		field *float64
+		zero  bool // Zero accepted (disables the sub-heuristic)
+	}{
+		{"CHANGE_WEIGHT_OPTIMAL", &cfg.OptimalChange, true},
+		{"CHANGE_WEIGHT_ROUND", &cfg.RoundNumber, true},
+		{"CHANGE_WEIGHT_SCRIPT_TYPE", &cfg.ScriptTypeMatch, true},
+		{"CHANGE_WEIGHT_ADDRESS_REUSE", &cfg.AddressReuseSelf, true},
+		{"CHANGE_WEIGHT_SHADOW", &cfg.ShadowChange, true},
+		{"CHANGE_WEIGHT_CLUSTER", &cfg.ClusterChange, true},
+		{"CHANGE_MIN_SCORE", &cfg.MinScore, false},
+	} {
+		raw := os.Getenv(f.env)
+		if raw == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(raw, 64)
+		if err == nil && validChangeWeight(v) && (f.zero || v > 0) {
+			*f.field = v
+		} else {
+			log.Printf("[Change] Invalid %s %q, using %.2f", f.env, raw, *f.field)
+		}
+	}
+	return cfg
+}
+
+// withDefaults replaces invalid fields with DefaultChangeDetectionConfig values
+func (c ChangeDetectionConfig) withDefaults() ChangeDetectionConfig {
+	def := DefaultChangeDetectionConfig()
+	for _, f := range []struct{ field, def *float64 }{
+		{&c.OptimalChange, &def.OptimalChange},
+		{&c.RoundNumber, &def.RoundNumber},
+		{&c.ScriptTypeMatch, &def.ScriptTypeMatch},
+		{&c.AddressReuseSelf, &def.AddressReuseSelf},
+		{&c.ShadowChange, &def.ShadowChange},
+		{&c.ClusterChange, &def.ClusterChange},
+	} {
+		if !validChangeWeight(*f.field) {
+			*f.field = *f.def
+		}
+	}
+	if !validChangeWeight(c.MinScore) || c.MinScore == 0 {
+		c.MinScore = def.MinScore
+	}
+	return c
+}
+
+func validChangeWeight(v float64) bool {
+	return v >= 0 && !math.IsInf(v, 0) && !math.IsNaN(v)
+}
@@ -0,0 +1,76 @@
+package heuristics
+
+import (
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// roundVsScriptType: the round-number vote picks output 0 (the only
+// non-round output), the script-type vote picks output 1 (the only P2WPKH
+// output beside P2WPKH inputs)
+func roundVsScriptType() models.Transaction {
+	return models.Transaction{
+		Inputs: []models.TxIn{
+			{Address: "bc1qsenderinputaaaaaaaaaaaaaaaaaaaaaaaaaa", Value: 300_000},
+			{Address: "bc1qsenderinputbbbbbbbbbbbbbbbbbbbbbbbbbb", Value: 250_000},
+		},
+		Outputs: []models.TxOut{
+			{Address: "bc1precipientcccccccccccccccccccccccccccccccccccccccccccccccc", Value: 123_456},
+			{Address: "bc1qrecipientdddddddddddddddddddddddddddd", Value: 400_000},
+		},
+		Fee: 26_544,
+	}
+}
+
+func TestDetectChangeOutputWith_TunedWeightChangesIndex(t *testing.T) {
+	tx := roundVsScriptType()
+
+	if res := DetectChangeOutput(tx); res.ChangeIndex != 0 {
+		t.Fatalf("Expected default weights to pick output 0 (round_number). Got %+v", res)
+	}
+
+	cfg := DefaultChangeDetectionConfig()
+	cfg.ScriptTypeMatch = 0.8
+	if res := DetectChangeOutputWith(tx, cfg); res.ChangeIndex != 1 || res.Method != "script_type_match" {
+		t.Errorf("Expected a heavier script-type weight to pick output 1. Got %+v", res)
+	}
+
+	cfg = DefaultChangeDetectionConfig()
+	cfg.MinScore = 0.9
+	if res := DetectChangeOutputWith(tx, cfg); res.ChangeIndex != -1 {
+		t.Errorf("Expected no change above a 0.9 minimum score. Got %+v", res)
+	}
+}
+
+func TestSetChangeDetectionConfig_InvalidFallsBack(t *testing.T) {
+	defer SetChangeDetectionConfig(DefaultChangeDetectionConfig())
+
+	cfg := DefaultChangeDetectionConfig()
+	cfg.RoundNumber = 0 // Disabled
+	cfg.ShadowChange = -1
+	cfg.MinScore = 0
+	SetChangeDetectionConfig(cfg)
+
+	got := CurrentChangeDetectionConfig()
+	def := DefaultChangeDetectionConfig()
+	if got.RoundNumber != 0 || got.ShadowChange != def.ShadowChange || got.MinScore != def.MinScore {
+		t.Errorf("Expected zero kept and invalid fields defaulted. Got %+v", got)
+	}
+}
+
+func TestChangeDetectionConfigFromEnv(t *testing.T) {
+	t.Setenv("CHANGE_WEIGHT_ROUND", "0.1")
+	t.Setenv("CHANGE_WEIGHT_SHADOW", "0")
+	t.Setenv("CHANGE_WEIGHT_OPTIMAL", "heavy")
+	t.Setenv("CHANGE_MIN_SCORE", "0")
+
+	cfg := ChangeDetectionConfigFromEnv()
+	def := DefaultChangeDetectionConfig()
+	if cfg.RoundNumber != 0.1 || cfg.ShadowChange != 0 {
+		t.Errorf("Expected round=0.1 and shadow disabled. Got %+v", cfg)
+	}
+	if cfg.OptimalChange != def.OptimalChange || cfg.MinScore != def.MinScore {
+		t.Errorf("Expected invalid values to keep defaults. Got %+v", cfg)
+	}
+}
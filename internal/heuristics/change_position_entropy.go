@@ -0,0 +1,174 @@
+package heuristics
+
+import (
+	"math"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Change-Position Randomization (per-cluster anti-fingerprint signal)
+//
+// Naive wallets always append change as the last output, which makes the
+// "change is last" heuristic reliable for them. Privacy wallets (Wasabi,
+// Samourai, Sparrow) randomize the change position precisely to defeat it.
+// A single transaction cannot tell the two apart, but an entity's history
+// can: the ClusterEngine keeps, at each cluster root, how often change
+// landed first, in the middle or last, and the Shannon entropy of that
+// distribution separates a fixed policy from a randomized one:
+//
+//   - Fixed: normalized entropy ≤ fixedChangeEntropy (always first or always last)
+//   - Randomized: normalized entropy ≥ randomizedChangeEntropy
+//   - Evidence: at least minChangePositionSamples change outputs in the cluster
+//   - BIP69 transactions are skipped (value/script ordering is deterministic,
+//     not random, even though the change position varies)
+//
+// Randomization is privacy-positive (change heuristics on the entity's
+// transactions become coin flips) and attributes the cluster to a privacy
+// wallet when the per-tx fingerprint was inconclusive. Bitcoin Core also
+// randomizes change position, so a per-tx bitcoin_core attribution is kept.
+//
+// References:
+//   - Meiklejohn et al., "A Fistful of Bitcoins" (IMC 2013) — change heuristics
+//   - Kappos et al., "How to Peel a Million" (USENIX Security 2022)
+
+const (
+	minChangePositionSamples = 5   // Change outputs needed before the cluster is judged
+	randomizedChangeEntropy  = 0.8 // Normalized entropy at or above → randomized
+	fixedChangeEntropy       = 0.2 // Normalized entropy at or below → fixed position
+	randomizedChangeBonus    = 10  // Privacy score bonus for randomized change
+	fixedChangePenalty       = 5   // Privacy score penalty for a fixed change position
+	privacyWalletFamily      = "privacy_wallet"
+)
+
+// changePositionCounts is the change-position history of one cluster
+type changePositionCounts struct {
+	first, middle, last int
+	multiOutput         bool // Some tx had ≥3 outputs, so "middle" was possible
+}
+
+// ChangePositionSignal summarizes a cluster's change-position history
+type ChangePositionSignal struct {
+	Samples      int     `json:"samples"`      // Change outputs observed in the cluster
+	Entropy      float64 `json:"entropy"`      // Shannon entropy of positions, normalized to [0,1]
+	FirstShare   float64 `json:"firstShare"`   // Fraction of change outputs at index 0
+	LastShare    float64 `json:"lastShare"`    // Fraction of change outputs at the last index
+	IsRandomized bool    `json:"isRandomized"` // Position varies like a privacy wallet's
+	IsFixed      bool    `json:"isFixed"`      // Position never varies (change heuristic reliable)
+}
+
+// RecordChangePosition adds one transaction's change output to the
+// history of the cluster containing addr (normally the spender's input)
+func (ce *ClusterEngine) RecordChangePosition(addr string, changeIndex, numOutputs int) {
+	if addr == "" || numOutputs < 2 || changeIndex < 0 || changeIndex >= numOutputs {
+		return
+	}
+
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	if ce.changePos == nil {
+		ce.changePos = make(map[string]*changePositionCounts)
+	}
+	root := ce.find(addr)
+	counts := ce.changePos[root]
+	if counts == nil {
+		counts = &changePositionCounts{}
+		ce.changePos[root] = counts
+	}
+
+	switch changeIndex {
+	case 0:
+		counts.first++
+	case numOutputs - 1:
+		counts.last++
+	default:
+		counts.middle++
+	}
+	if numOutputs >= 3 {
+		counts.multiOutput = true
+	}
+}
+
+// ChangePositionEntropy returns the change-position signal of the cluster
+// containing addr
+func (ce *ClusterEngine) ChangePositionEntropy(addr string) ChangePositionSignal {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	if _, exists := ce.parent[addr]; !exists {
+		return ChangePositionSignal{}
+	}
+	counts := ce.changePos[ce.find(addr)]
+	if counts == nil {
+		return ChangePositionSignal{}
+	}
+	return counts.signal()
+}
+
+// mergeChangePositions folds an absorbed root's history into its new root.
+// Caller holds ce.mu.
+func (ce *ClusterEngine) mergeChangePositions(absorbed, root string) {
+	from := ce.changePos[absorbed]
+	if from == nil {
+		return
+	}
+	delete(ce.changePos, absorbed)
+
+	into := ce.changePos[root]
+	if into == nil {
+		ce.changePos[root] = from
+		return
+	}
+	into.first += from.first
+	into.middle += from.middle
+	into.last += from.last
+	into.multiOutput = into.multiOutput || from.multiOutput
+}
+
+func (c *changePositionCounts) signal() ChangePositionSignal {
+	total := c.first + c.middle + c.last
+	sig := ChangePositionSignal{Samples: total}
+	if total == 0 {
+		return sig
+	}
+	sig.FirstShare = float64(c.first) / float64(total)
+	sig.LastShare = float64(c.last) / float64(total)
+
+	// Normalize by the positions that were possible: first/last only when
+	// every tx had two outputs, first/middle/last otherwise
+	positions := 2.0
+	if c.multiOutput {
+		positions = 3.0
+	}
+	entropy := 0.0
+	for _, n := range []int{c.first, c.middle, c.last} {
+		if n > 0 {
+			p := float64(n) / float64(total)
+			entropy -= p * math.Log2(p)
+		}
+	}
+	sig.Entropy = math.Min(1, entropy/math.Log2(positions))
+
+	if total >= minChangePositionSamples {
+		sig.IsRandomized = sig.Entropy >= randomizedChangeEntropy
+		sig.IsFixed = sig.Entropy <= fixedChangeEntropy
+	}
+	return sig
+}
+
+// ApplyChangePositionSignal feeds a cluster's change-position signal into
+// a transaction's result: randomization raises the privacy score, sets
+// FlagRandomizedChange and attributes an unattributed tx to a privacy
+// wallet; a fixed position lowers the score.
+func ApplyChangePositionSignal(res *models.PrivacyAnalysisResult, sig ChangePositionSignal) {
+	switch {
+	case sig.IsRandomized:
+		res.HeuristicFlags |= FlagRandomizedChange
+		res.PrivacyScore = min(100, res.PrivacyScore+randomizedChangeBonus)
+		if res.WalletFamily == "" || res.WalletFamily == "unknown" {
+			res.WalletFamily = privacyWalletFamily
+		}
+	case sig.IsFixed:
+		res.PrivacyScore = max(0, res.PrivacyScore-fixedChangePenalty)
+	}
+}
@@ -0,0 +1,74 @@
+package heuristics
+
+import (
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func TestChangePositionEntropy_RandomizedVsFixed(t *testing.T) {
+	ce := NewClusterEngine()
+
+	// Privacy wallet: change index drawn at random across 2- and 3-output txs
+	for _, pos := range [][2]int{{0, 2}, {1, 2}, {1, 3}, {0, 3}, {2, 3}, {0, 2}, {1, 3}, {2, 3}} {
+		ce.RecordChangePosition("bc1qprivacy", pos[0], pos[1])
+	}
+	random := ce.ChangePositionEntropy("bc1qprivacy")
+	if !random.IsRandomized || random.IsFixed || random.Samples != 8 {
+		t.Errorf("Expected randomized change over 8 samples. Got %+v", random)
+	}
+
+	// Naive wallet: change always appended last
+	for i := 0; i < 8; i++ {
+		ce.RecordChangePosition("bc1qnaive", 1+i%2, 2+i%2)
+	}
+	fixed := ce.ChangePositionEntropy("bc1qnaive")
+	if !fixed.IsFixed || fixed.IsRandomized || fixed.Entropy != 0 || fixed.LastShare != 1 {
+		t.Errorf("Expected a fixed last position. Got %+v", fixed)
+	}
+
+	// Too few samples to judge either way
+	ce.RecordChangePosition("bc1qnew", 0, 2)
+	ce.RecordChangePosition("bc1qnew", 1, 2)
+	if sig := ce.ChangePositionEntropy("bc1qnew"); sig.IsRandomized || sig.IsFixed {
+		t.Errorf("Expected no verdict from 2 samples. Got %+v", sig)
+	}
+	if sig := ce.ChangePositionEntropy("bc1qunseen"); sig.Samples != 0 {
+		t.Errorf("Expected no history for an unseen address. Got %+v", sig)
+	}
+}
+
+func TestChangePositionEntropy_MergesOnUnion(t *testing.T) {
+	ce := NewClusterEngine()
+	for i := 0; i < 3; i++ {
+		ce.RecordChangePosition("A", 0, 2)
+		ce.RecordChangePosition("B", 1, 2)
+	}
+
+	// Each half is fixed on its own; together they alternate
+	ce.Union("A", "B")
+	sig := ce.ChangePositionEntropy("B")
+	if sig.Samples != 6 || !sig.IsRandomized {
+		t.Errorf("Expected the merged cluster to carry 6 randomized samples. Got %+v", sig)
+	}
+}
+
+func TestApplyChangePositionSignal(t *testing.T) {
+	res := models.PrivacyAnalysisResult{PrivacyScore: 50, WalletFamily: "unknown"}
+	ApplyChangePositionSignal(&res, ChangePositionSignal{IsRandomized: true})
+	if res.PrivacyScore != 60 || res.WalletFamily != privacyWalletFamily || res.HeuristicFlags&FlagRandomizedChange == 0 {
+		t.Errorf("Expected a privacy bonus and attribution. Got score %d, family %q", res.PrivacyScore, res.WalletFamily)
+	}
+
+	core := models.PrivacyAnalysisResult{PrivacyScore: 50, WalletFamily: "bitcoin_core"}
+	ApplyChangePositionSignal(&core, ChangePositionSignal{IsRandomized: true})
+	if core.WalletFamily != "bitcoin_core" {
+		t.Errorf("Expected a per-tx attribution to be kept. Got %q", core.WalletFamily)
+	}
+
+	naive := models.PrivacyAnalysisResult{PrivacyScore: 50}
+	ApplyChangePositionSignal(&naive, ChangePositionSignal{IsFixed: true})
+	if naive.PrivacyScore != 45 || naive.HeuristicFlags&FlagRandomizedChange != 0 {
+		t.Errorf("Expected a fixed-position penalty. Got score %d", naive.PrivacyScore)
+	}
+}
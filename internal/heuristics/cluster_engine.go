@@ -1,6 +1,8 @@
 package heuristics
 
 import (
+	"sync"
+
 	"github.com/rawblock/coinjoin-engine/pkg/models"
 )
 
@@ -18,7 +20,19 @@ import (
 // Critical gating:
 //   - NEVER merge across CoinJoin boundaries
 //   - NEVER merge when PayJoin is suspected
-//   - Discount change-based merges by confidence
+//   - Discount change-based merges by confidence: change edges need
+//     ChangeMergePolicy.MinLLR, and in soft mode they are recorded as
+//     SoftLinks instead of merged, so clusters stay CIOH-only ("hard")
+//     and an investigator decides whether to follow change links
+//
+// Concurrency: every method takes the engine mutex (Find mutates via
+// path compression), so one engine can be fed by the block scanner while
+// the API reads it.
+//
+// Persistence: with change tracking enabled the engine records which
+// addresses joined a multi-address cluster and which roots were absorbed
+// by union-by-rank since the last DrainChanges, so a flusher can upsert
+// only those rows and re-point the absorbed roots' members in bulk.
 //
 // References:
 //   - Meiklejohn et al., "A Fistful of Bitcoins" (IMC 2013) — defined CIOH
@@ -27,9 +41,43 @@ import (
 
 // ClusterEngine implements weighted Union-Find for address clustering
 type ClusterEngine struct {
+	mu     sync.Mutex
 	parent map[string]string // parent[addr] = parent address
 	rank   map[string]int    // rank for union by rank
 	size   map[string]int    // cluster size at root
+
+	tracking bool            // Record changes for DrainChanges
+	dirty    map[string]bool // Addresses whose membership row must be upserted
+	rerooted map[string]bool // Former roots absorbed by a union since the last drain
+
+	hints []UnmergeHint // Recent merges of two large clusters (see cluster_health.go)
+
+	changePos map[string]*changePositionCounts // Change-position history at root (see change_position_entropy.go)
+
+	changePolicy ChangeMergePolicy
+	softLinks    map[string][]SoftLink // Soft change links by endpoint address
+	softCount    int
+}
+
+// DefaultChangeMergeLLR is the change-edge LLR required to merge (or
+// record a soft link) when no policy is set
+const DefaultChangeMergeLLR = 1.5
+
+// softLinkLimit bounds recorded soft links before the store resets
+const softLinkLimit = 1_000_000
+
+// ChangeMergePolicy controls how change edges feed the Union-Find
+type ChangeMergePolicy struct {
+	MinLLR float64 // Change edges below this LLR are ignored
+	Soft   bool    // Record qualifying change edges as SoftLinks instead of merging
+}
+
+// SoftLink is a change-based ownership link recorded but not merged
+type SoftLink struct {
+	Address1 string  `json:"address1"`
+	Address2 string  `json:"address2"`
+	EdgeID   string  `json:"edgeId,omitempty"`
+	LLRScore float64 `json:"llrScore"`
 }
 
 // NewClusterEngine creates a new clustering engine
@@ -38,12 +86,57 @@ func NewClusterEngine() *ClusterEngine {
 		parent: make(map[string]string),
 		rank:   make(map[string]int),
 		size:   make(map[string]int),
+
+		changePolicy: ChangeMergePolicy{MinLLR: DefaultChangeMergeLLR},
+	}
+}
+
+// SetChangeMergePolicy sets the LLR threshold for change-based merges
+// and whether they are hard (merged) or soft (recorded as SoftLinks).
+// Applies to edges merged after the call.
+func (ce *ClusterEngine) SetChangeMergePolicy(policy ChangeMergePolicy) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	ce.changePolicy = policy
+}
+
+// ChangeMergePolicy returns the current change-merge policy
+func (ce *ClusterEngine) ChangeMergePolicy() ChangeMergePolicy {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	return ce.changePolicy
+}
+
+// EnableChangeTracking makes the engine record membership changes for
+// DrainChanges. Leave it off when nothing drains, or the change set grows
+// without bound.
+func (ce *ClusterEngine) EnableChangeTracking() {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	if !ce.tracking {
+		ce.tracking = true
+		ce.dirty = make(map[string]bool)
+		ce.rerooted = make(map[string]bool)
 	}
 }
 
 // Find returns the root representative of the cluster containing addr.
 // Uses path compression for amortized O(1) performance.
 func (ce *ClusterEngine) Find(addr string) string {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	return ce.find(addr)
+}
+
+// Contains reports whether addr has been seen, without registering it
+func (ce *ClusterEngine) Contains(addr string) bool {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	_, exists := ce.parent[addr]
+	return exists
+}
+
+func (ce *ClusterEngine) find(addr string) string {
 	if _, exists := ce.parent[addr]; !exists {
 		ce.parent[addr] = addr
 		ce.rank[addr] = 0
@@ -52,7 +145,7 @@ func (ce *ClusterEngine) Find(addr string) string {
 
 	// Path compression: make every node point directly to root
 	if ce.parent[addr] != addr {
-		ce.parent[addr] = ce.Find(ce.parent[addr])
+		ce.parent[addr] = ce.find(ce.parent[addr])
 	}
 	return ce.parent[addr]
 }
@@ -61,17 +154,30 @@ func (ce *ClusterEngine) Find(addr string) string {
 // Uses union by rank to keep tree balanced.
 // Returns true if a merge actually occurred (they were in different clusters).
 func (ce *ClusterEngine) Union(addr1, addr2 string) bool {
-	root1 := ce.Find(addr1)
-	root2 := ce.Find(addr2)
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	return ce.union(addr1, addr2)
+}
+
+func (ce *ClusterEngine) union(addr1, addr2 string) bool {
+	root1 := ce.find(addr1)
+	root2 := ce.find(addr2)
 
 	if root1 == root2 {
 		return false // Already in the same cluster
 	}
 
+	if ce.tracking {
+		ce.dirty[addr1], ce.dirty[addr2] = true, true
+		ce.dirty[root1], ce.dirty[root2] = true, true
+	}
+
 	// Union by rank: attach smaller tree under root of larger tree
+	absorbed := root2
 	if ce.rank[root1] < ce.rank[root2] {
 		ce.parent[root1] = root2
 		ce.size[root2] += ce.size[root1]
+		absorbed = root1
 	} else if ce.rank[root1] > ce.rank[root2] {
 		ce.parent[root2] = root1
 		ce.size[root1] += ce.size[root2]
@@ -80,6 +186,10 @@ func (ce *ClusterEngine) Union(addr1, addr2 string) bool {
 		ce.size[root1] += ce.size[root2]
 		ce.rank[root1]++
 	}
+	if ce.tracking {
+		ce.rerooted[absorbed] = true
+	}
+	ce.mergeChangePositions(absorbed, ce.parent[absorbed])
 
 	return true
 }
@@ -88,6 +198,9 @@ func (ce *ClusterEngine) Union(addr1, addr2 string) bool {
 // Only CIOH and Change edges trigger merges. CoinJoin-gated edges
 // and PayJoin suspects are explicitly excluded.
 func (ce *ClusterEngine) MergeFromEdges(edges []models.EvidenceEdge) int {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
 	mergeCount := 0
 
 	for _, edge := range edges {
@@ -95,16 +208,32 @@ func (ce *ClusterEngine) MergeFromEdges(edges []models.EvidenceEdge) int {
 		switch edge.EdgeType {
 		case EdgeTypeCIOH:
 			// Standard CIOH: merge unconditionally
-			if ce.Union(edge.SrcNodeID, edge.DstNodeID) {
+			if len(edge.Members) > 0 {
+				// Consolidation hyper-edge: the destination is a synthetic
+				// "consolidation:<txid>" node, the inputs are the members
+				for _, member := range edge.Members {
+					if ce.unionTraced(edge.SrcNodeID, member, edgeCause(edge)) {
+						mergeCount++
+					}
+				}
+				continue
+			}
+			if ce.unionTraced(edge.SrcNodeID, edge.DstNodeID, edgeCause(edge)) {
 				mergeCount++
 			}
 
 		case EdgeTypeChange:
-			// Change detection: merge if LLR is sufficiently high
-			if edge.LLRScore >= 1.5 {
-				if ce.Union(edge.SrcNodeID, edge.DstNodeID) {
-					mergeCount++
-				}
+			// Change detection: merge if LLR clears the policy threshold,
+			// or only record the link in soft mode
+			if edge.LLRScore < ce.changePolicy.MinLLR {
+				continue
+			}
+			if ce.changePolicy.Soft {
+				ce.recordSoftLink(edge)
+				continue
+			}
+			if ce.unionTraced(edge.SrcNodeID, edge.DstNodeID, edgeCause(edge)) {
+				mergeCount++
 			}
 
 		case EdgeTypeCIOHInvalidated, EdgeTypeCoinjoinSuspected, EdgeTypePayJoinSuspect:
@@ -114,7 +243,7 @@ func (ce *ClusterEngine) MergeFromEdges(edges []models.EvidenceEdge) int {
 		case EdgeTypePeelChain:
 			// Peel chains: merge with high confidence
 			if edge.LLRScore >= 2.0 {
-				if ce.Union(edge.SrcNodeID, edge.DstNodeID) {
+				if ce.unionTraced(edge.SrcNodeID, edge.DstNodeID, edgeCause(edge)) {
 					mergeCount++
 				}
 			}
@@ -122,7 +251,7 @@ func (ce *ClusterEngine) MergeFromEdges(edges []models.EvidenceEdge) int {
 		default:
 			// Other edge types: require very strong evidence
 			if edge.LLRScore >= 3.0 {
-				if ce.Union(edge.SrcNodeID, edge.DstNodeID) {
+				if ce.unionTraced(edge.SrcNodeID, edge.DstNodeID, edgeCause(edge)) {
 					mergeCount++
 				}
 			}
@@ -132,6 +261,53 @@ func (ce *ClusterEngine) MergeFromEdges(edges []models.EvidenceEdge) int {
 	return mergeCount
 }
 
+// recordSoftLink stores a change link under both endpoints. Caller holds ce.mu.
+func (ce *ClusterEngine) recordSoftLink(edge models.EvidenceEdge) {
+	if edge.SrcNodeID == "" || edge.DstNodeID == "" || edge.SrcNodeID == edge.DstNodeID {
+		return
+	}
+	if ce.softLinks == nil || ce.softCount >= softLinkLimit {
+		ce.softLinks = make(map[string][]SoftLink)
+		ce.softCount = 0
+	}
+	link := SoftLink{Address1: edge.SrcNodeID, Address2: edge.DstNodeID, EdgeID: edge.EdgeID, LLRScore: edge.LLRScore}
+	ce.softLinks[link.Address1] = append(ce.softLinks[link.Address1], link)
+	ce.softLinks[link.Address2] = append(ce.softLinks[link.Address2], link)
+	ce.softCount++
+}
+
+// SoftLinks returns the soft change links touching any member of addr's
+// (hard) cluster
+func (ce *ClusterEngine) SoftLinks(addr string) []SoftLink {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	if len(ce.softLinks) == 0 {
+		return nil
+	}
+	root := addr
+	if _, exists := ce.parent[addr]; exists {
+		root = ce.find(addr)
+	}
+
+	seen := make(map[SoftLink]bool)
+	var links []SoftLink
+	for endpoint, endpointLinks := range ce.softLinks {
+		if endpoint != addr {
+			if _, exists := ce.parent[endpoint]; !exists || ce.find(endpoint) != root {
+				continue
+			}
+		}
+		for _, link := range endpointLinks {
+			if !seen[link] {
+				seen[link] = true
+				links = append(links, link)
+			}
+		}
+	}
+	return links
+}
+
 // MergeFromTransaction applies CIOH to a single transaction.
 // All inputs of a non-CoinJoin transaction are merged.
 func (ce *ClusterEngine) MergeFromTransaction(tx models.Transaction, isCoinJoin bool) int {
@@ -139,12 +315,15 @@ func (ce *ClusterEngine) MergeFromTransaction(tx models.Transaction, isCoinJoin
 		return 0
 	}
 
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
 	mergeCount := 0
 	firstAddr := tx.Inputs[0].Address
 
 	for i := 1; i < len(tx.Inputs); i++ {
 		if tx.Inputs[i].Address != "" && tx.Inputs[i].Address != firstAddr {
-			if ce.Union(firstAddr, tx.Inputs[i].Address) {
+			if ce.unionTraced(firstAddr, tx.Inputs[i].Address, UnmergeHint{EdgeType: EdgeTypeCIOH, Txid: tx.Txid}) {
 				mergeCount++
 			}
 		}
@@ -155,11 +334,17 @@ func (ce *ClusterEngine) MergeFromTransaction(tx models.Transaction, isCoinJoin
 
 // GetCluster returns all addresses in the same cluster as addr
 func (ce *ClusterEngine) GetCluster(addr string) []string {
-	root := ce.Find(addr)
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	return ce.cluster(addr)
+}
+
+func (ce *ClusterEngine) cluster(addr string) []string {
+	root := ce.find(addr)
 	var cluster []string
 
 	for a := range ce.parent {
-		if ce.Find(a) == root {
+		if ce.find(a) == root {
 			cluster = append(cluster, a)
 		}
 	}
@@ -168,7 +353,9 @@ func (ce *ClusterEngine) GetCluster(addr string) []string {
 
 // GetClusterSize returns the number of addresses in the cluster
 func (ce *ClusterEngine) GetClusterSize(addr string) int {
-	root := ce.Find(addr)
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	root := ce.find(addr)
 	return ce.size[root]
 }
 
@@ -182,23 +369,100 @@ type ClusterStats struct {
 
 // GetStats returns statistics for the cluster containing addr
 func (ce *ClusterEngine) GetStats(addr string) ClusterStats {
-	cluster := ce.GetCluster(addr)
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	cluster := ce.cluster(addr)
 	return ClusterStats{
-		RootAddress:  ce.Find(addr),
+		RootAddress:  ce.find(addr),
 		AddressCount: len(cluster),
 	}
 }
 
 // TotalClusters returns the number of distinct clusters
 func (ce *ClusterEngine) TotalClusters() int {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
 	roots := make(map[string]bool)
 	for addr := range ce.parent {
-		roots[ce.Find(addr)] = true
+		roots[ce.find(addr)] = true
 	}
 	return len(roots)
 }
 
 // TotalAddresses returns the number of tracked addresses
 func (ce *ClusterEngine) TotalAddresses() int {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
 	return len(ce.parent)
 }
+
+// DrainChanges returns the membership rows changed since the last drain,
+// resolved to their current roots, and a map from each absorbed former
+// root to its current root (rows stored under the old root must be
+// re-pointed). The change set is cleared.
+func (ce *ClusterEngine) DrainChanges() ([]models.ClusterMembership, map[string]string) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	if !ce.tracking {
+		return nil, nil
+	}
+
+	members := make([]models.ClusterMembership, 0, len(ce.dirty))
+	for addr := range ce.dirty {
+		members = append(members, models.ClusterMembership{Address: addr, RootAddress: ce.find(addr)})
+	}
+	reroots := make(map[string]string, len(ce.rerooted))
+	for old := range ce.rerooted {
+		reroots[old] = ce.find(old)
+	}
+	ce.dirty = make(map[string]bool)
+	ce.rerooted = make(map[string]bool)
+	return members, reroots
+}
+
+// RequeueChanges puts a drained change set back after a failed flush
+func (ce *ClusterEngine) RequeueChanges(members []models.ClusterMembership, reroots map[string]string) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	if !ce.tracking {
+		return
+	}
+	for _, m := range members {
+		ce.dirty[m.Address] = true
+	}
+	for old := range reroots {
+		ce.rerooted[old] = true
+	}
+}
+
+// Restore loads persisted memberships (e.g. on boot) and returns how many
+// addresses were added. Each stored root becomes a depth-1 tree, so later
+// unions continue from the persisted clusters.
+func (ce *ClusterEngine) Restore(members []models.ClusterMembership) int {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	before := len(ce.parent)
+	for _, m := range members {
+		if m.Address == "" || m.RootAddress == "" {
+			continue
+		}
+		if _, exists := ce.parent[m.Address]; exists {
+			// Already known in memory: merge rather than overwrite
+			ce.union(m.Address, m.RootAddress)
+			continue
+		}
+		root := ce.find(m.RootAddress)
+		if m.Address == root {
+			continue
+		}
+		ce.parent[m.Address] = root
+		ce.rank[m.Address] = 0
+		ce.size[m.Address] = 1
+		ce.size[root]++
+		if ce.rank[root] == 0 {
+			ce.rank[root] = 1
+		}
+	}
+	return len(ce.parent) - before
+}
@@ -0,0 +1,157 @@
+package heuristics
+
+import (
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// applyClusterChanges mimics PostgresStore.SaveClusterChanges on a map:
+// re-point absorbed roots first, then upsert the changed rows.
+func applyClusterChanges(table map[string]string, members []models.ClusterMembership, reroots map[string]string) {
+	for oldRoot, newRoot := range reroots {
+		for addr, root := range table {
+			if root == oldRoot {
+				table[addr] = newRoot
+			}
+		}
+	}
+	for _, m := range members {
+		table[m.Address] = m.RootAddress
+	}
+}
+
+func restoredEngine(table map[string]string) *ClusterEngine {
+	rows := make([]models.ClusterMembership, 0, len(table))
+	for addr, root := range table {
+		rows = append(rows, models.ClusterMembership{Address: addr, RootAddress: root})
+	}
+	ce := NewClusterEngine()
+	ce.Restore(rows)
+	return ce
+}
+
+func TestClusterEngine_PersistRoundTrip(t *testing.T) {
+	table := make(map[string]string)
+
+	ce := NewClusterEngine()
+	ce.EnableChangeTracking()
+	ce.Union("a1", "a2")
+	ce.Union("a1", "a3")
+	ce.Union("b1", "b2")
+	members, reroots := ce.DrainChanges()
+	applyClusterChanges(table, members, reroots)
+
+	if len(table) != 5 {
+		t.Fatalf("Expected 5 persisted memberships. Got %v", table)
+	}
+
+	// Second flush: merging the clusters absorbs one root; rows flushed
+	// earlier under it must be re-pointed without being re-sent
+	ce.Union("a3", "b1") // b2 is only reachable through the reroot
+	members, reroots = ce.DrainChanges()
+	if len(reroots) != 1 {
+		t.Fatalf("Expected one absorbed root. Got %v", reroots)
+	}
+	applyClusterChanges(table, members, reroots)
+
+	root := ce.Find("a1")
+	for addr, stored := range table {
+		if stored != root {
+			t.Errorf("%s persisted under %s, expected current root %s", addr, stored, root)
+		}
+	}
+
+	// "Restart": a fresh engine loaded from the table sees one cluster
+	restored := restoredEngine(table)
+	if size := restored.GetClusterSize("b1"); size != 5 {
+		t.Errorf("Expected the restored cluster to hold 5 addresses. Got %d", size)
+	}
+	if restored.Find("a3") != restored.Find("b1") {
+		t.Errorf("Expected a3 and b1 in the same restored cluster")
+	}
+
+	// Unions after the restart continue from the persisted clusters
+	restored.Union("c1", "a3")
+	if size := restored.GetClusterSize("c1"); size != 6 {
+		t.Errorf("Expected c1 to join the restored cluster. Got size %d", size)
+	}
+}
+
+func TestClusterEngine_ChangeTrackingOptIn(t *testing.T) {
+	ce := NewClusterEngine()
+	ce.Union("x1", "x2")
+	if members, reroots := ce.DrainChanges(); members != nil || reroots != nil {
+		t.Errorf("Untracked engine must not accumulate changes. Got %v %v", members, reroots)
+	}
+
+	ce.EnableChangeTracking()
+	ce.Union("x2", "x3")
+	members, reroots := ce.DrainChanges()
+	if len(members) == 0 {
+		t.Fatalf("Expected tracked changes after a union")
+	}
+	ce.RequeueChanges(members, reroots)
+	if again, _ := ce.DrainChanges(); len(again) != len(members) {
+		t.Errorf("Expected requeued rows to drain again. Got %d, want %d", len(again), len(members))
+	}
+	if empty, _ := ce.DrainChanges(); len(empty) != 0 {
+		t.Errorf("Expected an empty change set after draining. Got %v", empty)
+	}
+}
+
+func changeEdge(src, dst string, llr float64) models.EvidenceEdge {
+	return models.EvidenceEdge{EdgeID: src + "->" + dst, SrcNodeID: src, DstNodeID: dst, EdgeType: EdgeTypeChange, LLRScore: llr}
+}
+
+func TestClusterEngine_ChangeMergeThreshold(t *testing.T) {
+	ce := NewClusterEngine()
+	ce.SetChangeMergePolicy(ChangeMergePolicy{MinLLR: 2.5})
+
+	edges := []models.EvidenceEdge{
+		changeEdge("in1", "weak", 2.0),   // default 1.5 would merge this
+		changeEdge("in1", "strong", 3.0), // clears the stricter threshold
+		{SrcNodeID: "in1", DstNodeID: "cioh", EdgeType: EdgeTypeCIOH, LLRScore: 0.5},
+	}
+	if n := ce.MergeFromEdges(edges); n != 2 {
+		t.Errorf("Expected 2 merges (strong change + CIOH). Got %d", n)
+	}
+	if ce.Find("weak") == ce.Find("in1") {
+		t.Error("Expected a change edge below MinLLR not to merge")
+	}
+	if ce.Find("strong") != ce.Find("in1") {
+		t.Error("Expected a change edge above MinLLR to merge")
+	}
+}
+
+func TestClusterEngine_SoftChangeMerges(t *testing.T) {
+	ce := NewClusterEngine()
+	ce.SetChangeMergePolicy(ChangeMergePolicy{MinLLR: DefaultChangeMergeLLR, Soft: true})
+
+	edges := []models.EvidenceEdge{
+		{SrcNodeID: "in1", DstNodeID: "in2", EdgeType: EdgeTypeCIOH, LLRScore: 2.0},
+		changeEdge("in2", "change", 2.0),
+		changeEdge("in1", "noise", 1.0), // below threshold: not even linked
+	}
+	if n := ce.MergeFromEdges(edges); n != 1 {
+		t.Errorf("Expected only the CIOH edge to merge in soft mode. Got %d merges", n)
+	}
+	if ce.GetClusterSize("in1") != 2 || ce.Find("change") == ce.Find("in1") {
+		t.Error("Expected the hard cluster to exclude the change address")
+	}
+
+	links := ce.SoftLinks("in1")
+	if len(links) != 1 || links[0].Address2 != "change" || links[0].LLRScore != 2.0 {
+		t.Errorf("Expected one soft link to the change address via the cluster. Got %+v", links)
+	}
+	if links := ce.SoftLinks("change"); len(links) != 1 {
+		t.Errorf("Expected the change address to see its soft link. Got %+v", links)
+	}
+
+	// Switching to hard mode merges subsequent change edges
+	ce.SetChangeMergePolicy(ChangeMergePolicy{MinLLR: DefaultChangeMergeLLR})
+	ce.MergeFromEdges([]models.EvidenceEdge{changeEdge("in2", "change", 2.0)})
+	if ce.Find("change") != ce.Find("in1") {
+		t.Error("Expected a hard-mode change edge to merge")
+	}
+}
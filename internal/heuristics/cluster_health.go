@@ -0,0 +1,235 @@
+package heuristics
+
+import (
+	"math"
+	"sort"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Cluster Quality Metrics & Over-Merge Detection
+//
+// Union-Find never forgets: one false CIOH merge — typically through a
+// CoinJoin the detectors missed — fuses two entities for good, and the
+// next bad merge chains a third. The damage shows up in the size
+// distribution long before anyone inspects individual clusters:
+//
+//   - Super-clusters: entities above a plausibility threshold
+//   - Gini coefficient of cluster sizes: → 1 when one cluster swallows
+//     the address space
+//   - Normalized Shannon entropy of address mass over clusters: → 0 for
+//     the same collapse
+//   - Unmerge hints: each union of two already-large clusters is
+//     recorded with the edge that caused it, so an analyst can find
+//     (and discount) the link that made the jump
+//
+// References:
+//   - Harrigan & Fretter, "The Unreasonable Effectiveness of Address
+//     Clustering" (2016) — giant-cluster formation
+//   - Kappos et al., "How to Peel a Million" (USENIX Security 2022) —
+//     false-positive merges and cluster auditing
+//   - Möser & Narayanan, "Resurrecting Address Clustering" (2022)
+
+const (
+	defaultSuperClusterSize = 10_000 // Addresses above which an entity is flagged
+	unmergeHintMinSide      = 50     // Both sides at least this large to record a hint
+	unmergeHintLimit        = 256    // Hints retained (most recent)
+)
+
+// UnmergeHint records a union that fused two large clusters
+type UnmergeHint struct {
+	Address1    string  `json:"address1"`
+	Address2    string  `json:"address2"`
+	EdgeID      string  `json:"edgeId,omitempty"` // Evidence edge that caused the merge
+	EdgeType    int     `json:"edgeType"`
+	LLRScore    float64 `json:"llrScore,omitempty"`
+	Txid        string  `json:"txid,omitempty"` // Set for MergeFromTransaction merges
+	SmallerSize int     `json:"smallerSize"`
+	LargerSize  int     `json:"largerSize"`
+	MergedSize  int     `json:"mergedSize"`
+}
+
+// SuperCluster is a cluster above the plausibility threshold
+type SuperCluster struct {
+	RootAddress string        `json:"rootAddress"`
+	Size        int           `json:"size"`
+	Hints       []UnmergeHint `json:"hints"` // Large merges that built this cluster, newest first
+}
+
+// ClusterHealth summarizes the cluster size distribution
+type ClusterHealth struct {
+	TotalAddresses    int            `json:"totalAddresses"`
+	TotalClusters     int            `json:"totalClusters"`
+	LargestRoot       string         `json:"largestRoot"`
+	LargestSize       int            `json:"largestSize"`
+	LargestShare      float64        `json:"largestShare"`      // Fraction of addresses in the largest cluster
+	SizeGini          float64        `json:"sizeGini"`          // 0 = equal sizes, → 1 = one cluster holds everything
+	NormalizedEntropy float64        `json:"normalizedEntropy"` // Address mass entropy / log2(addresses), 0-1
+	SizeHistogram     map[string]int `json:"sizeHistogram"`     // Clusters per power-of-ten size bucket
+	SuperThreshold    int            `json:"superThreshold"`
+	SuperClusters     []SuperCluster `json:"superClusters"`
+}
+
+// edgeCause builds the hint template for an evidence-edge merge
+func edgeCause(edge models.EvidenceEdge) UnmergeHint {
+	return UnmergeHint{EdgeID: edge.EdgeID, EdgeType: edge.EdgeType, LLRScore: edge.LLRScore}
+}
+
+// unionTraced is union that records an UnmergeHint when both sides are
+// already large clusters
+func (ce *ClusterEngine) unionTraced(addr1, addr2 string, cause UnmergeHint) bool {
+	size1 := ce.size[ce.find(addr1)]
+	size2 := ce.size[ce.find(addr2)]
+	if !ce.union(addr1, addr2) {
+		return false
+	}
+	smaller, larger := size1, size2
+	if smaller > larger {
+		smaller, larger = larger, smaller
+	}
+	if smaller >= unmergeHintMinSide {
+		cause.Address1, cause.Address2 = addr1, addr2
+		cause.SmallerSize, cause.LargerSize, cause.MergedSize = smaller, larger, size1+size2
+		ce.hints = append(ce.hints, cause)
+		if over := len(ce.hints) - unmergeHintLimit; over > 0 {
+			ce.hints = ce.hints[over:]
+		}
+	}
+	return true
+}
+
+// UnmergeHints returns the recorded large merges, oldest first
+func (ce *ClusterEngine) UnmergeHints() []UnmergeHint {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	return append([]UnmergeHint(nil), ce.hints...)
+}
+
+// DetectSuperClusters returns clusters larger than threshold, largest
+// first, each with the unmerge hints that now resolve into it
+func (ce *ClusterEngine) DetectSuperClusters(threshold int) []SuperCluster {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+	return ce.superClusters(ce.rootSizes(), threshold)
+}
+
+// Health reports the size distribution and the clusters above threshold
+// (defaultSuperClusterSize when threshold ≤ 0)
+func (ce *ClusterEngine) Health(threshold int) ClusterHealth {
+	if threshold <= 0 {
+		threshold = defaultSuperClusterSize
+	}
+
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	sizes := ce.rootSizes()
+	h := ClusterHealth{
+		TotalAddresses: len(ce.parent),
+		TotalClusters:  len(sizes),
+		SizeHistogram:  make(map[string]int),
+		SuperThreshold: threshold,
+		SuperClusters:  ce.superClusters(sizes, threshold),
+	}
+	if h.TotalAddresses == 0 {
+		return h
+	}
+
+	values := make([]int, 0, len(sizes))
+	for root, size := range sizes {
+		values = append(values, size)
+		if size > h.LargestSize || (size == h.LargestSize && root < h.LargestRoot) {
+			h.LargestRoot, h.LargestSize = root, size
+		}
+		h.SizeHistogram[sizeBucket(size)]++
+	}
+	h.LargestShare = float64(h.LargestSize) / float64(h.TotalAddresses)
+	h.SizeGini = giniCoefficient(values)
+	h.NormalizedEntropy = normalizedMassEntropy(values, h.TotalAddresses)
+	return h
+}
+
+// rootSizes maps every cluster root to its size
+func (ce *ClusterEngine) rootSizes() map[string]int {
+	sizes := make(map[string]int)
+	for addr := range ce.parent {
+		root := ce.find(addr)
+		sizes[root] = ce.size[root]
+	}
+	return sizes
+}
+
+func (ce *ClusterEngine) superClusters(sizes map[string]int, threshold int) []SuperCluster {
+	var supers []SuperCluster
+	index := make(map[string]int)
+	for root, size := range sizes {
+		if size > threshold {
+			index[root] = len(supers)
+			supers = append(supers, SuperCluster{RootAddress: root, Size: size, Hints: []UnmergeHint{}})
+		}
+	}
+	for i := len(ce.hints) - 1; i >= 0; i-- {
+		hint := ce.hints[i]
+		if j, ok := index[ce.find(hint.Address1)]; ok {
+			supers[j].Hints = append(supers[j].Hints, hint)
+		}
+	}
+	sort.Slice(supers, func(a, b int) bool {
+		if supers[a].Size != supers[b].Size {
+			return supers[a].Size > supers[b].Size
+		}
+		return supers[a].RootAddress < supers[b].RootAddress
+	})
+	return supers
+}
+
+// sizeBucket labels a cluster size by power of ten ("1", "2-9", "10-99", ...)
+func sizeBucket(size int) string {
+	switch {
+	case size <= 1:
+		return "1"
+	case size < 10:
+		return "2-9"
+	case size < 100:
+		return "10-99"
+	case size < 1_000:
+		return "100-999"
+	case size < 10_000:
+		return "1000-9999"
+	default:
+		return "10000+"
+	}
+}
+
+// giniCoefficient of a set of positive sizes (0 = all equal)
+func giniCoefficient(values []int) float64 {
+	n := len(values)
+	if n < 2 {
+		return 0
+	}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+	var weighted, total float64
+	for i, v := range sorted {
+		weighted += float64(i+1) * float64(v)
+		total += float64(v)
+	}
+	if total == 0 {
+		return 0
+	}
+	return (2*weighted)/(float64(n)*total) - float64(n+1)/float64(n)
+}
+
+// normalizedMassEntropy is the Shannon entropy of the address → cluster
+// distribution divided by its maximum (every address its own cluster)
+func normalizedMassEntropy(values []int, total int) float64 {
+	if total < 2 {
+		return 1
+	}
+	var entropy float64
+	for _, v := range values {
+		p := float64(v) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy / math.Log2(float64(total))
+}
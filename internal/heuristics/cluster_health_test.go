@@ -0,0 +1,79 @@
+package heuristics
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// chainCluster unions prefix_0..prefix_{n-1} into one cluster
+func chainCluster(ce *ClusterEngine, prefix string, n int) {
+	for i := 1; i < n; i++ {
+		ce.Union(fmt.Sprintf("%s_%d", prefix, i-1), fmt.Sprintf("%s_%d", prefix, i))
+	}
+}
+
+func TestDetectSuperClusters_RecordsOverMergeEdge(t *testing.T) {
+	ce := NewClusterEngine()
+	chainCluster(ce, "exchange", 80)
+	chainCluster(ce, "user", 60)
+	chainCluster(ce, "small", 3)
+
+	// An undetected CoinJoin's CIOH edge fuses two large entities
+	bad := models.EvidenceEdge{EdgeID: "edge-bad", SrcNodeID: "user_0", DstNodeID: "exchange_5", EdgeType: EdgeTypeCIOH, LLRScore: ProbToLLR(0.95)}
+	if ce.MergeFromEdges([]models.EvidenceEdge{bad}) != 1 {
+		t.Fatalf("Expected the edge to merge the clusters")
+	}
+
+	supers := ce.DetectSuperClusters(100)
+	if len(supers) != 1 || supers[0].Size != 140 {
+		t.Fatalf("Expected one 140-address super-cluster. Got %+v", supers)
+	}
+	if len(supers[0].Hints) != 1 {
+		t.Fatalf("Expected one unmerge hint. Got %+v", supers[0].Hints)
+	}
+	hint := supers[0].Hints[0]
+	if hint.EdgeID != "edge-bad" || hint.SmallerSize != 60 || hint.LargerSize != 80 || hint.MergedSize != 140 {
+		t.Errorf("Unexpected hint: %+v", hint)
+	}
+
+	if got := ce.DetectSuperClusters(200); len(got) != 0 {
+		t.Errorf("Expected no clusters above 200. Got %+v", got)
+	}
+}
+
+func TestClusterHealth_Distribution(t *testing.T) {
+	ce := NewClusterEngine()
+	chainCluster(ce, "giant", 90)
+	for i := 0; i < 10; i++ {
+		ce.Find(fmt.Sprintf("single_%d", i))
+	}
+
+	h := ce.Health(50)
+	if h.TotalAddresses != 100 || h.TotalClusters != 11 {
+		t.Fatalf("Expected 100 addresses in 11 clusters. Got %d in %d", h.TotalAddresses, h.TotalClusters)
+	}
+	if h.LargestSize != 90 || math.Abs(h.LargestShare-0.9) > 1e-9 {
+		t.Errorf("Expected the giant cluster to hold 90%%. Got %d (%.2f)", h.LargestSize, h.LargestShare)
+	}
+	if h.SizeHistogram["1"] != 10 || h.SizeHistogram["10-99"] != 1 {
+		t.Errorf("Unexpected histogram: %v", h.SizeHistogram)
+	}
+	if h.SizeGini < 0.7 || h.NormalizedEntropy > 0.3 {
+		t.Errorf("Expected a skewed distribution. Got Gini %.3f entropy %.3f", h.SizeGini, h.NormalizedEntropy)
+	}
+	if len(h.SuperClusters) != 1 || h.SuperClusters[0].RootAddress != h.LargestRoot {
+		t.Errorf("Expected the giant cluster flagged. Got %+v", h.SuperClusters)
+	}
+
+	// Singletons only: perfectly equal sizes
+	flat := NewClusterEngine()
+	for i := 0; i < 10; i++ {
+		flat.Find(fmt.Sprintf("a_%d", i))
+	}
+	if fh := flat.Health(0); fh.SizeGini != 0 || math.Abs(fh.NormalizedEntropy-1) > 1e-9 {
+		t.Errorf("Expected Gini 0 and entropy 1 for singletons. Got %.3f / %.3f", fh.SizeGini, fh.NormalizedEntropy)
+	}
+}
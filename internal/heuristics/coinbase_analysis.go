@@ -15,6 +15,18 @@ import (
 //   - Outputs are pool-specific: Foundry sends to 1 address,
 //     AntPool distributes to multiple
 //
+// Reward split: the subsidy follows the halving schedule (50 BTC halved
+// every 210,000 blocks, zero after 64 halvings); everything the coinbase
+// claims above it is fees. The height comes from the confirming block, or
+// from the BIP34 push for mempool/synthetic input. Coinbase outputs are
+// spendable only CoinbaseMaturity blocks later.
+//
+// Payout structure (counting distinct paid scripts, ignoring the witness
+// commitment and other data/zero-value outputs):
+//   "single_wallet" → one payee, or one taking ≥95% (pool wallet, FPPS)
+//   "split"         → a few payees (pool plus partner/fee addresses)
+//   "distributed"   → many payees paid directly (PPLNS-style, e.g. OCEAN)
+//
 // Pool identification from coinbase markers:
 //   "Foundry USA"  → /Foundry USA Pool/
 //   "/AntPool/"    → AntPool (Bitmain)
@@ -23,22 +35,24 @@ import (
 //   "/SlushPool/"  → Braiins Pool
 //   "/MARA Pool/"  → Marathon Digital
 //
+// These are the built-in fallback; PoolTagDB (pool_tags.go) loads current
+// tags and payout addresses from a known-pools JSON file.
+//
 // References:
 //   - BIP34: Block v2, Height in Coinbase
 //   - Romiti et al., "Cross-Layer Deanonymization in Bitcoin" (USENIX 2021)
 //   - blockchain.com/pools (live pool distribution)
 
-// CoinbaseResult holds mining pool analysis results
-type CoinbaseResult struct {
-	IsCoinbase     bool    `json:"isCoinbase"`
-	PoolName       string  `json:"poolName"`       // Identified mining pool
-	PoolConfidence float64 `json:"poolConfidence"` // 0.0 to 1.0
-	BlockReward    int64   `json:"blockReward"`    // Total block reward (subsidy + fees)
-	OutputCount    int     `json:"outputCount"`    // Distribution pattern
-	PayoutType     string  `json:"payoutType"`     // "single"/"multi"/"pps"/"fpps"
-}
+// Reward schedule and payout thresholds
+const (
+	CoinbaseMaturity        = 100           // Confirmations before coinbase outputs are spendable
+	initialSubsidy          = 5_000_000_000 // 50 BTC
+	halvingInterval         = 210_000
+	singleWalletShare       = 0.95 // Payee share that still counts as one wallet
+	distributedPayeeMinimum = 5    // Payees paid directly by a PPLNS-style pool
+)
 
-// Known mining pool markers (found in coinbase scriptSig)
+// Built-in mining pool markers (found in coinbase scriptSig)
 var poolMarkers = map[string]string{
 	"foundry usa": "Foundry USA",
 	"/foundry/":   "Foundry USA",
@@ -70,8 +84,8 @@ var poolMarkers = map[string]string{
 
 // AnalyzeCoinbaseTx identifies if a transaction is a coinbase and
 // attributes it to a mining pool.
-func AnalyzeCoinbaseTx(tx models.Transaction) CoinbaseResult {
-	result := CoinbaseResult{
+func AnalyzeCoinbaseTx(tx models.Transaction) models.CoinbaseResult {
+	result := models.CoinbaseResult{
 		PayoutType: "unknown",
 	}
 
@@ -87,17 +101,62 @@ func AnalyzeCoinbaseTx(tx models.Transaction) CoinbaseResult {
 		result.BlockReward += out.Value
 	}
 
-	// Identify pool from scriptSig marker
+	// Identify pool from scriptSig tag and payout addresses
 	if len(tx.Inputs) > 0 {
-		result.PoolName, result.PoolConfidence = identifyPool(tx.Inputs[0].ScriptSig)
+		result.PoolName, result.PoolConfidence, result.PoolMatch = GetGlobalPoolTagDB().Identify(tx.Inputs[0].ScriptSig, tx.Outputs)
 	}
 
 	// Classify payout type from output pattern
 	result.PayoutType = classifyPayoutType(tx)
+	result.Payees, result.PayoutStructure = classifyPayoutStructure(tx)
+
+	// Split the reward into subsidy and fees
+	height := tx.BlockHeight
+	if height <= 0 && len(tx.Inputs) > 0 {
+		height = bip34Height(tx.Inputs[0].ScriptSig)
+	}
+	if height > 0 {
+		result.BlockHeight = height
+		result.Subsidy = BlockSubsidy(height)
+		result.Fees = max(0, result.BlockReward-result.Subsidy)
+		result.MaturityHeight = height + CoinbaseMaturity
+	}
 
 	return result
 }
 
+// BlockSubsidy returns the block subsidy in sats at height
+func BlockSubsidy(height int) int64 {
+	halvings := height / halvingInterval
+	if height < 0 || halvings >= 64 {
+		return 0
+	}
+	return initialSubsidy >> halvings
+}
+
+// bip34Height decodes the block height pushed first in a hex coinbase
+// scriptSig (BIP34), returning 0 when it is absent or malformed
+func bip34Height(scriptSig string) int {
+	if len(scriptSig) < 2 {
+		return 0
+	}
+	op := hexByte(scriptSig[0], scriptSig[1])
+	if op >= 0x51 && op <= 0x60 { // OP_1..OP_16
+		return int(op - 0x50)
+	}
+	if op < 1 || op > 4 || len(scriptSig) < 2+2*int(op) {
+		return 0
+	}
+	var height int
+	for i := int(op) - 1; i >= 0; i-- { // Little-endian script number
+		height = height<<8 | int(hexByte(scriptSig[2+2*i], scriptSig[3+2*i]))
+	}
+	if hexByte(scriptSig[2*int(op)], scriptSig[2*int(op)+1])&0x80 != 0 {
+		return 0 // Negative script number
+	}
+	return height
+}
+
 // isCoinbaseTx checks if a transaction is a coinbase (block reward)
 func isCoinbaseTx(tx models.Transaction) bool {
 	if len(tx.Inputs) != 1 {
@@ -116,29 +175,6 @@ func isCoinbaseTx(tx models.Transaction) bool {
 	return false
 }
 
-// identifyPool matches coinbase scriptSig against known pool markers
-func identifyPool(scriptSig string) (string, float64) {
-	if scriptSig == "" {
-		return "unknown", 0
-	}
-
-	lower := strings.ToLower(scriptSig)
-
-	// Try direct hex-decoded string matching
-	decoded := hexToASCII(lower)
-
-	for marker, poolName := range poolMarkers {
-		if strings.Contains(decoded, marker) {
-			return poolName, 0.95
-		}
-		if strings.Contains(lower, marker) {
-			return poolName, 0.85
-		}
-	}
-
-	return "unknown", 0
-}
-
 // hexToASCII converts hex string to ASCII (best-effort)
 func hexToASCII(hex string) string {
 	var result strings.Builder
@@ -184,6 +220,39 @@ func classifyPayoutType(tx models.Transaction) string {
 	}
 }
 
+// classifyPayoutStructure counts the distinct scripts a coinbase pays and
+// classifies whether they are one pool wallet or many directly-paid miners
+func classifyPayoutStructure(tx models.Transaction) (int, string) {
+	paid := make(map[string]int64)
+	var total int64
+	for _, out := range tx.Outputs {
+		if out.Value <= 0 || isOPReturn(out.ScriptPubKey) {
+			continue
+		}
+		key := out.Address
+		if key == "" {
+			key = out.ScriptPubKey
+		}
+		paid[key] += out.Value
+		total += out.Value
+	}
+
+	var largest int64
+	for _, v := range paid {
+		largest = max(largest, v)
+	}
+	switch {
+	case len(paid) == 0:
+		return 0, "unknown"
+	case len(paid) == 1 || float64(largest) >= singleWalletShare*float64(total):
+		return len(paid), "single_wallet"
+	case len(paid) >= distributedPayeeMinimum:
+		return len(paid), "distributed"
+	default:
+		return len(paid), "split"
+	}
+}
+
 // IsCoinbaseSpend checks if a transaction spends coinbase outputs.
 // Coinbase outputs require 100 confirmations (maturity) before spending.
 func IsCoinbaseSpend(tx models.Transaction, blockHeight int) bool {
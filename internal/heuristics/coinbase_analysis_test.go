@@ -0,0 +1,113 @@
+package heuristics
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// coinbaseAt builds a coinbase claiming subsidy plus fees at height 840,000
+// (BIP34 push 0340d10c), tagged with marker and paying outs plus the
+// witness commitment
+func coinbaseAt(marker string, outs ...models.TxOut) models.Transaction {
+	scriptSig := "0340d10c" + hex.EncodeToString([]byte(marker))
+	tx := models.Transaction{
+		Txid:   "coinbase",
+		Inputs: []models.TxIn{{Txid: "0000000000000000000000000000000000000000000000000000000000000000", Vout: 0xFFFFFFFF, ScriptSig: scriptSig}},
+	}
+	tx.Outputs = append(outs, models.TxOut{ScriptPubKey: "6a24aa21a9ed" + "00", Value: 0})
+	return tx
+}
+
+func TestBlockSubsidy(t *testing.T) {
+	cases := map[int]int64{
+		0:            5_000_000_000,
+		209_999:      5_000_000_000,
+		210_000:      2_500_000_000,
+		840_000:      312_500_000,
+		64 * 210_000: 0,
+	}
+	for height, want := range cases {
+		if got := BlockSubsidy(height); got != want {
+			t.Errorf("BlockSubsidy(%d) = %d, want %d", height, got, want)
+		}
+	}
+}
+
+func TestBIP34Height(t *testing.T) {
+	cases := map[string]int{
+		"0340d10c2f466f756e647279": 840_000,
+		"03a0bb0d":                 900_000,
+		"51":                       1,
+		"0180":                     0, // Negative script number
+		"03a0bb":                   0, // Truncated push
+		"":                         0,
+	}
+	for script, want := range cases {
+		if got := bip34Height(script); got != want {
+			t.Errorf("bip34Height(%q) = %d, want %d", script, got, want)
+		}
+	}
+}
+
+func TestAnalyzeCoinbaseTx_SingleWalletSplit(t *testing.T) {
+	tx := coinbaseAt("/Foundry USA Pool #dropgold/",
+		models.TxOut{Address: "bc1qfoundrypool", Value: 312_500_000 + 21_000_000})
+
+	cb := AnalyzeCoinbaseTx(tx)
+	if !cb.IsCoinbase || cb.PoolName != "Foundry USA" {
+		t.Fatalf("expected Foundry USA coinbase, got %+v", cb)
+	}
+	if cb.BlockHeight != 840_000 || cb.MaturityHeight != 840_100 {
+		t.Errorf("height %d maturity %d, want 840000/840100", cb.BlockHeight, cb.MaturityHeight)
+	}
+	if cb.Subsidy != 312_500_000 || cb.Fees != 21_000_000 {
+		t.Errorf("subsidy %d fees %d, want 312500000/21000000", cb.Subsidy, cb.Fees)
+	}
+	if cb.Payees != 1 || cb.PayoutStructure != "single_wallet" {
+		t.Errorf("payees %d structure %q, want 1 single_wallet", cb.Payees, cb.PayoutStructure)
+	}
+}
+
+func TestAnalyzeCoinbaseTx_DistributedPayout(t *testing.T) {
+	var outs []models.TxOut
+	for i := 0; i < 40; i++ {
+		outs = append(outs, models.TxOut{Address: fmt.Sprintf("bc1qminer%02d", i), Value: 8_000_000 + int64(i)*10_000})
+	}
+	tx := coinbaseAt("/ocean.xyz/", outs...)
+	tx.BlockHeight = 900_000 // Confirming block wins over the scriptSig push
+
+	cb := AnalyzeCoinbaseTx(tx)
+	if cb.PoolName != "OCEAN" || cb.PayoutStructure != "distributed" || cb.Payees != 40 {
+		t.Fatalf("expected OCEAN distributed payout to 40 miners, got %+v", cb)
+	}
+	if cb.BlockHeight != 900_000 || cb.Subsidy != 312_500_000 {
+		t.Errorf("height %d subsidy %d, want 900000/312500000", cb.BlockHeight, cb.Subsidy)
+	}
+	if cb.Fees != cb.BlockReward-cb.Subsidy {
+		t.Errorf("fees %d, want reward %d − subsidy", cb.Fees, cb.BlockReward)
+	}
+}
+
+func TestAnalyzeCoinbaseTx_SurfacedInAnalyzeTx(t *testing.T) {
+	tx := coinbaseAt("/AntPool/",
+		models.TxOut{Address: "bc1qantpool", Value: 250_000_000},
+		models.TxOut{Address: "bc1qpartner", Value: 83_000_000})
+
+	res := AnalyzeTx(tx)
+	if res.Coinbase == nil {
+		t.Fatal("expected coinbase analysis in the result")
+	}
+	if res.Coinbase.PayoutStructure != "split" || res.Coinbase.Fees != 20_500_000 {
+		t.Errorf("got structure %q fees %d, want split/20500000", res.Coinbase.PayoutStructure, res.Coinbase.Fees)
+	}
+	if res.WalletFamily != "mining:AntPool" {
+		t.Errorf("wallet family %q, want mining:AntPool", res.WalletFamily)
+	}
+
+	if AnalyzeTx(ladderRound()).Coinbase != nil {
+		t.Error("non-coinbase tx should not carry coinbase analysis")
+	}
+}
@@ -79,7 +79,7 @@ func AnalyzeUnmixability(tx models.Transaction, isCoinJoin bool) models.UnmixRes
 
 	// Compute linkability score (0.0 = perfect, 1.0 = fully linkable)
 	if result.TotalOutputs > 0 {
-		result.LinkabilityScore = math.Round(float64(result.UnmixableOutputs)*100/float64(result.TotalOutputs)) / 100
+		result.LinkabilityScore = float64(result.UnmixableOutputs) / float64(result.TotalOutputs)
 	}
 
 	// Classify mix quality
@@ -189,7 +189,7 @@ func ComputeOutputValueEntropy(outputs []models.TxOut) float64 {
 		}
 	}
 
-	return math.Round(entropy*100) / 100
+	return entropy
 }
 
 // GetOutputValueDistribution returns the sorted value frequencies
@@ -1,7 +1,7 @@
 package heuristics
 
 import (
-	"math"
+	"encoding/json"
 
 	"github.com/rawblock/coinjoin-engine/pkg/models"
 )
@@ -34,6 +34,15 @@ type ConsolidationResult struct {
 	EstimatedSavings  int64   `json:"estimatedSavings"`  // Estimated future fee savings (sats)
 }
 
+// MarshalJSON rounds reported floats to the configured precision
+func (r ConsolidationResult) MarshalJSON() ([]byte, error) {
+	type alias ConsolidationResult
+	a := alias(r)
+	a.InputReduction = models.RoundFloat(a.InputReduction)
+	a.FeeEfficiency = models.RoundFloat(a.FeeEfficiency)
+	return json.Marshal(a)
+}
+
 // AnalyzeConsolidation detects and classifies UTXO consolidation patterns
 func AnalyzeConsolidation(tx models.Transaction) ConsolidationResult {
 	result := ConsolidationResult{
@@ -52,7 +61,7 @@ func AnalyzeConsolidation(tx models.Transaction) ConsolidationResult {
 
 	// Input reduction ratio
 	if nIn > 0 {
-		result.InputReduction = math.Round(float64(nIn-nOut)*100/float64(nIn)) / 100
+		result.InputReduction = float64(nIn-nOut) / float64(nIn)
 	}
 
 	// Fee efficiency (value preservation)
@@ -65,7 +74,7 @@ func AnalyzeConsolidation(tx models.Transaction) ConsolidationResult {
 		totalOutput += out.Value
 	}
 	if totalInput > 0 {
-		result.FeeEfficiency = math.Round(float64(totalOutput)*10000/float64(totalInput)) / 10000
+		result.FeeEfficiency = float64(totalOutput) / float64(totalInput)
 	}
 
 	// Strategic timing: low fee rate indicates planned consolidation
@@ -155,5 +164,5 @@ func ComputeConsolidationEfficiency(inputCount int, feesPaid int64) float64 {
 	// Break-even: feesPaid / futureVbytes = fee_rate at which this consolidation pays off
 	breakEven := float64(feesPaid) / futureVbytes
 
-	return math.Round(breakEven*100) / 100
+	return breakEven
 }
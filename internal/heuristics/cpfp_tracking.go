@@ -0,0 +1,121 @@
+package heuristics
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Child-Pays-For-Parent (CPFP) Package Tracking
+//
+// Miners select ancestor packages, not single transactions: a child that
+// spends an unconfirmed output pulls its parent into a block at the
+// combined rate. The tracker remembers the fee and vsize of mempool
+// transactions and, when a newcomer spends one of their outputs, reports
+// the package:
+//
+//   - Package fee rate = (parent fees + child fee) / (parent vsizes + child vsize)
+//   - Abnormal pinning: a parent paying almost nothing held in place by a
+//     child paying many times its rate. Exchanges and wallets bump stuck
+//     payments this way, but the same shape is used to keep a low-fee
+//     parent in the mempool and block competing replacements of it
+//
+// Only direct mempool parents are counted (one ancestor level).
+//
+// References:
+//   - Bitcoin Core doc/policy/packages.md (ancestor package selection)
+//   - Riard (2020), "RBF Pinning with Counterparties and Competing
+//     Interest" (bitcoin-dev mailing list)
+
+const (
+	cpfpTxLimit = 500_000 // Mempool transactions remembered before the tracker resets
+
+	// pinningParentMaxSatVB is the parent fee rate below which a boosted
+	// parent counts as pinned (near the default relay floor)
+	pinningParentMaxSatVB = 2.0
+	// pinningBoostRatio is the child/parent fee-rate ratio that marks an
+	// abnormal boost
+	pinningBoostRatio = 10.0
+)
+
+// CPFPPackage is a child transaction and the unconfirmed parents it spends
+type CPFPPackage struct {
+	IsCPFP          bool     `json:"isCpfp"`
+	Parents         []string `json:"parents,omitempty"` // Mempool txids whose outputs the child spends
+	ParentFeeRate   float64  `json:"parentFeeRate"`     // Combined parents (sat/vB)
+	ChildFeeRate    float64  `json:"childFeeRate"`      // sat/vB
+	PackageFeeRate  float64  `json:"packageFeeRate"`    // Combined fee / combined vsize (sat/vB)
+	AbnormalPinning bool     `json:"abnormalPinning"`   // Near-zero-fee parent held by a much richer child
+}
+
+// cpfpEntry is what the tracker remembers of one mempool transaction
+type cpfpEntry struct {
+	fee   int64
+	vsize int
+}
+
+// CPFPTracker remembers the fee and size of recent mempool transactions
+type CPFPTracker struct {
+	mu      sync.Mutex
+	entries map[string]cpfpEntry
+}
+
+// NewCPFPTracker creates an empty tracker
+func NewCPFPTracker() *CPFPTracker {
+	return &CPFPTracker{entries: make(map[string]cpfpEntry)}
+}
+
+// Observe records tx (paying fee) and reports its package if it spends
+// outputs of transactions already seen in the mempool
+func (t *CPFPTracker) Observe(tx models.Transaction, fee int64) CPFPPackage {
+	if tx.Txid == "" || tx.Vsize <= 0 {
+		return CPFPPackage{}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.entries) >= cpfpTxLimit {
+		t.entries = make(map[string]cpfpEntry)
+	}
+	t.entries[tx.Txid] = cpfpEntry{fee: fee, vsize: tx.Vsize}
+
+	seen := make(map[string]bool)
+	var parents []string
+	var parentFee int64
+	parentVsize := 0
+	for _, in := range tx.Inputs {
+		parent, ok := t.entries[in.Txid]
+		if !ok || seen[in.Txid] || in.Txid == tx.Txid {
+			continue
+		}
+		seen[in.Txid] = true
+		parents = append(parents, in.Txid)
+		parentFee += parent.fee
+		parentVsize += parent.vsize
+	}
+	if len(parents) == 0 || parentVsize == 0 {
+		return CPFPPackage{}
+	}
+	sort.Strings(parents)
+
+	pkg := CPFPPackage{
+		IsCPFP:         true,
+		Parents:        parents,
+		ParentFeeRate:  float64(parentFee) / float64(parentVsize),
+		ChildFeeRate:   float64(fee) / float64(tx.Vsize),
+		PackageFeeRate: float64(parentFee+fee) / float64(parentVsize+tx.Vsize),
+	}
+	pkg.AbnormalPinning = pkg.ParentFeeRate < pinningParentMaxSatVB &&
+		pkg.ChildFeeRate >= pinningBoostRatio*max(pkg.ParentFeeRate, 1)
+	return pkg
+}
+
+// Forget drops a transaction that left the mempool: once confirmed, a
+// spend of its outputs no longer pulls it into a block
+func (t *CPFPTracker) Forget(txid string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, txid)
+}
@@ -0,0 +1,48 @@
+package heuristics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func TestCPFPTracker_PackageFeeRate(t *testing.T) {
+	tracker := NewCPFPTracker()
+	parent := models.Transaction{
+		Txid:    "parent",
+		Vsize:   200,
+		Inputs:  []models.TxIn{{Txid: "confirmed", Vout: 0}},
+		Outputs: []models.TxOut{{Value: 50_000}, {Value: 40_000}},
+	}
+	if pkg := tracker.Observe(parent, 200); pkg.IsCPFP {
+		t.Fatalf("Expected a parent spending confirmed coins not to be CPFP. Got %+v", pkg)
+	}
+
+	child := models.Transaction{
+		Txid:    "child",
+		Vsize:   100,
+		Inputs:  []models.TxIn{{Txid: "parent", Vout: 1}},
+		Outputs: []models.TxOut{{Value: 35_000}},
+	}
+	pkg := tracker.Observe(child, 5_000)
+	if !pkg.IsCPFP || len(pkg.Parents) != 1 || pkg.Parents[0] != "parent" {
+		t.Fatalf("Expected child to be a CPFP of parent. Got %+v", pkg)
+	}
+	// (200 + 5000) / (200 + 100)
+	if math.Abs(pkg.PackageFeeRate-5200.0/300.0) > 1e-9 || pkg.ParentFeeRate != 1 || pkg.ChildFeeRate != 50 {
+		t.Errorf("Expected parent 1, child 50, package 17.33 sat/vB. Got %+v", pkg)
+	}
+	if !pkg.AbnormalPinning {
+		t.Errorf("Expected a 1 sat/vB parent boosted 50x to be flagged")
+	}
+}
+
+func TestCPFPTracker_OrdinaryChainNotPinning(t *testing.T) {
+	tracker := NewCPFPTracker()
+	tracker.Observe(models.Transaction{Txid: "p", Vsize: 150, Inputs: []models.TxIn{{Txid: "c"}}}, 3_000)
+	pkg := tracker.Observe(models.Transaction{Txid: "k", Vsize: 150, Inputs: []models.TxIn{{Txid: "p"}, {Txid: "p", Vout: 1}}}, 3_600)
+	if !pkg.IsCPFP || len(pkg.Parents) != 1 || pkg.AbnormalPinning {
+		t.Errorf("Expected an ordinary 20→24 sat/vB chain, one parent, no pinning. Got %+v", pkg)
+	}
+}
@@ -1,6 +1,16 @@
 package heuristics
 
-import "log"
+import (
+	"log"
+	"sort"
+)
+
+// cpsatMaxOutputs bounds per-input subset enumeration (2^n candidate domains)
+const cpsatMaxOutputs = 20
+
+// cpsatNodeBudget caps search nodes so the solver stays "anytime": when the
+// budget runs out, the best assignment found so far is returned.
+const cpsatNodeBudget = 2_000_000
 
 // SolveCPSAT implements a Constraint Propagation solver for small, constrained instances.
 // CP-SAT / ILP engines are deployed strictly for small, highly constrained
@@ -9,15 +19,20 @@ import "log"
 //
 // This solver models the input-output assignment as a Boolean Satisfaction Problem:
 //   - For each input i and output j, we have a Boolean variable x[i][j]
-//   - Constraint 1: Each output is assigned to exactly one input (partition)
-//   - Constraint 2: sum(outputs assigned to input i) ≈ input[i] within fee tolerance
-//   - Objective: Find the maximum number of inputs that can be matched to valid output partitions
+//   - Constraint 1: Each output is assigned to at most one input (disjoint sub-transactions;
+//     unassigned outputs are unmatched change)
+//   - Constraint 2: a matched input's outputs sum to [input[i]-tau, input[i]]
+//     (outputs fall short of the input by the participant's fee share)
+//   - Objective: maximize the number of matched inputs
+//
+// Search is exact branch-and-bound over inputs (fewest feasible subsets
+// first) with optimistic-bound pruning, subject to cpsatNodeBudget.
 func SolveCPSAT(inputs []int64, outputs []int64, tau int64) int {
 	nIn := len(inputs)
 	nOut := len(outputs)
 
 	// Hard guardrail: refuse large unconstrained instances
-	if nIn*nOut > 100 {
+	if nIn*nOut > 100 || nOut > cpsatMaxOutputs {
 		log.Printf("[CP-SAT] Instance too large (%d x %d = %d). Refusing to run.", nIn, nOut, nIn*nOut)
 		return 0
 	}
@@ -26,88 +41,74 @@ func SolveCPSAT(inputs []int64, outputs []int64, tau int64) int {
 		return 0
 	}
 
-	if tau < 1000 {
-		tau = 1000
+	// Propagation: domain of each input = the output subsets it can claim
+	domains := make([][]uint32, nIn)
+	for i, in := range inputs {
+		domains[i] = feasibleSubsets(in, outputs, tau)
 	}
+	sort.SliceStable(domains, func(a, b int) bool { return len(domains[a]) < len(domains[b]) })
 
-	// The solver uses backtracking search with constraint propagation.
-	// assignment[j] = i means output j is assigned to input i. -1 = unassigned.
-	assignment := make([]int, nOut)
-	for j := range assignment {
-		assignment[j] = -1
+	s := cpsatSearch{domains: domains, budget: cpsatNodeBudget}
+	s.solve(0, 0, 0)
+	if s.budget <= 0 {
+		log.Printf("[CP-SAT] Node budget exhausted. Returning best assignment found (%d).", s.best)
 	}
-
-	bestResult := 0
-	solveRecursive(inputs, outputs, assignment, tau, 0, &bestResult)
-
-	return bestResult
+	return s.best
 }
 
-// solveRecursive performs backtracking search through output assignments.
-func solveRecursive(inputs, outputs []int64, assignment []int, tau int64, outputIdx int, bestResult *int) {
-	nOut := len(outputs)
-	nIn := len(inputs)
-
-	// Base case: all outputs have been assigned
-	if outputIdx == nOut {
-		// Count how many inputs have valid (non-empty) output partitions
-		// that satisfy the fee tolerance constraint
-		validInputs := countValidPartitions(inputs, outputs, assignment, tau)
-		if validInputs > *bestResult {
-			*bestResult = validInputs
+// feasibleSubsets enumerates output bitmasks whose sum lies in [in-tau, in]
+func feasibleSubsets(in int64, outputs []int64, tau int64) []uint32 {
+	var masks []uint32
+	var walk func(j int, mask uint32, sum int64)
+	walk = func(j int, mask uint32, sum int64) {
+		if sum > in {
+			return // Prune: already exceeds the input value
 		}
-		return
-	}
-
-	// Try assigning this output to each input
-	for i := 0; i < nIn; i++ {
-		assignment[outputIdx] = i
-
-		// Pruning: check if this partial assignment can still be feasible
-		// If the sum of outputs assigned to input i already exceeds input[i] + tau, prune
-		partialSum := int64(0)
-		for j := 0; j <= outputIdx; j++ {
-			if assignment[j] == i {
-				partialSum += outputs[j]
+		if j == len(outputs) {
+			if mask != 0 && sum >= in-tau {
+				masks = append(masks, mask)
 			}
+			return
 		}
-
-		if partialSum > inputs[i]+tau {
-			assignment[outputIdx] = -1
-			continue // Prune: this partition already exceeds the input value
-		}
-
-		solveRecursive(inputs, outputs, assignment, tau, outputIdx+1, bestResult)
+		walk(j+1, mask|1<<uint(j), sum+outputs[j])
+		walk(j+1, mask, sum)
 	}
+	walk(0, 0, 0)
+	return masks
+}
 
-	// Also try not assigning this output (leave as "unmatched change")
-	assignment[outputIdx] = -1
-	solveRecursive(inputs, outputs, assignment, tau, outputIdx+1, bestResult)
+// cpsatSearch holds branch-and-bound state
+type cpsatSearch struct {
+	domains [][]uint32
+	best    int
+	budget  int
 }
 
-// countValidPartitions checks how many input partitions satisfy the fee tolerance.
-func countValidPartitions(inputs, outputs []int64, assignment []int, tau int64) int {
-	nIn := len(inputs)
-	partitionSums := make([]int64, nIn)
-	partitionHasOutputs := make([]bool, nIn)
+// solve assigns inputs from index i onward given the claimed output mask
+func (s *cpsatSearch) solve(i int, used uint32, matched int) {
+	if matched > s.best {
+		s.best = matched
+	}
+	s.budget--
+	if i == len(s.domains) || s.budget <= 0 {
+		return
+	}
 
-	for j, inputIdx := range assignment {
-		if inputIdx >= 0 && inputIdx < nIn {
-			partitionSums[inputIdx] += outputs[j]
-			partitionHasOutputs[inputIdx] = true
-		}
+	// Bound: even matching every remaining input cannot beat the incumbent
+	if matched+len(s.domains)-i <= s.best {
+		return
 	}
 
-	validCount := 0
-	for i := 0; i < nIn; i++ {
-		if !partitionHasOutputs[i] {
-			continue
+	for _, mask := range s.domains[i] {
+		if mask&used != 0 {
+			continue // Constraint 1: outputs already claimed
 		}
-		// Valid if: input[i] - tau <= sum(outputs) <= input[i] + tau
-		if partitionSums[i] >= inputs[i]-tau && partitionSums[i] <= inputs[i]+tau {
-			validCount++
+		s.solve(i+1, used|mask, matched+1)
+		if s.budget <= 0 {
+			return
 		}
 	}
 
-	return validCount
+	// Input i left unmatched
+	s.solve(i+1, used, matched)
 }
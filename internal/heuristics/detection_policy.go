@@ -0,0 +1,122 @@
+package heuristics
+
+import (
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// CoinJoin Detection Policy
+//
+// AnalyzeTx Step 2 decides whether a transaction is a collaborative
+// construction. The historical gate (≥5 inputs, ≥5 outputs, AnonSet ≥5)
+// misses small JoinMarket-style joins and fires on batch payments whose
+// equal payouts give the solver something to count. The gate is a named
+// preset:
+//
+//   balanced   ≥5 participants, AnonSet ≥5 (historical behavior, default)
+//   strict     balanced, plus an equal-output group of ≥5 funded by as
+//              many distinct input addresses: one wallet paying five equal
+//              withdrawals from two addresses is a batch, not a mix
+//   sensitive  ≥3 participants, AnonSet ≥3 (3-party joins; more batch
+//              payments flagged)
+//
+// Environment override (read by DetectionPolicyFromEnv):
+//   COINJOIN_DETECTION_POLICY
+
+// Detection policy presets
+const (
+	DetectionPolicyBalanced  = "balanced"
+	DetectionPolicyStrict    = "strict"
+	DetectionPolicySensitive = "sensitive"
+)
+
+// DetectionPolicy gates AnalyzeTx's CoinJoin determination
+type DetectionPolicy struct {
+	Name                string `json:"name"`
+	MinParticipants     int    `json:"minParticipants"`     // Inputs and outputs required
+	MinAnonSet          int    `json:"minAnonSet"`          // Solver AnonSet required
+	RequireEqualOutputs bool   `json:"requireEqualOutputs"` // Equal-output group of MinParticipants from as many input addresses
+}
+
+// DetectionPolicyPreset returns the named preset
+func DetectionPolicyPreset(name string) (DetectionPolicy, bool) {
+	switch name {
+	case DetectionPolicyBalanced:
+		return DetectionPolicy{Name: name, MinParticipants: 5, MinAnonSet: 5}, true
+	case DetectionPolicyStrict:
+		return DetectionPolicy{Name: name, MinParticipants: 5, MinAnonSet: 5, RequireEqualOutputs: true}, true
+	case DetectionPolicySensitive:
+		return DetectionPolicy{Name: name, MinParticipants: 3, MinAnonSet: 3}, true
+	}
+	return DetectionPolicy{}, false
+}
+
+// DefaultDetectionPolicy returns the balanced (historical) preset
+func DefaultDetectionPolicy() DetectionPolicy {
+	p, _ := DetectionPolicyPreset(DetectionPolicyBalanced)
+	return p
+}
+
+var detectionPolicy atomic.Pointer[DetectionPolicy]
+
+func init() {
+	p := DefaultDetectionPolicy()
+	detectionPolicy.Store(&p)
+}
+
+// SetDetectionPolicy replaces the process-wide CoinJoin detection policy.
+// Thresholds below 2 fall back to the balanced preset's.
+func SetDetectionPolicy(p DetectionPolicy) {
+	def := DefaultDetectionPolicy()
+	if p.MinParticipants < 2 {
+		p.MinParticipants = def.MinParticipants
+	}
+	if p.MinAnonSet < 2 {
+		p.MinAnonSet = def.MinAnonSet
+	}
+	detectionPolicy.Store(&p)
+}
+
+// CurrentDetectionPolicy returns the process-wide CoinJoin detection policy
+func CurrentDetectionPolicy() DetectionPolicy {
+	return *detectionPolicy.Load()
+}
+
+// DetectionPolicyFromEnv returns the COINJOIN_DETECTION_POLICY preset,
+// balanced when unset or unknown
+func DetectionPolicyFromEnv() DetectionPolicy {
+	raw := strings.ToLower(strings.TrimSpace(os.Getenv("COINJOIN_DETECTION_POLICY")))
+	if raw == "" {
+		return DefaultDetectionPolicy()
+	}
+	p, ok := DetectionPolicyPreset(raw)
+	if !ok {
+		log.Printf("[Detection] Invalid COINJOIN_DETECTION_POLICY %q, using %s", raw, DetectionPolicyBalanced)
+		return DefaultDetectionPolicy()
+	}
+	return p
+}
+
+// IsCoinJoin reports whether tx with the solver's anonSet passes the policy
+func (p DetectionPolicy) IsCoinJoin(tx models.Transaction, anonSet int) bool {
+	if len(tx.Inputs) < p.MinParticipants || len(tx.Outputs) < p.MinParticipants || anonSet < p.MinAnonSet {
+		return false
+	}
+	if !p.RequireEqualOutputs {
+		return true
+	}
+	if countEqualOutputs(tx.Outputs) < p.MinParticipants {
+		return false
+	}
+	owners := make(map[string]bool)
+	for _, in := range tx.Inputs {
+		if in.Address != "" {
+			owners[in.Address] = true
+		}
+	}
+	return len(owners) >= p.MinParticipants
+}
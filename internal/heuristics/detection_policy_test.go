@@ -0,0 +1,61 @@
+package heuristics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// equalJoin builds n inputs each funding one 0.01 BTC output, the inputs
+// spread over owners distinct addresses
+func equalJoin(n, owners int) models.Transaction {
+	tx := models.Transaction{Txid: fmt.Sprintf("join_%d_%d", n, owners), Fee: int64(n) * 2_000, Vsize: n * 110}
+	for i := 0; i < n; i++ {
+		tx.Inputs = append(tx.Inputs, models.TxIn{Address: fmt.Sprintf("bc1qowner%d", i%owners), Value: 1_002_000})
+		tx.Outputs = append(tx.Outputs, models.TxOut{Address: fmt.Sprintf("bc1qmixed%d", i), Value: 1_000_000})
+	}
+	return tx
+}
+
+func TestDetectionPolicy_SensitiveCatchesThreePartyJoin(t *testing.T) {
+	defer SetDetectionPolicy(DefaultDetectionPolicy())
+	tx := equalJoin(3, 3)
+
+	if res := AnalyzeTx(tx); res.HeuristicFlags&FlagLikelyCollabConstruct != 0 {
+		t.Fatalf("Expected balanced to miss a 3-party join (AnonSet %d)", res.AnonSet)
+	}
+	p, _ := DetectionPolicyPreset(DetectionPolicySensitive)
+	SetDetectionPolicy(p)
+	if res := AnalyzeTx(tx); res.HeuristicFlags&FlagLikelyCollabConstruct == 0 {
+		t.Errorf("Expected sensitive to detect the 3-party join (AnonSet %d)", res.AnonSet)
+	}
+}
+
+func TestDetectionPolicy_StrictRejectsSingleWalletBatch(t *testing.T) {
+	defer SetDetectionPolicy(DefaultDetectionPolicy())
+	batch := equalJoin(6, 2)
+
+	if res := AnalyzeTx(batch); res.HeuristicFlags&FlagLikelyCollabConstruct == 0 {
+		t.Fatalf("Expected balanced to flag the equal-payout batch (AnonSet %d)", res.AnonSet)
+	}
+	p, _ := DetectionPolicyPreset(DetectionPolicyStrict)
+	SetDetectionPolicy(p)
+	if res := AnalyzeTx(batch); res.HeuristicFlags&FlagLikelyCollabConstruct != 0 {
+		t.Errorf("Expected strict to reject a batch funded by 2 addresses")
+	}
+	if res := AnalyzeTx(equalJoin(6, 6)); res.HeuristicFlags&FlagLikelyCollabConstruct == 0 {
+		t.Errorf("Expected strict to keep a 6-party join (AnonSet %d)", res.AnonSet)
+	}
+}
+
+func TestDetectionPolicyFromEnv(t *testing.T) {
+	t.Setenv("COINJOIN_DETECTION_POLICY", "Strict")
+	if p := DetectionPolicyFromEnv(); p.Name != DetectionPolicyStrict || !p.RequireEqualOutputs {
+		t.Errorf("Expected the strict preset. Got %+v", p)
+	}
+	t.Setenv("COINJOIN_DETECTION_POLICY", "paranoid")
+	if p := DetectionPolicyFromEnv(); p != DefaultDetectionPolicy() {
+		t.Errorf("Expected balanced for an unknown preset. Got %+v", p)
+	}
+}
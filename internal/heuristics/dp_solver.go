@@ -1,82 +1,178 @@
 package heuristics
 
-import "log"
+import (
+	"log"
+	"math/bits"
+	"sort"
+)
 
-// SolveDPBitset implements a Pseudo-Polynomial Dynamic Programming solver
-// (utilizing bitset-like arrays) for bounded-value sum subproblems.
+// SolveDPBitset is a greedy matcher over a pseudo-polynomial subset-sum
+// table (bitset DP) for bounded-value sum subproblems.
 // This lane is highly competitive when values are constrained or quantized
 // (e.g., verifying small structured constraints like coordinator fee patterns).
-func SolveDPBitset(inputs []int64, outputs []int64, tau int64) int {
+//
+// It returns the number of DISJOINT sub-transactions found: each matched
+// input i claims a non-empty subset of still-unclaimed outputs whose sum lies
+// in [inputs[i]-tau, inputs[i]] (outputs fall short of the input by the
+// participant's fee share). Only the per-round feasibility check is a DP;
+// matches are committed greedily (smallest subset first) and never revisited,
+// so the count is a LOWER BOUND on the maximum number of disjoint
+// sub-transactions, not the maximum itself. Every counted linkage is
+// realizable simultaneously with the others. CalculateAnonSetDetailed
+// always runs SolveCPSAT after this lane and keeps the larger count.
+//
+// Guardrail: the DP table spans every reachable output sum, so the solver
+// refuses (returns 0) when the total output value exceeds maxSum
+// (SSMPConfig.DPMaxSum). Callers route larger instances to SolveCPSAT.
+func SolveDPBitset(inputs []int64, outputs []int64, tau int64, maxSum int64) int {
 	if len(inputs) == 0 || len(outputs) == 0 {
 		return 0
 	}
 
-	// Calculate maximum possible sum (all outputs combined)
-	var maxSum int64 = 0
+	var totalOut int64
 	for _, out := range outputs {
-		maxSum += out
+		totalOut += out
 	}
-
-	// Guardrail: This is pseudo-polynomial in maxSum. If it's too large, Refuse to run.
-	// We're looking for constrained small problems (e.g., max 500,000 Satoshis).
-	if maxSum > 500_000 {
-		log.Printf("[DP-Solver] Values too large for pseudo-polynomial lane (MaxSum: %d). Bailing out.", maxSum)
+	if totalOut > maxSum {
+		log.Printf("[DP-Solver] Values too large for pseudo-polynomial lane (Sum: %d > %d). Bailing out.", totalOut, maxSum)
 		return 0
 	}
 
-	// Convert outputs to DP array
-	// dp[s] holds the max number of inputs that can be successfully mapped using sum `s`
-	// Actually, an easier DP formulation for subset sum:
-	// Find if there is a subset of outputs summing to exactly the target (within tau).
+	// Smallest inputs first: they constrain the fewest outputs, leaving
+	// larger outputs for the inputs that need them
+	order := make([]int, len(inputs))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return inputs[order[a]] < inputs[order[b]] })
+
+	used := make([]bool, len(outputs))
+	matched := make([]bool, len(inputs))
+	matches := 0
+	table := newSubsetSumTable(outputs, totalOut)
 
-	// Fast track for single-input mapping testing to establish AnonSet
-	maxValidSets := 0
+	for {
+		// One DP pass over the unclaimed outputs answers every input at once
+		table.fill(used)
 
-	for _, targetInput := range inputs {
-		// Can we form targetInput within tau using a subset of outputs?
-		// target = targetInput. We allow sums from targetInput - tau to targetInput + tau.
-		if isSubsetSumDP(outputs, targetInput, tau, maxSum) {
-			maxValidSets++
+		// Commit the feasible input whose matching subset is smallest
+		bestInput := -1
+		var bestSubset []int
+		for _, i := range order {
+			if matched[i] {
+				continue
+			}
+			sum, ok := table.highestIn(inputs[i]-tau, inputs[i])
+			if !ok {
+				continue
+			}
+			subset := table.reconstruct(sum)
+			if bestInput < 0 || len(subset) < len(bestSubset) {
+				bestInput, bestSubset = i, subset
+			}
 		}
+		if bestInput < 0 {
+			return matches
+		}
+
+		matched[bestInput] = true
+		for _, j := range bestSubset {
+			used[j] = true
+		}
+		matches++
 	}
+}
 
-	// This is a naive heuristic bound. True SSMP requires non-overlapping sets.
-	// For production we combine this bound with the MitM/CP-SAT constraints.
-	return maxValidSets
+// subsetSumTable is a bitset of reachable subset sums with back-pointers
+type subsetSumTable struct {
+	reach   []uint64 // bit s set ⇔ some subset of the active outputs sums to s
+	last    []int16  // Output index whose inclusion first reached sum s (-1 for s = 0)
+	shifted []uint64 // Scratch for the shifted bitset
+	values  []int64
+	maxSum  int64
 }
 
-// isSubsetSumDP solves the basic subset sum problem up to a bounded maximum using DP.
-func isSubsetSumDP(values []int64, target int64, tau int64, maxSum int64) bool {
-	if target-tau > maxSum {
-		return false
+// newSubsetSumTable allocates the table for sums up to maxSum once; fill
+// reuses it for every matching round
+func newSubsetSumTable(values []int64, maxSum int64) *subsetSumTable {
+	words := maxSum/64 + 1
+	return &subsetSumTable{
+		reach:   make([]uint64, words),
+		last:    make([]int16, maxSum+1),
+		shifted: make([]uint64, words),
+		values:  values,
+		maxSum:  maxSum,
 	}
+}
 
-	dp := make([]bool, maxSum+1)
-	dp[0] = true
+// fill runs the 0/1 subset-sum DP over outputs not marked used.
+// Each pass ORs the bitset with itself shifted by the output value; newly set
+// bits record the output that reached them, which makes every sum
+// reconstructible by walking back through strictly decreasing output indices.
+// Only reach is cleared: last is read solely for sums reach marks, and every
+// such entry is rewritten by this fill.
+func (t *subsetSumTable) fill(used []bool) {
+	clear(t.reach)
+	t.reach[0] = 1
+	t.last[0] = -1
 
-	for _, val := range values {
-		for s := maxSum; s >= val; s-- {
-			if dp[s-val] {
-				dp[s] = true
+	shifted := t.shifted
+	for j, v := range t.values {
+		if used[j] || v <= 0 || v > t.maxSum {
+			continue
+		}
+		shiftLeft(shifted, t.reach, v)
+		for w := range t.reach {
+			fresh := shifted[w] &^ t.reach[w]
+			for fresh != 0 {
+				bit := bits.TrailingZeros64(fresh)
+				t.last[int64(w)*64+int64(bit)] = int16(j)
+				fresh &= fresh - 1
 			}
+			t.reach[w] |= shifted[w]
 		}
 	}
+}
 
-	// Check if any sum within [target-tau, target+tau] is possible
-	lowerBound := target - tau
-	if lowerBound < 0 {
-		lowerBound = 0
+// highestIn returns the largest reachable non-zero sum in [lo, hi]
+func (t *subsetSumTable) highestIn(lo, hi int64) (int64, bool) {
+	if lo < 1 {
+		lo = 1
 	}
-	upperBound := target + tau
-	if upperBound > maxSum {
-		upperBound = maxSum
+	if top := int64(len(t.last)) - 1; hi > top {
+		hi = top
 	}
-
-	for s := lowerBound; s <= upperBound; s++ {
-		if dp[s] {
-			return true
+	for s := hi; s >= lo; s-- {
+		if t.reach[s/64]&(1<<(uint(s)%64)) != 0 {
+			return s, true
 		}
 	}
+	return 0, false
+}
+
+// reconstruct returns the output indices forming a reachable sum
+func (t *subsetSumTable) reconstruct(sum int64) []int {
+	var subset []int
+	for sum > 0 {
+		j := int(t.last[sum])
+		subset = append(subset, j)
+		sum -= t.values[j]
+	}
+	return subset
+}
 
-	return false
+// shiftLeft sets dst = src << n over little-endian uint64 words
+func shiftLeft(dst, src []uint64, n int64) {
+	wordShift := int(n / 64)
+	bitShift := uint(n % 64)
+	for w := len(dst) - 1; w >= 0; w-- {
+		var v uint64
+		if k := w - wordShift; k >= 0 {
+			v = src[k] << bitShift
+			if bitShift > 0 && k > 0 {
+				v |= src[k-1] >> (64 - bitShift)
+			}
+		}
+		dst[w] = v
+	}
 }
@@ -0,0 +1,73 @@
+package heuristics
+
+import "testing"
+
+func TestSolveDPBitset_Whirlpool5x5(t *testing.T) {
+	// Scaled-down 5x5 pool: every input covers the denomination plus its fee share
+	inputs := []int64{50_300, 50_250, 50_400, 50_200, 50_350}
+	outputs := []int64{50_000, 50_000, 50_000, 50_000, 50_000}
+
+	if got := SolveDPBitset(inputs, outputs, 500, 500_000); got != 5 {
+		t.Errorf("Expected anonset 5 for a perfect 5x5 mix. Got %d", got)
+	}
+}
+
+func TestSolveDPBitset_BrokenMix(t *testing.T) {
+	// 4 inputs into 3 equal outputs + change; the 30k input is short of the
+	// denomination and no output subset falls in its fee window, so at most
+	// 3 disjoint sub-transactions exist
+	inputs := []int64{80_000, 60_000, 40_500, 30_000}
+	outputs := []int64{40_000, 40_000, 40_000, 39_500, 19_600}
+
+	got := SolveDPBitset(inputs, outputs, 1_000, 500_000)
+	if got != 3 {
+		t.Errorf("Expected anonset 3 for a broken 4-party mix. Got %d", got)
+	}
+}
+
+func TestSolveDPBitset_OutputsNotReused(t *testing.T) {
+	// Both inputs can individually reach 10_000 but only one output exists
+	inputs := []int64{10_100, 10_200}
+	outputs := []int64{10_000}
+
+	if got := SolveDPBitset(inputs, outputs, 500, 500_000); got != 1 {
+		t.Errorf("Expected overlapping matches to count once. Got %d", got)
+	}
+}
+
+func TestSolveDPBitset_MaxSumGuard(t *testing.T) {
+	inputs := []int64{1_000_500, 1_000_500}
+	outputs := []int64{1_000_000, 1_000_000}
+
+	if got := SolveDPBitset(inputs, outputs, 1_000, 500_000); got != 0 {
+		t.Errorf("Expected the DP lane to refuse sums above DPMaxSum. Got %d", got)
+	}
+	if got := SolveDPBitset(inputs, outputs, 1_000, 2_000_000); got != 2 {
+		t.Errorf("Expected a raised DPMaxSum to admit the instance. Got %d", got)
+	}
+}
+
+func TestSolveCPSAT_MatchesDPOnBrokenMix(t *testing.T) {
+	inputs := []int64{80_000, 60_000, 40_500, 30_000}
+	outputs := []int64{40_000, 40_000, 40_000, 39_500, 19_600}
+
+	if got := SolveCPSAT(inputs, outputs, 1_000); got != 3 {
+		t.Errorf("Expected CP-SAT anonset 3 for a broken 4-party mix. Got %d", got)
+	}
+	if got := SolveCPSAT([]int64{50_300, 50_250, 50_400, 50_200, 50_350}, []int64{50_000, 50_000, 50_000, 50_000, 50_000}, 500); got != 5 {
+		t.Errorf("Expected CP-SAT anonset 5 for a perfect 5x5 mix. Got %d", got)
+	}
+}
+
+func TestSolveDPBitset_GreedyLowerBound(t *testing.T) {
+	// Greedy commits 7 = 2+1+4 (the smallest subset found first), which
+	// leaves 7 and 9 matching neither 10 nor 13; CP-SAT finds 7 = 7 and
+	// 13 = 4+9
+	inputs := []int64{7, 10, 13}
+	outputs := []int64{2, 1, 4, 7, 9}
+
+	dp, cp := SolveDPBitset(inputs, outputs, 0, 1_000), SolveCPSAT(inputs, outputs, 0)
+	if dp != 1 || cp != 2 {
+		t.Errorf("Expected greedy DP 1 below the CP-SAT maximum 2. Got DP %d, CP-SAT %d", dp, cp)
+	}
+}
@@ -0,0 +1,114 @@
+package heuristics
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Dust Attack Correlation (cross-transaction)
+//
+// DetectDustAttack sees the planting and the consolidation as two
+// unrelated transactions. The surveillance payoff is the link between
+// them: the planted dust is later spent together with the victim's other
+// UTXOs, and CIOH hands the attacker every co-spent address.
+//
+// DustTracker remembers the dust outputs of transactions classified as
+// surveillance, keyed by outpoint, and flags a later transaction that
+// spends planted dust alongside at least one non-dust input. Spending the
+// dust alone (or sweeping only dust) links nothing and is not flagged.
+
+const dustTrackerLimit = 1_000_000 // Planted outpoints remembered before the tracker resets
+
+// plantedDust is a dust output of a surveillance transaction
+type plantedDust struct {
+	txid    string
+	address string
+	value   int64
+}
+
+// DustDeanonymization is planted dust consolidated with other UTXOs
+type DustDeanonymization struct {
+	IsDeanonymization bool     `json:"isDeanonymization"`
+	PlantTxids        []string `json:"plantTxids"`      // Transactions that planted the spent dust
+	DustInputs        []int    `json:"dustInputs"`      // Inputs spending planted dust
+	DustAddresses     []string `json:"dustAddresses"`   // Addresses the dust was planted on
+	LinkedAddresses   []string `json:"linkedAddresses"` // Non-dust input addresses exposed by the co-spend
+	DustValue         int64    `json:"dustValue"`
+}
+
+// DustTracker links planted dust to its later consolidation
+type DustTracker struct {
+	mu      sync.Mutex
+	planted map[string]plantedDust // "txid:vout" → planting record
+}
+
+// NewDustTracker creates an empty tracker
+func NewDustTracker() *DustTracker {
+	return &DustTracker{planted: make(map[string]plantedDust)}
+}
+
+// Observe reports whether tx consolidates previously planted dust with
+// non-dust inputs, then records tx's own dust outputs when tx looks like a
+// surveillance dusting
+func (t *DustTracker) Observe(tx models.Transaction) DustDeanonymization {
+	var res DustDeanonymization
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	seenPlant := make(map[string]bool)
+	var linked []string
+	for i, in := range tx.Inputs {
+		key := outpointKey(in.Txid, in.Vout)
+		dust, ok := t.planted[key]
+		if !ok {
+			if in.Value > getDustThreshold(in.Address) && in.Address != "" {
+				linked = append(linked, in.Address)
+			}
+			continue
+		}
+		delete(t.planted, key)
+		res.DustInputs = append(res.DustInputs, i)
+		res.DustAddresses = append(res.DustAddresses, dust.address)
+		res.DustValue += dust.value
+		if !seenPlant[dust.txid] {
+			seenPlant[dust.txid] = true
+			res.PlantTxids = append(res.PlantTxids, dust.txid)
+		}
+	}
+	if len(res.DustInputs) > 0 && len(linked) > 0 {
+		res.IsDeanonymization = true
+		res.LinkedAddresses = linked
+	} else {
+		res = DustDeanonymization{}
+	}
+
+	if DetectDustAttack(tx).Intent == "surveillance" {
+		if len(t.planted) >= dustTrackerLimit {
+			t.planted = make(map[string]plantedDust)
+		}
+		for vout, out := range tx.Outputs {
+			if out.Value > 0 && out.Value <= getDustThreshold(out.Address) {
+				t.planted[outpointKey(tx.Txid, uint32(vout))] = plantedDust{txid: tx.Txid, address: out.Address, value: out.Value}
+			}
+		}
+	}
+	return res
+}
+
+// DustDeanonymizationAlert builds the high-severity alert linking the
+// dusting transactions to the consolidation
+func DustDeanonymizationAlert(tx models.Transaction, res DustDeanonymization) Alert {
+	return Alert{
+		Severity:  "high",
+		AlertType: "dust_deanonymization",
+		Title:     "Dust deanonymization: planted dust consolidated",
+		Description: fmt.Sprintf("Transaction %s spends dust planted by %s (%d sats on %s) together with %d other input address(es), linking them to the dusted addresses.",
+			tx.Txid, strings.Join(res.PlantTxids, ", "), res.DustValue, strings.Join(res.DustAddresses, ", "), len(res.LinkedAddresses)),
+		TxID:  tx.Txid,
+		Value: sumInputValues(tx),
+	}
+}
@@ -0,0 +1,69 @@
+package heuristics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// dustingTx scatters 546-sat dust to three victims (surveillance intent)
+func dustingTx() models.Transaction {
+	return models.Transaction{
+		Txid:   "dusting",
+		Inputs: []models.TxIn{{Txid: "attacker-funds", Address: "1AttackerFundsXXXXXXXXXXXXXXXXXXXX", Value: 100_000}},
+		Outputs: []models.TxOut{
+			{Address: "1VictimAAAAAAAAAAAAAAAAAAAAAAAAAAA", Value: 546},
+			{Address: "1VictimBBBBBBBBBBBBBBBBBBBBBBBBBBB", Value: 546},
+			{Address: "1VictimCCCCCCCCCCCCCCCCCCCCCCCCCCC", Value: 546},
+			{Address: "1AttackerChangeXXXXXXXXXXXXXXXXXXX", Value: 95_000},
+		},
+	}
+}
+
+func TestDustTracker_FlagsConsolidationWithVictimUTXOs(t *testing.T) {
+	tracker := NewDustTracker()
+	if res := tracker.Observe(dustingTx()); res.IsDeanonymization {
+		t.Fatalf("Planting alone must not alert. Got %+v", res)
+	}
+
+	sweep := models.Transaction{
+		Txid: "victim-sweep",
+		Inputs: []models.TxIn{
+			{Txid: "dusting", Vout: 1, Address: "1VictimBBBBBBBBBBBBBBBBBBBBBBBBBBB", Value: 546},
+			{Txid: "salary", Vout: 0, Address: "1VictimSavingsXXXXXXXXXXXXXXXXXXXX", Value: 2_000_000},
+		},
+		Outputs: []models.TxOut{{Address: "bc1qvictimnewwallet", Value: 1_999_000}},
+	}
+	res := tracker.Observe(sweep)
+	if !res.IsDeanonymization || len(res.PlantTxids) != 1 || res.PlantTxids[0] != "dusting" {
+		t.Fatalf("Expected the sweep linked to the dusting tx. Got %+v", res)
+	}
+	if len(res.DustInputs) != 1 || res.DustInputs[0] != 0 || len(res.LinkedAddresses) != 1 {
+		t.Errorf("Expected input 0 as dust and the savings address exposed. Got %+v", res)
+	}
+
+	alert := DustDeanonymizationAlert(sweep, res)
+	if alert.Severity != "high" || alert.AlertType != "dust_deanonymization" || !strings.Contains(alert.Description, "dusting") {
+		t.Errorf("Expected a high dust_deanonymization alert naming the dusting tx. Got %+v", alert)
+	}
+
+	// The dust is spent: the same outpoint cannot be flagged twice
+	if again := tracker.Observe(sweep); again.IsDeanonymization {
+		t.Errorf("Expected spent dust forgotten. Got %+v", again)
+	}
+}
+
+func TestDustTracker_DustOnlySpendNotFlagged(t *testing.T) {
+	tracker := NewDustTracker()
+	tracker.Observe(dustingTx())
+
+	dustOnly := models.Transaction{
+		Txid:    "dust-only",
+		Inputs:  []models.TxIn{{Txid: "dusting", Vout: 0, Address: "1VictimAAAAAAAAAAAAAAAAAAAAAAAAAAA", Value: 546}},
+		Outputs: []models.TxOut{{Address: "bc1qsomewhere", Value: 400}},
+	}
+	if res := tracker.Observe(dustOnly); res.IsDeanonymization {
+		t.Errorf("Spending dust alone links nothing. Got %+v", res)
+	}
+}
@@ -0,0 +1,214 @@
+package heuristics
+
+import (
+	"sort"
+	"time"
+)
+
+// Entity Graph Projection
+//
+// Analysts present flows between ENTITIES, not addresses. This module
+// collapses the address-level FlowGraph into an entity-level graph:
+//   1. Each address is mapped to its cluster root via the ClusterEngine
+//      (the scanner's engine-wide clusters plus CIOH merges recorded
+//      while tracing)
+//   2. Nodes become clusters, annotated with the strongest role present
+//      (theft > exchange > bridge-exit > mixer > suspect > intermediate)
+//      and investigator tags
+//   3. Edges between the same pair of entities are aggregated; flows
+//      inside an entity (change, consolidation) are dropped
+//
+// References:
+//   - Meiklejohn et al., "A Fistful of Bitcoins" (IMC 2013)
+//   - Chainalysis Reactor, "Cluster view" graph presentation
+
+// EntityGraph is the cluster-level projection of a FlowGraph
+type EntityGraph struct {
+	InvestigationID string       `json:"investigationId"`
+	Entities        []EntityNode `json:"entities"`
+	Flows           []EntityFlow `json:"flows"`
+	TotalAddresses  int          `json:"totalAddresses"` // Addresses in the underlying flow graph
+	CreatedAt       time.Time    `json:"createdAt"`
+}
+
+// EntityNode is a cluster of addresses believed to share an owner
+type EntityNode struct {
+	EntityID      string   `json:"entityId"`        // Cluster root address
+	Addresses     []string `json:"addresses"`       // Flow-graph addresses in this entity
+	Role          string   `json:"role"`            // Strongest member role
+	Label         string   `json:"label,omitempty"` // Exchange name / investigator label
+	MinHop        int      `json:"minHop"`          // Closest distance to the theft
+	ValueReceived int64    `json:"valueReceived"`   // Sats received from other entities
+	ValueSent     int64    `json:"valueSent"`       // Sats sent to other entities
+	Taint         float64  `json:"taint"`           // Highest member risk score (0-1)
+	IsFlagged     bool     `json:"isFlagged"`
+}
+
+// EntityFlow aggregates all fund movements between two entities
+type EntityFlow struct {
+	FromEntity  string   `json:"fromEntity"`
+	ToEntity    string   `json:"toEntity"`
+	Value       int64    `json:"value"`       // Total sats moved
+	TxCount     int      `json:"txCount"`     // Distinct transactions
+	Txids       []string `json:"txids"`       // Supporting transactions
+	MinHop      int      `json:"minHop"`      // Earliest hop of the flow
+	Confidence  float64  `json:"confidence"`  // Highest edge confidence
+	ViaCoinJoin bool     `json:"viaCoinJoin"` // Any edge crossed a mixer
+}
+
+// entityRolePriority ranks roles when members of an entity disagree
+var entityRolePriority = map[string]int{
+	"theft":        6,
+	"exchange":     5,
+	"bridge-exit":  4,
+	"mixer":        3,
+	"suspect":      2,
+	"intermediate": 1,
+	"unspent":      1,
+	"burned":       1,
+}
+
+// BuildEntityGraph projects a flow graph onto address clusters.
+// Investigator tags override the label and may raise the role.
+// A nil ClusterEngine treats every address as its own entity.
+func BuildEntityGraph(graph *FlowGraph, ce *ClusterEngine, tags []TaggedAddress) EntityGraph {
+	result := EntityGraph{
+		Entities:  []EntityNode{},
+		Flows:     []EntityFlow{},
+		CreatedAt: time.Now(),
+	}
+	if graph == nil {
+		return result
+	}
+	result.InvestigationID = graph.InvestigationID
+	result.TotalAddresses = len(graph.Nodes)
+
+	if ce == nil {
+		ce = NewClusterEngine()
+	}
+
+	tagByAddr := make(map[string]TaggedAddress, len(tags))
+	for _, tag := range tags {
+		tagByAddr[tag.Address] = tag
+	}
+
+	// ─── Nodes: collapse addresses into entities ─────────────────────
+	entityIdx := make(map[string]int)
+	for _, node := range graph.Nodes {
+		root := ce.Find(node.Address)
+		idx, ok := entityIdx[root]
+		if !ok {
+			idx = len(result.Entities)
+			entityIdx[root] = idx
+			result.Entities = append(result.Entities, EntityNode{
+				EntityID: root,
+				MinHop:   node.HopNumber,
+			})
+		}
+		entity := &result.Entities[idx]
+		entity.Addresses = append(entity.Addresses, node.Address)
+		if node.HopNumber < entity.MinHop {
+			entity.MinHop = node.HopNumber
+		}
+		if node.RiskScore > entity.Taint {
+			entity.Taint = node.RiskScore
+		}
+		entity.IsFlagged = entity.IsFlagged || node.IsFlagged
+		applyEntityRole(entity, node.Role, node.Label)
+
+		if tag, ok := tagByAddr[node.Address]; ok {
+			applyEntityRole(entity, tag.Role, "")
+			if tag.Label != "" {
+				entity.Label = tag.Label
+			}
+			entity.IsFlagged = true
+		}
+	}
+
+	// ─── Edges: aggregate inter-entity flows ─────────────────────────
+	flowIdx := make(map[string]int)
+	for _, edge := range graph.Edges {
+		from := ce.Find(edge.FromAddress)
+		to := ce.Find(edge.ToAddress)
+		if from == to {
+			continue // Internal movement (change / consolidation)
+		}
+
+		key := from + "|" + to
+		idx, ok := flowIdx[key]
+		if !ok {
+			idx = len(result.Flows)
+			flowIdx[key] = idx
+			result.Flows = append(result.Flows, EntityFlow{
+				FromEntity: from,
+				ToEntity:   to,
+				MinHop:     edge.HopNumber,
+			})
+		}
+		flow := &result.Flows[idx]
+		flow.Value += edge.Value
+		flow.Txids = appendUnique(flow.Txids, edge.Txid)
+		flow.TxCount = len(flow.Txids)
+		if edge.HopNumber < flow.MinHop {
+			flow.MinHop = edge.HopNumber
+		}
+		if edge.Confidence > flow.Confidence {
+			flow.Confidence = edge.Confidence
+		}
+		flow.ViaCoinJoin = flow.ViaCoinJoin || edge.IsCoinJoin
+
+		if i, ok := entityIdx[from]; ok {
+			result.Entities[i].ValueSent += edge.Value
+		}
+		if i, ok := entityIdx[to]; ok {
+			result.Entities[i].ValueReceived += edge.Value
+		}
+	}
+
+	for i := range result.Entities {
+		sort.Strings(result.Entities[i].Addresses)
+	}
+	sort.SliceStable(result.Flows, func(i, j int) bool {
+		return result.Flows[i].MinHop < result.Flows[j].MinHop
+	})
+
+	return result
+}
+
+// combineClusters builds a scratch ClusterEngine over the flow graph's
+// addresses, joining any two that share a cluster in one of the given
+// engines. The engines are only read: addresses they have never seen are
+// not registered with them.
+func combineClusters(graph *FlowGraph, engines ...*ClusterEngine) *ClusterEngine {
+	combined := NewClusterEngine()
+	for _, ce := range engines {
+		if ce == nil {
+			continue
+		}
+		firstByRoot := make(map[string]string) // Engine root → first flow-graph member
+		for _, node := range graph.Nodes {
+			combined.Find(node.Address)
+			if !ce.Contains(node.Address) {
+				continue
+			}
+			root := ce.Find(node.Address)
+			if first, ok := firstByRoot[root]; ok {
+				combined.Union(first, node.Address)
+			} else {
+				firstByRoot[root] = node.Address
+			}
+		}
+	}
+	return combined
+}
+
+// applyEntityRole raises the entity role if the member role ranks higher
+func applyEntityRole(entity *EntityNode, role, label string) {
+	if entity.Role != "" && entityRolePriority[role] <= entityRolePriority[entity.Role] {
+		return
+	}
+	entity.Role = role
+	if label != "" {
+		entity.Label = label
+	}
+}
@@ -0,0 +1,109 @@
+package heuristics
+
+import (
+	"testing"
+)
+
+func findEntity(g EntityGraph, addr string) *EntityNode {
+	for i := range g.Entities {
+		for _, a := range g.Entities[i].Addresses {
+			if a == addr {
+				return &g.Entities[i]
+			}
+		}
+	}
+	return nil
+}
+
+func TestBuildEntityGraph_CollapsesClusters(t *testing.T) {
+	graph := &FlowGraph{SourceAddresses: []string{"theft"}}
+	graph.Nodes = append(graph.Nodes, FlowNode{Address: "theft", Role: "theft", RiskScore: 1.0, IsFlagged: true})
+	graph.AddHop("theft", "walletA1", "tx1", 400_000, 1, false, 1.0)
+	graph.AddHop("theft", "walletA2", "tx1", 300_000, 1, false, 1.0)
+	graph.AddHop("walletA1", "walletA3", "tx2", 690_000, 2, false, 1.0) // Consolidation inside A
+	graph.AddHop("walletA2", "walletA3", "tx2", 0, 2, false, 1.0)
+	graph.AddHop("walletA3", "binance_dep", "tx3", 680_000, 3, false, 0.9)
+	graph.MarkExchangeExit("binance_dep", "Binance")
+
+	// walletA1/A2 co-spent in tx2; walletA3 is their change
+	ce := NewClusterEngine()
+	ce.Union("walletA1", "walletA2")
+	ce.Union("walletA1", "walletA3")
+
+	eg := BuildEntityGraph(graph, ce, []TaggedAddress{
+		{Address: "walletA2", Label: "Suspect Wallet", Role: "suspect"},
+	})
+
+	if len(eg.Entities) != 3 {
+		t.Fatalf("Expected 3 entities (theft, wallet A, exchange). Got %d: %+v", len(eg.Entities), eg.Entities)
+	}
+
+	walletA := findEntity(eg, "walletA1")
+	if walletA == nil || len(walletA.Addresses) != 3 {
+		t.Fatalf("Expected wallet A to contain 3 addresses. Got %+v", walletA)
+	}
+	if walletA.Role != "suspect" || walletA.Label != "Suspect Wallet" {
+		t.Errorf("Expected wallet A tagged as suspect. Got role=%q label=%q", walletA.Role, walletA.Label)
+	}
+	if walletA.ValueReceived != 700_000 || walletA.ValueSent != 680_000 {
+		t.Errorf("Expected wallet A received=700000 sent=680000. Got %d/%d", walletA.ValueReceived, walletA.ValueSent)
+	}
+
+	exchange := findEntity(eg, "binance_dep")
+	if exchange == nil || exchange.Role != "exchange" || exchange.Label != "Binance" {
+		t.Errorf("Expected Binance exchange entity. Got %+v", exchange)
+	}
+
+	// Internal consolidation dropped; the two theft→A edges aggregated
+	if len(eg.Flows) != 2 {
+		t.Fatalf("Expected 2 inter-entity flows. Got %d: %+v", len(eg.Flows), eg.Flows)
+	}
+	first := eg.Flows[0]
+	if first.FromEntity != "theft" || first.ToEntity != walletA.EntityID || first.Value != 700_000 || first.TxCount != 1 {
+		t.Errorf("Expected aggregated theft→walletA flow of 700000 in 1 tx. Got %+v", first)
+	}
+}
+
+func TestBuildEntityGraph_NilInputs(t *testing.T) {
+	eg := BuildEntityGraph(nil, nil, nil)
+	if eg.Entities == nil || eg.Flows == nil || len(eg.Entities) != 0 {
+		t.Errorf("Expected empty, non-nil entity graph. Got %+v", eg)
+	}
+
+	graph := &FlowGraph{}
+	graph.AddHop("a", "b", "tx1", 1000, 1, false, 1.0)
+	eg = BuildEntityGraph(graph, nil, nil)
+	if len(eg.Flows) != 1 {
+		t.Errorf("Expected each address to be its own entity without a cluster engine. Got %+v", eg.Flows)
+	}
+}
+
+func TestInvestigation_EntityGraphUsesKnownClusters(t *testing.T) {
+	graph := &FlowGraph{SourceAddresses: []string{"theft"}}
+	graph.Nodes = append(graph.Nodes, FlowNode{Address: "theft", Role: "theft", RiskScore: 1.0, IsFlagged: true})
+	graph.AddHop("theft", "mule1", "tx1", 500_000, 1, false, 1.0)
+	graph.AddHop("theft", "mule2", "tx1", 400_000, 1, false, 1.0)
+	graph.Clusters().Union("mule2", "mule3") // Trace-local CIOH merge
+	graph.AddHop("mule2", "mule3", "tx2", 390_000, 2, false, 1.0)
+	inv := &Investigation{FlowGraph: graph}
+
+	// The scanner co-spent mule1 and mule2 in a block the trace never saw
+	known := NewClusterEngine()
+	known.Union("mule1", "mule2")
+	known.Union("unrelated", "other")
+
+	if eg := inv.EntityGraph(nil); len(eg.Entities) != 3 {
+		t.Errorf("Expected 3 entities from the trace-local clusters. Got %d", len(eg.Entities))
+	}
+
+	eg := inv.EntityGraph(known)
+	if len(eg.Entities) != 2 {
+		t.Fatalf("Expected theft and one mule entity. Got %d: %+v", len(eg.Entities), eg.Entities)
+	}
+	if mule := findEntity(eg, "mule1"); mule == nil || len(mule.Addresses) != 3 {
+		t.Errorf("Expected mule1, mule2 and mule3 collapsed into one entity. Got %+v", mule)
+	}
+	if known.Contains("theft") || known.Contains("mule3") {
+		t.Errorf("Expected the engine-wide clusters to be read without registering trace addresses")
+	}
+}
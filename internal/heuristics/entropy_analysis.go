@@ -87,9 +87,9 @@ func ComputeBoltzmannEntropy(tx models.Transaction) models.EntropyResult {
 	level := classifyEntropyLevel(entropy)
 
 	return models.EntropyResult{
-		Entropy:         math.Round(entropy*100) / 100,
-		MaxEntropy:      math.Round(maxEntropy*100) / 100,
-		Efficiency:      math.Round(efficiency*100) / 100,
+		Entropy:         entropy,
+		MaxEntropy:      maxEntropy,
+		Efficiency:      efficiency,
 		Level:           level,
 		Interpretations: interpretations,
 	}
@@ -1,7 +1,11 @@
 package heuristics
 
 import (
+	"context"
+	"encoding/json"
 	"math"
+	"sort"
+	"strings"
 
 	"github.com/rawblock/coinjoin-engine/pkg/models"
 )
@@ -39,6 +43,9 @@ const (
 
 	// MinTransitiveLLR is the minimum LLR for a transitive edge to be emitted
 	MinTransitiveLLR = 0.5
+
+	// chainFanoutLimit bounds the stored edges loaded per BFS level
+	chainFanoutLimit = 5000
 )
 
 // EvidenceChainLink represents one hop in a multi-hop evidence chain
@@ -62,6 +69,16 @@ type PropagatedEdge struct {
 	Confidence    float64             `json:"confidence"` // Posterior probability
 }
 
+// MarshalJSON rounds reported floats to the configured precision
+func (p PropagatedEdge) MarshalJSON() ([]byte, error) {
+	type alias PropagatedEdge
+	a := alias(p)
+	a.TotalLLR = models.RoundFloat(a.TotalLLR)
+	a.DecayedLLR = models.RoundFloat(a.DecayedLLR)
+	a.Confidence = models.RoundFloat(a.Confidence)
+	return json.Marshal(a)
+}
+
 // PropagateEvidence composes evidence edges across multiple transaction hops.
 // Given a chain of evidence edges [E₁, E₂, ..., Eₙ], it produces a
 // transitive edge with LLR = sum(LLR_i) × hopDecay^(n-1).
@@ -84,16 +101,17 @@ func PropagateEvidence(chain []models.EvidenceEdge, hopDecay float64) *Propagate
 		return nil // Too many hops, evidence too weak
 	}
 
-	// Sum LLRs across the chain
+	// Sum LLRs across the chain (each edge bounded, so the sum stays finite)
 	totalLLR := 0.0
 	links := make([]EvidenceChainLink, hops)
 	for i, edge := range chain {
-		totalLLR += edge.LLRScore
+		llr := ClampLLR(edge.LLRScore)
+		totalLLR += llr
 		links[i] = EvidenceChainLink{
 			Txid:      edge.EdgeID,
 			FromAddr:  edge.SrcNodeID,
 			ToAddr:    edge.DstNodeID,
-			LLR:       edge.LLRScore,
+			LLR:       llr,
 			EdgeType:  edge.EdgeType,
 			HopNumber: i + 1,
 		}
@@ -112,12 +130,12 @@ func PropagateEvidence(chain []models.EvidenceEdge, hopDecay float64) *Propagate
 
 	return &PropagatedEdge{
 		OriginalEdges: links,
-		TotalLLR:      math.Round(totalLLR*100) / 100,
-		DecayedLLR:    math.Round(decayedLLR*100) / 100,
+		TotalLLR:      totalLLR,
+		DecayedLLR:    decayedLLR,
 		Hops:          hops,
 		SourceAddr:    chain[0].SrcNodeID,
 		SinkAddr:      chain[len(chain)-1].DstNodeID,
-		Confidence:    math.Round(confidence*1000) / 1000,
+		Confidence:    confidence,
 	}
 }
 
@@ -137,15 +155,105 @@ func BuildTransitiveEdge(prop *PropagatedEdge) models.EvidenceEdge {
 	}
 }
 
-// LLRToProb converts a Log-Likelihood Ratio back to a probability.
-// P = 10^LLR / (1 + 10^LLR)
-func LLRToProb(llr float64) float64 {
-	if llr > 10 {
-		return 0.999
+// EvidenceEdgeSource looks up stored evidence edges touching a set of
+// addresses, as source or hyper-edge member (implemented by db.PostgresStore)
+type EvidenceEdgeSource interface {
+	EvidenceEdgesFrom(ctx context.Context, addrs []string, limit int) ([]models.EvidenceEdge, error)
+}
+
+// chainHop is one directed step a stored edge allows
+type chainHop struct {
+	from, to string
+	edge     models.EvidenceEdge
+}
+
+// edgeHops expands a stored edge into the address steps it supports:
+// src→dst, and src↔member for CIOH hyper-edges. Consolidation sinks are
+// not addresses and are never followed.
+func edgeHops(e models.EvidenceEdge) []chainHop {
+	var hops []chainHop
+	if e.DstNodeID != "" && !strings.HasPrefix(e.DstNodeID, "consolidation:") {
+		hops = append(hops, chainHop{from: e.SrcNodeID, to: e.DstNodeID, edge: e})
+	}
+	for _, m := range e.Members {
+		pair := e
+		pair.Members = nil
+		hops = append(hops,
+			chainHop{from: e.SrcNodeID, to: m, edge: withEndpoints(pair, e.SrcNodeID, m)},
+			chainHop{from: m, to: e.SrcNodeID, edge: withEndpoints(pair, m, e.SrcNodeID)})
+	}
+	return hops
+}
+
+func withEndpoints(e models.EvidenceEdge, src, dst string) models.EvidenceEdge {
+	e.SrcNodeID, e.DstNodeID = src, dst
+	return e
+}
+
+// AssembleEvidenceChain follows stored SrcNodeID→DstNodeID edges
+// breadth-first from source and returns the shortest chain reaching sink
+// (at most MaxPropagationHops), taking the strongest edge wherever several
+// reach the same address. Only positive linking edges are followed:
+// gating edges and earlier transitive edges would compound. Returns nil
+// when sink is out of reach.
+func AssembleEvidenceChain(ctx context.Context, src EvidenceEdgeSource, source, sink string) ([]models.EvidenceEdge, error) {
+	if source == "" || sink == "" || source == sink {
+		return nil, nil
 	}
-	if llr < -10 {
-		return 0.001
+
+	parent := map[string]models.EvidenceEdge{source: {}}
+	frontier := []string{source}
+	for depth := 0; depth < MaxPropagationHops && len(frontier) > 0; depth++ {
+		edges, err := src.EvidenceEdgesFrom(ctx, frontier, chainFanoutLimit)
+		if err != nil {
+			return nil, err
+		}
+		inFrontier := make(map[string]bool, len(frontier))
+		for _, a := range frontier {
+			inFrontier[a] = true
+		}
+		var hops []chainHop
+		for _, e := range edges {
+			if e.LLRScore <= 0 || e.EdgeType == EdgeTypeTransitive {
+				continue
+			}
+			for _, hop := range edgeHops(e) {
+				if inFrontier[hop.from] {
+					hops = append(hops, hop)
+				}
+			}
+		}
+		sort.SliceStable(hops, func(i, j int) bool { return hops[i].edge.LLRScore > hops[j].edge.LLRScore })
+
+		var next []string
+		for _, hop := range hops {
+			if _, seen := parent[hop.to]; seen {
+				continue
+			}
+			parent[hop.to] = hop.edge
+			next = append(next, hop.to)
+		}
+		if _, found := parent[sink]; found {
+			chain := make([]models.EvidenceEdge, 0, depth+1)
+			for at := sink; at != source; at = parent[at].SrcNodeID {
+				chain = append(chain, parent[at])
+			}
+			for i, j := 0, len(chain)-1; i < j; i, j = i+1, j-1 {
+				chain[i], chain[j] = chain[j], chain[i]
+			}
+			return chain, nil
+		}
+		sort.Strings(next)
+		frontier = next
 	}
+	return nil, nil
+}
+
+// LLRToProb converts a Log-Likelihood Ratio back to a probability.
+// P = 10^LLR / (1 + 10^LLR), saturating at [ε, 1-ε] like ProbToLLR so
+// that LLRToProb(ProbToLLR(p)) round-trips for every clamped p.
+func LLRToProb(llr float64) float64 {
+	llr = ClampLLR(llr)
 	odds := math.Pow(10, llr)
 	return odds / (1 + odds)
 }
@@ -0,0 +1,59 @@
+package heuristics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// memEdgeSource mimics the evidence_edge lookup by source or member
+type memEdgeSource []models.EvidenceEdge
+
+func (s memEdgeSource) EvidenceEdgesFrom(_ context.Context, addrs []string, limit int) ([]models.EvidenceEdge, error) {
+	want := make(map[string]bool, len(addrs))
+	for _, a := range addrs {
+		want[a] = true
+	}
+	var out []models.EvidenceEdge
+	for _, e := range s {
+		touches := want[e.SrcNodeID]
+		for _, m := range e.Members {
+			touches = touches || want[m]
+		}
+		if touches && len(out) < limit {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func TestAssembleEvidenceChain_ShortestStrongestPath(t *testing.T) {
+	store := memEdgeSource{
+		{EdgeID: "1", SrcNodeID: "A", DstNodeID: "B", EdgeType: EdgeTypeChange, LLRScore: 1.0},
+		{EdgeID: "2", SrcNodeID: "A", DstNodeID: "X", EdgeType: EdgeTypeChange, LLRScore: 2.0},
+		{EdgeID: "3", SrcNodeID: "B", DstNodeID: "C", EdgeType: EdgeTypeChange, LLRScore: 0.8},
+		{EdgeID: "4", SrcNodeID: "X", DstNodeID: "C", EdgeType: EdgeTypeChange, LLRScore: 1.5},
+		{EdgeID: "5", SrcNodeID: "A", DstNodeID: "C", EdgeType: EdgeTypeCoinjoinSuspected, LLRScore: -1.0},
+		// CIOH hyper-edge binding D with C, followed member → source
+		{EdgeID: "6", SrcNodeID: "D", DstNodeID: "consolidation:tx6", EdgeType: EdgeTypeCIOH, LLRScore: 1.28, Members: []string{"C"}},
+	}
+
+	chain, err := AssembleEvidenceChain(context.Background(), store, "A", "D")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(chain) != 3 || chain[0].EdgeID != "2" || chain[1].EdgeID != "4" || chain[2].EdgeID != "6" {
+		t.Fatalf("Expected A→X→C→D through the stronger branch. Got %+v", chain)
+	}
+	if chain[2].SrcNodeID != "C" || chain[2].DstNodeID != "D" {
+		t.Errorf("Expected the hyper-edge hop oriented C→D. Got %s→%s", chain[2].SrcNodeID, chain[2].DstNodeID)
+	}
+	if PropagateEvidence(chain, DefaultHopDecay) == nil {
+		t.Errorf("Expected the assembled chain to propagate")
+	}
+
+	if chain, _ := AssembleEvidenceChain(context.Background(), store, "C", "A"); chain != nil {
+		t.Errorf("Expected no chain against edge direction. Got %+v", chain)
+	}
+}
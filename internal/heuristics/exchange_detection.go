@@ -35,7 +35,7 @@ type ExchangeExitResult struct {
 	ExchangeName      string  `json:"exchangeName"`
 	Confidence        float64 `json:"confidence"`
 	DepositValue      int64   `json:"depositValue"`
-	DetectionMethod   string  `json:"detectionMethod"` // "address_match"/"pattern"/"behavioral"
+	DetectionMethod   string  `json:"detectionMethod"` // "address_match"/"address_label"/"pattern"/"behavioral"
 }
 
 // Known exchange address prefixes and patterns
@@ -89,6 +89,14 @@ func DetectExchangeExit(tx models.Transaction) ExchangeExitResult {
 				return result
 			}
 		}
+		if l, ok := labeledExchange(out.Address); ok {
+			result.IsExchangeDeposit = true
+			result.ExchangeName = l.Label
+			result.Confidence = l.Confidence
+			result.DepositValue = out.Value
+			result.DetectionMethod = "address_label"
+			return result
+		}
 	}
 
 	// Method 2: Structural pattern matching
@@ -167,3 +175,132 @@ func IsKnownExchangeAddress(addr string) (string, bool) {
 	}
 	return "", false
 }
+
+// ─── Deposit-address identification (trace exits) ────────────────────
+//
+// DetectExchangeExit looks at a single transaction. During a trace the
+// tracer also sees how an address's funds leave it, which identifies
+// exchange deposit addresses without manual tagging:
+//
+//   - Label: a known exchange address, an "exchange" address label
+//     (address_labels), or an address tagged "exchange" (investigation
+//     tags, warm-loaded from the DB into the watchlist)
+//   - Sweep: the deposit is consolidated with many other addresses'
+//     UTXOs (≥ depositSweepMinInputs) into 1-2 outputs — exchanges sweep
+//     customer deposit addresses into a hot wallet
+//   - Batch payer: the sweep's destination pays out in batches
+//     (≥ batchPayoutMinOutputs outputs), the hot wallet's withdrawals
+//
+// References:
+//   - Meiklejohn et al., "A Fistful of Bitcoins" (IMC 2013) — deposit sweeps
+//   - Kappos et al., "How to Peel a Million" (USENIX Security 2022)
+
+const (
+	depositSweepMinInputs    = 5    // Distinct input addresses consolidated by a sweep
+	depositSweepMaxOutputs   = 2    // Hot wallet (+ rare change) outputs of a sweep
+	batchPayoutMinOutputs    = 10   // Outputs of an exchange withdrawal batch
+	depositLabelConfidence   = 0.95 // Labeled exchange address
+	depositSweepConfidence   = 0.6  // Swept into a consolidation
+	depositBatcherConfidence = 0.8  // Swept into a wallet that pays out in batches
+)
+
+// ExchangeDepositSignal classifies an address as an exchange deposit
+type ExchangeDepositSignal struct {
+	IsDeposit    bool    `json:"isDeposit"`
+	ExchangeName string  `json:"exchangeName"`
+	Confidence   float64 `json:"confidence"`
+	Method       string  `json:"method"`              // "label"/"sweep"/"sweep_to_batch_payer"
+	HotWallet    string  `json:"hotWallet,omitempty"` // Sweep destination
+
+	sweepHeight int // Block of the sweep (where the hot wallet's spends start)
+}
+
+// labeledExchange returns the address_labels entry of an exchange address
+func labeledExchange(addr string) (models.AddressLabel, bool) {
+	l, ok := GetGlobalAddressLabels().Get(addr)
+	return l, ok && l.Category == LabelCategoryExchange
+}
+
+// ExchangeLabel returns the exchange name of a known, labeled or
+// investigator-tagged exchange address
+func ExchangeLabel(addr string) (string, bool) {
+	if exchange, ok := IsKnownExchangeAddress(addr); ok {
+		return exchange, true
+	}
+	if l, ok := labeledExchange(addr); ok {
+		return l.Label, true
+	}
+	if entry, ok := GetGlobalAddressWatchlist().Get(addr); ok && entry.Category == "exchange" {
+		if entry.Label != "" {
+			return entry.Label, true
+		}
+		return "exchange (tagged)", true
+	}
+	return "", false
+}
+
+// ClassifyExchangeDeposit classifies addr from its label and the
+// transactions spending it. The batch-payer signal needs the hot wallet's
+// own spends, so the tracer upgrades a sweep afterwards (classifyTraceExit).
+func ClassifyExchangeDeposit(addr string, spends []models.Transaction) ExchangeDepositSignal {
+	if exchange, ok := ExchangeLabel(addr); ok {
+		return ExchangeDepositSignal{IsDeposit: true, ExchangeName: exchange, Confidence: depositLabelConfidence, Method: "label"}
+	}
+
+	for _, tx := range spends {
+		if hotWallet, ok := depositSweep(tx, addr); ok {
+			name := "unknown exchange (deposit sweep)"
+			if exchange, known := ExchangeLabel(hotWallet); known {
+				name = exchange
+			}
+			return ExchangeDepositSignal{
+				IsDeposit:    true,
+				ExchangeName: name,
+				Confidence:   depositSweepConfidence,
+				Method:       "sweep",
+				HotWallet:    hotWallet,
+				sweepHeight:  tx.BlockHeight,
+			}
+		}
+	}
+	return ExchangeDepositSignal{}
+}
+
+// depositSweep reports whether tx consolidates addr with many other
+// addresses into at most two outputs, returning the largest output
+func depositSweep(tx models.Transaction, addr string) (string, bool) {
+	if len(tx.Outputs) == 0 || len(tx.Outputs) > depositSweepMaxOutputs || len(tx.Inputs) < depositSweepMinInputs {
+		return "", false
+	}
+	distinct := make(map[string]bool, len(tx.Inputs))
+	for _, in := range tx.Inputs {
+		if in.Address != "" {
+			distinct[in.Address] = true
+		}
+	}
+	if !distinct[addr] || len(distinct) < depositSweepMinInputs {
+		return "", false
+	}
+
+	hotWallet := tx.Outputs[0]
+	for _, out := range tx.Outputs[1:] {
+		if out.Value > hotWallet.Value {
+			hotWallet = out
+		}
+	}
+	return hotWallet.Address, hotWallet.Address != ""
+}
+
+// isBatchPayout reports whether tx pays many distinct recipients at once
+func isBatchPayout(tx models.Transaction) bool {
+	if len(tx.Outputs) < batchPayoutMinOutputs {
+		return false
+	}
+	recipients := make(map[string]bool, len(tx.Outputs))
+	for _, out := range tx.Outputs {
+		if out.Address != "" {
+			recipients[out.Address] = true
+		}
+	}
+	return len(recipients) >= batchPayoutMinOutputs
+}
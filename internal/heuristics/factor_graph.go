@@ -24,7 +24,8 @@ import (
 
 // EvaluateFactorGraph takes a set of evidence edges and produces a calibrated
 // posterior belief by grouping edges by dependency_group, fusing correlated
-// signals, and summing independent LLR contributions.
+// signals, and summing independent LLR contributions. Edges without a
+// dependency group (DepGroupNone) are independent and each contribute.
 //
 // Mathematical basis:
 //
@@ -38,9 +39,33 @@ func EvaluateFactorGraph(edges []models.EvidenceEdge) models.InferenceResult {
 		}
 	}
 
+	posteriorLLR, discounted, factors := fuseDependencyGroups(edges)
+
+	// Classify confidence level based on posterior LLR magnitude
+	confidenceLevel := classifyConfidence(posteriorLLR)
+
+	return models.InferenceResult{
+		PosteriorLLR:     posteriorLLR,
+		ConfidenceLevel:  confidenceLevel,
+		DiscountedEdges:  discounted,
+		TotalEdges:       len(edges),
+		EffectiveFactors: factors,
+	}
+}
+
+// fuseDependencyGroups sums one factor per dependency group, each the
+// strongest (clamped) edge of its group; the rest of the group is
+// discounted. DepGroupNone edges are independent variables, so each is a
+// factor of its own.
+func fuseDependencyGroups(edges []models.EvidenceEdge) (llr float64, discounted, factors int) {
 	// Group edges by dependency_group
 	groups := make(map[int][]models.EvidenceEdge)
 	for _, edge := range edges {
+		if edge.DependencyGroup == DepGroupNone {
+			llr += ClampLLR(edge.LLRScore)
+			factors++
+			continue
+		}
 		groups[edge.DependencyGroup] = append(groups[edge.DependencyGroup], edge)
 	}
 
@@ -48,39 +73,22 @@ func EvaluateFactorGraph(edges []models.EvidenceEdge) models.InferenceResult {
 	// Strategy: take the MAXIMUM LLR within each group (conservative fusion).
 	// This prevents double-counting: correlated features contribute at most
 	// the strength of the strongest single feature.
-	var posteriorLLR float64
-	discounted := 0
-
 	for _, groupEdges := range groups {
-		if len(groupEdges) == 0 {
-			continue
-		}
-
-		// Find the strongest signal in this dependency group
-		maxLLR := groupEdges[0].LLRScore
+		// Find the strongest signal in this dependency group. Each edge is
+		// bounded to ±MaxEdgeLLR so one saturated edge cannot swamp the rest.
+		maxLLR := ClampLLR(groupEdges[0].LLRScore)
 		for _, edge := range groupEdges[1:] {
-			if math.Abs(edge.LLRScore) > math.Abs(maxLLR) {
-				maxLLR = edge.LLRScore
+			if l := ClampLLR(edge.LLRScore); math.Abs(l) > math.Abs(maxLLR) {
+				maxLLR = l
 			}
 		}
 
 		// The remaining edges in this group are discounted (fused)
 		discounted += len(groupEdges) - 1
-
-		// Add the group's representative to the posterior
-		posteriorLLR += maxLLR
-	}
-
-	// Classify confidence level based on posterior LLR magnitude
-	confidenceLevel := classifyConfidence(posteriorLLR)
-
-	return models.InferenceResult{
-		PosteriorLLR:     posteriorLLR,
-		ConfidenceLevel:  confidenceLevel,
-		DiscountedEdges:  discounted,
-		TotalEdges:       len(edges),
-		EffectiveFactors: len(groups),
+		llr += maxLLR
+		factors++
 	}
+	return llr, discounted, factors
 }
 
 // classifyConfidence maps the posterior LLR to a human-readable confidence band.
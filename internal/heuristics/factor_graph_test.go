@@ -115,3 +115,51 @@ func TestComputeClusterPosterior_ShouldReject(t *testing.T) {
 		t.Error("Expected cluster to be rejected with weak evidence")
 	}
 }
+
+func TestPropagateEvidence_GatingChainBounded(t *testing.T) {
+	// Three deterministic (p=1) hops used to sum to 2997 before decay
+	chain := []models.EvidenceEdge{
+		{EdgeID: "tx1", SrcNodeID: "A", DstNodeID: "B", EdgeType: EdgeTypeCIOH, LLRScore: ProbToLLR(1.0)},
+		{EdgeID: "tx2", SrcNodeID: "B", DstNodeID: "C", EdgeType: EdgeTypeCIOH, LLRScore: ProbToLLR(1.0)},
+		{EdgeID: "tx3", SrcNodeID: "C", DstNodeID: "D", EdgeType: EdgeTypeCIOH, LLRScore: 999}, // Legacy persisted score
+	}
+
+	prop := PropagateEvidence(chain, DefaultHopDecay)
+	if prop == nil {
+		t.Fatal("Expected a propagated edge for a strong chain")
+	}
+	if prop.TotalLLR > 3*MaxEdgeLLR+1e-9 {
+		t.Errorf("Expected the chain sum bounded by 3 × MaxEdgeLLR. Got %.2f", prop.TotalLLR)
+	}
+	if math.IsInf(prop.DecayedLLR, 0) || math.IsNaN(prop.DecayedLLR) || prop.DecayedLLR > 3*MaxEdgeLLR {
+		t.Errorf("Expected a finite decayed LLR. Got %v", prop.DecayedLLR)
+	}
+	if prop.Confidence > 1-LLRProbEpsilon || prop.Confidence < 0.99 {
+		t.Errorf("Expected a high but non-certain posterior. Got %v", prop.Confidence)
+	}
+}
+
+func TestEvaluateFactorGraph_SaturatedEdgesClamped(t *testing.T) {
+	// A certain CIOH edge against a certain CoinJoin gate should cancel,
+	// not leave ±999 residue from whichever sentinel wins
+	edges := []models.EvidenceEdge{
+		{EdgeType: 1, LLRScore: ProbToLLR(1.0), DependencyGroup: DepGroupNone},
+		{EdgeType: 4, LLRScore: -999, DependencyGroup: DepGroupCoordination},
+		{EdgeType: 2, LLRScore: math.Inf(1), DependencyGroup: DepGroupValueConstraints},
+	}
+
+	res := EvaluateFactorGraph(edges)
+	if math.IsInf(res.PosteriorLLR, 0) || math.IsNaN(res.PosteriorLLR) {
+		t.Fatalf("Expected a finite posterior. Got %v", res.PosteriorLLR)
+	}
+	if math.Abs(res.PosteriorLLR-MaxEdgeLLR) > 1e-9 {
+		t.Errorf("Expected +MaxEdgeLLR − MaxEdgeLLR + MaxEdgeLLR. Got %.4f", res.PosteriorLLR)
+	}
+
+	for _, p := range []float64{0, 1e-9, 0.3, 0.999, 1} {
+		want := math.Max(LLRProbEpsilon, math.Min(1-LLRProbEpsilon, p))
+		if got := LLRToProb(ProbToLLR(p)); math.Abs(got-want) > 1e-9 {
+			t.Errorf("Expected LLRToProb(ProbToLLR(%v)) = %v. Got %v", p, want, got)
+		}
+	}
+}
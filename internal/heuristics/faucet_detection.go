@@ -0,0 +1,254 @@
+package heuristics
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Faucet / Airdrop Service Detection (cross-transaction)
+//
+// Faucets, airdrop distributors and reward bots run a hot wallet that, tx
+// after tx, pays one fixed small amount to a fresh address and sends the
+// remainder back to itself. Each transaction on its own is an ordinary
+// payment — often tripping bot-timing, service-value and low-privacy risk
+// signals — so the pattern is only visible per source across many txs:
+//
+//   - Same source cluster: inputs merged by CIOH, and each tx's change
+//     linked back to the source so a chained hot wallet stays one entity
+//   - Near-identical payouts: most payouts within faucetValueTolerance of
+//     the source's typical payout, all ≤ faucetMaxPayout
+//   - Fresh recipients: payout addresses never seen before by the tracker
+//   - Faucet: ~1 payout per tx; airdrop: batched payouts per tx
+//
+// Classified sources are benign services: callers discount the clustering-
+// driven risk signals of their payouts (see DiscountServiceNoise).
+//
+// References:
+//   - Meiklejohn et al., "A Fistful of Bitcoins" (IMC 2013) — service tagging
+//   - Möser & Böhme, "The Price of Anonymity" (2017) — hot wallet change linkage
+//   - Kalodner et al., "BlockSci" (USENIX Security 2020) — payout pattern tags
+
+const (
+	faucetMaxPayout       = 100_000 // sats: larger payouts are payments, not drips
+	faucetValueTolerance  = 0.05    // Relative distance from the typical payout
+	faucetMinTxs          = 4       // Payout txs before a source can be classified
+	faucetMinUniformRatio = 0.8     // Fraction of payouts near the typical value
+	faucetMinFreshRatio   = 0.9     // Fraction of payouts to never-seen addresses
+	faucetAirdropBatch    = 1.5     // Mean payouts per tx above which it is an airdrop
+	faucetHistoryLimit    = 256     // Payout values retained per source
+	faucetMaxAddresses    = 500_000 // Tracked addresses before the tracker resets
+)
+
+// ServicePattern is the cross-tx classification of a payout source
+type ServicePattern struct {
+	Source       string  `json:"source"`       // Source cluster root address
+	IsService    bool    `json:"isService"`    // Classified as faucet/airdrop
+	Kind         string  `json:"kind"`         // "faucet"/"airdrop"/"" (unclassified)
+	TxCount      int     `json:"txCount"`      // Payout transactions observed
+	Payouts      int     `json:"payouts"`      // Payout outputs observed
+	TypicalValue int64   `json:"typicalValue"` // Median payout (sats)
+	UniformRatio float64 `json:"uniformRatio"` // Payouts near the typical value
+	FreshRatio   float64 `json:"freshRatio"`   // Payouts to fresh addresses
+	Confidence   float64 `json:"confidence"`   // 0-1
+}
+
+// payoutSource accumulates payout history for one source cluster
+type payoutSource struct {
+	txCount int
+	payouts int
+	fresh   int
+	values  []int64 // Most recent payout values (≤ faucetHistoryLimit)
+}
+
+// ServicePatternTracker classifies faucet/airdrop sources across transactions
+type ServicePatternTracker struct {
+	mu       sync.Mutex
+	clusters *ClusterEngine
+	seen     map[string]bool
+	sources  map[string]*payoutSource
+}
+
+// NewServicePatternTracker creates an empty tracker
+func NewServicePatternTracker() *ServicePatternTracker {
+	return &ServicePatternTracker{
+		clusters: NewClusterEngine(),
+		seen:     make(map[string]bool),
+		sources:  make(map[string]*payoutSource),
+	}
+}
+
+// Observe records a transaction and returns its source's classification.
+// gated marks CoinJoin/PayJoin txs, whose inputs span several owners: they
+// are neither clustered nor counted as payouts.
+func (t *ServicePatternTracker) Observe(tx models.Transaction, gated bool) ServicePattern {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.seen) > faucetMaxAddresses {
+		t.clusters = NewClusterEngine()
+		t.seen = make(map[string]bool)
+		t.sources = make(map[string]*payoutSource)
+	}
+
+	source := ""
+	if len(tx.Inputs) > 0 {
+		source = tx.Inputs[0].Address
+	}
+	if gated || source == "" {
+		t.markSeen(tx)
+		return ServicePattern{}
+	}
+
+	t.clusters.MergeFromTransaction(tx, false)
+	root := t.clusters.Find(source)
+
+	change, payouts, ok := t.splitPayouts(tx, root)
+	if ok {
+		if change != "" {
+			t.clusters.Union(source, change)
+			root = t.clusters.Find(source)
+		}
+		t.recordPayouts(root, payouts)
+	}
+	t.markSeen(tx)
+
+	return t.classify(root)
+}
+
+// Classify returns the current classification of addr's source cluster
+func (t *ServicePatternTracker) Classify(addr string) ServicePattern {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.clusters.Contains(addr) {
+		return ServicePattern{}
+	}
+	return t.classify(t.clusters.Find(addr))
+}
+
+// splitPayouts separates a tx's outputs into the source's change and its
+// payouts. Outputs already in the source cluster are change; otherwise,
+// with several outputs, the largest is taken as the hot wallet's change.
+// ok is false when any payout exceeds faucetMaxPayout.
+func (t *ServicePatternTracker) splitPayouts(tx models.Transaction, root string) (string, []models.TxOut, bool) {
+	changeIdx := -1
+	for i, out := range tx.Outputs {
+		if out.Address != "" && t.clusters.Contains(out.Address) && t.clusters.Find(out.Address) == root {
+			changeIdx = i
+			break
+		}
+	}
+	if changeIdx < 0 && len(tx.Outputs) >= 2 {
+		changeIdx = 0
+		for i, out := range tx.Outputs {
+			if out.Value > tx.Outputs[changeIdx].Value {
+				changeIdx = i
+			}
+		}
+	}
+
+	change := ""
+	var payouts []models.TxOut
+	for i, out := range tx.Outputs {
+		if i == changeIdx {
+			change = out.Address
+			continue
+		}
+		if isOPReturn(out.ScriptPubKey) || out.Address == "" {
+			continue
+		}
+		if out.Value <= 0 || out.Value > faucetMaxPayout {
+			return "", nil, false
+		}
+		payouts = append(payouts, out)
+	}
+	return change, payouts, len(payouts) > 0
+}
+
+// recordPayouts appends a payout tx to the source's history
+func (t *ServicePatternTracker) recordPayouts(root string, payouts []models.TxOut) {
+	src, ok := t.sources[root]
+	if !ok {
+		src = &payoutSource{}
+		t.sources[root] = src
+	}
+	src.txCount++
+	for _, out := range payouts {
+		src.payouts++
+		if !t.seen[out.Address] {
+			src.fresh++
+		}
+		src.values = append(src.values, out.Value)
+	}
+	if over := len(src.values) - faucetHistoryLimit; over > 0 {
+		src.values = src.values[over:]
+	}
+}
+
+// markSeen records every address the tx touches (freshness baseline)
+func (t *ServicePatternTracker) markSeen(tx models.Transaction) {
+	for _, in := range tx.Inputs {
+		if in.Address != "" {
+			t.seen[in.Address] = true
+		}
+	}
+	for _, out := range tx.Outputs {
+		if out.Address != "" {
+			t.seen[out.Address] = true
+		}
+	}
+}
+
+// classify scores a source cluster's payout history
+func (t *ServicePatternTracker) classify(root string) ServicePattern {
+	p := ServicePattern{Source: root}
+	src, ok := t.sources[root]
+	if !ok || src.payouts == 0 {
+		return p
+	}
+	p.TxCount = src.txCount
+	p.Payouts = src.payouts
+	p.FreshRatio = float64(src.fresh) / float64(src.payouts)
+
+	sorted := append([]int64(nil), src.values...)
+	sort.Slice(sorted, func(a, b int) bool { return sorted[a] < sorted[b] })
+	p.TypicalValue = sorted[len(sorted)/2]
+
+	near := 0
+	tolerance := float64(p.TypicalValue) * faucetValueTolerance
+	for _, v := range sorted {
+		diff := float64(v - p.TypicalValue)
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff <= tolerance {
+			near++
+		}
+	}
+	p.UniformRatio = float64(near) / float64(len(sorted))
+
+	if p.TxCount < faucetMinTxs || p.UniformRatio < faucetMinUniformRatio || p.FreshRatio < faucetMinFreshRatio {
+		return p
+	}
+
+	p.IsService = true
+	p.Kind = "faucet"
+	if float64(p.Payouts)/float64(p.TxCount) > faucetAirdropBatch {
+		p.Kind = "airdrop"
+	}
+
+	conf := 0.6 + 0.2*(p.UniformRatio-faucetMinUniformRatio)/(1-faucetMinUniformRatio)
+	if p.TxCount >= 2*faucetMinTxs {
+		conf += 0.1
+	}
+	if p.FreshRatio == 1 {
+		conf += 0.05
+	}
+	if conf > 0.95 {
+		conf = 0.95
+	}
+	p.Confidence = conf
+	return p
+}
@@ -0,0 +1,144 @@
+package heuristics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// faucetPayout builds hot-wallet tx i: spends the previous change, pays each
+// recipient `payout` sats and returns the rest to a new change address.
+func faucetPayout(i int, payout int64, recipients ...string) models.Transaction {
+	in := fmt.Sprintf("hot_change_%d", i-1)
+	if i == 0 {
+		in = "hot_wallet"
+	}
+	balance := int64(10_000_000 - i*200_000)
+	outs := []models.TxOut{}
+	for _, r := range recipients {
+		outs = append(outs, models.TxOut{Address: r, Value: payout})
+		balance -= payout
+	}
+	outs = append(outs, models.TxOut{Address: fmt.Sprintf("hot_change_%d", i), Value: balance - 500})
+	return models.Transaction{
+		Txid:    fmt.Sprintf("faucet_%d", i),
+		Inputs:  []models.TxIn{{Address: in, Value: 10_000_000 - int64(i)*200_000}},
+		Outputs: outs,
+	}
+}
+
+func TestServicePattern_FaucetChainedHotWallet(t *testing.T) {
+	tracker := NewServicePatternTracker()
+
+	var last ServicePattern
+	for i := 0; i < 6; i++ {
+		// Slight jitter, as faucets paying a fixed USD amount do
+		payout := int64(5_000 + (i%2)*100)
+		last = tracker.Observe(faucetPayout(i, payout, fmt.Sprintf("user_%d", i)), false)
+		if i < faucetMinTxs-1 && last.IsService {
+			t.Fatalf("Classified after only %d txs", i+1)
+		}
+	}
+
+	if !last.IsService || last.Kind != "faucet" {
+		t.Fatalf("Expected faucet classification. Got %+v", last)
+	}
+	if last.TxCount != 6 || last.Payouts != 6 {
+		t.Errorf("Expected 6 txs / 6 payouts on one source. Got %d / %d", last.TxCount, last.Payouts)
+	}
+	if last.FreshRatio != 1 || last.UniformRatio != 1 {
+		t.Errorf("Expected all payouts fresh and uniform. Got fresh=%.2f uniform=%.2f", last.FreshRatio, last.UniformRatio)
+	}
+
+	// Every address of the chained hot wallet resolves to the same service
+	if got := tracker.Classify("hot_change_3"); !got.IsService || got.Source != last.Source {
+		t.Errorf("Expected hot_change_3 in the faucet source cluster. Got %+v", got)
+	}
+	if got := tracker.Classify("user_2"); got.IsService {
+		t.Errorf("Recipient must not inherit the service classification. Got %+v", got)
+	}
+}
+
+func TestServicePattern_AirdropBatches(t *testing.T) {
+	tracker := NewServicePatternTracker()
+
+	var last ServicePattern
+	for i := 0; i < 4; i++ {
+		recipients := make([]string, 5)
+		for j := range recipients {
+			recipients[j] = fmt.Sprintf("claimant_%d_%d", i, j)
+		}
+		last = tracker.Observe(faucetPayout(i, 20_000, recipients...), false)
+	}
+
+	if !last.IsService || last.Kind != "airdrop" {
+		t.Fatalf("Expected airdrop classification. Got %+v", last)
+	}
+	if last.TypicalValue != 20_000 {
+		t.Errorf("Expected typical payout 20000. Got %d", last.TypicalValue)
+	}
+}
+
+func TestServicePattern_RejectsRepeatAndVariedPayees(t *testing.T) {
+	// Same small amount, but always to the same recipient: a subscription, not a faucet
+	repeat := NewServicePatternTracker()
+	var got ServicePattern
+	for i := 0; i < 6; i++ {
+		got = repeat.Observe(faucetPayout(i, 5_000, "landlord"), false)
+	}
+	if got.IsService {
+		t.Errorf("Expected repeat payee to stay unclassified. Got %+v", got)
+	}
+
+	// Fresh recipients, but unrelated amounts: an ordinary spender
+	varied := NewServicePatternTracker()
+	for i := 0; i < 6; i++ {
+		got = varied.Observe(faucetPayout(i, int64(3_000+i*9_000), fmt.Sprintf("shop_%d", i)), false)
+	}
+	if got.IsService {
+		t.Errorf("Expected varied payouts to stay unclassified. Got %+v", got)
+	}
+
+	// Large payouts are payments, not drips
+	large := NewServicePatternTracker()
+	for i := 0; i < 6; i++ {
+		got = large.Observe(faucetPayout(i, 1_000_000, fmt.Sprintf("vendor_%d", i)), false)
+	}
+	if got.IsService || got.TxCount != 0 {
+		t.Errorf("Expected large payouts to be ignored. Got %+v", got)
+	}
+}
+
+func TestServicePattern_GatedTxsNotCounted(t *testing.T) {
+	tracker := NewServicePatternTracker()
+	for i := 0; i < 6; i++ {
+		if got := tracker.Observe(faucetPayout(i, 5_000, fmt.Sprintf("user_%d", i)), true); got.IsService || got.TxCount != 0 {
+			t.Fatalf("Gated tx must not be recorded. Got %+v", got)
+		}
+	}
+}
+
+func TestDiscountServiceNoise(t *testing.T) {
+	a := ThreatAssessment{
+		RiskScore: 38,
+		Signals:   []string{"bot_pattern", "known_service_pattern", "low_privacy_score", "taint_exposure"},
+	}
+	a.Severity = classifySeverity(a.RiskScore)
+
+	got := DiscountServiceNoise(a, ServicePattern{IsService: true, Kind: "faucet"})
+	if got.RiskScore != 20 {
+		t.Errorf("Expected 38-10-5-3 = 20. Got %d", got.RiskScore)
+	}
+	if got.Severity != "low" {
+		t.Errorf("Expected severity to drop to low. Got %s", got.Severity)
+	}
+	want := []string{"taint_exposure", "service:faucet"}
+	if fmt.Sprint(got.Signals) != fmt.Sprint(want) {
+		t.Errorf("Expected signals %v. Got %v", want, got.Signals)
+	}
+
+	if unchanged := DiscountServiceNoise(a, ServicePattern{}); unchanged.RiskScore != 38 {
+		t.Errorf("Unclassified source must not discount. Got %d", unchanged.RiskScore)
+	}
+}
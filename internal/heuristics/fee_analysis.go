@@ -38,11 +38,11 @@ func AnalyzeFeePattern(tx models.Transaction) models.FeeAnalysisResult {
 
 	// 1. Compute fee rate (sat/vB)
 	if tx.Vsize > 0 {
-		result.FeeRate = math.Round(float64(tx.Fee)*100/float64(tx.Vsize)) / 100
+		result.FeeRate = float64(tx.Fee) / float64(tx.Vsize)
 	} else if tx.Weight > 0 {
 		// Fallback: estimate vsize from weight
 		vsize := (tx.Weight + 3) / 4
-		result.FeeRate = math.Round(float64(tx.Fee)*100/float64(vsize)) / 100
+		result.FeeRate = float64(tx.Fee) / float64(vsize)
 	}
 
 	// 2. Classify fee rate tier
@@ -171,8 +171,7 @@ func computeOverpayRatio(tx models.Transaction) float64 {
 		return 1.0
 	}
 
-	ratio := float64(tx.Fee) / float64(minFee)
-	return math.Round(ratio*100) / 100
+	return float64(tx.Fee) / float64(minFee)
 }
 
 // inferWalletFromFee combines fee signals to infer wallet software.
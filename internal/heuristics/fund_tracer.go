@@ -1,7 +1,14 @@
 package heuristics
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"math/bits"
+	"sort"
 	"time"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
 )
 
 // Fund Flow Tracer — Incident Response Core
@@ -35,7 +42,13 @@ type FlowGraph struct {
 	MaxHopReached   int        `json:"maxHopReached"`   // Deepest hop reached
 	ExchangeExits   int        `json:"exchangeExits"`   // Number of exchange cash-outs found
 	MixersPassed    int        `json:"mixersPassed"`    // Number of CoinJoins traversed
+	BridgeExits     int        `json:"bridgeExits"`     // Number of cross-chain bridge exits found
+	BurnedValue     int64      `json:"burnedValue"`     // Sats sent to provably-unspendable outputs
+	UnspentValue    int64      `json:"unspentValue"`    // Live UTXO balance across endpoint addresses
+	Truncated       bool       `json:"truncated"`       // Trace was cancelled or timed out before completing
 	CreatedAt       time.Time  `json:"createdAt"`
+
+	clusters *ClusterEngine // CIOH merges observed while tracing
 }
 
 // FlowNode represents a single address in the flow graph
@@ -44,10 +57,14 @@ type FlowNode struct {
 	HopNumber     int     `json:"hopNumber"`       // Distance from theft
 	ValueReceived int64   `json:"valueReceived"`   // Total sats received
 	ValueSent     int64   `json:"valueSent"`       // Total sats sent onward
-	Role          string  `json:"role"`            // "theft"/"intermediate"/"mixer"/"exchange"/"unspent"/"unknown"
+	Role          string  `json:"role"`            // "theft"/"intermediate"/"mixer"/"exchange"/"bridge-exit"/"burned"/"unspent"/"unknown"
 	Label         string  `json:"label,omitempty"` // Custom label (e.g., "Binance Hot Wallet")
 	RiskScore     float64 `json:"riskScore"`       // 0.0-1.0 from taint analysis
 	IsFlagged     bool    `json:"isFlagged"`       // Manually flagged by investigator
+
+	// Live UTXO balance of an endpoint (unspent, unexpanded or source
+	// address), when the chain source implements UnspentBalanceSource
+	UnspentBalance int64 `json:"unspentBalance,omitempty"`
 }
 
 // FlowEdge represents a single fund movement between addresses
@@ -60,6 +77,9 @@ type FlowEdge struct {
 	IsCoinJoin  bool      `json:"isCoinJoin"` // Went through a mixer
 	Confidence  float64   `json:"confidence"` // 0-1, lower for CoinJoin penetration
 	Timestamp   time.Time `json:"timestamp"`
+
+	// Set on mixer → output edges recovered by PenetrateCoinjoin
+	PenetrationMethod string `json:"penetrationMethod,omitempty"`
 }
 
 // TraceConfig controls the tracing behavior
@@ -69,6 +89,8 @@ type TraceConfig struct {
 	MinValue        int64   `json:"minValue"`        // Minimum value to trace (ignore dust)
 	MinConfidence   float64 `json:"minConfidence"`   // Minimum confidence to continue (default: 0.3)
 	PenetrateMixers bool    `json:"penetrateMixers"` // Attempt to trace through CoinJoins
+
+	DetectExchangeDeposits bool `json:"detectExchangeDeposits"` // Auto-flag deposit addresses (ClassifyExchangeDeposit)
 }
 
 // DefaultTraceConfig returns sensible defaults for fund tracing
@@ -79,21 +101,75 @@ func DefaultTraceConfig() TraceConfig {
 		MinValue:        10000, // Ignore < 10,000 sats (dust)
 		MinConfidence:   0.3,
 		PenetrateMixers: true,
+
+		DetectExchangeDeposits: true,
 	}
 }
 
-// TraceFundFlow builds the complete flow graph from source addresses.
-// In a full implementation, this would query the Bitcoin node for
-// transaction data at each hop. This implementation provides the
-// framework and data structures for the tracing engine.
-func TraceFundFlow(sourceAddresses []string, config TraceConfig) FlowGraph {
+// maxPenetrationConfidence caps a penetrated output's linkage: agreeing
+// heuristics raise confidence, but a link through a mix is never certain
+const maxPenetrationConfidence = 0.9
+
+// TraceChainSource abstracts the chain queries the tracer needs.
+// *bitcoin.Client implements it using compact block filters (scanblocks)
+// and getrawtransaction; tests substitute an in-memory chain.
+type TraceChainSource interface {
+	// FindSpendingTxs returns confirmed transactions spending outputs paid
+	// to addr, in block order, scanning from fromHeight onward. Input
+	// values and addresses must be populated.
+	FindSpendingTxs(ctx context.Context, addr string, fromHeight int) ([]models.Transaction, error)
+}
+
+// UnspentBalanceSource is implemented by chain sources that can report the
+// live UTXO balance of addresses. *bitcoin.Client scans the UTXO set
+// (scantxoutset), so no wallet import is needed.
+type UnspentBalanceSource interface {
+	UnspentBalances(ctx context.Context, addrs []string) (map[string]int64, error)
+}
+
+// traceFrontier is a pending address in the breadth-first walk
+type traceFrontier struct {
+	address    string
+	hop        int
+	fromHeight int     // Height at which the address was funded
+	confidence float64 // Path confidence from the source
+}
+
+// TraceFundFlow builds the complete flow graph from source addresses by
+// walking the chain hop-by-hop (breadth-first):
+//
+//  1. For each frontier address, find the transactions spending its outputs
+//  2. Ordinary spends: follow outputs (largest first, up to MaxBranches),
+//     attributing value proportionally to the tracked share of the inputs
+//  3. CoinJoin spends: record the mixer entry; with PenetrateMixers, hand
+//     off to PenetrateCoinjoin and continue from the outputs it links to
+//     the tracked inputs (confidence × output confidence), otherwise the
+//     path ends at the mixer
+//  4. Stop at exchange deposits (known or tagged addresses; with
+//     DetectExchangeDeposits also addresses swept into an exchange hot
+//     wallet, see ClassifyExchangeDeposit), cross-chain bridge deposits (funds
+//     left the BTC chain), burn outputs, unspent outputs, MaxHops, or when
+//     value/confidence falls below MinValue/MinConfidence
+//
+// If the source implements UnspentBalanceSource, the endpoints of a
+// completed walk (unspent, unexpanded and source addresses) are annotated
+// with their live UTXO balance.
+//
+// If ctx is cancelled (or its deadline passes) the walk stops between
+// addresses and the partial graph is returned with Truncated set. Each
+// spending transaction is recorded in full or not at all.
+func TraceFundFlow(ctx context.Context, source TraceChainSource, sourceAddresses []string, config TraceConfig) FlowGraph {
 	graph := FlowGraph{
 		SourceAddresses: sourceAddresses,
 		CreatedAt:       time.Now(),
 	}
 
 	// Initialize source nodes
+	var queue []traceFrontier
 	for _, addr := range sourceAddresses {
+		if graph.hasNode(addr) {
+			continue
+		}
 		graph.Nodes = append(graph.Nodes, FlowNode{
 			Address:   addr,
 			HopNumber: 0,
@@ -101,11 +177,294 @@ func TraceFundFlow(sourceAddresses []string, config TraceConfig) FlowGraph {
 			RiskScore: 1.0, // Theft address = maximum risk
 			IsFlagged: true,
 		})
+		queue = append(queue, traceFrontier{address: addr, confidence: 1.0})
+	}
+
+	if source == nil {
+		return graph
+	}
+
+	expanded := make(map[string]bool)
+	seenEdges := make(map[string]bool)
+
+	for len(queue) > 0 {
+		if ctx.Err() != nil {
+			break
+		}
+
+		cur := queue[0]
+		queue = queue[1:]
+
+		if expanded[cur.address] || cur.hop >= config.MaxHops {
+			continue
+		}
+		expanded[cur.address] = true
+
+		spends, err := source.FindSpendingTxs(ctx, cur.address, cur.fromHeight)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			log.Printf("[FundTracer] Failed to find spends of %s: %v", cur.address, err)
+			continue
+		}
+
+		if len(spends) == 0 {
+			graph.markUnspent(cur.address)
+			continue
+		}
+
+		if config.DetectExchangeDeposits && cur.hop > 0 {
+			if dep := classifyTraceExit(ctx, source, cur.address, spends); dep.IsDeposit {
+				graph.markExchangeOnce(cur.address, dep.ExchangeName)
+				log.Printf("[FundTracer] %s identified as %s deposit (%s, %.2f)", cur.address, dep.ExchangeName, dep.Method, dep.Confidence)
+				continue // Cash-out point: the sweep mixes in other customers' funds
+			}
+		}
+
+		for _, tx := range spends {
+			next := graph.traceSpend(tx, cur, config, seenEdges)
+			queue = append(queue, next...)
+		}
+	}
+
+	if ctx.Err() != nil {
+		graph.Truncated = true
+		log.Printf("[FundTracer] Trace truncated at hop %d: %v", graph.MaxHopReached, ctx.Err())
+		return graph
+	}
+
+	if utxos, ok := source.(UnspentBalanceSource); ok {
+		graph.annotateUnspent(ctx, utxos, expanded)
 	}
 
 	return graph
 }
 
+// annotateUnspent records the live UTXO balance of the graph's endpoints:
+// addresses whose funds never moved, addresses the walk did not expand
+// (MaxHops) and the source addresses
+func (g *FlowGraph) annotateUnspent(ctx context.Context, source UnspentBalanceSource, expanded map[string]bool) {
+	var endpoints []string
+	for _, node := range g.Nodes {
+		switch {
+		case node.Role == "unspent", node.Role == "theft":
+		case node.Role == "intermediate" && !expanded[node.Address]:
+		default:
+			continue
+		}
+		endpoints = append(endpoints, node.Address)
+	}
+	if len(endpoints) == 0 {
+		return
+	}
+
+	balances, err := source.UnspentBalances(ctx, endpoints)
+	if err != nil {
+		log.Printf("[FundTracer] Failed to scan UTXOs of %d endpoint(s): %v", len(endpoints), err)
+		return
+	}
+	for i := range g.Nodes {
+		if bal := balances[g.Nodes[i].Address]; bal > 0 {
+			g.Nodes[i].UnspentBalance = bal
+			g.UnspentValue += bal
+		}
+	}
+}
+
+// traceSpend records the flows of one spending transaction and returns the
+// destination addresses that should be expanded on the next hop.
+func (g *FlowGraph) traceSpend(tx models.Transaction, cur traceFrontier, config TraceConfig, seenEdges map[string]bool) []traceFrontier {
+	var trackedIdx []int
+	trackedValue := int64(0)
+	totalIn := int64(0)
+	for i, in := range tx.Inputs {
+		totalIn += in.Value
+		if in.Address == cur.address {
+			trackedIdx = append(trackedIdx, i)
+			trackedValue += in.Value
+		}
+	}
+	if len(trackedIdx) == 0 || trackedValue <= 0 || totalIn <= 0 {
+		return nil
+	}
+
+	// CIOH: co-spent inputs share an owner unless the tx is collaborative
+	flags := AnalyzeTx(tx).HeuristicFlags
+	isCoinJoin := IsCoinJoinResult(flags)
+	if !isCoinJoin && flags&FlagIsPayjoinSuspect == 0 {
+		g.Clusters().MergeFromTransaction(tx, false)
+	}
+
+	hop := cur.hop + 1
+	var next []traceFrontier
+
+	follow := func(toAddr string, value int64, isCoinJoin bool, confidence float64) {
+		edgeKey := cur.address + "|" + toAddr + "|" + tx.Txid
+		if seenEdges[edgeKey] {
+			return
+		}
+		seenEdges[edgeKey] = true
+
+		g.AddHop(cur.address, toAddr, tx.Txid, value, hop, isCoinJoin, confidence)
+		g.addValueSent(cur.address, value)
+
+		if exchange, ok := ExchangeLabel(toAddr); ok {
+			g.markExchangeOnce(toAddr, exchange)
+			return // Cash-out point: stop following
+		}
+		next = append(next, traceFrontier{
+			address:    toAddr,
+			hop:        hop,
+			fromHeight: tx.BlockHeight,
+			confidence: confidence,
+		})
+	}
+
+	// ─── Bridge exit: funds leave the BTC chain ──────────────────────
+	if protocol, ok := DetectBridgeExit(tx); ok {
+		bridgeAddr := "bridge:" + tx.Txid
+		edgeKey := cur.address + "|" + bridgeAddr + "|" + tx.Txid
+		if !seenEdges[edgeKey] {
+			seenEdges[edgeKey] = true
+			g.AddHop(cur.address, bridgeAddr, tx.Txid, trackedValue, hop, false, cur.confidence)
+			g.addValueSent(cur.address, trackedValue)
+			g.MarkBridgeExit(bridgeAddr, protocol)
+		}
+		return nil
+	}
+
+	// ─── CoinJoin boundary ───────────────────────────────────────────
+	if isCoinJoin {
+		mixerAddr := "mixer:" + tx.Txid
+		edgeKey := cur.address + "|" + mixerAddr + "|" + tx.Txid
+		if !seenEdges[edgeKey] {
+			seenEdges[edgeKey] = true
+			g.AddHop(cur.address, mixerAddr, tx.Txid, trackedValue, hop, true, cur.confidence)
+			g.addValueSent(cur.address, trackedValue)
+		}
+		if !config.PenetrateMixers {
+			return nil // Path ends at the mixer
+		}
+
+		pen := PenetrateCoinjoin(tx, trackedIdx)
+		for _, out := range pen.TrackedOutputs {
+			linkage := out.Confidence
+			if linkage > maxPenetrationConfidence {
+				linkage = maxPenetrationConfidence
+			}
+			confidence := cur.confidence * linkage
+			if out.Address == "" || out.Value < config.MinValue || confidence < config.MinConfidence {
+				continue
+			}
+			edgeKey := mixerAddr + "|" + out.Address + "|" + tx.Txid
+			if seenEdges[edgeKey] {
+				continue
+			}
+			seenEdges[edgeKey] = true
+
+			g.addPenetratedHop(mixerAddr, out, tx.Txid, hop, confidence)
+			if out.OutputIndex < len(tx.Outputs) {
+				if reason, burned := DetectBurnOutput(tx.Outputs[out.OutputIndex]); burned {
+					g.markBurned(out.Address, reason, out.Value)
+					continue
+				}
+			}
+			if exchange, ok := ExchangeLabel(out.Address); ok {
+				g.markExchangeOnce(out.Address, exchange)
+				continue
+			}
+			next = append(next, traceFrontier{
+				address:    out.Address,
+				hop:        hop,
+				fromHeight: tx.BlockHeight,
+				confidence: confidence,
+			})
+		}
+		if len(next) > 0 {
+			log.Printf("[FundTracer] Mixer %s penetrated: following %d output(s) via %v", tx.Txid, len(next), pen.Methods)
+		}
+		return next
+	}
+
+	// ─── Ordinary spend: proportional (haircut) attribution ──────────
+	outputs := make([]models.TxOut, 0, len(tx.Outputs))
+	for i, out := range tx.Outputs {
+		if reason, burned := DetectBurnOutput(out); burned {
+			// Terminal: record the destroyed share, never follow it
+			attributed := proportionalShare(out.Value, trackedValue, totalIn)
+			if attributed <= 0 {
+				continue
+			}
+			burnAddr := out.Address
+			if burnAddr == "" {
+				burnAddr = fmt.Sprintf("burn:%s:%d", tx.Txid, i)
+			}
+			edgeKey := cur.address + "|" + burnAddr + "|" + tx.Txid
+			if !seenEdges[edgeKey] {
+				seenEdges[edgeKey] = true
+				g.AddHop(cur.address, burnAddr, tx.Txid, attributed, hop, false, cur.confidence)
+				g.addValueSent(cur.address, attributed)
+				g.markBurned(burnAddr, reason, attributed)
+			}
+			continue
+		}
+		if out.Address != "" {
+			outputs = append(outputs, out)
+		}
+	}
+	sort.SliceStable(outputs, func(i, j int) bool {
+		return outputs[i].Value > outputs[j].Value
+	})
+	if config.MaxBranches > 0 && len(outputs) > config.MaxBranches {
+		outputs = outputs[:config.MaxBranches]
+	}
+
+	for _, out := range outputs {
+		attributed := proportionalShare(out.Value, trackedValue, totalIn)
+		if attributed < config.MinValue || cur.confidence < config.MinConfidence {
+			continue
+		}
+		follow(out.Address, attributed, false, cur.confidence)
+	}
+
+	return next
+}
+
+// classifyTraceExit classifies a traced address from its spends and, for
+// a sweep, checks whether the hot wallet pays out in batches
+func classifyTraceExit(ctx context.Context, source TraceChainSource, addr string, spends []models.Transaction) ExchangeDepositSignal {
+	dep := ClassifyExchangeDeposit(addr, spends)
+	if dep.Method != "sweep" {
+		return dep
+	}
+
+	payouts, err := source.FindSpendingTxs(ctx, dep.HotWallet, dep.sweepHeight)
+	if err != nil {
+		return dep
+	}
+	for _, tx := range payouts {
+		if isBatchPayout(tx) {
+			dep.Method = "sweep_to_batch_payer"
+			dep.Confidence = depositBatcherConfidence
+			break
+		}
+	}
+	return dep
+}
+
+// proportionalShare returns value*part/total without overflowing int64:
+// the product of two amounts above ~30 BTC exceeds 2^63 sats². Inputs are
+// non-negative with part <= total, so the quotient never exceeds value.
+func proportionalShare(value, part, total int64) int64 {
+	if value <= 0 || part <= 0 || total <= 0 || part > total {
+		return 0
+	}
+	hi, lo := bits.Mul64(uint64(value), uint64(part))
+	q, _ := bits.Div64(hi, lo, uint64(total))
+	return int64(q)
+}
+
 // AddHop extends the flow graph with a new hop of transactions.
 // Called by the block scanner or RPC client as it discovers
 // downstream transactions from traced addresses.
@@ -131,29 +490,51 @@ func (g *FlowGraph) AddHop(fromAddr, toAddr, txid string, value int64, hopNum in
 	}
 
 	// Add destination node if not already present
-	if !g.hasNode(toAddr) {
-		role := "intermediate"
-		if isCoinJoin {
-			role = "mixer"
-			g.MixersPassed++
-		}
-
-		g.Nodes = append(g.Nodes, FlowNode{
-			Address:       toAddr,
-			HopNumber:     hopNum,
-			ValueReceived: value,
-			Role:          role,
-			RiskScore:     computeHopRisk(hopNum, confidence),
-		})
-	} else {
-		// Update existing node's received value
-		for i := range g.Nodes {
-			if g.Nodes[i].Address == toAddr {
-				g.Nodes[i].ValueReceived += value
-				break
-			}
+	role := "intermediate"
+	if isCoinJoin {
+		role = "mixer"
+	}
+	if g.addReceiver(toAddr, hopNum, value, role, confidence) && isCoinJoin {
+		g.MixersPassed++
+	}
+}
+
+// addPenetratedHop records funds leaving a mixer node through an output
+// that PenetrateCoinjoin linked to the tracked inputs ("mixer penetrated").
+func (g *FlowGraph) addPenetratedHop(mixerAddr string, out TrackedOutput, txid string, hopNum int, confidence float64) {
+	g.Edges = append(g.Edges, FlowEdge{
+		FromAddress:       mixerAddr,
+		ToAddress:         out.Address,
+		Txid:              txid,
+		Value:             out.Value,
+		HopNumber:         hopNum,
+		IsCoinJoin:        true,
+		Confidence:        confidence,
+		Timestamp:         time.Now(),
+		PenetrationMethod: out.Method,
+	})
+	g.TotalTracked += out.Value
+	g.addValueSent(mixerAddr, out.Value)
+	g.addReceiver(out.Address, hopNum, out.Value, "intermediate", confidence)
+}
+
+// addReceiver credits value to a destination node, creating it with the
+// given role if absent. Returns true when a new node was created.
+func (g *FlowGraph) addReceiver(addr string, hopNum int, value int64, role string, confidence float64) bool {
+	for i := range g.Nodes {
+		if g.Nodes[i].Address == addr {
+			g.Nodes[i].ValueReceived += value
+			return false
 		}
 	}
+	g.Nodes = append(g.Nodes, FlowNode{
+		Address:       addr,
+		HopNumber:     hopNum,
+		ValueReceived: value,
+		Role:          role,
+		RiskScore:     computeHopRisk(hopNum, confidence),
+	})
+	return true
 }
 
 // MarkExchangeExit tags a node as an exchange deposit (cash-out point)
@@ -169,6 +550,19 @@ func (g *FlowGraph) MarkExchangeExit(addr, exchangeName string) {
 	}
 }
 
+// MarkBridgeExit tags a node as a cross-chain bridge deposit (funds left BTC)
+func (g *FlowGraph) MarkBridgeExit(addr, protocol string) {
+	for i := range g.Nodes {
+		if g.Nodes[i].Address == addr {
+			g.Nodes[i].Role = "bridge-exit"
+			g.Nodes[i].Label = protocol
+			g.Nodes[i].IsFlagged = true
+			g.BridgeExits++
+			return
+		}
+	}
+}
+
 // GetExitPoints returns all nodes classified as exchange exits
 func (g *FlowGraph) GetExitPoints() []FlowNode {
 	var exits []FlowNode
@@ -197,6 +591,56 @@ func (g *FlowGraph) GetHop(hop int) []FlowEdge {
 	return edges
 }
 
+// addValueSent accumulates the value sent onward from an address
+func (g *FlowGraph) addValueSent(addr string, value int64) {
+	for i := range g.Nodes {
+		if g.Nodes[i].Address == addr {
+			g.Nodes[i].ValueSent += value
+			return
+		}
+	}
+}
+
+// markExchangeOnce tags an exchange exit without double-counting it
+func (g *FlowGraph) markExchangeOnce(addr, exchangeName string) {
+	for _, node := range g.Nodes {
+		if node.Address == addr && node.Role == "exchange" {
+			return
+		}
+	}
+	g.MarkExchangeExit(addr, exchangeName)
+}
+
+// markBurned tags a node as a provably-unspendable destination
+func (g *FlowGraph) markBurned(addr, reason string, value int64) {
+	for i := range g.Nodes {
+		if g.Nodes[i].Address == addr {
+			g.Nodes[i].Role = "burned"
+			g.Nodes[i].Label = reason
+			break
+		}
+	}
+	g.BurnedValue += value
+}
+
+// markUnspent tags an intermediate address whose funds have not moved
+func (g *FlowGraph) markUnspent(addr string) {
+	for i := range g.Nodes {
+		if g.Nodes[i].Address == addr && g.Nodes[i].Role == "intermediate" {
+			g.Nodes[i].Role = "unspent"
+			return
+		}
+	}
+}
+
+// Clusters returns the address clusters observed while tracing
+func (g *FlowGraph) Clusters() *ClusterEngine {
+	if g.clusters == nil {
+		g.clusters = NewClusterEngine()
+	}
+	return g.clusters
+}
+
 // hasNode checks if an address already exists in the graph
 func (g *FlowGraph) hasNode(addr string) bool {
 	for _, node := range g.Nodes {
@@ -235,5 +679,9 @@ func (g *FlowGraph) Summary() map[string]interface{} {
 		"maxHopReached":   g.MaxHopReached,
 		"exchangeExits":   g.ExchangeExits,
 		"mixersPassed":    g.MixersPassed,
+		"bridgeExits":     g.BridgeExits,
+		"burnedValue":     g.BurnedValue,
+		"unspentValue":    g.UnspentValue,
+		"truncated":       g.Truncated,
 	}
 }
@@ -0,0 +1,467 @@
+package heuristics
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// fakeChain is an in-memory TraceChainSource keyed by spent address
+type fakeChain map[string][]models.Transaction
+
+func (c fakeChain) FindSpendingTxs(ctx context.Context, addr string, fromHeight int) ([]models.Transaction, error) {
+	var out []models.Transaction
+	for _, tx := range c[addr] {
+		if tx.BlockHeight >= fromHeight {
+			out = append(out, tx)
+		}
+	}
+	return out, nil
+}
+
+func simpleSpend(txid, from string, inValue int64, height int, outs ...models.TxOut) models.Transaction {
+	return models.Transaction{
+		Txid:        txid,
+		Inputs:      []models.TxIn{{Address: from, Value: inValue}},
+		Outputs:     outs,
+		BlockHeight: height,
+	}
+}
+
+func findEdge(g FlowGraph, from, to string) *FlowEdge {
+	for i := range g.Edges {
+		if g.Edges[i].FromAddress == from && g.Edges[i].ToAddress == to {
+			return &g.Edges[i]
+		}
+	}
+	return nil
+}
+
+func nodeRole(g FlowGraph, addr string) string {
+	for _, n := range g.Nodes {
+		if n.Address == addr {
+			return n.Role
+		}
+	}
+	return ""
+}
+
+func TestTraceFundFlow_MultiHop(t *testing.T) {
+	chain := fakeChain{
+		"theft": {simpleSpend("tx1", "theft", 1_000_000, 100,
+			models.TxOut{Address: "hopA", Value: 600_000},
+			models.TxOut{Address: "hopB", Value: 390_000},
+		)},
+		"hopA": {simpleSpend("tx2", "hopA", 600_000, 101,
+			models.TxOut{Address: "hopC", Value: 590_000},
+		)},
+	}
+
+	graph := TraceFundFlow(context.Background(), chain, []string{"theft"}, DefaultTraceConfig())
+
+	if len(graph.Edges) != 3 {
+		t.Fatalf("Expected 3 edges. Got %d", len(graph.Edges))
+	}
+	if graph.MaxHopReached != 2 {
+		t.Errorf("Expected max hop 2. Got %d", graph.MaxHopReached)
+	}
+	if e := findEdge(graph, "hopA", "hopC"); e == nil || e.HopNumber != 2 {
+		t.Errorf("Expected hopA->hopC edge at hop 2. Got %+v", e)
+	}
+	if role := nodeRole(graph, "hopB"); role != "unspent" {
+		t.Errorf("Expected hopB to be unspent. Got %q", role)
+	}
+}
+
+func TestTraceFundFlow_LargeValuesDoNotOverflow(t *testing.T) {
+	// 500 BTC co-spent with 100 BTC: out.Value*trackedValue is ~3e21 sats²,
+	// far beyond int64
+	const btc = 100_000_000
+	chain := fakeChain{
+		"theft": {{
+			Txid: "bigtx",
+			Inputs: []models.TxIn{
+				{Address: "theft", Value: 500 * btc},
+				{Address: "other", Value: 100 * btc},
+			},
+			Outputs: []models.TxOut{
+				{Address: "hopA", Value: 590 * btc},
+				{Address: "hopB", Value: 9 * btc},
+			},
+			BlockHeight: 100,
+		}},
+	}
+
+	graph := TraceFundFlow(context.Background(), chain, []string{"theft"}, DefaultTraceConfig())
+
+	e := findEdge(graph, "theft", "hopA")
+	if e == nil {
+		t.Fatalf("Expected theft->hopA edge. Got %+v", graph.Edges)
+	}
+	if want := int64(590 * btc * 5 / 6); e.Value != want {
+		t.Errorf("Expected %d sats attributed to hopA. Got %d", want, e.Value)
+	}
+	if e := findEdge(graph, "theft", "hopB"); e == nil || e.Value != 9*btc*5/6 {
+		t.Errorf("Expected %d sats attributed to hopB. Got %+v", int64(9*btc*5/6), e)
+	}
+}
+
+func TestTraceFundFlow_StopsAtExchange(t *testing.T) {
+	exchange := "bc1qm34lsc65zpw79lxes69zkqmdeposit"
+	chain := fakeChain{
+		"theft": {simpleSpend("tx1", "theft", 500_000, 100,
+			models.TxOut{Address: exchange, Value: 490_000},
+		)},
+		exchange: {simpleSpend("tx2", exchange, 490_000, 101,
+			models.TxOut{Address: "hot_wallet", Value: 480_000},
+		)},
+	}
+
+	graph := TraceFundFlow(context.Background(), chain, []string{"theft"}, DefaultTraceConfig())
+
+	if graph.ExchangeExits != 1 {
+		t.Errorf("Expected 1 exchange exit. Got %d", graph.ExchangeExits)
+	}
+	if findEdge(graph, exchange, "hot_wallet") != nil {
+		t.Error("Expected tracing to stop at the exchange deposit")
+	}
+}
+
+func TestTraceFundFlow_RespectsMaxHops(t *testing.T) {
+	chain := fakeChain{}
+	for i := 0; i < 5; i++ {
+		from := fmt.Sprintf("addr%d", i)
+		to := fmt.Sprintf("addr%d", i+1)
+		chain[from] = []models.Transaction{simpleSpend(fmt.Sprintf("tx%d", i), from, 100_000, 100+i,
+			models.TxOut{Address: to, Value: 99_000},
+		)}
+	}
+
+	cfg := DefaultTraceConfig()
+	cfg.MaxHops = 2
+	graph := TraceFundFlow(context.Background(), chain, []string{"addr0"}, cfg)
+
+	if graph.MaxHopReached != 2 {
+		t.Errorf("Expected max hop 2. Got %d", graph.MaxHopReached)
+	}
+	if len(graph.Edges) != 2 {
+		t.Errorf("Expected 2 edges. Got %d", len(graph.Edges))
+	}
+}
+
+func TestTraceFundFlow_MixerBoundary(t *testing.T) {
+	// Whirlpool-shaped 5x5 mix
+	mix := models.Transaction{Txid: "cj1", BlockHeight: 200, Fee: 2500, Vsize: 1000}
+	for i := 0; i < 5; i++ {
+		mix.Inputs = append(mix.Inputs, models.TxIn{Address: fmt.Sprintf("bc1qin%d", i), Value: 1_000_500})
+		mix.Outputs = append(mix.Outputs, models.TxOut{Address: fmt.Sprintf("bc1qout%d", i), Value: 1_000_000})
+	}
+	mix.Inputs[0].Address = "theft"
+	chain := fakeChain{"theft": {mix}}
+
+	cfg := DefaultTraceConfig()
+	cfg.PenetrateMixers = false
+	graph := TraceFundFlow(context.Background(), chain, []string{"theft"}, cfg)
+
+	e := findEdge(graph, "theft", "mixer:cj1")
+	if e == nil || !e.IsCoinJoin {
+		t.Fatalf("Expected a CoinJoin edge into mixer:cj1. Got %+v", graph.Edges)
+	}
+	if graph.MixersPassed != 1 {
+		t.Errorf("Expected 1 mixer passed. Got %d", graph.MixersPassed)
+	}
+	if len(graph.Edges) != 1 {
+		t.Errorf("Expected outputs of an unpenetrated mixer not to be followed. Got %d edges", len(graph.Edges))
+	}
+}
+
+// joinMarketMix builds a 4-party JoinMarket round in which "theft" is the
+// taker: its 1.5M input yields a 1M coinjoin output plus identifiable change.
+func joinMarketMix() models.Transaction {
+	mix := models.Transaction{Txid: "cj2", BlockHeight: 300, Fee: 100}
+	mix.Inputs = []models.TxIn{
+		{Address: "theft", Value: 1_500_000},
+		{Address: "bc1qmaker0", Value: 2_000_000},
+		{Address: "bc1qmaker1", Value: 2_500_000},
+		{Address: "bc1qmaker2", Value: 3_000_000},
+	}
+	for i := 0; i < 4; i++ {
+		mix.Outputs = append(mix.Outputs, models.TxOut{Address: fmt.Sprintf("bc1qmixed%d", i), Value: 1_000_000})
+	}
+	mix.Outputs = append(mix.Outputs,
+		models.TxOut{Address: "bc1qtakerchange", Value: 499_000},
+		models.TxOut{Address: "bc1qmakerchange0", Value: 1_000_300},
+		models.TxOut{Address: "bc1qmakerchange1", Value: 1_500_300},
+		models.TxOut{Address: "bc1qmakerchange2", Value: 2_000_300},
+	)
+	return mix
+}
+
+func TestTraceFundFlow_PenetratesMixer(t *testing.T) {
+	mix := joinMarketMix()
+	if !IsCoinJoinResult(AnalyzeTx(mix).HeuristicFlags) {
+		t.Fatalf("Test fixture must be detected as a CoinJoin")
+	}
+	onward := simpleSpend("tx3", "bc1qtakerchange", 499_000, 301,
+		models.TxOut{Address: "bc1qnext", Value: 498_000},
+	)
+	chain := fakeChain{"theft": {mix}, "bc1qtakerchange": {onward}}
+
+	graph := TraceFundFlow(context.Background(), chain, []string{"theft"}, DefaultTraceConfig())
+
+	if e := findEdge(graph, "theft", "mixer:cj2"); e == nil || !e.IsCoinJoin {
+		t.Fatalf("Expected a CoinJoin edge into mixer:cj2. Got %+v", graph.Edges)
+	}
+	if graph.MixersPassed != 1 {
+		t.Errorf("Expected 1 mixer passed. Got %d", graph.MixersPassed)
+	}
+
+	exit := findEdge(graph, "mixer:cj2", "bc1qtakerchange")
+	if exit == nil {
+		t.Fatalf("Expected a penetrated edge to the taker change. Got %+v", graph.Edges)
+	}
+	if !strings.Contains(exit.PenetrationMethod, "change_detection") {
+		t.Errorf("Expected change_detection penetration. Got %q", exit.PenetrationMethod)
+	}
+	if exit.Confidence >= 1 || exit.Confidence < DefaultTraceConfig().MinConfidence {
+		t.Errorf("Expected reduced confidence above the floor. Got %.2f", exit.Confidence)
+	}
+	if role := nodeRole(graph, "bc1qtakerchange"); role == "mixer" {
+		t.Errorf("Post-mix output must not be tagged as a mixer")
+	}
+
+	// The trace continues from the penetrated output with its confidence
+	next := findEdge(graph, "bc1qtakerchange", "bc1qnext")
+	if next == nil {
+		t.Fatalf("Expected the trace to continue past the mixer. Got %+v", graph.Edges)
+	}
+	if next.Confidence != exit.Confidence {
+		t.Errorf("Expected onward confidence %.2f. Got %.2f", exit.Confidence, next.Confidence)
+	}
+
+	inv := &Investigation{FlowGraph: &graph}
+	found := false
+	for _, ev := range inv.GetTimeline() {
+		if ev.EventType == "mixer_exit" && ev.ToAddress == "bc1qtakerchange" {
+			found = strings.HasPrefix(ev.Description, "Mixer penetrated")
+		}
+	}
+	if !found {
+		t.Errorf("Expected a mixer penetrated timeline event")
+	}
+}
+
+func TestTraceFundFlow_MixerStopsWithoutPenetration(t *testing.T) {
+	chain := fakeChain{"theft": {joinMarketMix()}}
+
+	cfg := DefaultTraceConfig()
+	cfg.PenetrateMixers = false
+	graph := TraceFundFlow(context.Background(), chain, []string{"theft"}, cfg)
+
+	if graph.MixersPassed != 1 || len(graph.Edges) != 1 {
+		t.Fatalf("Expected the path to end at the mixer. Got %d mixers, edges %+v", graph.MixersPassed, graph.Edges)
+	}
+	if findEdge(graph, "mixer:cj2", "bc1qtakerchange") != nil {
+		t.Errorf("Expected no penetrated edges when PenetrateMixers is false")
+	}
+}
+
+func TestTraceFundFlow_StopsAtBridgeExit(t *testing.T) {
+	bridgeTx := simpleSpend("tx2", "hopA", 800_000, 101,
+		models.TxOut{Address: "bc1qvault", Value: 790_000},
+		models.TxOut{ScriptPubKey: opReturnScript("=:ETH.ETH:0x742d35Cc6634C0532925a3b844Bc454e4438f44e")},
+	)
+	chain := fakeChain{
+		"theft": {simpleSpend("tx1", "theft", 810_000, 100,
+			models.TxOut{Address: "hopA", Value: 800_000},
+		)},
+		"hopA": {bridgeTx},
+		"bc1qvault": {simpleSpend("tx3", "bc1qvault", 790_000, 102,
+			models.TxOut{Address: "vault_sweep", Value: 780_000},
+		)},
+	}
+
+	graph := TraceFundFlow(context.Background(), chain, []string{"theft"}, DefaultTraceConfig())
+
+	if graph.BridgeExits != 1 {
+		t.Errorf("Expected 1 bridge exit. Got %d", graph.BridgeExits)
+	}
+	if role := nodeRole(graph, "bridge:tx2"); role != "bridge-exit" {
+		t.Errorf("Expected bridge:tx2 to be a bridge-exit. Got %q", role)
+	}
+	if findEdge(graph, "hopA", "bc1qvault") != nil {
+		t.Error("Expected tracing to stop at the bridge deposit")
+	}
+}
+
+func TestTraceFundFlow_CancelledReturnsPartialGraph(t *testing.T) {
+	chain := fakeChain{
+		"theft": {simpleSpend("tx1", "theft", 1_000_000, 100,
+			models.TxOut{Address: "hopA", Value: 990_000},
+		)},
+		"hopA": {simpleSpend("tx2", "hopA", 990_000, 101,
+			models.TxOut{Address: "hopB", Value: 980_000},
+		)},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	source := cancelAfter{chain: chain, calls: 1, cancel: cancel}
+	graph := TraceFundFlow(ctx, &source, []string{"theft"}, DefaultTraceConfig())
+
+	if !graph.Truncated {
+		t.Fatal("Expected truncated graph after cancellation")
+	}
+	if len(graph.Edges) != 1 || findEdge(graph, "theft", "hopA") == nil {
+		t.Errorf("Expected only the completed first hop. Got %+v", graph.Edges)
+	}
+	if graph.TotalTracked != 990_000 {
+		t.Errorf("Expected totals to match recorded edges. Got %d", graph.TotalTracked)
+	}
+}
+
+// cancelAfter cancels the trace context once the given number of lookups completed
+type cancelAfter struct {
+	chain  fakeChain
+	calls  int
+	cancel context.CancelFunc
+}
+
+func (c *cancelAfter) FindSpendingTxs(ctx context.Context, addr string, fromHeight int) ([]models.Transaction, error) {
+	txs, err := c.chain.FindSpendingTxs(ctx, addr, fromHeight)
+	c.calls--
+	if c.calls == 0 {
+		c.cancel()
+	}
+	return txs, err
+}
+
+// depositSweepTx consolidates deposit with other customers' deposits into hotWallet
+func depositSweepTx(txid, deposit string, value int64, height int, hotWallet string) models.Transaction {
+	tx := models.Transaction{Txid: txid, BlockHeight: height}
+	tx.Inputs = append(tx.Inputs, models.TxIn{Address: deposit, Value: value})
+	for i := 0; i < 7; i++ {
+		tx.Inputs = append(tx.Inputs, models.TxIn{Address: fmt.Sprintf("customer%d", i), Value: 250_000 + int64(i)*13_000})
+	}
+	tx.Outputs = []models.TxOut{{Address: hotWallet, Value: value + 1_900_000}}
+	return tx
+}
+
+func TestTraceFundFlow_AutoIdentifiesExchangeDeposit(t *testing.T) {
+	batch := models.Transaction{Txid: "payout", BlockHeight: 103, Inputs: []models.TxIn{{Address: "hot_wallet", Value: 50_000_000}}}
+	for i := 0; i < 12; i++ {
+		batch.Outputs = append(batch.Outputs, models.TxOut{Address: fmt.Sprintf("withdrawal%d", i), Value: 3_000_000 + int64(i)*7_919})
+	}
+	chain := fakeChain{
+		"theft": {simpleSpend("tx1", "theft", 800_000, 100,
+			models.TxOut{Address: "deposit1", Value: 500_000},
+			models.TxOut{Address: "hopB", Value: 290_000},
+		)},
+		"deposit1":   {depositSweepTx("sweep1", "deposit1", 500_000, 102, "hot_wallet")},
+		"hot_wallet": {batch},
+		"hopB": {simpleSpend("tx2", "hopB", 290_000, 101,
+			models.TxOut{Address: "deposit2", Value: 280_000},
+		)},
+		"deposit2": {depositSweepTx("sweep2", "deposit2", 280_000, 104, "other_consolidation")},
+	}
+
+	graph := TraceFundFlow(context.Background(), chain, []string{"theft"}, DefaultTraceConfig())
+
+	if graph.ExchangeExits != 2 {
+		t.Errorf("Expected both swept deposits flagged as exchange exits. Got %d", graph.ExchangeExits)
+	}
+	for _, addr := range []string{"deposit1", "deposit2"} {
+		if role := nodeRole(graph, addr); role != "exchange" {
+			t.Errorf("Expected %s to be an exchange exit. Got %q", addr, role)
+		}
+	}
+	if findEdge(graph, "deposit1", "hot_wallet") != nil || nodeRole(graph, "withdrawal0") != "" {
+		t.Error("Expected tracing to stop at the deposit instead of following the sweep")
+	}
+
+	dep := classifyTraceExit(context.Background(), chain, "deposit1", chain["deposit1"])
+	if dep.Method != "sweep_to_batch_payer" || dep.Confidence != depositBatcherConfidence || dep.HotWallet != "hot_wallet" {
+		t.Errorf("Expected the batch-paying hot wallet to raise confidence. Got %+v", dep)
+	}
+	if dep := classifyTraceExit(context.Background(), chain, "deposit2", chain["deposit2"]); dep.Method != "sweep" {
+		t.Errorf("Expected a plain sweep without batch payouts. Got %+v", dep)
+	}
+
+	cfg := DefaultTraceConfig()
+	cfg.DetectExchangeDeposits = false
+	if manual := TraceFundFlow(context.Background(), chain, []string{"theft"}, cfg); manual.ExchangeExits != 0 {
+		t.Errorf("Expected no automatic exits when detection is disabled. Got %d", manual.ExchangeExits)
+	}
+}
+
+func TestTraceFundFlow_TaggedExchangeAddress(t *testing.T) {
+	GetGlobalAddressWatchlist().Add("tagged_deposit", "exchange", "Kraken deposit", "CASE-X", AlertLevelForRole("exchange"))
+	t.Cleanup(func() { GetGlobalAddressWatchlist().Remove("tagged_deposit") })
+
+	chain := fakeChain{
+		"theft": {simpleSpend("tx1", "theft", 500_000, 100,
+			models.TxOut{Address: "tagged_deposit", Value: 490_000},
+		)},
+	}
+	graph := TraceFundFlow(context.Background(), chain, []string{"theft"}, DefaultTraceConfig())
+	exits := graph.GetExitPoints()
+	if len(exits) != 1 || exits[0].Label != "Kraken deposit" {
+		t.Errorf("Expected the investigator-tagged address to be an exit. Got %+v", exits)
+	}
+}
+
+// utxoChain is a fakeChain that also reports live balances
+type utxoChain struct {
+	fakeChain
+	balances map[string]int64
+	scanned  []string
+}
+
+func (c *utxoChain) UnspentBalances(ctx context.Context, addrs []string) (map[string]int64, error) {
+	c.scanned = append(c.scanned, addrs...)
+	out := make(map[string]int64)
+	for _, addr := range addrs {
+		out[addr] = c.balances[addr]
+	}
+	return out, nil
+}
+
+func TestTraceFundFlow_AnnotatesUnspentEndpoints(t *testing.T) {
+	chain := &utxoChain{
+		fakeChain: fakeChain{
+			"theft": {simpleSpend("tx1", "theft", 1_000_000, 100,
+				models.TxOut{Address: "hopA", Value: 600_000},
+				models.TxOut{Address: "hopB", Value: 390_000},
+			)},
+			"hopA": {simpleSpend("tx2", "hopA", 600_000, 101,
+				models.TxOut{Address: "hopC", Value: 590_000},
+			)},
+			"hopC": {simpleSpend("tx3", "hopC", 590_000, 102,
+				models.TxOut{Address: "hopD", Value: 580_000},
+			)},
+		},
+		balances: map[string]int64{"hopB": 390_000, "hopD": 580_000, "hopA": 5_000},
+	}
+
+	cfg := DefaultTraceConfig()
+	cfg.MaxHops = 3
+	graph := TraceFundFlow(context.Background(), chain, []string{"theft"}, cfg)
+
+	scanned := strings.Join(chain.scanned, ",")
+	if scanned != "theft,hopB,hopD" {
+		t.Errorf("Expected the source, unspent and unexpanded endpoints to be scanned. Got %s", scanned)
+	}
+	if graph.UnspentValue != 970_000 {
+		t.Errorf("Expected 970000 unspent sats. Got %d", graph.UnspentValue)
+	}
+	for _, n := range graph.Nodes {
+		if n.Address == "hopA" && n.UnspentBalance != 0 {
+			t.Errorf("Expected expanded hopA to be skipped. Got %d", n.UnspentBalance)
+		}
+		if n.Address == "hopD" && n.UnspentBalance != 580_000 {
+			t.Errorf("Expected hopD to hold 580000 sats. Got %d", n.UnspentBalance)
+		}
+	}
+}
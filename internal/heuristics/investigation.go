@@ -1,8 +1,13 @@
 package heuristics
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"sync"
 	"time"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
 )
 
 // Investigation Case Manager
@@ -23,6 +28,11 @@ import (
 //   paused    → temporarily halted
 //   completed → all funds accounted for
 //   archived  → closed and preserved for records
+//
+// With a store attached (AttachStore), cases are written through to the
+// database on create, tag and trace, and reloaded with their tagged
+// addresses on boot. The in-memory map stays the read path; flow graphs
+// are not persisted and are rebuilt by re-running the trace.
 
 // Investigation represents a single incident response case
 type Investigation struct {
@@ -38,6 +48,40 @@ type Investigation struct {
 	CreatedAt       time.Time       `json:"createdAt"`
 	UpdatedAt       time.Time       `json:"updatedAt"`
 	TraceConfig     TraceConfig     `json:"traceConfig"`
+
+	// mu guards every field: handlers trace, tag, re-status and persist the
+	// same case concurrently. A published FlowGraph is never modified in
+	// place (TagAddress copies it), so snapshots may share it.
+	mu sync.RWMutex
+}
+
+// MarshalJSON encodes the case under its read lock
+func (inv *Investigation) MarshalJSON() ([]byte, error) {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	type alias Investigation
+	return json.Marshal((*alias)(inv))
+}
+
+// Snapshot returns a consistent copy of the case for reading without
+// the lock. The flow graph is shared and must not be modified.
+func (inv *Investigation) Snapshot() *Investigation {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	return &Investigation{
+		ID:              inv.ID,
+		Name:            inv.Name,
+		Description:     inv.Description,
+		Status:          inv.Status,
+		TheftAddresses:  append([]string(nil), inv.TheftAddresses...),
+		TaggedAddresses: append([]TaggedAddress(nil), inv.TaggedAddresses...),
+		FlowGraph:       inv.FlowGraph,
+		TotalStolen:     inv.TotalStolen,
+		TotalRecovered:  inv.TotalRecovered,
+		CreatedAt:       inv.CreatedAt,
+		UpdatedAt:       inv.UpdatedAt,
+		TraceConfig:     inv.TraceConfig,
+	}
 }
 
 // TaggedAddress is an address with investigator-provided metadata
@@ -55,7 +99,7 @@ type TaggedAddress struct {
 // TimelineEvent represents a chronological event in the investigation
 type TimelineEvent struct {
 	Timestamp   time.Time `json:"timestamp"`
-	EventType   string    `json:"eventType"` // "theft"/"transfer"/"mixer_entry"/"mixer_exit"/"exchange_deposit"/"tagged"
+	EventType   string    `json:"eventType"` // "theft"/"transfer"/"mixer_entry"/"mixer_exit"/"exchange_deposit"/"bridge_exit"/"burn"/"tagged"
 	Description string    `json:"description"`
 	Txid        string    `json:"txid,omitempty"`
 	FromAddress string    `json:"fromAddress,omitempty"`
@@ -64,10 +108,25 @@ type TimelineEvent struct {
 	HopNumber   int       `json:"hopNumber"`
 }
 
+// InvestigationStore is the durable backing of the case manager
+// (implemented by db.PostgresStore)
+type InvestigationStore interface {
+	SaveInvestigation(ctx context.Context, rec models.InvestigationRecord) error
+	SaveInvestigationAddress(ctx context.Context, caseID, address, label, role, notes, taggedBy string) error
+	LoadInvestigations(ctx context.Context) ([]models.InvestigationRecord, error)
+}
+
+// Theft origins are persisted as investigation addresses tagged by the system
+const (
+	theftOriginTagger = "system"
+	theftOriginNote   = "seeded theft origin"
+)
+
 // InvestigationManager handles CRUD for investigations
 type InvestigationManager struct {
 	mu    sync.RWMutex
 	cases map[string]*Investigation
+	store InvestigationStore // nil = in-memory only
 }
 
 // NewInvestigationManager creates a new case manager
@@ -98,6 +157,114 @@ func (m *InvestigationManager) CreateInvestigation(id, name, description string,
 	return inv
 }
 
+// AttachStore loads every persisted case into the manager and writes
+// subsequent changes through to store. Returns the number of cases loaded.
+func (m *InvestigationManager) AttachStore(ctx context.Context, store InvestigationStore) (int, error) {
+	records, err := store.LoadInvestigations(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.store = store
+	for _, rec := range records {
+		m.cases[rec.CaseID] = investigationFromRecord(rec)
+	}
+	return len(records), nil
+}
+
+// Persistent reports whether a store is attached
+func (m *InvestigationManager) Persistent() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.store != nil
+}
+
+// Save writes a case and its theft addresses through to the store
+func (m *InvestigationManager) Save(ctx context.Context, inv *Investigation) error {
+	m.mu.RLock()
+	store := m.store
+	m.mu.RUnlock()
+	if store == nil {
+		return nil
+	}
+
+	inv = inv.Snapshot()
+	if err := store.SaveInvestigation(ctx, inv.record()); err != nil {
+		return fmt.Errorf("persist case %s: %w", inv.ID, err)
+	}
+	for _, addr := range inv.TheftAddresses {
+		if addr == "" {
+			continue
+		}
+		if err := store.SaveInvestigationAddress(ctx, inv.ID, addr, "Theft: "+inv.Name, "theft", theftOriginNote, theftOriginTagger); err != nil {
+			return fmt.Errorf("persist theft address %s of case %s: %w", addr, inv.ID, err)
+		}
+	}
+	return nil
+}
+
+// SaveTag writes one tagged address of a case through to the store
+func (m *InvestigationManager) SaveTag(ctx context.Context, caseID string, tag TaggedAddress) error {
+	m.mu.RLock()
+	store := m.store
+	m.mu.RUnlock()
+	if store == nil {
+		return nil
+	}
+
+	if err := store.SaveInvestigationAddress(ctx, caseID, tag.Address, tag.Label, tag.Role, tag.Notes, tag.TaggedBy); err != nil {
+		return fmt.Errorf("persist tagged address %s of case %s: %w", tag.Address, caseID, err)
+	}
+	return nil
+}
+
+// record converts a case to its persisted form (case row only); the
+// caller holds the lock or owns a snapshot
+func (inv *Investigation) record() models.InvestigationRecord {
+	return models.InvestigationRecord{
+		CaseID:         inv.ID,
+		Name:           inv.Name,
+		Description:    inv.Description,
+		Status:         inv.Status,
+		TotalStolen:    inv.TotalStolen,
+		TotalRecovered: inv.TotalRecovered,
+		CreatedAt:      inv.CreatedAt,
+		UpdatedAt:      inv.UpdatedAt,
+	}
+}
+
+// investigationFromRecord rebuilds a case from its persisted form
+func investigationFromRecord(rec models.InvestigationRecord) *Investigation {
+	inv := &Investigation{
+		ID:             rec.CaseID,
+		Name:           rec.Name,
+		Description:    rec.Description,
+		Status:         rec.Status,
+		TotalStolen:    rec.TotalStolen,
+		TotalRecovered: rec.TotalRecovered,
+		CreatedAt:      rec.CreatedAt,
+		UpdatedAt:      rec.UpdatedAt,
+		TraceConfig:    DefaultTraceConfig(),
+	}
+	for _, addr := range rec.Addresses {
+		if addr.Role == "theft" && addr.TaggedBy == theftOriginTagger {
+			inv.TheftAddresses = append(inv.TheftAddresses, addr.Address)
+			continue
+		}
+		inv.TaggedAddresses = append(inv.TaggedAddresses, TaggedAddress{
+			Address:  addr.Address,
+			Label:    addr.Label,
+			Role:     addr.Role,
+			Notes:    addr.Notes,
+			TaggedAt: addr.TaggedAt,
+			TaggedBy: addr.TaggedBy,
+		})
+	}
+	return inv
+}
+
 // GetInvestigation retrieves a case by ID
 func (m *InvestigationManager) GetInvestigation(id string) *Investigation {
 	m.mu.RLock()
@@ -117,14 +284,33 @@ func (m *InvestigationManager) ListInvestigations() []*Investigation {
 	return list
 }
 
-// RunTrace executes the fund flow trace for a case
-func (inv *Investigation) RunTrace() {
-	inv.FlowGraph = &FlowGraph{}
-	graph := TraceFundFlow(inv.TheftAddresses, inv.TraceConfig)
+// RunTrace executes the fund flow trace for a case against the given chain source.
+// A cancelled ctx leaves a partial graph with Truncated set.
+// The chain walk runs without the lock; the finished graph replaces the
+// previous one.
+func (inv *Investigation) RunTrace(ctx context.Context, source TraceChainSource) {
+	inv.mu.RLock()
+	theft := append([]string(nil), inv.TheftAddresses...)
+	config := inv.TraceConfig
+	inv.mu.RUnlock()
+
+	graph := TraceFundFlow(ctx, source, theft, config)
+	graph.InvestigationID = inv.ID
+	graph.Clusters() // Initialize now: readers of the published graph must not write it
+
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
 	inv.FlowGraph = &graph
 	inv.UpdatedAt = time.Now()
 }
 
+// UpdateTraceConfig applies fn to the case's trace configuration
+func (inv *Investigation) UpdateTraceConfig(fn func(*TraceConfig)) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	fn(&inv.TraceConfig)
+}
+
 // TagAddress adds a label and metadata to an address in the investigation
 func (inv *Investigation) TagAddress(addr, label, role, notes, taggedBy string) {
 	tag := TaggedAddress{
@@ -136,6 +322,9 @@ func (inv *Investigation) TagAddress(addr, label, role, notes, taggedBy string)
 		TaggedBy: taggedBy,
 	}
 
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+
 	// Find existing tag and update, or append new
 	for i, existing := range inv.TaggedAddresses {
 		if existing.Address == addr {
@@ -148,13 +337,17 @@ func (inv *Investigation) TagAddress(addr, label, role, notes, taggedBy string)
 	inv.TaggedAddresses = append(inv.TaggedAddresses, tag)
 	inv.UpdatedAt = time.Now()
 
-	// Also update the flow graph node if it exists
+	// Also update the flow graph node if it exists (on a copy: snapshots
+	// may still be reading the published graph)
 	if inv.FlowGraph != nil {
 		for i := range inv.FlowGraph.Nodes {
 			if inv.FlowGraph.Nodes[i].Address == addr {
-				inv.FlowGraph.Nodes[i].Label = label
-				inv.FlowGraph.Nodes[i].Role = role
-				inv.FlowGraph.Nodes[i].IsFlagged = true
+				graph := *inv.FlowGraph
+				graph.Nodes = append([]FlowNode(nil), graph.Nodes...)
+				graph.Nodes[i].Label = label
+				graph.Nodes[i].Role = role
+				graph.Nodes[i].IsFlagged = true
+				inv.FlowGraph = &graph
 				break
 			}
 		}
@@ -163,6 +356,12 @@ func (inv *Investigation) TagAddress(addr, label, role, notes, taggedBy string)
 
 // GetTimeline builds a chronological timeline of all events
 func (inv *Investigation) GetTimeline() []TimelineEvent {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	return inv.timeline()
+}
+
+func (inv *Investigation) timeline() []TimelineEvent {
 	var events []TimelineEvent
 
 	// Add theft events
@@ -182,7 +381,10 @@ func (inv *Investigation) GetTimeline() []TimelineEvent {
 		for _, edge := range inv.FlowGraph.Edges {
 			eventType := "transfer"
 			desc := "Fund transfer"
-			if edge.IsCoinJoin {
+			if edge.PenetrationMethod != "" {
+				eventType = "mixer_exit"
+				desc = "Mixer penetrated: funds traced out via " + edge.PenetrationMethod
+			} else if edge.IsCoinJoin {
 				eventType = "mixer_entry"
 				desc = "Funds entered CoinJoin mixer"
 			}
@@ -210,6 +412,24 @@ func (inv *Investigation) GetTimeline() []TimelineEvent {
 					HopNumber:   node.HopNumber,
 				})
 			}
+			if node.Role == "burned" {
+				events = append(events, TimelineEvent{
+					EventType:   "burn",
+					Description: "Funds burned (" + node.Label + ")",
+					ToAddress:   node.Address,
+					Value:       node.ValueReceived,
+					HopNumber:   node.HopNumber,
+				})
+			}
+			if node.Role == "bridge-exit" {
+				events = append(events, TimelineEvent{
+					EventType:   "bridge_exit",
+					Description: "Funds bridged to another chain via " + node.Label,
+					ToAddress:   node.Address,
+					Value:       node.ValueReceived,
+					HopNumber:   node.HopNumber,
+				})
+			}
 		}
 	}
 
@@ -227,8 +447,27 @@ func (inv *Investigation) GetTimeline() []TimelineEvent {
 	return events
 }
 
+// EntityGraph projects the flow graph onto address clusters, applying
+// the investigator's tags. Clusters known to the engine-wide ClusterEngine
+// (nil if none) are joined with the CIOH merges recorded while tracing.
+func (inv *Investigation) EntityGraph(known *ClusterEngine) EntityGraph {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	if inv.FlowGraph == nil {
+		return BuildEntityGraph(nil, nil, inv.TaggedAddresses)
+	}
+	ce := combineClusters(inv.FlowGraph, known, inv.FlowGraph.Clusters())
+	return BuildEntityGraph(inv.FlowGraph, ce, inv.TaggedAddresses)
+}
+
 // GetExchangeExits returns all identified exchange deposit points
 func (inv *Investigation) GetExchangeExits() []FlowNode {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+	return inv.exchangeExits()
+}
+
+func (inv *Investigation) exchangeExits() []FlowNode {
 	if inv.FlowGraph == nil {
 		return nil
 	}
@@ -237,17 +476,134 @@ func (inv *Investigation) GetExchangeExits() []FlowNode {
 
 // ComputeRecovery calculates total value at identified exchange exits
 func (inv *Investigation) ComputeRecovery() int64 {
-	exits := inv.GetExchangeExits()
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	inv.TotalRecovered = inv.recovery()
+	return inv.TotalRecovered
+}
+
+func (inv *Investigation) recovery() int64 {
 	total := int64(0)
-	for _, exit := range exits {
+	for _, exit := range inv.exchangeExits() {
 		total += exit.ValueReceived
 	}
-	inv.TotalRecovered = total
 	return total
 }
 
-// SetStatus updates the investigation status
-func (inv *Investigation) SetStatus(status string) {
+// investigationStatuses are the lifecycle states of a case
+var investigationStatuses = map[string]bool{
+	"active": true, "paused": true, "completed": true, "archived": true,
+}
+
+// IsValidInvestigationStatus reports whether s is active/paused/completed/archived
+func IsValidInvestigationStatus(s string) bool {
+	return investigationStatuses[s]
+}
+
+// SetStatus updates the investigation status, returning the previous one
+func (inv *Investigation) SetStatus(status string) string {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	previous := inv.Status
 	inv.Status = status
 	inv.UpdatedAt = time.Now()
+	return previous
+}
+
+// CaseFile is the self-contained evidence export of an investigation:
+// case metadata, investigator tags, the traced flow graph, its timeline,
+// exchange exits and recovery totals, stamped with the engine snapshot
+// that produced them.
+type CaseFile struct {
+	SnapshotID  int       `json:"snapshotId"` // Heuristics engine version (CurrentSnapshotID)
+	GeneratedAt time.Time `json:"generatedAt"`
+
+	CaseID         string      `json:"caseId"`
+	Name           string      `json:"name"`
+	Description    string      `json:"description"`
+	Status         string      `json:"status"`
+	TheftAddresses []string    `json:"theftAddresses"`
+	CreatedAt      time.Time   `json:"createdAt"`
+	UpdatedAt      time.Time   `json:"updatedAt"`
+	TraceConfig    TraceConfig `json:"traceConfig"`
+
+	TaggedAddresses []TaggedAddress `json:"taggedAddresses"`
+	Trace           *CaseTrace      `json:"trace"` // nil until a trace has run
+	Nodes           []FlowNode      `json:"nodes"`
+	Edges           []FlowEdge      `json:"edges"`
+	Timeline        []TimelineEvent `json:"timeline"`
+	ExchangeExits   []FlowNode      `json:"exchangeExits"`
+	Recovery        CaseRecovery    `json:"recovery"`
+}
+
+// CaseTrace summarizes the flow graph of a case file
+type CaseTrace struct {
+	TotalTracked  int64     `json:"totalTracked"`
+	MaxHopReached int       `json:"maxHopReached"`
+	ExchangeExits int       `json:"exchangeExits"`
+	MixersPassed  int       `json:"mixersPassed"`
+	BridgeExits   int       `json:"bridgeExits"`
+	BurnedValue   int64     `json:"burnedValue"`
+	Truncated     bool      `json:"truncated"`
+	TracedAt      time.Time `json:"tracedAt"`
+}
+
+// CaseRecovery is the value reaching identified exchange exits
+type CaseRecovery struct {
+	TotalStolen      int64   `json:"totalStolen"`
+	TotalRecoverable int64   `json:"totalRecoverable"`
+	RecoveryRate     float64 `json:"recoveryRate"`
+}
+
+// CaseFile builds the evidence export of the case
+func (inv *Investigation) CaseFile() CaseFile {
+	inv.mu.RLock()
+	defer inv.mu.RUnlock()
+
+	cf := CaseFile{
+		SnapshotID:      CurrentSnapshotID,
+		GeneratedAt:     time.Now().UTC(),
+		CaseID:          inv.ID,
+		Name:            inv.Name,
+		Description:     inv.Description,
+		Status:          inv.Status,
+		TheftAddresses:  append([]string(nil), inv.TheftAddresses...),
+		CreatedAt:       inv.CreatedAt,
+		UpdatedAt:       inv.UpdatedAt,
+		TraceConfig:     inv.TraceConfig,
+		TaggedAddresses: append([]TaggedAddress(nil), inv.TaggedAddresses...),
+		Nodes:           []FlowNode{},
+		Edges:           []FlowEdge{},
+		Timeline:        inv.timeline(),
+		ExchangeExits:   inv.exchangeExits(),
+	}
+	if g := inv.FlowGraph; g != nil {
+		cf.Trace = &CaseTrace{
+			TotalTracked:  g.TotalTracked,
+			MaxHopReached: g.MaxHopReached,
+			ExchangeExits: g.ExchangeExits,
+			MixersPassed:  g.MixersPassed,
+			BridgeExits:   g.BridgeExits,
+			BurnedValue:   g.BurnedValue,
+			Truncated:     g.Truncated,
+			TracedAt:      g.CreatedAt,
+		}
+		cf.Nodes = append(cf.Nodes, g.Nodes...)
+		cf.Edges = append(cf.Edges, g.Edges...)
+	}
+	if cf.TaggedAddresses == nil {
+		cf.TaggedAddresses = []TaggedAddress{}
+	}
+	if cf.Timeline == nil {
+		cf.Timeline = []TimelineEvent{}
+	}
+	if cf.ExchangeExits == nil {
+		cf.ExchangeExits = []FlowNode{}
+	}
+
+	cf.Recovery = CaseRecovery{TotalStolen: inv.TotalStolen, TotalRecoverable: inv.recovery()}
+	if inv.TotalStolen > 0 {
+		cf.Recovery.RecoveryRate = float64(cf.Recovery.TotalRecoverable) / float64(inv.TotalStolen)
+	}
+	return cf
 }
@@ -0,0 +1,140 @@
+package heuristics
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// memInvestigationStore mimics the investigations/investigation_addresses
+// tables: one row per case and one per (case, address)
+type memInvestigationStore struct {
+	order  []string
+	cases  map[string]models.InvestigationRecord
+	byCase map[string][]models.InvestigationAddressRecord
+}
+
+func newMemInvestigationStore() *memInvestigationStore {
+	return &memInvestigationStore{
+		cases:  make(map[string]models.InvestigationRecord),
+		byCase: make(map[string][]models.InvestigationAddressRecord),
+	}
+}
+
+func (s *memInvestigationStore) SaveInvestigation(_ context.Context, rec models.InvestigationRecord) error {
+	if _, ok := s.cases[rec.CaseID]; !ok {
+		s.order = append(s.order, rec.CaseID)
+	}
+	s.cases[rec.CaseID] = rec
+	return nil
+}
+
+func (s *memInvestigationStore) SaveInvestigationAddress(_ context.Context, caseID, address, label, role, notes, taggedBy string) error {
+	row := models.InvestigationAddressRecord{Address: address, Label: label, Role: role, Notes: notes, TaggedBy: taggedBy}
+	for i, existing := range s.byCase[caseID] {
+		if existing.Address == address {
+			s.byCase[caseID][i] = row
+			return nil
+		}
+	}
+	s.byCase[caseID] = append(s.byCase[caseID], row)
+	return nil
+}
+
+func (s *memInvestigationStore) LoadInvestigations(context.Context) ([]models.InvestigationRecord, error) {
+	var records []models.InvestigationRecord
+	for _, id := range s.order {
+		rec := s.cases[id]
+		rec.Addresses = s.byCase[id]
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func TestInvestigationManager_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	store := newMemInvestigationStore()
+
+	before := NewInvestigationManager()
+	if _, err := before.AttachStore(ctx, store); err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	inv := before.CreateInvestigation("CASE-1", "Exchange hack", "Hot wallet drained", []string{"bc1qtheft1", "bc1qtheft2"}, 5_000_000)
+	if err := before.Save(ctx, inv); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	inv.TagAddress("bc1qsuspect", "Suspect wallet", "suspect", "first hop", "analyst")
+	if err := before.SaveTag(ctx, inv.ID, TaggedAddress{Address: "bc1qsuspect", Label: "Suspect wallet", Role: "suspect", Notes: "first hop", TaggedBy: "analyst"}); err != nil {
+		t.Fatalf("save tag: %v", err)
+	}
+	inv.SetStatus("paused")
+	if err := before.Save(ctx, inv); err != nil {
+		t.Fatalf("save status: %v", err)
+	}
+
+	// Restart: a fresh manager over the same store
+	after := NewInvestigationManager()
+	n, err := after.AttachStore(ctx, store)
+	if err != nil || n != 1 {
+		t.Fatalf("Expected one case reloaded. Got %d (%v)", n, err)
+	}
+	got := after.GetInvestigation("CASE-1")
+	if got == nil {
+		t.Fatalf("Expected CASE-1 to be retrievable after restart")
+	}
+	if got.Name != "Exchange hack" || got.Status != "paused" || got.TotalStolen != 5_000_000 {
+		t.Errorf("Case metadata not restored. Got %+v", got)
+	}
+	if len(got.TheftAddresses) != 2 || got.TheftAddresses[0] != "bc1qtheft1" {
+		t.Errorf("Expected both theft addresses as theft origins. Got %v", got.TheftAddresses)
+	}
+	if len(got.TaggedAddresses) != 1 || got.TaggedAddresses[0].Address != "bc1qsuspect" || got.TaggedAddresses[0].TaggedBy != "analyst" {
+		t.Errorf("Expected the investigator tag restored. Got %+v", got.TaggedAddresses)
+	}
+}
+
+func TestInvestigationManager_InMemoryWithoutStore(t *testing.T) {
+	m := NewInvestigationManager()
+	inv := m.CreateInvestigation("CASE-2", "Scam", "", []string{"bc1qtheft"}, 0)
+	if m.Persistent() {
+		t.Errorf("Expected no store attached")
+	}
+	if err := m.Save(context.Background(), inv); err != nil {
+		t.Errorf("Save without a store must be a no-op. Got %v", err)
+	}
+}
+
+func TestInvestigation_ConcurrentAccess(t *testing.T) {
+	m := NewInvestigationManager()
+	inv := m.CreateInvestigation("CASE-race", "Race", "", []string{"theft"}, 1_000_000)
+	chain := fakeChain{
+		"theft": {simpleSpend("tx1", "theft", 1_000_000, 100,
+			models.TxOut{Address: "hopA", Value: 990_000},
+		)},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(5)
+		go func() { defer wg.Done(); inv.RunTrace(context.Background(), chain) }()
+		go func() { defer wg.Done(); inv.TagAddress("hopA", "Mule", "suspect", "", "") }()
+		go func() { defer wg.Done(); inv.SetStatus("paused") }()
+		go func() { defer wg.Done(); inv.ComputeRecovery(); _ = m.Save(context.Background(), inv) }()
+		go func() {
+			defer wg.Done()
+			_ = inv.CaseFile()
+			if _, err := json.Marshal(inv); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	snap := inv.Snapshot()
+	if snap.Status != "paused" || snap.FlowGraph == nil || len(snap.TaggedAddresses) != 1 {
+		t.Errorf("Unexpected final state: status %q, graph %v, tags %d", snap.Status, snap.FlowGraph != nil, len(snap.TaggedAddresses))
+	}
+}
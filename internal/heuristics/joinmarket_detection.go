@@ -0,0 +1,146 @@
+package heuristics
+
+import (
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// JoinMarket CoinJoin Detection
+//
+// JoinMarket is a maker/taker market: a taker pays makers a small
+// coordination fee to join a single-denomination CoinJoin. Its on-chain
+// shape differs from Whirlpool (fixed pools, no change) and WabiSabi
+// (many denominations):
+//
+//   - N equal-value "coinjoin" outputs, one per participant (N = 2..15)
+//   - One change output per maker, of varying size (maker input − amount
+//     + earned fee), plus the taker's change unless the taker swept
+//   - Hence N-1 or N distinct change outputs and ≥ N inputs
+//   - All participants use the same script type (JoinMarket wallets are
+//     homogeneous per release: p2sh-p2wpkh, later native p2wpkh)
+//
+// Two-party joins are indistinguishable from ordinary batched payments,
+// so flagging requires at least jmMinParticipants.
+//
+// References:
+//   - JoinMarket-Org/joinmarket-clientserver, docs/PROTOCOL.md
+//   - Möser & Böhme, "Join Me on a Market for Anonymity" (WEIS 2016)
+
+const (
+	jmMinParticipants = 3
+	jmMaxParticipants = 15
+	jmFlagConfidence  = 0.6
+)
+
+// JoinMarketResult holds JoinMarket structural detection results
+type JoinMarketResult struct {
+	IsJoinMarket  bool    `json:"isJoinMarket"`
+	Participants  int     `json:"participants"`  // Equal-value coinjoin outputs (taker + makers)
+	Denomination  int64   `json:"denomination"`  // CoinJoin amount chosen by the taker
+	ChangeOutputs int     `json:"changeOutputs"` // Maker (and taker) change outputs
+	TakerSwept    bool    `json:"takerSwept"`    // N-1 change outputs: taker spent its whole input
+	Confidence    float64 `json:"confidence"`    // 0-1
+}
+
+// DetectJoinMarket checks a transaction for the JoinMarket maker/taker shape
+func DetectJoinMarket(tx models.Transaction) JoinMarketResult {
+	result := JoinMarketResult{}
+	if len(tx.Outputs) < 2*jmMinParticipants-1 {
+		return result
+	}
+
+	// Exactly one denomination group: the coinjoin amount
+	counts := make(map[int64]int)
+	for _, out := range tx.Outputs {
+		if isOPReturn(out.ScriptPubKey) {
+			return result // JoinMarket carries no data outputs (unlike Whirlpool Tx0)
+		}
+		counts[out.Value]++
+	}
+	var denom int64
+	n := 0
+	groups := 0
+	for value, count := range counts {
+		if count < 2 {
+			continue
+		}
+		groups++
+		if count > n || (count == n && value > denom) {
+			denom, n = value, count
+		}
+	}
+	if groups != 1 || n < jmMinParticipants || n > jmMaxParticipants {
+		return result
+	}
+
+	// Every participant funds at least one input
+	if len(tx.Inputs) < n {
+		return result
+	}
+
+	// Remaining outputs are change: N-1 (taker sweep) or N, all distinct
+	change := len(tx.Outputs) - n
+	if change != n && change != n-1 {
+		return result
+	}
+
+	result.Participants = n
+	result.Denomination = denom
+	result.ChangeOutputs = change
+	result.TakerSwept = change == n-1
+
+	// Makers' change must be funded: total input covers denominations + change
+	var totalIn, totalOut int64
+	for _, in := range tx.Inputs {
+		totalIn += in.Value
+	}
+	for _, out := range tx.Outputs {
+		totalOut += out.Value
+	}
+	if totalIn > 0 && totalIn < totalOut {
+		return result
+	}
+
+	conf := 0.55
+	if !result.TakerSwept {
+		conf += 0.1 // Canonical N + N layout
+	}
+	if n >= 4 {
+		conf += 0.1 // Larger single-denomination joins are rarely payments
+	}
+	if homogeneousScriptTypes(tx) {
+		conf += 0.15
+	}
+	if conf > 0.95 {
+		conf = 0.95
+	}
+	result.Confidence = conf
+	result.IsJoinMarket = conf >= jmFlagConfidence
+
+	return result
+}
+
+// homogeneousScriptTypes reports whether all inputs and outputs share one address type
+func homogeneousScriptTypes(tx models.Transaction) bool {
+	kind := ""
+	check := func(addr string) bool {
+		if addr == "" {
+			return true // Unknown (e.g. OP_RETURN / no address): don't penalize
+		}
+		t := detectAddressType(addr)
+		if kind == "" {
+			kind = t
+		}
+		return t == kind
+	}
+	for _, in := range tx.Inputs {
+		if !check(in.Address) {
+			return false
+		}
+	}
+	for _, out := range tx.Outputs {
+		if !check(out.Address) {
+			return false
+		}
+	}
+	return kind != ""
+}
@@ -0,0 +1,96 @@
+package heuristics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// sampleJoinMarket builds an n-party JoinMarket join: the taker funds
+// denom+fees and gets change, each maker gets input − denom + cjfee back
+func sampleJoinMarket(n int, takerSweep bool) models.Transaction {
+	const denom int64 = 12_345_678
+	tx := models.Transaction{Txid: "jm", Version: 2, Fee: 3_000, Vsize: 900}
+	for i := 0; i < n; i++ {
+		in := denom + int64(1_000_000*(i+1))
+		tx.Inputs = append(tx.Inputs, models.TxIn{
+			Address: fmt.Sprintf("bc1qjminput%d", i),
+			Value:   in,
+		})
+		tx.Outputs = append(tx.Outputs, models.TxOut{Address: fmt.Sprintf("bc1qjmcj%d", i), Value: denom})
+
+		if i == 0 {
+			if !takerSweep {
+				tx.Outputs = append(tx.Outputs, models.TxOut{Address: "bc1qjmtakerchange", Value: in - denom - 3_000 - int64(250*(n-1))})
+			}
+			continue
+		}
+		tx.Outputs = append(tx.Outputs, models.TxOut{Address: fmt.Sprintf("bc1qjmchange%d", i), Value: in - denom + 250})
+	}
+	if takerSweep {
+		tx.Inputs[0].Value = denom + 3_000 + int64(250*(n-1))
+	}
+	return tx
+}
+
+func TestDetectJoinMarket_MakerTakerShape(t *testing.T) {
+	result := DetectJoinMarket(sampleJoinMarket(6, false))
+
+	if !result.IsJoinMarket {
+		t.Fatalf("Expected a JoinMarket detection. Got %+v", result)
+	}
+	if result.Participants != 6 || result.ChangeOutputs != 6 || result.TakerSwept {
+		t.Errorf("Expected 6 participants with 6 change outputs. Got %+v", result)
+	}
+	if result.Denomination != 12_345_678 {
+		t.Errorf("Expected the coinjoin denomination. Got %d", result.Denomination)
+	}
+}
+
+func TestDetectJoinMarket_TakerSweep(t *testing.T) {
+	result := DetectJoinMarket(sampleJoinMarket(4, true))
+
+	if !result.IsJoinMarket || !result.TakerSwept || result.ChangeOutputs != 3 {
+		t.Errorf("Expected a swept-taker JoinMarket join. Got %+v", result)
+	}
+}
+
+func TestDetectJoinMarket_RejectsOtherShapes(t *testing.T) {
+	// Whirlpool mix: equal outputs, no change
+	mix := models.Transaction{}
+	for i := 0; i < 5; i++ {
+		mix.Inputs = append(mix.Inputs, models.TxIn{Address: fmt.Sprintf("bc1qin%d", i), Value: 1_000_500})
+		mix.Outputs = append(mix.Outputs, models.TxOut{Address: fmt.Sprintf("bc1qout%d", i), Value: 1_000_000})
+	}
+	if DetectJoinMarket(mix).IsJoinMarket {
+		t.Error("Expected a Whirlpool mix not to match JoinMarket")
+	}
+
+	// Two-denomination join (WabiSabi-like)
+	multi := sampleJoinMarket(4, false)
+	multi.Outputs[1].Value = 777
+	multi.Outputs[3].Value = 777
+	if DetectJoinMarket(multi).IsJoinMarket {
+		t.Error("Expected multiple denomination groups not to match JoinMarket")
+	}
+
+	// Too few participants
+	if DetectJoinMarket(sampleJoinMarket(2, false)).IsJoinMarket {
+		t.Error("Expected 2-party joins not to be flagged")
+	}
+}
+
+func TestAnalyzeTx_FlagsJoinMarket(t *testing.T) {
+	res := AnalyzeTx(sampleJoinMarket(6, false))
+
+	if res.HeuristicFlags&FlagIsJoinMarket == 0 {
+		t.Fatalf("Expected FlagIsJoinMarket. Flags: %b", res.HeuristicFlags)
+	}
+	if res.HeuristicFlags&FlagIsWhirlpoolStruct != 0 {
+		t.Error("Expected a JoinMarket join not to be labelled Whirlpool")
+	}
+	if res.JoinMarketSize != 6 {
+		t.Errorf("Expected JoinMarketSize 6. Got %d", res.JoinMarketSize)
+	}
+}
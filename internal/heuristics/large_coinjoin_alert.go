@@ -0,0 +1,122 @@
+package heuristics
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Large CoinJoin Alerting (participant threshold + coordinator attribution)
+//
+// Operators watching for large mixing events (big WabiSabi rounds moving
+// hundreds of BTC at once) want a dedicated alert rather than one more
+// CoinJoin in the feed:
+//
+//   - Participants: EstimateParticipants over the detected CoinJoin
+//   - Threshold: LARGE_COINJOIN_PARTICIPANTS (SetLargeCoinJoinThreshold)
+//   - Attribution: mixer type, plus the Whirlpool pool when identified
+
+// defaultLargeCoinJoinThreshold is above any Whirlpool/JoinMarket round, so
+// by default only large WabiSabi (or unattributed) rounds alert
+const defaultLargeCoinJoinThreshold = 100
+
+var largeCoinJoinThreshold atomic.Int64
+
+func init() {
+	largeCoinJoinThreshold.Store(defaultLargeCoinJoinThreshold)
+}
+
+// SetLargeCoinJoinThreshold sets the participant count a CoinJoin must
+// exceed to raise a large_coinjoin alert (LARGE_COINJOIN_PARTICIPANTS)
+func SetLargeCoinJoinThreshold(n int) {
+	if n < 2 {
+		n = 2
+	}
+	largeCoinJoinThreshold.Store(int64(n))
+}
+
+// LargeCoinJoinThreshold returns the current participant threshold
+func LargeCoinJoinThreshold() int {
+	return int(largeCoinJoinThreshold.Load())
+}
+
+// MixerType names the CoinJoin implementation behind a flag set, with the
+// same precedence as the persisted mixer views
+func MixerType(flags uint64) string {
+	switch {
+	case flags&uint64(FlagIsWhirlpoolStruct) > 0:
+		return "Whirlpool"
+	case flags&uint64(FlagIsWasabiSuspect) > 0:
+		return "WabiSabi"
+	case flags&JoinMarketFlagMask > 0:
+		return "JoinMarket"
+	default:
+		return "CoinJoin"
+	}
+}
+
+// EstimateParticipants estimates how many users took part in a CoinJoin.
+// Fixed-shape protocols are exact (Whirlpool: one input each; JoinMarket:
+// one equal-value output each). For WabiSabi and unattributed rounds every
+// participant brings at least one input address and receives at least one
+// output, so the smaller of the two bounds the count; the anonymity set
+// (linkages the solver proved) is the floor.
+func EstimateParticipants(tx models.Transaction, result models.PrivacyAnalysisResult) int {
+	switch MixerType(result.HeuristicFlags) {
+	case "Whirlpool":
+		return len(tx.Inputs)
+	case "JoinMarket":
+		if result.JoinMarketSize > 0 {
+			return result.JoinMarketSize
+		}
+	}
+
+	addrs := make(map[string]bool)
+	for _, in := range tx.Inputs {
+		if in.Address != "" {
+			addrs[in.Address] = true
+		}
+	}
+	estimate := len(addrs)
+	if estimate == 0 {
+		estimate = len(tx.Inputs)
+	}
+	if len(tx.Outputs) < estimate {
+		estimate = len(tx.Outputs)
+	}
+	if result.AnonSet > estimate {
+		estimate = result.AnonSet
+	}
+	return estimate
+}
+
+// LargeCoinJoinAlert builds a large_coinjoin alert for a detected CoinJoin
+// whose participant estimate exceeds the threshold
+func LargeCoinJoinAlert(tx models.Transaction, result models.PrivacyAnalysisResult) (Alert, bool) {
+	participants := EstimateParticipants(tx, result)
+	threshold := LargeCoinJoinThreshold()
+	if participants <= threshold {
+		return Alert{}, false
+	}
+
+	coordinator := MixerType(result.HeuristicFlags)
+	if result.WhirlpoolPool != "" {
+		coordinator += " " + result.WhirlpoolPool
+	}
+
+	var value int64
+	for _, out := range tx.Outputs {
+		value += out.Value
+	}
+
+	return Alert{
+		Severity:  "medium",
+		AlertType: "large_coinjoin",
+		Title:     fmt.Sprintf("Large CoinJoin: ~%d participants (%s)", participants, coordinator),
+		Description: fmt.Sprintf("%s round with ~%d participants (threshold %d), %d inputs / %d outputs, %s mixed, anonset %d.",
+			coordinator, participants, threshold, len(tx.Inputs), len(tx.Outputs), formatBTC(value), result.AnonSet),
+		TxID:  tx.Txid,
+		Value: value,
+	}, true
+}
@@ -0,0 +1,69 @@
+package heuristics
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// wabiSabiRound builds an n-participant round: one input and one
+// 0.05 BTC output per participant, plus a smaller denomination every 4th
+func wabiSabiRound(n int) (models.Transaction, models.PrivacyAnalysisResult) {
+	tx := models.Transaction{Txid: fmt.Sprintf("wabisabi_%d", n)}
+	for i := 0; i < n; i++ {
+		tx.Inputs = append(tx.Inputs, models.TxIn{Address: fmt.Sprintf("bc1qin%d", i), Value: 5_100_000})
+		tx.Outputs = append(tx.Outputs, models.TxOut{Address: fmt.Sprintf("bc1qout%d", i), Value: 5_000_000})
+		if i%4 == 0 {
+			tx.Outputs = append(tx.Outputs, models.TxOut{Address: fmt.Sprintf("bc1qsmall%d", i), Value: 65_536})
+		}
+	}
+	res := models.PrivacyAnalysisResult{Txid: tx.Txid, AnonSet: 5, HeuristicFlags: uint64(FlagIsWasabiSuspect | FlagLikelyCollabConstruct)}
+	return tx, res
+}
+
+func TestLargeCoinJoinAlert_Threshold(t *testing.T) {
+	defer SetLargeCoinJoinThreshold(defaultLargeCoinJoinThreshold)
+	SetLargeCoinJoinThreshold(150)
+
+	big, bigRes := wabiSabiRound(200)
+	if got := EstimateParticipants(big, bigRes); got != 200 {
+		t.Errorf("Expected 200 participants (one input address each). Got %d", got)
+	}
+	alert, ok := LargeCoinJoinAlert(big, bigRes)
+	if !ok {
+		t.Fatal("Expected a 200-participant round to exceed the 150 threshold")
+	}
+	if alert.AlertType != "large_coinjoin" || alert.TxID != big.Txid || !strings.Contains(alert.Title, "WabiSabi") {
+		t.Errorf("Expected a WabiSabi-attributed large_coinjoin alert. Got %+v", alert)
+	}
+
+	small, smallRes := wabiSabiRound(120)
+	if _, ok := LargeCoinJoinAlert(small, smallRes); ok {
+		t.Error("Expected no alert for a 120-participant round under a 150 threshold")
+	}
+}
+
+func TestEstimateParticipants_FixedShapeProtocols(t *testing.T) {
+	whirlpool := models.Transaction{}
+	for i := 0; i < 5; i++ {
+		whirlpool.Inputs = append(whirlpool.Inputs, models.TxIn{Address: "bc1qsame", Value: 1_000_500})
+		whirlpool.Outputs = append(whirlpool.Outputs, models.TxOut{Value: 1_000_000})
+	}
+	res := models.PrivacyAnalysisResult{HeuristicFlags: uint64(FlagIsWhirlpoolStruct), WhirlpoolPool: "0.01btc"}
+	if got := EstimateParticipants(whirlpool, res); got != 5 {
+		t.Errorf("Expected one Whirlpool participant per input. Got %d", got)
+	}
+
+	defer SetLargeCoinJoinThreshold(defaultLargeCoinJoinThreshold)
+	SetLargeCoinJoinThreshold(4)
+	if alert, ok := LargeCoinJoinAlert(whirlpool, res); !ok || !strings.Contains(alert.Title, "Whirlpool 0.01btc") {
+		t.Errorf("Expected the pool in the attribution. Got %+v", alert)
+	}
+
+	jm := models.PrivacyAnalysisResult{HeuristicFlags: uint64(FlagIsJoinMarket), JoinMarketSize: 7}
+	if got := EstimateParticipants(whirlpool, jm); got != 7 {
+		t.Errorf("Expected the JoinMarket taker+maker count. Got %d", got)
+	}
+}
@@ -30,6 +30,10 @@ import (
 //   - Sweeps ALL funds to one party
 //   - 1 output (confiscation of entire channel)
 //
+//   KNOWN CHANNEL POINT (ln_channel_points.go):
+//   - Spends a funding outpoint from gossip data: a deterministic close,
+//     overriding the structural guesses above
+//
 // References:
 //   - BOLT #3: "Bitcoin Transaction and Script Formats"
 //   - Tikhomirov et al., "A Quantitative Analysis of the LN" (FC 2020)
@@ -41,6 +45,10 @@ type LightningResult struct {
 	ChannelType       string `json:"channelType"`       // "funding"/"cooperative-close"/"force-close"/"penalty"/"none"
 	EstimatedCapacity int64  `json:"estimatedCapacity"` // Channel capacity in sats
 	HasAnchorOutputs  bool   `json:"hasAnchorOutputs"`  // Modern anchor commitment
+
+	Confidence     float64 `json:"confidence"`               // 0.99 for a known channel point, lower for structure alone
+	ChannelPoint   string  `json:"channelPoint,omitempty"`   // Known funding outpoint spent by a close
+	ShortChannelID string  `json:"shortChannelId,omitempty"` // Gossip ID of that channel
 }
 
 // Common Lightning channel capacities (sats)
@@ -57,14 +65,21 @@ var lightningChannelSizes = []int64{
 	100000000, // 1.0 BTC
 }
 
-// DetectLightningChannel analyzes a transaction for LN channel signatures
+// DetectLightningChannel analyzes a transaction for LN channel signatures.
+// Spends of known channel points (GetGlobalChannelPointIndex) are
+// classified deterministically; everything else by structure.
 func DetectLightningChannel(tx models.Transaction) LightningResult {
+	if known, ok := detectKnownChannelClose(tx, GetGlobalChannelPointIndex()); ok {
+		return known
+	}
+
 	result := LightningResult{ChannelType: "none"}
 
 	// Check for funding transaction pattern
 	if detectLNFunding(tx) {
 		result.IsLightningTx = true
 		result.ChannelType = "funding"
+		result.Confidence = structuralLNConfidence
 		result.EstimatedCapacity = findChannelOutput(tx)
 		return result
 	}
@@ -73,6 +88,7 @@ func DetectLightningChannel(tx models.Transaction) LightningResult {
 	if detectCooperativeClose(tx) {
 		result.IsLightningTx = true
 		result.ChannelType = "cooperative-close"
+		result.Confidence = structuralLNConfidence
 		result.EstimatedCapacity = sumInputValues(tx)
 		return result
 	}
@@ -81,6 +97,7 @@ func DetectLightningChannel(tx models.Transaction) LightningResult {
 	if detectForceClose(tx) {
 		result.IsLightningTx = true
 		result.ChannelType = "force-close"
+		result.Confidence = structuralLNConfidence
 		result.EstimatedCapacity = sumInputValues(tx)
 		result.HasAnchorOutputs = detectAnchorOutputs(tx)
 		return result
@@ -90,6 +107,7 @@ func DetectLightningChannel(tx models.Transaction) LightningResult {
 	if detectPenaltyTx(tx) {
 		result.IsLightningTx = true
 		result.ChannelType = "penalty"
+		result.Confidence = structuralLNConfidence
 		result.EstimatedCapacity = sumInputValues(tx)
 		return result
 	}
@@ -5,6 +5,8 @@ import (
 	"encoding/hex"
 	"fmt"
 	"math"
+	"strings"
+	"sync/atomic"
 
 	"github.com/google/uuid"
 	"github.com/rawblock/coinjoin-engine/pkg/models"
@@ -22,6 +24,7 @@ const (
 	EdgeTypeDustLink          = 8  // Dust-based address linking
 	EdgeTypeUnmixLink         = 9  // Deterministic CoinJoin unmixing
 	EdgeTypeTransitive        = 10 // Multi-hop transitive evidence
+	EdgeTypeTimingCorrelation = 11 // Same/next-block self-spend timing link
 )
 
 // Dependency Groups (Used to discount overlapping heuristics to prevent Probability Mass explosion)
@@ -57,6 +60,7 @@ const (
 	FlagSuspiciousFeePattern  = 1 << 17 // Fee-rate anomaly (rounding, overpay)
 	FlagIsPeelChain           = 1 << 18 // Serial 1-in-2-out change linking
 	FlagTimingAnomaly         = 1 << 19 // Temporal coordination signature
+	FlagRandomizedChange      = 1 << 43 // Cluster randomizes change position (anti-fingerprint)
 )
 
 // Layer 3: Policy-Gated Hypotheses (Brittle by design, used for gating)
@@ -66,13 +70,29 @@ const (
 	FlagIsSilentPayment  = 1 << 22 // BIP352 (breaks output scanning)
 	FlagIsWasabiSuspect  = 1 << 23 // Extensive WabiSabi graph
 	FlagIsJoinMarketBond = 1 << 24 // BIP46 OP_CHECKLOCKTIMEVERIFY timelock
+	FlagIsJoinMarket     = 1 << 41 // JoinMarket maker/taker CoinJoin shape
 )
 
+// CoinJoinFlagMask holds every flag that marks a transaction as a CoinJoin.
+// A new CoinJoin detector adds its flag here so that every consumer (mempool
+// poller, block scanner, fund tracer, risk scoring) picks it up.
+const CoinJoinFlagMask = FlagIsWhirlpoolStruct | FlagIsWasabiSuspect | FlagLikelyCollabConstruct |
+	FlagIsJoinMarketBond | FlagIsJoinMarket
+
+// JoinMarketFlagMask holds the flags attributing a CoinJoin to JoinMarket
+const JoinMarketFlagMask = FlagIsJoinMarket | FlagIsJoinMarketBond
+
+// IsCoinJoinResult reports whether a heuristic bitmask marks a CoinJoin
+func IsCoinJoinResult(flags uint64) bool {
+	return flags&CoinJoinFlagMask != 0
+}
+
 // Layer 4: Forensic Intelligence (Phase 14 — Active threat signals)
 const (
 	FlagDustAttackSuspect = 1 << 25 // Dust surveillance UTXO detected
 	FlagWeakMix           = 1 << 26 // CoinJoin with unmixable outputs
 	FlagIsHubTransaction  = 1 << 27 // Hub/exchange-like fan-out pattern
+	FlagIsBatchPayout     = 1 << 42 // Exchange batch withdrawal (diverse payouts)
 	FlagDustConsolidation = 1 << 28 // Dust inputs consolidated (post-attack)
 	FlagHighTraceability  = 1 << 29 // Calibrated traceability > 0.8
 )
@@ -83,6 +103,7 @@ const (
 	FlagKnownServicePattern = 1 << 31 // Value matches known exchange/service fee
 	FlagIsMultisig          = 1 << 32 // M-of-N multisig script detected
 	FlagHasOPReturn         = 1 << 33 // OP_RETURN data payload present
+	FlagIsBIP47Notification = 1 << 44 // BIP47 payment-code notification (sender ↔ recipient link)
 )
 
 // Layer 6: Operational Intelligence (Phase 16 — Entity resolution & risk)
@@ -99,24 +120,90 @@ const (
 	FlagStrategicConsolidation = 1 << 39 // Planned UTXO consolidation pattern
 )
 
+// Layer 8: Relay Policy (mempool surveillance)
+const (
+	FlagNonStandard = 1 << 40 // Violates Bitcoin Core standardness (dust, datacarrier, sigops...)
+)
+
 const CurrentSnapshotID = 202602235 // Version of the Heuristics Engine (Phase 17)
 
+// LLR saturation bounds. No single on-chain observation is ever certain, so
+// probabilities are clamped to [LLRProbEpsilon, 1-LLRProbEpsilon] before
+// conversion. This bounds every edge to ±MaxEdgeLLR (≈ ±6.0) instead of the
+// old ±999 sentinels, which summed across hops into meaningless posteriors.
+const LLRProbEpsilon = 1e-6
+
+var MaxEdgeLLR = math.Log10((1 - LLRProbEpsilon) / LLRProbEpsilon)
+
 // ProbToLLR converts a real probability [0,1] into a Log-Likelihood Ratio.
 // LLR = log10( P(E|H1) / P(E|H0) )
 // For simplicity in this implementation, we convert the probability P directly into a weight logic.
-// LLR = log10( P / (1-P) )
+// LLR = log10( P / (1-P) ), with P clamped to [ε, 1-ε]
 func ProbToLLR(probability float64) float64 {
-	if probability >= 1.0 {
-		return 999.0 // Infinite certainty
-	}
-	if probability <= 0.0 {
-		return -999.0 // Infinite negative certainty
+	if math.IsNaN(probability) {
+		return 0 // No information
 	}
+	probability = math.Max(LLRProbEpsilon, math.Min(1-LLRProbEpsilon, probability))
 	return math.Log10(probability / (1.0 - probability))
 }
 
+// ClampLLR bounds a single edge's LLR to ±MaxEdgeLLR. Edges persisted before
+// the clamp (or built by hand) may still carry ±999 or non-finite scores.
+func ClampLLR(llr float64) float64 {
+	if math.IsNaN(llr) {
+		return 0
+	}
+	return math.Max(-MaxEdgeLLR, math.Min(MaxEdgeLLR, llr))
+}
+
+// CIOHPolicy bounds the CIOH edges emitted per transaction.
+// Large consolidations (hundreds of inputs) would otherwise write N-1 rows
+// to evidence_edge, all carrying the same single piece of evidence.
+type CIOHPolicy struct {
+	MinConfidence   float64 `json:"minConfidence"`   // CIOH edges below this confidence are not emitted
+	HyperEdgeInputs int     `json:"hyperEdgeInputs"` // Distinct inputs at which one hyper-edge replaces the star
+}
+
+// DefaultCIOHPolicy emits both script-homogeneity tiers (0.95 and 0.60)
+// and collapses consolidations of 20+ distinct inputs
+func DefaultCIOHPolicy() CIOHPolicy {
+	return CIOHPolicy{
+		MinConfidence:   0.5,
+		HyperEdgeInputs: 20,
+	}
+}
+
+var ciohPolicy atomic.Pointer[CIOHPolicy]
+
+func init() {
+	p := DefaultCIOHPolicy()
+	ciohPolicy.Store(&p)
+}
+
+// SetCIOHPolicy replaces the process-wide CIOH emission policy
+// (CIOH_MIN_CONFIDENCE, CIOH_HYPEREDGE_INPUTS). Out-of-range fields fall
+// back to their defaults.
+func SetCIOHPolicy(p CIOHPolicy) {
+	def := DefaultCIOHPolicy()
+	if p.MinConfidence < 0 || p.MinConfidence > 1 {
+		p.MinConfidence = def.MinConfidence
+	}
+	if p.HyperEdgeInputs < 3 {
+		p.HyperEdgeInputs = def.HyperEdgeInputs
+	}
+	ciohPolicy.Store(&p)
+}
+
+// CurrentCIOHPolicy returns the process-wide CIOH emission policy
+func CurrentCIOHPolicy() CIOHPolicy {
+	return *ciohPolicy.Load()
+}
+
 // GenerateCIOHEdges applies the Common-Input-Ownership Heuristic.
-// If the transaction is NOT a CoinJoin, it binds all inputs together.
+// If the transaction is NOT a CoinJoin or suspected PayJoin, it binds all inputs together
+// (less any suspected MuSig2 key-path inputs):
+// a star from the primary input, or a single hyper-edge (SrcNodeID + Members)
+// for consolidations reaching CIOHPolicy.HyperEdgeInputs.
 func GenerateCIOHEdges(tx models.Transaction, isCoinJoin bool, currentHeight int) []models.EvidenceEdge {
 	var edges []models.EvidenceEdge
 
@@ -151,29 +238,98 @@ func GenerateCIOHEdges(tx models.Transaction, isCoinJoin bool, currentHeight int
 		return edges
 	}
 
-	// 2. If it is NOT a CoinJoin, apply Standard CIOH (Assume all inputs belong to 1 entity)
+	// 2. Suspected PayJoin: the receiver contributed an input, so ownership is
+	//    split. Emit negative gating edges instead of CIOH merges.
+	if pj := DetectPayJoin(tx); pj.IsPayJoin {
+		primaryInput := tx.Inputs[0].Address
+		for i := 1; i < len(tx.Inputs); i++ {
+			edges = append(edges, createEdge(
+				primaryInput,
+				tx.Inputs[i].Address,
+				EdgeTypePayJoinSuspect,
+				-ProbToLLR(pj.Confidence), // Evidence AGAINST same-entity inputs
+				DepGroupCoordination,
+				currentHeight,
+			))
+		}
+		return edges
+	}
+
+	// 3. Suspected MuSig2 key-path inputs may be held by several parties.
+	//    The suspicion is too weak for gating edges; those inputs simply
+	//    sit out the CIOH merge below.
+	inputs := tx.Inputs
+	if musig := DetectMuSig2Suspect(tx); musig.IsSuspect {
+		shared := make(map[int]bool, len(musig.KeyPathInputs))
+		for _, i := range musig.KeyPathInputs {
+			shared[i] = true
+		}
+		inputs = make([]models.TxIn, 0, len(tx.Inputs))
+		for i, in := range tx.Inputs {
+			if !shared[i] {
+				inputs = append(inputs, in)
+			}
+		}
+		if len(inputs) < 2 {
+			return edges
+		}
+	}
+
+	// 4. Otherwise apply Standard CIOH (Assume all inputs belong to 1 entity)
 	// Factor Graph Math: We assign confidence based on script type homogeneity.
-	primaryInput := tx.Inputs[0].Address
+	primaryInput := inputs[0].Address
 
 	// Check if all inputs are the same type (e.g. all Native Segwit)
 	allSameType := true
-	for i := 1; i < len(tx.Inputs); i++ {
-		if detectAddressType(tx.Inputs[i].Address) != detectAddressType(primaryInput) {
+	for i := 1; i < len(inputs); i++ {
+		if detectAddressType(inputs[i].Address) != detectAddressType(primaryInput) {
 			allSameType = false
 			break
 		}
 	}
 
-	for i := 1; i < len(tx.Inputs); i++ {
-		// If they mix legacy and segwit, CIOH confidence drops significantly
-		confidence := 0.95
-		if !allSameType {
-			confidence = 0.60
+	// If they mix legacy and segwit, CIOH confidence drops significantly
+	confidence := 0.95
+	if !allSameType {
+		confidence = 0.60
+	}
+	policy := CurrentCIOHPolicy()
+	if confidence < policy.MinConfidence {
+		return edges
+	}
+
+	// Repeated addresses and self-links carry no clustering information
+	seen := map[string]bool{primaryInput: true}
+	var members []string
+	for i := 1; i < len(inputs); i++ {
+		addr := inputs[i].Address
+		if addr == "" || seen[addr] {
+			continue
 		}
+		seen[addr] = true
+		members = append(members, addr)
+	}
 
+	// Large consolidation: one hyper-edge binds every input to the primary
+	// instead of N-1 pairwise rows
+	if len(members)+1 >= policy.HyperEdgeInputs {
+		edge := createEdge(
+			primaryInput,
+			"consolidation:"+tx.Txid,
+			EdgeTypeCIOH,
+			ProbToLLR(confidence),
+			DepGroupScriptHomogeneity,
+			currentHeight,
+		)
+		edge.Members = members
+		edge.AuditHash = hyperEdgeAuditHash(edge)
+		return append(edges, edge)
+	}
+
+	for _, addr := range members {
 		edges = append(edges, createEdge(
 			primaryInput,
-			tx.Inputs[i].Address,
+			addr,
 			EdgeTypeCIOH,
 			ProbToLLR(confidence),
 			DepGroupScriptHomogeneity, // Prevents double-counting if we later add "round numbers"
@@ -184,6 +340,14 @@ func GenerateCIOHEdges(tx models.Transaction, isCoinJoin bool, currentHeight int
 	return edges
 }
 
+// hyperEdgeAuditHash extends the audit hash over a hyper-edge's members so
+// the bound address set is covered by the immutability digest
+func hyperEdgeAuditHash(edge models.EvidenceEdge) string {
+	hashPayload := fmt.Sprintf("%s|%s", edge.AuditHash, strings.Join(edge.Members, ","))
+	auditHash := sha256.Sum256([]byte(hashPayload))
+	return hex.EncodeToString(auditHash[:])
+}
+
 // Helper to instantiate an EvidenceEdge with Audit Hashing
 func createEdge(src, dst string, edgeType int, llr float64, depGroup int, height int) models.EvidenceEdge {
 	edgeID := uuid.New().String()
@@ -1,6 +1,7 @@
 package heuristics
 
 import (
+	"fmt"
 	"math"
 	"testing"
 
@@ -13,8 +14,8 @@ func TestProbToLLR(t *testing.T) {
 		prob     float64
 		expected float64
 	}{
-		{"Absolute Certainty", 1.0, 999.0},
-		{"Absolute Negative Certainty", 0.0, -999.0},
+		{"Absolute Certainty", 1.0, MaxEdgeLLR},
+		{"Absolute Negative Certainty", 0.0, -MaxEdgeLLR},
 		{"High Probability", 0.99, math.Log10(0.99 / 0.01)}, // ~1.995
 		{"Coin Flip", 0.5, 0.0},
 		{"Low Probability", 0.01, math.Log10(0.01 / 0.99)}, // ~-1.995
@@ -83,6 +84,58 @@ func TestGenerateCIOHEdges_CoinJoinGating(t *testing.T) {
 	}
 }
 
+func TestGenerateCIOHEdges_LargeConsolidationBounded(t *testing.T) {
+	tx := models.Transaction{Txid: "consolidation_tx"}
+	for i := 0; i < 100; i++ {
+		tx.Inputs = append(tx.Inputs, models.TxIn{Address: fmt.Sprintf("bc1q_input_%03d", i), Value: 10_000})
+	}
+	tx.Inputs = append(tx.Inputs, models.TxIn{Address: "bc1q_input_007", Value: 10_000}) // Reused address
+
+	edges := GenerateCIOHEdges(tx, false, 800000)
+	if len(edges) != 1 {
+		t.Fatalf("Expected a single consolidation hyper-edge for 100 inputs, got %d edges", len(edges))
+	}
+	edge := edges[0]
+	if edge.EdgeType != EdgeTypeCIOH || edge.SrcNodeID != "bc1q_input_000" || edge.DstNodeID != "consolidation:consolidation_tx" {
+		t.Errorf("Unexpected hyper-edge: %+v", edge)
+	}
+	if len(edge.Members) != 99 {
+		t.Errorf("Expected 99 distinct members besides the primary input, got %d", len(edge.Members))
+	}
+
+	// Clustering is preserved: all 100 addresses end up in one cluster
+	ce := NewClusterEngine()
+	if merges := ce.MergeFromEdges(edges); merges != 99 {
+		t.Errorf("Expected 99 merges from the hyper-edge, got %d", merges)
+	}
+	if size := ce.GetClusterSize("bc1q_input_099"); size != 100 {
+		t.Errorf("Expected a 100-address cluster, got %d", size)
+	}
+	if ce.Contains("consolidation:consolidation_tx") {
+		t.Errorf("The synthetic consolidation node must not join the cluster")
+	}
+}
+
+func TestGenerateCIOHEdges_MinConfidence(t *testing.T) {
+	tx := models.Transaction{
+		Txid: "mixed_scripts_tx",
+		Inputs: []models.TxIn{
+			{Address: "bc1qmixedinput", Value: 1000},
+			{Address: "1LegacyMixedInput", Value: 2000},
+		},
+	}
+	if edges := GenerateCIOHEdges(tx, false, 800000); len(edges) != 1 {
+		t.Fatalf("Expected the 0.60 mixed-script edge under the default policy, got %d", len(edges))
+	}
+
+	SetCIOHPolicy(CIOHPolicy{MinConfidence: 0.9, HyperEdgeInputs: 20})
+	defer SetCIOHPolicy(DefaultCIOHPolicy())
+
+	if edges := GenerateCIOHEdges(tx, false, 800000); len(edges) != 0 {
+		t.Errorf("Expected no edges below the 0.9 minimum confidence, got %d", len(edges))
+	}
+}
+
 func TestBitmaskOperations(t *testing.T) {
 	var bitmask uint64 = 0
 
@@ -105,3 +158,20 @@ func TestBitmaskOperations(t *testing.T) {
 		t.Errorf("Expected Wasabi flag to NOT be present")
 	}
 }
+
+func TestIsCoinJoinResult_CoversEveryCoinJoinFlag(t *testing.T) {
+	for _, flag := range []uint64{FlagIsWhirlpoolStruct, FlagIsWasabiSuspect, FlagLikelyCollabConstruct, FlagIsJoinMarketBond, FlagIsJoinMarket} {
+		if !IsCoinJoinResult(flag) {
+			t.Errorf("Expected flag %#x to mark a CoinJoin", flag)
+		}
+	}
+	if IsCoinJoinResult(FlagIsPayjoinSuspect | FlagLikelyChange) {
+		t.Error("Expected PayJoin/change flags not to mark a CoinJoin")
+	}
+
+	// Risk scoring uses the same mask (it used to miss JoinMarket)
+	res := models.PrivacyAnalysisResult{HeuristicFlags: FlagIsJoinMarket}
+	if !ScoreTransaction(models.Transaction{}, res, nil).IsCoinJoin {
+		t.Error("Expected a JoinMarket result to score as a CoinJoin")
+	}
+}
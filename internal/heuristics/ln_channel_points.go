@@ -0,0 +1,242 @@
+package heuristics
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Known Channel Points (gossip-inferred Lightning channel closes)
+//
+// Public channels are announced over LN gossip with their funding outpoint
+// (the "channel point"). With that list loaded, a close no longer has to be
+// guessed from structure: a transaction spending a known channel point IS
+// the channel's close, and BOLT #3 tells which kind:
+//
+//   - Force close: the commitment transaction encodes the obscured
+//     commitment number with nLockTime's upper byte 0x20 and the funding
+//     input's nSequence upper byte 0x80
+//   - Cooperative close: any other spend of the funding outpoint
+//     (closing_signed, typically nSequence 0xFFFFFFFF)
+//
+// A match overrides DetectLightningChannel's structural guess with high
+// confidence. Lists are loaded from a file, either one channel per line
+// ("txid:vout [capacity] [short_channel_id]") or `lncli describegraph` JSON.
+//
+// References:
+//   - BOLT #3: "Commitment Transaction" (obscured commitment number)
+//   - BOLT #7: "P2P Node and Channel Discovery" (channel_announcement)
+
+const (
+	knownChannelConfidence = 0.99 // Close matched against a known channel point
+	commitmentLockTimeByte = 0x20 // BOLT #3 commitment nLockTime upper byte
+	commitmentSequenceByte = 0x80 // BOLT #3 commitment nSequence upper byte
+	structuralLNConfidence = 0.4  // Structure-only Lightning classification
+)
+
+// KnownChannel is a public channel announced over gossip
+type KnownChannel struct {
+	ChannelPoint   string `json:"channelPoint"`             // Funding outpoint "txid:vout"
+	ShortChannelID string `json:"shortChannelId,omitempty"` // "block x tx x output" or the numeric form
+	Capacity       int64  `json:"capacity,omitempty"`       // Sats (0 = unknown)
+}
+
+// ChannelPointIndex maps funding outpoints to known channels
+type ChannelPointIndex struct {
+	mu       sync.RWMutex
+	channels map[string]KnownChannel
+}
+
+var (
+	globalChannelPoints     *ChannelPointIndex
+	globalChannelPointsOnce sync.Once
+)
+
+// NewChannelPointIndex creates an empty index
+func NewChannelPointIndex() *ChannelPointIndex {
+	return &ChannelPointIndex{channels: make(map[string]KnownChannel)}
+}
+
+// GetGlobalChannelPointIndex returns the process-wide index consulted by
+// DetectLightningChannel (empty unless a list was loaded)
+func GetGlobalChannelPointIndex() *ChannelPointIndex {
+	globalChannelPointsOnce.Do(func() {
+		globalChannelPoints = NewChannelPointIndex()
+	})
+	return globalChannelPoints
+}
+
+// Add indexes a channel; false if its channel point is malformed
+func (idx *ChannelPointIndex) Add(ch KnownChannel) bool {
+	txid, vout, err := parseChannelPoint(ch.ChannelPoint)
+	if err != nil {
+		return false
+	}
+	ch.ChannelPoint = outpointKey(txid, vout)
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	idx.channels[ch.ChannelPoint] = ch
+	return true
+}
+
+// Lookup returns the channel funded by an outpoint
+func (idx *ChannelPointIndex) Lookup(txid string, vout uint32) (KnownChannel, bool) {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	ch, ok := idx.channels[outpointKey(strings.ToLower(txid), vout)]
+	return ch, ok
+}
+
+// Size returns the number of indexed channels
+func (idx *ChannelPointIndex) Size() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+	return len(idx.channels)
+}
+
+// LoadFile loads a channel list from path (see Load)
+func (idx *ChannelPointIndex) LoadFile(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("open channel points: %w", err)
+	}
+	defer f.Close()
+	return idx.Load(f)
+}
+
+// Load indexes the channels of a text list or `lncli describegraph` JSON
+// and returns how many were added
+func (idx *ChannelPointIndex) Load(r io.Reader) (int, error) {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return 0, nil // Empty list
+		}
+		if !bytes.ContainsAny(b, " \t\r\n") {
+			if b[0] == '{' {
+				return idx.loadDescribeGraph(br)
+			}
+			return idx.loadText(br)
+		}
+		_, _ = br.ReadByte()
+	}
+}
+
+// loadText reads "txid:vout [capacity] [short_channel_id]" lines
+func (idx *ChannelPointIndex) loadText(r io.Reader) (int, error) {
+	added := 0
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' })
+		ch := KnownChannel{ChannelPoint: fields[0]}
+		if len(fields) > 1 {
+			capacity, err := strconv.ParseInt(fields[1], 10, 64)
+			if err != nil {
+				return added, fmt.Errorf("channel points line %d: invalid capacity %q", lineNo, fields[1])
+			}
+			ch.Capacity = capacity
+		}
+		if len(fields) > 2 {
+			ch.ShortChannelID = fields[2]
+		}
+		if !idx.Add(ch) {
+			return added, fmt.Errorf("channel points line %d: invalid channel point %q", lineNo, fields[0])
+		}
+		added++
+	}
+	if err := scanner.Err(); err != nil {
+		return added, fmt.Errorf("read channel points: %w", err)
+	}
+	return added, nil
+}
+
+// loadDescribeGraph reads the edges of `lncli describegraph` output
+// (int64 fields are JSON strings there)
+func (idx *ChannelPointIndex) loadDescribeGraph(r io.Reader) (int, error) {
+	var graph struct {
+		Edges []struct {
+			ChannelID string `json:"channel_id"`
+			ChanPoint string `json:"chan_point"`
+			Capacity  int64  `json:"capacity,string"`
+		} `json:"edges"`
+	}
+	if err := json.NewDecoder(r).Decode(&graph); err != nil {
+		return 0, fmt.Errorf("decode describegraph: %w", err)
+	}
+
+	added := 0
+	for _, edge := range graph.Edges {
+		if idx.Add(KnownChannel{ChannelPoint: edge.ChanPoint, ShortChannelID: edge.ChannelID, Capacity: edge.Capacity}) {
+			added++
+		}
+	}
+	return added, nil
+}
+
+// parseChannelPoint splits and validates "txid:vout"
+func parseChannelPoint(point string) (string, uint32, error) {
+	txid, voutStr, ok := strings.Cut(strings.TrimSpace(point), ":")
+	if !ok {
+		return "", 0, fmt.Errorf("missing output index")
+	}
+	if raw, err := hex.DecodeString(txid); err != nil || len(raw) != 32 {
+		return "", 0, fmt.Errorf("invalid txid %q", txid)
+	}
+	vout, err := strconv.ParseUint(voutStr, 10, 32)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid output index %q", voutStr)
+	}
+	return strings.ToLower(txid), uint32(vout), nil
+}
+
+// detectKnownChannelClose classifies tx as the close of a known channel
+// if one of its inputs spends an indexed channel point
+func detectKnownChannelClose(tx models.Transaction, idx *ChannelPointIndex) (LightningResult, bool) {
+	if idx == nil {
+		return LightningResult{}, false
+	}
+	for _, in := range tx.Inputs {
+		ch, ok := idx.Lookup(in.Txid, in.Vout)
+		if !ok {
+			continue
+		}
+
+		result := LightningResult{
+			IsLightningTx:     true,
+			ChannelType:       "cooperative-close",
+			EstimatedCapacity: ch.Capacity,
+			ChannelPoint:      ch.ChannelPoint,
+			ShortChannelID:    ch.ShortChannelID,
+			Confidence:        knownChannelConfidence,
+		}
+		if result.EstimatedCapacity == 0 {
+			result.EstimatedCapacity = in.Value
+		}
+		if isCommitmentTx(tx, in) {
+			result.ChannelType = "force-close"
+			result.HasAnchorOutputs = detectAnchorOutputs(tx)
+		}
+		return result, true
+	}
+	return LightningResult{}, false
+}
+
+// isCommitmentTx checks the BOLT #3 obscured commitment number encoding
+func isCommitmentTx(tx models.Transaction, fundingInput models.TxIn) bool {
+	return tx.LockTime>>24 == commitmentLockTimeByte && fundingInput.Sequence>>24 == commitmentSequenceByte
+}
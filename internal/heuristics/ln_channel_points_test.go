@@ -0,0 +1,77 @@
+package heuristics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+const testFundingTxid = "c3a0b5bd42a4f2e5a45d6e19e8e7ff3d1b2c5a6d7e8f90a1b2c3d4e5f6a7b8c9"
+
+func TestChannelPointIndex_LoadFormats(t *testing.T) {
+	idx := NewChannelPointIndex()
+	n, err := idx.Load(strings.NewReader("\n# txid:vout capacity scid\n" +
+		strings.ToUpper(testFundingTxid) + ":1 2000000 820000x1234x1\n"))
+	if err != nil || n != 1 {
+		t.Fatalf("Expected one channel from the text list. Got %d, %v", n, err)
+	}
+	if ch, ok := idx.Lookup(testFundingTxid, 1); !ok || ch.Capacity != 2_000_000 || ch.ShortChannelID != "820000x1234x1" {
+		t.Errorf("Expected the channel point indexed case-insensitively. Got %+v, %v", ch, ok)
+	}
+
+	graph := `{"nodes":[],"edges":[
+		{"channel_id":"901592950613114881","chan_point":"` + strings.Repeat("ab", 32) + `:0","capacity":"5000000"},
+		{"channel_id":"1","chan_point":"not-a-point","capacity":"1"}]}`
+	if n, err := idx.Load(strings.NewReader(graph)); err != nil || n != 1 {
+		t.Fatalf("Expected one valid edge from describegraph JSON. Got %d, %v", n, err)
+	}
+	if ch, ok := idx.Lookup(strings.Repeat("ab", 32), 0); !ok || ch.Capacity != 5_000_000 {
+		t.Errorf("Expected the describegraph edge indexed. Got %+v, %v", ch, ok)
+	}
+
+	if _, err := idx.Load(strings.NewReader(testFundingTxid + "\n")); err == nil {
+		t.Error("Expected a channel point without an output index to be rejected")
+	}
+}
+
+func TestDetectKnownChannelClose(t *testing.T) {
+	idx := NewChannelPointIndex()
+	idx.Add(KnownChannel{ChannelPoint: testFundingTxid + ":1", ShortChannelID: "820000x1234x1", Capacity: 2_000_000})
+
+	// One output: structurally a "penalty"; the channel point makes it a coop close
+	coop := models.Transaction{
+		Inputs:   []models.TxIn{{Txid: testFundingTxid, Vout: 1, Value: 2_000_000, Sequence: 0xFFFFFFFF}},
+		Outputs:  []models.TxOut{{Address: "bc1qclosingsweep", Value: 1_999_000}},
+		LockTime: 0,
+	}
+	if structural := DetectLightningChannel(coop); structural.ChannelType != "penalty" {
+		t.Fatalf("Fixture should be a structural penalty guess. Got %+v", structural)
+	}
+	res, ok := detectKnownChannelClose(coop, idx)
+	if !ok || res.ChannelType != "cooperative-close" || res.Confidence != knownChannelConfidence ||
+		res.ChannelPoint != testFundingTxid+":1" || res.EstimatedCapacity != 2_000_000 {
+		t.Errorf("Expected a deterministic cooperative close. Got %+v", res)
+	}
+
+	// BOLT #3 commitment: obscured commitment number in locktime/sequence
+	force := models.Transaction{
+		Inputs:   []models.TxIn{{Txid: testFundingTxid, Vout: 1, Value: 2_000_000, Sequence: 0x80a1b2c3}},
+		Outputs:  []models.TxOut{{Value: 330}, {Value: 330}, {Value: 1_200_000}, {Value: 790_000}},
+		LockTime: 0x20d4e5f6,
+	}
+	if res, ok := detectKnownChannelClose(force, idx); !ok || res.ChannelType != "force-close" || !res.HasAnchorOutputs {
+		t.Errorf("Expected a deterministic force close with anchors. Got %+v", res)
+	}
+
+	other := models.Transaction{Inputs: []models.TxIn{{Txid: testFundingTxid, Vout: 0, Value: 2_000_000}}}
+	if _, ok := detectKnownChannelClose(other, idx); ok {
+		t.Error("Expected a different output of the funding tx not to match")
+	}
+
+	// The global index feeds DetectLightningChannel
+	GetGlobalChannelPointIndex().Add(KnownChannel{ChannelPoint: testFundingTxid + ":1"})
+	if res := DetectLightningChannel(coop); res.ChannelType != "cooperative-close" || res.Confidence != knownChannelConfidence {
+		t.Errorf("Expected the known channel point to override the structural guess. Got %+v", res)
+	}
+}
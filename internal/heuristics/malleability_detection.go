@@ -0,0 +1,146 @@
+package heuristics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Transaction Malleability / Witness-Stripped Rebroadcast Detection
+//
+// A transaction spending a given set of outpoints to a given set of
+// outputs should reach the mempool in exactly one serialization. Seeing
+// a second one means someone re-signed, mutated or stripped it in flight:
+//
+//   - Witness malleation: same txid, different wtxid (BIP141 — the witness
+//     is not committed to by the txid, so a relayer can swap it)
+//   - Witness stripped: the new variant's wtxid equals its txid (no
+//     witness at all) where the first one carried witness data
+//   - Non-witness malleation: same inputs and outputs, different txid
+//     (scriptSig malleability of legacy inputs, BIP62)
+//
+// A variant that changes the outputs is a replacement (RBF), not
+// malleation, and is not flagged here. Observations are informational:
+// malleation is usually relay noise, occasionally a third party games
+// txid-dependent protocols.
+//
+// References:
+//   - BIP62 (Dealing with malleability), BIP141 (Segregated Witness)
+//   - Decker & Wattenhofer, "Bitcoin Transaction Malleability and MtGox" (ESORICS 2014)
+
+const witnessVariantLimit = 500_000 // Input sets remembered before the tracker resets
+
+// Malleation kinds
+const (
+	MalleationWitness         = "witness"
+	MalleationWitnessStripped = "witness_stripped"
+	MalleationScriptSig       = "scriptsig"
+)
+
+// MalleabilityResult describes a second serialization of a known transaction
+type MalleabilityResult struct {
+	IsMalleated   bool   `json:"isMalleated"`
+	Kind          string `json:"kind,omitempty"` // MalleationWitness / MalleationWitnessStripped / MalleationScriptSig
+	OriginalTxid  string `json:"originalTxid,omitempty"`
+	OriginalWtxid string `json:"originalWtxid,omitempty"`
+}
+
+// txVariant is the serialization last seen for an input set
+type txVariant struct {
+	txid, wtxid string
+	outputs     string
+}
+
+// WitnessVariantTracker remembers the txid/wtxid seen for each input set
+type WitnessVariantTracker struct {
+	mu       sync.Mutex
+	variants map[string]txVariant // sorted outpoints → last variant
+}
+
+// NewWitnessVariantTracker creates an empty tracker
+func NewWitnessVariantTracker() *WitnessVariantTracker {
+	return &WitnessVariantTracker{variants: make(map[string]txVariant)}
+}
+
+// Observe records tx and reports whether it is a malleated variant of a
+// transaction already seen with the same inputs and outputs
+func (t *WitnessVariantTracker) Observe(tx models.Transaction) MalleabilityResult {
+	if len(tx.Inputs) == 0 || tx.Txid == "" {
+		return MalleabilityResult{}
+	}
+	wtxid := tx.Wtxid
+	if wtxid == "" {
+		wtxid = tx.Txid // No witness data reported
+	}
+	current := txVariant{txid: tx.Txid, wtxid: wtxid, outputs: outputSetKey(tx)}
+	key := inputSetKey(tx)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	prev, seen := t.variants[key]
+	if len(t.variants) >= witnessVariantLimit && !seen {
+		t.variants = make(map[string]txVariant)
+	}
+	t.variants[key] = current
+	if !seen || prev.outputs != current.outputs || prev.wtxid == current.wtxid {
+		return MalleabilityResult{}
+	}
+
+	res := MalleabilityResult{IsMalleated: true, OriginalTxid: prev.txid, OriginalWtxid: prev.wtxid}
+	switch {
+	case prev.txid != current.txid:
+		res.Kind = MalleationScriptSig
+	case current.wtxid == current.txid:
+		res.Kind = MalleationWitnessStripped
+	default:
+		res.Kind = MalleationWitness
+	}
+	return res
+}
+
+// MalleabilityAlert builds the low-severity informational alert for a
+// malleated variant
+func MalleabilityAlert(tx models.Transaction, res MalleabilityResult) Alert {
+	return Alert{
+		Severity:  "low",
+		AlertType: "tx_malleation",
+		Title:     fmt.Sprintf("Malleated transaction variant (%s)", res.Kind),
+		Description: fmt.Sprintf("Transaction %s (wtxid %s) re-spends the inputs of %s (wtxid %s) to the same outputs with a different %s.",
+			tx.Txid, tx.Wtxid, res.OriginalTxid, res.OriginalWtxid, malleatedPart(res.Kind)),
+		TxID: tx.Txid,
+	}
+}
+
+func malleatedPart(kind string) string {
+	switch kind {
+	case MalleationScriptSig:
+		return "scriptSig (txid)"
+	case MalleationWitnessStripped:
+		return "witness (stripped)"
+	default:
+		return "witness"
+	}
+}
+
+// inputSetKey is the order-independent outpoint set of tx
+func inputSetKey(tx models.Transaction) string {
+	outpoints := make([]string, len(tx.Inputs))
+	for i, in := range tx.Inputs {
+		outpoints[i] = outpointKey(in.Txid, in.Vout)
+	}
+	sort.Strings(outpoints)
+	return strings.Join(outpoints, ",")
+}
+
+// outputSetKey is the ordered value/script list of tx
+func outputSetKey(tx models.Transaction) string {
+	var b strings.Builder
+	for _, out := range tx.Outputs {
+		fmt.Fprintf(&b, "%d:%s,", out.Value, out.ScriptPubKey)
+	}
+	return b.String()
+}
@@ -0,0 +1,89 @@
+package heuristics
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// witnessVariant builds one P2WPKH spend with the given witness stack and
+// maps it to the engine model (txid and wtxid from the serialization)
+func witnessVariant(witness wire.TxWitness) models.Transaction {
+	prev := chainhash.Hash{0x42}
+	msgTx := wire.NewMsgTx(2)
+	msgTx.AddTxIn(&wire.TxIn{PreviousOutPoint: wire.OutPoint{Hash: prev, Index: 1}, Sequence: 0xfffffffd, Witness: witness})
+	msgTx.AddTxOut(wire.NewTxOut(90_000, append([]byte{0x00, 0x14}, bytes.Repeat([]byte{0x22}, 20)...)))
+
+	tx := models.Transaction{
+		Txid:       msgTx.TxHash().String(),
+		Wtxid:      msgTx.WitnessHash().String(),
+		HasWitness: msgTx.HasWitness(),
+		Inputs:     []models.TxIn{{Txid: prev.String(), Vout: 1}},
+	}
+	for _, out := range msgTx.TxOut {
+		tx.Outputs = append(tx.Outputs, models.TxOut{Value: out.Value, ScriptPubKey: hex.EncodeToString(out.PkScript)})
+	}
+	return tx
+}
+
+func TestWitnessVariantTracker_WitnessMalleation(t *testing.T) {
+	pubkey := append([]byte{0x02}, bytes.Repeat([]byte{0x11}, 32)...)
+	original := witnessVariant(wire.TxWitness{bytes.Repeat([]byte{0x30}, 71), pubkey})
+	mutated := witnessVariant(wire.TxWitness{bytes.Repeat([]byte{0x31}, 72), pubkey})
+	if original.Txid != mutated.Txid || original.Wtxid == mutated.Wtxid {
+		t.Fatalf("Expected one txid with two wtxids. Got %s/%s vs %s/%s", original.Txid, original.Wtxid, mutated.Txid, mutated.Wtxid)
+	}
+
+	tracker := NewWitnessVariantTracker()
+	if res := tracker.Observe(original); res.IsMalleated {
+		t.Fatalf("First sighting must not be flagged. Got %+v", res)
+	}
+	if res := tracker.Observe(original); res.IsMalleated {
+		t.Errorf("Re-observing the same serialization must not be flagged. Got %+v", res)
+	}
+
+	res := tracker.Observe(mutated)
+	if !res.IsMalleated || res.Kind != MalleationWitness || res.OriginalWtxid != original.Wtxid {
+		t.Fatalf("Expected witness malleation against the original wtxid. Got %+v", res)
+	}
+	alert := MalleabilityAlert(mutated, res)
+	if alert.Severity != "low" || alert.AlertType != "tx_malleation" || alert.TxID != mutated.Txid {
+		t.Errorf("Expected a low-severity tx_malleation alert. Got %+v", alert)
+	}
+
+	// Rebroadcast with the witness stripped: wtxid collapses to the txid
+	stripped := witnessVariant(nil)
+	if res := tracker.Observe(stripped); !res.IsMalleated || res.Kind != MalleationWitnessStripped {
+		t.Errorf("Expected a witness-stripped variant. Got %+v", res)
+	}
+}
+
+func TestWitnessVariantTracker_ScriptSigAndReplacement(t *testing.T) {
+	tracker := NewWitnessVariantTracker()
+	legacy := models.Transaction{
+		Txid:    "aa",
+		Inputs:  []models.TxIn{{Txid: "prev", Vout: 0}, {Txid: "prev", Vout: 1}},
+		Outputs: []models.TxOut{{Value: 50_000, ScriptPubKey: "76a914"}},
+	}
+	tracker.Observe(legacy)
+
+	// Same outputs, inputs listed in another order, new scriptSig → new txid
+	malleated := legacy
+	malleated.Txid = "bb"
+	malleated.Inputs = []models.TxIn{legacy.Inputs[1], legacy.Inputs[0]}
+	if res := tracker.Observe(malleated); !res.IsMalleated || res.Kind != MalleationScriptSig || res.OriginalTxid != "aa" {
+		t.Errorf("Expected non-witness malleation of aa. Got %+v", res)
+	}
+
+	// Different outputs: an RBF replacement, not malleation
+	replacement := legacy
+	replacement.Txid = "cc"
+	replacement.Outputs = []models.TxOut{{Value: 45_000, ScriptPubKey: "76a914"}}
+	if res := tracker.Observe(replacement); res.IsMalleated {
+		t.Errorf("Expected a replacement not to be flagged. Got %+v", res)
+	}
+}
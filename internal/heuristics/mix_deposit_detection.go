@@ -0,0 +1,133 @@
+package heuristics
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Mix-then-Deposit Detection (CoinJoin output index + exchange identification)
+//
+// The most common way a CoinJoin user undoes their own mix: sending the
+// mixed output straight to an exchange deposit address, often swept
+// together with other (unmixed) UTXOs in the same transaction. The
+// exchange now holds KYC for the mixed coin, and every co-spent input is
+// linked to it by CIOH:
+//
+//   - Mixed inputs: the tx spends outputs recorded in the CoinJoinOutputIndex
+//   - Exchange deposit: an output pays an identified exchange address
+//   - Co-spend: mixed inputs consolidated with other UTXOs (raises confidence)
+//
+// References:
+//   - Möser & Narayanan, "Obfuscation in Bitcoin" (2017)
+//   - Kappos et al., "How to Peel a Million" (USENIX Security 2022)
+//   - OXT Research, "Understanding Whirlpool Post-Mix Spending" (2021)
+
+const coinJoinIndexLimit = 1_000_000 // Outpoints remembered before the index resets
+
+// CoinJoinOutputIndex remembers which outpoints were created by CoinJoins
+type CoinJoinOutputIndex struct {
+	mu      sync.Mutex
+	outputs map[string]string // "txid:vout" → CoinJoin txid
+}
+
+// NewCoinJoinOutputIndex creates an empty index
+func NewCoinJoinOutputIndex() *CoinJoinOutputIndex {
+	return &CoinJoinOutputIndex{outputs: make(map[string]string)}
+}
+
+// Record indexes every output of a CoinJoin transaction
+func (idx *CoinJoinOutputIndex) Record(tx models.Transaction) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if len(idx.outputs) > coinJoinIndexLimit {
+		idx.outputs = make(map[string]string)
+	}
+	for vout := range tx.Outputs {
+		idx.outputs[outpointKey(tx.Txid, uint32(vout))] = tx.Txid
+	}
+}
+
+// Lookup returns the CoinJoin that created an outpoint
+func (idx *CoinJoinOutputIndex) Lookup(txid string, vout uint32) (string, bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	mix, ok := idx.outputs[outpointKey(txid, vout)]
+	return mix, ok
+}
+
+// InputsFromCoinJoin marks each input that spends an indexed CoinJoin output,
+// in the shape AnalyzePostMixBehavior expects
+func (idx *CoinJoinOutputIndex) InputsFromCoinJoin(tx models.Transaction) []bool {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	mixed := make([]bool, len(tx.Inputs))
+	for i, in := range tx.Inputs {
+		_, mixed[i] = idx.outputs[outpointKey(in.Txid, in.Vout)]
+	}
+	return mixed
+}
+
+func outpointKey(txid string, vout uint32) string {
+	return fmt.Sprintf("%s:%d", txid, vout)
+}
+
+// MixToDepositPattern is a CoinJoin output deposited at an exchange
+type MixToDepositPattern struct {
+	IsMixToDeposit bool     `json:"isMixToDeposit"`
+	Label          string   `json:"label"`    // "mix-to-exchange-deposit"
+	Severity       string   `json:"severity"` // "high"
+	Exchange       string   `json:"exchange"`
+	DepositIndex   int      `json:"depositIndex"`
+	DepositValue   int64    `json:"depositValue"`
+	MixedInputs    int      `json:"mixedInputs"`
+	OtherInputs    int      `json:"otherInputs"` // Unmixed UTXOs co-spent with the mixed ones
+	MixTxids       []string `json:"mixTxids"`    // CoinJoins whose outputs were spent
+	Confidence     float64  `json:"confidence"`
+}
+
+// DetectMixToExchangeDeposit flags a transaction spending CoinJoin outputs
+// into an identified exchange deposit address
+func DetectMixToExchangeDeposit(tx models.Transaction, index *CoinJoinOutputIndex) MixToDepositPattern {
+	p := MixToDepositPattern{}
+	if index == nil {
+		return p
+	}
+
+	seenMix := make(map[string]bool)
+	for _, in := range tx.Inputs {
+		mix, ok := index.Lookup(in.Txid, in.Vout)
+		if !ok {
+			p.OtherInputs++
+			continue
+		}
+		p.MixedInputs++
+		if !seenMix[mix] {
+			seenMix[mix] = true
+			p.MixTxids = append(p.MixTxids, mix)
+		}
+	}
+	if p.MixedInputs == 0 {
+		return p
+	}
+
+	for i, out := range tx.Outputs {
+		if exchange, ok := IsKnownExchangeAddress(out.Address); ok {
+			p.Exchange, p.DepositIndex, p.DepositValue = exchange, i, out.Value
+			break
+		}
+	}
+	if p.Exchange == "" {
+		return MixToDepositPattern{}
+	}
+
+	p.IsMixToDeposit = true
+	p.Label = "mix-to-exchange-deposit"
+	p.Severity = "high"
+	p.Confidence = 0.85
+	if p.OtherInputs > 0 || p.MixedInputs >= 2 {
+		p.Confidence = 0.95 // Consolidation links the mixed coins to each other and to the deposit
+	}
+	return p
+}
@@ -0,0 +1,77 @@
+package heuristics
+
+import (
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func whirlpoolMixForDeposit() models.Transaction {
+	tx := models.Transaction{Txid: "whirlpool_round"}
+	for i := 0; i < 5; i++ {
+		tx.Inputs = append(tx.Inputs, models.TxIn{Txid: "premix", Vout: uint32(i), Value: 5_000_000})
+		tx.Outputs = append(tx.Outputs, models.TxOut{Address: "bc1qpostmix", Value: 5_000_000})
+	}
+	return tx
+}
+
+func TestDetectMixToExchangeDeposit_ConsolidatedIntoExchange(t *testing.T) {
+	index := NewCoinJoinOutputIndex()
+	index.Record(whirlpoolMixForDeposit())
+
+	// Mixed output swept together with an unmixed UTXO into a Binance deposit
+	deposit := models.Transaction{
+		Txid: "deposit",
+		Inputs: []models.TxIn{
+			{Txid: "whirlpool_round", Vout: 2, Value: 5_000_000},
+			{Txid: "old_wallet_utxo", Vout: 0, Value: 1_200_000},
+		},
+		Outputs: []models.TxOut{{Address: "bc1qm34lsc65zpw79lxes69zkqmk6ee3ewf0j77s0h", Value: 6_190_000}},
+	}
+
+	p := DetectMixToExchangeDeposit(deposit, index)
+	if !p.IsMixToDeposit || p.Label != "mix-to-exchange-deposit" || p.Severity != "high" {
+		t.Fatalf("Expected a high-severity mix-to-exchange deposit. Got %+v", p)
+	}
+	if p.Exchange != "Binance" || p.DepositValue != 6_190_000 {
+		t.Errorf("Expected the Binance deposit to be identified. Got %s / %d", p.Exchange, p.DepositValue)
+	}
+	if p.MixedInputs != 1 || p.OtherInputs != 1 || len(p.MixTxids) != 1 || p.MixTxids[0] != "whirlpool_round" {
+		t.Errorf("Unexpected input attribution: %+v", p)
+	}
+	if p.Confidence < 0.95 {
+		t.Errorf("Expected co-spending to raise confidence. Got %.2f", p.Confidence)
+	}
+
+	escalated := EscalateMixToDeposit(ThreatAssessment{RiskScore: 5, Severity: "info"}, p)
+	if escalated.Severity != "high" {
+		t.Errorf("Expected at least high severity. Got %d (%s)", escalated.RiskScore, escalated.Severity)
+	}
+
+	if mixed := index.InputsFromCoinJoin(deposit); !mixed[0] || mixed[1] {
+		t.Errorf("Expected only the first input marked as mixed. Got %v", mixed)
+	}
+}
+
+func TestDetectMixToExchangeDeposit_RequiresBothSides(t *testing.T) {
+	index := NewCoinJoinOutputIndex()
+	index.Record(whirlpoolMixForDeposit())
+
+	// Mixed output spent to a non-exchange address
+	private := models.Transaction{
+		Inputs:  []models.TxIn{{Txid: "whirlpool_round", Vout: 0, Value: 5_000_000}},
+		Outputs: []models.TxOut{{Address: "bc1qmerchant", Value: 4_990_000}},
+	}
+	if got := DetectMixToExchangeDeposit(private, index); got.IsMixToDeposit {
+		t.Errorf("Expected no flag without an exchange output. Got %+v", got)
+	}
+
+	// Unmixed coins deposited at an exchange
+	plain := models.Transaction{
+		Inputs:  []models.TxIn{{Txid: "salary", Vout: 1, Value: 5_000_000}},
+		Outputs: []models.TxOut{{Address: "3Cbq7aT1tY8kMxWLbitaG7yT6bPbKChq64", Value: 4_990_000}},
+	}
+	if got := DetectMixToExchangeDeposit(plain, index); got.IsMixToDeposit {
+		t.Errorf("Expected no flag without a CoinJoin input. Got %+v", got)
+	}
+}
@@ -0,0 +1,127 @@
+package heuristics
+
+import (
+	"math"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// MuSig2 (BIP327) Structural Suspicion
+//
+// A MuSig2 aggregate key signs with a single BIP340 Schnorr signature, so a
+// jointly controlled Taproot output spent via the key path is
+// indistinguishable from a single-key spend. Nothing on-chain proves
+// multi-party control; this detector only notes key-path spends that
+// co-occur with multi-party construction signals:
+//
+//   - Co-funded spend: key-path inputs alongside inputs of differing script
+//     type or nSequence (two wallets signed), e.g. a Taproot channel splice
+//   - Shared output funding: a co-funded transaction creating a Taproot
+//     output larger than any single input (channel open / joint vault)
+//   - Cooperative close: a lone key-path input split into at most two
+//     outputs with nLockTime 0 (single-key wallets usually set
+//     anti-fee-sniping locktimes)
+//
+// The result is LOW CONFIDENCE and policy-gated (Layer 3): it never adds
+// clustering evidence. Its only effect on the graph is to withhold the
+// suspected shared inputs from CIOH merges, since a shared key belongs to
+// more than one entity.
+//
+// References:
+//   - BIP327 (MuSig2 for BIP340-compatible Multi-Signatures)
+//   - BIP341 (Taproot key-path spending)
+//   - BOLT "simple taproot channels" proposal
+
+// musig2MaxConfidence caps the suspicion score: key-path spends never
+// reveal the signer count, so the detector cannot be more than a hint
+const musig2MaxConfidence = 0.5
+
+// MuSig2Result holds MuSig2 suspicion results
+type MuSig2Result struct {
+	IsSuspect     bool     `json:"isSuspect"`
+	Confidence    float64  `json:"confidence"`              // 0-musig2MaxConfidence
+	KeyPathInputs []int    `json:"keyPathInputs,omitempty"` // Taproot key-path input indexes
+	Signals       []string `json:"signals,omitempty"`       // Matched multi-party signals
+}
+
+// DetectMuSig2Suspect scores tx for Taproot key-path spends of possibly
+// jointly held outputs. Inputs without witness data are never counted as
+// key-path spends.
+func DetectMuSig2Suspect(tx models.Transaction) MuSig2Result {
+	var result MuSig2Result
+	for i, in := range tx.Inputs {
+		if isTaprootKeyPathSpend(in) {
+			result.KeyPathInputs = append(result.KeyPathInputs, i)
+		}
+	}
+	if len(result.KeyPathInputs) == 0 {
+		return MuSig2Result{}
+	}
+
+	score := 0.2 // Key-path spend: consistent with, not evidence of, an aggregate key
+	signal := func(name string, weight float64) {
+		score += weight
+		result.Signals = append(result.Signals, name)
+	}
+
+	if len(tx.Inputs) == 1 {
+		if len(tx.Outputs) >= 1 && len(tx.Outputs) <= 2 && tx.LockTime == 0 {
+			signal("cooperative_close", 0.15)
+		}
+	} else {
+		firstType := detectAddressType(tx.Inputs[0].Address)
+		firstSeq := tx.Inputs[0].Sequence
+		mixedType, mixedSeq := false, false
+		var maxIn int64
+		for _, in := range tx.Inputs {
+			if in.Address != "" && detectAddressType(in.Address) != firstType {
+				mixedType = true
+			}
+			if in.Sequence != firstSeq {
+				mixedSeq = true
+			}
+			maxIn = max(maxIn, in.Value)
+		}
+		if mixedType {
+			signal("mixed_script_types", 0.1)
+		}
+		if mixedSeq {
+			signal("mixed_sequence", 0.1)
+		}
+		// A consolidation also outgrows every input, so this only
+		// counts alongside evidence of a second signer
+		if (mixedType || mixedSeq) && maxIn > 0 {
+			for _, out := range tx.Outputs {
+				if detectAddressType(out.Address) == "taproot" && out.Value > maxIn {
+					signal("shared_output_funding", 0.1)
+					break
+				}
+			}
+		}
+	}
+
+	if len(result.Signals) == 0 {
+		return MuSig2Result{}
+	}
+	result.IsSuspect = true
+	result.Confidence = math.Min(score, musig2MaxConfidence)
+	return result
+}
+
+// isTaprootKeyPathSpend reports whether in spends a Taproot output via the
+// key path: a single 64-byte (SIGHASH_DEFAULT) or 65-byte Schnorr signature,
+// optionally followed by an annex (BIP341)
+func isTaprootKeyPathSpend(in models.TxIn) bool {
+	if detectAddressType(in.Address) != "taproot" {
+		return false
+	}
+	witness := in.Witness
+	if n := len(witness); n >= 2 && len(witness[n-1]) >= 2 && witness[n-1][:2] == "50" {
+		witness = witness[:n-1] // Annex
+	}
+	if len(witness) != 1 {
+		return false
+	}
+	sigLen := len(witness[0]) / 2
+	return sigLen == 64 || sigLen == 65
+}
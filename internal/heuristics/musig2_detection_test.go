@@ -0,0 +1,97 @@
+package heuristics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+var schnorrSig = strings.Repeat("ab", 64)
+
+// sampleTaprootSplice: a key-path spend of a Taproot channel output plus a
+// P2WPKH input from the second party, funding a larger Taproot output
+func sampleTaprootSplice() models.Transaction {
+	return models.Transaction{
+		Txid: "splice",
+		Inputs: []models.TxIn{
+			{Address: "bc1pchannelaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", Value: 2_000_000, Sequence: 0xfffffffd, Witness: []string{schnorrSig}},
+			{Address: "bc1qpeerinputbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Value: 500_000, Sequence: 0xffffffff, Witness: []string{"3044", "02"}},
+			{Address: "bc1qpeerinputcccccccccccccccccccccccccccc", Value: 300_000, Sequence: 0xffffffff, Witness: []string{"3044", "02"}},
+		},
+		Outputs: []models.TxOut{
+			{Address: "bc1pnewchanneldddddddddddddddddddddddddddddddddddddddddddddddd", Value: 2_790_000},
+			{Address: "bc1qpeerchangeeeeeeeeeeeeeeeeeeeeeeeeeeee", Value: 8_000},
+		},
+	}
+}
+
+func TestDetectMuSig2Suspect_TaprootSplice(t *testing.T) {
+	result := DetectMuSig2Suspect(sampleTaprootSplice())
+
+	if !result.IsSuspect {
+		t.Fatalf("Expected a MuSig2 suspect. Got %+v", result)
+	}
+	if len(result.KeyPathInputs) != 1 || result.KeyPathInputs[0] != 0 {
+		t.Errorf("Expected key-path input 0. Got %v", result.KeyPathInputs)
+	}
+	if result.Confidence > musig2MaxConfidence {
+		t.Errorf("Expected confidence capped at %.2f. Got %.2f", musig2MaxConfidence, result.Confidence)
+	}
+}
+
+func TestDetectMuSig2Suspect_RequiresKeyPathWitness(t *testing.T) {
+	tx := sampleTaprootSplice()
+	tx.Inputs[0].Witness = nil // Unknown spend path
+	if result := DetectMuSig2Suspect(tx); result.IsSuspect {
+		t.Errorf("Expected no suspicion without witness data. Got %+v", result)
+	}
+
+	tx.Inputs[0].Witness = []string{schnorrSig, "20" + strings.Repeat("11", 32), "c0" + strings.Repeat("22", 32)}
+	if result := DetectMuSig2Suspect(tx); result.IsSuspect {
+		t.Errorf("Expected a script-path spend not to be flagged. Got %+v", result)
+	}
+}
+
+func TestDetectMuSig2Suspect_SingleWalletSpend(t *testing.T) {
+	tx := models.Transaction{
+		LockTime: 850_000, // Anti-fee-sniping
+		Inputs: []models.TxIn{
+			{Address: "bc1pwalletaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", Value: 400_000, Sequence: 0xfffffffd, Witness: []string{schnorrSig}},
+		},
+		Outputs: []models.TxOut{
+			{Address: "bc1qmerchantfffffffffffffffffffffffffffff", Value: 300_000},
+			{Address: "bc1pchangebbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Value: 99_000},
+		},
+	}
+	if result := DetectMuSig2Suspect(tx); result.IsSuspect {
+		t.Errorf("Expected an ordinary single-key spend not to be flagged. Got %+v", result)
+	}
+
+	tx.LockTime = 0
+	if result := DetectMuSig2Suspect(tx); !result.IsSuspect || result.Signals[0] != "cooperative_close" {
+		t.Errorf("Expected a cooperative-close suspect. Got %+v", result)
+	}
+}
+
+func TestGenerateCIOHEdges_SkipsMuSig2Inputs(t *testing.T) {
+	tx := sampleTaprootSplice()
+	edges := GenerateCIOHEdges(tx, false, 850000)
+
+	if len(edges) != 1 {
+		t.Fatalf("Expected 1 CIOH edge between the peer inputs. Got %d", len(edges))
+	}
+	for _, edge := range edges {
+		if edge.SrcNodeID == tx.Inputs[0].Address || edge.DstNodeID == tx.Inputs[0].Address {
+			t.Errorf("Expected the key-path input to sit out CIOH. Got %+v", edge)
+		}
+	}
+}
+
+func TestAnalyzeTx_FlagsMuSig2Suspect(t *testing.T) {
+	res := AnalyzeTx(sampleTaprootSplice())
+
+	if res.HeuristicFlags&FlagIsMuSig2Suspect == 0 {
+		t.Errorf("Expected FlagIsMuSig2Suspect. Flags: %b", res.HeuristicFlags)
+	}
+}
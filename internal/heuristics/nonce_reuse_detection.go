@@ -0,0 +1,282 @@
+package heuristics
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// ECDSA Nonce (R-value) Reuse Detection
+//
+// An ECDSA signature (r, s) commits to a per-signature nonce k through
+// r = (k·G).x. Two signatures by the same key with the same r reveal k,
+// and k reveals the private key:
+//
+//   k = (z1 − z2) / (s1 − s2),  d = (s·k − z) / r
+//
+// Anyone watching the chain can do this, and sweepers do within minutes.
+// A repeated r is therefore a critical key-compromise signal, usually from
+// a broken RNG or a hand-rolled signer:
+//
+//   - Within a tx: two inputs (or two multisig signatures) share an r
+//   - Across txs: an r already seen in a recently observed transaction
+//     (NonceReuseTracker), e.g. the same address spent twice
+//   - Signatures are read from P2WPKH/P2WSH witnesses and legacy
+//     scriptSigs (strict DER + sighash byte); the same signature seen
+//     again (a rebroadcast or malleated variant) is not reuse
+//
+// References:
+//   - Bos et al., "Elliptic Curve Cryptography in Practice" (FC 2014)
+//   - Brengel & Rossow, "Identifying Key Leakage of Bitcoin Users" (RAID 2018)
+//   - BIP66 (Strict DER signatures)
+
+const nonceTrackerLimit = 2_000_000 // R-values remembered before the tracker resets
+
+// ecdsaSignature is one signature found in an input
+type ecdsaSignature struct {
+	r, s   string // Hex, minimal (no DER sign padding)
+	pubKey string // Hex, when the spend reveals which key signed (P2PKH/P2WPKH)
+	input  int
+}
+
+// NonceCollision is a reused R-value
+type NonceCollision struct {
+	RValue    string `json:"rValue"`
+	Inputs    []int  `json:"inputs"`              // Inputs of this tx carrying the R-value
+	OtherTxid string `json:"otherTxid,omitempty"` // Earlier tx with the same R-value (cross-tx reuse)
+	OtherVin  int    `json:"otherVin,omitempty"`
+	SameKey   bool   `json:"sameKey"` // Both signatures by one known pubkey: its private key is recoverable
+}
+
+// NonceReuseResult lists the reused R-values of a transaction
+type NonceReuseResult struct {
+	IsReused   bool             `json:"isReused"`
+	Collisions []NonceCollision `json:"collisions,omitempty"`
+}
+
+// DetectNonceReuse finds ECDSA signatures sharing an R-value across the
+// inputs of tx
+func DetectNonceReuse(tx models.Transaction) NonceReuseResult {
+	var res NonceReuseResult
+	byR := make(map[string][]ecdsaSignature)
+	var order []string
+	for _, sig := range transactionSignatures(tx) {
+		if len(byR[sig.r]) == 0 {
+			order = append(order, sig.r)
+		}
+		byR[sig.r] = append(byR[sig.r], sig)
+	}
+
+	for _, r := range order {
+		sigs := byR[r]
+		if len(sigs) < 2 || !distinctSignatures(sigs) {
+			continue
+		}
+		collision := NonceCollision{RValue: r}
+		for _, sig := range sigs {
+			if len(collision.Inputs) == 0 || collision.Inputs[len(collision.Inputs)-1] != sig.input {
+				collision.Inputs = append(collision.Inputs, sig.input)
+			}
+		}
+		collision.SameKey = sameKnownKey(sigs)
+		res.Collisions = append(res.Collisions, collision)
+	}
+	res.IsReused = len(res.Collisions) > 0
+	return res
+}
+
+// NonceReuseTracker remembers the R-values of recently observed
+// transactions to detect reuse across transactions
+type NonceReuseTracker struct {
+	mu   sync.Mutex
+	seen map[string]nonceRecord // R-value → first signature seen with it
+}
+
+type nonceRecord struct {
+	txid   string
+	vin    int
+	s      string
+	pubKey string
+}
+
+// NewNonceReuseTracker creates an empty tracker
+func NewNonceReuseTracker() *NonceReuseTracker {
+	return &NonceReuseTracker{seen: make(map[string]nonceRecord)}
+}
+
+// Observe returns within-tx reuse plus R-values of tx already seen in an
+// earlier transaction, and records tx's R-values
+func (t *NonceReuseTracker) Observe(tx models.Transaction) NonceReuseResult {
+	res := DetectNonceReuse(tx)
+	sigs := transactionSignatures(tx)
+	if len(sigs) == 0 {
+		return res
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if len(t.seen) >= nonceTrackerLimit {
+		t.seen = make(map[string]nonceRecord)
+	}
+	for _, sig := range sigs {
+		prev, ok := t.seen[sig.r]
+		if !ok {
+			t.seen[sig.r] = nonceRecord{txid: tx.Txid, vin: sig.input, s: sig.s, pubKey: sig.pubKey}
+			continue
+		}
+		if prev.txid == tx.Txid || prev.s == sig.s {
+			continue // Same tx seen again, or the same signature in a variant
+		}
+		res.Collisions = append(res.Collisions, NonceCollision{
+			RValue:    sig.r,
+			Inputs:    []int{sig.input},
+			OtherTxid: prev.txid,
+			OtherVin:  prev.vin,
+			SameKey:   sig.pubKey != "" && sig.pubKey == prev.pubKey,
+		})
+	}
+	res.IsReused = len(res.Collisions) > 0
+	return res
+}
+
+// NonceReuseAlert builds the critical alert for reused R-values
+func NonceReuseAlert(tx models.Transaction, res NonceReuseResult) Alert {
+	var details []string
+	for _, c := range res.Collisions {
+		where := fmt.Sprintf("inputs %v", c.Inputs)
+		if c.OtherTxid != "" {
+			where = fmt.Sprintf("input %d and %s:%d", c.Inputs[0], c.OtherTxid, c.OtherVin)
+		}
+		details = append(details, fmt.Sprintf("r=%s… (%s)", abbreviateHex(c.RValue), where))
+	}
+	return Alert{
+		Severity:  "critical",
+		AlertType: "nonce_reuse",
+		Title:     "ECDSA nonce reuse: private key compromised",
+		Description: fmt.Sprintf("Transaction %s reuses signature nonces: %s. The signing keys can be recovered from the chain; funds at these keys must be considered stolen.",
+			tx.Txid, strings.Join(details, "; ")),
+		TxID:  tx.Txid,
+		Value: sumInputValues(tx),
+	}
+}
+
+// transactionSignatures extracts the ECDSA signatures of every input
+func transactionSignatures(tx models.Transaction) []ecdsaSignature {
+	var sigs []ecdsaSignature
+	for i, in := range tx.Inputs {
+		sigs = append(sigs, inputSignatures(in, i)...)
+	}
+	return sigs
+}
+
+// inputSignatures reads the signatures of a witness or legacy scriptSig
+func inputSignatures(in models.TxIn, index int) []ecdsaSignature {
+	var items [][]byte
+	if len(in.Witness) > 0 {
+		for _, item := range in.Witness {
+			raw, err := hex.DecodeString(item)
+			if err != nil {
+				return nil
+			}
+			items = append(items, raw)
+		}
+	} else if in.ScriptSig != "" {
+		script, err := hex.DecodeString(in.ScriptSig)
+		if err != nil {
+			return nil
+		}
+		if items, err = txscript.PushedData(script); err != nil {
+			return nil
+		}
+	}
+
+	var sigs []ecdsaSignature
+	for _, item := range items {
+		if r, s, ok := parseDERSignature(item); ok {
+			sigs = append(sigs, ecdsaSignature{r: r, s: s, input: index})
+		}
+	}
+	// Single-key spends (P2PKH / P2WPKH): signature followed by the pubkey
+	if len(sigs) == 1 && len(items) == 2 && isPubKey(items[1]) {
+		sigs[0].pubKey = hex.EncodeToString(items[1])
+	}
+	return sigs
+}
+
+// parseDERSignature parses a strict-DER ECDSA signature followed by a
+// sighash byte (BIP66), returning minimal hex r and s
+func parseDERSignature(sig []byte) (string, string, bool) {
+	// 0x30 len 0x02 rlen r 0x02 slen s sighash
+	if len(sig) < 9 || len(sig) > 73 || sig[0] != 0x30 || int(sig[1]) != len(sig)-3 {
+		return "", "", false
+	}
+	switch txscript.SigHashType(sig[len(sig)-1]) &^ txscript.SigHashAnyOneCanPay {
+	case txscript.SigHashAll, txscript.SigHashNone, txscript.SigHashSingle:
+	default:
+		return "", "", false
+	}
+	if sig[2] != 0x02 {
+		return "", "", false
+	}
+	rLen := int(sig[3])
+	if rLen == 0 || 5+rLen >= len(sig) || sig[4+rLen] != 0x02 {
+		return "", "", false
+	}
+	sLen := int(sig[5+rLen])
+	if sLen == 0 || 6+rLen+sLen != len(sig)-1 {
+		return "", "", false
+	}
+	r := sig[4 : 4+rLen]
+	s := sig[6+rLen : 6+rLen+sLen]
+	return minimalHex(r), minimalHex(s), true
+}
+
+// minimalHex drops DER sign padding so equal integers compare equal
+func minimalHex(b []byte) string {
+	for len(b) > 1 && b[0] == 0 {
+		b = b[1:]
+	}
+	return hex.EncodeToString(b)
+}
+
+// isPubKey reports whether b looks like a compressed or uncompressed SEC pubkey
+func isPubKey(b []byte) bool {
+	return (len(b) == 33 && (b[0] == 0x02 || b[0] == 0x03)) || (len(b) == 65 && b[0] == 0x04)
+}
+
+// distinctSignatures reports whether sigs are not all the same signature
+func distinctSignatures(sigs []ecdsaSignature) bool {
+	for _, sig := range sigs[1:] {
+		if sig.s != sigs[0].s {
+			return true
+		}
+	}
+	return false
+}
+
+// sameKnownKey reports whether two of sigs were made by one revealed pubkey
+func sameKnownKey(sigs []ecdsaSignature) bool {
+	keys := make(map[string]bool)
+	for _, sig := range sigs {
+		if sig.pubKey == "" {
+			continue
+		}
+		if keys[sig.pubKey] {
+			return true
+		}
+		keys[sig.pubKey] = true
+	}
+	return false
+}
+
+func abbreviateHex(h string) string {
+	if len(h) > 16 {
+		return h[:16]
+	}
+	return h
+}
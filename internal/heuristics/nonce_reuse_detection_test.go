@@ -0,0 +1,152 @@
+package heuristics
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// derSig builds a strict-DER SIGHASH_ALL signature from 32-byte r and s
+// (a high first byte gets the 0x00 sign padding)
+func derSig(r, s []byte) string {
+	enc := func(v []byte) []byte {
+		if v[0]&0x80 != 0 {
+			v = append([]byte{0x00}, v...)
+		}
+		return append([]byte{0x02, byte(len(v))}, v...)
+	}
+	body := append(enc(r), enc(s)...)
+	sig := append([]byte{0x30, byte(len(body))}, body...)
+	return hex.EncodeToString(append(sig, 0x01))
+}
+
+func testPubKey(b byte) string {
+	return hex.EncodeToString(append([]byte{0x02}, bytes.Repeat([]byte{b}, 32)...))
+}
+
+func p2wpkhInput(txid string, sig, pubkey string) models.TxIn {
+	return models.TxIn{Txid: txid, Vout: 0, Value: 50_000, Witness: []string{sig, pubkey}}
+}
+
+func TestDetectNonceReuse_TwoInputsShareR(t *testing.T) {
+	r := bytes.Repeat([]byte{0x9a}, 32)
+	pub := testPubKey(0x11)
+	tx := models.Transaction{
+		Txid: "reuse",
+		Inputs: []models.TxIn{
+			p2wpkhInput("aa", derSig(r, bytes.Repeat([]byte{0x01}, 32)), pub),
+			p2wpkhInput("bb", derSig(r, bytes.Repeat([]byte{0x02}, 32)), pub),
+		},
+	}
+
+	res := DetectNonceReuse(tx)
+	if !res.IsReused || len(res.Collisions) != 1 {
+		t.Fatalf("Expected one R-value collision. Got %+v", res)
+	}
+	c := res.Collisions[0]
+	if c.RValue != hex.EncodeToString(r) {
+		t.Errorf("Expected the minimal R-value without sign padding. Got %s", c.RValue)
+	}
+	if len(c.Inputs) != 2 || c.Inputs[0] != 0 || c.Inputs[1] != 1 {
+		t.Errorf("Expected inputs [0 1]. Got %v", c.Inputs)
+	}
+	if !c.SameKey {
+		t.Errorf("Both inputs reveal the same pubkey, expected SameKey")
+	}
+
+	alert := NonceReuseAlert(tx, res)
+	if alert.Severity != "critical" || alert.AlertType != "nonce_reuse" || alert.Value != 100_000 {
+		t.Errorf("Expected a critical nonce_reuse alert over 100000 sats. Got %+v", alert)
+	}
+}
+
+func TestDetectNonceReuse_LegacyScriptSig(t *testing.T) {
+	r := bytes.Repeat([]byte{0x33}, 32)
+	scriptSig := func(s byte) string {
+		sig, _ := hex.DecodeString(derSig(r, bytes.Repeat([]byte{s}, 32)))
+		pub, _ := hex.DecodeString(testPubKey(0x44))
+		script := append([]byte{byte(len(sig))}, sig...)
+		script = append(script, byte(len(pub)))
+		return hex.EncodeToString(append(script, pub...))
+	}
+	tx := models.Transaction{
+		Txid: "legacy",
+		Inputs: []models.TxIn{
+			{Txid: "aa", ScriptSig: scriptSig(0x05)},
+			{Txid: "bb", ScriptSig: scriptSig(0x06)},
+		},
+	}
+
+	res := DetectNonceReuse(tx)
+	if !res.IsReused || !res.Collisions[0].SameKey {
+		t.Fatalf("Expected same-key reuse from P2PKH scriptSigs. Got %+v", res)
+	}
+}
+
+func TestDetectNonceReuse_DistinctNonces(t *testing.T) {
+	s := bytes.Repeat([]byte{0x01}, 32)
+	tx := models.Transaction{
+		Txid: "clean",
+		Inputs: []models.TxIn{
+			p2wpkhInput("aa", derSig(bytes.Repeat([]byte{0x21}, 32), s), testPubKey(0x11)),
+			p2wpkhInput("bb", derSig(bytes.Repeat([]byte{0x22}, 32), s), testPubKey(0x11)),
+		},
+	}
+	if res := DetectNonceReuse(tx); res.IsReused {
+		t.Errorf("Distinct R-values must not be flagged. Got %+v", res)
+	}
+}
+
+func TestDetectNonceReuse_SameSignatureNotReuse(t *testing.T) {
+	sig := derSig(bytes.Repeat([]byte{0x77}, 32), bytes.Repeat([]byte{0x01}, 32))
+	tx := models.Transaction{
+		Txid:   "dup",
+		Inputs: []models.TxIn{{Txid: "aa", Witness: []string{sig, sig, "51ae"}}},
+	}
+	if res := DetectNonceReuse(tx); res.IsReused {
+		t.Errorf("An identical signature repeated is not nonce reuse. Got %+v", res)
+	}
+}
+
+func TestNonceReuseTracker_AcrossTransactions(t *testing.T) {
+	r := bytes.Repeat([]byte{0x5c}, 32)
+	pub := testPubKey(0x66)
+	first := models.Transaction{Txid: "first", Inputs: []models.TxIn{p2wpkhInput("aa", derSig(r, bytes.Repeat([]byte{0x01}, 32)), pub)}}
+	second := models.Transaction{Txid: "second", Inputs: []models.TxIn{p2wpkhInput("bb", derSig(r, bytes.Repeat([]byte{0x02}, 32)), pub)}}
+
+	tracker := NewNonceReuseTracker()
+	if res := tracker.Observe(first); res.IsReused {
+		t.Fatalf("First sighting must not be flagged. Got %+v", res)
+	}
+	if res := tracker.Observe(first); res.IsReused {
+		t.Errorf("Re-observing the same tx must not be flagged. Got %+v", res)
+	}
+
+	res := tracker.Observe(second)
+	if !res.IsReused || len(res.Collisions) != 1 {
+		t.Fatalf("Expected cross-tx reuse. Got %+v", res)
+	}
+	if c := res.Collisions[0]; c.OtherTxid != "first" || c.OtherVin != 0 || !c.SameKey {
+		t.Errorf("Expected collision with first:0 by the same key. Got %+v", c)
+	}
+}
+
+func TestParseDERSignature_RejectsMalformed(t *testing.T) {
+	valid, _ := hex.DecodeString(derSig(bytes.Repeat([]byte{0x01}, 32), bytes.Repeat([]byte{0x02}, 32)))
+	cases := map[string][]byte{
+		"pubkey":       append([]byte{0x02}, bytes.Repeat([]byte{0x11}, 32)...),
+		"bad sighash":  append(append([]byte{}, valid[:len(valid)-1]...), 0x05),
+		"bad length":   append([]byte{0x30, 0x10}, valid[2:]...),
+		"schnorr size": bytes.Repeat([]byte{0x30}, 64),
+	}
+	for name, sig := range cases {
+		if _, _, ok := parseDERSignature(sig); ok {
+			t.Errorf("%s: expected rejection", name)
+		}
+	}
+	if _, _, ok := parseDERSignature(valid); !ok {
+		t.Errorf("Expected the valid signature to parse")
+	}
+}
@@ -0,0 +1,132 @@
+package heuristics
+
+import (
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// PayJoin (BIP78 / BIP77) Detection
+//
+// In a PayJoin the receiver adds one of its own inputs to the sender's
+// payment and raises its output by the same amount. The result looks like
+// an ordinary 2-output payment, but input ownership is split — naive CIOH
+// would merge the sender and receiver into one entity.
+//
+// Receiver-side signatures scored here:
+//   - Unnecessary input (UIH2): the smaller output exceeds the smallest
+//     input, so a normal wallet would not have selected every input
+//   - Output exceeding every single input: the receiver output absorbs
+//     the payment plus the receiver's contribution
+//   - Hidden round payment: receiver output − some input is a round
+//     amount, while neither visible output is round
+//   - Mixed input provenance: inputs differ in script type or nSequence
+//     (two wallets signed)
+//
+// References:
+//   - BIP78: "A Simple Payjoin Proposal"
+//   - BIP77: "Async Payjoin"
+//   - Ficsór, "Unnecessary Input Heuristics and PayJoin Transactions" (2020)
+
+// payjoinFlagConfidence is the minimum score for FlagIsPayjoinSuspect
+const payjoinFlagConfidence = 0.5
+
+// PayJoinResult holds PayJoin detection results
+type PayJoinResult struct {
+	IsPayJoin       bool     `json:"isPayJoin"`
+	Confidence      float64  `json:"confidence"`                // 0-1
+	ReceiverOutput  int      `json:"receiverOutput"`            // Likely receiver output index (-1 if unknown)
+	ReceiverInput   int      `json:"receiverInput"`             // Likely receiver-contributed input index (-1 if unknown)
+	Signals         []string `json:"signals,omitempty"`         // Matched signatures
+	OriginalPayment int64    `json:"originalPayment,omitempty"` // Round amount the sender intended, when recoverable
+}
+
+// DetectPayJoin scores a non-CoinJoin transaction for the BIP78 receiver-input shape
+func DetectPayJoin(tx models.Transaction) PayJoinResult {
+	result := PayJoinResult{ReceiverOutput: -1, ReceiverInput: -1}
+
+	// BIP78 keeps the 2-output payment + change shape and needs ≥1 sender + 1 receiver input
+	if len(tx.Inputs) < 2 || len(tx.Outputs) != 2 {
+		return result
+	}
+	for _, out := range tx.Outputs {
+		if isOPReturn(out.ScriptPubKey) || out.Value <= 0 {
+			return result
+		}
+	}
+
+	minIn, maxIn := tx.Inputs[0].Value, tx.Inputs[0].Value
+	for _, in := range tx.Inputs {
+		if in.Value <= 0 {
+			return result // Unknown provenance values: cannot reason about input selection
+		}
+		if in.Value < minIn {
+			minIn = in.Value
+		}
+		if in.Value > maxIn {
+			maxIn = in.Value
+		}
+	}
+
+	big, small := 0, 1
+	if tx.Outputs[1].Value > tx.Outputs[0].Value {
+		big, small = 1, 0
+	}
+
+	score := 0.0
+	signal := func(name string, weight float64) {
+		score += weight
+		result.Signals = append(result.Signals, name)
+	}
+
+	// UIH2: a normal wallet would have dropped the smallest input
+	if tx.Outputs[small].Value > minIn {
+		signal("unnecessary_input", 0.3)
+	}
+
+	// Receiver output = payment + receiver input exceeds every single input
+	if tx.Outputs[big].Value > maxIn {
+		signal("output_exceeds_inputs", 0.15)
+		result.ReceiverOutput = big
+	}
+
+	// Hidden round payment: out − receiverInput is round, outputs themselves are not
+	if !isRoundAmount(tx.Outputs[0].Value) && !isRoundAmount(tx.Outputs[1].Value) {
+	search:
+		for j, out := range tx.Outputs {
+			for k, in := range tx.Inputs {
+				if payment := out.Value - in.Value; payment > 0 && isRoundAmount(payment) {
+					signal("hidden_round_payment", 0.25)
+					result.ReceiverOutput = j
+					result.ReceiverInput = k
+					result.OriginalPayment = payment
+					break search
+				}
+			}
+		}
+	}
+
+	// Mixed provenance: two wallets rarely agree on script type and nSequence
+	firstType := detectAddressType(tx.Inputs[0].Address)
+	firstSeq := tx.Inputs[0].Sequence
+	mixedType, mixedSeq := false, false
+	for _, in := range tx.Inputs[1:] {
+		if in.Address != "" && detectAddressType(in.Address) != firstType {
+			mixedType = true
+		}
+		if in.Sequence != firstSeq {
+			mixedSeq = true
+		}
+	}
+	if mixedType {
+		signal("mixed_script_types", 0.15)
+	}
+	if mixedSeq {
+		signal("mixed_sequence", 0.1)
+	}
+
+	if score > 0.95 {
+		score = 0.95
+	}
+	result.Confidence = score
+	result.IsPayJoin = score >= payjoinFlagConfidence
+	return result
+}
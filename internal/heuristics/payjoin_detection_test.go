@@ -0,0 +1,89 @@
+package heuristics
+
+import (
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// samplePayJoin: sender pays a round 0.005 BTC from two inputs, receiver
+// adds a 61,337 sat input and receives payment + contribution
+func samplePayJoin() models.Transaction {
+	return models.Transaction{
+		Txid: "payjoin",
+		Inputs: []models.TxIn{
+			{Address: "bc1qsenderinputaaaaaaaaaaaaaaaaaaaaaaaaaa", Value: 400_000, Sequence: 0xfffffffd},
+			{Address: "bc1qsenderinputbbbbbbbbbbbbbbbbbbbbbbbbbb", Value: 180_000, Sequence: 0xfffffffd},
+			{Address: "bc1qreceiverinputcccccccccccccccccccccccc", Value: 61_337, Sequence: 0xffffffff},
+		},
+		Outputs: []models.TxOut{
+			{Address: "bc1qsenderchangedddddddddddddddddddddddddd", Value: 78_800},
+			{Address: "bc1qreceiveroutputeeeeeeeeeeeeeeeeeeeeeee", Value: 561_337},
+		},
+		Fee:   1_200,
+		Vsize: 300,
+	}
+}
+
+func TestDetectPayJoin_ReceiverInput(t *testing.T) {
+	result := DetectPayJoin(samplePayJoin())
+
+	if !result.IsPayJoin {
+		t.Fatalf("Expected a PayJoin detection. Got %+v", result)
+	}
+	if result.ReceiverOutput != 1 || result.ReceiverInput != 2 {
+		t.Errorf("Expected receiver output 1 / input 2. Got %d / %d", result.ReceiverOutput, result.ReceiverInput)
+	}
+	if result.OriginalPayment != 500_000 {
+		t.Errorf("Expected the hidden 500,000 sat payment. Got %d", result.OriginalPayment)
+	}
+}
+
+func TestDetectPayJoin_OrdinaryPayment(t *testing.T) {
+	tx := models.Transaction{
+		Inputs: []models.TxIn{
+			{Address: "bc1qsenderinputaaaaaaaaaaaaaaaaaaaaaaaaaa", Value: 400_000, Sequence: 0xfffffffd},
+			{Address: "bc1qsenderinputbbbbbbbbbbbbbbbbbbbbbbbbbb", Value: 180_000, Sequence: 0xfffffffd},
+		},
+		Outputs: []models.TxOut{
+			{Address: "bc1qmerchantfffffffffffffffffffffffffffff", Value: 500_000},
+			{Address: "bc1qsenderchangedddddddddddddddddddddddddd", Value: 78_800},
+		},
+	}
+
+	if result := DetectPayJoin(tx); result.IsPayJoin {
+		t.Errorf("Expected an ordinary payment not to be flagged. Got %+v", result)
+	}
+}
+
+func TestGenerateCIOHEdges_PayJoinGating(t *testing.T) {
+	tx := samplePayJoin()
+	edges := GenerateCIOHEdges(tx, false, 800000)
+
+	if len(edges) != 2 {
+		t.Fatalf("Expected 2 gating edges. Got %d", len(edges))
+	}
+	for _, edge := range edges {
+		if edge.EdgeType != EdgeTypePayJoinSuspect || edge.LLRScore >= 0 {
+			t.Errorf("Expected negative PayJoin gating edge. Got type %d LLR %.2f", edge.EdgeType, edge.LLRScore)
+		}
+	}
+
+	ce := NewClusterEngine()
+	if merges := ce.MergeFromEdges(edges); merges != 0 {
+		t.Errorf("Expected PayJoin inputs not to be CIOH-clustered. Got %d merges", merges)
+	}
+}
+
+func TestAnalyzeTx_FlagsPayJoin(t *testing.T) {
+	res := AnalyzeTx(samplePayJoin())
+
+	if res.HeuristicFlags&FlagIsPayjoinSuspect == 0 {
+		t.Errorf("Expected FlagIsPayjoinSuspect. Flags: %b", res.HeuristicFlags)
+	}
+	for _, edge := range res.Edges {
+		if edge.EdgeType == EdgeTypeCIOH {
+			t.Errorf("Expected no CIOH edges for a suspected PayJoin. Got %+v", edge)
+		}
+	}
+}
@@ -150,8 +150,8 @@ func DetectPeelChainStep(tx models.Transaction, isCoinJoin bool) PeelChainCandid
 }
 
 // BuildPeelChainResult converts a peel chain candidate into the
-// PrivacyAnalysisResult field. For full chain linking, the scanner
-// would need to maintain a state map of previous change outputs.
+// PrivacyAnalysisResult field. The block scanner links steps across
+// transactions with a PeelChainTracker.
 func BuildPeelChainResult(candidate PeelChainCandidate) *models.PeelChainResult {
 	if !candidate.IsPeelStep {
 		return nil
@@ -159,7 +159,7 @@ func BuildPeelChainResult(candidate PeelChainCandidate) *models.PeelChainResult
 
 	return &models.PeelChainResult{
 		IsChain:     true,
-		ChainLength: 1, // Single step detected; scanner upgrades this for multi-step chains
+		ChainLength: 1, // Single step detected; PeelChainTracker upgrades this for multi-step chains
 		Direction:   "forward",
 		Confidence:  candidate.Confidence,
 		ChangeIndex: candidate.ChangeIndex,
@@ -0,0 +1,194 @@
+package heuristics
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Peel Chain Linking (cross-transaction)
+//
+// DetectPeelChainStep sees one transaction at a time, so every step of a
+// chain is reported as an isolated chain of length 1. PeelChainTracker is
+// fed peel steps in chain order (the block scanner observes blocks in
+// height order) and links a step to the earlier step whose output it
+// spends, either by outpoint or by a shared change address:
+//
+//   Tx₁ (head, "forward") → Tx₂ (spends Tx₁ change, "backward") → Tx₃ …
+//
+// Both outputs of an unlinked step are remembered: the per-step ChangeIndex
+// is a value-based guess, and in a canonical peel chain the large remainder
+// is the change the next step spends. Whichever output the next step
+// spends becomes that step's change; the step then stops accepting other
+// successors, so a payee that happens to peel its own coins does not fork
+// the chain.
+
+// peelChainTrackerLimit bounds remembered steps before the tracker resets
+const peelChainTrackerLimit = 1_000_000
+
+// PeelChain is a run of linked peel steps
+type PeelChain struct {
+	ID         string   `json:"id"`         // Txid of the head step
+	Txids      []string `json:"txids"`      // Steps in chain order
+	Length     int      `json:"length"`     // len(Txids)
+	Confidence float64  `json:"confidence"` // Mean per-step confidence
+	StartValue int64    `json:"startValue"` // Total input value of the head step
+	LastChange int64    `json:"lastChange"` // Value of the newest step's identified change
+}
+
+// peelStep is a linked step whose continuing output is not yet known
+type peelStep struct {
+	chainID string
+	keys    []string // Outpoint and address keys in PeelChainTracker.open
+}
+
+// PeelChainTracker links peel steps across transactions
+type PeelChainTracker struct {
+	mu      sync.Mutex
+	open    map[string]*peelStep // "txid:vout" and address → step that created it
+	chains  map[string]*PeelChain
+	byTxid  map[string]string // Step txid → chain ID
+	confSum map[string]float64
+}
+
+// NewPeelChainTracker creates an empty tracker
+func NewPeelChainTracker() *PeelChainTracker {
+	t := &PeelChainTracker{}
+	t.reset()
+	return t
+}
+
+func (t *PeelChainTracker) reset() {
+	t.open = make(map[string]*peelStep)
+	t.chains = make(map[string]*PeelChain)
+	t.byTxid = make(map[string]string)
+	t.confSum = make(map[string]float64)
+}
+
+// Observe links tx to the chain of the peel step it spends from and
+// returns peel with ChainLength, PreviousTxid and Direction filled in.
+// Non-peel transactions (peel nil or not a chain) return peel unchanged.
+func (t *PeelChainTracker) Observe(tx models.Transaction, peel *models.PeelChainResult) *models.PeelChainResult {
+	if peel == nil || !peel.IsChain || tx.Txid == "" {
+		return peel
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if chainID, seen := t.byTxid[tx.Txid]; seen {
+		return t.result(peel, chainID, tx.Txid) // Re-scan of a known step
+	}
+	if len(t.byTxid) > peelChainTrackerLimit {
+		t.reset()
+	}
+
+	var chain *PeelChain
+	if prev := t.predecessor(tx); prev != nil {
+		chain = t.chains[prev.chainID]
+		for _, key := range prev.keys {
+			if t.open[key] == prev {
+				delete(t.open, key) // Stop accepting further successors
+			}
+		}
+	} else {
+		var startValue int64
+		for _, in := range tx.Inputs {
+			startValue += in.Value
+		}
+		chain = &PeelChain{ID: tx.Txid, StartValue: startValue}
+		t.chains[chain.ID] = chain
+	}
+
+	chain.Txids = append(chain.Txids, tx.Txid)
+	chain.Length = len(chain.Txids)
+	t.confSum[chain.ID] += peel.Confidence
+	chain.Confidence = t.confSum[chain.ID] / float64(chain.Length)
+	if peel.ChangeIndex >= 0 && peel.ChangeIndex < len(tx.Outputs) {
+		chain.LastChange = tx.Outputs[peel.ChangeIndex].Value
+	}
+	t.byTxid[tx.Txid] = chain.ID
+
+	step := &peelStep{chainID: chain.ID}
+	for vout, out := range tx.Outputs {
+		step.keys = append(step.keys, outpointKey(tx.Txid, uint32(vout)))
+		if out.Address != "" {
+			step.keys = append(step.keys, out.Address)
+		}
+	}
+	for _, key := range step.keys {
+		t.open[key] = step
+	}
+
+	return t.result(peel, chain.ID, tx.Txid)
+}
+
+// predecessor returns the open step tx spends from, matching outpoints
+// before shared addresses
+func (t *PeelChainTracker) predecessor(tx models.Transaction) *peelStep {
+	for _, in := range tx.Inputs {
+		if step, ok := t.open[outpointKey(in.Txid, in.Vout)]; ok {
+			return step
+		}
+	}
+	for _, in := range tx.Inputs {
+		if step, ok := t.open[in.Address]; ok && in.Address != "" {
+			return step
+		}
+	}
+	return nil
+}
+
+// result copies peel with the chain context of txid
+func (t *PeelChainTracker) result(peel *models.PeelChainResult, chainID, txid string) *models.PeelChainResult {
+	out := *peel
+	out.Direction = "forward"
+	out.PreviousTxid = ""
+	chain := t.chains[chainID]
+	for i, id := range chain.Txids {
+		if id == txid {
+			out.ChainLength = i + 1
+			if i > 0 {
+				out.Direction = "backward" // Input is the previous step's change
+				out.PreviousTxid = chain.Txids[i-1]
+			}
+			break
+		}
+	}
+	return &out
+}
+
+// Chain returns a copy of the chain containing txid
+func (t *PeelChainTracker) Chain(txid string) (PeelChain, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	chainID, ok := t.byTxid[txid]
+	if !ok {
+		return PeelChain{}, false
+	}
+	chain := *t.chains[chainID]
+	chain.Txids = append([]string(nil), chain.Txids...)
+	return chain, true
+}
+
+// Chains returns copies of chains with at least minLength steps, longest first
+func (t *PeelChainTracker) Chains(minLength int) []PeelChain {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var list []PeelChain
+	for _, c := range t.chains {
+		if c.Length >= minLength {
+			chain := *c
+			chain.Txids = append([]string(nil), c.Txids...)
+			list = append(list, chain)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Length != list[j].Length {
+			return list[i].Length > list[j].Length
+		}
+		return list[i].ID < list[j].ID
+	})
+	return list
+}
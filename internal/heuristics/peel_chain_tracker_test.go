@@ -0,0 +1,78 @@
+package heuristics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// peelHop spends prev:vout (the large remainder) and peels a round payment
+func peelHop(i int, prevTxid string, vout uint32, value int64) models.Transaction {
+	payment := int64(1_000_000)
+	fee := int64(2_000)
+	return models.Transaction{
+		Txid:   fmt.Sprintf("peel-%02d", i),
+		Inputs: []models.TxIn{{Txid: prevTxid, Vout: vout, Address: fmt.Sprintf("bc1qchange%02d", i-1), Value: value}},
+		Outputs: []models.TxOut{
+			{Address: fmt.Sprintf("bc1qpayee%02d", i), Value: payment},
+			{Address: fmt.Sprintf("bc1qchange%02d", i), Value: value - payment - fee},
+		},
+		Fee:   fee,
+		Vsize: 141,
+	}
+}
+
+func TestPeelChainTracker_LinksTenHops(t *testing.T) {
+	tracker := NewPeelChainTracker()
+	prev, value := "funding", int64(50_000_000)
+
+	var last *models.PeelChainResult
+	for i := 1; i <= 10; i++ {
+		tx := peelHop(i, prev, 1, value)
+		peel := BuildPeelChainResult(DetectPeelChainStep(tx, false))
+		if peel == nil {
+			t.Fatalf("Expected hop %d to be a peel step", i)
+		}
+		last = tracker.Observe(tx, peel)
+		if last.ChainLength != i {
+			t.Fatalf("Expected hop %d at chain length %d. Got %+v", i, i, last)
+		}
+		if i > 1 && (last.PreviousTxid != prev || last.Direction != "backward") {
+			t.Errorf("Expected hop %d linked to %s. Got %+v", i, prev, last)
+		}
+		prev, value = tx.Txid, tx.Outputs[1].Value
+	}
+	if last.PreviousTxid != "peel-09" {
+		t.Errorf("Expected the last hop to follow peel-09. Got %+v", last)
+	}
+
+	chains := tracker.Chains(2)
+	if len(chains) != 1 || chains[0].Length != 10 || chains[0].ID != "peel-01" {
+		t.Fatalf("Expected one chain of length 10. Got %+v", chains)
+	}
+	if chain, ok := tracker.Chain("peel-05"); !ok || chain.ID != "peel-01" || chain.StartValue != 50_000_000 {
+		t.Errorf("Expected peel-05 in the chain headed by peel-01. Got %+v", chain)
+	}
+}
+
+func TestPeelChainTracker_NoForkFromSecondSpender(t *testing.T) {
+	tracker := NewPeelChainTracker()
+	head := peelHop(1, "funding", 0, 50_000_000)
+	tracker.Observe(head, BuildPeelChainResult(DetectPeelChainStep(head, false)))
+
+	next := peelHop(2, head.Txid, 1, head.Outputs[1].Value)
+	tracker.Observe(next, BuildPeelChainResult(DetectPeelChainStep(next, false)))
+
+	// The payee of the head step peels its own coins: a new chain, not a fork
+	payee := peelHop(3, head.Txid, 0, head.Outputs[0].Value*10)
+	payee.Inputs[0].Address = head.Outputs[0].Address
+	got := tracker.Observe(payee, &models.PeelChainResult{IsChain: true, ChainLength: 1, Confidence: 0.5})
+	if got.ChainLength != 1 || got.PreviousTxid != "" || got.Direction != "forward" {
+		t.Errorf("Expected the payee's step to head a new chain. Got %+v", got)
+	}
+
+	if got := tracker.Observe(payee, nil); got != nil {
+		t.Errorf("Expected non-peel results passed through. Got %+v", got)
+	}
+}
@@ -3,6 +3,8 @@ package heuristics
 import (
 	"testing"
 	"time"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
 )
 
 func TestComputeWindowedAnonSet_NoErosion(t *testing.T) {
@@ -78,3 +80,25 @@ func TestSolveCPSAT_GuardrailRefuse(t *testing.T) {
 		t.Errorf("Expected CP-SAT to refuse large instance and return 0. Got: %d", result)
 	}
 }
+
+func TestComputeHelpers_FullPrecision(t *testing.T) {
+	// Rounding belongs to the JSON marshalers; the helpers keep every digit
+	if got, want := ComputeConsolidationEfficiency(4, 1000), 1000.0/204.0; got != want {
+		t.Errorf("Expected break-even %v. Got %v", want, got)
+	}
+	score := 33
+	if got, want := ComputeTraceability(score), 1.0-float64(score)/100.0; got != want || got == 0.67 {
+		t.Errorf("Expected traceability %v. Got %v", want, got)
+	}
+
+	tx := models.Transaction{
+		Inputs:  []models.TxIn{{Value: 100_000}, {Value: 200_000}, {Value: 300_000}},
+		Outputs: []models.TxOut{{Value: 200_000}, {Value: 399_000}},
+	}
+	if got, want := ComputeFanRatio(tx), 2.0/3.0; got != want {
+		t.Errorf("Expected fan ratio %v. Got %v", want, got)
+	}
+	if got, want := ComputeValueFlow(tx), 399_000.0/600_000.0; got != want {
+		t.Errorf("Expected value flow %v. Got %v", want, got)
+	}
+}
@@ -0,0 +1,109 @@
+package heuristics
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Pipeline Step Profiling
+//
+// AnalyzeTx runs ~30 heuristic steps; on large CoinJoins one of them
+// (usually the anonymity-set solver, Boltzmann or unmixing) dominates the
+// latency. With profiling enabled each step's wall time is recorded:
+//
+//   - StepTimings: step name → milliseconds on PrivacyAnalysisResult
+//   - Slow log: a [Pipeline] line naming the dominant steps when the total
+//     exceeds the slow threshold (0 = never log)
+//   - Toggle: PIPELINE_PROFILE / PIPELINE_PROFILE_SLOW_MS (SetPipelineProfiling)
+//
+// When disabled the timer is nil: no clock reads, no allocations.
+
+var (
+	pipelineProfiling atomic.Bool
+	pipelineSlowNanos atomic.Int64
+)
+
+// SetPipelineProfiling enables per-step timing of AnalyzeTx. Analyses
+// slower than slow (when > 0) are logged with their dominant steps.
+func SetPipelineProfiling(enabled bool, slow time.Duration) {
+	if slow < 0 {
+		slow = 0
+	}
+	pipelineSlowNanos.Store(int64(slow))
+	pipelineProfiling.Store(enabled)
+}
+
+// PipelineProfiling reports whether per-step timing is enabled and the
+// slow-analysis log threshold
+func PipelineProfiling() (bool, time.Duration) {
+	return pipelineProfiling.Load(), time.Duration(pipelineSlowNanos.Load())
+}
+
+// stepTimer accumulates the wall time of each pipeline step
+type stepTimer struct {
+	start time.Time
+	last  time.Time
+	steps map[string]float64
+}
+
+// newStepTimer returns nil when profiling is disabled
+func newStepTimer() *stepTimer {
+	if !pipelineProfiling.Load() {
+		return nil
+	}
+	now := time.Now()
+	return &stepTimer{start: now, last: now, steps: make(map[string]float64, 32)}
+}
+
+// mark attributes the time since the previous mark to step
+func (t *stepTimer) mark(step string) {
+	if t == nil {
+		return
+	}
+	now := time.Now()
+	t.steps[step] += float64(now.Sub(t.last)) / float64(time.Millisecond)
+	t.last = now
+}
+
+// finish attaches the timings to res and logs slow analyses
+func (t *stepTimer) finish(tx models.Transaction, res *models.PrivacyAnalysisResult) {
+	if t == nil {
+		return
+	}
+	total := t.last.Sub(t.start)
+	res.StepTimings = t.steps
+
+	slow := time.Duration(pipelineSlowNanos.Load())
+	if slow <= 0 || total < slow {
+		return
+	}
+	log.Printf("[Pipeline] Slow analysis of %s: %s total (%d in / %d out), slowest steps: %v",
+		tx.Txid, total.Round(time.Microsecond), len(tx.Inputs), len(tx.Outputs), slowestSteps(t.steps, 3))
+}
+
+// slowestSteps returns the n steps with the largest recorded time
+func slowestSteps(steps map[string]float64, n int) []string {
+	names := make([]string, 0, len(steps))
+	for name := range steps {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if steps[names[i]] != steps[names[j]] {
+			return steps[names[i]] > steps[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	if len(names) > n {
+		names = names[:n]
+	}
+	out := make([]string, len(names))
+	for i, name := range names {
+		out[i] = fmt.Sprintf("%s=%.1fms", name, steps[name])
+	}
+	return out
+}
@@ -0,0 +1,42 @@
+package heuristics
+
+import (
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func TestAnalyzeTx_StepTimings(t *testing.T) {
+	tx := models.Transaction{
+		Txid:   "profile1",
+		Inputs: []models.TxIn{{Address: "bc1qsender", Value: 200_000}},
+		Outputs: []models.TxOut{
+			{Address: "bc1qrecipient", Value: 150_000},
+			{Address: "bc1qchange", Value: 49_000},
+		},
+	}
+
+	if res := AnalyzeTx(tx); res.StepTimings != nil {
+		t.Errorf("Expected no step timings with profiling disabled. Got %v", res.StepTimings)
+	}
+
+	SetPipelineProfiling(true, 0)
+	defer SetPipelineProfiling(false, 0)
+
+	res := AnalyzeTx(tx)
+	for _, step := range []string{"anonset", "change", "boltzmann", "evidence_inference", "standardness"} {
+		if ms, ok := res.StepTimings[step]; !ok || ms < 0 {
+			t.Errorf("Expected a timing for step %s. Got %v", step, res.StepTimings)
+		}
+	}
+	if len(res.StepTimings) != 32 {
+		t.Errorf("Expected all 32 pipeline steps timed. Got %d", len(res.StepTimings))
+	}
+}
+
+func TestSlowestSteps(t *testing.T) {
+	got := slowestSteps(map[string]float64{"anonset": 12.5, "change": 0.2, "boltzmann": 40, "dust": 0.1}, 2)
+	if len(got) != 2 || got[0] != "boltzmann=40.0ms" || got[1] != "anonset=12.5ms" {
+		t.Errorf("Expected boltzmann then anonset. Got %v", got)
+	}
+}
@@ -0,0 +1,254 @@
+package heuristics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Mining Pool Tag Database
+//
+// Coinbase attribution matches two things a pool controls: the ASCII tag
+// it writes into the coinbase scriptSig and the address it pays the reward
+// to. Both change over time (pools rebrand, rotate payout wallets), so the
+// built-in markers are only a fallback: operators point POOL_TAGS_FILE at a
+// list in the known-pools JSON format
+//
+//   {
+//     "coinbase_tags":    {"/Foundry USA Pool/": {"name": "Foundry USA", "link": "…"}},
+//     "payout_addresses": {"bc1q…": {"name": "Foundry USA", "link": "…"}}
+//   }
+//
+// and reload it at runtime (POST /api/v1/pools/reload) without a restart.
+// File entries extend the built-ins and win on conflicting tags.
+//
+// References:
+//   - btccom/Blockchain-Known-Pools (pools.json)
+
+// Pool attribution confidences
+const (
+	poolTagConfidence      = 0.95 // Tag found in the decoded scriptSig
+	poolHexTagConfidence   = 0.85 // Tag found in the raw scriptSig hex
+	poolAddressConfidence  = 0.9  // Reward paid to a known payout address
+	poolAgreeConfidence    = 0.99 // Tag and payout address name the same pool
+	poolConflictConfidence = 0.7  // Payout address contradicts the tag
+)
+
+// PoolTagInfo is a pool entry of the known-pools JSON format
+type PoolTagInfo struct {
+	Name string `json:"name"`
+	Link string `json:"link,omitempty"`
+}
+
+// PoolTagFile is the known-pools JSON format
+type PoolTagFile struct {
+	CoinbaseTags    map[string]PoolTagInfo `json:"coinbase_tags"`
+	PayoutAddresses map[string]PoolTagInfo `json:"payout_addresses"`
+}
+
+// PoolTagStats summarizes the loaded database
+type PoolTagStats struct {
+	Source    string `json:"source"` // File the entries came from ("builtin" if none)
+	Tags      int    `json:"tags"`
+	Addresses int    `json:"addresses"`
+	Pools     int    `json:"pools"`
+}
+
+// poolTag is a lowercased coinbase tag
+type poolTag struct {
+	tag  string
+	pool string
+}
+
+// PoolTagDB attributes coinbase transactions to mining pools
+type PoolTagDB struct {
+	mu        sync.RWMutex
+	path      string            // File Reload re-reads
+	source    string            // File the current entries came from ("" = built-ins only)
+	tags      []poolTag         // Longest first, so specific tags beat generic ones
+	addresses map[string]string // Payout address → pool
+}
+
+var (
+	globalPoolTags     *PoolTagDB
+	globalPoolTagsOnce sync.Once
+)
+
+// NewPoolTagDB creates a database holding the built-in markers
+func NewPoolTagDB() *PoolTagDB {
+	db := &PoolTagDB{}
+	db.tags, db.addresses = buildPoolTags(PoolTagFile{})
+	return db
+}
+
+// GetGlobalPoolTagDB returns the process-wide database consulted by
+// AnalyzeCoinbaseTx (built-in markers unless a file was loaded)
+func GetGlobalPoolTagDB() *PoolTagDB {
+	globalPoolTagsOnce.Do(func() {
+		globalPoolTags = NewPoolTagDB()
+	})
+	return globalPoolTags
+}
+
+// buildPoolTags merges the built-in markers with file
+func buildPoolTags(file PoolTagFile) ([]poolTag, map[string]string) {
+	byTag := make(map[string]string, len(poolMarkers)+len(file.CoinbaseTags))
+	for marker, pool := range poolMarkers {
+		byTag[marker] = pool
+	}
+	for tag, info := range file.CoinbaseTags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag != "" && info.Name != "" {
+			byTag[tag] = info.Name
+		}
+	}
+	tags := make([]poolTag, 0, len(byTag))
+	for tag, pool := range byTag {
+		tags = append(tags, poolTag{tag: tag, pool: pool})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if len(tags[i].tag) != len(tags[j].tag) {
+			return len(tags[i].tag) > len(tags[j].tag)
+		}
+		return tags[i].tag < tags[j].tag
+	})
+
+	addresses := make(map[string]string, len(file.PayoutAddresses))
+	for addr, info := range file.PayoutAddresses {
+		addr = strings.TrimSpace(addr)
+		if addr != "" && info.Name != "" {
+			addresses[addr] = info.Name
+		}
+	}
+	return tags, addresses
+}
+
+// Load replaces the file entries with a known-pools JSON list. On error
+// the database is left unchanged.
+func (db *PoolTagDB) Load(r io.Reader) (PoolTagStats, error) {
+	if err := db.load(r, "reader"); err != nil {
+		return PoolTagStats{}, err
+	}
+	return db.Stats(), nil
+}
+
+// LoadFile loads a known-pools JSON file. The path is remembered for
+// Reload even when loading fails, so a broken file can be fixed in place.
+func (db *PoolTagDB) LoadFile(path string) (PoolTagStats, error) {
+	db.mu.Lock()
+	db.path = path
+	db.mu.Unlock()
+
+	f, err := os.Open(path)
+	if err != nil {
+		return PoolTagStats{}, fmt.Errorf("open pool tags: %w", err)
+	}
+	defer f.Close()
+	if err := db.load(f, path); err != nil {
+		return PoolTagStats{}, err
+	}
+	return db.Stats(), nil
+}
+
+func (db *PoolTagDB) load(r io.Reader, source string) error {
+	var file PoolTagFile
+	if err := json.NewDecoder(r).Decode(&file); err != nil {
+		return fmt.Errorf("decode pool tags: %w", err)
+	}
+	tags, addresses := buildPoolTags(file)
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.tags, db.addresses, db.source = tags, addresses, source
+	return nil
+}
+
+// Path returns the file Reload re-reads ("" if none was configured)
+func (db *PoolTagDB) Path() string {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.path
+}
+
+// Reload re-reads the file last loaded with LoadFile
+func (db *PoolTagDB) Reload() (PoolTagStats, error) {
+	db.mu.RLock()
+	path := db.path
+	db.mu.RUnlock()
+	if path == "" {
+		return PoolTagStats{}, fmt.Errorf("no pool tags file loaded")
+	}
+	return db.LoadFile(path)
+}
+
+// Stats summarizes the loaded tags and payout addresses
+func (db *PoolTagDB) Stats() PoolTagStats {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	stats := PoolTagStats{Source: "builtin", Tags: len(db.tags), Addresses: len(db.addresses)}
+	if db.source != "" {
+		stats.Source = db.source
+	}
+	pools := make(map[string]bool)
+	for _, t := range db.tags {
+		pools[t.pool] = true
+	}
+	for _, pool := range db.addresses {
+		pools[pool] = true
+	}
+	stats.Pools = len(pools)
+	return stats
+}
+
+// Identify attributes a coinbase from its scriptSig tag and payout
+// addresses, returning the pool, confidence and what matched
+// ("tag"/"address"/"tag+address"); "unknown" when nothing matches
+func (db *PoolTagDB) Identify(scriptSig string, outputs []models.TxOut) (string, float64, string) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	tagPool, tagConf := db.matchTag(scriptSig)
+	addrPool := ""
+	for _, out := range outputs {
+		if pool, ok := db.addresses[out.Address]; ok && out.Address != "" {
+			addrPool = pool
+			break
+		}
+	}
+
+	switch {
+	case tagPool != "" && addrPool == "":
+		return tagPool, tagConf, "tag"
+	case tagPool == "" && addrPool != "":
+		return addrPool, poolAddressConfidence, "address"
+	case tagPool != "" && tagPool == addrPool:
+		return tagPool, poolAgreeConfidence, "tag+address"
+	case tagPool != "":
+		return addrPool, poolConflictConfidence, "address" // The reward's destination outweighs a copied tag
+	}
+	return "unknown", 0, ""
+}
+
+// matchTag matches the scriptSig against the coinbase tags
+func (db *PoolTagDB) matchTag(scriptSig string) (string, float64) {
+	if scriptSig == "" {
+		return "", 0
+	}
+	lower := strings.ToLower(scriptSig)
+	decoded := hexToASCII(lower)
+	for _, t := range db.tags {
+		if strings.Contains(decoded, t.tag) {
+			return t.pool, poolTagConfidence
+		}
+		if strings.Contains(lower, t.tag) {
+			return t.pool, poolHexTagConfidence
+		}
+	}
+	return "", 0
+}
@@ -0,0 +1,112 @@
+package heuristics
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func writePoolTags(t *testing.T, path, body string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPoolTagDB_BuiltinFallback(t *testing.T) {
+	db := NewPoolTagDB()
+	pool, conf, match := db.Identify("0340d10c2f416e74506f6f6c2f", nil) // "/AntPool/"
+	if pool != "AntPool" || conf != poolTagConfidence || match != "tag" {
+		t.Fatalf("got %q %.2f %q, want AntPool via built-in tag", pool, conf, match)
+	}
+	if stats := db.Stats(); stats.Source != "builtin" || stats.Addresses != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+}
+
+func TestPoolTagDB_TagAndAddressMatching(t *testing.T) {
+	db := NewPoolTagDB()
+	_, err := db.Load(strings.NewReader(`{
+		"coinbase_tags": {"/NewPool/": {"name": "NewPool", "link": "https://newpool.example"}},
+		"payout_addresses": {"bc1qnewpoolpayout": {"name": "NewPool"}, "bc1qfoundrypayout": {"name": "Foundry USA"}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tag := "03a0bb0d2f4e6577506f6f6c2f" // "/NewPool/"
+	payout := []models.TxOut{{Address: "bc1qnewpoolpayout", Value: 312_500_000}}
+	other := []models.TxOut{{Address: "bc1qfoundrypayout", Value: 312_500_000}}
+
+	cases := []struct {
+		name      string
+		scriptSig string
+		outputs   []models.TxOut
+		pool      string
+		match     string
+		conf      float64
+	}{
+		{"tag only", tag, nil, "NewPool", "tag", poolTagConfidence},
+		{"address only", "03a0bb0d", payout, "NewPool", "address", poolAddressConfidence},
+		{"tag and address agree", tag, payout, "NewPool", "tag+address", poolAgreeConfidence},
+		{"address contradicts tag", tag, other, "Foundry USA", "address", poolConflictConfidence},
+		{"nothing", "03a0bb0d", nil, "unknown", "", 0},
+	}
+	for _, tc := range cases {
+		pool, conf, match := db.Identify(tc.scriptSig, tc.outputs)
+		if pool != tc.pool || match != tc.match || conf != tc.conf {
+			t.Errorf("%s: got %q %.2f %q, want %q %.2f %q", tc.name, pool, conf, match, tc.pool, tc.conf, tc.match)
+		}
+	}
+}
+
+func TestPoolTagDB_ReloadChangesAttribution(t *testing.T) {
+	prev := GetGlobalPoolTagDB()
+	globalPoolTags = NewPoolTagDB()
+	t.Cleanup(func() { globalPoolTags = prev })
+
+	path := filepath.Join(t.TempDir(), "pools.json")
+	writePoolTags(t, path, `{"coinbase_tags": {"/NewPool/": {"name": "NewPool"}}}`)
+	if _, err := GetGlobalPoolTagDB().LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	tx := coinbaseAt("/NewPool/", models.TxOut{Address: "bc1qrebrandpayout", Value: 330_000_000})
+	if cb := AnalyzeCoinbaseTx(tx); cb.PoolName != "NewPool" {
+		t.Fatalf("pool %q, want NewPool", cb.PoolName)
+	}
+
+	// The pool rebrands: same tag, new name and a known payout address
+	writePoolTags(t, path, `{
+		"coinbase_tags": {"/NewPool/": {"name": "RebrandPool"}},
+		"payout_addresses": {"bc1qrebrandpayout": {"name": "RebrandPool"}}
+	}`)
+	stats, err := GetGlobalPoolTagDB().Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Source != path || stats.Addresses != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	cb := AnalyzeCoinbaseTx(tx)
+	if cb.PoolName != "RebrandPool" || cb.PoolMatch != "tag+address" {
+		t.Fatalf("after reload got %q via %q, want RebrandPool via tag+address", cb.PoolName, cb.PoolMatch)
+	}
+
+	// A broken edit keeps the previous tags
+	writePoolTags(t, path, `{"coinbase_tags": `)
+	if _, err := GetGlobalPoolTagDB().Reload(); err == nil {
+		t.Fatal("expected reload of malformed JSON to fail")
+	}
+	if cb := AnalyzeCoinbaseTx(tx); cb.PoolName != "RebrandPool" {
+		t.Errorf("failed reload changed attribution to %q", cb.PoolName)
+	}
+}
+
+func TestPoolTagDB_ReloadWithoutFile(t *testing.T) {
+	if _, err := NewPoolTagDB().Reload(); err == nil {
+		t.Error("expected reload without a configured file to fail")
+	}
+}
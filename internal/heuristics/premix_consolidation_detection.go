@@ -0,0 +1,146 @@
+package heuristics
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Pre-Mix Consolidation Detection (consolidation output index + CoinJoin entry)
+//
+// The inverse of post-mix leakage: a user sweeps many UTXOs into one and
+// then feeds the consolidated coin into a CoinJoin. The mix hides where
+// the coin goes next, but the consolidation has already linked every
+// pre-mix UTXO (and address) to one entity by CIOH — a linkage the mix's
+// anonymity set does nothing to undo:
+//
+//   - Consolidation: ≥3 inputs into ≤2 outputs, recorded in the ConsolidationOutputIndex
+//   - CoinJoin entry: a CoinJoin input spends a recorded consolidation output
+//   - Shortly after: within premixWindowBlocks when both heights are known
+//
+// References:
+//   - Meiklejohn et al., "A Fistful of Bitcoins" (IMC 2013)
+//   - Möser & Narayanan, "Obfuscation in Bitcoin" (2017)
+
+const (
+	consolidationIndexLimit = 1_000_000 // Outpoints remembered before the index resets
+	premixWindowBlocks      = 144       // ~1 day between consolidation and mix
+)
+
+// consolidationRecord describes the consolidation behind an indexed outpoint
+type consolidationRecord struct {
+	txid        string
+	inputs      int
+	addresses   int // Distinct input addresses linked by the consolidation
+	blockHeight int // 0 = mempool
+}
+
+// ConsolidationOutputIndex remembers which outpoints were created by consolidations
+type ConsolidationOutputIndex struct {
+	mu      sync.Mutex
+	outputs map[string]consolidationRecord // "txid:vout" → consolidation
+}
+
+// NewConsolidationOutputIndex creates an empty index
+func NewConsolidationOutputIndex() *ConsolidationOutputIndex {
+	return &ConsolidationOutputIndex{outputs: make(map[string]consolidationRecord)}
+}
+
+// Record indexes the outputs of tx if it is a consolidation, reporting
+// whether it was one
+func (idx *ConsolidationOutputIndex) Record(tx models.Transaction) bool {
+	if !AnalyzeConsolidation(tx).IsConsolidation {
+		return false
+	}
+
+	addrs := make(map[string]bool)
+	for _, in := range tx.Inputs {
+		if in.Address != "" {
+			addrs[in.Address] = true
+		}
+	}
+	rec := consolidationRecord{txid: tx.Txid, inputs: len(tx.Inputs), addresses: len(addrs), blockHeight: tx.BlockHeight}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	if len(idx.outputs) > consolidationIndexLimit {
+		idx.outputs = make(map[string]consolidationRecord)
+	}
+	for vout := range tx.Outputs {
+		idx.outputs[outpointKey(tx.Txid, uint32(vout))] = rec
+	}
+	return true
+}
+
+func (idx *ConsolidationOutputIndex) lookup(txid string, vout uint32) (consolidationRecord, bool) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+	rec, ok := idx.outputs[outpointKey(txid, vout)]
+	return rec, ok
+}
+
+// PreMixConsolidationPattern is a consolidation spent into a CoinJoin
+type PreMixConsolidationPattern struct {
+	IsPreMixConsolidation bool     `json:"isPreMixConsolidation"`
+	Label                 string   `json:"label"` // "pre-mix consolidation"
+	CoinJoinTxid          string   `json:"coinJoinTxid"`
+	ConsolidationTxids    []string `json:"consolidationTxids"`
+	MixInputs             int      `json:"mixInputs"`       // CoinJoin inputs that spend a consolidation
+	LinkedUTXOs           int      `json:"linkedUtxos"`     // Pre-mix UTXOs linked by the consolidations
+	LinkedAddresses       int      `json:"linkedAddresses"` // Distinct pre-mix addresses linked
+	BlocksBeforeMix       int      `json:"blocksBeforeMix"` // Smallest consolidation→mix gap (-1 = unknown)
+	Note                  string   `json:"note"`
+	Confidence            float64  `json:"confidence"`
+}
+
+// DetectPreMixConsolidation flags CoinJoin inputs that spend a recently
+// indexed consolidation. Call it on transactions already classified as
+// CoinJoins, before recording them elsewhere.
+func DetectPreMixConsolidation(coinjoin models.Transaction, index *ConsolidationOutputIndex) PreMixConsolidationPattern {
+	p := PreMixConsolidationPattern{BlocksBeforeMix: -1}
+	if index == nil {
+		return p
+	}
+
+	seen := make(map[string]bool)
+	for _, in := range coinjoin.Inputs {
+		rec, ok := index.lookup(in.Txid, in.Vout)
+		if !ok {
+			continue
+		}
+
+		gap := -1
+		if rec.blockHeight > 0 && coinjoin.BlockHeight > 0 {
+			gap = coinjoin.BlockHeight - rec.blockHeight
+			if gap > premixWindowBlocks {
+				continue // Long-settled coin: no longer "consolidate then mix"
+			}
+		}
+
+		p.MixInputs++
+		if gap >= 0 && (p.BlocksBeforeMix < 0 || gap < p.BlocksBeforeMix) {
+			p.BlocksBeforeMix = gap
+		}
+		if !seen[rec.txid] {
+			seen[rec.txid] = true
+			p.ConsolidationTxids = append(p.ConsolidationTxids, rec.txid)
+			p.LinkedUTXOs += rec.inputs
+			p.LinkedAddresses += rec.addresses
+		}
+	}
+	if p.MixInputs == 0 {
+		return PreMixConsolidationPattern{BlocksBeforeMix: -1}
+	}
+
+	p.IsPreMixConsolidation = true
+	p.Label = "pre-mix consolidation"
+	p.CoinJoinTxid = coinjoin.Txid
+	p.Note = fmt.Sprintf("%d UTXO(s) across %d address(es) were linked by consolidation before entering the mix; the CoinJoin does not break that linkage",
+		p.LinkedUTXOs, p.LinkedAddresses)
+	p.Confidence = 0.80
+	if p.LinkedUTXOs >= 5 || p.MixInputs >= 2 {
+		p.Confidence = 0.90 // Large sweep, or several consolidated coins co-entering the same round
+	}
+	return p
+}
@@ -0,0 +1,78 @@
+package heuristics
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// sweepTx consolidates six small UTXOs from four addresses into one coin
+func sweepTx(height int) models.Transaction {
+	tx := models.Transaction{Txid: "sweep", BlockHeight: height, Fee: 3_000, Vsize: 400}
+	for i := 0; i < 6; i++ {
+		tx.Inputs = append(tx.Inputs, models.TxIn{Txid: fmt.Sprintf("utxo%d", i), Address: fmt.Sprintf("bc1qaddr%d", i%4), Value: 1_000_000})
+	}
+	tx.Outputs = []models.TxOut{{Address: "bc1qconsolidated", Value: 5_997_000}}
+	return tx
+}
+
+// premixRound is a 5-party CoinJoin whose first input is the swept coin
+func premixRound(height int) models.Transaction {
+	tx := models.Transaction{Txid: "mix_round", BlockHeight: height}
+	tx.Inputs = append(tx.Inputs, models.TxIn{Txid: "sweep", Vout: 0, Value: 5_997_000})
+	for i := 1; i < 5; i++ {
+		tx.Inputs = append(tx.Inputs, models.TxIn{Txid: fmt.Sprintf("peer%d", i), Value: 5_500_000})
+	}
+	for i := 0; i < 5; i++ {
+		tx.Outputs = append(tx.Outputs, models.TxOut{Value: 5_000_000})
+	}
+	return tx
+}
+
+func TestDetectPreMixConsolidation_SweepIntoCoinJoin(t *testing.T) {
+	index := NewConsolidationOutputIndex()
+	if !index.Record(sweepTx(850_000)) {
+		t.Fatal("Expected the 6-into-1 sweep to be recorded as a consolidation")
+	}
+
+	p := DetectPreMixConsolidation(premixRound(850_003), index)
+	if !p.IsPreMixConsolidation || p.Label != "pre-mix consolidation" {
+		t.Fatalf("Expected a pre-mix consolidation. Got %+v", p)
+	}
+	if p.MixInputs != 1 || p.LinkedUTXOs != 6 || p.LinkedAddresses != 4 || p.BlocksBeforeMix != 3 {
+		t.Errorf("Unexpected linkage: %+v", p)
+	}
+	if len(p.ConsolidationTxids) != 1 || p.ConsolidationTxids[0] != "sweep" || p.CoinJoinTxid != "mix_round" {
+		t.Errorf("Expected sweep → mix_round. Got %+v", p)
+	}
+	if !strings.Contains(p.Note, "6 UTXO(s) across 4 address(es)") || p.Confidence < 0.9 {
+		t.Errorf("Expected the pre-linkage noted with high confidence. Got %q (%.2f)", p.Note, p.Confidence)
+	}
+}
+
+func TestDetectPreMixConsolidation_Negatives(t *testing.T) {
+	index := NewConsolidationOutputIndex()
+
+	// A 1-in-2-out payment is not a consolidation
+	payment := models.Transaction{
+		Txid:    "payment",
+		Inputs:  []models.TxIn{{Txid: "x", Value: 6_000_000}},
+		Outputs: []models.TxOut{{Value: 5_000_000}, {Value: 990_000}},
+	}
+	if index.Record(payment) {
+		t.Error("Expected a simple payment to be ignored")
+	}
+
+	// Consolidated a month before the mix
+	index.Record(sweepTx(850_000))
+	if got := DetectPreMixConsolidation(premixRound(850_000+4_320), index); got.IsPreMixConsolidation {
+		t.Errorf("Expected a long-settled consolidation to be outside the window. Got %+v", got)
+	}
+
+	// Mix with no consolidated inputs
+	if got := DetectPreMixConsolidation(premixRound(850_001), NewConsolidationOutputIndex()); got.IsPreMixConsolidation {
+		t.Errorf("Expected no flag without a consolidation. Got %+v", got)
+	}
+}
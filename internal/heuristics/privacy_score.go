@@ -144,7 +144,7 @@ func CalibratePrivacyScore(res *models.PrivacyAnalysisResult) models.ScoreBreakd
 
 	// ─── Traceability ────────────────────────────────────────────────
 	// Inverse of privacy: probability the tx can be de-anonymized
-	bd.Traceability = math.Round((1.0-float64(score)/100.0)*100) / 100
+	bd.Traceability = 1.0 - float64(score)/100.0
 
 	// Apply calibrated score
 	res.PrivacyScore = score
@@ -161,5 +161,5 @@ func CalibratePrivacyScore(res *models.PrivacyAnalysisResult) models.ScoreBreakd
 // the probability that an analyst can de-anonymize the transaction.
 // 0.0 = untraceable, 1.0 = fully transparent
 func ComputeTraceability(privacyScore int) float64 {
-	return math.Round((1.0-float64(privacyScore)/100.0)*100) / 100
+	return 1.0 - float64(privacyScore)/100.0
 }
@@ -0,0 +1,196 @@
+package heuristics
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Ransomware Payment Split Detection (taint + topology)
+//
+// Ransomware-as-a-Service payments are divided before they are laundered:
+// the victim's payment lands on an operator-controlled address, then one
+// transaction fans it out into the affiliate's cut (typically 70–80%) and
+// the core developers' share, and the cuts move on to mixers or exchange
+// deposits. Taint alone says "ransomware money moved"; the split says the
+// proceeds are being distributed, which is the window to act in:
+//
+//   - Ransomware taint: inputs seeded with category "ransomware" carry
+//     most of the input value
+//   - Affiliate fan-out: a small number of outputs where the largest cut
+//     holds ransomwareAffiliateMin–Max of the value and the rest are
+//     operator/developer shares
+//   - Followed by mixing: a split output is later spent into a CoinJoin
+//     (stage "mixed") or deposited at a known exchange ("cashed_out")
+//
+// References:
+//   - Paquet-Clouston, Haslhofer & Dupont, "Ransomware payments in the
+//     Bitcoin ecosystem" (J. Cybersecurity 2019)
+//   - Chainalysis, "The 2022 Crypto Crime Report" — RaaS affiliate splits
+//   - FinCEN, "Ransomware Trends in Bank Secrecy Act Data" (2021)
+
+const (
+	ransomwareMinTaint       = 0.5     // Input taint counted as ransomware-sourced
+	ransomwareMinTaintedFrac = 0.5     // Tainted share of input value
+	ransomwareMinOutputs     = 2       // Affiliate cut + at least one operator share
+	ransomwareMaxOutputs     = 10      // Wider fan-outs look like exchange batches
+	ransomwareAffiliateMin   = 0.6     // Largest cut share (RaaS affiliates keep 60–90%)
+	ransomwareAffiliateMax   = 0.9     // Above this the rest is just change/fee noise
+	ransomwareTrackedLimit   = 100_000 // Split outputs remembered before the tracker resets
+)
+
+// RansomwareShare is one output of a ransomware split
+type RansomwareShare struct {
+	Index   int     `json:"index"`
+	Address string  `json:"address"`
+	Value   int64   `json:"value"`
+	Share   float64 `json:"share"` // Fraction of the split's output value
+	Role    string  `json:"role"`  // "affiliate"/"operator"
+}
+
+// RansomwarePaymentPattern is the classification of a ransomware split
+type RansomwarePaymentPattern struct {
+	IsRansomwareSplit bool              `json:"isRansomwareSplit"`
+	Stage             string            `json:"stage"`              // "split"/"mixed"/"cashed_out"
+	SplitTxid         string            `json:"splitTxid"`          // Transaction that divided the payment
+	TaintedInputs     int               `json:"taintedInputs"`      // Ransomware-tainted inputs of the split
+	TaintedValue      int64             `json:"taintedValue"`       // Sats from ransomware-tainted inputs
+	AffiliateShare    float64           `json:"affiliateShare"`     // Largest cut's fraction
+	Shares            []RansomwareShare `json:"shares"`             // Split structure, largest first
+	FollowUpTxid      string            `json:"followUpTxid"`       // Mixing/cash-out tx spending a split output
+	Exchange          string            `json:"exchange,omitempty"` // Exchange for "cashed_out"
+	Confidence        float64           `json:"confidence"`
+}
+
+// DetectRansomwareSplit classifies a transaction spending ransomware-tainted
+// inputs into an affiliate-split fan-out
+func DetectRansomwareSplit(tx models.Transaction) RansomwarePaymentPattern {
+	p := RansomwarePaymentPattern{}
+
+	var totalIn int64
+	for _, in := range tx.Inputs {
+		if in.Value <= 0 {
+			continue
+		}
+		totalIn += in.Value
+		if category, level := TaintCategoryOf(in.Address); category == "ransomware" && level >= ransomwareMinTaint {
+			p.TaintedInputs++
+			p.TaintedValue += in.Value
+		}
+	}
+	if p.TaintedInputs == 0 || float64(p.TaintedValue) < ransomwareMinTaintedFrac*float64(totalIn) {
+		return p
+	}
+
+	var shares []RansomwareShare
+	var totalOut int64
+	for i, out := range tx.Outputs {
+		if out.Value <= 0 || out.Address == "" || isOPReturn(out.ScriptPubKey) {
+			continue
+		}
+		shares = append(shares, RansomwareShare{Index: i, Address: out.Address, Value: out.Value})
+		totalOut += out.Value
+	}
+	if len(shares) < ransomwareMinOutputs || len(shares) > ransomwareMaxOutputs || totalOut == 0 {
+		return p
+	}
+
+	sort.SliceStable(shares, func(a, b int) bool { return shares[a].Value > shares[b].Value })
+	for i := range shares {
+		shares[i].Share = float64(shares[i].Value) / float64(totalOut)
+		shares[i].Role = "operator"
+	}
+	shares[0].Role = "affiliate"
+
+	p.AffiliateShare = shares[0].Share
+	if p.AffiliateShare < ransomwareAffiliateMin || p.AffiliateShare > ransomwareAffiliateMax {
+		return p
+	}
+
+	p.IsRansomwareSplit = true
+	p.Stage = "split"
+	p.SplitTxid = tx.Txid
+	p.Shares = shares
+
+	conf := 0.6
+	if p.AffiliateShare >= 0.7 && p.AffiliateShare <= 0.8 {
+		conf += 0.15 // Canonical RaaS affiliate cut
+	}
+	if p.TaintedValue == totalIn {
+		conf += 0.1
+	}
+	p.Confidence = conf
+	return p
+}
+
+// RansomwareSplitTracker follows split outputs to the mixing or cash-out
+// transaction that spends them
+type RansomwareSplitTracker struct {
+	mu     sync.Mutex
+	splits map[string]*RansomwarePaymentPattern // split output address → its split
+}
+
+// NewRansomwareSplitTracker creates an empty tracker
+func NewRansomwareSplitTracker() *RansomwareSplitTracker {
+	return &RansomwareSplitTracker{splits: make(map[string]*RansomwarePaymentPattern)}
+}
+
+// Observe classifies a transaction: either a new ransomware split, or a
+// CoinJoin / exchange deposit spending a previously seen split output
+func (t *RansomwareSplitTracker) Observe(tx models.Transaction, isCoinJoin bool) RansomwarePaymentPattern {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if follow, ok := t.followUp(tx, isCoinJoin); ok {
+		return follow
+	}
+
+	p := DetectRansomwareSplit(tx)
+	if !p.IsRansomwareSplit {
+		return p
+	}
+	if len(t.splits) > ransomwareTrackedLimit {
+		t.splits = make(map[string]*RansomwarePaymentPattern)
+	}
+	split := p
+	for _, share := range p.Shares {
+		t.splits[share.Address] = &split
+	}
+	return p
+}
+
+// followUp matches a tx spending a tracked split output into a mixer or exchange
+func (t *RansomwareSplitTracker) followUp(tx models.Transaction, isCoinJoin bool) (RansomwarePaymentPattern, bool) {
+	var split *RansomwarePaymentPattern
+	for _, in := range tx.Inputs {
+		if s, ok := t.splits[in.Address]; ok {
+			split = s
+			break
+		}
+	}
+	if split == nil {
+		return RansomwarePaymentPattern{}, false
+	}
+
+	p := *split
+	p.FollowUpTxid = tx.Txid
+	if isCoinJoin {
+		p.Stage = "mixed"
+	} else {
+		for _, out := range tx.Outputs {
+			if exchange, ok := IsKnownExchangeAddress(out.Address); ok {
+				p.Stage, p.Exchange = "cashed_out", exchange
+				break
+			}
+		}
+	}
+	if p.Stage == "split" {
+		return RansomwarePaymentPattern{}, false // Onward spend: neither mixing nor cash-out
+	}
+	p.Confidence += 0.1
+	if p.Confidence > 0.95 {
+		p.Confidence = 0.95
+	}
+	return p, true
+}
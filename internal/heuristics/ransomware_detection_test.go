@@ -0,0 +1,103 @@
+package heuristics
+
+import (
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func seedRansomwareWallet(t *testing.T) {
+	resetTaintMapForTest(nil)
+	t.Cleanup(func() { resetTaintMapForTest(nil) })
+	SeedFromExternalIntel([]TaintSource{
+		{Address: "bc1qransomcollector", Category: "ransomware", TaintLevel: 1.0, Label: "LockBit payment wallet"},
+		{Address: "bc1qstolenfunds", Category: "theft", TaintLevel: 1.0, Label: "Exchange hack"},
+	})
+}
+
+// ransomwareFanOut pays a victim's 10 BTC payment out as a 75% affiliate cut
+// plus two operator shares
+func ransomwareFanOut(input string) models.Transaction {
+	return models.Transaction{
+		Txid:   "ransom_split",
+		Inputs: []models.TxIn{{Address: input, Value: 1_000_000_000}},
+		Outputs: []models.TxOut{
+			{Address: "bc1qoperatorcore", Value: 150_000_000},
+			{Address: "bc1qaffiliate", Value: 749_990_000},
+			{Address: "bc1qoperatordev", Value: 100_000_000},
+		},
+	}
+}
+
+func TestDetectRansomwareSplit_AffiliateFanOut(t *testing.T) {
+	seedRansomwareWallet(t)
+
+	p := DetectRansomwareSplit(ransomwareFanOut("bc1qransomcollector"))
+	if !p.IsRansomwareSplit || p.Stage != "split" {
+		t.Fatalf("Expected a ransomware split. Got %+v", p)
+	}
+	if p.TaintedInputs != 1 || p.TaintedValue != 1_000_000_000 {
+		t.Errorf("Expected the full input to be ransomware-tainted. Got %d inputs / %d sats", p.TaintedInputs, p.TaintedValue)
+	}
+	if len(p.Shares) != 3 || p.Shares[0].Address != "bc1qaffiliate" || p.Shares[0].Role != "affiliate" || p.Shares[0].Index != 1 {
+		t.Errorf("Expected the largest cut first as the affiliate share. Got %+v", p.Shares)
+	}
+	if p.AffiliateShare < 0.74 || p.AffiliateShare > 0.76 {
+		t.Errorf("Expected a ~75%% affiliate cut. Got %.3f", p.AffiliateShare)
+	}
+	if p.Confidence < 0.8 {
+		t.Errorf("Expected high confidence for a canonical RaaS split. Got %.2f", p.Confidence)
+	}
+
+	// The same topology funded by a different taint category is not ransomware
+	if other := DetectRansomwareSplit(ransomwareFanOut("bc1qstolenfunds")); other.IsRansomwareSplit {
+		t.Errorf("Theft-tainted fan-out must not be classified as ransomware. Got %+v", other)
+	}
+
+	// An even split is not an affiliate cut
+	even := ransomwareFanOut("bc1qransomcollector")
+	even.Outputs = []models.TxOut{{Address: "bc1qa", Value: 500_000_000}, {Address: "bc1qb", Value: 499_990_000}}
+	if got := DetectRansomwareSplit(even); got.IsRansomwareSplit {
+		t.Errorf("Expected a 50/50 split to be rejected. Got %+v", got)
+	}
+}
+
+func TestRansomwareSplitTracker_FollowsAffiliateCutIntoMixer(t *testing.T) {
+	seedRansomwareWallet(t)
+	tracker := NewRansomwareSplitTracker()
+
+	split := tracker.Observe(ransomwareFanOut("bc1qransomcollector"), false)
+	if !split.IsRansomwareSplit {
+		t.Fatalf("Expected the split to be recorded. Got %+v", split)
+	}
+
+	// The affiliate's cut joins a CoinJoin
+	mix := models.Transaction{
+		Txid:    "whirlpool_mix",
+		Inputs:  []models.TxIn{{Address: "bc1qaffiliate", Value: 749_990_000}, {Address: "bc1qpeer", Value: 749_990_000}},
+		Outputs: []models.TxOut{{Address: "bc1qmixout1", Value: 749_980_000}, {Address: "bc1qmixout2", Value: 749_980_000}},
+	}
+	mixed := tracker.Observe(mix, true)
+	if !mixed.IsRansomwareSplit || mixed.Stage != "mixed" || mixed.FollowUpTxid != "whirlpool_mix" || mixed.SplitTxid != "ransom_split" {
+		t.Fatalf("Expected the mix to be linked to the split. Got %+v", mixed)
+	}
+
+	base := ThreatAssessment{RiskScore: 30, Signals: []string{"taint_exposure"}}
+	escalated := EscalateRansomwareSplit(base, mixed)
+	if escalated.RiskScore != 70 || escalated.Severity != "high" {
+		t.Errorf("Expected 30+40 = 70 (high). Got %d (%s)", escalated.RiskScore, escalated.Severity)
+	}
+	if last := escalated.Signals[len(escalated.Signals)-1]; last != "ransomware_mixed" {
+		t.Errorf("Expected ransomware_mixed signal. Got %v", escalated.Signals)
+	}
+
+	// An ordinary onward payment from an operator share is not a laundering step
+	onward := models.Transaction{
+		Txid:    "operator_spend",
+		Inputs:  []models.TxIn{{Address: "bc1qoperatordev", Value: 100_000_000}},
+		Outputs: []models.TxOut{{Address: "bc1qvendor", Value: 99_990_000}},
+	}
+	if got := tracker.Observe(onward, false); got.IsRansomwareSplit {
+		t.Errorf("Expected onward spend to stay unclassified. Got %+v", got)
+	}
+}
@@ -0,0 +1,189 @@
+package heuristics
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Replace-By-Fee (BIP125) Replacement Tracking
+//
+// The mempool poller analyzes each txid once. When a transaction is
+// replaced, the replacement has a new txid and spends at least one of the
+// same outpoints; without tracking, the stale analysis of the evicted
+// transaction lives on. The tracker indexes every mempool transaction by
+// the outpoints it spends and reports the txids a newcomer conflicts with:
+//
+//   - Fee bump: same output scripts, higher fee (typically the change
+//     output shrinks)
+//   - Cancel: every output pays back to an address of the inputs
+//   - Redirect: the payment outputs changed
+//
+// A conflicting variant with identical inputs and outputs is malleation
+// (WitnessVariantTracker), not a replacement.
+//
+// References:
+//   - BIP125 (Opt-in Full Replace-by-Fee Signaling)
+//   - Bitcoin Core doc/policy/mempool-replacements.md
+
+const rbfOutpointLimit = 500_000 // Outpoints remembered before the tracker resets
+
+// Replacement kinds
+const (
+	RBFFeeBump  = "fee_bump"
+	RBFCancel   = "cancel"
+	RBFRedirect = "redirect"
+)
+
+// RBFReplacement describes a transaction that replaced earlier mempool
+// transactions by spending their outpoints
+type RBFReplacement struct {
+	IsReplacement bool     `json:"isReplacement"`
+	Kind          string   `json:"kind,omitempty"` // RBFFeeBump / RBFCancel / RBFRedirect
+	ReplacedTxids []string `json:"replacedTxids,omitempty"`
+	OldFee        int64    `json:"oldFee"` // Sum over the replaced transactions (sats)
+	NewFee        int64    `json:"newFee"`
+	FeeBump       int64    `json:"feeBump"` // NewFee − OldFee
+}
+
+// rbfEntry is what the tracker remembers of one mempool transaction
+type rbfEntry struct {
+	fee       int64
+	inputs    string // inputSetKey
+	outputs   string // outputSetKey
+	scripts   string // Sorted output scripts, values ignored
+	outpoints []string
+}
+
+// RBFTracker indexes mempool transactions by the outpoints they spend
+type RBFTracker struct {
+	mu       sync.Mutex
+	spenders map[string]string // outpoint → spending txid
+	entries  map[string]rbfEntry
+}
+
+// NewRBFTracker creates an empty tracker
+func NewRBFTracker() *RBFTracker {
+	return &RBFTracker{spenders: make(map[string]string), entries: make(map[string]rbfEntry)}
+}
+
+// Observe records tx (paying fee) and reports the earlier transactions it
+// replaces. Replaced transactions are forgotten so a later bump of the
+// replacement links to the replacement, not the original.
+func (t *RBFTracker) Observe(tx models.Transaction, fee int64) RBFReplacement {
+	if len(tx.Inputs) == 0 || tx.Txid == "" {
+		return RBFReplacement{}
+	}
+	entry := rbfEntry{
+		fee:       fee,
+		inputs:    inputSetKey(tx),
+		outputs:   outputSetKey(tx),
+		scripts:   outputScriptsKey(tx),
+		outpoints: make([]string, 0, len(tx.Inputs)),
+	}
+	for _, in := range tx.Inputs {
+		entry.outpoints = append(entry.outpoints, outpointKey(in.Txid, in.Vout))
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, seen := t.entries[tx.Txid]; seen {
+		return RBFReplacement{} // Re-entered the mempool (e.g. after a reorg)
+	}
+	if len(t.spenders) >= rbfOutpointLimit {
+		t.spenders = make(map[string]string)
+		t.entries = make(map[string]rbfEntry)
+	}
+
+	conflicts := make(map[string]bool)
+	for _, op := range entry.outpoints {
+		if prev, ok := t.spenders[op]; ok && prev != tx.Txid {
+			conflicts[prev] = true
+		}
+	}
+
+	res := RBFReplacement{NewFee: fee}
+	sameScripts := true
+	for txid := range conflicts {
+		old := t.entries[txid]
+		if old.inputs == entry.inputs && old.outputs == entry.outputs {
+			continue // Malleated serialization of the same transaction
+		}
+		res.ReplacedTxids = append(res.ReplacedTxids, txid)
+		res.OldFee += old.fee
+		sameScripts = sameScripts && old.scripts == entry.scripts
+		for _, op := range old.outpoints {
+			if t.spenders[op] == txid {
+				delete(t.spenders, op)
+			}
+		}
+		delete(t.entries, txid)
+	}
+
+	for _, op := range entry.outpoints {
+		t.spenders[op] = tx.Txid
+	}
+	t.entries[tx.Txid] = entry
+
+	if len(res.ReplacedTxids) == 0 {
+		return RBFReplacement{}
+	}
+	sort.Strings(res.ReplacedTxids)
+	res.IsReplacement = true
+	res.FeeBump = res.NewFee - res.OldFee
+	switch {
+	case sameScripts:
+		res.Kind = RBFFeeBump
+	case paysOnlyInputAddresses(tx):
+		res.Kind = RBFCancel
+	default:
+		res.Kind = RBFRedirect
+	}
+	return res
+}
+
+// RBFReplacementAlert builds the informational alert linking a
+// replacement to the transactions it evicted
+func RBFReplacementAlert(tx models.Transaction, res RBFReplacement) Alert {
+	return Alert{
+		Severity:  "low",
+		AlertType: "rbf_replacement",
+		Title:     fmt.Sprintf("RBF replacement (%s)", strings.ReplaceAll(res.Kind, "_", " ")),
+		Description: fmt.Sprintf("Transaction %s replaces %s, fee %d → %d sats (%+d).",
+			tx.Txid, strings.Join(res.ReplacedTxids, ", "), res.OldFee, res.NewFee, res.FeeBump),
+		TxID: tx.Txid,
+	}
+}
+
+// outputScriptsKey is the sorted output scripts of tx (values ignored)
+func outputScriptsKey(tx models.Transaction) string {
+	scripts := make([]string, len(tx.Outputs))
+	for i, out := range tx.Outputs {
+		scripts[i] = out.ScriptPubKey
+		if scripts[i] == "" {
+			scripts[i] = out.Address
+		}
+	}
+	sort.Strings(scripts)
+	return strings.Join(scripts, ",")
+}
+
+// paysOnlyInputAddresses reports whether every output returns to an input address
+func paysOnlyInputAddresses(tx models.Transaction) bool {
+	inputs := make(map[string]bool, len(tx.Inputs))
+	for _, in := range tx.Inputs {
+		if in.Address != "" {
+			inputs[in.Address] = true
+		}
+	}
+	for _, out := range tx.Outputs {
+		if !inputs[out.Address] {
+			return false
+		}
+	}
+	return len(tx.Outputs) > 0
+}
@@ -0,0 +1,75 @@
+package heuristics
+
+import (
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func rbfSpend(txid string, outputs ...models.TxOut) models.Transaction {
+	return models.Transaction{
+		Txid: txid,
+		Inputs: []models.TxIn{
+			{Txid: "funding", Vout: 0, Address: "bc1qowner", Value: 100_000},
+			{Txid: "funding", Vout: 1, Address: "bc1qowner2", Value: 50_000},
+		},
+		Outputs: outputs,
+	}
+}
+
+func TestRBFTracker_FeeBump(t *testing.T) {
+	tracker := NewRBFTracker()
+	original := rbfSpend("tx_v1",
+		models.TxOut{Address: "bc1qmerchant", ScriptPubKey: "0014aa", Value: 120_000},
+		models.TxOut{Address: "bc1qchange", ScriptPubKey: "0014bb", Value: 29_000})
+	if res := tracker.Observe(original, 1_000); res.IsReplacement {
+		t.Fatalf("Expected the first broadcast not to be a replacement. Got %+v", res)
+	}
+
+	bumped := rbfSpend("tx_v2",
+		models.TxOut{Address: "bc1qmerchant", ScriptPubKey: "0014aa", Value: 120_000},
+		models.TxOut{Address: "bc1qchange", ScriptPubKey: "0014bb", Value: 25_000})
+	res := tracker.Observe(bumped, 5_000)
+	if !res.IsReplacement || res.Kind != RBFFeeBump || len(res.ReplacedTxids) != 1 || res.ReplacedTxids[0] != "tx_v1" {
+		t.Fatalf("Expected a fee bump replacing tx_v1. Got %+v", res)
+	}
+	if res.FeeBump != 4_000 {
+		t.Errorf("Expected a 4000 sat bump. Got %d", res.FeeBump)
+	}
+	if alert := RBFReplacementAlert(bumped, res); alert.AlertType != "rbf_replacement" || alert.TxID != "tx_v2" {
+		t.Errorf("Expected an rbf_replacement alert for tx_v2. Got %+v", alert)
+	}
+
+	// A second bump replaces the first replacement, not the original
+	third := rbfSpend("tx_v3",
+		models.TxOut{Address: "bc1qmerchant", ScriptPubKey: "0014aa", Value: 120_000},
+		models.TxOut{Address: "bc1qchange", ScriptPubKey: "0014bb", Value: 20_000})
+	again := tracker.Observe(third, 10_000)
+	if len(again.ReplacedTxids) != 1 || again.ReplacedTxids[0] != "tx_v2" || again.FeeBump != 5_000 {
+		t.Errorf("Expected tx_v3 to replace tx_v2 (+5000). Got %+v", again)
+	}
+	if res := tracker.Observe(third, 10_000); res.IsReplacement {
+		t.Errorf("Expected a re-fetched known txid to be ignored. Got %+v", res)
+	}
+}
+
+func TestRBFTracker_CancelAndMalleation(t *testing.T) {
+	tracker := NewRBFTracker()
+	payment := rbfSpend("pay",
+		models.TxOut{Address: "bc1qvictim", ScriptPubKey: "0014cc", Value: 140_000},
+		models.TxOut{Address: "bc1qchange", ScriptPubKey: "0014dd", Value: 9_000})
+	tracker.Observe(payment, 1_000)
+
+	// Same inputs and outputs under a new txid: malleation, not RBF
+	malleated := payment
+	malleated.Txid = "pay_malleated"
+	if res := tracker.Observe(malleated, 1_000); res.IsReplacement {
+		t.Errorf("Expected a malleated copy not to count as a replacement. Got %+v", res)
+	}
+
+	cancel := rbfSpend("cancel", models.TxOut{Address: "bc1qowner", ScriptPubKey: "0014ee", Value: 145_000})
+	res := tracker.Observe(cancel, 5_000)
+	if !res.IsReplacement || res.Kind != RBFCancel {
+		t.Errorf("Expected a cancel (double-spend back to the owner). Got %+v", res)
+	}
+}
@@ -25,6 +25,41 @@ import (
 //   high     (51-75):  Suspicious activity, alert team
 //   critical (76-100): Immediate action required
 
+// Points for behavioral signals that a known benign service trips on every payout
+const (
+	riskPointsBotPattern     = 10
+	riskPointsServicePattern = 5
+	riskPointsLowPrivacy     = 3
+)
+
+// Points for ransomware payment splits (see ransomware_detection.go)
+const (
+	riskPointsRansomwareSplit     = 25
+	riskPointsRansomwareLaundered = 40 // Split proceeds reaching a mixer or exchange
+)
+
+// riskPointsWatchlistSpend is added when a watched address appears on the
+// input side: the funds are moving now, which is when a freeze request or
+// exchange notification can still land
+const riskPointsWatchlistSpend = 20
+
+// riskPointsLabeledCashOut is added when watched or high-risk funds pay an
+// exchange-labeled address (address_labels): the KYC'd cash-out point
+const riskPointsLabeledCashOut = 15
+
+// Points for CoinJoin outputs deposited at an exchange (see mix_deposit_detection.go)
+const (
+	riskPointsMixToDeposit = 30
+	riskScoreHighFloor     = 51 // Lowest score classified "high"
+)
+
+// serviceNoiseSignals maps those signals to the points they contributed
+var serviceNoiseSignals = map[string]int{
+	"bot_pattern":           riskPointsBotPattern,
+	"known_service_pattern": riskPointsServicePattern,
+	"low_privacy_score":     riskPointsLowPrivacy,
+}
+
 // ThreatAssessment is the real-time risk verdict for a transaction
 type ThreatAssessment struct {
 	TxID              string   `json:"txid"`
@@ -33,7 +68,9 @@ type ThreatAssessment struct {
 	Signals           []string `json:"signals"`           // Contributing risk signals
 	RecommendedAction string   `json:"recommendedAction"` // "none"/"log"/"review"/"alert"/"escalate"
 	IsWatchlistHit    bool     `json:"isWatchlistHit"`
+	IsWatchlistSpend  bool     `json:"isWatchlistSpend"` // A watched address is spent from (funds leaving)
 	IsCoinJoin        bool     `json:"isCoinJoin"`
+	ExchangeDeposits  []string `json:"exchangeDeposits,omitempty"` // Labels of exchange-labeled outputs
 	ValueBTC          float64  `json:"valueBtc"`
 }
 
@@ -92,15 +129,20 @@ func ScoreTransaction(tx models.Transaction, result models.PrivacyAnalysisResult
 				riskScore += 20
 				signals = append(signals, "watchlist:"+hit.Category+":"+hit.Label)
 			}
+			if hit.Direction == "input" {
+				assessment.IsWatchlistSpend = true
+			}
+		}
+		if assessment.IsWatchlistSpend {
+			riskScore += riskPointsWatchlistSpend
+			signals = append(signals, "watchlisted_funds_moving")
 		}
 	}
 
 	// ─── CoinJoin detection ──────────────────────────────────────────
 	flags := result.HeuristicFlags
 
-	if (flags&uint64(FlagIsWhirlpoolStruct)) > 0 ||
-		(flags&uint64(FlagIsWasabiSuspect)) > 0 ||
-		(flags&uint64(FlagLikelyCollabConstruct)) > 0 {
+	if IsCoinJoinResult(flags) {
 		assessment.IsCoinJoin = true
 		riskScore += 15
 		signals = append(signals, "coinjoin_detected")
@@ -142,7 +184,7 @@ func ScoreTransaction(tx models.Transaction, result models.PrivacyAnalysisResult
 
 	// ─── Bot behavior ────────────────────────────────────────────────
 	if (flags & uint64(FlagBotBehavior)) > 0 {
-		riskScore += 10
+		riskScore += riskPointsBotPattern
 		signals = append(signals, "bot_pattern")
 	}
 
@@ -154,13 +196,13 @@ func ScoreTransaction(tx models.Transaction, result models.PrivacyAnalysisResult
 
 	// ─── Known service pattern ───────────────────────────────────────
 	if (flags & uint64(FlagKnownServicePattern)) > 0 {
-		riskScore += 5
+		riskScore += riskPointsServicePattern
 		signals = append(signals, "known_service_pattern")
 	}
 
 	// ─── Low privacy score (includes address reuse, change detection) ────
 	if result.PrivacyScore < 30 {
-		riskScore += 3
+		riskScore += riskPointsLowPrivacy
 		signals = append(signals, "low_privacy_score")
 	}
 
@@ -176,12 +218,30 @@ func ScoreTransaction(tx models.Transaction, result models.PrivacyAnalysisResult
 		signals = append(signals, "consolidation")
 	}
 
+	// ─── Labeled exchange deposits ───────────────────────────────────
+	for _, hit := range result.Labels {
+		if hit.Direction == "output" && hit.Category == LabelCategoryExchange {
+			assessment.ExchangeDeposits = append(assessment.ExchangeDeposits, hit.Label)
+			signals = append(signals, "exchange_deposit:"+hit.Label)
+		}
+	}
+	if len(assessment.ExchangeDeposits) > 0 &&
+		(assessment.IsWatchlistSpend || taintHighRisk || (flags&uint64(FlagHighRisk)) > 0) {
+		riskScore += riskPointsLabeledCashOut
+		signals = append(signals, "labeled_cash_out")
+	}
+
 	// ─── Compound escalation: CoinJoin + watchlist + high value ──────
 	if assessment.IsCoinJoin && assessment.IsWatchlistHit && totalValue > 100000000 {
 		riskScore += 20
 		signals = append(signals, "compound_escalation")
 	}
 
+	// Watched funds leaving are always at least high severity
+	if assessment.IsWatchlistSpend && riskScore < riskScoreHighFloor {
+		riskScore = riskScoreHighFloor
+	}
+
 	// Cap at 100
 	if riskScore > 100 {
 		riskScore = 100
@@ -198,6 +258,74 @@ func ScoreTransaction(tx models.Transaction, result models.PrivacyAnalysisResult
 	return assessment
 }
 
+// DiscountServiceNoise removes the behavioral noise signals from a payout by
+// a classified faucet/airdrop service. Watchlist, taint and CoinJoin signals
+// are kept: a service payout can still carry real exposure.
+func DiscountServiceNoise(a ThreatAssessment, svc ServicePattern) ThreatAssessment {
+	if !svc.IsService {
+		return a
+	}
+
+	kept := make([]string, 0, len(a.Signals)+1)
+	for _, sig := range a.Signals {
+		if points, noise := serviceNoiseSignals[sig]; noise {
+			a.RiskScore -= points
+			continue
+		}
+		kept = append(kept, sig)
+	}
+	a.Signals = append(kept, "service:"+svc.Kind)
+
+	if a.RiskScore < 0 {
+		a.RiskScore = 0
+	}
+	a.Severity = classifySeverity(a.RiskScore)
+	a.RecommendedAction = recommendAction(a.RiskScore)
+	return a
+}
+
+// EscalateRansomwareSplit raises a transaction's risk for a ransomware
+// payment split, and further when the split proceeds are mixed or cashed out
+func EscalateRansomwareSplit(a ThreatAssessment, split RansomwarePaymentPattern) ThreatAssessment {
+	if !split.IsRansomwareSplit {
+		return a
+	}
+
+	if split.Stage == "split" {
+		a.RiskScore += riskPointsRansomwareSplit
+	} else {
+		a.RiskScore += riskPointsRansomwareLaundered
+	}
+	a.Signals = append(a.Signals, "ransomware_"+split.Stage)
+
+	if a.RiskScore > 100 {
+		a.RiskScore = 100
+	}
+	a.Severity = classifySeverity(a.RiskScore)
+	a.RecommendedAction = recommendAction(a.RiskScore)
+	return a
+}
+
+// EscalateMixToDeposit raises a transaction depositing CoinJoin outputs at
+// an exchange to at least high severity
+func EscalateMixToDeposit(a ThreatAssessment, p MixToDepositPattern) ThreatAssessment {
+	if !p.IsMixToDeposit {
+		return a
+	}
+
+	a.RiskScore += riskPointsMixToDeposit
+	if a.RiskScore < riskScoreHighFloor {
+		a.RiskScore = riskScoreHighFloor
+	}
+	if a.RiskScore > 100 {
+		a.RiskScore = 100
+	}
+	a.Signals = append(a.Signals, p.Label)
+	a.Severity = classifySeverity(a.RiskScore)
+	a.RecommendedAction = recommendAction(a.RiskScore)
+	return a
+}
+
 // classifySeverity maps risk score to severity level
 func classifySeverity(score int) string {
 	switch {
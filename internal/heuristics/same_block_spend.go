@@ -0,0 +1,280 @@
+package heuristics
+
+import (
+	"fmt"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Same-Block Self-Spend Detection
+//
+// An output spent in the same block that created it (or the very next
+// block) was almost certainly spent by software that already held its
+// key: the change owner's wallet, or a mixer participant rushing out of
+// a CoinJoin. Normal recipients rarely react within one block interval.
+// This timing correlation is a concrete deanonymization signal:
+//
+//   - Non-CoinJoin parent: the fast-spent output is the sender's own
+//     change → link it to the parent's input owner, but only when the
+//     output otherwise looks like the sender's (detected change, a reused
+//     input address, or a child built by the same wallet). Timing alone
+//     also fits a payee sweeping a payment (exchange deposits, merchant
+//     processors), which must not be clustered with the payer.
+//   - CoinJoin parent: a child spending 2+ mixed outputs of the same
+//     round links those outputs (undoing the mix)
+//
+// The tracker keeps a one-block window of created outputs, so blocks
+// must be processed in ascending height order.
+//
+// References:
+//   - Möser & Narayanan, "Anonymous Alone" (IEEE S&P 2017) — timing linkage
+//   - Kappos et al., "An Empirical Analysis of Anonymity in Zcash" (USENIX 2018)
+
+// SameBlockSpend records an output spent within one block of its creation
+type SameBlockSpend struct {
+	ParentTxid       string   `json:"parentTxid"`
+	ChildTxid        string   `json:"childTxid"`
+	SpentOutputs     []uint32 `json:"spentOutputs"`   // Parent output indices spent by the child
+	SpentAddresses   []string `json:"spentAddresses"` // Addresses of those outputs
+	BlocksApart      int      `json:"blocksApart"`    // 0 = same block, 1 = next block
+	ParentIsCoinJoin bool     `json:"parentIsCoinJoin"`
+	LinkSignal       string   `json:"linkSignal,omitempty"` // "change", "address_reuse", "wallet_fingerprint"; "" = timing only
+	Confidence       float64  `json:"confidence"`           // 0-1 that parent and child share an owner
+
+	parentOwner string     // Parent's first input address
+	parent      *createdTx // Parent, for its ownership signals
+}
+
+// Same-owner signals a non-CoinJoin fast spend needs before it is linked
+const (
+	linkSignalChange      = "change"
+	linkSignalReuse       = "address_reuse"
+	linkSignalFingerprint = "wallet_fingerprint"
+)
+
+// createdOutput is an output seen in the tracker's window
+type createdOutput struct {
+	txid       string
+	vout       uint32
+	address    string
+	owner      string // Parent's first input address (CIOH owner)
+	isCoinJoin bool
+	parent     *createdTx
+}
+
+// createdTx is a transaction in the tracker's window. Its ownership
+// signals are computed only once one of its outputs is spent fast.
+type createdTx struct {
+	tx       models.Transaction
+	analyzed bool
+	change   int    // Detected change output (-1 = none)
+	wallet   string // Wallet fingerprint ("" = unattributed)
+}
+
+func (p *createdTx) analyze() {
+	if p.analyzed {
+		return
+	}
+	p.analyzed = true
+	p.change = DetectChangeOutput(p.tx).ChangeIndex
+	p.wallet = walletFingerprintKey(p.tx)
+}
+
+// outputSignal returns the parent-side signal that output vout is the
+// sender's own: detected change or a reused input address
+func (p *createdTx) outputSignal(vout uint32) string {
+	p.analyze()
+	if int(vout) == p.change {
+		return linkSignalChange
+	}
+	if int(vout) < len(p.tx.Outputs) {
+		addr := p.tx.Outputs[vout].Address
+		for _, in := range p.tx.Inputs {
+			if addr != "" && in.Address == addr {
+				return linkSignalReuse
+			}
+		}
+	}
+	return ""
+}
+
+// walletFingerprintKey identifies the wallet that built tx: a recognized
+// family plus its input script type ("" when either is unknown)
+func walletFingerprintKey(tx models.Transaction) string {
+	fp := DetectWalletFingerprint(tx)
+	if fp.WalletFamily == "" || fp.WalletFamily == "unknown" || fp.InputScriptTypes == "" || fp.InputScriptTypes == "unknown" {
+		return ""
+	}
+	return fp.WalletFamily + "/" + fp.InputScriptTypes
+}
+
+// SameBlockSpendTracker detects fast self-spends across consecutive blocks
+type SameBlockSpendTracker struct {
+	prevHeight  int
+	prevOutputs map[string]createdOutput
+}
+
+// NewSameBlockSpendTracker creates an empty tracker
+func NewSameBlockSpendTracker() *SameBlockSpendTracker {
+	return &SameBlockSpendTracker{prevHeight: -1}
+}
+
+// Reset clears the window (call when the next block is not contiguous)
+func (t *SameBlockSpendTracker) Reset() {
+	t.prevHeight = -1
+	t.prevOutputs = nil
+}
+
+// ProcessBlock scans a block's transactions (in block order) for outputs
+// spent within the same or the following block, returning the spends and
+// timing-correlation evidence edges. coinJoins marks CoinJoin txids.
+func (t *SameBlockSpendTracker) ProcessBlock(height int, txs []models.Transaction, coinJoins map[string]bool) ([]SameBlockSpend, []models.EvidenceEdge) {
+	if height != t.prevHeight+1 {
+		t.prevOutputs = nil
+	}
+
+	current := make(map[string]createdOutput)
+	var spends []SameBlockSpend
+	var edges []models.EvidenceEdge
+
+	for _, tx := range txs {
+		// Group this tx's fast-spent inputs by parent transaction
+		byParent := make(map[string]*SameBlockSpend)
+		var order []string
+		for _, in := range tx.Inputs {
+			key := outpointID(in.Txid, in.Vout)
+			created, blocksApart := current[key], 0
+			if created.txid == "" {
+				created, blocksApart = t.prevOutputs[key], 1
+			}
+			if created.txid == "" {
+				continue
+			}
+
+			spend, ok := byParent[created.txid]
+			if !ok {
+				spend = &SameBlockSpend{
+					ParentTxid:       created.txid,
+					ChildTxid:        tx.Txid,
+					BlocksApart:      blocksApart,
+					ParentIsCoinJoin: created.isCoinJoin,
+					parentOwner:      created.owner,
+					parent:           created.parent,
+				}
+				byParent[created.txid] = spend
+				order = append(order, created.txid)
+			}
+			spend.SpentOutputs = append(spend.SpentOutputs, created.vout)
+			spend.SpentAddresses = append(spend.SpentAddresses, created.address)
+		}
+
+		childWallet, childAnalyzed := "", false
+		for _, parentTxid := range order {
+			spend := byParent[parentTxid]
+			if !spend.ParentIsCoinJoin {
+				spend.LinkSignal = spend.parent.linkSignal(spend.SpentOutputs, func() string {
+					if !childAnalyzed {
+						childWallet, childAnalyzed = walletFingerprintKey(tx), true
+					}
+					return childWallet
+				})
+			}
+			spend.Confidence = sameBlockSpendConfidence(*spend)
+			spends = append(spends, *spend)
+			edges = append(edges, sameBlockSpendEdges(*spend, height)...)
+		}
+
+		// Register this tx's outputs for later spends
+		owner := ""
+		if len(tx.Inputs) > 0 {
+			owner = tx.Inputs[0].Address
+		}
+		parent := &createdTx{tx: tx}
+		for i, out := range tx.Outputs {
+			current[outpointID(tx.Txid, uint32(i))] = createdOutput{
+				txid:       tx.Txid,
+				vout:       uint32(i),
+				address:    out.Address,
+				owner:      owner,
+				isCoinJoin: coinJoins[tx.Txid],
+				parent:     parent,
+			}
+		}
+	}
+
+	t.prevHeight = height
+	t.prevOutputs = current
+	return spends, edges
+}
+
+// linkSignal returns the first same-owner signal for the spent outputs,
+// falling back to the child being built by the parent's wallet
+func (p *createdTx) linkSignal(spent []uint32, childWallet func() string) string {
+	if p == nil {
+		return ""
+	}
+	for _, vout := range spent {
+		if signal := p.outputSignal(vout); signal != "" {
+			return signal
+		}
+	}
+	if p.wallet != "" && p.wallet == childWallet() {
+		return linkSignalFingerprint
+	}
+	return ""
+}
+
+// sameBlockSpendConfidence scores how strongly a fast spend implies shared ownership
+func sameBlockSpendConfidence(s SameBlockSpend) float64 {
+	conf := 0.7 // Same block: child built before the parent even confirmed
+	if s.BlocksApart > 0 {
+		conf = 0.55
+	}
+	if len(s.SpentOutputs) >= 2 {
+		conf += 0.15 // Co-spending several outputs of one parent links them
+	}
+	if s.ParentIsCoinJoin && len(s.SpentOutputs) < 2 {
+		conf -= 0.2 // Mixed output rushed out: timing leak, but no owner to link
+	}
+	if conf > 0.95 {
+		conf = 0.95
+	}
+	return conf
+}
+
+// sameBlockSpendEdges builds the timing-correlation edges for a fast spend
+func sameBlockSpendEdges(s SameBlockSpend, height int) []models.EvidenceEdge {
+	var edges []models.EvidenceEdge
+	llr := ProbToLLR(s.Confidence)
+
+	if !s.ParentIsCoinJoin {
+		// Fast-spent output is the parent owner's change, provided something
+		// besides timing says so
+		if s.LinkSignal == "" {
+			return nil
+		}
+		for _, addr := range s.SpentAddresses {
+			if s.parentOwner == "" || addr == "" || addr == s.parentOwner {
+				continue
+			}
+			edges = append(edges, createEdge(s.parentOwner, addr, EdgeTypeTimingCorrelation, llr, DepGroupTemporalSignals, height))
+		}
+		return edges
+	}
+
+	// CoinJoin parent: link the co-spent mixed outputs to each other
+	if len(s.SpentAddresses) >= 2 {
+		first := s.SpentAddresses[0]
+		for _, addr := range s.SpentAddresses[1:] {
+			if first == "" || addr == "" || addr == first {
+				continue
+			}
+			edges = append(edges, createEdge(first, addr, EdgeTypeTimingCorrelation, llr, DepGroupTemporalSignals, height))
+		}
+	}
+	return edges
+}
+
+func outpointID(txid string, vout uint32) string {
+	return fmt.Sprintf("%s:%d", txid, vout)
+}
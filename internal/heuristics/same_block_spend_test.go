@@ -0,0 +1,121 @@
+package heuristics
+
+import (
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// paymentWithChange pays a round 0.04 BTC to a legacy merchant address and
+// returns non-round change to the payer's native SegWit wallet (vout 1)
+func paymentWithChange() models.Transaction {
+	return simpleSpend("parent", "bc1qalice", 5_000_000, 500,
+		models.TxOut{Address: "1MerchantPayee", Value: 4_000_000},
+		models.TxOut{Address: "bc1qalicechange", Value: 987_654},
+	)
+}
+
+func TestSameBlockSpend_ChangeSpentInSameBlock(t *testing.T) {
+	parent := paymentWithChange()
+	child := models.Transaction{
+		Txid:    "child",
+		Inputs:  []models.TxIn{{Txid: "parent", Vout: 1, Address: "bc1qalicechange", Value: 987_654}},
+		Outputs: []models.TxOut{{Address: "bc1qbob", Value: 980_000}},
+	}
+
+	tracker := NewSameBlockSpendTracker()
+	spends, edges := tracker.ProcessBlock(500, []models.Transaction{parent, child}, nil)
+
+	if len(spends) != 1 {
+		t.Fatalf("Expected 1 same-block spend. Got %d", len(spends))
+	}
+	if spends[0].BlocksApart != 0 || spends[0].ParentTxid != "parent" || spends[0].ChildTxid != "child" {
+		t.Errorf("Unexpected spend: %+v", spends[0])
+	}
+	if spends[0].LinkSignal != linkSignalChange {
+		t.Errorf("Expected change link signal. Got %q", spends[0].LinkSignal)
+	}
+	if len(edges) != 1 {
+		t.Fatalf("Expected 1 timing edge. Got %d", len(edges))
+	}
+	e := edges[0]
+	if e.EdgeType != EdgeTypeTimingCorrelation || e.SrcNodeID != "bc1qalice" || e.DstNodeID != "bc1qalicechange" {
+		t.Errorf("Expected bc1qalice -> bc1qalicechange timing edge. Got %+v", e)
+	}
+	if e.LLRScore <= 0 {
+		t.Errorf("Expected positive LLR. Got %.3f", e.LLRScore)
+	}
+}
+
+func TestSameBlockSpend_PayeeSweepIsNotLinked(t *testing.T) {
+	// The merchant's processor sweeps the payment at once: fast, but
+	// nothing besides timing ties it to the payer
+	parent := paymentWithChange()
+	sweep := models.Transaction{
+		Txid:    "sweep",
+		Inputs:  []models.TxIn{{Txid: "parent", Vout: 0, Address: "1MerchantPayee", Value: 4_000_000}},
+		Outputs: []models.TxOut{{Address: "3ProcessorHotWallet", Value: 3_990_000}},
+	}
+
+	tracker := NewSameBlockSpendTracker()
+	spends, edges := tracker.ProcessBlock(500, []models.Transaction{parent, sweep}, nil)
+
+	if len(spends) != 1 || spends[0].LinkSignal != "" {
+		t.Fatalf("Expected one timing-only spend. Got %+v", spends)
+	}
+	if len(edges) != 0 {
+		t.Errorf("Expected no timing edge for an unrelated payee's sweep. Got %+v", edges)
+	}
+}
+
+func TestSameBlockSpend_NextBlockAndGap(t *testing.T) {
+	parent := simpleSpend("parent", "alice", 1_000_000, 500,
+		models.TxOut{Address: "alice_change", Value: 990_000},
+	)
+	child := models.Transaction{
+		Txid:   "child",
+		Inputs: []models.TxIn{{Txid: "parent", Vout: 0, Address: "alice_change", Value: 990_000}},
+	}
+
+	tracker := NewSameBlockSpendTracker()
+	tracker.ProcessBlock(500, []models.Transaction{parent}, nil)
+	spends, _ := tracker.ProcessBlock(501, []models.Transaction{child}, nil)
+	if len(spends) != 1 || spends[0].BlocksApart != 1 {
+		t.Fatalf("Expected a next-block spend. Got %+v", spends)
+	}
+	if spends[0].Confidence >= sameBlockSpendConfidence(SameBlockSpend{SpentOutputs: []uint32{0}}) {
+		t.Errorf("Expected next-block spend to score below a same-block spend. Got %.2f", spends[0].Confidence)
+	}
+
+	// A skipped height breaks the window
+	tracker.Reset()
+	tracker.ProcessBlock(500, []models.Transaction{parent}, nil)
+	if spends, _ := tracker.ProcessBlock(502, []models.Transaction{child}, nil); len(spends) != 0 {
+		t.Errorf("Expected no detection across a height gap. Got %+v", spends)
+	}
+}
+
+func TestSameBlockSpend_CoinJoinCoSpendLinksOutputs(t *testing.T) {
+	mix := models.Transaction{Txid: "cj1"}
+	for i := 0; i < 5; i++ {
+		mix.Inputs = append(mix.Inputs, models.TxIn{Address: "in", Value: 1_000_500})
+		mix.Outputs = append(mix.Outputs, models.TxOut{Address: "mixed" + string(rune('A'+i)), Value: 1_000_000})
+	}
+	child := models.Transaction{
+		Txid: "consolidate",
+		Inputs: []models.TxIn{
+			{Txid: "cj1", Vout: 1, Address: "mixedB", Value: 1_000_000},
+			{Txid: "cj1", Vout: 3, Address: "mixedD", Value: 1_000_000},
+		},
+	}
+
+	tracker := NewSameBlockSpendTracker()
+	spends, edges := tracker.ProcessBlock(700, []models.Transaction{mix, child}, map[string]bool{"cj1": true})
+
+	if len(spends) != 1 || !spends[0].ParentIsCoinJoin || len(spends[0].SpentOutputs) != 2 {
+		t.Fatalf("Expected one CoinJoin co-spend of 2 outputs. Got %+v", spends)
+	}
+	if len(edges) != 1 || edges[0].SrcNodeID != "mixedB" || edges[0].DstNodeID != "mixedD" {
+		t.Errorf("Expected mixedB -> mixedD timing edge. Got %+v", edges)
+	}
+}
@@ -0,0 +1,65 @@
+package heuristics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func TestParseAddressList_NewlineAndCSV(t *testing.T) {
+	list := strings.Join([]string{
+		"# OFAC SDN digital currency addresses",
+		"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa",
+		"",
+		"BC1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KV8F3T4",
+		"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNb", // Bad checksum
+		"ent_num,SDN_Name,SDN_Type,Remarks",
+		`12345,"LAZARUS GROUP",-0-,"Digital Currency Address - XBT 3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy; Digital Currency Address - ETH 0x098B716B8Aaf21512996dC57EB0615e2383E2f96; alt. Digital Currency Address - XBT 1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa."`,
+	}, "\n")
+
+	parsed, err := ParseAddressList(strings.NewReader(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa",
+		"bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4",
+		"3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy",
+	}
+	if strings.Join(parsed.Addresses, ",") != strings.Join(want, ",") {
+		t.Errorf("Expected unique normalized addresses %v. Got %v", want, parsed.Addresses)
+	}
+	if parsed.Skipped != 2 {
+		t.Errorf("Expected the bad-checksum row and CSV header skipped. Got %d", parsed.Skipped)
+	}
+}
+
+func TestImportSanctionedAddresses_WatchlistHitAndTaint(t *testing.T) {
+	resetTaintMapForTest(nil)
+	t.Cleanup(func() { resetTaintMapForTest(nil) })
+	w := NewAddressWatchlist()
+	w.Add("1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "theft", "Case theft", "CASE-1", AlertLevelForRole("theft"))
+	w.Add("3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", "exchange", "Hot wallet", "", AlertLevelForRole("exchange"))
+
+	addrs := []string{"1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"}
+	res := ImportSanctionedAddresses(w, addrs, "OFAC SDN")
+	if res.Parsed != 3 || res.Added != 2 || res.Duplicates != 1 || res.TaintSeeded != 3 {
+		t.Errorf("Expected the theft entry kept and the exchange entry upgraded. Got %+v", res)
+	}
+	if again := ImportSanctionedAddresses(w, addrs, "OFAC SDN"); again.Added != 0 || again.Duplicates != 3 || again.TaintSeeded != 0 {
+		t.Errorf("Expected a re-import to be deduplicated. Got %+v", again)
+	}
+
+	hits := w.CheckTransaction(models.Transaction{
+		Txid:    "pays-sanctioned",
+		Inputs:  []models.TxIn{{Address: "bc1qclean", Value: 50_000}},
+		Outputs: []models.TxOut{{Address: "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", Value: 49_000}},
+	})
+	if len(hits) != 1 || hits[0].Category != "sanctioned" || hits[0].AlertLevel != "critical" || hits[0].Label != "OFAC SDN" {
+		t.Errorf("Expected a critical sanctioned hit. Got %+v", hits)
+	}
+	if risk := GlobalTaintRisk("3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy"); risk.RiskScore != 1.0 || risk.TaintSources[0].Category != "sanctioned" {
+		t.Errorf("Expected the sanctioned address fully tainted. Got %+v", risk)
+	}
+}
@@ -1,6 +1,9 @@
 package heuristics
 
 import (
+	"encoding/binary"
+	"encoding/hex"
+	"regexp"
 	"strings"
 
 	"github.com/rawblock/coinjoin-engine/pkg/models"
@@ -13,7 +16,9 @@ import (
 //
 //   - Multisig patterns: 2-of-3 (standard), 3-of-5 (corporate custody)
 //   - HTLC timelocks: Lightning Network channel opens/closes
-//   - OP_RETURN payloads: Omni Layer, OpenAssets, timestamp proofs
+//   - OP_RETURN payloads: Omni Layer, OpenAssets, timestamp proofs,
+//     cross-chain bridge memos (THORChain, Stacks, EVM mint destinations),
+//     BIP47 payment-code notifications
 //   - Tapscript complexity: key-path vs script-path spending
 //   - Witness version: v0 (SegWit), v1 (Taproot), legacy
 //
@@ -25,6 +30,8 @@ import (
 //   - Harding (2019), "Bitcoin Script History" (Bitcoin Optech)
 //   - Pérez-Solà et al., "The Bitcoin P2P Network" (FC 2019)
 //   - Towns (2021), "BIP341/342: Taproot/Tapscript Spending Rules"
+//   - Pacia (2015), "BIP47: Reusable Payment Codes for Hierarchical
+//     Deterministic Wallets"
 
 // AnalyzeScriptTemplates performs deep inspection of all transaction scripts
 func AnalyzeScriptTemplates(tx models.Transaction) models.ScriptAnalysis {
@@ -69,6 +76,10 @@ func AnalyzeScriptTemplates(tx models.Transaction) models.ScriptAnalysis {
 		}
 	}
 
+	if addr, ok := DetectBIP47Notification(tx); ok {
+		result.BIP47Notification = addr
+	}
+
 	// 3. Determine dominant witness version from addresses
 	result.DominantWitness = detectDominantWitnessVersion(tx)
 
@@ -182,8 +193,14 @@ func classifyOPReturn(scriptPubKey string) string {
 		return "unknown"
 	}
 
-	// Extract data after OP_RETURN (6a) and length byte
-	data := lower[4:] // Skip 6a + length byte
+	data := opReturnPayload(lower)
+
+	if isBIP47PaymentCodePayload(data) {
+		return "bip47"
+	}
+	if protocol, ok := classifyBridgeMemo(data); ok {
+		return protocol
+	}
 
 	switch {
 	case strings.HasPrefix(data, "6f6d6e69"):
@@ -203,14 +220,205 @@ func classifyOPReturn(scriptPubKey string) string {
 	}
 }
 
-// estimateOPReturnSize estimates the size of OP_RETURN data in bytes
-func estimateOPReturnSize(scriptPubKey string) int {
-	// Each hex pair = 1 byte, subtract OP_RETURN opcode (1 byte)
-	dataLen := len(scriptPubKey) - 2
-	if dataLen < 0 {
-		return 0
+// opReturnPayload extracts the hex data pushed after OP_RETURN (6a):
+// the concatenated data of every push, whether direct (0x01–0x4b) or
+// OP_PUSHDATA1/2/4 (0x4c/0x4d/0x4e, little-endian length). Parsing stops
+// at a non-push opcode or a truncated push.
+func opReturnPayload(lowerScript string) string {
+	raw, err := hex.DecodeString(lowerScript)
+	if err != nil || len(raw) == 0 || raw[0] != 0x6a {
+		return ""
+	}
+
+	var data []byte
+	for pc := 1; pc < len(raw); {
+		op := raw[pc]
+		pc++
+		var n int
+		switch {
+		case op <= 0x4b:
+			n = int(op) // OP_0 pushes nothing
+		case op == 0x4c && pc+1 <= len(raw):
+			n = int(raw[pc])
+			pc++
+		case op == 0x4d && pc+2 <= len(raw):
+			n = int(binary.LittleEndian.Uint16(raw[pc:]))
+			pc += 2
+		case op == 0x4e && pc+4 <= len(raw):
+			n = int(binary.LittleEndian.Uint32(raw[pc:]))
+			pc += 4
+		default:
+			return hex.EncodeToString(data) // OP_1..OP_16 etc. carry no data bytes
+		}
+		if n < 0 || pc+n > len(raw) {
+			return hex.EncodeToString(data)
+		}
+		data = append(data, raw[pc:pc+n]...)
+		pc += n
+	}
+	return hex.EncodeToString(data)
+}
+
+// ─── Cross-Chain Bridge Markers ──────────────────────────────────────
+// Bridge deposits carry the destination-chain instruction in OP_RETURN.
+// When one is present the BTC has left the chain: fund tracing treats the
+// transaction as a terminus ("bridge-exit") rather than following outputs.
+
+// thorchainSwapOps are the THORChain memo functions of a swap out of BTC
+// ("SWAP:ASSET:DEST[:LIMIT...]", with "=" and "s" as shorthands)
+var thorchainSwapOps = map[string]bool{"swap": true, "s": true, "=": true}
+
+// thorchainChains are the chain tickers a THORChain asset can name
+var thorchainChains = map[string]bool{
+	"BTC": true, "ETH": true, "BSC": true, "BCH": true, "LTC": true, "DOGE": true,
+	"GAIA": true, "AVAX": true, "BASE": true, "THOR": true, "XRP": true, "TRON": true,
+}
+
+// thorchainDestPattern matches a destination address of any supported chain
+var thorchainDestPattern = regexp.MustCompile(`^[0-9A-Za-z]{20,100}$`)
+
+// Stacks peg-in: the mainnet magic "X2", opcode "<" (sBTC deposit), then
+// the recipient principal (1 version byte + 20-byte hash160 at least)
+const (
+	stacksMagic        = "X2"
+	stacksOpPegIn      = '<'
+	stacksPegInMinSize = len(stacksMagic) + 1 + 1 + 20
+)
+
+// isThorchainSwapMemo reports whether memo is a THORChain swap:
+// op ":" CHAIN "." ASSET ":" destination, with a known chain ticker.
+// Synth ("/") and trade ("~") asset separators are accepted too.
+func isThorchainSwapMemo(memo []byte) bool {
+	parts := strings.Split(string(memo), ":")
+	if len(parts) < 3 || !thorchainSwapOps[strings.ToLower(parts[0])] {
+		return false
+	}
+	sep := strings.IndexAny(parts[1], "./~")
+	if sep <= 0 || sep == len(parts[1])-1 || !thorchainChains[strings.ToUpper(parts[1][:sep])] {
+		return false
+	}
+	return thorchainDestPattern.MatchString(parts[2])
+}
+
+// isStacksPegIn reports whether data is a Stacks mainnet sBTC peg-in
+func isStacksPegIn(data []byte) bool {
+	return len(data) >= stacksPegInMinSize &&
+		string(data[:len(stacksMagic)]) == stacksMagic && data[len(stacksMagic)] == stacksOpPegIn
+}
+
+// evmDestinationPattern matches an EVM address embedded in an ASCII memo
+// (wBTC merchant mints, renBTC-style gateways, generic bridge memos)
+var evmDestinationPattern = regexp.MustCompile(`0x[0-9a-fA-F]{40}`)
+
+// classifyBridgeMemo identifies a cross-chain bridge marker in OP_RETURN data
+func classifyBridgeMemo(data string) (string, bool) {
+	raw, err := hex.DecodeString(data)
+	if err != nil {
+		return "", false
+	}
+	switch {
+	case isThorchainSwapMemo(raw):
+		return "thorchain", true
+	case isStacksPegIn(raw):
+		return "stacks", true
+	case evmDestinationPattern.Match(raw):
+		return "bridge-memo", true
+	}
+	return "", false
+}
+
+// isBridgeProtocol reports whether an OP_RETURN protocol is a bridge marker
+func isBridgeProtocol(protocol string) bool {
+	switch protocol {
+	case "thorchain", "stacks", "bridge-memo":
+		return true
+	}
+	return false
+}
+
+// DetectBridgeExit reports whether a transaction carries a cross-chain
+// bridge marker, returning the bridge protocol.
+func DetectBridgeExit(tx models.Transaction) (string, bool) {
+	for _, out := range tx.Outputs {
+		if !isOPReturn(out.ScriptPubKey) {
+			continue
+		}
+		if protocol := classifyOPReturn(out.ScriptPubKey); isBridgeProtocol(protocol) {
+			return protocol, true
+		}
+	}
+	return "", false
+}
+
+// ─── BIP47 Notification Transactions ─────────────────────────────────
+// Before paying a payment code for the first time, the sender publishes
+// its own payment code, blinded with an ECDH secret, in an 80-byte
+// OP_RETURN and pays dust to the recipient's notification address. The
+// blinding hides the code from everyone but the recipient, yet the shape
+// is unmistakable: the unmasked version/feature/sign bytes and the 13
+// reserved zero bytes survive, and the dust output names the recipient.
+// Every later payment between the two is linked to this transaction.
+
+// bip47PayloadSize is the length of a v1 payment code (bytes)
+const bip47PayloadSize = 80
+
+// bip47NotificationMaxSats bounds the notification output; wallets pay
+// the dust limit (546 sats) or close to it
+const bip47NotificationMaxSats = 10_000
+
+// isBIP47PaymentCodePayload reports whether OP_RETURN data is a (blinded)
+// v1 payment code: version 0x01, features 0x00, sign 0x02/0x03, a masked
+// x coordinate and chain code, then 13 reserved zero bytes
+func isBIP47PaymentCodePayload(data string) bool {
+	if len(data) != 2*bip47PayloadSize {
+		return false
+	}
+	raw, err := hex.DecodeString(data)
+	if err != nil {
+		return false
 	}
-	return dataLen / 2
+	if raw[0] != 0x01 || raw[1] != 0x00 || (raw[2] != 0x02 && raw[2] != 0x03) {
+		return false
+	}
+	for _, b := range raw[67:] {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// DetectBIP47Notification reports whether a transaction is a BIP47
+// notification, returning the recipient's notification address (the
+// dust output paid alongside the payment-code OP_RETURN)
+func DetectBIP47Notification(tx models.Transaction) (string, bool) {
+	hasCode := false
+	notifyAddr := ""
+	var notifyValue int64
+	for _, out := range tx.Outputs {
+		if isOPReturn(out.ScriptPubKey) {
+			if classifyOPReturn(out.ScriptPubKey) == "bip47" {
+				hasCode = true
+			}
+			continue
+		}
+		if out.Address == "" || out.Value > bip47NotificationMaxSats {
+			continue
+		}
+		if notifyAddr == "" || out.Value < notifyValue {
+			notifyAddr, notifyValue = out.Address, out.Value
+		}
+	}
+	if !hasCode || notifyAddr == "" {
+		return "", false
+	}
+	return notifyAddr, true
+}
+
+// estimateOPReturnSize returns the size of the OP_RETURN data payload in
+// bytes, excluding the opcode and push-length prefixes
+func estimateOPReturnSize(scriptPubKey string) int {
+	return len(opReturnPayload(strings.ToLower(scriptPubKey))) / 2
 }
 
 // detectDominantWitnessVersion determines the most common witness
@@ -0,0 +1,180 @@
+package heuristics
+
+import (
+	"encoding/hex"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// opReturnScript builds an OP_RETURN scriptPubKey pushing the given memo
+func opReturnScript(memo string) string {
+	data := hex.EncodeToString([]byte(memo))
+	if len(memo) > 75 {
+		return "6a4c" + hex.EncodeToString([]byte{byte(len(memo))}) + data
+	}
+	return "6a" + hex.EncodeToString([]byte{byte(len(memo))}) + data
+}
+
+func TestClassifyOPReturn_BridgeMarkers(t *testing.T) {
+	cases := []struct {
+		memo string
+		want string
+	}{
+		{"=:ETH.ETH:0x742d35Cc6634C0532925a3b844Bc454e4438f44e", "thorchain"},
+		{"SWAP:BSC.BNB:bnb1grpf0955h0ykzq3ar5nmum7y6gdfl6lxfn46h2", "thorchain"},
+		{"wbtc mint to 0x742d35Cc6634C0532925a3b844Bc454e4438f44e", "bridge-memo"},
+		{"ETH destination 0x742d35cc6634c0532925a3b844bc454e4438f44e via gateway renBTC mint", "bridge-memo"},
+		{"s:BTC/BTC:thor1g98cy3n9mmjrpn0sxmn63lztelera37n8n67c0", "thorchain"},
+		{"X2<" + string(make([]byte, 21)), "stacks"}, // sBTC peg-in: version + hash160
+		{"omni", "omni"}, // Non-bridge protocols unchanged
+	}
+
+	for _, tc := range cases {
+		if got := classifyOPReturn(opReturnScript(tc.memo)); got != tc.want {
+			t.Errorf("memo %q: expected %q. Got %q", tc.memo, tc.want, got)
+		}
+	}
+}
+
+func TestClassifyBridgeMemo_RejectsLookalikes(t *testing.T) {
+	memos := []string{
+		"=:",
+		"=:hello",
+		"s:some note",
+		"s:ETH.ETH:", // No destination
+		"=:FOO.BAR:thor1g98cy3n9mmjrpn0sxmn63lztelera37n8n67c0", // Unknown chain
+		"=:THOR:thor1g98cy3n9mmjrpn0sxmn63lztelera37n8n67c0",    // Asset without chain
+		"X2",
+		"X2x" + string(make([]byte, 21)), // Stacks stack-stx, not a peg-in
+		"X2<short",
+	}
+	for _, memo := range memos {
+		if protocol, ok := classifyBridgeMemo(hex.EncodeToString([]byte(memo))); ok {
+			t.Errorf("memo %q: expected no bridge marker. Got %q", memo, protocol)
+		}
+	}
+
+	// Random payloads that merely start with the old 2-byte prefixes
+	rng := rand.New(rand.NewSource(1))
+	for _, prefix := range []string{"=:", "s:", "X2"} {
+		for i := 0; i < 200; i++ {
+			payload := make([]byte, 2+rng.Intn(78))
+			rng.Read(payload)
+			copy(payload, prefix)
+			if protocol, ok := classifyBridgeMemo(hex.EncodeToString(payload)); ok {
+				t.Fatalf("random payload %x: expected no bridge marker. Got %q", payload, protocol)
+			}
+		}
+	}
+}
+
+func TestDetectBridgeExit(t *testing.T) {
+	tx := models.Transaction{
+		Txid: "bridge_tx",
+		Outputs: []models.TxOut{
+			{Address: "bc1qvault", Value: 500_000},
+			{ScriptPubKey: opReturnScript("=:ETH.ETH:0x742d35Cc6634C0532925a3b844Bc454e4438f44e")},
+		},
+	}
+
+	protocol, ok := DetectBridgeExit(tx)
+	if !ok || protocol != "thorchain" {
+		t.Errorf("Expected thorchain bridge exit. Got %q, %v", protocol, ok)
+	}
+
+	tx.Outputs[1].ScriptPubKey = opReturnScript("hello world")
+	if _, ok := DetectBridgeExit(tx); ok {
+		t.Error("Expected plain OP_RETURN not to be a bridge exit")
+	}
+}
+
+// bip47NotificationScript builds the OP_RETURN of a BIP47 notification:
+// a v1 payment code with masked x/chain code and reserved zero bytes
+func bip47NotificationScript() string {
+	code := make([]byte, 80)
+	code[0], code[1], code[2] = 0x01, 0x00, 0x03
+	for i := 3; i < 67; i++ {
+		code[i] = byte(i*37 + 11) // Stand-in for the blinded key material
+	}
+	return "6a4c50" + hex.EncodeToString(code)
+}
+
+func TestDetectBIP47Notification(t *testing.T) {
+	tx := models.Transaction{
+		Txid:   "bip47_notify",
+		Inputs: []models.TxIn{{Address: "bc1qsender", Value: 100_000}},
+		Outputs: []models.TxOut{
+			{Address: "1NotificationAddrOfRecipient", Value: 546},
+			{ScriptPubKey: bip47NotificationScript()},
+			{Address: "bc1qsenderchange", Value: 98_000},
+		},
+	}
+
+	addr, ok := DetectBIP47Notification(tx)
+	if !ok || addr != "1NotificationAddrOfRecipient" {
+		t.Fatalf("Expected a notification to the dust output. Got %q, %v", addr, ok)
+	}
+	res := AnalyzeTx(tx)
+	if res.HeuristicFlags&FlagIsBIP47Notification == 0 || res.HeuristicFlags&FlagHasOPReturn == 0 {
+		t.Errorf("Expected both the BIP47 and OP_RETURN flags. Got %b", res.HeuristicFlags)
+	}
+	if res.ScriptInfo == nil || res.ScriptInfo.OPReturnProtocol != "bip47" || res.ScriptInfo.BIP47Notification != addr {
+		t.Errorf("Expected script info to surface the notification. Got %+v", res.ScriptInfo)
+	}
+}
+
+func TestDetectBIP47Notification_GenericOPReturn(t *testing.T) {
+	generic := make([]byte, 80)
+	generic[0] = 0x01
+	generic[79] = 0xff // Reserved bytes must be zero
+	tx := models.Transaction{
+		Txid: "plain_op_return",
+		Outputs: []models.TxOut{
+			{Address: "1Recipient", Value: 546},
+			{ScriptPubKey: "6a4c50" + hex.EncodeToString(generic)},
+		},
+	}
+	if _, ok := DetectBIP47Notification(tx); ok {
+		t.Error("Expected an 80-byte payload with non-zero reserved bytes not to be BIP47")
+	}
+	if res := AnalyzeTx(tx); res.HeuristicFlags&FlagIsBIP47Notification != 0 || res.HeuristicFlags&FlagHasOPReturn == 0 {
+		t.Errorf("Expected only the generic OP_RETURN flag. Got %b", res.HeuristicFlags)
+	}
+
+	tx.Outputs[1].ScriptPubKey = bip47NotificationScript()
+	tx.Outputs[0].Value = 50_000 // No dust notification output
+	if _, ok := DetectBIP47Notification(tx); ok {
+		t.Error("Expected a payment code without a notification output not to match")
+	}
+}
+
+func TestOPReturnPayload_PushEncodings(t *testing.T) {
+	payload80 := strings.Repeat("ab", 80)
+	cases := []struct {
+		name     string
+		script   string
+		protocol string
+		size     int
+	}{
+		// Omni Simple Send: "omni", version 0, type 0, property 31 (USDT), amount
+		{"omni", "6a146f6d6e69000000000000001f000000003b9aca00", "omni", 20},
+		{"pushdata1", "6a4c50" + payload80, "unknown", 80},
+		{"pushdata2", "6a4d5000" + payload80, "unknown", 80},
+		{"pushdata1 omni", "6a4c146f6d6e69000000000000001f000000003b9aca00", "omni", 20},
+		{"bare short", "6a024f41", "openassets", 2},
+		{"two pushes", "6a046f6d6e69" + "02abcd", "omni", 6},
+		{"truncated", "6a4c50abab", "unknown", 0},
+		{"empty", "6a", "unknown", 0},
+	}
+	for _, tc := range cases {
+		if got := classifyOPReturn(tc.script); got != tc.protocol {
+			t.Errorf("%s: expected protocol %q. Got %q", tc.name, tc.protocol, got)
+		}
+		if got := estimateOPReturnSize(tc.script); got != tc.size {
+			t.Errorf("%s: expected a %d-byte payload. Got %d", tc.name, tc.size, got)
+		}
+	}
+}
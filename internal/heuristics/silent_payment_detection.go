@@ -0,0 +1,101 @@
+package heuristics
+
+import (
+	"strings"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// BIP352 Silent Payments Suspicion
+//
+// A silent payment output is a Taproot key tweaked with an ECDH secret
+// between the sender's input keys and the recipient's scan key. Every
+// payment lands on a fresh, unlinkable Taproot address, so watching a
+// recipient's "address" finds nothing and output-address clustering
+// breaks. Without the scan key the outputs are indistinguishable from any
+// other Taproot output; this detector only flags the narrow shape a
+// silent payment sender is likely to produce:
+//
+//   - Exactly one input, of a type BIP352 derives the shared secret from
+//     (P2PKH, P2WPKH, P2SH-P2WPKH, or a Taproot spend without the NUMS
+//     internal key), so the sender's public key is on-chain
+//   - Two or more outputs, every one Taproot (the payment and a change
+//     output derived with the change label)
+//   - No address reuse: outputs are distinct and none pays the input back
+//   - The input is not Taproot: an ordinary wallet returns change to its
+//     own script type, so a non-Taproot input funding only fresh Taproot
+//     outputs is the distinguishing signal
+//
+// Limitations: a Taproot-native sender paying with Taproot change is
+// indistinguishable from an ordinary Taproot payment and is deliberately
+// not flagged, nor are multi-input silent payments. Inputs without
+// witness data are only accepted for P2PKH and P2WPKH, whose type is
+// known from the address. The flag is a policy gate (Layer 3), not
+// evidence of a silent payment.
+//
+// References:
+//   - BIP352 (Silent Payments)
+//   - BIP341 (Taproot; NUMS point H for script-only outputs)
+
+// taprootNUMSKey is the BIP341 NUMS point H; Taproot spends revealing it
+// as the internal key have no usable key and are ineligible under BIP352
+const taprootNUMSKey = "50929b74c1a04954b78b4b6035e97a5e078a5a0f28ec96d547bfee9ace803ac0"
+
+// SilentPaymentResult holds BIP352 suspicion results
+type SilentPaymentResult struct {
+	IsSuspect bool  `json:"isSuspect"`
+	Outputs   []int `json:"outputs,omitempty"` // Candidate silent payment output indexes
+}
+
+// DetectSilentPaymentSuspect reports whether tx has the conservative
+// silent payment sender shape described above
+func DetectSilentPaymentSuspect(tx models.Transaction) SilentPaymentResult {
+	if len(tx.Inputs) != 1 || len(tx.Outputs) < 2 {
+		return SilentPaymentResult{}
+	}
+	in := tx.Inputs[0]
+	if detectAddressType(in.Address) == "taproot" || !isSilentPaymentEligibleInput(in) {
+		return SilentPaymentResult{}
+	}
+
+	seen := make(map[string]bool, len(tx.Outputs))
+	outputs := make([]int, 0, len(tx.Outputs))
+	for i, out := range tx.Outputs {
+		if detectAddressType(out.Address) != "taproot" || out.Address == in.Address || seen[out.Address] {
+			return SilentPaymentResult{}
+		}
+		seen[out.Address] = true
+		outputs = append(outputs, i)
+	}
+	return SilentPaymentResult{IsSuspect: true, Outputs: outputs}
+}
+
+// isSilentPaymentEligibleInput reports whether BIP352 would use in's public
+// key for the shared secret
+func isSilentPaymentEligibleInput(in models.TxIn) bool {
+	switch classifyAddressType(in.Address) {
+	case "p2pkh":
+		return true
+	case "p2wpkh":
+		return len(in.Address) == 42 // bc1q + 20-byte program; 62 chars is P2WSH
+	case "p2sh":
+		// Only P2SH-P2WPKH: signature + 33-byte compressed key
+		return len(in.Witness) == 2 && len(in.Witness[1]) == 66
+	case "p2tr":
+		witness := in.Witness
+		if n := len(witness); n >= 2 && strings.HasPrefix(witness[n-1], "50") {
+			witness = witness[:n-1] // Annex
+		}
+		switch {
+		case len(witness) == 0:
+			return false // Spend path unknown
+		case len(witness) == 1:
+			return true // Key path
+		default:
+			// Script path: control block = leaf version byte + internal key
+			control := strings.ToLower(witness[len(witness)-1])
+			return len(control) >= 66 && control[2:66] != taprootNUMSKey
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package heuristics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// sampleSilentPayment: a P2WPKH sender pays a silent payment address and
+// takes change on a fresh Taproot output
+func sampleSilentPayment() models.Transaction {
+	return models.Transaction{
+		Txid: "silentpayment",
+		Inputs: []models.TxIn{
+			{Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", Value: 400_000, Witness: []string{"3044", strings.Repeat("02", 33)}},
+		},
+		Outputs: []models.TxOut{
+			{Address: "bc1precipientbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", Value: 250_000},
+			{Address: "bc1pchangecccccccccccccccccccccccccccccccccccccccccccccccccccc", Value: 149_000},
+		},
+	}
+}
+
+func TestDetectSilentPaymentSuspect_SenderShape(t *testing.T) {
+	result := DetectSilentPaymentSuspect(sampleSilentPayment())
+	if !result.IsSuspect || len(result.Outputs) != 2 {
+		t.Errorf("Expected both Taproot outputs as candidates. Got %+v", result)
+	}
+
+	res := AnalyzeTx(sampleSilentPayment())
+	if res.HeuristicFlags&FlagIsSilentPayment == 0 {
+		t.Errorf("Expected FlagIsSilentPayment. Flags: %b", res.HeuristicFlags)
+	}
+}
+
+func TestDetectSilentPaymentSuspect_OrdinaryTaprootPayments(t *testing.T) {
+	cases := map[string]func(tx *models.Transaction){
+		"taproot sender": func(tx *models.Transaction) {
+			tx.Inputs[0].Address = "bc1psenderdddddddddddddddddddddddddddddddddddddddddddddddddddd"
+			tx.Inputs[0].Witness = []string{schnorrSig}
+		},
+		"same-type change": func(tx *models.Transaction) {
+			tx.Outputs[1].Address = "bc1qsenderchangeeeeeeeeeeeeeeeeeeeeeeeeee"
+		},
+		"multiple inputs": func(tx *models.Transaction) {
+			tx.Inputs = append(tx.Inputs, tx.Inputs[0])
+			tx.Inputs[1].Address = "bc1qsenderinputffffffffffffffffffffffffff"
+		},
+		"reused output": func(tx *models.Transaction) {
+			tx.Outputs[1].Address = tx.Outputs[0].Address
+		},
+		"p2wsh input": func(tx *models.Transaction) {
+			tx.Inputs[0].Address = "bc1qmultisigggggggggggggggggggggggggggggggggggggggggggggggggg"
+		},
+		"single output": func(tx *models.Transaction) {
+			tx.Outputs = tx.Outputs[:1]
+		},
+	}
+	for name, mutate := range cases {
+		tx := sampleSilentPayment()
+		mutate(&tx)
+		if result := DetectSilentPaymentSuspect(tx); result.IsSuspect {
+			t.Errorf("%s: expected no suspicion. Got %+v", name, result)
+		}
+	}
+}
+
+func TestIsSilentPaymentEligibleInput_TaprootSpends(t *testing.T) {
+	in := models.TxIn{Address: "bc1pinputaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"}
+	if isSilentPaymentEligibleInput(in) {
+		t.Error("Expected a Taproot input without witness data to be ineligible")
+	}
+
+	in.Witness = []string{schnorrSig}
+	if !isSilentPaymentEligibleInput(in) {
+		t.Error("Expected a key-path spend to be eligible")
+	}
+
+	in.Witness = []string{schnorrSig, "20" + strings.Repeat("11", 32) + "ac", "c0" + taprootNUMSKey}
+	if isSilentPaymentEligibleInput(in) {
+		t.Error("Expected a script-path spend with the NUMS internal key to be ineligible")
+	}
+}
@@ -2,16 +2,33 @@ package heuristics
 
 import (
 	"log"
+	"slices"
+	"sort"
 
 	"github.com/rawblock/coinjoin-engine/internal/cuda"
 	"github.com/rawblock/coinjoin-engine/pkg/models"
 )
 
 // CalculateAnonSet calculates the anonymity set using a fee-tolerant Subset Sum Matcher (SSMP)
-// Utilizing the "Anytime" K-Best Schroeppel-Shamir Meet-in-the-Middle solver strategy
+// Utilizing the "Anytime" K-Best Schroeppel-Shamir Meet-in-the-Middle solver strategy.
+// Tolerances come from the process-wide SSMPConfig.
 func CalculateAnonSet(inputs []models.TxIn, outputs []models.TxOut, txFee int64, txVsize int) int {
+	return CalculateAnonSetWithConfig(inputs, outputs, txFee, txVsize, CurrentSSMPConfig())
+}
+
+// CalculateAnonSetWithConfig is CalculateAnonSet with explicit solver tolerances
+func CalculateAnonSetWithConfig(inputs []models.TxIn, outputs []models.TxOut, txFee int64, txVsize int, cfg SSMPConfig) int {
+	anonSet, _ := CalculateAnonSetDetailed(inputs, outputs, txFee, txVsize, cfg)
+	return anonSet
+}
+
+// CalculateAnonSetDetailed runs the CPU lanes of cfg.Strategy's portfolio and
+// also reports which solver produced the anonymity set (Solver* constants)
+func CalculateAnonSetDetailed(inputs []models.TxIn, outputs []models.TxOut, txFee int64, txVsize int, cfg SSMPConfig) (int, string) {
+	cfg = cfg.withDefaults()
+	portfolio := solverPortfolioFor(cfg.Strategy)
 	if len(inputs) == 0 || len(outputs) == 0 {
-		return 0
+		return 0, SolverNone
 	}
 
 	// Dynamic fee tolerance (tau) derived from network conditions and vsize (BIP141 Grounded)
@@ -21,11 +38,11 @@ func CalculateAnonSet(inputs []models.TxIn, outputs []models.TxOut, txFee int64,
 	}
 
 	// Capping the solver to prevent catastrophic hangs on massive WabiSabi / Surge transactions
-	// If inputs or outputs exceed 15 (2^15 combinations), we fallback to a structural counting method
-	// because the NP-hard nature of the problem will hang the processor.
-	if len(inputs) > 15 || len(outputs) > 15 {
+	// If inputs or outputs exceed MitMInputCap (default 15, 2^15 combinations), we fallback to a
+	// structural counting method because the NP-hard nature of the problem will hang the processor.
+	if len(inputs) > cfg.MitMInputCap || len(outputs) > cfg.MitMInputCap {
 		log.Printf("[Heuristics] Transaction %d inputs, %d outputs exceeds anytime compute budget. Bailing out early.", len(inputs), len(outputs))
-		return countEqualOutputs(outputs)
+		return countEqualOutputs(outputs), SolverStructural
 	}
 
 	// 1. Array formatting
@@ -60,7 +77,7 @@ func CalculateAnonSet(inputs []models.TxIn, outputs []models.TxOut, txFee int64,
 
 	// If there's no equal denomination outputs, AnonSet is structurally 0 (unless WabiSabi, handled elsewhere)
 	if maxEqualOutputs <= 1 {
-		return 0
+		return 0, SolverNone
 	}
 
 	// ----------------------------------------------------
@@ -90,12 +107,13 @@ func CalculateAnonSet(inputs []models.TxIn, outputs []models.TxOut, txFee int64,
 	// this input's value (within fee tolerance) such that one of the outputs is the MixDenomination?
 	var validLinkages int
 
+	// Target to match is the input value minus implicit fee. MitM checks [inVal-tau, inVal]
+	tau := cfg.tau(feeRate) // ~150 vbytes of fee per participant by default
+	if tau < cfg.MinTau {
+		tau = cfg.MinTau // Minimum safety bounds for fee discrepancy
+	}
+
 	for _, inVal := range inputVals {
-		// Target to match is the input value minus implicit fee. MitM checks [inVal-tau, inVal]
-		tau := int64(feeRate * 150.0) // ~150 sats for the test
-		if tau < 1000 {
-			tau = 1000 // Minimum safety bounds for fee discrepancy
-		}
 
 		// If the input doesn't even cover the strict denomination, it's not part of the AnonSet.
 		if inVal < mixDenomination {
@@ -142,27 +160,31 @@ func CalculateAnonSet(inputs []models.TxIn, outputs []models.TxOut, txFee int64,
 	// 3. Anytime Solver Portfolio (DP/Bitset & CP-SAT Bailout)
 	// ----------------------------------------------------
 	// If the Meet-in-the-Middle bounds fail to find a perfect 1-to-1 mapping
-	// we evaluate the problem constraints and deploy the strictly bounded solvers.
+	// we run DP/Bitset when the sum fits its table, then CP-SAT, keeping the
+	// larger count. The solver label only moves off MitM when a lane improves on it.
+	solver := SolverMitM
 	if maxAnonSet == 1 && maxEqualOutputs > 1 {
-		// 3a. DP/Bitset pseudo-polynomial lane for bounded small values
 		var sumOutputs int64 = 0
 		for _, o := range outputVals {
 			sumOutputs += o
 		}
-		if sumOutputs <= 500_000 { // Max limit for pseudo-polynomial DP array size
+		dpMaxSum := cfg.DPMaxSum * portfolio.dpSumScale
+
+		// 3a. DP/Bitset pseudo-polynomial lane for bounded small values
+		//     (greedy matching: a lower bound that CP-SAT may improve on)
+		if sumOutputs <= dpMaxSum { // Max limit for pseudo-polynomial DP array size
 			log.Printf("[Heuristics] MitM failed. Running DP/Bitset pseudo-polynomial constraint solver.")
-			dpResult := SolveDPBitset(inputVals, outputVals, int64(feeRate*150.0))
+			dpResult := SolveDPBitset(inputVals, outputVals, tau, dpMaxSum)
 			if dpResult > maxAnonSet {
-				maxAnonSet = dpResult
-			}
-		} else {
-			// 3b. CP-SAT / ILP lane for highly-constrained large-value instances
-			log.Printf("[Heuristics] MitM failed for clustered TXID. Running CP-SAT Fallback.")
-			cpResult := SolveCPSAT(inputVals, outputVals, int64(feeRate*150.0))
-			if cpResult > maxAnonSet {
-				maxAnonSet = cpResult
+				maxAnonSet, solver = dpResult, SolverDP
 			}
 		}
+		// 3b. CP-SAT / ILP lane: exact within its guardrails, so it runs after
+		//     DP too and lifts the greedy lower bound to the maximum
+		log.Printf("[Heuristics] MitM failed for clustered TXID. Running CP-SAT Fallback.")
+		if cpResult := SolveCPSAT(inputVals, outputVals, tau); cpResult > maxAnonSet {
+			maxAnonSet, solver = cpResult, SolverCPSAT
+		}
 
 		if maxAnonSet > maxEqualOutputs {
 			maxAnonSet = maxEqualOutputs
@@ -172,7 +194,24 @@ func CalculateAnonSet(inputs []models.TxIn, outputs []models.TxOut, txFee int64,
 		}
 	}
 
-	return maxAnonSet
+	return maxAnonSet, solver
+}
+
+// solveAnonSet is STEP 1 of the pipeline: the GPU lanes of cfg.Strategy's
+// portfolio wrapped around the CPU lanes of CalculateAnonSetDetailed
+func solveAnonSet(tx models.Transaction, cfg SSMPConfig) (int, string) {
+	portfolio := solverPortfolioFor(cfg.Strategy)
+	oversized := len(tx.Inputs) > cfg.MitMInputCap || len(tx.Outputs) > cfg.MitMInputCap
+
+	if portfolio.gpuFirst && cuda.Available() && len(tx.Inputs) > 1 {
+		if anonSet := cuda.CalculateAnonSetHardware(tx); anonSet > 0 {
+			return anonSet, SolverGPU
+		}
+	}
+	if oversized && portfolio.gpuOversized {
+		return cuda.CalculateAnonSetHardware(tx), SolverGPU
+	}
+	return CalculateAnonSetDetailed(tx.Inputs, tx.Outputs, tx.Fee, tx.Vsize, cfg)
 }
 
 // hasMatchingInputSubsetMitM implements a simplified Schroeppel-Shamir MitM search for a target value
@@ -192,6 +231,7 @@ func hasMatchingInputSubsetMitM(vals []int64, target int64, tau int64) bool {
 		}
 		leftSums = append(leftSums, sum)
 	}
+	slices.Sort(leftSums)
 
 	// Right Half
 	rightSize := n - mid
@@ -203,15 +243,12 @@ func hasMatchingInputSubsetMitM(vals []int64, target int64, tau int64) bool {
 			}
 		}
 
-		// Meet in the middle check
-		// For a full production deploy, leftSums would be sorted for binary search.
-		for _, lSum := range leftSums {
-			total := lSum + sum
-			// Valid if total is within the fee tolerance downward window:
-			// target - tau <= total <= target
-			if total >= (target-tau) && total <= target {
-				return true
-			}
+		// Meet in the middle check: binary-search the sorted left sums for
+		// any lSum in [target-tau-sum, target-sum], i.e. the fee tolerance
+		// downward window target - tau <= lSum + sum <= target
+		lo := sort.Search(len(leftSums), func(k int) bool { return leftSums[k] >= target-tau-sum })
+		if lo < len(leftSums) && leftSums[lo] <= target-sum {
+			return true
 		}
 	}
 	return false
@@ -236,6 +273,12 @@ func countEqualOutputs(outputs []models.TxOut) int {
 // AnalyzeTx parses a transaction and calculates its privacy score, AnonSet, and Evidence Edges
 // 28-Step Pipeline (Phase 17: Steps 1-24 + Steps 25-28 next-gen threat intelligence)
 func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
+	return AnalyzeTxWithConfig(tx, CurrentSSMPConfig())
+}
+
+// AnalyzeTxWithConfig is AnalyzeTx with explicit anonymity-set solver tolerances
+func AnalyzeTxWithConfig(tx models.Transaction, cfg SSMPConfig) models.PrivacyAnalysisResult {
+	cfg = cfg.withDefaults()
 	res := models.PrivacyAnalysisResult{
 		Txid:           tx.Txid,
 		PrivacyScore:   100,
@@ -243,29 +286,33 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		Edges:          make([]models.EvidenceEdge, 0),
 	}
 
+	timer := newStepTimer()
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 1: AnonSet Calculation
 	// Enforcing strict GPU batch-eligibility contract.
-	// GPU offload only if combinatorial tree justifies PCIe bus overhead.
+	// GPU offload only if combinatorial tree justifies PCIe bus overhead
+	// (or the gpu-first strategy asks for it).
 	// ════════════════════════════════════════════════════════════════════
-	var anonSet int
-	if len(tx.Inputs) > 15 || len(tx.Outputs) > 15 {
-		anonSet = cuda.CalculateAnonSetHardware(tx)
-	} else {
-		anonSet = CalculateAnonSet(tx.Inputs, tx.Outputs, tx.Fee, tx.Vsize)
-	}
+	anonSet, solver := solveAnonSet(tx, cfg)
 	res.AnonSet = anonSet
+	res.AnonSetSolver = solver
+
+	timer.mark("anonset")
 
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 2: CoinJoin Detection (collaborative construction gating)
+	// Thresholds come from the process-wide DetectionPolicy preset.
 	// ════════════════════════════════════════════════════════════════════
 	isCj := false
-	if len(tx.Inputs) >= 5 && len(tx.Outputs) >= 5 && anonSet >= 5 {
+	if CurrentDetectionPolicy().IsCoinJoin(tx, anonSet) {
 		isCj = true
 		res.HeuristicFlags |= FlagLikelyCollabConstruct
 		res.PrivacyScore = min(100, res.PrivacyScore+40)
 	}
 
+	timer.mark("coinjoin")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 3: Address Reuse Analysis
 	// ════════════════════════════════════════════════════════════════════
@@ -282,6 +329,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		res.PrivacyScore -= 40
 	}
 
+	timer.mark("address_reuse")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 4: Deterministic Flags (SegWit / Taproot / Schnorr)
 	// These were defined in llr_engine.go but never populated. Phase 13 fix.
@@ -297,8 +346,10 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		}
 	}
 
+	timer.mark("script_flags")
+
 	// ════════════════════════════════════════════════════════════════════
-	// STEP 5: Protocol Fingerprinting (Whirlpool / WabiSabi / PayJoin)
+	// STEP 5: Protocol Fingerprinting (Whirlpool / WabiSabi / JoinMarket / PayJoin)
 	// ════════════════════════════════════════════════════════════════════
 
 	// Whirlpool: 5-8 inputs/outputs, anonSet = input count
@@ -307,36 +358,53 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		res.HeuristicFlags |= FlagIsWhirlpoolStruct
 	}
 
-	// WabiSabi: ≥3 equal-value output denomination groups
-	if isCj && len(tx.Inputs) >= 5 && len(tx.Outputs) >= 10 {
-		outputCounts := make(map[int64]int)
-		for _, out := range tx.Outputs {
-			outputCounts[out.Value]++
-		}
-		equalGroups := 0
-		for _, count := range outputCounts {
-			if count >= 2 {
-				equalGroups++
+	// WabiSabi: outputs on the standard denomination ladder, with the
+	// coordinator fee and participant count estimated. Large rounds exceed
+	// the solver budget (AnonSet may be structural or 0), so a validated
+	// ladder marks the CoinJoin on its own, like JoinMarket below.
+	if ws := DetectWabiSabi(tx); ws.IsWabiSabi {
+		res.HeuristicFlags |= FlagIsWasabiSuspect
+		res.WabiSabi = &ws
+		if !isCj {
+			isCj = true
+			res.PrivacyScore = min(100, res.PrivacyScore+40)
+		}
+	}
+
+	// JoinMarket: one denomination group + N-1..N distinct maker/taker change outputs.
+	// Makers' change exceeds input − amount (they earn the fee), so the fee-tolerant
+	// SSMP rarely links them and Step 2 misses these joins.
+	if !isWhirlpoolShape {
+		if jm := DetectJoinMarket(tx); jm.IsJoinMarket {
+			res.HeuristicFlags |= FlagIsJoinMarket
+			res.JoinMarketSize = jm.Participants
+			if !isCj {
+				isCj = true
+				res.PrivacyScore = min(100, res.PrivacyScore+40)
 			}
 		}
-		if equalGroups >= 3 || (len(tx.Inputs) > 50 && len(tx.Outputs) > 50) {
-			res.HeuristicFlags |= FlagIsWasabiSuspect
-		}
 	}
 
-	// PayJoin: 2 inputs, 2+ outputs, output matches input value
-	if !isCj && len(tx.Inputs) == 2 && len(tx.Outputs) >= 2 {
-		if tx.Outputs[0].Value == tx.Inputs[0].Value {
-			res.HeuristicFlags |= FlagIsPayjoinSuspect
-		}
+	// PayJoin (BIP78): receiver-contributed input hidden in a payment + change shape
+	if !isCj && DetectPayJoin(tx).IsPayJoin {
+		res.HeuristicFlags |= FlagIsPayjoinSuspect
+	}
+
+	// MuSig2 (BIP327): low-confidence hint that a key-path input is jointly held
+	if !isCj && DetectMuSig2Suspect(tx).IsSuspect {
+		res.HeuristicFlags |= FlagIsMuSig2Suspect
 	}
 
+	timer.mark("protocol_fingerprint")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 6: Emerging Protocols Watch List (BIP352, BIP77, BIP324/330)
 	// ════════════════════════════════════════════════════════════════════
 	watchList := NewWatchListMonitor()
 	res.HeuristicFlags |= watchList.Evaluate(tx)
 
+	timer.mark("watch_list")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 7: Change Output Detection (5 sub-heuristics, weighted voting)
 	// ════════════════════════════════════════════════════════════════════
@@ -357,6 +425,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		}
 	}
 
+	timer.mark("change")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 8: Wallet Fingerprinting (BIP69, Script Types, nLockTime/nSequence)
 	// Now enhanced with Phase 13 nLockTime/nSequence/version scoring
@@ -373,17 +443,36 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		res.HeuristicFlags |= FlagIsConsolidation
 		res.PrivacyScore -= 20
 	}
+	// Exchange batch withdrawal: scored on payout count and value/script-type
+	// diversity rather than the fingerprint's 1-in-many-out rule alone
+	batch := DetectBatchPayout(tx)
+	if batch.IsBatch {
+		res.HeuristicFlags |= FlagIsBatchPayout
+		if res.WalletFamily == "" {
+			res.WalletFamily = "exchange"
+		}
+	}
+
+	timer.mark("wallet_fingerprint")
 
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 9: Whirlpool Pool Identification
 	// ════════════════════════════════════════════════════════════════════
-	if (res.HeuristicFlags & FlagIsWhirlpoolStruct) > 0 {
+	// Tx0 (premix funding) is labeled distinctly from a mix round.
+	if tx0Info := IdentifyTx0Pool(tx); tx0Info != nil {
+		res.WhirlpoolPool = tx0Info.PoolID
+		res.WhirlpoolStage = "tx0"
+	} else if (res.HeuristicFlags & FlagIsWhirlpoolStruct) > 0 {
 		poolInfo := IdentifyWhirlpoolPool(tx)
 		if poolInfo != nil {
 			res.WhirlpoolPool = poolInfo.PoolID
+			res.WhirlpoolStage = "mix"
+			res.WhirlpoolRemix = poolInfo.RemixCount
 		}
 	}
 
+	timer.mark("whirlpool_pool")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 10: Boltzmann Entropy Analysis (NEW — Phase 13)
 	// Information-theoretic measure of transaction ambiguity.
@@ -401,6 +490,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		res.PrivacyScore -= 10
 	}
 
+	timer.mark("boltzmann")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 11: Fee-Rate Intelligence (NEW — Phase 13)
 	// Wallet fingerprinting via fee rounding, overpay ratio, UTXO selection
@@ -418,6 +509,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		res.WalletFamily = feeResult.WalletHint
 	}
 
+	timer.mark("fee_rate")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 12: Peel Chain Detection (NEW — Phase 13)
 	// Serial 1-in-2-out change linking — #1 pattern exploited by Chainalysis
@@ -431,6 +524,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		}
 	}
 
+	timer.mark("peel_chain")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 13: Timing & Temporal Analysis (NEW — Phase 13)
 	// nLockTime, nSequence/RBF, coordinator round detection
@@ -446,6 +541,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		res.WalletFamily = timingWallet
 	}
 
+	timer.mark("timing")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 14: Dust Attack Detection (NEW — Phase 14)
 	// Active surveillance: tiny UTXOs (546 sats) planted to trace wallets
@@ -460,6 +557,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		res.HeuristicFlags |= FlagDustConsolidation
 	}
 
+	timer.mark("dust")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 15: UTXO Graph Topology Analysis (NEW — Phase 14)
 	// Fan-in/fan-out, Gini coefficient, shape classification
@@ -470,6 +569,11 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 	if topoResult.IsHub {
 		res.HeuristicFlags |= FlagIsHubTransaction
 	}
+	if batch.IsBatch {
+		topoResult.BatchPayout = &batch
+	}
+
+	timer.mark("topology")
 
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 16: CoinJoin Unmixing (NEW — Phase 14)
@@ -484,6 +588,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		}
 	}
 
+	timer.mark("unmixing")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 17: Calibrated Privacy Score (NEW — Phase 14)
 	// Replaces ad-hoc penalties with Bayesian-weighted composition.
@@ -493,6 +599,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 	scoreBreakdown := CalibratePrivacyScore(&res)
 	res.ScoreBreakdown = &scoreBreakdown
 
+	timer.mark("calibrated_score")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 18: Input Age & UTXO Lifespan Analysis (NEW — Phase 15)
 	// CoinDays Destroyed, holding pattern classification
@@ -504,6 +612,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		res.HeuristicFlags |= FlagAncientUTXO
 	}
 
+	timer.mark("input_age")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 19: Value Fingerprinting (NEW — Phase 15)
 	// Known exchange fees, round denominations, value entropy
@@ -515,6 +625,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		res.HeuristicFlags |= FlagKnownServicePattern
 	}
 
+	timer.mark("value_fingerprint")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 20: Script Template Deep Inspection (NEW — Phase 15)
 	// Multisig, HTLC, OP_RETURN, Tapscript complexity
@@ -528,6 +640,14 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 	if scriptResult.HasOPReturn {
 		res.HeuristicFlags |= FlagHasOPReturn
 	}
+	if scriptResult.BIP47Notification != "" {
+		res.HeuristicFlags |= FlagIsBIP47Notification
+	}
+
+	// Provably-unspendable outputs are terminal: burned, never "unspent"
+	res.BurnedOutputs, res.BurnedValue = DetectBurnOutputs(tx)
+
+	timer.mark("script_template")
 
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 21: Re-calibrate Privacy Score with Phase 15 signals
@@ -544,6 +664,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		res.PrivacyScore -= 5
 	}
 
+	timer.mark("recalibrate")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 22: Build Composable Evidence Graph & Factor-Graph Inference
 	// ════════════════════════════════════════════════════════════════════
@@ -554,6 +676,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		res.Inference = &inference
 	}
 
+	timer.mark("evidence_inference")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 23: Address Clustering (NEW — Phase 16)
 	// CIOH-based entity resolution via Union-Find.
@@ -565,6 +689,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 	// maintains a persistent ClusterEngine. The per-tx edges in res.Edges
 	// are the input to MergeFromEdges().)
 
+	timer.mark("clustering")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 24: Post-Mix Behavior Flagging (NEW — Phase 16)
 	// Detect if this tx destroys privacy gained from prior CoinJoin.
@@ -584,6 +710,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		}
 	}
 
+	timer.mark("post_mix")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 25: Lightning Channel Detection (NEW — Phase 17)
 	// Identifies funding, cooperative/force close, and penalty txs
@@ -595,18 +723,24 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		res.PrivacyScore = min(100, res.PrivacyScore+10)
 	}
 
+	timer.mark("lightning")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 26: Coinbase & Mining Pool Attribution (NEW — Phase 17)
-	// Identifies mining pool from coinbase scriptSig markers
+	// Identifies mining pool from coinbase scriptSig markers, splits the
+	// reward into subsidy and fees, and classifies the payout structure
 	// ════════════════════════════════════════════════════════════════════
 	cbResult := AnalyzeCoinbaseTx(tx)
 	if cbResult.IsCoinbase {
 		res.HeuristicFlags |= FlagIsCoinbase
+		res.Coinbase = &cbResult
 		if cbResult.PoolName != "unknown" {
 			res.WalletFamily = "mining:" + cbResult.PoolName
 		}
 	}
 
+	timer.mark("coinbase")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 27: Address Type Migration Tracking (NEW — Phase 17)
 	// Detects entity continuity across format upgrades
@@ -620,6 +754,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		res.PrivacyScore = min(100, res.PrivacyScore+5)
 	}
 
+	timer.mark("address_migration")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 28: Consolidation Intelligence (NEW — Phase 17)
 	// UTXO management profiling for entity classification
@@ -634,6 +770,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		}
 	}
 
+	timer.mark("consolidation")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 29: Taint Check — Global Illicit Fund Detection (Sprint 1)
 	// Checks all input addresses against the seeded taint map.
@@ -651,6 +789,8 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 	// Store taint level for risk assessment persistence
 	_ = taintLevel
 
+	timer.mark("taint")
+
 	// ════════════════════════════════════════════════════════════════════
 	// STEP 30: Behavioral Bot Detection (Sprint 1)
 	// Structural heuristics for automated transaction patterns.
@@ -660,6 +800,27 @@ func AnalyzeTx(tx models.Transaction) models.PrivacyAnalysisResult {
 		res.HeuristicFlags |= uint64(FlagBotBehavior)
 	}
 
+	timer.mark("bot")
+
+	// ════════════════════════════════════════════════════════════════════
+	// STEP 31: Standardness Policy
+	// Non-standard txs (dust, oversized OP_RETURN, bare multisig N>3)
+	// bypassed relay policy: protocol experiments, spam, miner payloads.
+	// ════════════════════════════════════════════════════════════════════
+	if standardness := DetectNonStandard(tx); standardness.IsNonStandard {
+		res.HeuristicFlags |= uint64(FlagNonStandard)
+		res.NonStandard = standardness.Reasons
+	}
+	timer.mark("standardness")
+
+	// ════════════════════════════════════════════════════════════════════
+	// STEP 32: Address Labels
+	// Known-entity attribution (address_labels) of inputs and outputs.
+	// ════════════════════════════════════════════════════════════════════
+	res.Labels = LabelTransaction(tx)
+	timer.mark("labels")
+	timer.finish(tx, &res)
+
 	return res
 }
 
@@ -0,0 +1,218 @@
+package heuristics
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"sync/atomic"
+)
+
+// SSMP Solver Configuration
+//
+// The fee-tolerant Subset Sum Matcher accepts a sub-transaction when
+// its outputs fall within tau sats below its inputs, where
+// tau = feeRate × FeeToleranceVbytes (floored at MinTau). In high-fee
+// environments participants pay more per input, so tau must widen or
+// genuine CoinJoin linkages are missed.
+//
+// Solver strategy: which lanes of the anytime portfolio run after
+// Meet-in-the-Middle, and with what budget:
+//   balanced   MitM → DP/Bitset (sum ≤ DPMaxSum) → CP-SAT; oversized txs
+//              offloaded to CUDA (default)
+//   fast       the balanced CPU lanes; oversized txs counted structurally
+//              instead of offloaded (CPU-limited deployments)
+//   accurate   balanced with DP/Bitset at 4× DPMaxSum (accuracy-critical
+//              deployments)
+//   gpu-first  every multi-party tx offered to CUDA first, balanced CPU
+//              lanes when the kernel is unavailable or finds nothing
+//
+// DP/Bitset matches greedily, so its count is only a lower bound; CP-SAT
+// always runs after it and the larger result is kept.
+//
+// Environment overrides (read by SSMPConfigFromEnv):
+//   SSMP_FEE_TOLERANCE_VBYTES, SSMP_MIN_TAU, SSMP_DP_MAX_SUM, SSMP_MITM_INPUT_CAP,
+//   SOLVER_STRATEGY
+
+// SSMPConfig controls the anonymity-set solver tolerances and budgets
+type SSMPConfig struct {
+	FeeToleranceVbytes float64 `json:"feeToleranceVbytes"` // vbytes of fee each participant may absorb (tau = feeRate × this)
+	MinTau             int64   `json:"minTau"`             // Floor on tau in sats
+	DPMaxSum           int64   `json:"dpMaxSum"`           // Max total output sats for the DP/Bitset lane
+	MitMInputCap       int     `json:"mitmInputCap"`       // Inputs/outputs above this bail out to structural counting
+	Strategy           string  `json:"strategy"`           // balanced/fast/accurate/gpu-first
+}
+
+// MaxMitMInputCap bounds MitMInputCap: Meet-in-the-Middle enumerates
+// 2^(n/2) subset sums per half, so each step above this doubles the memory
+// a single oversized transaction can claim
+const MaxMitMInputCap = 24
+
+// MaxDPMaxSum bounds DPMaxSum: the DP/Bitset table holds ~2.25 bytes per
+// reachable sum (×4 under the accurate strategy), so this caps a single
+// transaction at ~90 MB
+const MaxDPMaxSum = 10_000_000
+
+// Solver strategies (see solverPortfolioFor)
+const (
+	SolverStrategyBalanced = "balanced"
+	SolverStrategyFast     = "fast"
+	SolverStrategyAccurate = "accurate"
+	SolverStrategyGPUFirst = "gpu-first"
+)
+
+// Solvers reported in PrivacyAnalysisResult.AnonSetSolver
+const (
+	SolverNone       = "none"       // No equal-denomination outputs to solve for
+	SolverStructural = "structural" // Equal-output count (instance over MitMInputCap)
+	SolverMitM       = "mitm"
+	SolverDP         = "dp"
+	SolverCPSAT      = "cpsat"
+	SolverGPU        = "gpu"
+)
+
+// solverPortfolio is the lane selection of one strategy
+type solverPortfolio struct {
+	gpuFirst     bool  // Offer every multi-party tx to CUDA before the CPU lanes
+	gpuOversized bool  // Offload txs above MitMInputCap to CUDA (else structural count)
+	dpSumScale   int64 // Multiplier on DPMaxSum for the DP lane
+}
+
+// solverPortfolioFor returns the lanes a strategy runs
+func solverPortfolioFor(strategy string) solverPortfolio {
+	switch strategy {
+	case SolverStrategyFast:
+		return solverPortfolio{dpSumScale: 1}
+	case SolverStrategyAccurate:
+		return solverPortfolio{gpuOversized: true, dpSumScale: 4}
+	case SolverStrategyGPUFirst:
+		return solverPortfolio{gpuFirst: true, gpuOversized: true, dpSumScale: 1}
+	default:
+		return solverPortfolio{gpuOversized: true, dpSumScale: 1}
+	}
+}
+
+// IsValidSolverStrategy reports whether s names a solver strategy
+func IsValidSolverStrategy(s string) bool {
+	switch s {
+	case SolverStrategyBalanced, SolverStrategyFast, SolverStrategyAccurate, SolverStrategyGPUFirst:
+		return true
+	}
+	return false
+}
+
+// DefaultSSMPConfig returns the historical solver constants
+func DefaultSSMPConfig() SSMPConfig {
+	return SSMPConfig{
+		FeeToleranceVbytes: 150,
+		MinTau:             1000,
+		DPMaxSum:           500_000,
+		MitMInputCap:       15, // 2^15 combinations per half
+		Strategy:           SolverStrategyBalanced,
+	}
+}
+
+var ssmpConfig atomic.Pointer[SSMPConfig]
+
+func init() {
+	cfg := DefaultSSMPConfig()
+	ssmpConfig.Store(&cfg)
+}
+
+// SetSSMPConfig replaces the process-wide solver configuration.
+// Non-positive fields fall back to their defaults.
+func SetSSMPConfig(cfg SSMPConfig) {
+	cfg = cfg.withDefaults()
+	ssmpConfig.Store(&cfg)
+}
+
+// CurrentSSMPConfig returns the process-wide solver configuration
+func CurrentSSMPConfig() SSMPConfig {
+	return *ssmpConfig.Load()
+}
+
+// SSMPConfigFromEnv builds a solver configuration from the SSMP_* environment
+// variables, keeping defaults for unset or invalid values.
+func SSMPConfigFromEnv() SSMPConfig {
+	cfg := DefaultSSMPConfig()
+
+	if raw := os.Getenv("SSMP_FEE_TOLERANCE_VBYTES"); raw != "" {
+		if v, err := strconv.ParseFloat(raw, 64); err == nil && v > 0 {
+			cfg.FeeToleranceVbytes = v
+		} else {
+			log.Printf("[SSMP] Invalid SSMP_FEE_TOLERANCE_VBYTES %q, using %.0f", raw, cfg.FeeToleranceVbytes)
+		}
+	}
+	if raw := os.Getenv("SSMP_MIN_TAU"); raw != "" {
+		if v, err := strconv.ParseInt(raw, 10, 64); err == nil && v > 0 {
+			cfg.MinTau = v
+		} else {
+			log.Printf("[SSMP] Invalid SSMP_MIN_TAU %q, using %d", raw, cfg.MinTau)
+		}
+	}
+	if raw := os.Getenv("SSMP_DP_MAX_SUM"); raw != "" {
+		if v, err := strconv.ParseInt(raw, 10, 64); err == nil && v > 0 {
+			cfg.DPMaxSum = v
+			if v > MaxDPMaxSum {
+				log.Printf("[SSMP] SSMP_DP_MAX_SUM %d exceeds %d, clamping", v, MaxDPMaxSum)
+				cfg.DPMaxSum = MaxDPMaxSum
+			}
+		} else {
+			log.Printf("[SSMP] Invalid SSMP_DP_MAX_SUM %q, using %d", raw, cfg.DPMaxSum)
+		}
+	}
+	if raw := os.Getenv("SSMP_MITM_INPUT_CAP"); raw != "" {
+		if v, err := strconv.Atoi(raw); err == nil && v > 0 {
+			cfg.MitMInputCap = v
+			if v > MaxMitMInputCap {
+				log.Printf("[SSMP] SSMP_MITM_INPUT_CAP %d exceeds %d, clamping", v, MaxMitMInputCap)
+				cfg.MitMInputCap = MaxMitMInputCap
+			}
+		} else {
+			log.Printf("[SSMP] Invalid SSMP_MITM_INPUT_CAP %q, using %d", raw, cfg.MitMInputCap)
+		}
+	}
+	if raw := os.Getenv("SOLVER_STRATEGY"); raw != "" {
+		if IsValidSolverStrategy(raw) {
+			cfg.Strategy = raw
+		} else {
+			log.Printf("[SSMP] Invalid SOLVER_STRATEGY %q, using %s", raw, cfg.Strategy)
+		}
+	}
+
+	return cfg
+}
+
+// withDefaults fills non-positive fields (and unknown strategies) from
+// DefaultSSMPConfig and clamps MitMInputCap and DPMaxSum to their maxima
+func (c SSMPConfig) withDefaults() SSMPConfig {
+	def := DefaultSSMPConfig()
+	if c.FeeToleranceVbytes <= 0 {
+		c.FeeToleranceVbytes = def.FeeToleranceVbytes
+	}
+	if c.MinTau <= 0 {
+		c.MinTau = def.MinTau
+	}
+	if c.DPMaxSum <= 0 {
+		c.DPMaxSum = def.DPMaxSum
+	}
+	if c.DPMaxSum > MaxDPMaxSum {
+		log.Printf("[SSMP] DPMaxSum %d exceeds %d, clamping", c.DPMaxSum, MaxDPMaxSum)
+		c.DPMaxSum = MaxDPMaxSum
+	}
+	if c.MitMInputCap <= 0 {
+		c.MitMInputCap = def.MitMInputCap
+	}
+	if c.MitMInputCap > MaxMitMInputCap {
+		log.Printf("[SSMP] MitMInputCap %d exceeds %d, clamping", c.MitMInputCap, MaxMitMInputCap)
+		c.MitMInputCap = MaxMitMInputCap
+	}
+	if !IsValidSolverStrategy(c.Strategy) {
+		c.Strategy = def.Strategy
+	}
+	return c
+}
+
+// tau returns the fee tolerance window in sats for a given fee rate
+func (c SSMPConfig) tau(feeRate float64) int64 {
+	return int64(feeRate * c.FeeToleranceVbytes)
+}
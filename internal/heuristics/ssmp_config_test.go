@@ -0,0 +1,205 @@
+package heuristics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/internal/cuda"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// highFeeMix is a 3-person mix where each participant paid 5,000 sats of
+// fee at a nominal 10 sat/vB — above the default 1,500-sat tau.
+func highFeeMix() ([]models.TxIn, []models.TxOut) {
+	inputs := []models.TxIn{
+		{Value: 150_000_000, Address: "A"},
+		{Value: 200_000_000, Address: "B"},
+		{Value: 120_000_000, Address: "C"},
+	}
+	outputs := []models.TxOut{
+		{Value: 100_000_000},
+		{Value: 100_000_000},
+		{Value: 100_000_000},
+		{Value: 49_995_000},
+		{Value: 99_995_000},
+		{Value: 19_995_000},
+	}
+	return inputs, outputs
+}
+
+func TestCalculateAnonSetWithConfig_WidenedTau(t *testing.T) {
+	inputs, outputs := highFeeMix()
+
+	if got := CalculateAnonSetWithConfig(inputs, outputs, 4500, 450, DefaultSSMPConfig()); got == 3 {
+		t.Fatalf("Expected default tau to miss the high-fee linkages. Got anonSet=%d", got)
+	}
+
+	cfg := DefaultSSMPConfig()
+	cfg.FeeToleranceVbytes = 600
+	if got := CalculateAnonSetWithConfig(inputs, outputs, 4500, 450, cfg); got != 3 {
+		t.Errorf("Expected widened tau to recover anonSet=3. Got %d", got)
+	}
+}
+
+func TestSetSSMPConfig_DefaultsPreserved(t *testing.T) {
+	defer SetSSMPConfig(DefaultSSMPConfig())
+
+	SetSSMPConfig(SSMPConfig{MinTau: 2500})
+	cfg := CurrentSSMPConfig()
+	def := DefaultSSMPConfig()
+	if cfg.MinTau != 2500 {
+		t.Errorf("Expected MinTau=2500. Got %d", cfg.MinTau)
+	}
+	if cfg.FeeToleranceVbytes != def.FeeToleranceVbytes || cfg.DPMaxSum != def.DPMaxSum || cfg.MitMInputCap != def.MitMInputCap {
+		t.Errorf("Expected unset fields to keep defaults. Got %+v", cfg)
+	}
+}
+
+func TestSSMPConfigFromEnv(t *testing.T) {
+	t.Setenv("SSMP_FEE_TOLERANCE_VBYTES", "400")
+	t.Setenv("SSMP_MIN_TAU", "not-a-number")
+	t.Setenv("SSMP_MITM_INPUT_CAP", "12")
+	t.Setenv("SOLVER_STRATEGY", "accurate")
+
+	cfg := SSMPConfigFromEnv()
+	if cfg.FeeToleranceVbytes != 400 || cfg.MitMInputCap != 12 || cfg.Strategy != SolverStrategyAccurate {
+		t.Errorf("Expected env overrides applied. Got %+v", cfg)
+	}
+	if cfg.MinTau != DefaultSSMPConfig().MinTau {
+		t.Errorf("Expected invalid SSMP_MIN_TAU to keep default. Got %d", cfg.MinTau)
+	}
+}
+
+func TestSSMPConfig_ClampsMitMInputCap(t *testing.T) {
+	defer SetSSMPConfig(DefaultSSMPConfig())
+
+	t.Setenv("SSMP_MITM_INPUT_CAP", "60")
+	if cfg := SSMPConfigFromEnv(); cfg.MitMInputCap != MaxMitMInputCap {
+		t.Errorf("Expected SSMP_MITM_INPUT_CAP=60 clamped to %d. Got %d", MaxMitMInputCap, cfg.MitMInputCap)
+	}
+
+	SetSSMPConfig(SSMPConfig{MitMInputCap: 40})
+	if cfg := CurrentSSMPConfig(); cfg.MitMInputCap != MaxMitMInputCap {
+		t.Errorf("Expected SetSSMPConfig to clamp MitMInputCap to %d. Got %d", MaxMitMInputCap, cfg.MitMInputCap)
+	}
+}
+
+func TestSSMPConfig_ClampsDPMaxSum(t *testing.T) {
+	defer SetSSMPConfig(DefaultSSMPConfig())
+
+	t.Setenv("SSMP_DP_MAX_SUM", "2100000000000000")
+	if cfg := SSMPConfigFromEnv(); cfg.DPMaxSum != MaxDPMaxSum {
+		t.Errorf("Expected SSMP_DP_MAX_SUM clamped to %d. Got %d", MaxDPMaxSum, cfg.DPMaxSum)
+	}
+
+	SetSSMPConfig(SSMPConfig{DPMaxSum: 50_000_000})
+	if cfg := CurrentSSMPConfig(); cfg.DPMaxSum != MaxDPMaxSum {
+		t.Errorf("Expected SetSSMPConfig to clamp DPMaxSum to %d. Got %d", MaxDPMaxSum, cfg.DPMaxSum)
+	}
+}
+
+// mitmMissTx is a 2-party mix MitM only half-resolves: the 300k input paid
+// for the two change outputs without touching the 200k denomination, so
+// only the DP/CP-SAT lanes link it. Outputs sum to 700k — above the default
+// DPMaxSum but within the accurate strategy's 4× budget.
+func mitmMissTx() models.Transaction {
+	return models.Transaction{
+		Txid: "mitm_miss",
+		Inputs: []models.TxIn{
+			{Value: 200_100, Address: "A"},
+			{Value: 300_000, Address: "B"},
+		},
+		Outputs: []models.TxOut{
+			{Value: 200_000},
+			{Value: 200_000},
+			{Value: 140_000},
+			{Value: 160_000},
+		},
+		Fee:   100,
+		Vsize: 100,
+	}
+}
+
+func TestSolverStrategy_Routing(t *testing.T) {
+	tx := mitmMissTx()
+	gpuFirst := SolverCPSAT // Without the kernel gpu-first runs the balanced CPU lanes
+	if cuda.Available() {
+		gpuFirst = SolverGPU
+	}
+
+	tests := []struct {
+		strategy string
+		anonSet  int
+		solver   string
+	}{
+		{SolverStrategyBalanced, 2, SolverCPSAT}, // Sum above DPMaxSum → CP-SAT
+		{SolverStrategyFast, 2, SolverCPSAT},     // Same CPU lanes as balanced
+		{SolverStrategyAccurate, 2, SolverDP},    // 4× DP budget admits the instance; CP-SAT finds no more
+		{SolverStrategyGPUFirst, 2, gpuFirst},
+	}
+	for _, tt := range tests {
+		cfg := DefaultSSMPConfig()
+		cfg.Strategy = tt.strategy
+		anonSet, solver := solveAnonSet(tx, cfg.withDefaults())
+		if solver != tt.solver {
+			t.Errorf("%s: expected solver %s. Got %s (anonSet=%d)", tt.strategy, tt.solver, solver, anonSet)
+		}
+		if !cuda.Available() && anonSet != tt.anonSet {
+			t.Errorf("%s: expected anonSet=%d. Got %d", tt.strategy, tt.anonSet, anonSet)
+		}
+	}
+
+	if res := AnalyzeTxWithConfig(tx, DefaultSSMPConfig()); res.AnonSetSolver != SolverCPSAT {
+		t.Errorf("Expected the solver surfaced on the analysis result. Got %q", res.AnonSetSolver)
+	}
+
+	// Balanced routes to DP once the sum fits the default budget
+	cfg := DefaultSSMPConfig()
+	cfg.DPMaxSum = 1_000_000
+	if got, solver := CalculateAnonSetDetailed(tx.Inputs, tx.Outputs, tx.Fee, tx.Vsize, cfg); got != 2 || solver != SolverDP {
+		t.Errorf("Expected balanced DP routing under a raised DPMaxSum. Got %d via %s", got, solver)
+	}
+}
+
+func TestSolverStrategy_CPSATLiftsDPLowerBound(t *testing.T) {
+	// The DP table answers 13000 with its highest reachable sum, 4000+9000,
+	// taking the 9000 that 20000 = 11000+9000 needs; CP-SAT pairs 13000 with
+	// a 12000 output instead and matches both
+	inputs := []models.TxIn{{Value: 20_000}, {Value: 20_000}, {Value: 13_000}}
+	outputs := []models.TxOut{{Value: 4_000}, {Value: 12_000}, {Value: 11_000}, {Value: 9_000}, {Value: 12_000}}
+	tau := DefaultSSMPConfig().MinTau
+	if dp := SolveDPBitset([]int64{20_000, 20_000, 13_000}, []int64{4_000, 12_000, 11_000, 9_000, 12_000}, tau, 1_000_000); dp != 1 {
+		t.Fatalf("Expected the greedy DP lane to find 1 matching. Got %d", dp)
+	}
+
+	for _, strategy := range []string{SolverStrategyBalanced, SolverStrategyFast, SolverStrategyAccurate} {
+		cfg := DefaultSSMPConfig()
+		cfg.Strategy = strategy
+		if got, solver := CalculateAnonSetDetailed(inputs, outputs, 100, 100, cfg); got != 2 || solver != SolverCPSAT {
+			t.Errorf("%s: expected CP-SAT to lift the DP count to 2. Got %d via %s", strategy, got, solver)
+		}
+	}
+}
+
+func TestSolverStrategy_Oversized(t *testing.T) {
+	tx := models.Transaction{Fee: 5_000, Vsize: 2_000}
+	for i := 0; i < 20; i++ {
+		tx.Inputs = append(tx.Inputs, models.TxIn{Value: 1_000_500, Address: fmt.Sprintf("in%d", i)})
+		tx.Outputs = append(tx.Outputs, models.TxOut{Value: 1_000_000})
+	}
+
+	cfg := DefaultSSMPConfig()
+	cfg.Strategy = SolverStrategyFast
+	if got, solver := solveAnonSet(tx, cfg); got != 20 || solver != SolverStructural {
+		t.Errorf("Expected fast to count oversized txs structurally. Got %d via %s", got, solver)
+	}
+
+	if _, solver := solveAnonSet(tx, DefaultSSMPConfig()); solver != SolverGPU {
+		t.Errorf("Expected balanced to offload oversized txs to the GPU lane. Got %s", solver)
+	}
+
+	cfg.Strategy = "quantum"
+	if cfg.withDefaults().Strategy != SolverStrategyBalanced {
+		t.Errorf("Expected an unknown strategy to fall back to balanced")
+	}
+}
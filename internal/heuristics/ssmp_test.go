@@ -1,6 +1,7 @@
 package heuristics
 
 import (
+	"math/rand"
 	"testing"
 
 	"github.com/rawblock/coinjoin-engine/pkg/models"
@@ -61,3 +62,78 @@ func TestCalculateAnonSet_MassiveBailout(t *testing.T) {
 		t.Errorf("Expected bailout structural AnonSet to be 50. Got: %d", anonSet)
 	}
 }
+
+// linearMitM is the original quadratic meet-in-the-middle check, kept as a
+// reference oracle for the sorted binary-search solver
+func linearMitM(vals []int64, target int64, tau int64) bool {
+	mid := len(vals) / 2
+	leftSums := make([]int64, 0, 1<<mid)
+	for i := 0; i < (1 << mid); i++ {
+		var sum int64
+		for j := 0; j < mid; j++ {
+			if (i & (1 << j)) > 0 {
+				sum += vals[j]
+			}
+		}
+		leftSums = append(leftSums, sum)
+	}
+
+	rightSize := len(vals) - mid
+	for i := 0; i < (1 << rightSize); i++ {
+		var sum int64
+		for j := 0; j < rightSize; j++ {
+			if (i & (1 << j)) > 0 {
+				sum += vals[mid+j]
+			}
+		}
+		for _, lSum := range leftSums {
+			total := lSum + sum
+			if total >= (target-tau) && total <= target {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func TestHasMatchingInputSubsetMitM_MatchesLinearScan(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	for trial := 0; trial < 300; trial++ {
+		n := 1 + rng.Intn(12)
+		vals := make([]int64, n)
+		for i := range vals {
+			vals[i] = 1 + rng.Int63n(100_000)
+		}
+		target := rng.Int63n(int64(n) * 100_000)
+		tau := rng.Int63n(2_000)
+
+		want := linearMitM(vals, target, tau)
+		if got := hasMatchingInputSubsetMitM(vals, target, tau); got != want {
+			t.Fatalf("Mismatch for vals=%v target=%d tau=%d: got %v, want %v", vals, target, tau, got, want)
+		}
+	}
+}
+
+// mitmWorstCase returns 15 values whose subset sums never reach the
+// target window, forcing a full search of both halves
+func mitmWorstCase() ([]int64, int64, int64) {
+	vals := make([]int64, 15)
+	for i := range vals {
+		vals[i] = int64(1_000_000 * (i + 1)) // All sums are multiples of 1M
+	}
+	return vals, 500_000_000 + 500_000, 1000
+}
+
+func BenchmarkHasMatchingInputSubsetMitM_15Inputs(b *testing.B) {
+	vals, target, tau := mitmWorstCase()
+	for i := 0; i < b.N; i++ {
+		hasMatchingInputSubsetMitM(vals, target, tau)
+	}
+}
+
+func BenchmarkLinearMitM_15Inputs(b *testing.B) {
+	vals, target, tau := mitmWorstCase()
+	for i := 0; i < b.N; i++ {
+		linearMitM(vals, target, tau)
+	}
+}
@@ -0,0 +1,218 @@
+package heuristics
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Standardness Policy Module
+//
+// Bitcoin Core relays only "standard" transactions (policy/policy.cpp
+// IsStandardTx). Non-standard transactions can still be mined directly
+// by a pool, so seeing one usually means a protocol experiment, an
+// inscription/spam campaign, or a miner-submitted payload — all worth
+// surfacing in mempool surveillance.
+//
+// Checks applied (Bitcoin Core ≤ v29 defaults):
+//   - version in [1, 3]
+//   - weight ≤ 400,000 WU (MAX_STANDARD_TX_WEIGHT)
+//   - scriptSig ≤ 1,650 bytes per input
+//   - outputs use a standard script template; bare multisig N ≤ 3
+//   - non-OP_RETURN outputs at or above the dust threshold (3 sat/vB)
+//   - at most one OP_RETURN output, ≤ 83 bytes of script
+//   - legacy sigop cost ≤ 16,000 (MAX_STANDARD_TX_SIGOPS_COST)
+//
+// References:
+//   - Bitcoin Core, src/policy/policy.cpp (IsStandard, IsDust)
+//   - Bitcoin Core, src/policy/policy.h (MAX_STANDARD_* constants)
+
+// Standardness limits (Bitcoin Core policy defaults)
+const (
+	MaxStandardTxVersion     = 3
+	MaxStandardTxWeight      = 400_000
+	MaxStandardScriptSigSize = 1650
+	MaxStandardSigOpsCost    = 16_000
+	MaxOPReturnRelayBytes    = 83 // Full OP_RETURN scriptPubKey incl. opcode and pushes
+	MaxBareMultisigKeys      = 3
+	DustRelayFeeRate         = 3 // sat/vB
+)
+
+// StandardnessResult lists the policy rules a transaction violates
+type StandardnessResult struct {
+	IsNonStandard bool     `json:"isNonStandard"`
+	Reasons       []string `json:"reasons,omitempty"` // Bitcoin Core reject reasons, e.g. "dust", "datacarrier"
+	DustOutputs   []int    `json:"dustOutputs,omitempty"`
+	SigOpsCost    int      `json:"sigOpsCost"` // Estimated legacy sigop cost of the outputs
+}
+
+// DetectNonStandard applies Bitcoin Core's standardness checks
+func DetectNonStandard(tx models.Transaction) StandardnessResult {
+	result := StandardnessResult{}
+	reject := func(reason string) {
+		result.IsNonStandard = true
+		result.Reasons = appendUnique(result.Reasons, reason)
+	}
+
+	if tx.Version < 1 || tx.Version > MaxStandardTxVersion {
+		reject("version")
+	}
+
+	weight := tx.Weight
+	if weight == 0 {
+		weight = tx.Vsize * 4
+	}
+	if weight > MaxStandardTxWeight {
+		reject("tx-size")
+	}
+
+	if len(tx.Outputs) == 0 {
+		reject("vout-empty")
+	}
+
+	for _, in := range tx.Inputs {
+		if len(in.ScriptSig)/2 > MaxStandardScriptSigSize {
+			reject("scriptsig-size")
+		}
+	}
+
+	opReturns := 0
+	sigOps := 0
+	for i, out := range tx.Outputs {
+		script := strings.ToLower(out.ScriptPubKey)
+
+		if isOPReturn(script) {
+			opReturns++
+			if len(script)/2 > MaxOPReturnRelayBytes {
+				reject("datacarrier")
+			}
+			continue
+		}
+
+		switch scriptTemplate(script) {
+		case "nonstandard":
+			reject("scriptpubkey")
+		case "multisig":
+			if _, n := extractMultisigMN(script); n > MaxBareMultisigKeys {
+				reject("bare-multisig")
+			}
+		}
+
+		if out.Value < outputDustThreshold(out) {
+			reject("dust")
+			result.DustOutputs = append(result.DustOutputs, i)
+		}
+
+		sigOps += countLegacySigOps(script)
+	}
+
+	if opReturns > 1 {
+		reject("multi-op-return")
+	}
+
+	result.SigOpsCost = sigOps * 4 // WITNESS_SCALE_FACTOR
+	if result.SigOpsCost > MaxStandardSigOpsCost {
+		reject("bad-txns-too-many-sigops")
+	}
+
+	return result
+}
+
+// scriptTemplate classifies a scriptPubKey (hex) into a standard template.
+// An empty script is "unknown" (only the address is available).
+func scriptTemplate(script string) string {
+	n := len(script)
+	switch {
+	case n == 0:
+		return "unknown"
+	case n == 50 && strings.HasPrefix(script, "76a914") && strings.HasSuffix(script, "88ac"):
+		return "p2pkh"
+	case n == 46 && strings.HasPrefix(script, "a914") && strings.HasSuffix(script, "87"):
+		return "p2sh"
+	case n == 44 && strings.HasPrefix(script, "0014"):
+		return "p2wpkh"
+	case n == 68 && strings.HasPrefix(script, "0020"):
+		return "p2wsh"
+	case n == 68 && strings.HasPrefix(script, "5120"):
+		return "p2tr"
+	case script == "51024e73":
+		return "anchor" // Pay-to-Anchor (P2A)
+	case (n == 70 && strings.HasPrefix(script, "21") || n == 134 && strings.HasPrefix(script, "41")) && strings.HasSuffix(script, "ac"):
+		return "p2pk"
+	case strings.HasSuffix(script, "ae") && parseOpN(script[:2]) > 0:
+		return "multisig"
+	case isWitnessProgram(script) && !strings.HasPrefix(script, "00"):
+		return "witness_unknown" // Future segwit versions are standard to relay
+	default:
+		return "nonstandard"
+	}
+}
+
+// isWitnessProgram reports OP_0..OP_16 followed by a single 2-40 byte push
+func isWitnessProgram(script string) bool {
+	if len(script) < 8 || (script[:2] != "00" && parseOpN(script[:2]) == 0) {
+		return false
+	}
+	raw, err := hex.DecodeString(script[2:])
+	if err != nil || len(raw) < 3 {
+		return false
+	}
+	pushLen := int(raw[0])
+	return pushLen >= 2 && pushLen <= 40 && len(raw) == pushLen+1
+}
+
+// outputDustThreshold computes Bitcoin Core's dust limit for an output:
+// (serialized output size + spending input size) × dust relay fee.
+// Falls back to the address-type table when the script is unavailable.
+func outputDustThreshold(out models.TxOut) int64 {
+	script := strings.ToLower(out.ScriptPubKey)
+	switch scriptTemplate(script) {
+	case "unknown":
+		return getDustThreshold(out.Address)
+	case "anchor":
+		return 240 // P2A dust limit
+	}
+
+	size := 8 + 1 + len(script)/2 // value + compact size + script
+	if isWitnessProgram(script) {
+		size += 32 + 4 + 1 + 107/4 + 4 // Discounted witness input
+	} else {
+		size += 32 + 4 + 1 + 107 + 4 // Legacy input
+	}
+	return int64(size * DustRelayFeeRate)
+}
+
+// countLegacySigOps counts signature operations in a script the way
+// GetLegacySigOpCount does: CHECKSIG = 1, bare CHECKMULTISIG = 20.
+func countLegacySigOps(script string) int {
+	raw, err := hex.DecodeString(script)
+	if err != nil {
+		return 0
+	}
+
+	count := 0
+	for i := 0; i < len(raw); {
+		op := raw[i]
+		i++
+		switch {
+		case op >= 0x01 && op <= 0x4b: // Direct push
+			i += int(op)
+		case op == 0x4c: // OP_PUSHDATA1
+			if i < len(raw) {
+				i += 1 + int(raw[i])
+			}
+		case op == 0x4d: // OP_PUSHDATA2
+			if i+1 < len(raw) {
+				i += 2 + (int(raw[i]) | int(raw[i+1])<<8)
+			}
+		case op == 0x4e: // OP_PUSHDATA4
+			return count // Larger than any standard script
+		case op == 0xac || op == 0xad: // OP_CHECKSIG(VERIFY)
+			count++
+		case op == 0xae || op == 0xaf: // OP_CHECKMULTISIG(VERIFY)
+			count += 20
+		}
+	}
+	return count
+}
@@ -0,0 +1,95 @@
+package heuristics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+const (
+	testP2WPKHScript = "0014" + "751e76e8199196d454941c45d1b3a323f1433bd6"
+	testP2PKHScript  = "76a914" + "751e76e8199196d454941c45d1b3a323f1433bd6" + "88ac"
+)
+
+func standardTx() models.Transaction {
+	return models.Transaction{
+		Txid:    "std",
+		Version: 2,
+		Vsize:   141,
+		Inputs:  []models.TxIn{{Address: "bc1qsender", Value: 100_000}},
+		Outputs: []models.TxOut{
+			{ScriptPubKey: testP2WPKHScript, Value: 50_000},
+			{ScriptPubKey: testP2PKHScript, Value: 49_000},
+		},
+	}
+}
+
+func hasReason(r StandardnessResult, reason string) bool {
+	for _, got := range r.Reasons {
+		if got == reason {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDetectNonStandard_StandardTx(t *testing.T) {
+	if r := DetectNonStandard(standardTx()); r.IsNonStandard {
+		t.Errorf("Expected standard tx. Got reasons %v", r.Reasons)
+	}
+}
+
+func TestDetectNonStandard_BelowDust(t *testing.T) {
+	tx := standardTx()
+	tx.Outputs[0].Value = 293 // P2WPKH dust limit is 294
+	tx.Outputs[1].Value = 546 // P2PKH at exactly the limit is fine
+
+	r := DetectNonStandard(tx)
+	if !hasReason(r, "dust") {
+		t.Fatalf("Expected dust violation. Got %v", r.Reasons)
+	}
+	if len(r.DustOutputs) != 1 || r.DustOutputs[0] != 0 {
+		t.Errorf("Expected only output 0 to be dust. Got %v", r.DustOutputs)
+	}
+
+	res := AnalyzeTx(tx)
+	if res.HeuristicFlags&FlagNonStandard == 0 {
+		t.Error("Expected FlagNonStandard to be set by AnalyzeTx")
+	}
+}
+
+func TestDetectNonStandard_OversizedOPReturn(t *testing.T) {
+	tx := standardTx()
+	payload := strings.Repeat("ab", 100) // 100 bytes > 80-byte datacarrier limit
+	tx.Outputs = append(tx.Outputs, models.TxOut{ScriptPubKey: "6a4c64" + payload})
+
+	r := DetectNonStandard(tx)
+	if !hasReason(r, "datacarrier") {
+		t.Errorf("Expected datacarrier violation. Got %v", r.Reasons)
+	}
+	if hasReason(r, "dust") {
+		t.Errorf("Expected OP_RETURN output to be exempt from dust. Got %v", r.Reasons)
+	}
+
+	tx = standardTx()
+	tx.Outputs = append(tx.Outputs,
+		models.TxOut{ScriptPubKey: "6a04deadbeef"},
+		models.TxOut{ScriptPubKey: "6a04cafebabe"},
+	)
+	if r := DetectNonStandard(tx); !hasReason(r, "multi-op-return") {
+		t.Errorf("Expected multi-op-return violation. Got %v", r.Reasons)
+	}
+}
+
+func TestDetectNonStandard_BareMultisigTooManyKeys(t *testing.T) {
+	key := "21" + "02" + strings.Repeat("11", 32)
+	script := "51" + strings.Repeat(key, 4) + "54ae" // 1-of-4 bare multisig
+
+	tx := standardTx()
+	tx.Outputs = append(tx.Outputs, models.TxOut{ScriptPubKey: script, Value: 10_000})
+
+	if r := DetectNonStandard(tx); !hasReason(r, "bare-multisig") {
+		t.Errorf("Expected bare-multisig violation. Got %v", r.Reasons)
+	}
+}
@@ -1,20 +1,26 @@
 package heuristics
 
 import (
+	"encoding/json"
+	"fmt"
 	"math"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
 )
 
 // Taint Propagation & Risk Scoring
 //
 // Traces the flow of funds from known illicit sources through the
-// transaction graph. Uses a FIFO (First-In-First-Out) model:
+// transaction graph. Three propagation models are selectable (TaintModel):
 //
 //   Poison model:     ANY input from a tainted source → ALL outputs tainted
 //   Haircut model:    Taint proportionally distributed by output value
 //   FIFO model:       Taint follows value through first-in-first-out ordering
 //
-// We implement the haircut model (proportional allocation) which is the
-// standard used by FATF and most regulatory frameworks.
+// Haircut (proportional allocation) is the default and the standard used
+// by FATF and most regulatory frameworks. FIFO (Clayton's Case) is what
+// many law-enforcement tracing frameworks require; poison is the
+// conservative upper bound.
 //
 // Risk levels:
 //   clean:     No taint exposure
@@ -27,6 +33,7 @@ import (
 //   - FATF, "Virtual Assets Red Flag Indicators" (2020)
 //   - FinCEN, "Advisory on Illicit Activity Involving Convertible Virtual Currency"
 //   - Möser et al., "An Empirical Analysis of Traceability" (USENIX 2013)
+//   - Anderson et al., "Bitcoin Redux" (WEIS 2018) — FIFO / Clayton's Case
 
 // TaintSource represents a known source of illicit funds
 type TaintSource struct {
@@ -45,6 +52,15 @@ type TaintResult struct {
 	TaintedRatio   float64       `json:"taintedRatio"`   // Fraction of tx value from tainted sources
 }
 
+// MarshalJSON rounds reported floats to the configured precision
+func (r TaintResult) MarshalJSON() ([]byte, error) {
+	type alias TaintResult
+	a := alias(r)
+	a.RiskScore = models.RoundFloat(a.RiskScore)
+	a.TaintedRatio = models.RoundFloat(a.TaintedRatio)
+	return json.Marshal(a)
+}
+
 // TaintMap is a mapping from address to accumulated taint level
 type TaintMap map[string]float64
 
@@ -63,6 +79,54 @@ func (tm TaintMap) SeedTaint(sources []TaintSource) {
 	}
 }
 
+// TaintModel selects how taint flows from a transaction's inputs to its outputs
+type TaintModel int
+
+const (
+	TaintModelHaircut TaintModel = iota // Proportional to output value (default)
+	TaintModelFIFO                      // First-in-first-out by input/output position
+	TaintModelPoison                    // Any tainted input taints every output fully
+)
+
+// String returns the model name used in configuration and API responses
+func (m TaintModel) String() string {
+	switch m {
+	case TaintModelFIFO:
+		return "fifo"
+	case TaintModelPoison:
+		return "poison"
+	default:
+		return "haircut"
+	}
+}
+
+// ParseTaintModel maps "haircut"/"fifo"/"poison" to a TaintModel
+func ParseTaintModel(s string) (TaintModel, error) {
+	switch s {
+	case "haircut", "":
+		return TaintModelHaircut, nil
+	case "fifo":
+		return TaintModelFIFO, nil
+	case "poison":
+		return TaintModelPoison, nil
+	}
+	return TaintModelHaircut, fmt.Errorf("unknown taint model %q (want haircut, fifo or poison)", s)
+}
+
+// PropagateTaint spreads taint through a transaction under the given model
+func (tm TaintMap) PropagateTaint(model TaintModel, inputAddrs []string, inputValues []int64,
+	outputAddrs []string, outputValues []int64) {
+
+	switch model {
+	case TaintModelFIFO:
+		tm.PropagateTaintFIFO(inputAddrs, inputValues, outputAddrs, outputValues)
+	case TaintModelPoison:
+		tm.PropagateTaintPoison(inputAddrs, inputValues, outputAddrs, outputValues)
+	default:
+		tm.PropagateTaintHaircut(inputAddrs, inputValues, outputAddrs, outputValues)
+	}
+}
+
 // PropagateTaintHaircut spreads taint through a transaction using the
 // haircut (proportional) model. Each output receives a share of taint
 // proportional to its value relative to total output value.
@@ -114,6 +178,103 @@ func (tm TaintMap) PropagateTaintHaircut(inputAddrs []string, inputValues []int6
 	}
 }
 
+// PropagateTaintFIFO spreads taint through a transaction using the FIFO
+// (Clayton's Case) model. Input satoshis are laid end to end in input
+// order and paid out to outputs in output order; the fee is taken from the
+// tail. Each output's taint is the tainted fraction of the satoshis it
+// received:
+//
+//	inputs  [ A: 1.0 BTC taint 1.0 ][ B: 1.0 BTC clean ]
+//	outputs [ X: 1.0 BTC → 1.0     ][ Y: 0.99 BTC → 0 ] (fee 0.01)
+func (tm TaintMap) PropagateTaintFIFO(inputAddrs []string, inputValues []int64,
+	outputAddrs []string, outputValues []int64) {
+
+	if len(inputAddrs) != len(inputValues) || len(outputAddrs) != len(outputValues) {
+		return
+	}
+
+	inputTaints := make([]float64, len(inputAddrs))
+	anyTaint := false
+	for i, addr := range inputAddrs {
+		inputTaints[i] = tm[addr]
+		anyTaint = anyTaint || inputTaints[i] > 0
+	}
+	if !anyTaint {
+		return
+	}
+
+	for j, taint := range fifoOutputTaints(inputTaints, inputValues, outputValues) {
+		if taint > 0 {
+			tm.raiseTaint(outputAddrs[j], taint)
+		}
+	}
+}
+
+// fifoOutputTaints returns each output's taint under FIFO allocation
+func fifoOutputTaints(inputTaints []float64, inputValues, outputValues []int64) []float64 {
+	taints := make([]float64, len(outputValues))
+
+	in := 0
+	var inLeft int64 // Satoshis of inputs[in] not yet paid out
+	if len(inputValues) > 0 {
+		inLeft = inputValues[0]
+	}
+	for j, outValue := range outputValues {
+		if outValue <= 0 {
+			continue
+		}
+		need := outValue
+		tainted := 0.0
+		for need > 0 && in < len(inputValues) {
+			if inLeft <= 0 {
+				in++
+				if in < len(inputValues) {
+					inLeft = inputValues[in]
+				}
+				continue
+			}
+			take := need
+			if inLeft < take {
+				take = inLeft
+			}
+			tainted += inputTaints[in] * float64(take)
+			inLeft -= take
+			need -= take
+		}
+		taints[j] = math.Min(1.0, tainted/float64(outValue))
+	}
+	return taints
+}
+
+// PropagateTaintPoison spreads taint through a transaction using the
+// poison model: if any input is tainted, every output inherits the
+// highest input taint in full.
+func (tm TaintMap) PropagateTaintPoison(inputAddrs []string, inputValues []int64,
+	outputAddrs []string, outputValues []int64) {
+
+	if len(inputAddrs) != len(inputValues) || len(outputAddrs) != len(outputValues) {
+		return
+	}
+
+	maxTaint := 0.0
+	for _, addr := range inputAddrs {
+		maxTaint = math.Max(maxTaint, tm[addr])
+	}
+	if maxTaint <= 0 {
+		return
+	}
+	for _, addr := range outputAddrs {
+		tm.raiseTaint(addr, maxTaint)
+	}
+}
+
+// raiseTaint records taint for an address (never decreases, worst-case model)
+func (tm TaintMap) raiseTaint(addr string, taint float64) {
+	if current, exists := tm[addr]; !exists || taint > current {
+		tm[addr] = math.Min(1.0, taint)
+	}
+}
+
 // GetTaint returns the taint level for an address
 func (tm TaintMap) GetTaint(addr string) float64 {
 	return tm[addr]
@@ -122,15 +283,15 @@ func (tm TaintMap) GetTaint(addr string) float64 {
 // AssessRisk computes a risk assessment for a given taint level
 func AssessRisk(taintLevel float64, hops int) TaintResult {
 	result := TaintResult{
-		RiskScore:      math.Round(taintLevel*1000) / 1000,
+		RiskScore:      taintLevel,
 		HopsFromSource: hops,
-		TaintedRatio:   math.Round(taintLevel*100) / 100,
+		TaintedRatio:   taintLevel,
 	}
 
 	// Apply hop decay to risk score (farther = less risky)
 	if hops > 0 {
 		decayFactor := math.Pow(0.85, float64(hops-1))
-		result.RiskScore = math.Round(taintLevel*decayFactor*1000) / 1000
+		result.RiskScore = taintLevel * decayFactor
 	}
 
 	// Classify risk level
@@ -184,8 +345,8 @@ func ComputeTransactionRisk(tm TaintMap, inputAddrs []string, inputValues []int6
 	overallTaint := taintedValue / float64(totalValue)
 
 	result := TaintResult{
-		RiskScore:    math.Round(overallTaint*1000) / 1000,
-		TaintedRatio: math.Round(overallTaint*100) / 100,
+		RiskScore:    overallTaint,
+		TaintedRatio: overallTaint,
 		TaintSources: sources,
 	}
 	result.RiskLevel = classifyRisk(result.RiskScore)
@@ -0,0 +1,58 @@
+package heuristics
+
+import (
+	"math"
+	"testing"
+)
+
+// peelFromTheft spends a stolen 1 BTC coin (first input) together with a
+// clean 1 BTC coin into two outputs, paying 0.01 BTC fee
+func peelFromTheft() ([]string, []int64, []string, []int64) {
+	return []string{"theft", "clean"}, []int64{100_000_000, 100_000_000},
+		[]string{"first", "second"}, []int64{100_000_000, 99_000_000}
+}
+
+func TestPropagateTaint_ModelsDiffer(t *testing.T) {
+	inAddrs, inVals, outAddrs, outVals := peelFromTheft()
+
+	tests := []struct {
+		model         TaintModel
+		first, second float64
+	}{
+		// weighted 0.5 × output share (~0.50 / ~0.50)
+		{TaintModelHaircut, 0.5 * 100.0 / 199.0, 0.5 * 99.0 / 199.0},
+		// the stolen satoshis fill the first output exactly
+		{TaintModelFIFO, 1.0, 0},
+		// any tainted input taints everything
+		{TaintModelPoison, 1.0, 1.0},
+	}
+	for _, tt := range tests {
+		tm := NewTaintMap()
+		tm.SeedTaint([]TaintSource{{Address: "theft", TaintLevel: 1.0}})
+		tm.PropagateTaint(tt.model, inAddrs, inVals, outAddrs, outVals)
+
+		if got := tm.GetTaint("first"); math.Abs(got-tt.first) > 1e-9 {
+			t.Errorf("%s: expected first output taint %.4f. Got %.4f", tt.model, tt.first, got)
+		}
+		if got := tm.GetTaint("second"); math.Abs(got-tt.second) > 1e-9 {
+			t.Errorf("%s: expected second output taint %.4f. Got %.4f", tt.model, tt.second, got)
+		}
+	}
+}
+
+func TestFIFOOutputTaints_SplitsAcrossBoundary(t *testing.T) {
+	// Clean 0.3 then tainted 0.7 (level 0.5) into 0.5 + 0.49
+	got := fifoOutputTaints([]float64{0, 0.5}, []int64{30_000_000, 70_000_000}, []int64{50_000_000, 49_000_000})
+
+	// First output: 0.3 clean + 0.2 at 0.5 → 0.2; second: all at 0.5
+	if math.Abs(got[0]-0.2) > 1e-9 || math.Abs(got[1]-0.5) > 1e-9 {
+		t.Errorf("Expected [0.2 0.5]. Got %v", got)
+	}
+
+	if m, err := ParseTaintModel("fifo"); err != nil || m != TaintModelFIFO {
+		t.Errorf("Expected fifo to parse. Got %v, %v", m, err)
+	}
+	if _, err := ParseTaintModel("lifo"); err == nil {
+		t.Error("Expected an unknown model to be rejected")
+	}
+}
@@ -2,7 +2,7 @@ package heuristics
 
 import (
 	"log"
-	"strings"
+	"math"
 	"sync"
 
 	"github.com/rawblock/coinjoin-engine/pkg/models"
@@ -14,13 +14,25 @@ import (
 // Thread-safe taint state shared across the pipeline. Seeded from
 // investigation theft addresses and external intelligence feeds.
 // The poller and block scanner call CheckInputsForTaint() on every
-// analyzed transaction to set FlagHighRisk when tainted funds move.
+// analyzed transaction to set FlagHighRisk when tainted funds move,
+// and the block scanner calls PropagateGlobalTaint() on every confirmed
+// transaction so taint spreads hop by hop to downstream addresses.
+// With change tracking enabled, changed scores are drained to the
+// taint_scores table and restored on boot (RestoreTaintScores).
 // ──────────────────────────────────────────────────────────────────
 
+const (
+	minPropagatedTaint    = 0.01 // Output taint below this is not tracked (IsTainted threshold)
+	coinJoinTaintDiscount = 0.1  // Taint surviving a CoinJoin (unlinkable outputs)
+)
+
 var (
-	globalTaintMap TaintMap
-	taintMu        sync.RWMutex
-	taintInitOnce  sync.Once
+	globalTaintMap        TaintMap
+	globalTaintCategories map[string]string // addr → TaintSource.Category of the strongest seed
+	globalTaintHops       map[string]int    // addr → hops from the nearest seed (absent = seed)
+	globalTaintDirty      map[string]bool   // Addresses changed since the last drain (nil = tracking off)
+	taintMu               sync.RWMutex
+	taintInitOnce         sync.Once
 )
 
 // InitGlobalTaintMap initializes the singleton. Safe to call multiple times.
@@ -44,12 +56,14 @@ func SeedFromInvestigationAddresses(addresses []string) int {
 
 	seeded := 0
 	for _, addr := range addresses {
-		addr = strings.TrimSpace(addr)
+		addr = NormalizeAddress(addr)
 		if addr == "" {
 			continue
 		}
-		if _, exists := globalTaintMap[addr]; !exists {
+		if current, exists := globalTaintMap[addr]; !exists || current < 1.0 || globalTaintHops[addr] > 0 {
 			globalTaintMap[addr] = 1.0 // Full taint for known theft addresses
+			delete(globalTaintHops, addr)
+			markTaintDirty(addr)
 			seeded++
 		}
 	}
@@ -72,7 +86,7 @@ func SeedFromExternalIntel(sources []TaintSource) int {
 
 	seeded := 0
 	for _, src := range sources {
-		addr := strings.TrimSpace(src.Address)
+		addr := NormalizeAddress(src.Address)
 		if addr == "" {
 			continue
 		}
@@ -80,6 +94,14 @@ func SeedFromExternalIntel(sources []TaintSource) int {
 		current, exists := globalTaintMap[src.Address]
 		if !exists || src.TaintLevel > current {
 			globalTaintMap[src.Address] = src.TaintLevel
+			delete(globalTaintHops, src.Address)
+			markTaintDirty(src.Address)
+			if src.Category != "" {
+				if globalTaintCategories == nil {
+					globalTaintCategories = make(map[string]string)
+				}
+				globalTaintCategories[src.Address] = src.Category
+			}
 			seeded++
 		}
 	}
@@ -93,7 +115,8 @@ func SeedFromExternalIntel(sources []TaintSource) int {
 //
 // High-risk is triggered when:
 //   - weighted exposure >= 0.25 (material taint share), OR
-//   - any direct source taint >= 0.85 (near-certain sanctioned/theft source)
+//   - near-certain tainted funds (>= 0.85) pass intact into an output under
+//     FIFO allocation (a stolen coin forwarded, not diluted into a sweep)
 //
 // Called by AnalyzeTx and risk scoring paths to integrate taint into the pipeline.
 func CheckInputsForTaint(tx models.Transaction) (taintLevel float64, isHighRisk bool) {
@@ -106,10 +129,11 @@ func CheckInputsForTaint(tx models.Transaction) (taintLevel float64, isHighRisk
 
 	var totalIn int64
 	var weightedTaint float64
-	maxTaint := 0.0
+	inputTaints := make([]float64, 0, len(tx.Inputs))
+	inputValues := make([]int64, 0, len(tx.Inputs))
 
 	for _, input := range tx.Inputs {
-		addr := strings.TrimSpace(input.Address)
+		addr := NormalizeAddress(input.Address)
 		if addr == "" {
 			continue
 		}
@@ -118,20 +142,19 @@ func CheckInputsForTaint(tx models.Transaction) (taintLevel float64, isHighRisk
 		}
 
 		totalIn += input.Value
-
-		if taint, exists := globalTaintMap[addr]; exists {
-			weightedTaint += taint * float64(input.Value)
-			if taint > maxTaint {
-				maxTaint = taint
-			}
-		}
+		taint := globalTaintMap[addr]
+		weightedTaint += taint * float64(input.Value)
+		inputTaints = append(inputTaints, taint)
+		inputValues = append(inputValues, input.Value)
 	}
 
 	totalOut := int64(0)
+	outputValues := make([]int64, 0, len(tx.Outputs))
 	for _, out := range tx.Outputs {
 		if out.Value > 0 {
 			totalOut += out.Value
 		}
+		outputValues = append(outputValues, out.Value)
 	}
 
 	denom := totalIn
@@ -143,11 +166,262 @@ func CheckInputsForTaint(tx models.Transaction) (taintLevel float64, isHighRisk
 	}
 
 	exposure := weightedTaint / float64(denom)
-	isHigh := exposure >= 0.25 || maxTaint >= 0.85
+	isHigh := exposure >= 0.25
+	if !isHigh && weightedTaint > 0 {
+		for _, outTaint := range fifoOutputTaints(inputTaints, inputValues, outputValues) {
+			if outTaint >= 0.85 {
+				isHigh = true
+				break
+			}
+		}
+	}
 
 	return exposure, isHigh
 }
 
+// PropagateGlobalTaint spreads the global taint map through a confirmed
+// transaction under the haircut model, so downstream addresses accumulate
+// taint as blocks are processed. Each newly tainted (or more tainted)
+// output records one hop more than its nearest tainted input. CoinJoin
+// outputs keep only coinJoinTaintDiscount of the taint, and output taint
+// below minPropagatedTaint is dropped so dilution ends the trace instead
+// of growing the map without bound.
+//
+// Returns the number of output addresses whose taint was raised.
+func PropagateGlobalTaint(tx models.Transaction, isCoinJoin bool) int {
+	taintMu.Lock()
+	defer taintMu.Unlock()
+
+	if len(globalTaintMap) == 0 {
+		return 0
+	}
+
+	inputAddrs := make([]string, 0, len(tx.Inputs))
+	inputValues := make([]int64, 0, len(tx.Inputs))
+	scratch := NewTaintMap()
+	hops := -1
+	for _, in := range tx.Inputs {
+		addr := NormalizeAddress(in.Address)
+		if addr == "" || in.Value <= 0 {
+			continue
+		}
+		inputAddrs = append(inputAddrs, addr)
+		inputValues = append(inputValues, in.Value)
+		if taint := globalTaintMap[addr]; taint > 0 {
+			scratch[addr] = taint
+			if h := globalTaintHops[addr]; hops < 0 || h < hops {
+				hops = h
+			}
+		}
+	}
+	if hops < 0 {
+		return 0
+	}
+
+	outputAddrs := make([]string, len(tx.Outputs))
+	outputValues := make([]int64, len(tx.Outputs))
+	for i, out := range tx.Outputs {
+		outputAddrs[i] = NormalizeAddress(out.Address)
+		outputValues[i] = out.Value
+	}
+
+	// Outputs are computed in isolation so only this tx's contribution is compared
+	outputs := NewTaintMap()
+	for addr, taint := range scratch {
+		outputs[addr] = taint
+	}
+	outputs.PropagateTaintHaircut(inputAddrs, inputValues, outputAddrs, outputValues)
+
+	raised := 0
+	for _, addr := range outputAddrs {
+		if addr == "" {
+			continue
+		}
+		taint := outputs[addr]
+		if _, isInput := scratch[addr]; isInput {
+			continue // Change back to a tainted input address: nothing new reached
+		}
+		if isCoinJoin {
+			taint *= coinJoinTaintDiscount
+		}
+		if taint < minPropagatedTaint || taint <= globalTaintMap[addr] {
+			continue
+		}
+
+		_, known := globalTaintMap[addr]
+		globalTaintMap[addr] = taint
+		if globalTaintHops == nil {
+			globalTaintHops = make(map[string]int)
+		}
+		if h, ok := globalTaintHops[addr]; !known || (ok && hops+1 < h) {
+			globalTaintHops[addr] = hops + 1
+		}
+		markTaintDirty(addr)
+		raised++
+	}
+	return raised
+}
+
+// markTaintDirty queues addr for the next DrainTaintChanges. Caller holds taintMu.
+func markTaintDirty(addr string) {
+	if globalTaintDirty != nil {
+		globalTaintDirty[addr] = true
+	}
+}
+
+// EnableTaintChangeTracking makes the global taint map record changed
+// addresses for DrainTaintChanges. Leave it off when nothing drains, or
+// the change set grows without bound.
+func EnableTaintChangeTracking() {
+	taintMu.Lock()
+	defer taintMu.Unlock()
+	if globalTaintDirty == nil {
+		globalTaintDirty = make(map[string]bool)
+	}
+}
+
+// DrainTaintChanges returns the current scores of every address changed
+// since the last drain and clears the change set
+func DrainTaintChanges() []models.TaintScore {
+	taintMu.Lock()
+	defer taintMu.Unlock()
+
+	if len(globalTaintDirty) == 0 {
+		return nil
+	}
+	scores := make([]models.TaintScore, 0, len(globalTaintDirty))
+	for addr := range globalTaintDirty {
+		scores = append(scores, models.TaintScore{
+			Address:        addr,
+			TaintLevel:     globalTaintMap[addr],
+			Category:       globalTaintCategories[addr],
+			HopsFromSource: globalTaintHops[addr],
+		})
+	}
+	globalTaintDirty = make(map[string]bool)
+	return scores
+}
+
+// RequeueTaintChanges re-marks drained scores whose flush failed
+func RequeueTaintChanges(scores []models.TaintScore) {
+	taintMu.Lock()
+	defer taintMu.Unlock()
+	for _, score := range scores {
+		markTaintDirty(score.Address)
+	}
+}
+
+// RestoreTaintScores loads persisted scores into the global taint map,
+// keeping the higher taint (and shorter hop distance) where an address is
+// already tracked, e.g. freshly seeded from an investigation. Restored
+// scores are not marked changed. Returns the number of scores applied.
+func RestoreTaintScores(scores []models.TaintScore) int {
+	taintMu.Lock()
+	defer taintMu.Unlock()
+
+	if globalTaintMap == nil {
+		globalTaintMap = NewTaintMap()
+	}
+
+	restored := 0
+	for _, score := range scores {
+		addr := NormalizeAddress(score.Address)
+		if addr == "" || score.TaintLevel <= 0 {
+			continue
+		}
+		current, exists := globalTaintMap[addr]
+		if exists && score.HopsFromSource < globalTaintHops[addr] {
+			setTaintHops(addr, score.HopsFromSource)
+		}
+		if exists && current >= score.TaintLevel {
+			continue
+		}
+		globalTaintMap[addr] = math.Min(1.0, score.TaintLevel)
+		if !exists {
+			setTaintHops(addr, score.HopsFromSource)
+		}
+		if score.Category != "" {
+			if globalTaintCategories == nil {
+				globalTaintCategories = make(map[string]string)
+			}
+			globalTaintCategories[addr] = score.Category
+		}
+		restored++
+	}
+	return restored
+}
+
+// setTaintHops records a hop distance (0 = seed). Caller holds taintMu.
+func setTaintHops(addr string, hops int) {
+	if hops <= 0 {
+		delete(globalTaintHops, addr)
+		return
+	}
+	if globalTaintHops == nil {
+		globalTaintHops = make(map[string]int)
+	}
+	globalTaintHops[addr] = hops
+}
+
+// GlobalTaintRisk assesses an address against the global taint map,
+// including its hop distance from the nearest seed. A seeded address
+// lists itself as the contributing source.
+func GlobalTaintRisk(addr string) TaintResult {
+	taintMu.RLock()
+	defer taintMu.RUnlock()
+
+	addr = NormalizeAddress(addr)
+	taint := globalTaintMap[addr]
+	if taint <= 0 {
+		return TaintResult{RiskLevel: "clean"}
+	}
+	result := AssessRisk(taint, globalTaintHops[addr])
+	if result.HopsFromSource == 0 {
+		result.TaintSources = []TaintSource{{Address: addr, Category: globalTaintCategories[addr], TaintLevel: taint}}
+	}
+	return result
+}
+
+// GlobalTransactionTaintRisk assesses a transaction's inputs against the
+// global taint map (ComputeTransactionRisk), with each tainted input's
+// seed category and the hop distance of the nearest tainted input
+func GlobalTransactionTaintRisk(tx models.Transaction) TaintResult {
+	taintMu.RLock()
+	defer taintMu.RUnlock()
+
+	inputAddrs := make([]string, 0, len(tx.Inputs))
+	inputValues := make([]int64, 0, len(tx.Inputs))
+	for _, in := range tx.Inputs {
+		if addr := NormalizeAddress(in.Address); addr != "" {
+			inputAddrs = append(inputAddrs, addr)
+			inputValues = append(inputValues, in.Value)
+		}
+	}
+
+	result := ComputeTransactionRisk(globalTaintMap, inputAddrs, inputValues)
+	for i, src := range result.TaintSources {
+		result.TaintSources[i].Category = globalTaintCategories[src.Address]
+		if hops := globalTaintHops[src.Address]; i == 0 || hops < result.HopsFromSource {
+			result.HopsFromSource = hops
+		}
+	}
+	return result
+}
+
+// TaintCategoryOf returns the seeded category ("ransomware", "theft", ...)
+// and taint level of an address; category is "" for unseeded or
+// propagated taint
+func TaintCategoryOf(addr string) (string, float64) {
+	taintMu.RLock()
+	defer taintMu.RUnlock()
+
+	addr = NormalizeAddress(addr)
+	if globalTaintMap == nil || addr == "" {
+		return "", 0
+	}
+	return globalTaintCategories[addr], globalTaintMap[addr]
+}
+
 // GetGlobalTaintMapSize returns the current number of tracked tainted addresses
 func GetGlobalTaintMapSize() int {
 	taintMu.RLock()
@@ -12,6 +12,9 @@ func resetTaintMapForTest(entries map[string]float64) {
 	defer taintMu.Unlock()
 
 	globalTaintMap = NewTaintMap()
+	globalTaintCategories = nil
+	globalTaintHops = nil
+	globalTaintDirty = nil
 	for addr, level := range entries {
 		globalTaintMap[addr] = level
 	}
@@ -92,3 +95,106 @@ func TestScoreTransaction_TaintSignalsEscalateRisk(t *testing.T) {
 		t.Fatalf("expected severity to escalate beyond low, got %s", assessment.Severity)
 	}
 }
+
+func TestSeedFromExternalIntel_MixedCaseBech32(t *testing.T) {
+	resetTaintMapForTest(nil)
+	SeedFromExternalIntel([]TaintSource{{Address: "BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ", TaintLevel: 1.0}})
+
+	tx := models.Transaction{
+		Inputs:  []models.TxIn{{Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", Value: 100000}},
+		Outputs: []models.TxOut{{Value: 99000}},
+	}
+
+	exposure, highRisk := CheckInputsForTaint(tx)
+	if !highRisk || exposure < 0.99 {
+		t.Fatalf("expected uppercase seed to taint the lowercase on-chain input, got exposure=%.4f highRisk=%v", exposure, highRisk)
+	}
+}
+
+func TestPropagateGlobalTaint_MultiHop(t *testing.T) {
+	resetTaintMapForTest(nil)
+	SeedFromInvestigationAddresses([]string{"theft"})
+
+	// theft → hop1 → (hop2 + clean payee) → hop3, as the scanner sees them block by block
+	chain := []models.Transaction{
+		{Inputs: []models.TxIn{{Address: "theft", Value: 100_000}}, Outputs: []models.TxOut{{Address: "hop1", Value: 99_000}}},
+		{Inputs: []models.TxIn{{Address: "hop1", Value: 99_000}}, Outputs: []models.TxOut{{Address: "hop2", Value: 49_000}, {Address: "payee", Value: 49_000}}},
+		{Inputs: []models.TxIn{{Address: "hop2", Value: 49_000}}, Outputs: []models.TxOut{{Address: "hop3", Value: 48_000}}},
+	}
+	for _, tx := range chain {
+		if PropagateGlobalTaint(tx, false) == 0 {
+			t.Fatalf("expected taint to reach the outputs of %v", tx.Outputs)
+		}
+	}
+
+	for addr, hops := range map[string]int{"hop1": 1, "hop2": 2, "payee": 2, "hop3": 3} {
+		risk := GlobalTaintRisk(addr)
+		if risk.RiskScore <= 0 || risk.HopsFromSource != hops {
+			t.Errorf("%s: expected nonzero taint %d hops out. Got %+v", addr, hops, risk)
+		}
+	}
+	if risk := GlobalTaintRisk("theft"); risk.HopsFromSource != 0 || risk.RiskLevel != "critical" {
+		t.Errorf("Expected the seed itself at 0 hops. Got %+v", risk)
+	}
+}
+
+func TestPropagateGlobalTaint_CoinJoinDiscount(t *testing.T) {
+	resetTaintMapForTest(nil)
+	SeedFromInvestigationAddresses([]string{"theft"})
+
+	mix := models.Transaction{
+		Inputs: []models.TxIn{{Address: "theft", Value: 100_000}, {Address: "peer", Value: 100_000}},
+		Outputs: []models.TxOut{
+			{Address: "mixed1", Value: 99_000}, {Address: "mixed2", Value: 99_000},
+		},
+	}
+	PropagateGlobalTaint(mix, true)
+
+	// Haircut alone would leave 0.25 on each output; the CoinJoin keeps a tenth
+	got := GlobalTaintRisk("mixed1").RiskScore
+	if math.Abs(got-0.025) > 1e-9 {
+		t.Errorf("Expected CoinJoin-discounted taint 0.025. Got %.4f", got)
+	}
+
+	// A second mix dilutes it below the tracking floor
+	remix := models.Transaction{
+		Inputs:  []models.TxIn{{Address: "mixed1", Value: 99_000}, {Address: "peer2", Value: 99_000}},
+		Outputs: []models.TxOut{{Address: "remixed1", Value: 98_000}, {Address: "remixed2", Value: 98_000}},
+	}
+	if n := PropagateGlobalTaint(remix, true); n != 0 {
+		t.Errorf("Expected propagation to stop below the floor. Raised %d outputs", n)
+	}
+}
+
+func TestTaintScores_DrainAndRestore(t *testing.T) {
+	resetTaintMapForTest(nil)
+	EnableTaintChangeTracking()
+	SeedFromExternalIntel([]TaintSource{{Address: "theft", Category: "theft", TaintLevel: 1.0}})
+	PropagateGlobalTaint(models.Transaction{
+		Inputs:  []models.TxIn{{Address: "theft", Value: 100_000}},
+		Outputs: []models.TxOut{{Address: "hop1", Value: 99_000}},
+	}, false)
+
+	scores := DrainTaintChanges()
+	if len(scores) != 2 {
+		t.Fatalf("Expected the seed and the propagated address. Got %+v", scores)
+	}
+	if again := DrainTaintChanges(); len(again) != 0 {
+		t.Errorf("Expected the change set to clear. Got %+v", again)
+	}
+
+	// Restart: only investigation seeds are reloaded before the persisted scores
+	resetTaintMapForTest(nil)
+	SeedFromExternalIntel([]TaintSource{{Address: "theft", Category: "theft", TaintLevel: 1.0}})
+	if n := RestoreTaintScores(scores); n != 1 {
+		t.Errorf("Expected only the propagated score to be new. Restored %d", n)
+	}
+
+	risk := GlobalTaintRisk("hop1")
+	if risk.RiskScore != 1.0 || risk.HopsFromSource != 1 {
+		t.Errorf("Expected hop1 to keep its propagated taint at 1 hop. Got %+v", risk)
+	}
+	if category, _ := TaintCategoryOf("theft"); category != "theft" {
+		t.Errorf("Expected the seed category to survive. Got %q", category)
+	}
+}
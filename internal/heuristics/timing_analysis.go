@@ -1,8 +1,6 @@
 package heuristics
 
 import (
-	"math"
-
 	"github.com/rawblock/coinjoin-engine/pkg/models"
 )
 
@@ -145,21 +143,13 @@ type timingAnomaly struct {
 // specific temporal behaviors (batch payouts, coordinator rounds, bots).
 func detectTimingAnomalies(tx models.Transaction) timingAnomaly {
 	// Pattern 1: Batch payout (exchange)
-	// Exchanges send batched withdrawals: 1 input, many outputs (>10)
-	// All outputs are different values, often to different address types
-	if len(tx.Inputs) <= 3 && len(tx.Outputs) >= 10 {
-		// Check output diversity (exchanges pay to many different addresses)
-		addressTypes := make(map[string]int)
-		for _, out := range tx.Outputs {
-			addressTypes[detectAddressType(out.Address)]++
-		}
-		if len(addressTypes) >= 2 {
-			confidence := math.Min(0.9, 0.5+0.05*float64(len(tx.Outputs)))
-			return timingAnomaly{
-				detected:    true,
-				anomalyType: "batch_payout",
-				confidence:  confidence,
-			}
+	// Exchanges send batched withdrawals: few inputs, many outputs (>10)
+	// with diverse values and address types (see DetectBatchPayout)
+	if batch := DetectBatchPayout(tx); batch.IsBatch && batch.PayoutCount >= 10 {
+		return timingAnomaly{
+			detected:    true,
+			anomalyType: "batch_payout",
+			confidence:  batch.Confidence,
 		}
 	}
 
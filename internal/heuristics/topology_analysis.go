@@ -41,7 +41,7 @@ func AnalyzeTopology(tx models.Transaction) models.TopologyResult {
 	// 1. I/O Symmetry: 0 = perfectly symmetric, 1 = maximally asymmetric
 	maxIO := math.Max(float64(result.FanIn), float64(result.FanOut))
 	if maxIO > 0 {
-		result.IOSymmetry = math.Round(math.Abs(float64(result.FanIn)-float64(result.FanOut))*100/maxIO) / 100
+		result.IOSymmetry = math.Abs(float64(result.FanIn)-float64(result.FanOut)) / maxIO
 	}
 
 	// 2. Gini Coefficient of output values
@@ -101,7 +101,7 @@ func computeGiniCoefficient(outputs []models.TxOut) float64 {
 		gini = 1
 	}
 
-	return math.Round(gini*100) / 100
+	return gini
 }
 
 // classifyValueConcentration maps Gini to human-readable bands
@@ -165,8 +165,7 @@ func ComputeFanRatio(tx models.Transaction) float64 {
 	if len(tx.Inputs) == 0 {
 		return 0
 	}
-	ratio := float64(len(tx.Outputs)) / float64(len(tx.Inputs))
-	return math.Round(ratio*100) / 100
+	return float64(len(tx.Outputs)) / float64(len(tx.Inputs))
 }
 
 // ComputeValueFlow analyzes how value moves through the transaction.
@@ -187,5 +186,5 @@ func ComputeValueFlow(tx models.Transaction) float64 {
 		}
 	}
 
-	return math.Round(float64(maxOutput)*100/float64(totalInput)) / 100
+	return float64(maxOutput) / float64(totalInput)
 }
@@ -2,6 +2,7 @@ package heuristics
 
 import (
 	"math"
+	"time"
 
 	"github.com/rawblock/coinjoin-engine/pkg/models"
 )
@@ -25,36 +26,37 @@ import (
 //   - Glassnode Academy, "Coin Days Destroyed" (2020)
 //   - Bistarelli et al., "Analysis of Bitcoin Blockchain" (2018)
 
-// AnalyzeUTXOAge computes age statistics for input UTXOs.
-// Requires BlockTime on the transaction (from the spending block) and
-// estimated creation times for the input UTXOs. If block context is
-// unavailable, estimates are used based on heuristic block height.
+// AnalyzeUTXOAge computes age statistics for input UTXOs from the spent
+// outputs' confirmation (TxIn.PrevBlockTime/PrevBlockHeight, filled by the
+// prevout lookup). Age is the spread between the prevout's block time and
+// the spending block's time — or now, for a mempool spend. When block
+// times are missing, the height difference is used (144 blocks ≈ 1 day).
+// Inputs with no known confirmation (unconfirmed parents) are skipped.
 func AnalyzeUTXOAge(tx models.Transaction) models.UTXOAgeResult {
+	return analyzeUTXOAgeAt(tx, time.Now().Unix())
+}
+
+// analyzeUTXOAgeAt is AnalyzeUTXOAge with the mempool reference time injected
+func analyzeUTXOAgeAt(tx models.Transaction, now int64) models.UTXOAgeResult {
 	result := models.UTXOAgeResult{
 		HoldingPattern: "unknown",
 	}
 
-	// Cannot compute age without block time context
-	if tx.BlockTime <= 0 {
-		return result
+	refTime := tx.BlockTime
+	if refTime <= 0 {
+		refTime = now // Unconfirmed spend: it is happening now
 	}
 
-	// Estimate UTXO ages from block height difference
-	// Average block time ≈ 600 seconds (10 minutes)
-	// We use the spending tx's block height minus an estimated creation height
-	// derived from the input's txid (heuristic: first 4 hex chars → block offset)
 	ages := make([]float64, 0, len(tx.Inputs))
 	values := make([]int64, 0, len(tx.Inputs))
 
 	for _, in := range tx.Inputs {
-		// Estimate creation height from txid entropy
-		// This is a heuristic — in production, you'd look up the actual
-		// confirmation height from the UTXO index
-		estimatedAge := estimateInputAge(in, tx.BlockHeight)
-		if estimatedAge > 0 {
-			ages = append(ages, estimatedAge)
-			values = append(values, in.Value)
+		age, ok := inputAgeDays(in, refTime, tx.BlockHeight)
+		if !ok {
+			continue
 		}
+		ages = append(ages, age)
+		values = append(values, in.Value)
 	}
 
 	if len(ages) == 0 {
@@ -79,51 +81,25 @@ func AnalyzeUTXOAge(tx models.Transaction) models.UTXOAgeResult {
 		cdd += float64(values[i]) / 1e8 * age
 	}
 
-	result.AvgAgeDays = math.Round(totalAge*100/float64(len(ages))) / 100
-	result.CoinDaysDestroyed = math.Round(cdd*100) / 100
+	result.AvgAgeDays = totalAge / float64(len(ages))
+	result.CoinDaysDestroyed = cdd
 	result.HasAncientUTXO = result.MaxAgeDays > 365
 	result.HoldingPattern = classifyHoldingPattern(result.AvgAgeDays)
 
 	return result
 }
 
-// estimateInputAge estimates the age of an input UTXO in days.
-// In a production system, this would query the UTXO index for the
-// actual confirmation height. Here we use a deterministic heuristic
-// based on the input txid to produce consistent results.
-func estimateInputAge(in models.TxIn, spendingHeight int) float64 {
-	if spendingHeight <= 0 || len(in.Txid) < 8 {
-		return 0
+// inputAgeDays returns how long the spent output existed, in days.
+// Block timestamps may run up to ~2h out of order, so a slightly negative
+// spread (parent and child in near blocks) is clamped to 0.
+func inputAgeDays(in models.TxIn, refTime int64, spendingHeight int) (float64, bool) {
+	if in.PrevBlockTime > 0 && refTime > 0 {
+		return math.Max(0, float64(refTime-in.PrevBlockTime)/86400.0), true
 	}
-
-	// Derive a deterministic "creation height" from the txid
-	// Use first 4 hex characters as a block height offset
-	offset := 0
-	for i := 0; i < 8 && i < len(in.Txid); i++ {
-		c := in.Txid[i]
-		var val int
-		switch {
-		case c >= '0' && c <= '9':
-			val = int(c - '0')
-		case c >= 'a' && c <= 'f':
-			val = int(c-'a') + 10
-		case c >= 'A' && c <= 'F':
-			val = int(c-'A') + 10
-		}
-		offset = offset*16 + val
+	if in.PrevBlockHeight > 0 && spendingHeight >= in.PrevBlockHeight {
+		return float64(spendingHeight-in.PrevBlockHeight) / 144.0, true
 	}
-
-	// Clamp to a reasonable creation height
-	creationHeight := spendingHeight - (offset % spendingHeight)
-	if creationHeight < 0 {
-		creationHeight = 0
-	}
-
-	// Convert block difference to days (1 block ≈ 10 min = 1/144 day)
-	heightDiff := spendingHeight - creationHeight
-	ageDays := float64(heightDiff) / 144.0
-
-	return math.Max(0, ageDays)
+	return 0, false
 }
 
 // classifyHoldingPattern maps average UTXO age to entity behavior
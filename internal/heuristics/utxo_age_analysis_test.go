@@ -0,0 +1,68 @@
+package heuristics
+
+import (
+	"math"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+const secondsPerDay = 86400
+
+func TestAnalyzeUTXOAge_CoinDaysFromPrevoutBlockTime(t *testing.T) {
+	spendTime := int64(1_700_000_000)
+	tx := models.Transaction{
+		BlockHeight: 820_000,
+		BlockTime:   spendTime,
+		Inputs: []models.TxIn{
+			{Value: 200_000_000, PrevBlockTime: spendTime - 10*secondsPerDay},   // 2 BTC, 10 days
+			{Value: 50_000_000, PrevBlockTime: spendTime - 2_000*secondsPerDay}, // 0.5 BTC, ~5.5 years
+		},
+	}
+
+	got := AnalyzeUTXOAge(tx)
+	if math.Abs(got.CoinDaysDestroyed-(2*10+0.5*2000)) > 1e-9 {
+		t.Errorf("Expected CDD 1020. Got %f", got.CoinDaysDestroyed)
+	}
+	if got.MinAgeDays != 10 || got.MaxAgeDays != 2000 || got.AvgAgeDays != 1005 {
+		t.Errorf("Unexpected ages: min %.1f max %.1f avg %.1f", got.MinAgeDays, got.MaxAgeDays, got.AvgAgeDays)
+	}
+	if !got.HasAncientUTXO || got.HoldingPattern != "ancient" {
+		t.Errorf("Expected an ancient UTXO. Got %+v", got)
+	}
+}
+
+func TestAnalyzeUTXOAge_HeightFallbackAndUnconfirmedParents(t *testing.T) {
+	tx := models.Transaction{
+		BlockHeight: 820_000,
+		Inputs: []models.TxIn{
+			{Value: 100_000_000, PrevBlockHeight: 820_000 - 288}, // 2 days by height
+			{Value: 100_000_000}, // Unconfirmed parent: no age
+		},
+	}
+	got := analyzeUTXOAgeAt(tx, 0)
+	if got.AvgAgeDays != 2 || got.CoinDaysDestroyed != 2 || got.HasAncientUTXO {
+		t.Errorf("Expected one 2-day input by height. Got %+v", got)
+	}
+
+	// No confirmation data at all: no placeholder ages
+	none := AnalyzeUTXOAge(models.Transaction{
+		BlockHeight: 820_000,
+		BlockTime:   1_700_000_000,
+		Inputs:      []models.TxIn{{Txid: "ffffffff00000000", Value: 100_000_000}},
+	})
+	if none.HoldingPattern != "unknown" || none.CoinDaysDestroyed != 0 {
+		t.Errorf("Expected unknown age without prevout confirmation. Got %+v", none)
+	}
+}
+
+func TestAnalyzeUTXOAge_MempoolSpendUsesNow(t *testing.T) {
+	now := int64(1_700_000_000)
+	tx := models.Transaction{
+		Inputs: []models.TxIn{{Value: 100_000_000, PrevBlockTime: now - 400*secondsPerDay}},
+	}
+	got := analyzeUTXOAgeAt(tx, now)
+	if got.MaxAgeDays != 400 || !got.HasAncientUTXO {
+		t.Errorf("Expected a 400-day input for a mempool spend. Got %+v", got)
+	}
+}
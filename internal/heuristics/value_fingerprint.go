@@ -92,7 +92,7 @@ func AnalyzeValuePatterns(tx models.Transaction) models.ValuePatternResult {
 
 	// 5. Unique value ratio (what fraction of outputs are unique?)
 	if len(tx.Outputs) > 0 {
-		result.UniqueValueRatio = math.Round(float64(len(valueCounts))*100/float64(len(tx.Outputs))) / 100
+		result.UniqueValueRatio = float64(len(valueCounts)) / float64(len(tx.Outputs))
 	}
 
 	return result
@@ -169,7 +169,7 @@ func computeOutputShannon(outputs []models.TxOut) float64 {
 		}
 	}
 
-	return math.Round(entropy*100) / 100
+	return entropy
 }
 
 // DetectExchangeWithdrawal combines fee pattern + output value to
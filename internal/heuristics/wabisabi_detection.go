@@ -0,0 +1,153 @@
+package heuristics
+
+import (
+	"sort"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Wasabi 2.0 (WabiSabi) Round Validation
+//
+// WabiSabi rounds are not identified by "many equal outputs" alone: the
+// client decomposes every participant's amount onto a fixed ladder of
+// standard denominations, so almost every output of a genuine round sits
+// on that ladder and only change (and the coordinator fee) falls off it:
+//
+//   - Ladder: 2^n, 3^n, 2·3^n, 10^n, 2·10^n, 5·10^n sats within
+//     [wsMinOutput, wsMaxDenomination]
+//   - No output below wsMinOutput (smaller change is left to the miners)
+//   - Coordinator fee: historically 0.3% of inputs above the plebs-free
+//     threshold, paid to one off-ladder output; fee-free coordinators and
+//     all-remix rounds pay none
+//
+// A random many-output transaction (exchange batch, payout) almost never
+// lands most of its values on the ladder, so the share of ladder outputs
+// drives the confidence.
+//
+// References:
+//   - Ficsór et al., "WabiSabi: Centrally Coordinated CoinJoins with Variable
+//     Amounts" (2021)
+//   - zkSNACKs/WalletWasabi, DenominationBuilder / CoordinationFeeRate
+
+const (
+	wsMinOutput           = 5_000           // MinAllowedOutputAmount
+	wsMaxDenomination     = 137_438_953_472 // 2^37 sats, top of the ladder
+	wsCoordinatorRate     = 0.003           // Historical coordination fee rate
+	wsPlebsThreshold      = 1_000_000       // Inputs at or below pay no coordination fee
+	wsFeeTolerance        = 0.1             // Relative tolerance when matching the fee output
+	wsMinStandardShare    = 0.5             // Ladder share below which a round is rejected
+	wsFlagConfidence      = 0.6
+	wsInputsPerPerson     = 2 // Typical inputs registered per participant
+	wsMinInputs           = 5
+	wsMinOutputs          = 10
+	wsLargeRoundInputs    = 50
+	wsSpreadDenominations = 5 // Distinct denominations of a real decomposition
+)
+
+// wabiSabiDenominations is the standard denomination ladder
+var wabiSabiDenominations = buildWabiSabiDenominations()
+
+func buildWabiSabiDenominations() map[int64]bool {
+	ladder := make(map[int64]bool)
+	add := func(v int64) {
+		if v >= wsMinOutput && v <= wsMaxDenomination {
+			ladder[v] = true
+		}
+	}
+	for v := int64(1); v <= wsMaxDenomination; v *= 2 {
+		add(v)
+	}
+	for v := int64(1); v <= wsMaxDenomination; v *= 3 {
+		add(v)
+		add(2 * v)
+	}
+	for v := int64(1); v <= wsMaxDenomination; v *= 10 {
+		add(v)
+		add(2 * v)
+		add(5 * v)
+	}
+	return ladder
+}
+
+// IsWabiSabiDenomination reports whether sats is on the standard ladder
+func IsWabiSabiDenomination(sats int64) bool {
+	return wabiSabiDenominations[sats]
+}
+
+// DetectWabiSabi validates tx's outputs against the WabiSabi denomination
+// ladder and estimates participants and the coordinator fee
+func DetectWabiSabi(tx models.Transaction) models.WabiSabiResult {
+	res := models.WabiSabiResult{CoordinatorFeeIndex: -1}
+	if len(tx.Inputs) < wsMinInputs || len(tx.Outputs) < wsMinOutputs {
+		return res
+	}
+
+	counts := make(map[int64]int)
+	var offLadder []int
+	for i, out := range tx.Outputs {
+		if isOPReturn(out.ScriptPubKey) || out.Value < wsMinOutput {
+			return res // WabiSabi creates neither data nor sub-minimum outputs
+		}
+		if IsWabiSabiDenomination(out.Value) {
+			counts[out.Value]++
+			res.StandardOutputs++
+		} else {
+			offLadder = append(offLadder, i)
+		}
+	}
+	for denom, n := range counts {
+		res.Denominations = append(res.Denominations, denom)
+		if n >= 2 {
+			res.DenominationGroups++
+		}
+	}
+	sort.Slice(res.Denominations, func(i, j int) bool { return res.Denominations[i] < res.Denominations[j] })
+	res.ChangeOutputs = len(offLadder)
+
+	share := float64(res.StandardOutputs) / float64(len(tx.Outputs))
+	if share < wsMinStandardShare || res.DenominationGroups == 0 {
+		return res
+	}
+
+	// Coordinator fee: one off-ladder output close to the expected fee
+	var feeBase int64
+	for _, in := range tx.Inputs {
+		if in.Value > wsPlebsThreshold {
+			feeBase += in.Value
+		}
+	}
+	if expected := float64(feeBase) * wsCoordinatorRate; expected > 0 {
+		for _, i := range offLadder {
+			v := float64(tx.Outputs[i].Value)
+			if v >= expected*(1-wsFeeTolerance) && v <= expected*(1+wsFeeTolerance) {
+				res.CoordinatorFee = tx.Outputs[i].Value
+				res.CoordinatorFeeIndex = i
+				res.ChangeOutputs--
+				break
+			}
+		}
+	}
+
+	// Each participant leaves at most one change output; inputs bound it from below
+	res.EstimatedParticipants = max(res.ChangeOutputs, (len(tx.Inputs)+wsInputsPerPerson-1)/wsInputsPerPerson)
+
+	conf := 0.3 + 0.4*share
+	if res.DenominationGroups >= 3 {
+		conf += 0.1
+	}
+	if len(res.Denominations) >= wsSpreadDenominations {
+		conf += 0.05
+	}
+	if res.CoordinatorFeeIndex >= 0 {
+		conf += 0.1
+	}
+	if len(tx.Inputs) >= wsLargeRoundInputs {
+		conf += 0.05
+	}
+	if conf > 0.95 {
+		conf = 0.95
+	}
+	res.Confidence = conf
+	res.IsWabiSabi = conf >= wsFlagConfidence
+	return res
+}
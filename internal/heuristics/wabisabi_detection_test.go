@@ -0,0 +1,85 @@
+package heuristics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// ladderRound builds a round: 12 participants, each decomposed onto the
+// ladder (a 0.1 BTC and a 2^20 sats output apiece, plus some 3^11 and
+// 5·10^5 outputs), odd change for half of them and a 0.3% coordinator fee
+func ladderRound() models.Transaction {
+	tx := models.Transaction{Txid: "wabisabi"}
+	var totalIn int64
+	for i := 0; i < 24; i++ {
+		v := int64(6_000_000 + i*1_111)
+		totalIn += v
+		tx.Inputs = append(tx.Inputs, models.TxIn{Txid: fmt.Sprintf("prev%02d", i), Address: fmt.Sprintf("bc1qparticipant%02d", i), Value: v})
+	}
+	for i := 0; i < 12; i++ {
+		tx.Outputs = append(tx.Outputs,
+			models.TxOut{Address: fmt.Sprintf("bc1pden%02da", i), Value: 10_000_000},
+			models.TxOut{Address: fmt.Sprintf("bc1pden%02db", i), Value: 1_048_576},
+		)
+		if i%2 == 0 {
+			tx.Outputs = append(tx.Outputs, models.TxOut{Address: fmt.Sprintf("bc1pden%02dc", i), Value: 177_147})
+		} else {
+			tx.Outputs = append(tx.Outputs, models.TxOut{Address: fmt.Sprintf("bc1pden%02dc", i), Value: 500_000})
+		}
+	}
+	for i := 0; i < 6; i++ {
+		tx.Outputs = append(tx.Outputs, models.TxOut{Address: fmt.Sprintf("bc1qchange%02d", i), Value: int64(123_457 + i*7_919)})
+	}
+	tx.Outputs = append(tx.Outputs, models.TxOut{Address: "bc1qcoordinator", Value: int64(float64(totalIn) * 0.003)})
+	tx.Vsize = 3_500
+	tx.Fee = 35_000
+	return tx
+}
+
+func TestDetectWabiSabi_GenuineRound(t *testing.T) {
+	tx := ladderRound()
+	ws := DetectWabiSabi(tx)
+	if !ws.IsWabiSabi || ws.Confidence < 0.8 {
+		t.Fatalf("Expected a high-confidence WabiSabi round. Got %+v", ws)
+	}
+	if ws.DenominationGroups != 4 || len(ws.Denominations) != 4 || ws.Denominations[0] != 177_147 {
+		t.Errorf("Expected the 4 ladder denominations validated. Got %+v", ws)
+	}
+	if ws.CoordinatorFeeIndex != len(tx.Outputs)-1 || ws.ChangeOutputs != 6 || ws.EstimatedParticipants != 12 {
+		t.Errorf("Expected the coordinator fee output, 6 change outputs and 12 participants. Got %+v", ws)
+	}
+
+	res := AnalyzeTx(tx)
+	if res.HeuristicFlags&FlagIsWasabiSuspect == 0 || res.WabiSabi == nil {
+		t.Errorf("Expected AnalyzeTx to flag the round. Got flags %d", res.HeuristicFlags)
+	}
+}
+
+func TestDetectWabiSabi_RejectsRandomBatch(t *testing.T) {
+	tx := models.Transaction{Txid: "batch"}
+	for i := 0; i < 8; i++ {
+		tx.Inputs = append(tx.Inputs, models.TxIn{Address: fmt.Sprintf("bc1qexchange%02d", i), Value: 90_000_000})
+	}
+	for i := 0; i < 60; i++ {
+		// Repeated but off-ladder amounts: equal groups alone are not WabiSabi
+		tx.Outputs = append(tx.Outputs, models.TxOut{Address: fmt.Sprintf("bc1qwithdrawal%02d", i), Value: int64(1_234_567 + (i%20)*10_007)})
+	}
+	if ws := DetectWabiSabi(tx); ws.IsWabiSabi || ws.StandardOutputs != 0 {
+		t.Errorf("Expected a random batch rejected. Got %+v", ws)
+	}
+}
+
+func TestIsWabiSabiDenomination(t *testing.T) {
+	for _, v := range []int64{5_000, 6_561, 8_192, 13_122, 100_000, 200_000, 500_000, 1_048_576, 137_438_953_472} {
+		if !IsWabiSabiDenomination(v) {
+			t.Errorf("Expected %d on the ladder", v)
+		}
+	}
+	for _, v := range []int64{4_096, 7_000, 123_456, 300_000} {
+		if IsWabiSabiDenomination(v) {
+			t.Errorf("Expected %d off the ladder", v)
+		}
+	}
+}
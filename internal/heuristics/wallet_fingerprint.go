@@ -29,6 +29,8 @@ type WhirlpoolPoolInfo struct {
 	NumParticipants int    `json:"numParticipants"`
 	IsSurge         bool   `json:"isSurge"`        // Surge cycle (>5 participants)
 	CoordinatorFee  int64  `json:"coordinatorFee"` // Detected SC fee output
+	IsTx0           bool   `json:"isTx0"`          // Premix funding tx (Tx0), not a mix round
+	RemixCount      int    `json:"remixCount"`     // Inputs already at the pool denomination (postmix remixes)
 }
 
 // Whirlpool standard pool denominations (in satoshis)
@@ -39,6 +41,15 @@ var whirlpoolPools = map[string]int64{
 	"0.001btc": 100000,   // 0.001 BTC
 }
 
+// Whirlpool coordinator (SC) fees charged in Tx0, per pool (in satoshis).
+// An SCODE promo code may discount this by up to 50%.
+var whirlpoolPoolFees = map[string]int64{
+	"0.5btc":   1750000,
+	"0.05btc":  175000,
+	"0.01btc":  50000,
+	"0.001btc": 5000,
+}
+
 // DetectWalletFingerprint analyzes structural transaction properties to
 // identify which wallet software likely created it.
 //
@@ -265,6 +276,84 @@ func IdentifyWhirlpoolPool(tx models.Transaction) *WhirlpoolPoolInfo {
 				}
 			}
 
+			// Remixers enter with a postmix UTXO at exactly the denomination;
+			// new entrants bring a premix UTXO carrying the mining fee premium
+			for _, in := range tx.Inputs {
+				if in.Value == dominantValue {
+					info.RemixCount++
+				}
+			}
+
+			return info
+		}
+	}
+
+	return nil
+}
+
+// DetectTx0 reports whether a transaction is a Samourai Whirlpool Tx0
+func DetectTx0(tx models.Transaction) bool {
+	return IdentifyTx0Pool(tx) != nil
+}
+
+// IdentifyTx0Pool detects a Whirlpool Tx0: the premix transaction that
+// splits a deposit into pool-sized UTXOs before the first mix round.
+//
+// Tx0 structure:
+//   - OP_RETURN carrying the encrypted fee payload (SCODE / fee indice)
+//   - 1..70 equal premix outputs = pool denomination + mix mining fee premium
+//   - Coordinator (SC) fee output at the pool fee, or SCODE-discounted
+//   - Optional "doxxic" change output (toxic change, never mixed)
+func IdentifyTx0Pool(tx models.Transaction) *WhirlpoolPoolInfo {
+	if len(tx.Outputs) < 3 {
+		return nil
+	}
+
+	hasOPReturn := false
+	valueCounts := make(map[int64]int)
+	for _, out := range tx.Outputs {
+		if isOPReturn(out.ScriptPubKey) {
+			hasOPReturn = true
+			continue
+		}
+		if out.Value > 0 {
+			valueCounts[out.Value]++
+		}
+	}
+	if !hasOPReturn {
+		return nil
+	}
+
+	// Find the most repeated premix-sized value
+	var info *WhirlpoolPoolInfo
+	for val, count := range valueCounts {
+		for poolID, poolDenom := range whirlpoolPools {
+			if !isPremixValue(val, poolDenom) {
+				continue
+			}
+			if info == nil || count > info.NumParticipants {
+				info = &WhirlpoolPoolInfo{
+					PoolID:          poolID,
+					DenomSats:       poolDenom,
+					NumParticipants: count, // Premix UTXOs created
+					IsTx0:           true,
+				}
+			}
+		}
+	}
+	if info == nil {
+		return nil
+	}
+
+	// The coordinator fee output distinguishes Tx0 from an ordinary
+	// payment that happens to include an OP_RETURN
+	poolFee := whirlpoolPoolFees[info.PoolID]
+	for _, out := range tx.Outputs {
+		if isOPReturn(out.ScriptPubKey) || isPremixValue(out.Value, info.DenomSats) {
+			continue
+		}
+		if out.Value >= poolFee/2 && out.Value <= poolFee {
+			info.CoordinatorFee = out.Value
 			return info
 		}
 	}
@@ -272,6 +361,16 @@ func IdentifyWhirlpoolPool(tx models.Transaction) *WhirlpoolPoolInfo {
 	return nil
 }
 
+// isPremixValue reports whether a value is a premix UTXO for the pool:
+// the denomination plus a mining fee premium for the first mix.
+func isPremixValue(value, poolDenom int64) bool {
+	maxPremium := poolDenom / 100
+	if maxPremium < 10000 {
+		maxPremium = 10000 // Small pools: premium is dominated by the fee rate
+	}
+	return value > poolDenom && value <= poolDenom+maxPremium
+}
+
 // checkBIP69Ordering verifies if inputs and outputs follow BIP69 lexicographic ordering.
 // BIP69: Inputs sorted by (txid ASC, vout ASC), outputs sorted by (value ASC, scriptPubKey ASC).
 func checkBIP69Ordering(tx models.Transaction) bool {
@@ -0,0 +1,82 @@
+package heuristics
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// sampleTx0 builds a 0.01btc pool Tx0: deposit → 4 premix + SC fee + change + OP_RETURN
+func sampleTx0() models.Transaction {
+	tx := models.Transaction{
+		Txid:   "tx0",
+		Inputs: []models.TxIn{{Address: "bc1qdeposit", Value: 4_500_000}},
+	}
+	for i := 0; i < 4; i++ {
+		tx.Outputs = append(tx.Outputs, models.TxOut{Address: fmt.Sprintf("bc1qpremix%d", i), Value: 1_000_170})
+	}
+	tx.Outputs = append(tx.Outputs,
+		models.TxOut{Address: "bc1qcoordinator", Value: 50_000},
+		models.TxOut{Address: "bc1qdoxxic", Value: 448_000},
+		models.TxOut{ScriptPubKey: "6a2e" + "00112233445566778899aabbccddeeff00112233445566778899aabbccddeeff0011223344556677889900aa"},
+	)
+	return tx
+}
+
+func TestIdentifyTx0Pool(t *testing.T) {
+	info := IdentifyTx0Pool(sampleTx0())
+	if info == nil {
+		t.Fatal("Expected Tx0 to be detected")
+	}
+	if !info.IsTx0 || info.PoolID != "0.01btc" {
+		t.Errorf("Expected 0.01btc Tx0. Got %+v", info)
+	}
+	if info.NumParticipants != 4 || info.CoordinatorFee != 50_000 {
+		t.Errorf("Expected 4 premix outputs and 50000 sat SC fee. Got %+v", info)
+	}
+}
+
+func TestDetectTx0_RequiresOPReturnAndFee(t *testing.T) {
+	noMarker := sampleTx0()
+	noMarker.Outputs = noMarker.Outputs[:len(noMarker.Outputs)-1]
+	if DetectTx0(noMarker) {
+		t.Error("Expected tx without OP_RETURN not to be a Tx0")
+	}
+
+	noFee := sampleTx0()
+	noFee.Outputs[4].Value = 12_345
+	if DetectTx0(noFee) {
+		t.Error("Expected tx without a coordinator fee output not to be a Tx0")
+	}
+}
+
+func TestIdentifyWhirlpoolPool_RemixCount(t *testing.T) {
+	mix := models.Transaction{Txid: "mix"}
+	for i := 0; i < 5; i++ {
+		value := int64(1_000_170) // Premix entrant
+		if i < 2 {
+			value = 1_000_000 // Postmix remixer
+		}
+		mix.Inputs = append(mix.Inputs, models.TxIn{Address: fmt.Sprintf("bc1qin%d", i), Value: value})
+		mix.Outputs = append(mix.Outputs, models.TxOut{Address: fmt.Sprintf("bc1qout%d", i), Value: 1_000_000})
+	}
+
+	info := IdentifyWhirlpoolPool(mix)
+	if info == nil || info.IsTx0 {
+		t.Fatalf("Expected a mix round. Got %+v", info)
+	}
+	if info.RemixCount != 2 {
+		t.Errorf("Expected 2 remixing inputs. Got %d", info.RemixCount)
+	}
+	if DetectTx0(mix) {
+		t.Error("Expected a mix round not to be labeled Tx0")
+	}
+}
+
+func TestAnalyzeTx_LabelsTx0(t *testing.T) {
+	res := AnalyzeTx(sampleTx0())
+	if res.WhirlpoolStage != "tx0" || res.WhirlpoolPool != "0.01btc" {
+		t.Errorf("Expected Tx0 label for 0.01btc pool. Got stage=%q pool=%q", res.WhirlpoolStage, res.WhirlpoolPool)
+	}
+}
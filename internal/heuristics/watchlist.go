@@ -43,38 +43,22 @@ func (w *WatchListMonitor) Evaluate(tx models.Transaction) uint64 {
 }
 
 // detectSilentPayments (BIP352)
-// Silent payments use non-interactive Diffie-Hellman to generate unique outputs.
-// Signatures: Taproot outputs where the sender has directly tweaked the public key.
-// Without the scan key, it's indistinguishable from random Taproot outputs, but
-// we may flag massive 1-in-N-out Taproot consolidation sweeping as suspicious.
+// Silent payments pay fresh Taproot outputs derived per sender, which breaks
+// output-address scanning. Ordinary Taproot payments share the same outputs,
+// so this defers to the conservative DetectSilentPaymentSuspect shape.
 func (w *WatchListMonitor) detectSilentPayments(tx models.Transaction) uint64 {
-	// Stub: Actual detection requires tracking output distribution entropies.
-	// If a transaction creates numerous distinct Taproot outputs that are never reused.
-	if len(tx.Inputs) == 1 && len(tx.Outputs) >= 5 {
-		allTaproot := true
-		for _, out := range tx.Outputs {
-			if detectAddressType(out.Address) != "taproot" {
-				allTaproot = false
-				break
-			}
-		}
-		if allTaproot {
-			return FlagIsSilentPayment
-		}
+	if DetectSilentPaymentSuspect(tx).IsSuspect {
+		return FlagIsSilentPayment
 	}
 	return 0
 }
 
 // detectPayJoinV3 (BIP77)
 // Async PayJoin breaks the assumption that all inputs belong to the sender.
-// Signature: 2-input, 2-output transaction where outputs perfectly match input sums
-// (or one matches one input, effectively making it a zero-fee transfer conceptually).
+// BIP77 produces the same on-chain shape as BIP78, so this defers to DetectPayJoin.
 func (w *WatchListMonitor) detectPayJoinV3(tx models.Transaction) uint64 {
-	if len(tx.Inputs) == 2 && len(tx.Outputs) == 2 {
-		// Heuristic PayJoin detector is already in ssmp.go, but we formalize it here.
-		if tx.Outputs[0].Value == tx.Inputs[0].Value || tx.Outputs[1].Value == tx.Inputs[1].Value {
-			return FlagIsPayjoinSuspect
-		}
+	if DetectPayJoin(tx).IsPayJoin {
+		return FlagIsPayjoinSuspect
 	}
 	return 0
 }
@@ -0,0 +1,164 @@
+package heuristics
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Webhook Payload Formats
+//
+// Chat and paging services reject (or render as an unreadable blob) a raw
+// Alert JSON body. Each WebhookEndpoint.Format selects the shape its
+// receiver expects:
+//
+//   - raw:       the Alert itself (SIEM / custom consumers)
+//   - slack:     {"text": ...} plus a severity-colored attachment
+//   - discord:   {"content": ...} plus a severity-colored embed
+//   - pagerduty: Events API v2 trigger, severity mapped to its enum and
+//     dedup_key set from the alert ID so repeats update one incident
+//
+// References:
+//   - Slack, "Sending messages using incoming webhooks"
+//   - Discord, "Execute Webhook" (Developer Portal)
+//   - PagerDuty, "Events API v2 Overview"
+
+const (
+	WebhookFormatRaw       = "raw"
+	WebhookFormatSlack     = "slack"
+	WebhookFormatDiscord   = "discord"
+	WebhookFormatPagerDuty = "pagerduty"
+)
+
+// severityColors are the attachment/embed colors per alert severity
+var severityColors = map[string]int{
+	"info":     0x439FE0,
+	"low":      0x2EB886,
+	"medium":   0xDAA038,
+	"high":     0xE8730C,
+	"critical": 0xD40E0D,
+}
+
+// pagerDutySeverity maps alert severity to the Events API enum
+var pagerDutySeverity = map[string]string{
+	"info":     "info",
+	"low":      "info",
+	"medium":   "warning",
+	"high":     "error",
+	"critical": "critical",
+}
+
+// IsValidWebhookFormat reports whether f is a supported payload format
+// (empty means raw)
+func IsValidWebhookFormat(f string) bool {
+	switch f {
+	case "", WebhookFormatRaw, WebhookFormatSlack, WebhookFormatDiscord, WebhookFormatPagerDuty:
+		return true
+	}
+	return false
+}
+
+// formatWebhookPayload renders an alert in the endpoint's format
+func formatWebhookPayload(wh WebhookEndpoint, alert Alert) ([]byte, error) {
+	switch wh.Format {
+	case "", WebhookFormatRaw:
+		return json.Marshal(alert)
+	case WebhookFormatSlack:
+		return json.Marshal(slackPayload(alert))
+	case WebhookFormatDiscord:
+		return json.Marshal(discordPayload(alert))
+	case WebhookFormatPagerDuty:
+		return json.Marshal(pagerDutyPayload(wh.RoutingKey, alert))
+	default:
+		return nil, fmt.Errorf("unknown webhook format %q", wh.Format)
+	}
+}
+
+// alertHeadline is the one-line summary shared by the chat formats
+func alertHeadline(alert Alert) string {
+	return fmt.Sprintf("[%s] %s", strings.ToUpper(alert.Severity), alert.Title)
+}
+
+func slackPayload(alert Alert) map[string]any {
+	fields := []map[string]any{
+		{"title": "Severity", "value": alert.Severity, "short": true},
+		{"title": "Type", "value": alert.AlertType, "short": true},
+	}
+	if alert.TxID != "" {
+		fields = append(fields, map[string]any{"title": "Transaction", "value": alert.TxID, "short": false})
+	}
+	if alert.Value > 0 {
+		fields = append(fields, map[string]any{"title": "Value", "value": formatBTC(alert.Value), "short": true})
+	}
+	return map[string]any{
+		"text": alertHeadline(alert),
+		"attachments": []map[string]any{{
+			"color":    fmt.Sprintf("#%06X", severityColors[alert.Severity]),
+			"title":    alert.Title,
+			"text":     alert.Description,
+			"fields":   fields,
+			"fallback": alertHeadline(alert),
+			"ts":       alert.Timestamp.Unix(),
+		}},
+	}
+}
+
+func discordPayload(alert Alert) map[string]any {
+	fields := []map[string]any{
+		{"name": "Severity", "value": alert.Severity, "inline": true},
+		{"name": "Type", "value": alert.AlertType, "inline": true},
+	}
+	if alert.TxID != "" {
+		fields = append(fields, map[string]any{"name": "Transaction", "value": alert.TxID, "inline": false})
+	}
+	if alert.Value > 0 {
+		fields = append(fields, map[string]any{"name": "Value", "value": formatBTC(alert.Value), "inline": true})
+	}
+	return map[string]any{
+		"content": "**" + alertHeadline(alert) + "**",
+		"embeds": []map[string]any{{
+			"title":       alert.Title,
+			"description": alert.Description,
+			"color":       severityColors[alert.Severity],
+			"fields":      fields,
+			"timestamp":   alert.Timestamp.UTC().Format(time.RFC3339),
+		}},
+	}
+}
+
+func pagerDutyPayload(routingKey string, alert Alert) map[string]any {
+	severity, ok := pagerDutySeverity[alert.Severity]
+	if !ok {
+		severity = "info"
+	}
+	details := map[string]any{
+		"alertType":   alert.AlertType,
+		"description": alert.Description,
+	}
+	if alert.TxID != "" {
+		details["txid"] = alert.TxID
+	}
+	if alert.Value > 0 {
+		details["valueSats"] = alert.Value
+	}
+	return map[string]any{
+		"routing_key":  routingKey,
+		"event_action": "trigger",
+		"dedup_key":    alert.ID,
+		"payload": map[string]any{
+			"summary":        alertHeadline(alert),
+			"source":         "coinjoin-engine",
+			"severity":       severity,
+			"timestamp":      alert.Timestamp.UTC().Format(time.RFC3339),
+			"component":      "alert-system",
+			"class":          alert.AlertType,
+			"custom_details": details,
+		},
+	}
+}
+
+// formatBTC renders sats as a BTC amount
+func formatBTC(sats int64) string {
+	return fmt.Sprintf("%.8f BTC", float64(sats)/1e8)
+}
@@ -0,0 +1,99 @@
+package heuristics
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleAlert() Alert {
+	return Alert{
+		ID:          "high-watchlist_funds_moving-txabc",
+		Timestamp:   time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Severity:    "high",
+		AlertType:   "watchlist_funds_moving",
+		Title:       "Watchlisted funds moving",
+		Description: "Theft wallet spent 0.2 BTC",
+		TxID:        "txabc",
+		Value:       20_000_000,
+	}
+}
+
+func decodePayload(t *testing.T, wh WebhookEndpoint) map[string]any {
+	t.Helper()
+	raw, err := formatWebhookPayload(wh, sampleAlert())
+	if err != nil {
+		t.Fatalf("format %q failed: %v", wh.Format, err)
+	}
+	var out map[string]any
+	if err := json.Unmarshal(raw, &out); err != nil {
+		t.Fatalf("format %q produced invalid JSON: %v", wh.Format, err)
+	}
+	return out
+}
+
+func TestFormatWebhookPayload_ChatFormats(t *testing.T) {
+	slack := decodePayload(t, WebhookEndpoint{Format: WebhookFormatSlack})
+	if slack["text"] != "[HIGH] Watchlisted funds moving" {
+		t.Errorf("Unexpected Slack text: %v", slack["text"])
+	}
+	attachment := slack["attachments"].([]any)[0].(map[string]any)
+	if attachment["color"] != "#E8730C" || attachment["text"] != "Theft wallet spent 0.2 BTC" {
+		t.Errorf("Expected a severity-colored attachment. Got %v", attachment)
+	}
+
+	discord := decodePayload(t, WebhookEndpoint{Format: WebhookFormatDiscord})
+	if !strings.Contains(discord["content"].(string), "Watchlisted funds moving") {
+		t.Errorf("Unexpected Discord content: %v", discord["content"])
+	}
+	embed := discord["embeds"].([]any)[0].(map[string]any)
+	if embed["color"].(float64) != 0xE8730C || embed["timestamp"] != "2026-03-01T12:00:00Z" {
+		t.Errorf("Unexpected Discord embed: %v", embed)
+	}
+
+	raw := decodePayload(t, WebhookEndpoint{})
+	if raw["alertType"] != "watchlist_funds_moving" || raw["txid"] != "txabc" {
+		t.Errorf("Expected the raw Alert JSON by default. Got %v", raw)
+	}
+
+	if _, err := formatWebhookPayload(WebhookEndpoint{Format: "teams"}, sampleAlert()); err == nil {
+		t.Error("Expected an unknown format to be rejected")
+	}
+}
+
+func TestFormatWebhookPayload_PagerDuty(t *testing.T) {
+	pd := decodePayload(t, WebhookEndpoint{Format: WebhookFormatPagerDuty, RoutingKey: "R0UT1NG"})
+	if pd["routing_key"] != "R0UT1NG" || pd["event_action"] != "trigger" || pd["dedup_key"] != "high-watchlist_funds_moving-txabc" {
+		t.Errorf("Unexpected PagerDuty envelope: %v", pd)
+	}
+	payload := pd["payload"].(map[string]any)
+	if payload["severity"] != "error" || payload["summary"] != "[HIGH] Watchlisted funds moving" {
+		t.Errorf("Expected high → error. Got %v", payload)
+	}
+}
+
+func TestSendWebhook_SlackFormatDelivered(t *testing.T) {
+	bodies := make(chan string, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies <- string(b)
+	}))
+	defer receiver.Close()
+
+	am := NewAlertManager(nil)
+	am.RegisterWebhookEndpoint(WebhookEndpoint{Name: "slack", URL: receiver.URL, MinSeverity: "high", Format: WebhookFormatSlack})
+	am.EmitAlert(sampleAlert())
+
+	select {
+	case body := <-bodies:
+		if !strings.HasPrefix(body, `{"attachments"`) || !strings.Contains(body, `"text":"[HIGH] Watchlisted funds moving"`) {
+			t.Errorf("Expected a Slack message, not the raw alert. Got %s", body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected the alert to be delivered")
+	}
+}
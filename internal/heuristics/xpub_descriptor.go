@@ -0,0 +1,280 @@
+package heuristics
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// Extended-Key Watch Descriptors
+//
+// Investigators usually hold a whole wallet, not a single address: an
+// xpub seized from a device or an output descriptor from a wallet export.
+// A watch descriptor derives that wallet's addresses so the watchlist can
+// match any of them:
+//
+//   - Bare extended public keys: the SLIP-132 version picks the script
+//     (xpub/tpub → P2PKH, ypub/upub → P2SH-P2WPKH, zpub/vpub → P2WPKH) and
+//     both the receive (0) and change (1) branches are derived
+//   - Descriptors: pkh(), wpkh(), sh(wpkh()) and tr() over one key with an
+//     optional [origin], unhardened path, <a;b> multipath and trailing /*
+//
+// Ranged descriptors derive indices [0, gap) per branch; the watchlist
+// slides the window forward when a derived address is seen on-chain, as
+// a wallet's own gap-limit scan would.
+//
+// References:
+//   - BIP32: "Hierarchical Deterministic Wallets"
+//   - BIP380/381/382/386: Output Script Descriptors
+//   - BIP389: Multipath descriptor key expressions
+//   - SLIP-0132: Registered HD version bytes
+
+// defaultXpubGapLimit is the BIP44 address gap limit
+const defaultXpubGapLimit = 20
+
+// maxXpubGapLimit bounds derivation work per descriptor branch
+const maxXpubGapLimit = 10_000
+
+var xpubGapLimit atomic.Int64
+
+func init() {
+	xpubGapLimit.Store(defaultXpubGapLimit)
+}
+
+// SetXpubGapLimit sets how many addresses past the last seen index are
+// derived per descriptor branch (WATCHLIST_XPUB_GAP_LIMIT)
+func SetXpubGapLimit(n int) {
+	if n < 1 {
+		n = 1
+	}
+	if n > maxXpubGapLimit {
+		n = maxXpubGapLimit
+	}
+	xpubGapLimit.Store(int64(n))
+}
+
+// XpubGapLimit returns the current per-branch gap limit
+func XpubGapLimit() uint32 {
+	return uint32(xpubGapLimit.Load())
+}
+
+// extKeyVersion maps SLIP-132 public version bytes to script type and network
+type extKeyVersion struct {
+	script string
+	net    *chaincfg.Params
+}
+
+var extKeyVersions = map[string]extKeyVersion{
+	"0488b21e": {"pkh", &chaincfg.MainNetParams},      // xpub
+	"049d7cb2": {"sh-wpkh", &chaincfg.MainNetParams},  // ypub
+	"04b24746": {"wpkh", &chaincfg.MainNetParams},     // zpub
+	"043587cf": {"pkh", &chaincfg.TestNet3Params},     // tpub
+	"044a5262": {"sh-wpkh", &chaincfg.TestNet3Params}, // upub
+	"045f1cf6": {"wpkh", &chaincfg.TestNet3Params},    // vpub
+}
+
+// extKeyPrefixes are the base58 prefixes of serialized extended keys
+var extKeyPrefixes = []string{"xpub", "ypub", "zpub", "tpub", "upub", "vpub", "xprv", "yprv", "zprv", "tprv", "uprv", "vprv"}
+
+// watchDescriptor is a parsed single-key descriptor
+type watchDescriptor struct {
+	source   string                    // Descriptor as given (checksum stripped)
+	script   string                    // "pkh"/"wpkh"/"sh-wpkh"/"tr"
+	net      *chaincfg.Params          // Address encoding network
+	branches []*hdkeychain.ExtendedKey // Key at each branch (multipath expanded)
+	paths    []string                  // Branch path relative to the key, for hits
+	ranged   bool                      // Trailing /*: derive child indices per branch
+}
+
+// IsWatchDescriptor reports whether s is an output descriptor or extended key
+// rather than a plain address
+func IsWatchDescriptor(s string) bool {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, "(") {
+		return true
+	}
+	if len(s) < 100 {
+		return false // Serialized extended keys are 111 base58 characters
+	}
+	for _, prefix := range extKeyPrefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWatchDescriptor parses a bare extended public key or a single-key descriptor
+func parseWatchDescriptor(s string) (*watchDescriptor, error) {
+	s = strings.TrimSpace(s)
+	if i := strings.IndexByte(s, '#'); i >= 0 {
+		s = s[:i] // Checksum is not needed to derive
+	}
+	d := &watchDescriptor{source: s}
+
+	body := s
+	explicit := ""
+	for _, w := range []struct{ open, script string }{
+		{"sh(wpkh(", "sh-wpkh"}, {"wpkh(", "wpkh"}, {"pkh(", "pkh"}, {"tr(", "tr"},
+	} {
+		if strings.HasPrefix(body, w.open) {
+			closing := strings.Repeat(")", strings.Count(w.open, "("))
+			if !strings.HasSuffix(body, closing) {
+				return nil, fmt.Errorf("unbalanced descriptor %q", s)
+			}
+			body = body[len(w.open) : len(body)-len(closing)]
+			explicit = w.script
+			break
+		}
+	}
+	if explicit == "" && strings.Contains(body, "(") {
+		return nil, fmt.Errorf("unsupported descriptor %q: expected pkh, wpkh, sh(wpkh) or tr", s)
+	}
+
+	// Key origin [fingerprint/path] documents where the key came from
+	if strings.HasPrefix(body, "[") {
+		end := strings.IndexByte(body, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated key origin in %q", s)
+		}
+		body = body[end+1:]
+	}
+
+	parts := strings.Split(body, "/")
+	key, err := hdkeychain.NewKeyFromString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid extended key: %w", err)
+	}
+	if key.IsPrivate() {
+		return nil, fmt.Errorf("private extended keys are not accepted; supply the xpub")
+	}
+	version, ok := extKeyVersions[hex.EncodeToString(key.Version())]
+	if !ok {
+		return nil, fmt.Errorf("unknown extended key version %x", key.Version())
+	}
+	d.net = version.net
+	d.script = version.script
+	if explicit != "" {
+		d.script = explicit
+	}
+
+	steps := parts[1:]
+	if explicit == "" && len(steps) == 0 {
+		steps = []string{"<0;1>", "*"} // Bare key: receive and change branches
+	}
+	if n := len(steps); n > 0 && steps[n-1] == "*" {
+		d.ranged = true
+		steps = steps[:n-1]
+	}
+
+	d.branches = []*hdkeychain.ExtendedKey{key}
+	d.paths = []string{""}
+	for _, step := range steps {
+		indices, err := parsePathStep(step)
+		if err != nil {
+			return nil, fmt.Errorf("descriptor %q: %w", s, err)
+		}
+		if len(indices) > 1 && len(d.branches) > 1 {
+			return nil, fmt.Errorf("descriptor %q: only one multipath step is allowed", s)
+		}
+		var branches []*hdkeychain.ExtendedKey
+		var paths []string
+		for b, parent := range d.branches {
+			for _, idx := range indices {
+				child, err := parent.Derive(idx)
+				if err != nil {
+					return nil, fmt.Errorf("derive %s/%d: %w", d.paths[b], idx, err)
+				}
+				branches = append(branches, child)
+				paths = append(paths, d.paths[b]+"/"+strconv.FormatUint(uint64(idx), 10))
+			}
+		}
+		d.branches, d.paths = branches, paths
+	}
+
+	return d, nil
+}
+
+// parsePathStep parses an unhardened index or a <a;b;...> multipath step
+func parsePathStep(step string) ([]uint32, error) {
+	if strings.HasSuffix(step, "'") || strings.HasSuffix(step, "h") || strings.HasSuffix(step, "H") {
+		return nil, fmt.Errorf("hardened step %q cannot be derived from a public key", step)
+	}
+	if step == "*" {
+		return nil, fmt.Errorf("wildcard must be the final step")
+	}
+	raw := []string{step}
+	if strings.HasPrefix(step, "<") && strings.HasSuffix(step, ">") {
+		raw = strings.Split(step[1:len(step)-1], ";")
+	}
+	indices := make([]uint32, 0, len(raw))
+	for _, r := range raw {
+		n, err := strconv.ParseUint(r, 10, 31)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path step %q", step)
+		}
+		indices = append(indices, uint32(n))
+	}
+	return indices, nil
+}
+
+// addressAt derives the address at index of a branch (index ignored when not ranged)
+func (d *watchDescriptor) addressAt(branch int, index uint32) (string, error) {
+	key := d.branches[branch]
+	if d.ranged {
+		child, err := key.Derive(index)
+		if err != nil {
+			return "", err
+		}
+		key = child
+	}
+	pub, err := key.ECPubKey()
+	if err != nil {
+		return "", err
+	}
+	return encodeDescriptorAddress(d.script, pub, d.net)
+}
+
+// pathAt returns the branch/index derivation path relative to the key
+func (d *watchDescriptor) pathAt(branch int, index uint32) string {
+	path := d.paths[branch]
+	if d.ranged {
+		path += "/" + strconv.FormatUint(uint64(index), 10)
+	}
+	return strings.TrimPrefix(path, "/")
+}
+
+// encodeDescriptorAddress renders a public key as the descriptor's script type
+func encodeDescriptorAddress(script string, pub *btcec.PublicKey, net *chaincfg.Params) (string, error) {
+	var addr btcutil.Address
+	var err error
+	hash := btcutil.Hash160(pub.SerializeCompressed())
+	switch script {
+	case "pkh":
+		addr, err = btcutil.NewAddressPubKeyHash(hash, net)
+	case "wpkh":
+		addr, err = btcutil.NewAddressWitnessPubKeyHash(hash, net)
+	case "sh-wpkh":
+		redeem := append([]byte{txscript.OP_0, txscript.OP_DATA_20}, hash...)
+		addr, err = btcutil.NewAddressScriptHash(redeem, net)
+	case "tr":
+		// BIP86: key-path only output key
+		output := txscript.ComputeTaprootKeyNoScript(pub)
+		addr, err = btcutil.NewAddressTaproot(schnorr.SerializePubKey(output), net)
+	default:
+		return "", fmt.Errorf("unsupported script type %q", script)
+	}
+	if err != nil {
+		return "", err
+	}
+	return addr.EncodeAddress(), nil
+}
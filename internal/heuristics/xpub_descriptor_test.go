@@ -0,0 +1,106 @@
+package heuristics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// BIP84 test vector: account m/84'/0'/0' of the "abandon ... about" mnemonic
+const bip84Zpub = "zpub6rFR7y4Q2AijBEqTUquhVz398htDFrtymD9xYYfG1m4wAcvPhXNfE3EfH1r1ADqtfSdVCToUG868RvUUkgDKf31mGDtKsAYz2oz2AGutZYs"
+
+// BIP86 test vector: account m/86'/0'/0' of the same mnemonic
+const bip86Xpub = "xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ"
+
+func TestAddressWatchlist_ZpubDerivesReceiveAndChange(t *testing.T) {
+	w := NewAddressWatchlist()
+	n, err := w.AddDescriptor(bip84Zpub, "theft", "Seized wallet", "CASE-7", "critical")
+	if err != nil {
+		t.Fatalf("AddDescriptor failed: %v", err)
+	}
+	if n != 2*defaultXpubGapLimit || w.Size() != n {
+		t.Fatalf("Expected %d derived addresses. Got %d (size %d)", 2*defaultXpubGapLimit, n, w.Size())
+	}
+
+	tx := models.Transaction{
+		Inputs:  []models.TxIn{{Address: "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu", Value: 100_000}}, // m/84'/0'/0'/0/0
+		Outputs: []models.TxOut{{Address: "bc1q8c6fshw2dlwun7ekn9qwf37cu2rn755upcp6el", Value: 99_000}}, // m/84'/0'/0'/1/0
+	}
+	hits := w.CheckTransaction(tx)
+	if len(hits) != 2 {
+		t.Fatalf("Expected receive and change hits. Got %+v", hits)
+	}
+	if hits[0].Descriptor != bip84Zpub || hits[0].DerivationPath != "0/0" || hits[0].CaseID != "CASE-7" {
+		t.Errorf("Unexpected receive hit: %+v", hits[0])
+	}
+	if hits[1].DerivationPath != "1/0" || hits[1].Direction != "output" {
+		t.Errorf("Unexpected change hit: %+v", hits[1])
+	}
+}
+
+func TestAddressWatchlist_TaprootDescriptorWithOrigin(t *testing.T) {
+	w := NewAddressWatchlist()
+	desc := "tr([73c5da0a/86'/0'/0']" + bip86Xpub + "/0/*)#checksum"
+
+	// Add routes descriptors through AddDescriptor
+	w.Add(desc, "suspect", "Suspect wallet", "CASE-8", "high")
+	if w.Size() != defaultXpubGapLimit {
+		t.Fatalf("Expected one ranged branch of %d addresses. Got %d", defaultXpubGapLimit, w.Size())
+	}
+
+	entry, ok := w.Get("bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh") // m/86'/0'/0'/0/1
+	if !ok {
+		t.Fatalf("Expected BIP86 receive address 0/1 to be watched")
+	}
+	if entry.DerivationPath != "0/1" || !strings.HasPrefix(entry.Descriptor, "tr([73c5da0a") || strings.Contains(entry.Descriptor, "#") {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+
+	w.Remove(desc)
+	if w.Size() != 0 {
+		t.Errorf("Expected removing the descriptor to drop its addresses. Got %d", w.Size())
+	}
+}
+
+func TestAddressWatchlist_GapWindowSlidesOnHit(t *testing.T) {
+	SetXpubGapLimit(2)
+	defer SetXpubGapLimit(defaultXpubGapLimit)
+
+	w := NewAddressWatchlist()
+	if _, err := w.AddDescriptor("wpkh("+bip84Zpub+"/<0;1>/*)", "suspect", "", "CASE-9", "high"); err != nil {
+		t.Fatalf("AddDescriptor failed: %v", err)
+	}
+	if w.Size() != 4 {
+		t.Fatalf("Expected 2 addresses per branch. Got %d", w.Size())
+	}
+
+	// Seeing receive index 1 keeps a full gap of 2 beyond it: 0/0..0/3
+	w.CheckTransaction(models.Transaction{
+		Outputs: []models.TxOut{{Address: "bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g", Value: 10_000}},
+	})
+	if w.Size() != 6 {
+		t.Errorf("Expected the receive window to extend to index 3. Got %d addresses", w.Size())
+	}
+	if entry, ok := w.Get("bc1qnjg0jd8228aq7egyzacy8cys3knf9xvrerkf9g"); !ok || entry.DerivationPath != "0/1" {
+		t.Errorf("Expected the hit address to keep its entry. Got %+v", entry)
+	}
+}
+
+func TestParseWatchDescriptor_Rejects(t *testing.T) {
+	cases := map[string]string{
+		"hardened step": "wpkh(" + bip84Zpub + "/0h/*)",
+		"private key":   "xprv9s21ZrQH143K3QTDL4LXw2F7HEK3wJUD2nW2nRk4stbPy6cq3jPPqjiChkVvvNKmPGJxWUtg6LnF5kejMRNNU3TGtRBeJgk33yuGBxrMPHi",
+		"multisig":      "wsh(multi(2," + bip84Zpub + "/0/*," + bip86Xpub + "/0/*))",
+		"bad checksum":  strings.Replace(bip84Zpub, "Ys", "Yt", 1),
+	}
+	for name, desc := range cases {
+		if _, err := parseWatchDescriptor(desc); err == nil {
+			t.Errorf("%s: expected an error for %q", name, desc)
+		}
+	}
+
+	if IsWatchDescriptor("bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu") {
+		t.Errorf("Plain addresses must not be treated as descriptors")
+	}
+}
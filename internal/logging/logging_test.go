@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestParseLevel(t *testing.T) {
+	cases := map[string]slog.Level{
+		"":        slog.LevelInfo,
+		"debug":   slog.LevelDebug,
+		"WARN":    slog.LevelWarn,
+		"warning": slog.LevelWarn,
+		"error":   slog.LevelError,
+	}
+	for in, want := range cases {
+		if got, err := ParseLevel(in); err != nil || got != want {
+			t.Errorf("ParseLevel(%q): expected %v. Got %v (%v)", in, want, got, err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("Expected an error for an unknown level")
+	}
+}
+
+func TestNewHandler_JSONRecordsAreParseable(t *testing.T) {
+	var buf bytes.Buffer
+	h, err := NewHandler(&buf, "json", slog.LevelInfo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger := slog.New(h).With(slog.String("component", "poller"))
+	logger.Debug("dropped below level")
+	logger.Warn("fetch failed", "txid", "abc", "height", 850000)
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("Expected exactly one JSON record. Got %q: %v", buf.String(), err)
+	}
+	if rec["level"] != "WARN" || rec["component"] != "poller" || rec["txid"] != "abc" || rec["height"] != float64(850000) {
+		t.Errorf("Unexpected record %v", rec)
+	}
+}
+
+func TestNewHandler_RejectsUnknownFormat(t *testing.T) {
+	if _, err := NewHandler(&bytes.Buffer{}, "xml", slog.LevelInfo); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestFromContext_TagsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	prev := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer slog.SetDefault(prev)
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	FromContext(ctx, "api").Info("request")
+	FromContext(context.Background(), "api").Info("untagged")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 records. Got %q", buf.String())
+	}
+	var tagged, untagged map[string]any
+	_ = json.Unmarshal(lines[0], &tagged)
+	_ = json.Unmarshal(lines[1], &untagged)
+	if tagged["request_id"] != "req-1" || tagged["component"] != "api" {
+		t.Errorf("Expected request_id and component. Got %v", tagged)
+	}
+	if _, ok := untagged["request_id"]; ok {
+		t.Errorf("Expected no request_id without one in ctx. Got %v", untagged)
+	}
+}
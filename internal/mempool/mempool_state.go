@@ -0,0 +1,165 @@
+package mempool
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Mempool State Broadcast
+//
+// Every StateInterval the poller broadcasts a "mempool_state" message with
+// the node's mempool size, relay fee floor, smart-fee estimates and a
+// fee-rate histogram built from getrawmempool (verbose), so dashboards see
+// congestion alongside the per-tx analysis stream.
+
+// DefaultStateInterval is how often the mempool state is broadcast
+const DefaultStateInterval = 30 * time.Second
+
+// blockVSize is the virtual size of a full block (4M weight units)
+const blockVSize = 1_000_000
+
+// feeBucketEdges are the lower bounds (sat/vB) of the histogram buckets;
+// the last bucket is open-ended
+var feeBucketEdges = []float64{1, 2, 3, 4, 5, 6, 8, 10, 12, 15, 20, 30, 40, 50, 75, 100, 150, 200, 300, 500, 1000}
+
+// FeeBucket is one fee-rate band of the mempool
+type FeeBucket struct {
+	MinFeeRate float64 `json:"minFeeRate"`           // sat/vB, inclusive
+	MaxFeeRate float64 `json:"maxFeeRate,omitempty"` // sat/vB, exclusive (0 = open-ended)
+	TxCount    int     `json:"txCount"`
+	VSize      int64   `json:"vsize"`
+}
+
+// MempoolState is the periodic "mempool_state" WebSocket message
+type MempoolState struct {
+	Type             string             `json:"type"` // "mempool_state"
+	Size             int                `json:"size"`
+	VSize            int64              `json:"vsize"`
+	TotalFeeSats     int64              `json:"totalFeeSats"`
+	MinRelayFeeSatVB float64            `json:"minRelayFeeSatVB"`
+	FeeFloorSatVB    float64            `json:"feeFloorSatVB"` // max(mempoolminfee, minrelaytxfee)
+	FeeEstimates     map[string]float64 `json:"feeEstimates"`  // Confirmation target (blocks) → sat/vB
+	PendingBlocks    float64            `json:"pendingBlocks"` // Mempool vsize in full blocks
+	Congestion       string             `json:"congestion"`    // low/moderate/high/severe
+	Buckets          []FeeBucket        `json:"buckets"`       // Ascending fee rate
+	Timestamp        time.Time          `json:"timestamp"`
+}
+
+// MarshalJSON rounds reported floats to the configured precision
+func (s MempoolState) MarshalJSON() ([]byte, error) {
+	type alias MempoolState
+	a := alias(s)
+	a.MinRelayFeeSatVB = models.RoundFloat(a.MinRelayFeeSatVB)
+	a.FeeFloorSatVB = models.RoundFloat(a.FeeFloorSatVB)
+	a.PendingBlocks = models.RoundFloat(a.PendingBlocks)
+	if s.FeeEstimates != nil {
+		a.FeeEstimates = make(map[string]float64, len(s.FeeEstimates))
+		for target, fee := range s.FeeEstimates {
+			a.FeeEstimates[target] = models.RoundFloat(fee)
+		}
+	}
+	return json.Marshal(a)
+}
+
+// feeEstimateTargets are the confirmation targets reported in FeeEstimates
+var feeEstimateTargets = map[string]int64{"1": 1, "3": 3, "6": 6, "144": 144}
+
+// buildFeeHistogram buckets mempool entries by fee rate
+func buildFeeHistogram(entries map[string]btcjson.GetRawMempoolVerboseResult) ([]FeeBucket, int64, int64) {
+	buckets := make([]FeeBucket, len(feeBucketEdges))
+	for i, edge := range feeBucketEdges {
+		buckets[i].MinFeeRate = edge
+		if i+1 < len(feeBucketEdges) {
+			buckets[i].MaxFeeRate = feeBucketEdges[i+1]
+		}
+	}
+
+	var vsize, fees int64
+	for _, entry := range entries {
+		if entry.Vsize <= 0 {
+			continue
+		}
+		feeSats := models.BTCToSats(entry.Fee)
+		rate := float64(feeSats) / float64(entry.Vsize)
+
+		// Highest bucket whose lower bound the rate reaches (sub-1 sat/vB goes in the first)
+		i := len(feeBucketEdges) - 1
+		for i > 0 && rate < feeBucketEdges[i] {
+			i--
+		}
+		buckets[i].TxCount++
+		buckets[i].VSize += int64(entry.Vsize)
+		vsize += int64(entry.Vsize)
+		fees += feeSats
+	}
+	return buckets, vsize, fees
+}
+
+// congestionLevel grades the backlog by how many full blocks it fills
+func congestionLevel(pendingBlocks float64) string {
+	switch {
+	case pendingBlocks < 1:
+		return "low"
+	case pendingBlocks < 3:
+		return "moderate"
+	case pendingBlocks < 10:
+		return "high"
+	default:
+		return "severe"
+	}
+}
+
+// mempoolState gathers the current mempool state from the node
+func (p *Poller) mempoolState() (MempoolState, error) {
+	entries, err := p.btcClient.GetRawMempoolVerbose()
+	if err != nil {
+		return MempoolState{}, err
+	}
+
+	buckets, vsize, fees := buildFeeHistogram(entries)
+	pending := float64(vsize) / blockVSize
+	state := MempoolState{
+		Type:          "mempool_state",
+		Size:          len(entries),
+		VSize:         vsize,
+		TotalFeeSats:  fees,
+		FeeEstimates:  make(map[string]float64, len(feeEstimateTargets)),
+		PendingBlocks: pending,
+		Congestion:    congestionLevel(pending),
+		Buckets:       buckets,
+		Timestamp:     time.Now(),
+	}
+	if minRelay, err := p.btcClient.GetMinRelayFeeSatVB(); err == nil {
+		state.MinRelayFeeSatVB = minRelay
+	}
+	if floor, err := p.btcClient.GetMempoolFeeFloorSatVB(); err == nil {
+		state.FeeFloorSatVB = floor
+	}
+	for label, target := range feeEstimateTargets {
+		if fee, err := p.btcClient.EstimateSmartFeeSatVB(target); err == nil {
+			state.FeeEstimates[label] = fee
+		}
+	}
+	return state, nil
+}
+
+// broadcastMempoolState sends the current mempool state to WebSocket clients
+func (p *Poller) broadcastMempoolState() {
+	if p.wsHub == nil {
+		return
+	}
+	state, err := p.mempoolState()
+	if err != nil {
+		logger().Error("failed to fetch mempool state", "err", err)
+		return
+	}
+	payload, err := json.Marshal(state)
+	if err != nil {
+		logger().Error("failed to marshal mempool state", "err", err)
+		return
+	}
+	p.wsHub.Broadcast(payload)
+}
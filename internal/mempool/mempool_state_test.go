@@ -0,0 +1,63 @@
+package mempool
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+)
+
+func TestBuildFeeHistogram(t *testing.T) {
+	entries := map[string]btcjson.GetRawMempoolVerboseResult{
+		"a": {Vsize: 200, Fee: 0.00000100}, // 0.5 sat/vB → first bucket
+		"b": {Vsize: 200, Fee: 0.00000400}, // 2 sat/vB
+		"c": {Vsize: 100, Fee: 0.00000250}, // 2.5 sat/vB
+		"d": {Vsize: 150, Fee: 0.00300000}, // 2000 sat/vB → open-ended bucket
+		"e": {Vsize: 0, Fee: 0.00001000},   // Malformed, skipped
+	}
+
+	buckets, vsize, fees := buildFeeHistogram(entries)
+	if len(buckets) != len(feeBucketEdges) {
+		t.Fatalf("Expected %d buckets. Got %d", len(feeBucketEdges), len(buckets))
+	}
+	if vsize != 650 || fees != 300_750 {
+		t.Errorf("Expected 650 vB and 300750 sats. Got %d vB, %d sats", vsize, fees)
+	}
+
+	if b := buckets[0]; b.MinFeeRate != 1 || b.MaxFeeRate != 2 || b.TxCount != 1 || b.VSize != 200 {
+		t.Errorf("Expected the sub-1 sat/vB tx in the first bucket. Got %+v", b)
+	}
+	if b := buckets[1]; b.MinFeeRate != 2 || b.TxCount != 2 || b.VSize != 300 {
+		t.Errorf("Expected two txs in the 2–3 sat/vB bucket. Got %+v", b)
+	}
+	last := buckets[len(buckets)-1]
+	if last.MaxFeeRate != 0 || last.TxCount != 1 {
+		t.Errorf("Expected the 2000 sat/vB tx in the open-ended bucket. Got %+v", last)
+	}
+}
+
+func TestCongestionLevel(t *testing.T) {
+	for blocks, want := range map[float64]string{0.4: "low", 1: "moderate", 5: "high", 40: "severe"} {
+		if got := congestionLevel(blocks); got != want {
+			t.Errorf("%.1f blocks: expected %s, got %s", blocks, want, got)
+		}
+	}
+}
+
+func TestMempoolStateMarshalJSON_RoundsFloats(t *testing.T) {
+	state := MempoolState{PendingBlocks: 2.3456789, FeeEstimates: map[string]float64{"1": 12.34567}}
+	raw, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got MempoolState
+	if err := json.Unmarshal(raw, &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.PendingBlocks != 2.346 || got.FeeEstimates["1"] != 12.346 {
+		t.Errorf("Expected floats rounded to 3 places. Got %s", raw)
+	}
+	if state.PendingBlocks != 2.3456789 {
+		t.Error("Expected the raw value to be kept on the struct")
+	}
+}
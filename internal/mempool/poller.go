@@ -3,7 +3,11 @@ package mempool
 import (
 	"context"
 	"encoding/json"
-	"log"
+	"fmt"
+	"log/slog"
+	"math"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
@@ -11,9 +15,43 @@ import (
 	"github.com/rawblock/coinjoin-engine/internal/bitcoin"
 	"github.com/rawblock/coinjoin-engine/internal/db"
 	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/internal/logging"
+	"github.com/rawblock/coinjoin-engine/internal/publish"
+	"github.com/rawblock/coinjoin-engine/internal/scanner"
 	"github.com/rawblock/coinjoin-engine/pkg/models"
 )
 
+// Poll pacing: how often getrawmempool is polled and how many new
+// transactions are fetched and analyzed per tick
+const (
+	DefaultPollInterval  = 3 * time.Second
+	DefaultPollBatchSize = 20
+
+	MinPollInterval  = 250 * time.Millisecond
+	MaxPollInterval  = 5 * time.Minute
+	MaxPollBatchSize = 1000
+)
+
+// ParsePollInterval parses POLLER_INTERVAL_MS, a period in milliseconds
+// within MinPollInterval..MaxPollInterval
+func ParsePollInterval(raw string) (time.Duration, error) {
+	ms, err := strconv.Atoi(raw)
+	interval := time.Duration(ms) * time.Millisecond
+	if err != nil || interval < MinPollInterval || interval > MaxPollInterval {
+		return 0, fmt.Errorf("poll interval %q: want %d-%d ms", raw, MinPollInterval.Milliseconds(), MaxPollInterval.Milliseconds())
+	}
+	return interval, nil
+}
+
+// ParsePollBatchSize parses POLLER_BATCH_SIZE, 1..MaxPollBatchSize
+func ParsePollBatchSize(raw string) (int, error) {
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 || n > MaxPollBatchSize {
+		return 0, fmt.Errorf("poll batch size %q: want 1-%d", raw, MaxPollBatchSize)
+	}
+	return n, nil
+}
+
 type Poller struct {
 	btcClient *bitcoin.Client
 	wsHub     *api.Hub
@@ -21,8 +59,31 @@ type Poller struct {
 	seenTXs   map[string]bool
 	Watchlist *heuristics.AddressWatchlist
 	AlertMgr  *heuristics.AlertManager
+	Services  *heuristics.ServicePatternTracker    // Cross-tx faucet/airdrop classification
+	Ransom    *heuristics.RansomwareSplitTracker   // Ransomware splits and their mixing/cash-out
+	Mixed     *heuristics.CoinJoinOutputIndex      // Outputs of CoinJoins seen in the mempool
+	Premix    *heuristics.ConsolidationOutputIndex // Outputs of consolidations that may feed a mix
+	Variants  *heuristics.WitnessVariantTracker    // txid/wtxid per input set (malleation)
+	Nonces    *heuristics.NonceReuseTracker        // Recent signature R-values (ECDSA nonce reuse)
+	Dust      *heuristics.DustTracker              // Planted surveillance dust awaiting consolidation
+	RBF       *heuristics.RBFTracker               // Spent outpoints of mempool txs (BIP125 replacements)
+	CPFP      *heuristics.CPFPTracker              // Fee/vsize of mempool txs (child-pays-for-parent packages)
+	Publisher *publish.AsyncPublisher              // Optional result bus (nil = disabled)
+
+	StateInterval time.Duration // mempool_state broadcast period (0 = disabled)
+	PollInterval  time.Duration // getrawmempool period (MinPollInterval..MaxPollInterval)
+	BatchSize     int           // New transactions analyzed per tick (1..MaxPollBatchSize)
+
+	// Last-tick observations exposed to the /stats endpoint
+	mempoolSize   atomic.Int64
+	feeFloorBits  atomic.Uint64 // math.Float64bits of sat/vB
+	totalAnalyzed atomic.Int64
+	lastPollUnix  atomic.Int64
 }
 
+// logger tags the poller's records (component=poller)
+func logger() *slog.Logger { return logging.Component("poller") }
+
 // StreamPayload represents the real-time data sent to the dashboard UI
 type StreamPayload struct {
 	TxID           string                  `json:"txid"`
@@ -38,6 +99,8 @@ type StreamPayload struct {
 	CUDAOffloaded  bool                    `json:"cudaOffloaded"`
 	HeuristicFlags uint64                  `json:"heuristicFlags"`
 	Inference      *models.InferenceResult `json:"inference,omitempty"`
+	Replaces       []string                `json:"replaces,omitempty"` // Txids this RBF replacement evicted
+	CPFP           *heuristics.CPFPPackage `json:"cpfp,omitempty"`     // Unconfirmed parents and package fee rate
 }
 
 func NewPoller(btcClient *bitcoin.Client, wsHub *api.Hub, dbStore *db.PostgresStore) *Poller {
@@ -51,7 +114,7 @@ func NewPoller(btcClient *bitcoin.Client, wsHub *api.Hub, dbStore *db.PostgresSt
 			"alert": alert,
 		})
 		if err != nil {
-			log.Printf("[Poller] Failed to marshal security alert payload: %v", err)
+			logger().Error("failed to marshal security alert payload", "alert", alert.ID, "err", err)
 			return
 		}
 		wsHub.Broadcast(payload)
@@ -64,38 +127,116 @@ func NewPoller(btcClient *bitcoin.Client, wsHub *api.Hub, dbStore *db.PostgresSt
 		seenTXs:   make(map[string]bool),
 		Watchlist: watchlist,
 		AlertMgr:  alertMgr,
+		Services:  heuristics.NewServicePatternTracker(),
+		Ransom:    heuristics.NewRansomwareSplitTracker(),
+		Mixed:     heuristics.NewCoinJoinOutputIndex(),
+		Premix:    heuristics.NewConsolidationOutputIndex(),
+		Variants:  heuristics.NewWitnessVariantTracker(),
+		Nonces:    heuristics.NewNonceReuseTracker(),
+		Dust:      heuristics.NewDustTracker(),
+		RBF:       heuristics.NewRBFTracker(),
+		CPFP:      heuristics.NewCPFPTracker(),
+
+		StateInterval: DefaultStateInterval,
+		PollInterval:  DefaultPollInterval,
+		BatchSize:     DefaultPollBatchSize,
 	}
 }
 
+// MempoolStats returns the poller's latest mempool observations (thread-safe)
+func (p *Poller) MempoolStats() api.MempoolStats {
+	stats := api.MempoolStats{
+		Size:          int(p.mempoolSize.Load()),
+		FeeFloorSatVB: math.Float64frombits(p.feeFloorBits.Load()),
+		TotalAnalyzed: p.totalAnalyzed.Load(),
+	}
+	if ts := p.lastPollUnix.Load(); ts > 0 {
+		stats.LastPoll = time.Unix(ts, 0)
+	}
+	return stats
+}
+
+// evictDeparted forgets transactions that left the mempool (confirmed,
+// replaced or expired), so the seen set tracks the mempool's size and
+// transactions still waiting are never reprocessed. The RBF tracker keeps
+// departed spends: the replacement of an evicted tx may still be queued.
+func (p *Poller) evictDeparted(mempool []string) {
+	current := make(map[string]bool, len(mempool))
+	for _, txid := range mempool {
+		current[txid] = true
+	}
+	for txid := range p.seenTXs {
+		if !current[txid] {
+			delete(p.seenTXs, txid)
+			p.CPFP.Forget(txid)
+		}
+	}
+}
+
+// broadcastMempoolCoinJoin sends the "mempool_coinjoin" early warning for
+// an unconfirmed CoinJoin (same alert shape the scanner emits once mined)
+func (p *Poller) broadcastMempoolCoinJoin(alert scanner.CoinJoinAlert) {
+	logger().Info("mempool CoinJoin", "txid", alert.Txid, "mixer", alert.MixerType,
+		"anonset", alert.AnonSet, "btc", alert.TotalValueBTC)
+	if p.wsHub == nil {
+		return
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"type":  "mempool_coinjoin",
+		"alert": alert,
+	})
+	if err != nil {
+		logger().Error("failed to marshal mempool CoinJoin payload", "txid", alert.Txid, "err", err)
+		return
+	}
+	p.wsHub.Broadcast(payload)
+}
+
+// pacing returns PollInterval and BatchSize clamped to their bounds
+func (p *Poller) pacing() (time.Duration, int) {
+	return min(max(p.PollInterval, MinPollInterval), MaxPollInterval), min(max(p.BatchSize, 1), MaxPollBatchSize)
+}
+
 func (p *Poller) Run(ctx context.Context) {
 	if p.btcClient == nil {
-		log.Println("[Poller] Bitcoin client is nil; poller will not start")
+		logger().Warn("bitcoin client is nil; poller will not start")
 		return
 	}
 
-	log.Println("Starting Mempool CUDA Analytics Poller...")
+	pollInterval, batchSize := p.pacing()
+	logger().Info("starting mempool poller", "interval", pollInterval, "batch", batchSize)
 
-	ticker := time.NewTicker(3 * time.Second)
+	ticker := time.NewTicker(pollInterval)
 	defer ticker.Stop()
 
-	// Keep map clean by resetting seen every hour just to prevent infinite memory growth
-	cleanupTicker := time.NewTicker(1 * time.Hour)
-	defer cleanupTicker.Stop()
+	// Fee histogram / congestion broadcast (nil channel when disabled)
+	var stateTick <-chan time.Time
+	if p.StateInterval > 0 {
+		stateTicker := time.NewTicker(p.StateInterval)
+		defer stateTicker.Stop()
+		stateTick = stateTicker.C
+	}
 
 	for {
 		select {
 		case <-ctx.Done():
-			log.Println("Stopping Mempool Poller...")
+			logger().Info("stopping mempool poller")
 			return
-		case <-cleanupTicker.C:
-			p.seenTXs = make(map[string]bool)
+		case <-stateTick:
+			p.broadcastMempoolState()
 		case <-ticker.C:
 			// Fetch current mempool hashes (verbose=false)
 			mempool, err := p.btcClient.GetRawMempool()
 			if err != nil {
-				log.Printf("[Poller] Error fetching mempool: %v", err)
+				logger().Error("failed to fetch mempool", "rpc", "getrawmempool", "err", err)
 				continue
 			}
+			p.mempoolSize.Store(int64(len(mempool)))
+			p.evictDeparted(mempool)
+			p.lastPollUnix.Store(time.Now().Unix())
+			if floor, err := p.btcClient.GetMempoolFeeFloorSatVB(); err == nil {
+				p.feeFloorBits.Store(math.Float64bits(floor))
+			}
 
 			// Get current block height for accurate DB persistence
 			currentHeight := 0
@@ -103,7 +244,7 @@ func (p *Poller) Run(ctx context.Context) {
 				currentHeight = int(count)
 			}
 
-			// Process up to 20 new transactions per tick to avoid lagging the node too much
+			// Process up to batchSize new transactions per tick to avoid lagging the node too much
 			processedCount := 0
 			for _, txidStr := range mempool {
 				if p.seenTXs[txidStr] {
@@ -129,6 +270,7 @@ func (p *Poller) Run(ctx context.Context) {
 				// Map to internal format
 				tx := models.Transaction{
 					Txid:      rawTx.Txid,
+					Wtxid:     bitcoin.RawWtxid(rawTx),
 					Inputs:    make([]models.TxIn, len(rawTx.Vin)),
 					Outputs:   make([]models.TxOut, len(rawTx.Vout)),
 					Weight:    int(rawTx.Weight),
@@ -150,11 +292,9 @@ func (p *Poller) Run(ctx context.Context) {
 					var inAddr string
 					if err == nil && int(vin.Vout) < len(prevTx.Vout) {
 						inValue = prevTx.Vout[vin.Vout].Value
-						if len(prevTx.Vout[vin.Vout].ScriptPubKey.Addresses) > 0 {
-							inAddr = prevTx.Vout[vin.Vout].ScriptPubKey.Addresses[0]
-						}
+						inAddr = bitcoin.ScriptAddress(prevTx.Vout[vin.Vout].ScriptPubKey)
 					}
-					valSats := int64(inValue * 100000000)
+					valSats := models.BTCToSats(inValue)
 					scriptSigHex := ""
 					if vin.ScriptSig != nil {
 						scriptSigHex = vin.ScriptSig.Hex
@@ -166,16 +306,18 @@ func (p *Poller) Run(ctx context.Context) {
 						Address:   inAddr,
 						ScriptSig: scriptSigHex,
 						Sequence:  vin.Sequence,
+						Witness:   vin.Witness,
+					}
+					if err == nil {
+						// Mempool tx: the current tip anchors the prevout's confirmation height
+						tx.Inputs[i].PrevBlockHeight, tx.Inputs[i].PrevBlockTime = bitcoin.PrevoutConfirmation(prevTx, currentHeight)
 					}
 					totalIn += valSats
 				}
 
 				for i, vout := range rawTx.Vout {
-					valSats := int64(vout.Value * 100000000)
-					var outAddr string
-					if len(vout.ScriptPubKey.Addresses) > 0 {
-						outAddr = vout.ScriptPubKey.Addresses[0]
-					}
+					valSats := models.BTCToSats(vout.Value)
+					outAddr := bitcoin.ScriptAddress(vout.ScriptPubKey)
 					tx.Outputs[i] = models.TxOut{
 						Value:        valSats,
 						Address:      outAddr,
@@ -194,6 +336,7 @@ func (p *Poller) Run(ctx context.Context) {
 
 				// Re-using the engine's core 28-step analysis pipeline
 				result := heuristics.AnalyzeTx(tx)
+				p.totalAnalyzed.Add(1)
 
 				elapsed := float64(time.Since(start).Microseconds()) / 1000.0
 
@@ -205,24 +348,100 @@ func (p *Poller) Run(ctx context.Context) {
 				assessment := heuristics.ScoreTransaction(tx, result, watchlistHits)
 				taintLevel, _ := heuristics.CheckInputsForTaint(tx)
 
+				isCoinJoinFlag := heuristics.IsCoinJoinResult(result.HeuristicFlags)
+
+				// Faucet/airdrop payouts trip bot and service signals on every tx
+				gated := isCoinJoinFlag || (result.HeuristicFlags&uint64(heuristics.FlagIsPayjoinSuspect)) > 0
+				if svc := p.Services.Observe(tx, gated); svc.IsService {
+					assessment = heuristics.DiscountServiceNoise(assessment, svc)
+				}
+
+				// Ransomware proceeds being divided (and later mixed/cashed out)
+				if split := p.Ransom.Observe(tx, isCoinJoinFlag); split.IsRansomwareSplit {
+					assessment = heuristics.EscalateRansomwareSplit(assessment, split)
+					logger().Warn("ransomware split", "stage", split.Stage, "txid", tx.Txid, "split", split.SplitTxid,
+						"affiliateShare", split.AffiliateShare, "outputs", len(split.Shares))
+				}
+
+				// Mixed coins sent straight to an exchange deposit
+				if deposit := heuristics.DetectMixToExchangeDeposit(tx, p.Mixed); deposit.IsMixToDeposit {
+					assessment = heuristics.EscalateMixToDeposit(assessment, deposit)
+					logger().Warn("mix-to-exchange deposit", "txid", tx.Txid, "mixedInputs", deposit.MixedInputs,
+						"exchange", deposit.Exchange)
+				}
+				if isCoinJoinFlag {
+					// Early warning: the mix is visible before any block confirms it
+					p.broadcastMempoolCoinJoin(scanner.NewCoinJoinAlert(tx, result, 0, totalIn))
+
+					// Coins consolidated just before entering the mix
+					if premix := heuristics.DetectPreMixConsolidation(tx, p.Premix); premix.IsPreMixConsolidation {
+						logger().Info("pre-mix consolidation", "txid", tx.Txid,
+							"consolidations", premix.ConsolidationTxids, "note", premix.Note)
+					}
+					if alert, ok := heuristics.LargeCoinJoinAlert(tx, result); ok {
+						p.AlertMgr.EmitAlert(alert)
+					}
+					p.Mixed.Record(tx)
+				} else {
+					p.Premix.Record(tx)
+				}
+
+				// A second serialization of a known tx (same txid variants
+				// surface when the txid leaves the mempool and is re-fetched
+				// on its return)
+				if malleated := p.Variants.Observe(tx); malleated.IsMalleated {
+					p.AlertMgr.EmitAlert(heuristics.MalleabilityAlert(tx, malleated))
+				}
+
+				// Reused signature nonce: the signing key is recoverable
+				if reuse := p.Nonces.Observe(tx); reuse.IsReused {
+					p.AlertMgr.EmitAlert(heuristics.NonceReuseAlert(tx, reuse))
+				}
+
+				// Planted dust swept with the victim's other UTXOs
+				if dusted := p.Dust.Observe(tx); dusted.IsDeanonymization {
+					p.AlertMgr.EmitAlert(heuristics.DustDeanonymizationAlert(tx, dusted))
+				}
+
+				// A replacement supersedes the analysis streamed for the
+				// transactions it evicted; this analysis is the fresh one
+				replacement := p.RBF.Observe(tx, fee)
+				if replacement.IsReplacement {
+					p.AlertMgr.EmitAlert(heuristics.RBFReplacementAlert(tx, replacement))
+					logger().Info("RBF replacement", "kind", replacement.Kind, "txid", tx.Txid,
+						"replaces", replacement.ReplacedTxids, "feeBump", replacement.FeeBump)
+				}
+
+				// Spending an unconfirmed output: the parent confirms at the package rate
+				var cpfp *heuristics.CPFPPackage
+				if pkg := p.CPFP.Observe(tx, fee); pkg.IsCPFP {
+					cpfp = &pkg
+					if pkg.AbnormalPinning {
+						logger().Warn("pinning suspect", "txid", tx.Txid, "childFeeRate", pkg.ChildFeeRate,
+							"parents", pkg.Parents, "parentFeeRate", pkg.ParentFeeRate, "packageFeeRate", pkg.PackageFeeRate)
+					}
+				}
+
 				// Emit alerts for medium+ severity
 				if assessment.Severity != "info" && assessment.Severity != "low" {
-					p.AlertMgr.EmitFromAssessment(assessment, watchlistHits)
+					p.AlertMgr.EmitFromTransaction(tx, assessment, watchlistHits)
+				}
+
+				// Stream to the result bus (blocks while the bus is backed up)
+				if err := p.Publisher.PublishResult(ctx, "mempool", currentHeight, result, assessment); err != nil {
+					logger().Error("failed to publish result", "txid", tx.Txid, "err", err)
 				}
 
 				// Persist CoinJoin detections to the isolated database
 				if p.dbStore != nil {
-					isCoinJoinFlag := (result.HeuristicFlags&uint64(heuristics.FlagIsWhirlpoolStruct)) > 0 ||
-						(result.HeuristicFlags&uint64(heuristics.FlagIsWasabiSuspect)) > 0 ||
-						(result.HeuristicFlags&uint64(heuristics.FlagLikelyCollabConstruct)) > 0 ||
-						(result.HeuristicFlags&uint64(heuristics.FlagIsJoinMarketBond)) > 0
-
 					if isCoinJoinFlag {
-						if err := p.dbStore.SaveAnalysisResult(ctx, currentHeight, result); err != nil {
-							log.Printf("[Poller] Failed to persist CoinJoin detection to DB: %v", err)
+						// Dated by first sighting; the scanner's row at the confirming
+						// block supersedes it in the stored aggregates
+						if err := p.dbStore.SaveAnalysisResult(ctx, currentHeight, time.Now().Unix(), result); err != nil {
+							logger().Error("failed to persist CoinJoin detection", "txid", tx.Txid, "err", err)
 						} else {
-							log.Printf("[Poller] 🔍 CoinJoin detected and persisted: %s (flags: %d, anonset: %d)",
-								tx.Txid, result.HeuristicFlags, result.AnonSet)
+							logger().Info("CoinJoin detected and persisted", "txid", tx.Txid,
+								"flags", result.HeuristicFlags, "anonset", result.AnonSet)
 						}
 					}
 
@@ -240,7 +459,7 @@ func (p *Poller) Run(ctx context.Context) {
 						assessment.RiskScore, riskLevel, result.PrivacyScore, result.HeuristicFlags,
 						taintLevel,
 						len(tx.Inputs), len(tx.Outputs), totalValue); err != nil {
-						log.Printf("[Poller] Failed to persist risk assessment: %v", err)
+						logger().Error("failed to persist risk assessment", "txid", tx.Txid, "err", err)
 					}
 				}
 
@@ -258,13 +477,15 @@ func (p *Poller) Run(ctx context.Context) {
 					CUDAOffloaded:  isCuda,
 					HeuristicFlags: result.HeuristicFlags,
 					Inference:      result.Inference,
+					Replaces:       replacement.ReplacedTxids,
+					CPFP:           cpfp,
 				}
 
 				payloadBytes, _ := json.Marshal(payload)
 				p.wsHub.Broadcast(payloadBytes)
 
 				processedCount++
-				if processedCount >= 20 {
+				if processedCount >= batchSize {
 					break
 				}
 			}
@@ -0,0 +1,94 @@
+package mempool
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func TestEvictDeparted_TracksMempoolSnapshot(t *testing.T) {
+	p := NewPoller(nil, nil, nil)
+	for _, txid := range []string{"confirmed", "waiting", "replaced"} {
+		p.seenTXs[txid] = true
+	}
+	p.CPFP.Observe(models.Transaction{Txid: "confirmed", Vsize: 100}, 100)
+
+	p.evictDeparted([]string{"waiting", "new"})
+
+	if len(p.seenTXs) != 1 || !p.seenTXs["waiting"] {
+		t.Errorf("Expected only the still-pending tx kept. Got %v", p.seenTXs)
+	}
+	// A spend of a confirmed parent is no longer a CPFP package
+	child := models.Transaction{Txid: "child", Vsize: 100, Inputs: []models.TxIn{{Txid: "confirmed"}}}
+	if pkg := p.CPFP.Observe(child, 5_000); pkg.IsCPFP {
+		t.Errorf("Expected the departed parent forgotten. Got %+v", pkg)
+	}
+}
+
+func TestParsePollInterval(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want time.Duration
+		ok   bool
+	}{
+		{"3000", 3 * time.Second, true},
+		{"250", MinPollInterval, true},
+		{"300000", MaxPollInterval, true},
+		{"249", 0, false},
+		{"300001", 0, false},
+		{"0", 0, false},
+		{"-1000", 0, false},
+		{"3s", 0, false},
+		{"fast", 0, false},
+	}
+	for _, tc := range cases {
+		got, err := ParsePollInterval(tc.raw)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("ParsePollInterval(%q): Expected %s (ok=%v). Got %s, %v", tc.raw, tc.want, tc.ok, got, err)
+		}
+	}
+}
+
+func TestParsePollBatchSize(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want int
+		ok   bool
+	}{
+		{"20", 20, true},
+		{"1", 1, true},
+		{"1000", MaxPollBatchSize, true},
+		{"0", 0, false},
+		{"1001", 0, false},
+		{"-5", 0, false},
+		{"ten", 0, false},
+	}
+	for _, tc := range cases {
+		got, err := ParsePollBatchSize(tc.raw)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("ParsePollBatchSize(%q): Expected %d (ok=%v). Got %d, %v", tc.raw, tc.want, tc.ok, got, err)
+		}
+	}
+}
+
+func TestPoller_PacingClamps(t *testing.T) {
+	cases := []struct {
+		interval     time.Duration
+		batch        int
+		wantInterval time.Duration
+		wantBatch    int
+	}{
+		{0, 0, MinPollInterval, 1},
+		{100 * time.Millisecond, -3, MinPollInterval, 1},
+		{time.Hour, 5000, MaxPollInterval, MaxPollBatchSize},
+		{DefaultPollInterval, DefaultPollBatchSize, DefaultPollInterval, DefaultPollBatchSize},
+	}
+	for _, tc := range cases {
+		p := &Poller{PollInterval: tc.interval, BatchSize: tc.batch}
+		interval, batch := p.pacing()
+		if interval != tc.wantInterval || batch != tc.wantBatch {
+			t.Errorf("pacing(%s, %d): Expected %s, %d. Got %s, %d", tc.interval, tc.batch, tc.wantInterval, tc.wantBatch, interval, batch)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+)
+
+// Address-keyed clustering comparison
+//
+// Ground-truth datasets label addresses with entity IDs (map[address]clusterID).
+// The partitions are aligned over the addresses the ground truth labels;
+// an address the predicted clustering has never seen is its own singleton
+// cluster, so unclustered coverage counts against the engine rather than
+// being silently dropped.
+//
+// Ground-truth sets run to 10^5 addresses, mostly unclustered, so the
+// contingency table is kept sparse rather than as the dense matrix of
+// AdjustedRandIndex / VariationOfInformation.
+
+// PartitionComparison is the agreement between a predicted clustering and
+// a ground-truth labeling
+type PartitionComparison struct {
+	Addresses         int     `json:"addresses"`         // Ground-truth addresses compared
+	PredictedClusters int     `json:"predictedClusters"` // Distinct predicted clusters among them
+	TruthClusters     int     `json:"truthClusters"`     // Distinct ground-truth entities
+	ARI               float64 `json:"ari"`               // -1..1, 1 = identical partitions
+	VI                float64 `json:"vi"`                // Bits, 0 = identical partitions
+	NormalizedVI      float64 `json:"normalizedVi"`      // VI / log2(n), 0..1
+}
+
+// alignPartitions converts two address → cluster ID maps into parallel
+// integer label slices over groundTruth's addresses (sorted, so results
+// are deterministic)
+func alignPartitions(predicted, groundTruth map[string]string) ([]int, []int) {
+	addrs := make([]string, 0, len(groundTruth))
+	for addr := range groundTruth {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+
+	predIDs := make(map[string]int)
+	truthIDs := make(map[string]int)
+	predLabels := make([]int, len(addrs))
+	truthLabels := make([]int, len(addrs))
+	for i, addr := range addrs {
+		cluster, ok := predicted[addr]
+		if !ok {
+			cluster = "\x00" + addr // Unseen: a singleton no real cluster ID can collide with
+		}
+		if _, seen := predIDs[cluster]; !seen {
+			predIDs[cluster] = len(predIDs)
+		}
+		if _, seen := truthIDs[groundTruth[addr]]; !seen {
+			truthIDs[groundTruth[addr]] = len(truthIDs)
+		}
+		predLabels[i] = predIDs[cluster]
+		truthLabels[i] = truthIDs[groundTruth[addr]]
+	}
+	return predLabels, truthLabels
+}
+
+// sparseContingency is the non-zero cells n_ij of a contingency table
+// with its row (predicted) and column (ground-truth) sums
+type sparseContingency struct {
+	n       int
+	cells   map[[2]int]int
+	rowSums []int
+	colSums []int
+}
+
+// newSparseContingency builds the table of two aligned label slices whose
+// labels are dense indices (as produced by alignPartitions)
+func newSparseContingency(predicted, groundTruth []int) sparseContingency {
+	t := sparseContingency{n: len(predicted), cells: make(map[[2]int]int)}
+	for k := range predicted {
+		i, j := predicted[k], groundTruth[k]
+		t.cells[[2]int{i, j}]++
+		for len(t.rowSums) <= i {
+			t.rowSums = append(t.rowSums, 0)
+		}
+		for len(t.colSums) <= j {
+			t.colSums = append(t.colSums, 0)
+		}
+		t.rowSums[i]++
+		t.colSums[j]++
+	}
+	return t
+}
+
+// ari is AdjustedRandIndex over the sparse table
+func (t sparseContingency) ari() float64 {
+	nC2 := comb2(t.n)
+	if nC2 == 0 {
+		return 0.0
+	}
+	sumNijC2, sumAiC2, sumBjC2 := 0.0, 0.0, 0.0
+	for _, nij := range t.cells {
+		sumNijC2 += comb2(nij)
+	}
+	for _, a := range t.rowSums {
+		sumAiC2 += comb2(a)
+	}
+	for _, b := range t.colSums {
+		sumBjC2 += comb2(b)
+	}
+
+	expectedIndex := (sumAiC2 * sumBjC2) / nC2
+	maxIndex := 0.5 * (sumAiC2 + sumBjC2)
+	denominator := maxIndex - expectedIndex
+	if math.Abs(denominator) < 1e-12 {
+		return 1.0 // Perfect agreement (both are 0)
+	}
+	return (sumNijC2 - expectedIndex) / denominator
+}
+
+// vi is VariationOfInformation (bits) over the sparse table
+func (t sparseContingency) vi() float64 {
+	if t.n < 2 {
+		return 0.0
+	}
+	nf := float64(t.n)
+	vi := 0.0
+	for cell, nij := range t.cells {
+		pij := float64(nij) / nf
+		vi -= pij * math.Log2(float64(nij)/float64(t.colSums[cell[1]])) // H(C|C')
+		vi -= pij * math.Log2(float64(nij)/float64(t.rowSums[cell[0]])) // H(C'|C)
+	}
+	return vi
+}
+
+// AdjustedRandIndexByAddress is AdjustedRandIndex over address → cluster ID maps
+func AdjustedRandIndexByAddress(predicted, groundTruth map[string]string) float64 {
+	return newSparseContingency(alignPartitions(predicted, groundTruth)).ari()
+}
+
+// VariationOfInformationByAddress is VariationOfInformation over address → cluster ID maps
+func VariationOfInformationByAddress(predicted, groundTruth map[string]string) float64 {
+	return newSparseContingency(alignPartitions(predicted, groundTruth)).vi()
+}
+
+// ComparePartitions scores predicted against groundTruth
+func ComparePartitions(predicted, groundTruth map[string]string) PartitionComparison {
+	t := newSparseContingency(alignPartitions(predicted, groundTruth))
+	cmp := PartitionComparison{
+		Addresses:         t.n,
+		PredictedClusters: len(t.rowSums),
+		TruthClusters:     len(t.colSums),
+		ARI:               t.ari(),
+		VI:                t.vi(),
+	}
+	if cmp.Addresses >= 2 {
+		cmp.NormalizedVI = cmp.VI / math.Log2(float64(cmp.Addresses))
+	}
+	return cmp
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"math"
+	"testing"
+)
+
+func TestComparePartitions_MatchesDenseMetrics(t *testing.T) {
+	truth := map[string]string{"a": "exchange", "b": "exchange", "c": "exchange", "d": "miner", "e": "miner", "f": "user"}
+	predicted := map[string]string{"a": "r1", "b": "r1", "c": "r2", "d": "r2", "e": "r2"} // f unseen → singleton
+
+	pred, gt := alignPartitions(predicted, truth)
+	cmp := ComparePartitions(predicted, truth)
+	if math.Abs(cmp.ARI-AdjustedRandIndex(pred, gt)) > 1e-9 || math.Abs(cmp.VI-VariationOfInformation(pred, gt)) > 1e-9 {
+		t.Errorf("Sparse metrics diverge from the dense ones: %+v vs ARI %f VI %f", cmp, AdjustedRandIndex(pred, gt), VariationOfInformation(pred, gt))
+	}
+	if cmp.Addresses != 6 || cmp.PredictedClusters != 3 || cmp.TruthClusters != 3 {
+		t.Errorf("Expected 6 addresses in 3 predicted / 3 true clusters. Got %+v", cmp)
+	}
+}
+
+func TestComparePartitions_IdenticalUpToRelabeling(t *testing.T) {
+	truth := map[string]string{"a": "x", "b": "x", "c": "y", "d": "z"}
+	predicted := map[string]string{"a": "root-a", "b": "root-a", "c": "root-c"} // d unseen = its own cluster
+
+	if ari := AdjustedRandIndexByAddress(predicted, truth); math.Abs(ari-1) > 1e-9 {
+		t.Errorf("Expected ARI 1 for the same partition. Got %f", ari)
+	}
+	if vi := VariationOfInformationByAddress(predicted, truth); vi > 1e-9 {
+		t.Errorf("Expected VI 0 for the same partition. Got %f", vi)
+	}
+}
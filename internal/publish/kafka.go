@@ -0,0 +1,70 @@
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// KafkaRESTPublisher produces to a Kafka topic through a Kafka REST proxy
+// (Confluent REST Proxy v2 API), which keeps the engine free of a native
+// Kafka client. A 2xx response means the proxy's producer has the record
+// acknowledged by the brokers.
+type KafkaRESTPublisher struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewKafkaRESTPublisher creates a publisher for the proxy at baseURL
+func NewKafkaRESTPublisher(baseURL, topic string) *KafkaRESTPublisher {
+	return &KafkaRESTPublisher{
+		endpoint: strings.TrimRight(baseURL, "/") + "/topics/" + url.PathEscape(topic),
+		client:   &http.Client{Timeout: attemptTimeout},
+	}
+}
+
+type kafkaRecord struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Publish produces one record keyed by key (the txid, so a transaction's
+// results land on one partition)
+func (k *KafkaRESTPublisher) Publish(ctx context.Context, key string, payload []byte) error {
+	body, err := json.Marshal(map[string][]kafkaRecord{
+		"records": {{Key: key, Value: payload}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal kafka record: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, k.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("kafka request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("kafka produce: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("kafka produce: status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Close releases idle proxy connections
+func (k *KafkaRESTPublisher) Close() error {
+	k.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,129 @@
+package publish
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NATSPublisher publishes to a NATS core subject over the plain-text
+// client protocol. Each PUB is followed by a PING; the server answers
+// PONG only after processing everything before it, so the PONG is the
+// delivery acknowledgement. The connection is re-dialed after any error.
+type NATSPublisher struct {
+	addr    string
+	subject string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// NewNATSPublisher creates a publisher for rawURL (nats://host:port)
+func NewNATSPublisher(rawURL, subject string) *NATSPublisher {
+	addr := rawURL
+	if u, err := url.Parse(rawURL); err == nil && u.Host != "" {
+		addr = u.Host
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "4222")
+	}
+	return &NATSPublisher{addr: addr, subject: subject}
+}
+
+// Publish sends payload to the subject and waits for the server's PONG
+func (n *NATSPublisher) Publish(ctx context.Context, key string, payload []byte) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.conn == nil {
+		if err := n.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = n.conn.SetDeadline(deadline)
+	} else {
+		_ = n.conn.SetDeadline(time.Time{})
+	}
+
+	frame := fmt.Sprintf("PUB %s %d\r\n%s\r\nPING\r\n", n.subject, len(payload), payload)
+	if _, err := n.conn.Write([]byte(frame)); err != nil {
+		n.reset()
+		return fmt.Errorf("nats publish: %w", err)
+	}
+	if err := n.awaitPong(); err != nil {
+		n.reset()
+		return err
+	}
+	return nil
+}
+
+// connect dials the server, reads INFO and sends CONNECT. Caller holds n.mu.
+func (n *NATSPublisher) connect(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return fmt.Errorf("nats dial %s: %w", n.addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+	reader := bufio.NewReader(conn)
+
+	line, err := reader.ReadString('\n')
+	if err != nil || !strings.HasPrefix(line, "INFO") {
+		conn.Close()
+		return fmt.Errorf("nats handshake with %s: expected INFO, got %q (%v)", n.addr, strings.TrimSpace(line), err)
+	}
+	if _, err := conn.Write([]byte("CONNECT {\"verbose\":false,\"pedantic\":false,\"name\":\"coinjoin-engine\"}\r\n")); err != nil {
+		conn.Close()
+		return fmt.Errorf("nats connect: %w", err)
+	}
+
+	n.conn, n.reader = conn, reader
+	return nil
+}
+
+// awaitPong reads server lines until the PONG for our PING. Caller holds n.mu.
+func (n *NATSPublisher) awaitPong() error {
+	for {
+		line, err := n.reader.ReadString('\n')
+		if err != nil {
+			return fmt.Errorf("nats ack: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "PONG":
+			return nil
+		case line == "PING":
+			if _, err := n.conn.Write([]byte("PONG\r\n")); err != nil {
+				return fmt.Errorf("nats pong: %w", err)
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("nats server error: %s", line)
+		}
+		// +OK and INFO updates are ignored
+	}
+}
+
+// reset drops the connection so the next Publish re-dials. Caller holds n.mu.
+func (n *NATSPublisher) reset() {
+	if n.conn != nil {
+		n.conn.Close()
+	}
+	n.conn, n.reader = nil, nil
+}
+
+// Close closes the connection
+func (n *NATSPublisher) Close() error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.reset()
+	return nil
+}
@@ -0,0 +1,294 @@
+package publish
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// ════════════════════════════════════════════════════════════════════
+// Analysis Result Streaming (Kafka / NATS)
+// ════════════════════════════════════════════════════════════════════
+//
+// Every analyzed transaction can be forwarded to a message bus for a
+// downstream data platform, alongside the WebSocket feed and webhooks:
+//
+//   - Backends: NATS core (subject) or Kafka via a REST proxy (topic),
+//     both behind the Publisher interface
+//   - At-least-once: a message is retried with capped exponential
+//     backoff until the backend acknowledges it (NATS PONG, Kafka 2xx)
+//   - Backpressure: the queue is bounded; when the bus falls behind,
+//     Publish blocks the analysis loop instead of dropping results
+//
+// Disabled unless RESULT_BUS is set.
+
+// ErrPublisherClosed is returned by Publish after Close
+var ErrPublisherClosed = errors.New("publisher closed")
+
+// Publisher is a message bus backend. Publish returns nil only once the
+// broker has acknowledged the message.
+type Publisher interface {
+	Publish(ctx context.Context, key string, payload []byte) error
+	Close() error
+}
+
+// ResultMessage is the published record of one analyzed transaction
+type ResultMessage struct {
+	Txid             string                       `json:"txid"`
+	Source           string                       `json:"source"` // "mempool" / "block"
+	BlockHeight      int                          `json:"blockHeight,omitempty"`
+	Analysis         models.PrivacyAnalysisResult `json:"analysis"`
+	ThreatAssessment heuristics.ThreatAssessment  `json:"threatAssessment"`
+	PublishedAt      time.Time                    `json:"publishedAt"`
+}
+
+// Config selects and configures the bus (RESULT_BUS_* env vars)
+type Config struct {
+	Backend   string // "" (disabled), "nats" or "kafka"
+	URL       string // nats://host:4222 or the Kafka REST proxy base URL
+	Topic     string // NATS subject / Kafka topic
+	QueueSize int    // Messages buffered before Publish blocks
+}
+
+const (
+	defaultTopic     = "coinjoin.analysis"
+	defaultQueueSize = 1000
+	attemptTimeout   = 10 * time.Second
+	retryBaseDelay   = 500 * time.Millisecond
+	retryMaxDelay    = 30 * time.Second
+)
+
+// ConfigFromEnv reads RESULT_BUS, RESULT_BUS_URL, RESULT_BUS_TOPIC and
+// RESULT_BUS_QUEUE
+func ConfigFromEnv() Config {
+	cfg := Config{
+		Backend:   os.Getenv("RESULT_BUS"),
+		URL:       os.Getenv("RESULT_BUS_URL"),
+		Topic:     os.Getenv("RESULT_BUS_TOPIC"),
+		QueueSize: defaultQueueSize,
+	}
+	if cfg.Topic == "" {
+		cfg.Topic = defaultTopic
+	}
+	if raw := os.Getenv("RESULT_BUS_QUEUE"); raw != "" {
+		if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+			cfg.QueueSize = n
+		} else {
+			log.Printf("[Publish] Invalid RESULT_BUS_QUEUE %q, using %d", raw, cfg.QueueSize)
+		}
+	}
+	return cfg
+}
+
+// New builds the configured publisher, or returns nil when the bus is disabled
+func New(cfg Config) (*AsyncPublisher, error) {
+	var backend Publisher
+	switch cfg.Backend {
+	case "":
+		return nil, nil
+	case "nats":
+		backend = NewNATSPublisher(cfg.URL, cfg.Topic)
+	case "kafka":
+		backend = NewKafkaRESTPublisher(cfg.URL, cfg.Topic)
+	default:
+		return nil, fmt.Errorf("unknown result bus %q (want nats or kafka)", cfg.Backend)
+	}
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("RESULT_BUS_URL is required for the %s result bus", cfg.Backend)
+	}
+	return NewAsyncPublisher(backend, cfg.QueueSize), nil
+}
+
+type queuedMessage struct {
+	key     string
+	payload []byte
+}
+
+// AsyncPublisher decouples the analysis loops from the bus with a bounded
+// queue and one delivery goroutine that retries until acknowledged.
+// A nil *AsyncPublisher is a valid, disabled publisher.
+type AsyncPublisher struct {
+	backend Publisher
+
+	mu      sync.RWMutex // Guards sends against close(queue)
+	queue   chan queuedMessage
+	closing chan struct{} // Closed when Close starts: blocked Publish calls return
+	stop    chan struct{} // Closed when Close gives up draining: retries abort
+	done    chan struct{} // Closed when the delivery goroutine exits
+	once    sync.Once
+
+	baseDelay, maxDelay time.Duration
+
+	published atomic.Int64
+	retried   atomic.Int64
+	dropped   atomic.Int64
+}
+
+// NewAsyncPublisher starts delivering to backend through a queue of queueSize
+func NewAsyncPublisher(backend Publisher, queueSize int) *AsyncPublisher {
+	if queueSize <= 0 {
+		queueSize = defaultQueueSize
+	}
+	p := &AsyncPublisher{
+		backend:   backend,
+		queue:     make(chan queuedMessage, queueSize),
+		closing:   make(chan struct{}),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+		baseDelay: retryBaseDelay,
+		maxDelay:  retryMaxDelay,
+	}
+	go p.run()
+	return p
+}
+
+// PublishResult queues the result of one analyzed transaction
+func (p *AsyncPublisher) PublishResult(ctx context.Context, source string, blockHeight int,
+	result models.PrivacyAnalysisResult, assessment heuristics.ThreatAssessment) error {
+	if p == nil {
+		return nil
+	}
+	return p.Publish(ctx, ResultMessage{
+		Txid:             result.Txid,
+		Source:           source,
+		BlockHeight:      blockHeight,
+		Analysis:         result,
+		ThreatAssessment: assessment,
+		PublishedAt:      time.Now(),
+	})
+}
+
+// Publish queues msg, blocking while the queue is full (backpressure)
+// until there is room, ctx is done or the publisher is closed
+func (p *AsyncPublisher) Publish(ctx context.Context, msg ResultMessage) error {
+	if p == nil {
+		return nil
+	}
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result message: %w", err)
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	select {
+	case <-p.closing:
+		return ErrPublisherClosed
+	default:
+	}
+
+	select {
+	case p.queue <- queuedMessage{key: msg.Txid, payload: payload}:
+		return nil
+	case <-p.closing:
+		return ErrPublisherClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// run delivers queued messages in order, retrying each until acknowledged
+func (p *AsyncPublisher) run() {
+	defer close(p.done)
+	for msg := range p.queue {
+		if !p.deliver(msg) {
+			p.dropped.Add(1)
+		}
+	}
+}
+
+// deliver retries one message until the backend acknowledges it; false
+// only when Close stopped waiting
+func (p *AsyncPublisher) deliver(msg queuedMessage) bool {
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), attemptTimeout)
+		err := p.backend.Publish(ctx, msg.key, msg.payload)
+		cancel()
+		if err == nil {
+			p.published.Add(1)
+			return true
+		}
+
+		p.retried.Add(1)
+		if attempt == 0 {
+			log.Printf("[Publish] Delivery of %s failed, retrying: %v", msg.key, err)
+		}
+		select {
+		case <-time.After(p.backoff(attempt)):
+		case <-p.stop:
+			return false
+		}
+	}
+}
+
+// backoff is exponential from baseDelay, capped at maxDelay, with ±20% jitter
+func (p *AsyncPublisher) backoff(attempt int) time.Duration {
+	delay := p.baseDelay << min(attempt, 16)
+	if delay <= 0 || delay > p.maxDelay {
+		delay = p.maxDelay
+	}
+	jitter := time.Duration((rand.Float64()*0.4 - 0.2) * float64(delay))
+	return delay + jitter
+}
+
+// Close stops accepting messages and drains the queue until ctx is done;
+// messages still undelivered then are counted as dropped
+func (p *AsyncPublisher) Close(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	p.once.Do(func() {
+		close(p.closing)
+		p.mu.Lock()
+		close(p.queue)
+		p.mu.Unlock()
+	})
+
+	select {
+	case <-p.done:
+	case <-ctx.Done():
+		select {
+		case <-p.stop:
+		default:
+			close(p.stop)
+		}
+		<-p.done
+	}
+
+	if dropped := p.dropped.Load(); dropped > 0 {
+		log.Printf("[Publish] Closed with %d undelivered messages", dropped)
+	}
+	return p.backend.Close()
+}
+
+// PublisherStats counts deliveries since start
+type PublisherStats struct {
+	Published int64 `json:"published"`
+	Retried   int64 `json:"retried"`
+	Dropped   int64 `json:"dropped"` // Undelivered at Close
+	Queued    int   `json:"queued"`
+}
+
+// Stats returns delivery counters
+func (p *AsyncPublisher) Stats() PublisherStats {
+	if p == nil {
+		return PublisherStats{}
+	}
+	return PublisherStats{
+		Published: p.published.Load(),
+		Retried:   p.retried.Load(),
+		Dropped:   p.dropped.Load(),
+		Queued:    len(p.queue),
+	}
+}
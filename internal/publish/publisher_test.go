@@ -0,0 +1,200 @@
+package publish
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// fakePublisher records acknowledged messages and fails the first
+// failFirst attempts of every message whose key ends in "0"
+type fakePublisher struct {
+	mu        sync.Mutex
+	received  []ResultMessage
+	attempts  map[string]int
+	failFirst int
+	gate      chan struct{} // When set, Publish waits for a value per message
+}
+
+func (f *fakePublisher) Publish(ctx context.Context, key string, payload []byte) error {
+	if f.gate != nil {
+		select {
+		case <-f.gate:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts[key]++
+	if strings.HasSuffix(key, "0") && f.attempts[key] <= f.failFirst {
+		return errors.New("broker unavailable")
+	}
+	var msg ResultMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		return err
+	}
+	f.received = append(f.received, msg)
+	return nil
+}
+
+func (f *fakePublisher) Close() error { return nil }
+
+func fastPublisher(backend Publisher, queueSize int) *AsyncPublisher {
+	p := NewAsyncPublisher(backend, queueSize)
+	p.baseDelay, p.maxDelay = time.Millisecond, 5*time.Millisecond
+	return p
+}
+
+func TestAsyncPublisher_EveryResultDeliveredInOrder(t *testing.T) {
+	fake := &fakePublisher{attempts: make(map[string]int), failFirst: 2}
+	p := fastPublisher(fake, 8)
+
+	const n = 40
+	for i := 0; i < n; i++ {
+		result := models.PrivacyAnalysisResult{Txid: fmt.Sprintf("tx%02d", i), PrivacyScore: i}
+		assessment := heuristics.ThreatAssessment{TxID: result.Txid, Severity: "info"}
+		if err := p.PublishResult(context.Background(), "block", 850_000+i, result, assessment); err != nil {
+			t.Fatalf("publish %d: %v", i, err)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fake.received) != n {
+		t.Fatalf("Expected all %d results delivered. Got %d", n, len(fake.received))
+	}
+	for i, msg := range fake.received {
+		if msg.Txid != fmt.Sprintf("tx%02d", i) || msg.Analysis.PrivacyScore != i || msg.BlockHeight != 850_000+i || msg.ThreatAssessment.TxID != msg.Txid {
+			t.Errorf("Message %d out of order or incomplete: %+v", i, msg)
+		}
+	}
+	if stats := p.Stats(); stats.Published != n || stats.Retried != 4*2 || stats.Dropped != 0 {
+		t.Errorf("Expected %d published with 8 retries (tx00..tx30 failing twice). Got %+v", n, stats)
+	}
+	if err := p.PublishResult(context.Background(), "block", 0, models.PrivacyAnalysisResult{Txid: "late"}, heuristics.ThreatAssessment{}); !errors.Is(err, ErrPublisherClosed) {
+		t.Errorf("Expected publishing after Close to fail. Got %v", err)
+	}
+}
+
+func TestAsyncPublisher_Backpressure(t *testing.T) {
+	fake := &fakePublisher{attempts: make(map[string]int), gate: make(chan struct{})}
+	p := fastPublisher(fake, 1)
+
+	// One message held by the stalled delivery goroutine, one queued
+	if err := p.Publish(context.Background(), ResultMessage{Txid: "a"}); err != nil {
+		t.Fatal(err)
+	}
+	for deadline := time.Now().Add(time.Second); len(p.queue) > 0 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	if err := p.Publish(context.Background(), ResultMessage{Txid: "b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := p.Publish(ctx, ResultMessage{Txid: "c"}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected a full queue to block until the deadline. Got %v", err)
+	}
+
+	close(fake.gate) // Broker recovers
+	drainCtx, drainCancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer drainCancel()
+	_ = p.Close(drainCtx)
+	if len(fake.received) != 2 {
+		t.Errorf("Expected queued messages to drain after the stall. Got %d", len(fake.received))
+	}
+
+	var disabled *AsyncPublisher
+	if err := disabled.PublishResult(context.Background(), "mempool", 0, models.PrivacyAnalysisResult{}, heuristics.ThreatAssessment{}); err != nil {
+		t.Errorf("Expected a nil publisher to be a no-op. Got %v", err)
+	}
+}
+
+func TestNATSPublisher_WaitsForPong(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	got := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		fmt.Fprint(conn, "INFO {\"server_id\":\"test\"}\r\n")
+		connect, _ := r.ReadString('\n')
+		pub, _ := r.ReadString('\n')
+		var size int
+		var subject string
+		fmt.Sscanf(pub, "PUB %s %d", &subject, &size)
+		payload := make([]byte, size+2)
+		_, _ = io.ReadFull(r, payload)
+		ping, _ := r.ReadString('\n')
+		got <- strings.TrimSpace(connect)[:7] + "|" + subject + "|" + string(payload[:size]) + "|" + strings.TrimSpace(ping)
+		fmt.Fprint(conn, "PONG\r\n")
+	}()
+
+	n := NewNATSPublisher("nats://"+ln.Addr().String(), "coinjoin.analysis")
+	defer n.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := n.Publish(ctx, "tx1", []byte(`{"txid":"tx1"}`)); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+	if frame := <-got; frame != `CONNECT|coinjoin.analysis|{"txid":"tx1"}|PING` {
+		t.Errorf("Unexpected protocol exchange %q", frame)
+	}
+}
+
+func TestKafkaRESTPublisher_ProducesRecord(t *testing.T) {
+	var body map[string][]struct {
+		Key   string          `json:"key"`
+		Value json.RawMessage `json:"value"`
+	}
+	calls := 0
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			http.Error(w, "leader not available", http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path != "/topics/coinjoin.analysis" || r.Header.Get("Content-Type") != "application/vnd.kafka.json.v2+json" {
+			t.Errorf("Unexpected request %s %s", r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer proxy.Close()
+
+	p := fastPublisher(NewKafkaRESTPublisher(proxy.URL+"/", "coinjoin.analysis"), 4)
+	_ = p.Publish(context.Background(), ResultMessage{Txid: "tx1", Source: "mempool"})
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = p.Close(ctx)
+
+	records := body["records"]
+	if calls != 2 || len(records) != 1 || records[0].Key != "tx1" || !strings.Contains(string(records[0].Value), `"source":"mempool"`) {
+		t.Errorf("Expected the record retried once then produced keyed by txid. Got %d calls, %+v", calls, body)
+	}
+}
@@ -2,7 +2,8 @@ package scanner
 
 import (
 	"context"
-	"log"
+	"log/slog"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -10,9 +11,21 @@ import (
 	"github.com/rawblock/coinjoin-engine/internal/bitcoin"
 	"github.com/rawblock/coinjoin-engine/internal/db"
 	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/internal/logging"
+	"github.com/rawblock/coinjoin-engine/internal/publish"
 	"github.com/rawblock/coinjoin-engine/pkg/models"
 )
 
+// logger tags the scanner's records (component=scanner)
+func logger() *slog.Logger { return logging.Component("scanner") }
+
+// clusterFlushInterval is how often a running scan persists changed cluster memberships
+const clusterFlushInterval = 30 * time.Second
+
+// DefaultScanConcurrency is how many transactions of a block are fetched
+// and analyzed at once; each worker issues blocking prevout RPCs
+const DefaultScanConcurrency = 4
+
 // BlockScanner iterates confirmed blocks and applies heuristic analysis
 // to every transaction, persisting CoinJoin detections to the isolated database.
 // This provides the retroactive coverage that differentiates Tier-1 analytics
@@ -22,18 +35,52 @@ type BlockScanner struct {
 	dbStore   *db.PostgresStore
 	alertFunc func(alert CoinJoinAlert) // Optional broadcast callback
 	watchlist *heuristics.AddressWatchlist
+	clusters  *heuristics.ClusterEngine // Persistent entity clusters across scans
+	trackers  *scanTrackers             // Cross-tx state of persisting scans
+	alertMgr  *heuristics.AlertManager  // Optional structured alerts (large CoinJoins)
+	publisher *publish.AsyncPublisher   // Optional result bus (nil = disabled)
 
 	// Progress tracking (atomic for safe concurrent reads)
 	currentHeight  atomic.Int64
 	totalScanned   atomic.Int64
 	totalCoinJoins atomic.Int64
 	isRunning      atomic.Bool
+	dryRun         atomic.Bool
+
+	concurrency atomic.Int64 // Per-block worker cap (see SetConcurrency)
+
+	scans sync.WaitGroup // In-flight scan goroutine (see Wait)
+}
+
+// scanTrackers is the cross-transaction state a scan builds up block by
+// block. Persisting scans share the scanner's set (served by the API and
+// carried into later scans); a dry run gets a scratch set of its own.
+type scanTrackers struct {
+	spends *heuristics.SameBlockSpendTracker    // Cross-tx timing pass (scan goroutine only)
+	ransom *heuristics.RansomwareSplitTracker   // Ransomware splits followed into mixers/exchanges
+	mixed  *heuristics.CoinJoinOutputIndex      // Outputs of CoinJoins seen by the scanner
+	premix *heuristics.ConsolidationOutputIndex // Outputs of consolidations that may feed a mix
+	nonces *heuristics.NonceReuseTracker        // Recent signature R-values (ECDSA nonce reuse)
+	peels  *heuristics.PeelChainTracker         // Peel steps linked into multi-hop chains
+	dust   *heuristics.DustTracker              // Planted surveillance dust awaiting consolidation
+}
+
+func newScanTrackers() *scanTrackers {
+	return &scanTrackers{
+		spends: heuristics.NewSameBlockSpendTracker(),
+		ransom: heuristics.NewRansomwareSplitTracker(),
+		mixed:  heuristics.NewCoinJoinOutputIndex(),
+		premix: heuristics.NewConsolidationOutputIndex(),
+		nonces: heuristics.NewNonceReuseTracker(),
+		peels:  heuristics.NewPeelChainTracker(),
+		dust:   heuristics.NewDustTracker(),
+	}
 }
 
 // CoinJoinAlert represents a real-time notification emitted when a CoinJoin is detected
 type CoinJoinAlert struct {
 	Txid           string  `json:"txid"`
-	BlockHeight    int     `json:"blockHeight"`
+	BlockHeight    int     `json:"blockHeight"` // 0 = unconfirmed (mempool early warning)
 	MixerType      string  `json:"mixerType"`
 	AnonSet        int     `json:"anonSet"`
 	NumInputs      int     `json:"numInputs"`
@@ -43,20 +90,173 @@ type CoinJoinAlert struct {
 	Timestamp      string  `json:"timestamp"`
 }
 
+// NewCoinJoinAlert builds the alert for a detected CoinJoin; height is 0
+// for a transaction still in the mempool
+func NewCoinJoinAlert(tx models.Transaction, result models.PrivacyAnalysisResult, height int, totalIn int64) CoinJoinAlert {
+	return CoinJoinAlert{
+		Txid:           tx.Txid,
+		BlockHeight:    height,
+		MixerType:      heuristics.MixerType(result.HeuristicFlags),
+		AnonSet:        result.AnonSet,
+		NumInputs:      len(tx.Inputs),
+		NumOutputs:     len(tx.Outputs),
+		TotalValueBTC:  float64(totalIn) / 100000000.0,
+		HeuristicFlags: result.HeuristicFlags,
+		Timestamp:      time.Now().Format(time.RFC3339),
+	}
+}
+
 // ScanProgress represents the scanner's current state for the API
 type ScanProgress struct {
 	IsRunning      bool  `json:"isRunning"`
 	CurrentHeight  int64 `json:"currentHeight"`
 	TotalScanned   int64 `json:"totalScanned"`
 	TotalCoinJoins int64 `json:"totalCoinJoins"`
+	DryRun         bool  `json:"dryRun"` // Current (or last) scan writes nothing to PostgreSQL
+}
+
+// ScanOptions controls what a scan writes and emits
+type ScanOptions struct {
+	// Persist writes detections, risk assessments, timing edges, block
+	// summaries, cluster memberships and taint scores to PostgreSQL and
+	// publishes results to the result bus. A dry run (false) also leaves the
+	// shared cluster engine, taint map and cross-tx trackers (peel chains,
+	// dust, nonces, ransomware splits, mixed/pre-mix outputs) untouched, so
+	// neither the API nor a later persisting scan sees its side effects.
+	Persist bool
+	// EmitAlerts broadcasts CoinJoin alerts and raises structured alerts
+	EmitAlerts bool
+}
+
+// DefaultScanOptions persists and alerts (historical behavior)
+func DefaultScanOptions() ScanOptions {
+	return ScanOptions{Persist: true, EmitAlerts: true}
 }
 
 func NewBlockScanner(btcClient *bitcoin.Client, dbStore *db.PostgresStore, alertFunc func(CoinJoinAlert)) *BlockScanner {
-	return &BlockScanner{
+	s := &BlockScanner{
 		btcClient: btcClient,
 		dbStore:   dbStore,
 		alertFunc: alertFunc,
 		watchlist: heuristics.GetGlobalAddressWatchlist(),
+		clusters:  heuristics.NewClusterEngine(),
+		trackers:  newScanTrackers(),
+	}
+	s.concurrency.Store(DefaultScanConcurrency)
+	return s
+}
+
+// SetConcurrency caps how many transactions per block are fetched and
+// analyzed in parallel (SCAN_CONCURRENCY); 1 scans serially. Takes
+// effect from the next block.
+func (s *BlockScanner) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	s.concurrency.Store(int64(n))
+}
+
+// Concurrency returns the per-block worker cap
+func (s *BlockScanner) Concurrency() int {
+	return int(s.concurrency.Load())
+}
+
+// SetAlertManager routes the scanner's structured alerts (large CoinJoins)
+// through the shared alert manager. Call before the first scan.
+func (s *BlockScanner) SetAlertManager(am *heuristics.AlertManager) {
+	s.alertMgr = am
+}
+
+// SetPublisher streams every analyzed transaction to a result bus. Call
+// before the first scan.
+func (s *BlockScanner) SetPublisher(p *publish.AsyncPublisher) {
+	s.publisher = p
+}
+
+// Clusters returns the scanner's persistent address clusters (safe for concurrent reads)
+func (s *BlockScanner) Clusters() *heuristics.ClusterEngine {
+	return s.clusters
+}
+
+// PeelChains returns the peel chains linked by the scanner (safe for concurrent reads)
+func (s *BlockScanner) PeelChains() *heuristics.PeelChainTracker {
+	return s.trackers.peels
+}
+
+// trackersFor returns the cross-tx trackers a scan with opts updates: the
+// shared set when it persists, a scratch set for a dry run
+func (s *BlockScanner) trackersFor(opts ScanOptions) *scanTrackers {
+	if opts.Persist {
+		return s.trackers
+	}
+	return newScanTrackers()
+}
+
+// LoadClusters warm-starts the cluster engine from address_clusters and
+// enables change tracking so scans flush new memberships back.
+func (s *BlockScanner) LoadClusters(ctx context.Context) error {
+	if s.dbStore == nil {
+		return nil
+	}
+	members, err := s.dbStore.LoadClusterMemberships(ctx)
+	if err != nil {
+		return err
+	}
+	restored := s.clusters.Restore(members)
+	s.clusters.EnableChangeTracking()
+	logger().Info("restored clustered addresses", "addresses", restored, "clusters", s.clusters.TotalClusters())
+	return nil
+}
+
+// warnSuperClusters logs entities above the plausibility threshold with
+// the merge most likely to have over-merged them
+func (s *BlockScanner) warnSuperClusters() {
+	health := s.clusters.Health(0)
+	for _, sc := range health.SuperClusters {
+		if len(sc.Hints) > 0 {
+			hint := sc.Hints[0]
+			logger().Warn("super-cluster", "root", sc.RootAddress, "size", sc.Size,
+				"mergeAddress1", hint.Address1, "mergeAddress2", hint.Address2, "smallerSize", hint.SmallerSize, "largerSize", hint.LargerSize,
+				"edgeType", hint.EdgeType, "edgeId", hint.EdgeID, "txid", hint.Txid)
+		} else {
+			logger().Warn("super-cluster", "root", sc.RootAddress, "size", sc.Size)
+		}
+	}
+	if len(health.SuperClusters) > 0 {
+		logger().Warn("cluster health", "clusters", health.TotalClusters, "sizeGini", health.SizeGini,
+			"largestShare", health.LargestShare)
+	}
+}
+
+// flushClusters persists the cluster changes accumulated since the last
+// flush; on failure they are requeued for the next attempt.
+func (s *BlockScanner) flushClusters(ctx context.Context) {
+	if s.dbStore == nil {
+		return
+	}
+	members, reroots := s.clusters.DrainChanges()
+	if len(members) == 0 && len(reroots) == 0 {
+		return
+	}
+	if err := s.dbStore.SaveClusterChanges(ctx, members, reroots, heuristics.CurrentSnapshotID); err != nil {
+		logger().Error("cluster flush failed", "requeued", len(members), "err", err)
+		s.clusters.RequeueChanges(members, reroots)
+	}
+}
+
+// flushTaint persists the taint scores changed since the last flush
+// (propagation during the scan); on failure they are requeued.
+func (s *BlockScanner) flushTaint(ctx context.Context) {
+	if s.dbStore == nil {
+		return
+	}
+	scores := heuristics.DrainTaintChanges()
+	if len(scores) == 0 {
+		return
+	}
+	if err := s.dbStore.SaveTaintScores(ctx, scores); err != nil {
+		logger().Error("taint flush failed", "requeued", len(scores), "err", err)
+		heuristics.RequeueTaintChanges(scores)
 	}
 }
 
@@ -67,169 +267,221 @@ func (s *BlockScanner) GetProgress() ScanProgress {
 		CurrentHeight:  s.currentHeight.Load(),
 		TotalScanned:   s.totalScanned.Load(),
 		TotalCoinJoins: s.totalCoinJoins.Load(),
+		DryRun:         s.dryRun.Load(),
 	}
 }
 
 // ScanRange processes a specific block range asynchronously.
-// It analyzes every transaction in each block and persists CoinJoin detections.
-func (s *BlockScanner) ScanRange(ctx context.Context, startHeight, endHeight int64) {
+// It analyzes every transaction in each block and, when opts.Persist is
+// set, persists CoinJoin detections. Scan lifecycle records carry ctx's
+// request ID, if any.
+func (s *BlockScanner) ScanRange(ctx context.Context, startHeight, endHeight int64, opts ScanOptions) {
+	logger := logging.FromContext(ctx, "scanner") // Tagged with the starting request's ID
 	if s.btcClient == nil {
-		log.Println("[BlockScanner] Bitcoin client is nil; scan request ignored")
+		logger.Warn("bitcoin client is nil; scan request ignored")
 		return
 	}
 
 	if s.isRunning.Load() {
-		log.Println("[BlockScanner] Scan already in progress, ignoring duplicate request")
+		logger.Warn("scan already in progress, ignoring duplicate request")
 		return
 	}
 
 	s.isRunning.Store(true)
+	s.dryRun.Store(!opts.Persist)
 	s.totalScanned.Store(0)
 	s.totalCoinJoins.Store(0)
+	trackers := s.trackersFor(opts)
+	trackers.spends.Reset()
 
+	s.scans.Add(1)
 	go func() {
+		defer s.scans.Done()
 		defer s.isRunning.Store(false)
+		// Final flush runs even when ctx is cancelled
+		if opts.Persist {
+			defer s.flushClusters(context.Background())
+			defer s.flushTaint(context.Background())
+		}
+		lastFlush := time.Now()
 
-		log.Printf("[BlockScanner] Starting historical scan: blocks %d → %d (%d blocks)",
-			startHeight, endHeight, endHeight-startHeight+1)
+		logger.Info("starting historical scan", "from", startHeight, "to", endHeight,
+			"blocks", endHeight-startHeight+1, "dryRun", !opts.Persist)
 
 		for height := startHeight; height <= endHeight; height++ {
 			select {
 			case <-ctx.Done():
-				log.Printf("[BlockScanner] Scan cancelled at block %d", height)
+				logger.Info("scan cancelled", "height", height)
 				return
 			default:
 			}
 
 			s.currentHeight.Store(height)
-			s.scanBlock(ctx, height)
+			s.scanBlock(ctx, height, opts, trackers)
+
+			if opts.Persist && time.Since(lastFlush) >= clusterFlushInterval {
+				s.flushClusters(ctx)
+				s.flushTaint(ctx)
+				lastFlush = time.Now()
+			}
 
 			// Log progress every 100 blocks
 			scanned := s.totalScanned.Load()
 			if scanned%100 == 0 && scanned > 0 {
-				log.Printf("[BlockScanner] Progress: block %d | scanned %d txs | found %d CoinJoins",
-					height, scanned, s.totalCoinJoins.Load())
+				logger.Info("scan progress", "height", height, "scanned", scanned, "coinjoins", s.totalCoinJoins.Load())
 			}
 		}
 
-		log.Printf("[BlockScanner] ✅ Scan complete: %d transactions analyzed, %d CoinJoins detected",
-			s.totalScanned.Load(), s.totalCoinJoins.Load())
+		logger.Info("scan complete", "scanned", s.totalScanned.Load(), "coinjoins", s.totalCoinJoins.Load())
+		s.warnSuperClusters()
+	}()
+}
+
+// Wait blocks until an in-flight scan, including its final cluster/taint
+// flush, has finished, or until ctx is done
+func (s *BlockScanner) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		s.scans.Wait()
+		close(done)
 	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// scannedTx is one block transaction fetched and analyzed by a scan worker
+type scannedTx struct {
+	tx      models.Transaction
+	result  models.PrivacyAnalysisResult
+	totalIn int64
+	ok      bool // False when the fetch failed or the tx is malformed
 }
 
-// scanBlock fetches a single block and analyzes every transaction
-func (s *BlockScanner) scanBlock(ctx context.Context, height int64) {
+// scanBlock fetches a single block and analyzes every transaction.
+// Prevout fetching and AnalyzeTx run on up to s.concurrency workers; the
+// stateful pass (clusters, cross-tx trackers, taint, persistence) then
+// runs serially in block order so results match a sequential scan.
+// trackers is the scan's cross-tx state (see trackersFor).
+func (s *BlockScanner) scanBlock(ctx context.Context, height int64, opts ScanOptions, trackers *scanTrackers) {
 	// Get block hash for this height
 	hash, err := s.btcClient.RPC.GetBlockHash(height)
 	if err != nil {
-		log.Printf("[BlockScanner] Error getting block hash for height %d: %v", height, err)
+		logger().Error("failed to get block hash", "rpc", "getblockhash", "height", height, "err", err)
 		return
 	}
 
 	// Use GetBlockVerbose which returns transaction IDs as strings
 	block, err := s.btcClient.GetBlockVerbose(hash)
 	if err != nil {
-		log.Printf("[BlockScanner] Error getting block %d: %v", height, err)
+		logger().Error("failed to get block", "rpc", "getblock", "height", height, "err", err)
 		return
 	}
 
-	for _, txidStr := range block.Tx {
-		// Skip coinbase (first tx in block)
-		if txidStr == block.Tx[0] {
-			continue
-		}
+	// Every valid block has a coinbase; an empty tx list is a malformed RPC reply
+	if len(block.Tx) == 0 {
+		logger().Warn("block has no transactions, skipping", "height", height, "hash", block.Hash)
+		return
+	}
 
-		// Fetch the full transaction
-		txHash, err := chainhash.NewHashFromStr(txidStr)
-		if err != nil {
-			continue
-		}
-		rawTx, err := s.btcClient.GetRawTransaction(txHash)
-		if err != nil {
+	// Skip coinbase (first tx in block) by position
+	txids := make([]string, 0, len(block.Tx)-1)
+	for i, txidStr := range block.Tx {
+		if i == 0 {
 			continue
 		}
+		txids = append(txids, txidStr)
+	}
+	scanned := s.analyzeBlockTxs(ctx, height, txids)
 
-		// Ignore malformed empty transactions only.
-		if len(rawTx.Vin) == 0 || len(rawTx.Vout) == 0 {
-			s.totalScanned.Add(1)
-			continue
-		}
+	// Sinks disabled by opts are nil for the rest of the block
+	store, publisher := s.dbStore, s.publisher
+	if !opts.Persist {
+		store, publisher = nil, nil
+	}
+	alertMgr, alertFunc := s.alertMgr, s.alertFunc
+	if !opts.EmitAlerts {
+		alertMgr, alertFunc = nil, nil
+	}
 
-		// Map to internal format
-		tx := models.Transaction{
-			Txid:        rawTx.Txid,
-			Inputs:      make([]models.TxIn, len(rawTx.Vin)),
-			Outputs:     make([]models.TxOut, len(rawTx.Vout)),
-			Weight:      int(rawTx.Weight),
-			Vsize:       int(rawTx.Vsize),
-			Version:     int32(rawTx.Version),
-			LockTime:    rawTx.LockTime,
-			BlockTime:   rawTx.Blocktime,
-			BlockHeight: int(height),
+	// Collected for the same-block self-spend pass after per-tx analysis
+	blockTxs := make([]models.Transaction, 0, len(scanned))
+	coinJoins := make(map[string]bool)
+	summary := newBlockSummaryBuilder(int(height), block.Hash, block.Time)
+
+	for _, st := range scanned {
+		if !st.ok {
+			continue
 		}
+		tx, result, totalIn := st.tx, st.result, st.totalIn
+		blockTxs = append(blockTxs, tx)
 
-		var totalIn, totalOut int64
+		// Step 12 across transactions: link this peel step to the one it spends
+		result.PeelChain = trackers.peels.Observe(tx, result.PeelChain)
 
-		for i, vin := range rawTx.Vin {
-			if vin.Txid == "" {
-				continue
-			}
-			// Fetch previous transaction for input value and address
-			prevHash, _ := chainhash.NewHashFromStr(vin.Txid)
-			prevTx, err := s.btcClient.GetRawTransaction(prevHash)
-			var inValue float64
-			var inAddr string
-			if err == nil && int(vin.Vout) < len(prevTx.Vout) {
-				inValue = prevTx.Vout[vin.Vout].Value
-				if len(prevTx.Vout[vin.Vout].ScriptPubKey.Addresses) > 0 {
-					inAddr = prevTx.Vout[vin.Vout].ScriptPubKey.Addresses[0]
-				}
-			}
-			valSats := int64(inValue * 100000000)
-			scriptSigHex := ""
-			if vin.ScriptSig != nil {
-				scriptSigHex = vin.ScriptSig.Hex
-			}
-			tx.Inputs[i] = models.TxIn{
-				Txid:      vin.Txid,
-				Vout:      vin.Vout,
-				Value:     valSats,
-				Address:   inAddr,
-				ScriptSig: scriptSigHex,
-				Sequence:  vin.Sequence,
-			}
-			totalIn += valSats
+		// Step 23: entity resolution over the per-tx evidence edges
+		if opts.Persist {
+			s.clusters.MergeFromEdges(result.Edges)
 		}
 
-		for i, vout := range rawTx.Vout {
-			valSats := int64(vout.Value * 100000000)
-			var outAddr string
-			if len(vout.ScriptPubKey.Addresses) > 0 {
-				outAddr = vout.ScriptPubKey.Addresses[0]
-			}
-			tx.Outputs[i] = models.TxOut{
-				Value:        valSats,
-				Address:      outAddr,
-				ScriptPubKey: vout.ScriptPubKey.Hex,
+		// Per-cluster change-position entropy (randomized change = privacy wallet)
+		if len(tx.Inputs) > 0 && tx.Inputs[0].Address != "" {
+			spender := tx.Inputs[0].Address
+			if opts.Persist && result.ChangeOutput != nil && result.HeuristicFlags&uint64(heuristics.FlagIsBIP69) == 0 {
+				s.clusters.RecordChangePosition(spender, result.ChangeOutput.Index, len(tx.Outputs))
 			}
-			totalOut += valSats
+			heuristics.ApplyChangePositionSignal(&result, s.clusters.ChangePositionEntropy(spender))
 		}
 
-		tx.Fee = totalIn - totalOut
-		if tx.Fee < 0 {
-			tx.Fee = 0
-		}
+		isCoinJoin := heuristics.IsCoinJoinResult(result.HeuristicFlags)
 
-		// Run the heuristics engine
-		result := heuristics.AnalyzeTx(tx)
-		s.totalScanned.Add(1)
+		summary.add(tx, result, isCoinJoin)
 
 		watchlistHits := s.watchlist.CheckTransaction(tx)
 		assessment := heuristics.ScoreTransaction(tx, result, watchlistHits)
 		taintLevel, _ := heuristics.CheckInputsForTaint(tx)
+		if opts.Persist {
+			heuristics.PropagateGlobalTaint(tx, isCoinJoin)
+		}
+		if split := trackers.ransom.Observe(tx, isCoinJoin); split.IsRansomwareSplit {
+			assessment = heuristics.EscalateRansomwareSplit(assessment, split)
+			logger().Warn("ransomware split", "stage", split.Stage, "height", height, "txid", tx.Txid, "split", split.SplitTxid)
+		}
+		if deposit := heuristics.DetectMixToExchangeDeposit(tx, trackers.mixed); deposit.IsMixToDeposit {
+			assessment = heuristics.EscalateMixToDeposit(assessment, deposit)
+			logger().Warn("mix-to-exchange deposit", "height", height, "txid", tx.Txid, "exchange", deposit.Exchange)
+		}
+		if reuse := trackers.nonces.Observe(tx); reuse.IsReused {
+			logger().Warn("ECDSA nonce reuse", "height", height, "txid", tx.Txid, "collisions", len(reuse.Collisions))
+			if alertMgr != nil {
+				alertMgr.EmitAlert(heuristics.NonceReuseAlert(tx, reuse))
+			}
+		}
+		if dusted := trackers.dust.Observe(tx); dusted.IsDeanonymization {
+			logger().Warn("dust deanonymization", "height", height, "txid", tx.Txid, "plants", dusted.PlantTxids)
+			if alertMgr != nil {
+				alertMgr.EmitAlert(heuristics.DustDeanonymizationAlert(tx, dusted))
+			}
+		}
+		if isCoinJoin {
+			if premix := heuristics.DetectPreMixConsolidation(tx, trackers.premix); premix.IsPreMixConsolidation {
+				logger().Info("pre-mix consolidation", "height", height, "txid", tx.Txid,
+					"consolidations", premix.ConsolidationTxids, "blocksBeforeMix", premix.BlocksBeforeMix)
+			}
+			trackers.mixed.Record(tx)
+		} else {
+			trackers.premix.Record(tx)
+		}
+
+		if err := publisher.PublishResult(ctx, "block", int(height), result, assessment); err != nil {
+			logger().Error("failed to publish result", "height", height, "txid", tx.Txid, "err", err)
+		}
 
 		// Persist risk assessment for ALL analyzed transactions.
-		if s.dbStore != nil {
+		if store != nil {
 			totalValue := int64(0)
 			for _, out := range tx.Outputs {
 				totalValue += out.Value
@@ -239,51 +491,181 @@ func (s *BlockScanner) scanBlock(ctx context.Context, height int64) {
 				riskLevel = "info"
 			}
 
-			if err := s.dbStore.SaveRiskAssessment(ctx, int(height), tx.Txid,
+			if err := store.SaveRiskAssessment(ctx, int(height), tx.Txid,
 				assessment.RiskScore, riskLevel, result.PrivacyScore, result.HeuristicFlags,
 				taintLevel, len(tx.Inputs), len(tx.Outputs), totalValue); err != nil {
-				log.Printf("[BlockScanner] Risk persistence error at block %d tx %s: %v", height, rawTx.Txid, err)
+				logger().Error("failed to persist risk assessment", "height", height, "txid", tx.Txid, "err", err)
 			}
 		}
 
 		// Persist only CoinJoin-flagged transactions
-		isCoinJoin := (result.HeuristicFlags&uint64(heuristics.FlagIsWhirlpoolStruct)) > 0 ||
-			(result.HeuristicFlags&uint64(heuristics.FlagIsWasabiSuspect)) > 0 ||
-			(result.HeuristicFlags&uint64(heuristics.FlagLikelyCollabConstruct)) > 0 ||
-			(result.HeuristicFlags&uint64(heuristics.FlagIsJoinMarketBond)) > 0
-
 		if isCoinJoin {
-			if s.dbStore != nil {
-				if err := s.dbStore.SaveAnalysisResult(ctx, int(height), result); err != nil {
-					log.Printf("[BlockScanner] DB persist error at block %d tx %s: %v", height, rawTx.Txid, err)
+			coinJoins[tx.Txid] = true
+			if store != nil {
+				if err := store.SaveAnalysisResult(ctx, int(height), block.Time, result); err != nil {
+					logger().Error("failed to persist CoinJoin detection", "height", height, "txid", tx.Txid, "err", err)
+				}
+				// Equal-value outputs feed the retroactive anonset windows
+				for _, out := range heuristics.CoinJoinAnonSetOutputs(tx) {
+					if err := store.SaveAnonSetWindow(ctx, out); err != nil {
+						logger().Error("failed to persist anonset window", "height", height, "txid", tx.Txid, "err", err)
+						break
+					}
 				}
 			}
 			s.totalCoinJoins.Add(1)
 
-			// Determine mixer type for alert
-			mixerType := "CoinJoin"
-			if (result.HeuristicFlags & uint64(heuristics.FlagIsWhirlpoolStruct)) > 0 {
-				mixerType = "Whirlpool"
-			} else if (result.HeuristicFlags & uint64(heuristics.FlagIsWasabiSuspect)) > 0 {
-				mixerType = "WabiSabi"
-			} else if (result.HeuristicFlags & uint64(heuristics.FlagIsJoinMarketBond)) > 0 {
-				mixerType = "JoinMarket"
+			if alertMgr != nil {
+				if alert, ok := heuristics.LargeCoinJoinAlert(tx, result); ok {
+					alertMgr.EmitAlert(alert)
+				}
 			}
 
 			// Emit real-time alert
-			if s.alertFunc != nil {
-				s.alertFunc(CoinJoinAlert{
-					Txid:           rawTx.Txid,
-					BlockHeight:    int(height),
-					MixerType:      mixerType,
-					AnonSet:        result.AnonSet,
-					NumInputs:      len(tx.Inputs),
-					NumOutputs:     len(tx.Outputs),
-					TotalValueBTC:  float64(totalIn) / 100000000.0,
-					HeuristicFlags: result.HeuristicFlags,
-					Timestamp:      time.Now().Format(time.RFC3339),
-				})
+			if alertFunc != nil {
+				alertFunc(NewCoinJoinAlert(tx, result, int(height), totalIn))
 			}
 		}
 	}
+
+	s.detectSameBlockSpends(ctx, trackers.spends, int(height), blockTxs, coinJoins, store)
+
+	if store != nil {
+		if err := store.SaveBlockSummary(ctx, summary.finish(), heuristics.CurrentSnapshotID); err != nil {
+			logger().Error("failed to persist block summary", "height", height, "err", err)
+		}
+	}
+}
+
+// analyzeBlockTxs fetches and analyzes txids on a bounded worker pool,
+// returning results in block order. Workers stop picking up new
+// transactions once ctx is done.
+func (s *BlockScanner) analyzeBlockTxs(ctx context.Context, height int64, txids []string) []scannedTx {
+	results := make([]scannedTx, len(txids))
+	workers := min(s.Concurrency(), len(txids))
+
+	var next atomic.Int64
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := int(next.Add(1) - 1)
+				if i >= len(txids) {
+					return
+				}
+				results[i] = s.fetchAndAnalyze(height, txids[i])
+			}
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// fetchAndAnalyze resolves a transaction and its prevouts over RPC and
+// runs the heuristics engine on it (safe to call from scan workers)
+func (s *BlockScanner) fetchAndAnalyze(height int64, txid string) scannedTx {
+	// Fetch the full transaction
+	txHash, err := chainhash.NewHashFromStr(txid)
+	if err != nil {
+		return scannedTx{}
+	}
+	rawTx, err := s.btcClient.GetRawTransaction(txHash)
+	if err != nil {
+		return scannedTx{}
+	}
+
+	// Ignore malformed empty transactions only.
+	if len(rawTx.Vin) == 0 || len(rawTx.Vout) == 0 {
+		s.totalScanned.Add(1)
+		return scannedTx{}
+	}
+
+	// Map to internal format
+	tx := models.Transaction{
+		Txid:        rawTx.Txid,
+		Inputs:      make([]models.TxIn, len(rawTx.Vin)),
+		Outputs:     make([]models.TxOut, len(rawTx.Vout)),
+		Weight:      int(rawTx.Weight),
+		Vsize:       int(rawTx.Vsize),
+		Version:     int32(rawTx.Version),
+		LockTime:    rawTx.LockTime,
+		BlockTime:   rawTx.Blocktime,
+		BlockHeight: int(height),
+	}
+
+	var totalIn, totalOut int64
+
+	for i, vin := range rawTx.Vin {
+		if vin.Txid == "" {
+			continue
+		}
+		// Fetch previous transaction for input value and address
+		prevHash, _ := chainhash.NewHashFromStr(vin.Txid)
+		prevTx, err := s.btcClient.GetRawTransaction(prevHash)
+		var inValue float64
+		var inAddr string
+		if err == nil && int(vin.Vout) < len(prevTx.Vout) {
+			inValue = prevTx.Vout[vin.Vout].Value
+			inAddr = bitcoin.ScriptAddress(prevTx.Vout[vin.Vout].ScriptPubKey)
+		}
+		valSats := models.BTCToSats(inValue)
+		scriptSigHex := ""
+		if vin.ScriptSig != nil {
+			scriptSigHex = vin.ScriptSig.Hex
+		}
+		tx.Inputs[i] = models.TxIn{
+			Txid:      vin.Txid,
+			Vout:      vin.Vout,
+			Value:     valSats,
+			Address:   inAddr,
+			ScriptSig: scriptSigHex,
+			Sequence:  vin.Sequence,
+			Witness:   vin.Witness,
+		}
+		if err == nil {
+			tx.Inputs[i].PrevBlockHeight, tx.Inputs[i].PrevBlockTime = bitcoin.PrevoutConfirmation(prevTx, bitcoin.TipFromConfirmations(int(height), rawTx.Confirmations))
+		}
+		totalIn += valSats
+	}
+
+	for i, vout := range rawTx.Vout {
+		valSats := models.BTCToSats(vout.Value)
+		outAddr := bitcoin.ScriptAddress(vout.ScriptPubKey)
+		tx.Outputs[i] = models.TxOut{
+			Value:        valSats,
+			Address:      outAddr,
+			ScriptPubKey: vout.ScriptPubKey.Hex,
+		}
+		totalOut += valSats
+	}
+
+	tx.Fee = totalIn - totalOut
+	if tx.Fee < 0 {
+		tx.Fee = 0
+	}
+
+	// Run the heuristics engine
+	result := heuristics.AnalyzeTx(tx)
+	s.totalScanned.Add(1)
+	return scannedTx{tx: tx, result: result, totalIn: totalIn, ok: true}
+}
+
+// detectSameBlockSpends runs the same/next-block self-spend pass over a
+// scanned block and persists the resulting timing-correlation edges to
+// store (nil = dry run).
+func (s *BlockScanner) detectSameBlockSpends(ctx context.Context, tracker *heuristics.SameBlockSpendTracker, height int, txs []models.Transaction, coinJoins map[string]bool, store *db.PostgresStore) {
+	spends, edges := tracker.ProcessBlock(height, txs, coinJoins)
+	if len(spends) == 0 {
+		return
+	}
+
+	logger().Info("fast self-spends", "height", height, "spends", len(spends), "timingEdges", len(edges))
+
+	if store != nil {
+		if err := store.SaveEvidenceEdges(ctx, height, edges); err != nil {
+			logger().Error("failed to persist timing edges", "height", height, "err", err)
+		}
+	}
 }
@@ -0,0 +1,382 @@
+package scanner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/rawblock/coinjoin-engine/internal/bitcoin"
+	"github.com/rawblock/coinjoin-engine/internal/db"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func TestNewCoinJoinAlert_MempoolShape(t *testing.T) {
+	tx := models.Transaction{
+		Txid:    "whirlpool_mix",
+		Inputs:  make([]models.TxIn, 5),
+		Outputs: make([]models.TxOut, 5),
+	}
+	result := models.PrivacyAnalysisResult{AnonSet: 5, HeuristicFlags: heuristics.FlagIsWhirlpoolStruct}
+
+	alert := NewCoinJoinAlert(tx, result, 0, 25_000_000)
+	if alert.BlockHeight != 0 || alert.MixerType != "Whirlpool" || alert.AnonSet != 5 || alert.NumInputs != 5 {
+		t.Errorf("Expected an unconfirmed 5-input Whirlpool alert. Got %+v", alert)
+	}
+	if alert.TotalValueBTC != 0.25 || alert.Timestamp == "" {
+		t.Errorf("Expected 0.25 BTC with a timestamp. Got %+v", alert)
+	}
+}
+
+// stubNode is a minimal bitcoind JSON-RPC server serving getblockhash,
+// getblock (verbosity 1) and getrawtransaction (verbose) from fixtures
+type stubNode struct {
+	mu     sync.Mutex
+	blocks map[int64]btcjson.GetBlockVerboseResult
+	txs    map[string]btcjson.TxRawResult
+	calls  map[string]int              // getrawtransaction requests per txid
+	onTx   func(txid string, call int) // Called before each getrawtransaction reply
+}
+
+func newStubNode() *stubNode {
+	return &stubNode{
+		blocks: make(map[int64]btcjson.GetBlockVerboseResult),
+		txs:    make(map[string]btcjson.TxRawResult),
+		calls:  make(map[string]int),
+	}
+}
+
+// txCalls returns the total number of getrawtransaction requests served
+func (n *stubNode) txCalls() int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	total := 0
+	for _, c := range n.calls {
+		total += c
+	}
+	return total
+}
+
+func (n *stubNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+		ID     json.RawMessage   `json:"id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var result any
+	rpcErr := &btcjson.RPCError{Code: btcjson.ErrRPCNoTxInfo, Message: "not found"}
+	switch req.Method {
+	case "getblockhash":
+		var height int64
+		json.Unmarshal(req.Params[0], &height)
+		if b, ok := n.blocks[height]; ok {
+			result, rpcErr = b.Hash, nil
+		}
+	case "getblock":
+		var hash string
+		json.Unmarshal(req.Params[0], &hash)
+		for _, b := range n.blocks {
+			if b.Hash == hash {
+				result, rpcErr = b, nil
+			}
+		}
+	case "getrawtransaction":
+		var txid string
+		json.Unmarshal(req.Params[0], &txid)
+		n.mu.Lock()
+		n.calls[txid]++
+		call := n.calls[txid]
+		n.mu.Unlock()
+		if n.onTx != nil {
+			n.onTx(txid, call)
+		}
+		if tx, ok := n.txs[txid]; ok {
+			result, rpcErr = tx, nil
+		}
+	}
+
+	resp := map[string]any{"result": result, "error": nil, "id": req.ID}
+	if rpcErr != nil {
+		resp["error"] = rpcErr
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// client starts the stub and returns a bitcoin client connected to it
+func (n *stubNode) client(t *testing.T) *bitcoin.Client {
+	t.Helper()
+	srv := httptest.NewServer(n)
+	t.Cleanup(srv.Close)
+	rpc, err := rpcclient.New(&rpcclient.ConnConfig{
+		Host:         strings.TrimPrefix(srv.URL, "http://"),
+		User:         "user",
+		Pass:         "pass",
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(rpc.Shutdown)
+	return &bitcoin.Client{RPC: rpc}
+}
+
+// stubTxid returns a distinct, well-formed txid
+func stubTxid(i int) string {
+	return fmt.Sprintf("%064x", i+1)
+}
+
+// addBlock serves a block at height listing txids
+func (n *stubNode) addBlock(height int64, txids ...string) {
+	n.blocks[height] = btcjson.GetBlockVerboseResult{
+		Hash:   fmt.Sprintf("%064x", 0xb10c000+height),
+		Height: height,
+		Time:   1_718_000_000,
+		Tx:     txids,
+	}
+}
+
+// addCoinbaseLike serves a transaction without prevouts to resolve
+func (n *stubNode) addCoinbaseLike(txid string, addr string, btc float64) {
+	n.txs[txid] = btcjson.TxRawResult{
+		Txid: txid,
+		Vin:  []btcjson.Vin{{Coinbase: "03a0bb0d", Sequence: 0xffffffff}},
+		Vout: []btcjson.Vout{{Value: btc, ScriptPubKey: btcjson.ScriptPubKeyResult{Address: addr}}},
+	}
+}
+
+// addPayment serves a transaction spending the first output of each of
+// prevs to outputs (address → BTC)
+func (n *stubNode) addPayment(txid string, prevs []string, outputs map[string]float64) {
+	tx := btcjson.TxRawResult{Txid: txid}
+	for _, prev := range prevs {
+		tx.Vin = append(tx.Vin, btcjson.Vin{Txid: prev, Vout: 0, Sequence: 0xfffffffd})
+	}
+	addrs := make([]string, 0, len(outputs))
+	for addr := range outputs {
+		addrs = append(addrs, addr)
+	}
+	sort.Strings(addrs)
+	for _, addr := range addrs {
+		tx.Vout = append(tx.Vout, btcjson.Vout{
+			Value:        outputs[addr],
+			N:            uint32(len(tx.Vout)),
+			ScriptPubKey: btcjson.ScriptPubKeyResult{Address: addr},
+		})
+	}
+	n.txs[txid] = tx
+}
+
+func TestAnalyzeBlockTxs_ReturnsBlockOrder(t *testing.T) {
+	node := newStubNode()
+	txids := make([]string, 12)
+	for i := range txids {
+		txids[i] = stubTxid(i)
+		node.addCoinbaseLike(txids[i], fmt.Sprintf("bc1qout%02d", i), float64(i+1)/1000)
+	}
+	s := NewBlockScanner(node.client(t), nil, nil)
+	s.SetConcurrency(4)
+
+	results := s.analyzeBlockTxs(context.Background(), 850_000, txids)
+	if len(results) != len(txids) {
+		t.Fatalf("Expected %d results. Got %d", len(txids), len(results))
+	}
+	for i, st := range results {
+		if !st.ok || st.tx.Txid != txids[i] || st.tx.BlockHeight != 850_000 {
+			t.Errorf("Expected %s analyzed at position %d. Got ok=%v txid=%s", txids[i], i, st.ok, st.tx.Txid)
+		}
+	}
+	if got := s.totalScanned.Load(); got != int64(len(txids)) {
+		t.Errorf("Expected %d scanned. Got %d", len(txids), got)
+	}
+}
+
+func TestAnalyzeBlockTxs_CancelStopsWorkers(t *testing.T) {
+	node := newStubNode()
+	txids := make([]string, 40)
+	for i := range txids {
+		txids[i] = stubTxid(i)
+		node.addCoinbaseLike(txids[i], fmt.Sprintf("bc1qout%02d", i), 0.001)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	node.onTx = func(string, int) { cancel() } // Cancel while the first fetch is in flight
+	s := NewBlockScanner(node.client(t), nil, nil)
+	s.SetConcurrency(4)
+
+	results := s.analyzeBlockTxs(ctx, 850_000, txids)
+
+	// Each worker finishes at most the fetch it had in flight
+	if calls := node.txCalls(); calls > s.Concurrency() {
+		t.Errorf("Expected at most %d fetches after cancel. Got %d", s.Concurrency(), calls)
+	}
+	analyzed := 0
+	for _, st := range results {
+		if st.ok {
+			analyzed++
+		}
+	}
+	if analyzed > s.Concurrency() || results[len(results)-1].ok {
+		t.Errorf("Expected the tail of the block left unanalyzed. Got %d analyzed", analyzed)
+	}
+}
+
+// stubSignature is a DER-encoded ECDSA signature (with sighash byte) and
+// compressed pubkey, as a P2WPKH witness carries them
+var stubSignature = []string{
+	"3044" + "0220" + strings.Repeat("11", 32) + "0220" + strings.Repeat("22", 32) + "01",
+	"02" + strings.Repeat("33", 32),
+}
+
+// dryRunBlock serves a block that a persisting scan learns from:
+//   - a payment co-spending a seeded-taint input (clusters, taint)
+//   - a signed 75/25 split of ransomware-tagged funds (ransomware splits,
+//     peel chains, nonces)
+//   - a surveillance dusting of three addresses (planted dust)
+func dryRunBlock(t *testing.T, height int64) (*stubNode, []string, []string) {
+	t.Helper()
+	node := newStubNode()
+	addr := func(role string) string { return fmt.Sprintf("bc1q%s%d", role, height) }
+	inputs := []string{addr("dryina"), addr("dryinb")}
+	outputs := []string{addr("dryouta"), addr("dryoutb")}
+	prevA, prevB, pay := stubTxid(100), stubTxid(101), stubTxid(102)
+	node.addCoinbaseLike(prevA, inputs[0], 0.6)
+	node.addCoinbaseLike(prevB, inputs[1], 0.4)
+	node.addPayment(pay, []string{prevA, prevB}, map[string]float64{outputs[0]: 0.7, outputs[1]: 0.29})
+	heuristics.SeedFromInvestigationAddresses(inputs[:1])
+
+	prevRansom, split := stubTxid(103), stubTxid(104)
+	node.addCoinbaseLike(prevRansom, addr("ransom"), 1.0)
+	node.addPayment(split, []string{prevRansom}, map[string]float64{addr("affiliate"): 0.75, addr("operator"): 0.2499})
+	signed := node.txs[split]
+	signed.Vin[0].Witness = stubSignature
+	node.txs[split] = signed
+	heuristics.SeedFromExternalIntel([]heuristics.TaintSource{{Address: addr("ransom"), TaintLevel: 1.0, Category: "ransomware"}})
+
+	prevDust, dusting := stubTxid(105), stubTxid(106)
+	node.addCoinbaseLike(prevDust, addr("duster"), 0.01)
+	node.addPayment(dusting, []string{prevDust}, map[string]float64{
+		addr("victima"): 0.000002, addr("victimb"): 0.000002, addr("victimc"): 0.000002, addr("dusterchange"): 0.0099,
+	})
+
+	node.addCoinbaseLike(stubTxid(0), "bc1qminer", 3.125)
+	node.addBlock(height, stubTxid(0), pay, split, dusting)
+	return node, inputs, outputs
+}
+
+// changedTrackers names the cross-tx trackers that differ from a fresh set
+func changedTrackers(trackers *scanTrackers) []string {
+	fresh := newScanTrackers()
+	var changed []string
+	for _, tc := range []struct {
+		name       string
+		got, empty any
+	}{
+		{"peels", trackers.peels, fresh.peels},
+		{"dust", trackers.dust, fresh.dust},
+		{"nonces", trackers.nonces, fresh.nonces},
+		{"ransom", trackers.ransom, fresh.ransom},
+	} {
+		if !reflect.DeepEqual(tc.got, tc.empty) {
+			changed = append(changed, tc.name)
+		}
+	}
+	return changed
+}
+
+func TestScanRange_DryRunLeavesSharedStateUntouched(t *testing.T) {
+	node, inputs, outputs := dryRunBlock(t, 850_001)
+	// Zero-value store: any write would dereference its nil pool and panic
+	s := NewBlockScanner(node.client(t), &db.PostgresStore{}, nil)
+
+	s.ScanRange(context.Background(), 850_001, 850_001, ScanOptions{Persist: false})
+	if err := s.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := s.totalScanned.Load(); got != 3 {
+		t.Fatalf("Expected the block's 3 transactions analyzed. Got %d scanned", got)
+	}
+	if s.clusters.TotalAddresses() != 0 || s.clusters.Contains(inputs[0]) {
+		t.Errorf("Expected the cluster engine untouched. Got %d addresses", s.clusters.TotalAddresses())
+	}
+	for _, addr := range outputs {
+		if risk := heuristics.GlobalTaintRisk(addr); risk.RiskLevel != "clean" {
+			t.Errorf("Expected %s untainted after a dry run. Got %+v", addr, risk)
+		}
+	}
+	if changed := changedTrackers(s.trackers); len(changed) > 0 {
+		t.Errorf("Expected the shared trackers untouched. Got changes in %v", changed)
+	}
+	if chains := s.PeelChains().Chains(1); len(chains) != 0 {
+		t.Errorf("Expected no peel chains served after a dry run. Got %d", len(chains))
+	}
+}
+
+func TestScanRange_PersistingScanUpdatesSharedState(t *testing.T) {
+	node, inputs, outputs := dryRunBlock(t, 850_002)
+	s := NewBlockScanner(node.client(t), nil, nil)
+
+	s.ScanRange(context.Background(), 850_002, 850_002, DefaultScanOptions())
+	if err := s.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if s.clusters.Find(inputs[0]) != s.clusters.Find(inputs[1]) {
+		t.Errorf("Expected the co-spent inputs clustered")
+	}
+	if risk := heuristics.GlobalTaintRisk(outputs[0]); risk.RiskLevel == "clean" {
+		t.Errorf("Expected taint propagated to %s. Got %+v", outputs[0], risk)
+	}
+	if changed := changedTrackers(s.trackers); len(changed) != 4 {
+		t.Errorf("Expected the fixture to reach every tracker. Got changes in %v", changed)
+	}
+}
+
+func TestScanBlock_EmptyBlockIsSkipped(t *testing.T) {
+	node := newStubNode()
+	node.addBlock(850_003)
+	s := NewBlockScanner(node.client(t), nil, nil)
+
+	s.scanBlock(context.Background(), 850_003, DefaultScanOptions(), s.trackers) // Must not panic
+
+	if node.txCalls() != 0 || s.totalScanned.Load() != 0 {
+		t.Errorf("Expected an empty block skipped. Got %d fetches, %d scanned", node.txCalls(), s.totalScanned.Load())
+	}
+}
+
+func TestScanBlock_SkipsCoinbaseByPosition(t *testing.T) {
+	node := newStubNode()
+	coinbase, pay := stubTxid(0), stubTxid(1)
+	node.addCoinbaseLike(coinbase, "bc1qminer", 3.125)
+	node.addCoinbaseLike(pay, "bc1qpayee", 0.5)
+	// The coinbase txid repeated later in the block is not mistaken for the coinbase
+	node.addBlock(850_004, coinbase, pay, coinbase)
+	s := NewBlockScanner(node.client(t), nil, nil)
+
+	s.scanBlock(context.Background(), 850_004, ScanOptions{}, s.trackersFor(ScanOptions{}))
+
+	node.mu.Lock()
+	defer node.mu.Unlock()
+	if node.calls[coinbase] != 1 || node.calls[pay] != 1 {
+		t.Errorf("Expected the coinbase skipped only at index 0. Got fetches %v", node.calls)
+	}
+	if got := s.totalScanned.Load(); got != 2 {
+		t.Errorf("Expected 2 scanned. Got %d", got)
+	}
+}
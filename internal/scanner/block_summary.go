@@ -0,0 +1,70 @@
+package scanner
+
+import (
+	"sort"
+
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// dominantWalletLimit is how many wallet families a block summary ranks
+const dominantWalletLimit = 3
+
+// blockSummaryBuilder accumulates per-tx results into a block rollup
+type blockSummaryBuilder struct {
+	summary       models.BlockSummary
+	privacyTotal  int
+	privacyCounts int
+}
+
+func newBlockSummaryBuilder(height int, hash string, blockTime int64) *blockSummaryBuilder {
+	return &blockSummaryBuilder{summary: models.BlockSummary{
+		BlockHeight:     height,
+		BlockHash:       hash,
+		BlockTime:       blockTime,
+		CoinJoinsByType: make(map[string]int),
+		WalletFamilies:  make(map[string]int),
+	}}
+}
+
+// add folds one analyzed transaction into the summary
+func (b *blockSummaryBuilder) add(tx models.Transaction, result models.PrivacyAnalysisResult, isCoinJoin bool) {
+	b.summary.TxCount++
+	for _, out := range tx.Outputs {
+		b.summary.TotalValueSats += out.Value
+	}
+	b.privacyTotal += result.PrivacyScore
+	b.privacyCounts++
+
+	if isCoinJoin {
+		b.summary.CoinJoinCount++
+		b.summary.CoinJoinsByType[heuristics.MixerType(result.HeuristicFlags)]++
+	}
+	if family := result.WalletFamily; family != "" && family != "unknown" {
+		b.summary.WalletFamilies[family]++
+	}
+}
+
+// finish computes the averages and wallet ranking
+func (b *blockSummaryBuilder) finish() models.BlockSummary {
+	s := b.summary
+	if b.privacyCounts > 0 {
+		s.AvgPrivacyScore = float64(b.privacyTotal) / float64(b.privacyCounts)
+	}
+
+	s.DominantWallets = make([]string, 0, len(s.WalletFamilies))
+	for family := range s.WalletFamilies {
+		s.DominantWallets = append(s.DominantWallets, family)
+	}
+	sort.Slice(s.DominantWallets, func(i, j int) bool {
+		a, c := s.DominantWallets[i], s.DominantWallets[j]
+		if s.WalletFamilies[a] != s.WalletFamilies[c] {
+			return s.WalletFamilies[a] > s.WalletFamilies[c]
+		}
+		return a < c
+	})
+	if len(s.DominantWallets) > dominantWalletLimit {
+		s.DominantWallets = s.DominantWallets[:dominantWalletLimit]
+	}
+	return s
+}
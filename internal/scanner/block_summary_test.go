@@ -0,0 +1,61 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func summaryTx(txid string, outputs ...int64) models.Transaction {
+	tx := models.Transaction{Txid: txid, Inputs: []models.TxIn{{Txid: "prev", Value: 1}}}
+	for _, v := range outputs {
+		tx.Outputs = append(tx.Outputs, models.TxOut{Value: v})
+	}
+	return tx
+}
+
+func TestBlockSummaryBuilder_RollsUpScannedBlock(t *testing.T) {
+	b := newBlockSummaryBuilder(850_000, "00000000000000000002a7c4", 1_718_000_000)
+
+	b.add(summaryTx("whirlpool", 5_000_000, 5_000_000, 5_000_000, 5_000_000, 5_000_000),
+		models.PrivacyAnalysisResult{PrivacyScore: 90, HeuristicFlags: heuristics.FlagIsWhirlpoolStruct, WalletFamily: "samourai"}, true)
+	b.add(summaryTx("wabisabi", 1_000_000, 2_000_000),
+		models.PrivacyAnalysisResult{PrivacyScore: 80, HeuristicFlags: heuristics.FlagIsWasabiSuspect, WalletFamily: "wasabi"}, true)
+	b.add(summaryTx("payment1", 300_000, 700_000),
+		models.PrivacyAnalysisResult{PrivacyScore: 40, WalletFamily: "bitcoin_core"}, false)
+	b.add(summaryTx("payment2", 100_000),
+		models.PrivacyAnalysisResult{PrivacyScore: 30, WalletFamily: "bitcoin_core"}, false)
+	b.add(summaryTx("payment3", 50_000),
+		models.PrivacyAnalysisResult{PrivacyScore: 10, WalletFamily: "unknown"}, false)
+
+	s := b.finish()
+	if s.BlockHeight != 850_000 || s.BlockHash != "00000000000000000002a7c4" || s.BlockTime != 1_718_000_000 {
+		t.Errorf("Unexpected block identity: %+v", s)
+	}
+	if s.TxCount != 5 || s.CoinJoinCount != 2 {
+		t.Errorf("Expected 5 txs / 2 CoinJoins. Got %d / %d", s.TxCount, s.CoinJoinCount)
+	}
+	if s.CoinJoinsByType["Whirlpool"] != 1 || s.CoinJoinsByType["WabiSabi"] != 1 {
+		t.Errorf("Unexpected CoinJoin breakdown: %v", s.CoinJoinsByType)
+	}
+	if s.TotalValueSats != 29_150_000 {
+		t.Errorf("Expected 29,150,000 sats of outputs. Got %d", s.TotalValueSats)
+	}
+	if s.AvgPrivacyScore != 50 {
+		t.Errorf("Expected average privacy score 50. Got %.2f", s.AvgPrivacyScore)
+	}
+	if _, ok := s.WalletFamilies["unknown"]; ok || s.WalletFamilies["bitcoin_core"] != 2 {
+		t.Errorf("Expected only attributed families counted. Got %v", s.WalletFamilies)
+	}
+	if len(s.DominantWallets) != 3 || s.DominantWallets[0] != "bitcoin_core" || s.DominantWallets[1] != "samourai" {
+		t.Errorf("Expected bitcoin_core first, then ties by name. Got %v", s.DominantWallets)
+	}
+}
+
+func TestBlockSummaryBuilder_EmptyBlock(t *testing.T) {
+	s := newBlockSummaryBuilder(1, "hash", 0).finish()
+	if s.TxCount != 0 || s.AvgPrivacyScore != 0 || s.DominantWallets == nil {
+		t.Errorf("Expected an empty, JSON-safe summary. Got %+v", s)
+	}
+}
@@ -0,0 +1,257 @@
+package shadow
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math/bits"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/rawblock/coinjoin-engine/internal/bitcoin"
+	"github.com/rawblock/coinjoin-engine/internal/heuristics"
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+// Block-Range Shadow Comparison
+//
+// RunRange re-analyzes every transaction the production pipeline stored
+// for a block range (risk_assessments, with the CoinJoin AnonSet from
+// tx_heuristics) using the shadow heuristics, writes each comparison to
+// shadow_results and returns a per-tx diff report: flags added/removed,
+// AnonSet and privacy score deltas.
+//
+// Flags derived from cross-transaction scanner state (change-position
+// entropy) are masked on both sides: a standalone re-analysis cannot
+// reproduce them, so they would show up as spurious removals.
+
+// contextFlags are set by the scanner from cluster history, not AnalyzeTx
+const contextFlags = uint64(heuristics.FlagRandomizedChange)
+
+// ProductionResult is one stored production analysis
+type ProductionResult struct {
+	Txid         string
+	BlockHeight  int
+	Flags        uint64
+	PrivacyScore int
+	AnonSet      int // 0 for transactions not persisted as CoinJoins
+}
+
+// TxDiff is the production vs shadow comparison of one transaction
+type TxDiff struct {
+	Txid              string `json:"txid"`
+	BlockHeight       int    `json:"blockHeight"`
+	ProductionFlags   uint64 `json:"productionFlags"`
+	ShadowFlags       uint64 `json:"shadowFlags"`
+	FlagsAdded        uint64 `json:"flagsAdded"`   // Set only by the shadow heuristics
+	FlagsRemoved      uint64 `json:"flagsRemoved"` // Set only by production
+	ProductionAnonSet int    `json:"productionAnonset"`
+	ShadowAnonSet     int    `json:"shadowAnonset"`
+	DeltaAnonSet      int    `json:"deltaAnonset"`
+	ProductionScore   int    `json:"productionScore"`
+	ShadowScore       int    `json:"shadowScore"`
+	DeltaScore        int    `json:"deltaScore"`
+}
+
+// Diverged reports whether the shadow heuristics disagree with production
+func (d TxDiff) Diverged() bool {
+	return d.FlagsAdded != 0 || d.FlagsRemoved != 0 || d.DeltaAnonSet != 0 || d.DeltaScore != 0
+}
+
+// FlagBitChange counts how often one flag bit was added or removed
+type FlagBitChange struct {
+	Bit     int `json:"bit"`
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+}
+
+// RangeReport is the shadow comparison over a block range
+type RangeReport struct {
+	SnapshotID      int64           `json:"snapshotId"`
+	FromHeight      int             `json:"fromHeight"`
+	ToHeight        int             `json:"toHeight"`
+	TotalTxs        int             `json:"totalTxs"`    // Re-analyzed transactions
+	Divergences     int             `json:"divergences"` // Transactions with any difference
+	FetchErrors     int             `json:"fetchErrors"` // Stored transactions the node could not return
+	AvgDeltaAnonSet float64         `json:"avgDeltaAnonset"`
+	AvgDeltaScore   float64         `json:"avgDeltaScore"`
+	FlagChanges     []FlagBitChange `json:"flagChanges"` // Ascending bit
+	Diffs           []TxDiff        `json:"diffs"`       // Diverging transactions, block order
+}
+
+// diffResult compares a stored production result with a shadow analysis
+func diffResult(prod ProductionResult, shadow models.PrivacyAnalysisResult) TxDiff {
+	prodFlags := prod.Flags &^ contextFlags
+	shadowFlags := shadow.HeuristicFlags &^ contextFlags
+	shadowAnonSet := 0
+	if prod.AnonSet > 0 {
+		// Production only stores the AnonSet of persisted CoinJoins
+		shadowAnonSet = shadow.AnonSet
+	}
+	return TxDiff{
+		Txid:              prod.Txid,
+		BlockHeight:       prod.BlockHeight,
+		ProductionFlags:   prodFlags,
+		ShadowFlags:       shadowFlags,
+		FlagsAdded:        shadowFlags &^ prodFlags,
+		FlagsRemoved:      prodFlags &^ shadowFlags,
+		ProductionAnonSet: prod.AnonSet,
+		ShadowAnonSet:     shadowAnonSet,
+		DeltaAnonSet:      shadowAnonSet - prod.AnonSet,
+		ProductionScore:   prod.PrivacyScore,
+		ShadowScore:       shadow.PrivacyScore,
+		DeltaScore:        shadow.PrivacyScore - prod.PrivacyScore,
+	}
+}
+
+// reportBuilder accumulates per-tx diffs into a RangeReport
+type reportBuilder struct {
+	report     RangeReport
+	added      [64]int
+	removed    [64]int
+	sumAnonSet int
+	sumScore   int
+}
+
+func (b *reportBuilder) add(d TxDiff) {
+	b.report.TotalTxs++
+	b.sumAnonSet += d.DeltaAnonSet
+	b.sumScore += d.DeltaScore
+	for m := d.FlagsAdded; m != 0; m &= m - 1 {
+		b.added[bits.TrailingZeros64(m)]++
+	}
+	for m := d.FlagsRemoved; m != 0; m &= m - 1 {
+		b.removed[bits.TrailingZeros64(m)]++
+	}
+	if d.Diverged() {
+		b.report.Divergences++
+		b.report.Diffs = append(b.report.Diffs, d)
+	}
+}
+
+func (b *reportBuilder) finish() *RangeReport {
+	r := b.report
+	if r.TotalTxs > 0 {
+		r.AvgDeltaAnonSet = float64(b.sumAnonSet) / float64(r.TotalTxs)
+		r.AvgDeltaScore = float64(b.sumScore) / float64(r.TotalTxs)
+	}
+	r.FlagChanges = make([]FlagBitChange, 0)
+	for bit := range 64 {
+		if b.added[bit] > 0 || b.removed[bit] > 0 {
+			r.FlagChanges = append(r.FlagChanges, FlagBitChange{Bit: bit, Added: b.added[bit], Removed: b.removed[bit]})
+		}
+	}
+	if r.Diffs == nil {
+		r.Diffs = make([]TxDiff, 0)
+	}
+	return &r
+}
+
+// SetBitcoinClient sets the node RunRange re-fetches stored transactions
+// (and their prevouts) from
+func (sr *ShadowRunner) SetBitcoinClient(c *bitcoin.Client) {
+	sr.fetchTx = func(_ context.Context, txid string, height int) (models.Transaction, error) {
+		hash, err := chainhash.NewHashFromStr(txid)
+		if err != nil {
+			return models.Transaction{}, err
+		}
+		raw, err := c.GetRawTransaction(hash)
+		if err != nil {
+			return models.Transaction{}, err
+		}
+		return c.TransactionFromRaw(raw, height, raw.Blocktime, bitcoin.TipFromConfirmations(height, raw.Confirmations))
+	}
+}
+
+// RunRange re-analyzes the production results stored for blocks
+// [fromHeight, toHeight] with the shadow heuristics and persists each
+// comparison under the runner's snapshot ID
+func (sr *ShadowRunner) RunRange(ctx context.Context, fromHeight, toHeight int) (*RangeReport, error) {
+	if sr.pool == nil {
+		return nil, fmt.Errorf("shadow runner has no database")
+	}
+	if sr.fetchTx == nil {
+		return nil, fmt.Errorf("shadow runner has no transaction source")
+	}
+
+	stored, err := sr.loadProductionResults(ctx, fromHeight, toHeight)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load production results: %v", err)
+	}
+
+	b := reportBuilder{report: RangeReport{SnapshotID: sr.shadowSnapshotID, FromHeight: fromHeight, ToHeight: toHeight}}
+	for _, prod := range stored {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		tx, err := sr.fetchTx(ctx, prod.Txid, prod.BlockHeight)
+		if err != nil {
+			log.Printf("[Shadow] Failed to fetch %s: %v", prod.Txid, err)
+			b.report.FetchErrors++
+			continue
+		}
+		diff := diffResult(prod, sr.shadowFunc(tx))
+		b.add(diff)
+		if err := sr.persistTxDiff(ctx, diff); err != nil {
+			return nil, fmt.Errorf("failed to persist shadow result for %s: %v", prod.Txid, err)
+		}
+	}
+
+	report := b.finish()
+	log.Printf("[Shadow] Blocks %d → %d: %d txs re-analyzed, %d diverged (avg ΔAnonSet %.2f, avg Δscore %.2f)",
+		fromHeight, toHeight, report.TotalTxs, report.Divergences, report.AvgDeltaAnonSet, report.AvgDeltaScore)
+	return report, nil
+}
+
+// loadProductionResults reads the stored production analyses of a block
+// range in block order
+func (sr *ShadowRunner) loadProductionResults(ctx context.Context, fromHeight, toHeight int) ([]ProductionResult, error) {
+	sql := `
+		SELECT r.txid, r.block_height, r.heuristic_flags, r.privacy_score, COALESCE(h.anonset_local, 0)
+		FROM risk_assessments r
+		LEFT JOIN tx_heuristics h ON h.txid = r.txid AND h.block_height = r.block_height
+		WHERE r.block_height BETWEEN $1 AND $2
+		ORDER BY r.block_height ASC, r.txid ASC`
+	rows, err := sr.pool.Query(ctx, sql, fromHeight, toHeight)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]ProductionResult, 0)
+	for rows.Next() {
+		var r ProductionResult
+		var flags int64
+		if err := rows.Scan(&r.Txid, &r.BlockHeight, &flags, &r.PrivacyScore, &r.AnonSet); err != nil {
+			return nil, err
+		}
+		r.Flags = uint64(flags)
+		results = append(results, r)
+	}
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+	return results, nil
+}
+
+// persistTxDiff writes one block-range comparison to shadow_results
+func (sr *ShadowRunner) persistTxDiff(ctx context.Context, d TxDiff) error {
+	sql := `INSERT INTO shadow_results
+		(txid, shadow_flags, production_flags, delta_anonset, snapshot_id, created_at,
+		 block_height, production_score, shadow_score, production_anonset, shadow_anonset)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+	_, err := sr.pool.Exec(ctx, sql,
+		d.Txid,
+		int64(d.ShadowFlags),
+		int64(d.ProductionFlags),
+		d.DeltaAnonSet,
+		sr.shadowSnapshotID,
+		time.Now(),
+		d.BlockHeight,
+		d.ProductionScore,
+		d.ShadowScore,
+		d.ProductionAnonSet,
+		d.ShadowAnonSet,
+	)
+	return err
+}
@@ -0,0 +1,44 @@
+package shadow
+
+import (
+	"testing"
+
+	"github.com/rawblock/coinjoin-engine/pkg/models"
+)
+
+func TestDiffResult(t *testing.T) {
+	prod := ProductionResult{Txid: "cj", BlockHeight: 800_000, Flags: 1<<3 | 1<<10 | contextFlags, PrivacyScore: 70, AnonSet: 5}
+	shadowRes := models.PrivacyAnalysisResult{HeuristicFlags: 1<<3 | 1<<12, PrivacyScore: 64, AnonSet: 7}
+
+	d := diffResult(prod, shadowRes)
+	if d.FlagsAdded != 1<<12 || d.FlagsRemoved != 1<<10 {
+		t.Errorf("Expected bit 12 added and bit 10 removed (context flag masked). Got +%b -%b", d.FlagsAdded, d.FlagsRemoved)
+	}
+	if d.DeltaAnonSet != 2 || d.DeltaScore != -6 || !d.Diverged() {
+		t.Errorf("Expected ΔAnonSet +2 and Δscore -6. Got %+v", d)
+	}
+
+	// Production stores no AnonSet for non-CoinJoins: never report it as a delta
+	plain := diffResult(ProductionResult{Txid: "tx", PrivacyScore: 40}, models.PrivacyAnalysisResult{PrivacyScore: 40, AnonSet: 1})
+	if plain.Diverged() {
+		t.Errorf("Expected identical non-CoinJoin results not to diverge. Got %+v", plain)
+	}
+}
+
+func TestReportBuilder(t *testing.T) {
+	var b reportBuilder
+	b.add(TxDiff{Txid: "a", FlagsAdded: 1 << 5, DeltaScore: -4})
+	b.add(TxDiff{Txid: "b", FlagsRemoved: 1 << 5, DeltaAnonSet: 3})
+	b.add(TxDiff{Txid: "c"})
+
+	r := b.finish()
+	if r.TotalTxs != 3 || r.Divergences != 2 || len(r.Diffs) != 2 || r.Diffs[1].Txid != "b" {
+		t.Errorf("Expected 2 of 3 diverging, in order. Got %+v", r)
+	}
+	if r.AvgDeltaAnonSet != 1 || r.AvgDeltaScore != -4.0/3 {
+		t.Errorf("Expected averages over all re-analyzed txs. Got %.2f / %.2f", r.AvgDeltaAnonSet, r.AvgDeltaScore)
+	}
+	if len(r.FlagChanges) != 1 || r.FlagChanges[0] != (FlagBitChange{Bit: 5, Added: 1, Removed: 1}) {
+		t.Errorf("Expected bit 5 added once and removed once. Got %+v", r.FlagChanges)
+	}
+}
@@ -18,6 +18,7 @@ type ShadowRunner struct {
 	shadowSnapshotID int64
 	productionFunc  func(tx models.Transaction) models.PrivacyAnalysisResult
 	shadowFunc      func(tx models.Transaction) models.PrivacyAnalysisResult
+	fetchTx         func(ctx context.Context, txid string, height int) (models.Transaction, error) // RunRange source (SetBitcoinClient)
 }
 
 // ShadowResult captures the diff between production and shadow heuristics.
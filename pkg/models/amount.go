@@ -0,0 +1,14 @@
+package models
+
+import "github.com/btcsuite/btcd/btcutil"
+
+// BTCToSats converts a BTC float from RPC to satoshis with correct rounding
+// (btcutil.NewAmount), not truncating float multiplication. Invalid
+// amounts (NaN, ±Inf) convert to 0.
+func BTCToSats(btc float64) int64 {
+	amt, err := btcutil.NewAmount(btc)
+	if err != nil {
+		return 0
+	}
+	return int64(amt)
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBTCToSats_RoundsInsteadOfTruncating(t *testing.T) {
+	cases := []struct {
+		btc  float64
+		want int64
+	}{
+		{0.29, 29_000_000}, // 0.29 * 1e8 = 28999999.999999996
+		{0.57, 57_000_000}, // 0.57 * 1e8 = 56999999.99999999
+		{0.00000001, 1},    // One satoshi
+		{0.00000546, 546},  // Dust limit
+		{20999999.9769, 2099999997690000},
+	}
+	for _, tc := range cases {
+		if got := BTCToSats(tc.btc); got != tc.want {
+			t.Errorf("BTCToSats(%v): expected %d. Got %d", tc.btc, tc.want, got)
+		}
+	}
+}
+
+func TestBTCToSats_InvalidAmount(t *testing.T) {
+	if got := BTCToSats(math.NaN()); got != 0 {
+		t.Errorf("Expected NaN to convert to 0. Got %d", got)
+	}
+}
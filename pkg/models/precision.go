@@ -0,0 +1,157 @@
+package models
+
+import (
+	"encoding/json"
+	"math"
+	"sync/atomic"
+)
+
+// Reported Float Precision
+//
+// Heuristic modules compute ratios, entropies and scores at full float64
+// precision. Rounding is applied only at the JSON boundary by the
+// MarshalJSON methods below, so every reported float uses the same number
+// of decimal places regardless of which module produced it.
+
+// DefaultFloatPrecision is the number of decimal places used for reported floats
+const DefaultFloatPrecision = 3
+
+// MaxFloatPrecision caps the configurable precision (float64 has ~15 significant digits)
+const MaxFloatPrecision = 10
+
+var floatPrecision atomic.Int32
+
+func init() {
+	floatPrecision.Store(DefaultFloatPrecision)
+}
+
+// SetFloatPrecision sets the number of decimal places used for reported floats.
+// Values outside [0, MaxFloatPrecision] are clamped.
+func SetFloatPrecision(decimals int) {
+	if decimals < 0 {
+		decimals = 0
+	}
+	if decimals > MaxFloatPrecision {
+		decimals = MaxFloatPrecision
+	}
+	floatPrecision.Store(int32(decimals))
+}
+
+// FloatPrecision returns the current number of decimal places for reported floats
+func FloatPrecision() int {
+	return int(floatPrecision.Load())
+}
+
+// RoundFloat rounds a value to the configured reporting precision.
+// NaN and ±Inf are returned unchanged.
+func RoundFloat(v float64) float64 {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return v
+	}
+	scale := math.Pow(10, float64(FloatPrecision()))
+	return math.Round(v*scale) / scale
+}
+
+// MarshalJSON rounds reported floats to the configured precision
+func (r InferenceResult) MarshalJSON() ([]byte, error) {
+	type alias InferenceResult
+	a := alias(r)
+	a.PosteriorLLR = RoundFloat(a.PosteriorLLR)
+	return json.Marshal(a)
+}
+
+// MarshalJSON rounds reported floats to the configured precision
+func (r EntropyResult) MarshalJSON() ([]byte, error) {
+	type alias EntropyResult
+	a := alias(r)
+	a.Entropy = RoundFloat(a.Entropy)
+	a.MaxEntropy = RoundFloat(a.MaxEntropy)
+	a.Efficiency = RoundFloat(a.Efficiency)
+	return json.Marshal(a)
+}
+
+// MarshalJSON rounds reported floats to the configured precision
+func (r FeeAnalysisResult) MarshalJSON() ([]byte, error) {
+	type alias FeeAnalysisResult
+	a := alias(r)
+	a.FeeRate = RoundFloat(a.FeeRate)
+	a.OverpayRatio = RoundFloat(a.OverpayRatio)
+	return json.Marshal(a)
+}
+
+// MarshalJSON rounds reported floats to the configured precision
+func (r PeelChainResult) MarshalJSON() ([]byte, error) {
+	type alias PeelChainResult
+	a := alias(r)
+	a.Confidence = RoundFloat(a.Confidence)
+	return json.Marshal(a)
+}
+
+// MarshalJSON rounds reported floats to the configured precision
+func (r WabiSabiResult) MarshalJSON() ([]byte, error) {
+	type alias WabiSabiResult
+	a := alias(r)
+	a.Confidence = RoundFloat(a.Confidence)
+	return json.Marshal(a)
+}
+
+// MarshalJSON rounds reported floats to the configured precision
+func (r CoinbaseResult) MarshalJSON() ([]byte, error) {
+	type alias CoinbaseResult
+	a := alias(r)
+	a.PoolConfidence = RoundFloat(a.PoolConfidence)
+	return json.Marshal(a)
+}
+
+// MarshalJSON rounds reported floats to the configured precision
+func (r UnmixResult) MarshalJSON() ([]byte, error) {
+	type alias UnmixResult
+	a := alias(r)
+	a.LinkabilityScore = RoundFloat(a.LinkabilityScore)
+	return json.Marshal(a)
+}
+
+// MarshalJSON rounds reported floats to the configured precision
+func (r TopologyResult) MarshalJSON() ([]byte, error) {
+	type alias TopologyResult
+	a := alias(r)
+	a.IOSymmetry = RoundFloat(a.IOSymmetry)
+	a.GiniCoefficient = RoundFloat(a.GiniCoefficient)
+	return json.Marshal(a)
+}
+
+// MarshalJSON rounds reported floats to the configured precision
+func (r ScoreBreakdown) MarshalJSON() ([]byte, error) {
+	type alias ScoreBreakdown
+	a := alias(r)
+	a.Traceability = RoundFloat(a.Traceability)
+	return json.Marshal(a)
+}
+
+// MarshalJSON rounds reported floats to the configured precision
+func (r ChangeOutput) MarshalJSON() ([]byte, error) {
+	type alias ChangeOutput
+	a := alias(r)
+	a.Confidence = RoundFloat(a.Confidence)
+	return json.Marshal(a)
+}
+
+// MarshalJSON rounds reported floats to the configured precision
+func (r UTXOAgeResult) MarshalJSON() ([]byte, error) {
+	type alias UTXOAgeResult
+	a := alias(r)
+	a.AvgAgeDays = RoundFloat(a.AvgAgeDays)
+	a.MaxAgeDays = RoundFloat(a.MaxAgeDays)
+	a.MinAgeDays = RoundFloat(a.MinAgeDays)
+	a.CoinDaysDestroyed = RoundFloat(a.CoinDaysDestroyed)
+	return json.Marshal(a)
+}
+
+// MarshalJSON rounds reported floats to the configured precision
+func (r ValuePatternResult) MarshalJSON() ([]byte, error) {
+	type alias ValuePatternResult
+	a := alias(r)
+	a.OutputValueEntropy = RoundFloat(a.OutputValueEntropy)
+	a.UniqueValueRatio = RoundFloat(a.UniqueValueRatio)
+	return json.Marshal(a)
+}
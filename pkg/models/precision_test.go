@@ -0,0 +1,137 @@
+package models
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// maxDecimals walks a decoded JSON value and returns the largest number
+// of decimal places found in any numeric field.
+func maxDecimals(t *testing.T, v interface{}) int {
+	t.Helper()
+	max := 0
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for _, child := range val {
+			if d := maxDecimals(t, child); d > max {
+				max = d
+			}
+		}
+	case []interface{}:
+		for _, child := range val {
+			if d := maxDecimals(t, child); d > max {
+				max = d
+			}
+		}
+	case json.Number:
+		s := val.String()
+		if idx := strings.IndexByte(s, '.'); idx >= 0 {
+			max = len(s) - idx - 1
+		}
+	}
+	return max
+}
+
+func decodeNumbers(t *testing.T, raw []byte) interface{} {
+	t.Helper()
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.UseNumber()
+	var out interface{}
+	if err := dec.Decode(&out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return out
+}
+
+func sampleResult() PrivacyAnalysisResult {
+	return PrivacyAnalysisResult{
+		Txid:      "precision_tx",
+		Inference: &InferenceResult{PosteriorLLR: 1.2345678},
+		ChangeOutput: &ChangeOutput{
+			Confidence: 0.6666666,
+		},
+		Entropy: &EntropyResult{
+			Entropy:    6.906890595608519,
+			MaxEntropy: 6.906890595608519,
+			Efficiency: 0.99999999,
+		},
+		FeeAnalysis: &FeeAnalysisResult{
+			FeeRate:      12.3456789,
+			OverpayRatio: 1.23456789,
+		},
+		PeelChain:      &PeelChainResult{Confidence: 0.123456},
+		UnmixResult:    &UnmixResult{LinkabilityScore: 1.0 / 3.0},
+		Topology:       &TopologyResult{IOSymmetry: 2.0 / 3.0, GiniCoefficient: 0.4444444},
+		ScoreBreakdown: &ScoreBreakdown{Traceability: 0.7777777},
+		UTXOAge: &UTXOAgeResult{
+			AvgAgeDays:        12.3456,
+			MaxAgeDays:        100.987654,
+			MinAgeDays:        0.0069444,
+			CoinDaysDestroyed: 1234.56789,
+		},
+		ValuePattern: &ValuePatternResult{
+			OutputValueEntropy: 2.32192809,
+			UniqueValueRatio:   0.8333333,
+		},
+	}
+}
+
+func TestMarshalJSON_ConsistentDefaultPrecision(t *testing.T) {
+	SetFloatPrecision(DefaultFloatPrecision)
+
+	raw, err := json.Marshal(sampleResult())
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	if got := maxDecimals(t, decodeNumbers(t, raw)); got != DefaultFloatPrecision {
+		t.Errorf("Expected reported floats to use %d decimals. Got max: %d (%s)", DefaultFloatPrecision, got, raw)
+	}
+}
+
+func TestMarshalJSON_ConfigurablePrecision(t *testing.T) {
+	defer SetFloatPrecision(DefaultFloatPrecision)
+
+	for _, decimals := range []int{0, 1, 2, 5} {
+		SetFloatPrecision(decimals)
+
+		raw, err := json.Marshal(sampleResult())
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		if got := maxDecimals(t, decodeNumbers(t, raw)); got > decimals {
+			t.Errorf("precision=%d: found a float with %d decimals (%s)", decimals, got, raw)
+		}
+	}
+}
+
+func TestMarshalJSON_ValueAndPointerAgree(t *testing.T) {
+	SetFloatPrecision(2)
+	defer SetFloatPrecision(DefaultFloatPrecision)
+
+	e := EntropyResult{Entropy: 1.23456, Level: "low"}
+	byValue, _ := json.Marshal(e)
+	byPointer, _ := json.Marshal(&e)
+
+	if string(byValue) != string(byPointer) {
+		t.Errorf("Expected identical output for value and pointer. Got %s vs %s", byValue, byPointer)
+	}
+	if !strings.Contains(string(byValue), `"entropy":1.23`) {
+		t.Errorf("Expected entropy rounded to 1.23. Got %s", byValue)
+	}
+}
+
+func TestSetFloatPrecision_Clamps(t *testing.T) {
+	defer SetFloatPrecision(DefaultFloatPrecision)
+
+	SetFloatPrecision(-3)
+	if FloatPrecision() != 0 {
+		t.Errorf("Expected negative precision to clamp to 0. Got %d", FloatPrecision())
+	}
+
+	SetFloatPrecision(99)
+	if FloatPrecision() != MaxFloatPrecision {
+		t.Errorf("Expected precision to clamp to %d. Got %d", MaxFloatPrecision, FloatPrecision())
+	}
+}
@@ -1,13 +1,18 @@
 package models
 
+import "time"
+
 // TxIn represents a Bitcoin transaction input
 type TxIn struct {
-	Txid      string `json:"txid"`
-	Vout      uint32 `json:"vout"`
-	Value     int64  `json:"value"` // in Satoshis
-	Address   string `json:"address"`
-	ScriptSig string `json:"scriptSig"`
-	Sequence  uint32 `json:"sequence"` // nSequence: 0xFFFFFFFE = RBF (BIP125), 0xFFFFFFFF = final
+	Txid            string   `json:"txid"`
+	Vout            uint32   `json:"vout"`
+	Value           int64    `json:"value"` // in Satoshis
+	Address         string   `json:"address"`
+	ScriptSig       string   `json:"scriptSig"`
+	Sequence        uint32   `json:"sequence"`                  // nSequence: 0xFFFFFFFE = RBF (BIP125), 0xFFFFFFFF = final
+	Witness         []string `json:"witness,omitempty"`         // Hex-encoded witness stack items (BIP141)
+	PrevBlockHeight int      `json:"prevBlockHeight,omitempty"` // Confirmation height of the spent output (0 = unknown/unconfirmed)
+	PrevBlockTime   int64    `json:"prevBlockTime,omitempty"`   // Block timestamp of the spent output (unix seconds)
 }
 
 // TxOut represents a Bitcoin transaction output
@@ -21,6 +26,7 @@ type TxOut struct {
 // Transaction represents a parsed Bitcoin transaction
 type Transaction struct {
 	Txid        string  `json:"txid"`
+	Wtxid       string  `json:"wtxid,omitempty"` // BIP141 witness txid (equals Txid without witness data)
 	Inputs      []TxIn  `json:"inputs"`
 	Outputs     []TxOut `json:"outputs"`
 	Fee         int64   `json:"fee"` // Calculated as Inputs - Outputs in Satoshis
@@ -28,21 +34,109 @@ type Transaction struct {
 	Vsize       int     `json:"vsize"`                 // BIP141 Virtual Size
 	LockTime    uint32  `json:"locktime"`              // nLockTime: anti-fee-sniping or timelock
 	Version     int32   `json:"version"`               // Tx version (1 or 2)
+	HasWitness  bool    `json:"hasWitness,omitempty"`  // Serialized with the segwit marker/flag (BIP144)
 	BlockHeight int     `json:"blockHeight,omitempty"` // Block height (0 for mempool)
 	BlockTime   int64   `json:"blockTime,omitempty"`   // Block timestamp (unix seconds)
 }
 
 // EvidenceEdge represents a directional, probabilistic linkage in the UTXO graph.
 type EvidenceEdge struct {
-	EdgeID          string  `json:"edgeId"`
-	CreatedHeight   int     `json:"createdHeight"`
-	SrcNodeID       string  `json:"srcNodeId"`           // Origin Address
-	DstNodeID       string  `json:"dstNodeId"`           // Destination Address
-	EdgeType        int     `json:"edgeType"`            // 1=CIOH, 2=Change, 3=NegativeGating
-	LLRScore        float64 `json:"llrScore"`            // Log-Likelihood Ratio
-	DependencyGroup int     `json:"dependencyGroup"`     // To prevent double-counting correlated features
-	SnapshotID      int     `json:"snapshotId"`          // Version of the heuristic engine that generated this
-	AuditHash       string  `json:"auditHash,omitempty"` // SHA256 digest for immutability
+	EdgeID          string   `json:"edgeId"`
+	CreatedHeight   int      `json:"createdHeight"`
+	SrcNodeID       string   `json:"srcNodeId"`           // Origin Address
+	DstNodeID       string   `json:"dstNodeId"`           // Destination Address
+	EdgeType        int      `json:"edgeType"`            // 1=CIOH, 2=Change, 3=NegativeGating
+	LLRScore        float64  `json:"llrScore"`            // Log-Likelihood Ratio
+	DependencyGroup int      `json:"dependencyGroup"`     // To prevent double-counting correlated features
+	SnapshotID      int      `json:"snapshotId"`          // Version of the heuristic engine that generated this
+	AuditHash       string   `json:"auditHash,omitempty"` // SHA256 digest for immutability
+	Members         []string `json:"members,omitempty"`   // Hyper-edge: further addresses bound to SrcNodeID
+}
+
+// ClusterMembership maps an address to the root of its entity cluster
+// (one row of the persisted address_clusters table)
+type ClusterMembership struct {
+	Address     string `json:"address"`
+	RootAddress string `json:"rootAddress"`
+}
+
+// TaintScore is the persisted taint of one address (one row of the
+// taint_scores table)
+type TaintScore struct {
+	Address        string  `json:"address"`
+	TaintLevel     float64 `json:"taintLevel"`
+	Category       string  `json:"category,omitempty"` // Seed category; "" for propagated taint
+	HopsFromSource int     `json:"hopsFromSource"`
+}
+
+// AddressLabel attributes an address to a known entity (one row of the
+// address_labels table)
+type AddressLabel struct {
+	Address    string    `json:"address"`
+	Label      string    `json:"label"`      // Entity name, e.g. "Binance"
+	Category   string    `json:"category"`   // exchange/service/mining_pool/mixer/merchant/gambling/darknet/other
+	Source     string    `json:"source"`     // Origin of the attribution (manual, vendor feed, import file)
+	Confidence float64   `json:"confidence"` // 0-1
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// LabelHit is a labeled address appearing in an analyzed transaction
+type LabelHit struct {
+	Direction  string  `json:"direction"` // "input" or "output"
+	Index      int     `json:"index"`
+	Address    string  `json:"address"`
+	Label      string  `json:"label"`
+	Category   string  `json:"category"`
+	Confidence float64 `json:"confidence"`
+}
+
+// InvestigationRecord is a persisted investigation case (one row of the
+// investigations table with its investigation_addresses)
+type InvestigationRecord struct {
+	CaseID         string                       `json:"caseId"`
+	Name           string                       `json:"name"`
+	Description    string                       `json:"description"`
+	Status         string                       `json:"status"`
+	TotalStolen    int64                        `json:"totalStolen"`
+	TotalRecovered int64                        `json:"totalRecovered"`
+	CreatedAt      time.Time                    `json:"createdAt"`
+	UpdatedAt      time.Time                    `json:"updatedAt"`
+	Addresses      []InvestigationAddressRecord `json:"addresses"`
+}
+
+// InvestigationAddressRecord is one row of the investigation_addresses table
+type InvestigationAddressRecord struct {
+	Address  string    `json:"address"`
+	Label    string    `json:"label"`
+	Role     string    `json:"role"`
+	Notes    string    `json:"notes,omitempty"`
+	TaggedBy string    `json:"taggedBy,omitempty"`
+	TaggedAt time.Time `json:"taggedAt"`
+}
+
+// BlockSummary is the per-block analysis rollup written after a block scan
+// (one row of the block_summaries table)
+type BlockSummary struct {
+	BlockHeight     int            `json:"blockHeight"`
+	BlockHash       string         `json:"blockHash"`
+	BlockTime       int64          `json:"blockTime"`       // Block timestamp (unix seconds)
+	TxCount         int            `json:"txCount"`         // Analyzed transactions (coinbase excluded)
+	CoinJoinCount   int            `json:"coinJoinCount"`   // CoinJoin-flagged transactions
+	CoinJoinsByType map[string]int `json:"coinJoinsByType"` // Whirlpool / WabiSabi / JoinMarket / CoinJoin
+	TotalValueSats  int64          `json:"totalValueSats"`  // Sum of analyzed output values
+	AvgPrivacyScore float64        `json:"avgPrivacyScore"`
+	WalletFamilies  map[string]int `json:"walletFamilies"`  // Attributed wallet family → tx count
+	DominantWallets []string       `json:"dominantWallets"` // Most frequent families, most common first
+}
+
+// AnonSetOutput is a CoinJoin output tracked for time-windowed anonymity
+// sets (one anonset_windows row)
+type AnonSetOutput struct {
+	Txid         string `json:"txid"`
+	OutputIndex  int    `json:"outputIndex"`
+	AnonSetLocal int    `json:"anonsetLocal"` // Equal-value outputs in the mix itself
+	ValueSats    int64  `json:"valueSats"`    // Denomination
+	BlockTime    int64  `json:"blockTime"`    // Confirmation time (unix seconds)
 }
 
 // InferenceResult is the factor-graph posterior evaluation
@@ -59,12 +153,18 @@ type PrivacyAnalysisResult struct {
 	Txid           string              `json:"txid"`
 	PrivacyScore   int                 `json:"privacyScore"`
 	AnonSet        int                 `json:"anonSet"`
+	AnonSetSolver  string              `json:"anonSetSolver,omitempty"`  // Portfolio lane that produced AnonSet
 	HeuristicFlags uint64              `json:"heuristicFlags"`           // 64-bit Bitmask
 	Edges          []EvidenceEdge      `json:"edges"`                    // Composable probabilistic edges
 	Inference      *InferenceResult    `json:"inference,omitempty"`      // Factor-graph posterior (Phase 3)
 	ChangeOutput   *ChangeOutput       `json:"changeOutput,omitempty"`   // Detected change output
 	WalletFamily   string              `json:"walletFamily,omitempty"`   // Attributed wallet software
 	WhirlpoolPool  string              `json:"whirlpoolPool,omitempty"`  // Specific pool denomination
+	WhirlpoolStage string              `json:"whirlpoolStage,omitempty"` // "tx0" (premix funding) or "mix" (mix round)
+	WhirlpoolRemix int                 `json:"whirlpoolRemix,omitempty"` // Inputs remixing from a previous round
+	JoinMarketSize int                 `json:"joinMarketSize,omitempty"` // JoinMarket participants (taker + makers)
+	WabiSabi       *WabiSabiResult     `json:"wabiSabi,omitempty"`       // WabiSabi denomination/fee validation
+	Coinbase       *CoinbaseResult     `json:"coinbase,omitempty"`       // Pool, subsidy/fee split and payout structure
 	Entropy        *EntropyResult      `json:"entropy,omitempty"`        // Boltzmann entropy analysis
 	FeeAnalysis    *FeeAnalysisResult  `json:"feeAnalysis,omitempty"`    // Fee-rate intelligence
 	PeelChain      *PeelChainResult    `json:"peelChain,omitempty"`      // Peel chain detection
@@ -75,6 +175,11 @@ type PrivacyAnalysisResult struct {
 	UTXOAge        *UTXOAgeResult      `json:"utxoAge,omitempty"`        // Input UTXO lifespan analysis
 	ValuePattern   *ValuePatternResult `json:"valuePattern,omitempty"`   // Value fingerprinting
 	ScriptInfo     *ScriptAnalysis     `json:"scriptInfo,omitempty"`     // Script template deep inspection
+	NonStandard    []string            `json:"nonStandard,omitempty"`    // Bitcoin Core standardness violations
+	BurnedOutputs  []int               `json:"burnedOutputs,omitempty"`  // Provably-unspendable output indices
+	BurnedValue    int64               `json:"burnedValue,omitempty"`    // Sats destroyed in those outputs
+	Labels         []LabelHit          `json:"labels,omitempty"`         // Known-entity labels on inputs/outputs
+	StepTimings    map[string]float64  `json:"stepTimings,omitempty"`    // Pipeline step → ms (PIPELINE_PROFILE only)
 }
 
 // EntropyResult holds Boltzmann transaction entropy analysis
@@ -106,6 +211,36 @@ type PeelChainResult struct {
 	ChangeIndex  int     `json:"changeIndex"`            // Which output is the identified change
 }
 
+// WabiSabiResult holds Wasabi 2.0 (WabiSabi) round validation results
+type WabiSabiResult struct {
+	IsWabiSabi            bool    `json:"isWabiSabi"`
+	Confidence            float64 `json:"confidence"`            // 0.0 - 1.0
+	StandardOutputs       int     `json:"standardOutputs"`       // Outputs on the standard denomination ladder
+	DenominationGroups    int     `json:"denominationGroups"`    // Standard denominations used by ≥2 outputs
+	Denominations         []int64 `json:"denominations"`         // Distinct standard denominations, ascending
+	ChangeOutputs         int     `json:"changeOutputs"`         // Off-ladder outputs (change, coordinator fee)
+	EstimatedParticipants int     `json:"estimatedParticipants"` // Rough participant count
+	CoordinatorFee        int64   `json:"coordinatorFee"`        // Estimated coordinator fee (sats)
+	CoordinatorFeeIndex   int     `json:"coordinatorFeeIndex"`   // Output matching the estimated fee (-1 if none)
+}
+
+// CoinbaseResult holds coinbase / mining pool analysis results
+type CoinbaseResult struct {
+	IsCoinbase      bool    `json:"isCoinbase"`
+	PoolName        string  `json:"poolName"`        // Identified mining pool
+	PoolConfidence  float64 `json:"poolConfidence"`  // 0.0 to 1.0
+	PoolMatch       string  `json:"poolMatch"`       // "tag"/"address"/"tag+address" ("" if unattributed)
+	BlockReward     int64   `json:"blockReward"`     // Total block reward (subsidy + fees)
+	OutputCount     int     `json:"outputCount"`     // Distribution pattern
+	PayoutType      string  `json:"payoutType"`      // "single"/"multi"/"pps"/"fpps"
+	BlockHeight     int     `json:"blockHeight"`     // Confirming block, else BIP34 height (0 if unknown)
+	Subsidy         int64   `json:"subsidy"`         // Halving-schedule subsidy at BlockHeight
+	Fees            int64   `json:"fees"`            // BlockReward − Subsidy
+	MaturityHeight  int     `json:"maturityHeight"`  // First height whose block may spend the outputs
+	Payees          int     `json:"payees"`          // Distinct scripts paid (data/zero-value outputs excluded)
+	PayoutStructure string  `json:"payoutStructure"` // "single_wallet"/"split"/"distributed"
+}
+
 // DustResult holds dust attack detection results
 type DustResult struct {
 	HasDustOutputs  bool   `json:"hasDustOutputs"`  // Tx creates dust outputs (potential attack)
@@ -136,6 +271,17 @@ type TopologyResult struct {
 	GiniCoefficient    float64 `json:"giniCoefficient"`    // Output value dispersion: 0=equal, 1=concentrated
 	IsHub              bool    `json:"isHub"`              // Transaction acts as hub (high fan-in or fan-out)
 	ValueConcentration string  `json:"valueConcentration"` // "dispersed"/"moderate"/"concentrated"
+
+	BatchPayout *BatchPayoutResult `json:"batchPayout,omitempty"` // Exchange batch withdrawal classification
+}
+
+// BatchPayoutResult scores a fan-out as an exchange/service batch withdrawal
+type BatchPayoutResult struct {
+	IsBatch            bool    `json:"isBatch"`
+	PayoutCount        int     `json:"payoutCount"`        // Spendable outputs paid
+	ValueEntropy       float64 `json:"valueEntropy"`       // Output value entropy / log2(payouts), 0-1
+	AddressTypeEntropy float64 `json:"addressTypeEntropy"` // Script-type entropy / its maximum, 0-1
+	Confidence         float64 `json:"confidence"`
 }
 
 // ScoreBreakdown decomposes the privacy score into individual signal contributions
@@ -183,13 +329,14 @@ type ValuePatternResult struct {
 
 // ScriptAnalysis holds deep script template inspection results
 type ScriptAnalysis struct {
-	HasMultisig      bool   `json:"hasMultisig"`      // M-of-N multisig detected
-	MultisigM        int    `json:"multisigM"`        // M in M-of-N
-	MultisigN        int    `json:"multisigN"`        // N in M-of-N
-	HasHTLC          bool   `json:"hasHTLC"`          // Hash timelock contract (Lightning)
-	HasOPReturn      bool   `json:"hasOPReturn"`      // OP_RETURN data present
-	OPReturnProtocol string `json:"opReturnProtocol"` // "omni"/"openassets"/"unknown"
-	OPReturnSize     int    `json:"opReturnSize"`     // Size of OP_RETURN data in bytes
-	DominantWitness  string `json:"dominantWitness"`  // "v0"/"v1"/"legacy"
-	TapscriptDepth   int    `json:"tapscriptDepth"`   // Tapscript tree depth (0 = key-path)
+	HasMultisig       bool   `json:"hasMultisig"`                 // M-of-N multisig detected
+	MultisigM         int    `json:"multisigM"`                   // M in M-of-N
+	MultisigN         int    `json:"multisigN"`                   // N in M-of-N
+	HasHTLC           bool   `json:"hasHTLC"`                     // Hash timelock contract (Lightning)
+	HasOPReturn       bool   `json:"hasOPReturn"`                 // OP_RETURN data present
+	OPReturnProtocol  string `json:"opReturnProtocol"`            // "omni"/"openassets"/"bip47"/"thorchain"/"bridge-memo"/"unknown"
+	OPReturnSize      int    `json:"opReturnSize"`                // Size of OP_RETURN data in bytes
+	BIP47Notification string `json:"bip47Notification,omitempty"` // Notification address of a BIP47 notification tx
+	DominantWitness   string `json:"dominantWitness"`             // "v0"/"v1"/"legacy"
+	TapscriptDepth    int    `json:"tapscriptDepth"`              // Tapscript tree depth (0 = key-path)
 }